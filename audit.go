@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// auditQueueSize is the number of decisions queued for the WithAuditSink
+// function before the overflow policy applies
+const auditQueueSize = 1000
+
+// AuditFunc receives every decision of Allow, AllowN and Check with its
+// complete per-tier results, see WithAuditSink
+type AuditFunc func(ctx context.Context, key string, results strategies.Results, allowed bool)
+
+// AuditOverflow is what WithAuditSink does with decisions while its queue is full
+type AuditOverflow int
+
+const (
+	// AuditDrop drops decisions, so the request path never waits. It is the default.
+	AuditDrop AuditOverflow = iota
+	// AuditBlock makes requests wait for room in the queue, or for their
+	// context to be done, so no decision is lost while the context lives
+	AuditBlock
+)
+
+// WithAuditSink registers a function receiving every decision with the full
+// per-tier results, e.g. to write an audit trail of every limiting decision
+// to Kafka.
+//
+// Unlike hooks, fn never runs on the request path: decisions are queued and
+// fn is called on a single goroutine, in decision order, with the request
+// context detached from its cancellation. While the queue is full, overflow
+// decides between dropping decisions and delaying requests. Results are
+// copied, including for allow- and deny-listed and sampled-out requests;
+// failed-open requests are reported as allowed with nil results, and failed
+// ones not at all. Close waits for queued decisions to be delivered.
+func WithAuditSink(fn AuditFunc, overflow AuditOverflow) Option {
+	return func(config *Config) error {
+		if fn == nil {
+			return fmt.Errorf("audit function cannot be nil")
+		}
+		if overflow != AuditDrop && overflow != AuditBlock {
+			return fmt.Errorf("invalid audit overflow %d", overflow)
+		}
+		config.auditFunc = fn
+		config.auditOverflow = overflow
+		return nil
+	}
+}
+
+// auditRecord is a queued decision of WithAuditSink
+type auditRecord struct {
+	ctx     context.Context
+	key     string
+	results strategies.Results
+	allowed bool
+}
+
+// auditSink delivers decisions to the WithAuditSink function on its own goroutine
+type auditSink struct {
+	fn      AuditFunc
+	block   bool
+	records chan auditRecord
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// newAuditSink starts the goroutine calling fn, nil if fn is nil
+func newAuditSink(fn AuditFunc, overflow AuditOverflow, queueSize int) *auditSink {
+	if fn == nil {
+		return nil
+	}
+	a := &auditSink{
+		fn:      fn,
+		block:   overflow == AuditBlock,
+		records: make(chan auditRecord, queueSize),
+	}
+	a.wg.Go(func() {
+		for record := range a.records {
+			a.fn(record.ctx, record.key, record.results, record.allowed)
+		}
+	})
+	return a
+}
+
+// record queues a decision, applying the overflow policy if the queue is full
+func (a *auditSink) record(ctx context.Context, key string, results strategies.Results, allowed bool) {
+	if a == nil {
+		return
+	}
+	record := auditRecord{ctx: context.WithoutCancel(ctx), key: key, results: maps.Clone(results), allowed: allowed}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return
+	}
+	if a.block {
+		select {
+		case a.records <- record:
+		case <-ctx.Done():
+		}
+		return
+	}
+	select {
+	case a.records <- record:
+	default:
+		// Queue full, drop the decision
+	}
+}
+
+// close stops accepting decisions and waits for the queued ones to be delivered
+func (a *auditSink) close() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.records)
+	}
+	a.mu.Unlock()
+	a.wg.Wait()
+}
@@ -0,0 +1,215 @@
+package backends
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// localCacheMaxEntries bounds the number of keys held by a local cache
+const localCacheMaxEntries = 10000
+
+type freshReadKey struct{}
+
+// FreshRead marks the context so that reads through a WithLocalCache backend
+// skip the cache and go to the inner backend.
+//
+// The limiter applies it to Allow and Refund, whose read-modify-write cycles
+// must start from the current stored value.
+func FreshRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshReadKey{}, true)
+}
+
+func isFreshRead(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshReadKey{}).(bool)
+	return fresh
+}
+
+type cacheEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+// cacheFetch tracks the inner Gets of a key in flight
+type cacheFetch struct {
+	readers    int
+	generation uint64 // bumped by every write to the key
+}
+
+// cachedBackend caches Get results in-process in front of a remote backend
+type cachedBackend struct {
+	inner      Backend
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *cacheEntry
+	order   *list.List               // oldest entry, first to expire, at the front
+	fetches map[string]*cacheFetch
+}
+
+// WithLocalCache wraps a backend with a small in-process read-through cache.
+//
+// Get results are cached for ttl, which should be very short (e.g. 50-250ms):
+// Peek may observe state up to ttl old in exchange for fewer network hops.
+// Set, CheckAndSet and Delete always go to the inner backend and invalidate
+// the cached entry. Reads on a context marked with FreshRead bypass the cache,
+// so Allow is never decided on stale state.
+//
+// The cache holds at most 10000 keys; the entry closest to expiring, expired
+// ones first, is evicted to make room. The optional LockCleaner and Failover
+// interfaces of inner are kept. If ttl <= 0, inner is returned as is.
+func WithLocalCache(inner Backend, ttl time.Duration) Backend {
+	if ttl <= 0 {
+		return inner
+	}
+	return withOptional(&cachedBackend{
+		inner:      inner,
+		ttl:        ttl,
+		maxEntries: localCacheMaxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		fetches:    make(map[string]*cacheFetch),
+	}, inner)
+}
+
+func (c *cachedBackend) Get(ctx context.Context, key string) (string, error) {
+	if !isFreshRead(ctx) {
+		if value, ok := c.lookup(key); ok {
+			return value, nil
+		}
+	}
+
+	// A write racing with the inner Get may land before the value read is
+	// cached; the value is only cached if no write started meanwhile
+	generation := c.startFetch(key)
+	value, err := c.inner.Get(ctx, key)
+	c.endFetch(key, generation, value, err == nil)
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (c *cachedBackend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	defer c.invalidate(key)
+	return c.inner.Set(ctx, key, value, expiration)
+}
+
+func (c *cachedBackend) CheckAndSet(ctx context.Context, key string, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	// Invalidate on mismatch too, the cached value is known to be stale
+	defer c.invalidate(key)
+	return c.inner.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+}
+
+func (c *cachedBackend) Delete(ctx context.Context, key string) error {
+	defer c.invalidate(key)
+	return c.inner.Delete(ctx, key)
+}
+
+func (c *cachedBackend) Close() error {
+	c.mu.Lock()
+	clear(c.entries)
+	c.order.Init()
+	c.mu.Unlock()
+	return c.inner.Close()
+}
+
+// Keys always lists from the inner backend when it is a Lister
+func (c *cachedBackend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	lister, ok := c.inner.(Lister)
+	if !ok {
+		return nil, ErrKeysNotSupported
+	}
+	return lister.Keys(ctx, pattern)
+}
+
+// IncrementWindows always goes to the inner backend when it is a WindowIncrementer
+func (c *cachedBackend) IncrementWindows(ctx context.Context, key string, quotas []WindowQuota, cost int, now time.Time) (string, bool, error) {
+	incrementer, ok := c.inner.(WindowIncrementer)
+	if !ok {
+		return "", false, ErrWindowsNotSupported
+	}
+	defer c.invalidate(key)
+	return incrementer.IncrementWindows(ctx, key, quotas, cost, now)
+}
+
+func (c *cachedBackend) lookup(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(elem)
+		return "", false
+	}
+	return entry.value, true
+}
+
+// startFetch registers an inner Get of key and returns the write generation
+// it started at
+func (c *cachedBackend) startFetch(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fetch, ok := c.fetches[key]
+	if !ok {
+		fetch = &cacheFetch{}
+		c.fetches[key] = fetch
+	}
+	fetch.readers++
+	return fetch.generation
+}
+
+// endFetch unregisters an inner Get of key and caches its value if ok and
+// key was not written since the Get started
+func (c *cachedBackend) endFetch(key string, generation uint64, value string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fetch := c.fetches[key]
+	if fetch.readers--; fetch.readers == 0 {
+		delete(c.fetches, key)
+	}
+	if ok && fetch.generation == generation {
+		c.store(key, value)
+	}
+}
+
+// store caches value for key, evicting the oldest entry if the cache is full.
+// Must be called with c.mu held.
+func (c *cachedBackend) store(key, value string) {
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	} else if len(c.entries) >= c.maxEntries {
+		c.remove(c.order.Front())
+	}
+	entry := &cacheEntry{key: key, value: value, expiresAt: time.Now().Add(c.ttl)}
+	c.entries[key] = c.order.PushBack(entry)
+}
+
+// remove drops a cached entry. Must be called with c.mu held.
+func (c *cachedBackend) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+	c.order.Remove(elem)
+}
+
+// invalidate drops the cached entry of key and keeps Gets in flight from
+// caching what they read before the write
+func (c *cachedBackend) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if fetch, ok := c.fetches[key]; ok {
+		fetch.generation++
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
@@ -0,0 +1,226 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingBackend struct {
+	*mockBackend
+	gets int
+}
+
+func (c *countingBackend) Get(ctx context.Context, key string) (string, error) {
+	c.gets++
+	return c.mockBackend.Get(ctx, key)
+}
+
+func newCachedTestBackend(ttl time.Duration) (*countingBackend, Backend) {
+	inner := &countingBackend{mockBackend: newMockBackend()}
+	return inner, WithLocalCache(inner, ttl)
+}
+
+func TestWithLocalCache_CachesGet(t *testing.T) {
+	inner, backend := newCachedTestBackend(time.Minute)
+	ctx := t.Context()
+	inner.data["key"] = "v1"
+
+	for range 3 {
+		value, err := backend.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, "v1", value)
+	}
+	assert.Equal(t, 1, inner.gets, "repeated reads should be served from the cache")
+
+	// Out-of-band changes are not seen until the entry expires
+	inner.data["key"] = "v2"
+	value, err := backend.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", value)
+}
+
+func TestWithLocalCache_WritesInvalidate(t *testing.T) {
+	inner, backend := newCachedTestBackend(time.Minute)
+	ctx := t.Context()
+
+	read := func() string {
+		t.Helper()
+		value, err := backend.Get(ctx, "key")
+		require.NoError(t, err)
+		return value
+	}
+
+	require.Empty(t, read())
+
+	ok, err := backend.CheckAndSet(ctx, "key", "", "v1", 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "v1", read(), "CheckAndSet should invalidate")
+
+	require.NoError(t, backend.Set(ctx, "key", "v2", 0))
+	assert.Equal(t, "v2", read(), "Set should invalidate")
+
+	require.NoError(t, backend.Delete(ctx, "key"))
+	assert.Empty(t, read(), "Delete should invalidate")
+
+	// A failed compare also drops the stale entry
+	inner.data["key"] = "v3"
+	ok, err = backend.CheckAndSet(ctx, "key", "", "v4", 0)
+	require.NoError(t, err)
+	require.False(t, ok)
+	assert.Equal(t, "v3", read())
+}
+
+func TestWithLocalCache_FreshReadBypasses(t *testing.T) {
+	inner, backend := newCachedTestBackend(time.Minute)
+	ctx := t.Context()
+	inner.data["key"] = "v1"
+
+	_, err := backend.Get(ctx, "key")
+	require.NoError(t, err)
+	inner.data["key"] = "v2"
+
+	value, err := backend.Get(FreshRead(ctx), "key")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", value)
+	assert.Equal(t, 2, inner.gets)
+
+	// The fresh value refreshes the cache
+	value, err = backend.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", value)
+	assert.Equal(t, 2, inner.gets)
+}
+
+func TestWithLocalCache_Expiry(t *testing.T) {
+	inner, backend := newCachedTestBackend(10 * time.Millisecond)
+	ctx := t.Context()
+
+	_, err := backend.Get(ctx, "key")
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = backend.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.gets)
+}
+
+func TestWithLocalCache_Bounded(t *testing.T) {
+	_, backend := newCachedTestBackend(time.Minute)
+	ctx := t.Context()
+
+	for i := range localCacheMaxEntries + 100 {
+		_, err := backend.Get(ctx, fmt.Sprintf("key%d", i))
+		require.NoError(t, err)
+	}
+
+	cache := backend.(*cachedBackend)
+	assert.Len(t, cache.entries, localCacheMaxEntries)
+}
+
+func TestWithLocalCache_ZeroTTL(t *testing.T) {
+	inner := newMockBackend()
+	assert.Same(t, inner, WithLocalCache(inner, 0))
+}
+
+func TestWithLocalCache_IncrementWindowsInvalidates(t *testing.T) {
+	inner := &windowBackend{mockBackend: newMockBackend()}
+	backend := WithLocalCache(inner, time.Minute)
+	ctx := t.Context()
+
+	value, err := backend.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Empty(t, value)
+
+	_, _, err = backend.(WindowIncrementer).IncrementWindows(ctx, "key", nil, 1, time.Now())
+	require.NoError(t, err)
+
+	value, err = backend.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "state", value, "increment should invalidate the cached entry")
+
+	plain := WithLocalCache(newMockBackend(), time.Minute)
+	_, _, err = plain.(WindowIncrementer).IncrementWindows(ctx, "key", nil, 1, time.Now())
+	assert.ErrorIs(t, err, ErrWindowsNotSupported)
+}
+
+// stallingBackend holds Gets until release is closed, after signalling started
+type stallingBackend struct {
+	*mockBackend
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *stallingBackend) Get(ctx context.Context, key string) (string, error) {
+	value, err := s.mockBackend.Get(ctx, key)
+	close(s.started)
+	<-s.release
+	return value, err
+}
+
+func TestWithLocalCache_WriteDuringGet(t *testing.T) {
+	inner := &stallingBackend{
+		mockBackend: newMockBackend(),
+		started:     make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+	inner.data["key"] = "v1"
+	backend := WithLocalCache(inner, time.Minute)
+	ctx := t.Context()
+
+	done := make(chan string)
+	go func() {
+		value, _ := backend.Get(ctx, "key")
+		done <- value
+	}()
+
+	// The Get has read v1 when the write lands
+	<-inner.started
+	require.NoError(t, backend.Set(ctx, "key", "v2", 0))
+	close(inner.release)
+	assert.Equal(t, "v1", <-done)
+
+	// v1 must not have been cached over v2
+	value, err := inner.mockBackend.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "v2", value)
+	cache := backend.(*cachedBackend)
+	_, ok := cache.lookup("key")
+	assert.False(t, ok, "a value read before a write should not be cached")
+	assert.Empty(t, cache.fetches)
+}
+
+func TestWithLocalCache_EvictsOldest(t *testing.T) {
+	inner, backend := newCachedTestBackend(time.Minute)
+	ctx := t.Context()
+	cache := backend.(*cachedBackend)
+	cache.maxEntries = 3
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		_, err := backend.Get(ctx, key)
+		require.NoError(t, err)
+	}
+	assert.Len(t, cache.entries, 3)
+	assert.NotContains(t, cache.entries, "a")
+
+	// Re-reading b after invalidation makes it the newest entry
+	require.NoError(t, backend.Delete(ctx, "b"))
+	for _, key := range []string{"b", "e"} {
+		_, err := backend.Get(ctx, key)
+		require.NoError(t, err)
+	}
+	assert.Len(t, cache.entries, 3)
+	assert.NotContains(t, cache.entries, "c")
+	assert.Contains(t, cache.entries, "b")
+	assert.Equal(t, 6, inner.gets)
+}
+
+func TestWithLocalCache_OptionalInterfaces(t *testing.T) {
+	assertOptional(t, func(inner Backend) Backend {
+		return WithLocalCache(inner, time.Minute)
+	})
+}
@@ -0,0 +1,253 @@
+// Package cassandra provides a Cassandra/ScyllaDB backend.
+//
+// Every write is a lightweight transaction (LWT), which runs a Paxos round:
+// Cassandra does not order regular writes with LWTs on the same key, so a plain
+// Set could be lost against a concurrent CheckAndSet. LWTs cost several round trips between replicas
+// and are considerably slower than regular writes, so this backend is best
+// suited to lower-throughput, multi-datacenter deployments that already run
+// Cassandra or ScyllaDB. For high request rates prefer the redis backend.
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/gocql/gocql"
+)
+
+// Config holds configuration for creating a Cassandra backend.
+type Config struct {
+	// Hosts is the list of contact points, e.g. []string{"10.0.0.1", "10.0.0.2:9042"}.
+	Hosts []string
+	// Keyspace is the existing keyspace holding the ratelimit_kv table.
+	//
+	// The table is created if it does not exist, the keyspace is not.
+	Keyspace string
+	// Consistency is the consistency level for reads and the commit phase of writes.
+	//
+	// If 0 (gocql.Any), defaults to gocql.LocalQuorum. The Paxos phase of
+	// lightweight transactions always uses gocql.LocalSerial.
+	Consistency gocql.Consistency
+	// Username and Password enable password authentication when Username is set.
+	Username string
+	Password string
+	// Timeout is the per-query timeout.
+	//
+	// If 0, defaults to 2 seconds.
+	Timeout time.Duration
+	// ConnErrorStrings contains string patterns to identify connectivity-related errors.
+	//
+	// If nil, the default patterns from connErrorStrings are used.
+	ConnErrorStrings []string
+}
+
+type Backend struct {
+	session          *gocql.Session
+	connErrorStrings []string
+	ownsSession      bool // false for a caller-owned session, which Close leaves open
+}
+
+// New initializes a new Cassandra backend with the given configuration.
+func New(config Config) (*Backend, error) {
+	if len(config.Hosts) == 0 {
+		return nil, fmt.Errorf("cassandra hosts cannot be empty")
+	}
+	if config.Keyspace == "" {
+		return nil, fmt.Errorf("cassandra keyspace cannot be empty")
+	}
+	if config.Consistency == gocql.Any {
+		config.Consistency = gocql.LocalQuorum
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 2 * time.Second
+	}
+
+	// Use custom patterns if provided, otherwise fall back to defaults
+	patterns := config.ConnErrorStrings
+	if patterns == nil {
+		patterns = connErrorStrings
+	}
+
+	cluster := gocql.NewCluster(config.Hosts...)
+	cluster.Keyspace = config.Keyspace
+	cluster.Consistency = config.Consistency
+	cluster.SerialConsistency = gocql.LocalSerial
+	cluster.Timeout = config.Timeout
+	cluster.ConnectTimeout = config.Timeout
+	if config.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: config.Username,
+			Password: config.Password,
+		}
+	}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, backends.MaybeConnError("cassandra:CreateSession",
+			fmt.Errorf("failed to create cassandra session: %w", err), patterns)
+	}
+
+	if err := createTable(context.Background(), session); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to create ratelimit table: %w", err)
+	}
+
+	return &Backend{
+		session:          session,
+		connErrorStrings: patterns,
+		ownsSession:      true,
+	}, nil
+}
+
+// NewWithClient initializes a new Cassandra backend with a pre-configured session.
+//
+// The session must be bound to a keyspace containing the ratelimit_kv table and
+// should have its serial consistency set for lightweight transactions. The
+// session is left open by Close for its owner to close.
+func NewWithClient(session *gocql.Session) *Backend {
+	return &Backend{
+		session:          session,
+		connErrorStrings: connErrorStrings, // Use default patterns
+	}
+}
+
+func createTable(ctx context.Context, session *gocql.Session) error {
+	err := session.Query(`
+		CREATE TABLE IF NOT EXISTS ratelimit_kv (
+			key text PRIMARY KEY,
+			value text
+		)
+	`).WithContext(ctx).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to execute table query 'CREATE TABLE': %w", err)
+	}
+	return nil
+}
+
+func (c *Backend) GetSession() *gocql.Session {
+	return c.session
+}
+
+func (c *Backend) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := c.session.Query(`SELECT value FROM ratelimit_kv WHERE key = ?`, key).
+		WithContext(ctx).
+		Scan(&value)
+	if err != nil {
+		if errors.Is(err, gocql.ErrNotFound) {
+			return "", nil
+		}
+		return "", c.maybeConnError("cassandra:Get",
+			fmt.Errorf("failed to get key '%s' from cassandra: %w", key, err))
+	}
+	return value, nil
+}
+
+// Set writes the key using lightweight transactions, so that it is ordered
+// with concurrent CheckAndSet writes on the same key.
+//
+// CQL has no unconditional LWT: Set updates the key IF EXISTS, or inserts it
+// IF NOT EXISTS, retrying while a concurrent insert or delete flips which one
+// applies.
+func (c *Backend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	ttl := ttlSeconds(expiration)
+	for {
+		applied, err := c.session.Query(`UPDATE ratelimit_kv USING TTL ? SET value = ? WHERE key = ? IF EXISTS`,
+			ttl, value, key).
+			WithContext(ctx).
+			MapScanCAS(map[string]any{})
+		if err == nil && !applied {
+			applied, err = c.session.Query(`INSERT INTO ratelimit_kv (key, value) VALUES (?, ?) IF NOT EXISTS USING TTL ?`,
+				key, value, ttl).
+				WithContext(ctx).
+				MapScanCAS(map[string]any{})
+		}
+		if err != nil {
+			return c.maybeConnError("cassandra:Set",
+				fmt.Errorf("failed to set key '%s' in cassandra: %w", key, err))
+		}
+		if applied {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("failed to set key '%s' in cassandra: %w", key, err)
+		}
+	}
+}
+
+// Delete removes the key using a lightweight transaction, so that it is
+// ordered with concurrent CheckAndSet writes on the same key.
+func (c *Backend) Delete(ctx context.Context, key string) error {
+	_, err := c.session.Query(`DELETE FROM ratelimit_kv WHERE key = ? IF EXISTS`, key).
+		WithContext(ctx).
+		MapScanCAS(map[string]any{})
+	if err != nil {
+		return c.maybeConnError("cassandra:Delete",
+			fmt.Errorf("failed to delete key '%s' from cassandra: %w", key, err))
+	}
+	return nil
+}
+
+// Close closes the session created by New.
+//
+// A session passed to NewWithClient is left open for its owner to close.
+func (c *Backend) Close() error {
+	if c.session != nil && c.ownsSession {
+		c.session.Close()
+	}
+	return nil
+}
+
+// CheckAndSet atomically sets key to newValue only if current value matches oldValue.
+// This operation provides compare-and-swap (CAS) semantics for implementing optimistic locking.
+//
+// The compare is performed by a lightweight transaction: INSERT ... IF NOT EXISTS
+// when oldValue is "", UPDATE ... IF value = ? otherwise. The [applied] column
+// of the LWT result is returned as the CAS outcome. Expiration is applied with
+// USING TTL, rounded up to whole seconds; 0 means no expiration.
+//
+// Caller contract:
+//   - A (false, nil) return indicates the compare condition did not match (e.g., another writer won the race
+//     or the key already exists when using "set if not exists"). This is not an error. Callers may safely
+//     reload state and retry with backoff according to their contention policy.
+//   - A non-nil error indicates a storage/backend failure and should not be retried blindly.
+func (c *Backend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	var query *gocql.Query
+	if oldValue == "" {
+		// Expired rows are removed by Cassandra, so they don't exist for IF NOT EXISTS
+		query = c.session.Query(`INSERT INTO ratelimit_kv (key, value) VALUES (?, ?) IF NOT EXISTS USING TTL ?`,
+			key, newValue, ttlSeconds(expiration))
+	} else {
+		query = c.session.Query(`UPDATE ratelimit_kv USING TTL ? SET value = ? WHERE key = ? IF value = ?`,
+			ttlSeconds(expiration), newValue, key, oldValue)
+	}
+
+	applied, err := query.WithContext(ctx).MapScanCAS(map[string]any{})
+	if err != nil {
+		return false, c.maybeConnError("cassandra:CheckAndSet",
+			fmt.Errorf("check-and-set operation failed for key '%s': %w", key, err))
+	}
+
+	return applied, nil
+}
+
+// ttlSeconds converts an expiration to a CQL TTL, rounding up partial seconds.
+// Cassandra interprets a TTL of 0 as no expiration.
+func ttlSeconds(expiration time.Duration) int {
+	if expiration <= 0 {
+		return 0
+	}
+	return int(math.Ceil(expiration.Seconds()))
+}
+
+// maybeConnError checks if the error is a connectivity issue and wraps it as a health error.
+//
+// For Cassandra, unavailable hosts, timeouts and unreachable consistency levels are
+// considered health issues. Invalid queries are not considered health errors.
+func (c *Backend) maybeConnError(op string, err error) error {
+	return backends.MaybeConnError(op, err, c.connErrorStrings)
+}
@@ -0,0 +1,201 @@
+package cassandra
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupCassandraTest(t *testing.T) (*Backend, func()) {
+	t.Helper()
+
+	hosts := os.Getenv("TEST_CASSANDRA_HOSTS")
+	if hosts == "" {
+		hosts = "localhost:9042"
+	}
+	keyspace := os.Getenv("TEST_CASSANDRA_KEYSPACE")
+	if keyspace == "" {
+		keyspace = "ratelimit_test"
+	}
+
+	storage, err := New(Config{
+		Hosts:    strings.Split(hosts, ","),
+		Keyspace: keyspace,
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		return nil, func() {}
+	}
+
+	teardown := func() {
+		_ = storage.GetSession().Query(`TRUNCATE ratelimit_kv`).Exec()
+		_ = storage.Close()
+	}
+
+	return storage, teardown
+}
+
+func TestCassandraStorage_GetSetDelete(t *testing.T) {
+	ctx := t.Context()
+	storage, teardown := setupCassandraTest(t)
+	t.Cleanup(teardown)
+
+	if storage == nil {
+		t.Skip("Cassandra not available, skipping tests")
+	}
+
+	val, err := storage.Get(ctx, "nonexistent")
+	require.NoError(t, err)
+	require.Equal(t, "", val)
+
+	require.NoError(t, storage.Set(ctx, "testkey", "testvalue", time.Hour))
+	val, err = storage.Get(ctx, "testkey")
+	require.NoError(t, err)
+	require.Equal(t, "testvalue", val)
+
+	require.NoError(t, storage.Delete(ctx, "testkey"))
+	val, err = storage.Get(ctx, "testkey")
+	require.NoError(t, err)
+	require.Equal(t, "", val)
+
+	require.NoError(t, storage.Delete(ctx, "nonexistent"), "deleting a missing key should not error")
+}
+
+func TestCassandraStorage_CheckAndSet(t *testing.T) {
+	ctx := t.Context()
+	storage, teardown := setupCassandraTest(t)
+	t.Cleanup(teardown)
+
+	if storage == nil {
+		t.Skip("Cassandra not available, skipping tests")
+	}
+
+	t.Run("set if not exists", func(t *testing.T) {
+		ok, err := storage.CheckAndSet(ctx, "cas-new", "", "v1", time.Hour)
+		require.NoError(t, err)
+		require.True(t, ok, "first insert should be applied")
+
+		ok, err = storage.CheckAndSet(ctx, "cas-new", "", "v2", time.Hour)
+		require.NoError(t, err)
+		require.False(t, ok, "insert over existing key should not be applied")
+
+		val, err := storage.Get(ctx, "cas-new")
+		require.NoError(t, err)
+		require.Equal(t, "v1", val)
+	})
+
+	t.Run("compare and swap", func(t *testing.T) {
+		require.NoError(t, storage.Set(ctx, "cas-swap", "v1", time.Hour))
+
+		ok, err := storage.CheckAndSet(ctx, "cas-swap", "wrong", "v2", time.Hour)
+		require.NoError(t, err)
+		require.False(t, ok, "mismatched old value should not be applied")
+
+		ok, err = storage.CheckAndSet(ctx, "cas-swap", "v1", "v2", time.Hour)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		val, err := storage.Get(ctx, "cas-swap")
+		require.NoError(t, err)
+		require.Equal(t, "v2", val)
+	})
+
+	t.Run("swap on missing key", func(t *testing.T) {
+		ok, err := storage.CheckAndSet(ctx, "cas-missing", "v1", "v2", time.Hour)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
+
+func TestCassandraStorage_TTL(t *testing.T) {
+	ctx := t.Context()
+	storage, teardown := setupCassandraTest(t)
+	t.Cleanup(teardown)
+
+	if storage == nil {
+		t.Skip("Cassandra not available, skipping tests")
+	}
+
+	ok, err := storage.CheckAndSet(ctx, "ttl-key", "", "v1", time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.Eventually(t, func() bool {
+		val, err := storage.Get(ctx, "ttl-key")
+		return err == nil && val == ""
+	}, 5*time.Second, 200*time.Millisecond, "key should expire")
+
+	ok, err = storage.CheckAndSet(ctx, "ttl-key", "", "v2", time.Hour)
+	require.NoError(t, err)
+	require.True(t, ok, "expired key should be insertable again")
+}
+
+func TestCassandraStorage_SetOverwrites(t *testing.T) {
+	ctx := t.Context()
+	storage, teardown := setupCassandraTest(t)
+	t.Cleanup(teardown)
+
+	if storage == nil {
+		t.Skip("Cassandra not available, skipping tests")
+	}
+
+	// Set inserts missing keys and overwrites existing ones
+	require.NoError(t, storage.Set(ctx, "set-key", "v1", time.Hour))
+	require.NoError(t, storage.Set(ctx, "set-key", "v2", time.Hour))
+	val, err := storage.Get(ctx, "set-key")
+	require.NoError(t, err)
+	require.Equal(t, "v2", val)
+
+	// CheckAndSet observes the value written by Set
+	ok, err := storage.CheckAndSet(ctx, "set-key", "v2", "v3", time.Hour)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestCassandraStorage_CloseOwnership(t *testing.T) {
+	storage, teardown := setupCassandraTest(t)
+	t.Cleanup(teardown)
+
+	if storage == nil {
+		t.Skip("Cassandra not available, skipping tests")
+	}
+
+	// A caller-owned session stays open
+	borrowed := NewWithClient(storage.GetSession())
+	require.NoError(t, borrowed.Close())
+	require.False(t, storage.GetSession().Closed())
+	_, err := storage.Get(t.Context(), "key")
+	require.NoError(t, err)
+
+	// A backend-owned session is closed
+	owned := &Backend{session: storage.GetSession(), connErrorStrings: connErrorStrings, ownsSession: true}
+	require.NoError(t, owned.Close())
+	require.True(t, storage.GetSession().Closed())
+}
+
+func TestTTLSeconds(t *testing.T) {
+	tests := []struct {
+		expiration time.Duration
+		want       int
+	}{
+		{0, 0},
+		{-time.Second, 0},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{time.Minute, 60},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, ttlSeconds(tt.expiration), "ttlSeconds(%v)", tt.expiration)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(Config{Keyspace: "ks"})
+	require.Error(t, err)
+	_, err = New(Config{Hosts: []string{"localhost"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package cassandra
+
+// connErrorStrings contains string patterns used to identify connectivity-related errors
+// in Cassandra/ScyllaDB connections. These patterns are used to distinguish between
+// temporary connectivity issues (which should trigger health errors and potential failover)
+// versus other types of errors (like CQL syntax errors or invalid requests).
+//
+// The patterns are matched against the lowercase version of error messages using
+// string containment.
+//
+// There are brittle detections but users can override these patterns by providing their
+// own ConnErrorStrings in the Config.
+var connErrorStrings = []string{
+	"no hosts available",
+	"no connections were made",
+	"unable to create session",
+	"connection refused",
+	"connection reset",
+	"connection closed",
+	"network is unreachable",
+	"no such host",
+	"i/o timeout",
+	"no response received",
+	"broken pipe",
+	"cannot achieve consistency level",
+	"operation timed out",
+}
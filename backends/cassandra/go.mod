@@ -0,0 +1,20 @@
+module github.com/ajiwo/ratelimit/backends/cassandra
+
+go 1.25.0
+
+replace github.com/ajiwo/ratelimit v0.0.9 => ../..
+
+require (
+	github.com/ajiwo/ratelimit v0.0.9
+	github.com/gocql/gocql v1.7.0
+	github.com/stretchr/testify v1.11.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/snappy v0.0.3 // indirect
+	github.com/hailocab/go-hostpool v0.0.0-20160125115350-e80d13ce29ed // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/inf.v0 v0.9.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,18 @@
+package cassandra
+
+import (
+	"github.com/ajiwo/ratelimit/backends"
+)
+
+func init() {
+	backends.Register("cassandra", func(config any) (backends.Backend, error) {
+		cassandraConfig, ok := config.(Config)
+		if !ok {
+			return nil, backends.ErrInvalidConfig
+		}
+		if len(cassandraConfig.Hosts) == 0 || cassandraConfig.Keyspace == "" {
+			return nil, backends.ErrInvalidConfig
+		}
+		return New(cassandraConfig)
+	})
+}
@@ -0,0 +1,161 @@
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Codec converts strategy state between the compact format strategies work
+// with, e.g. "23|1|default|2|1761884055342794596", and the value stored in a
+// backend.
+type Codec interface {
+	// Encode converts a compact state into its stored form. It must be
+	// deterministic, since CheckAndSet compares encoded values.
+	Encode(state string) (string, error)
+
+	// Decode converts a stored value back into the compact state. Values
+	// written by other codecs should be recognized and decoded as well, so
+	// switching codecs keeps existing state readable.
+	Decode(data string) (string, error)
+}
+
+var (
+	// CompactCodec stores the compact strategy format as is. It is the default.
+	CompactCodec Codec = compactCodec{}
+
+	// JSONCodec stores state as a JSON object for inspection with backend
+	// tools, e.g. {"header":"23","fields":["1","default","2","1761884055342794596"]}.
+	// It decodes compact values as well.
+	JSONCodec Codec = jsonCodec{}
+)
+
+type compactCodec struct{}
+
+func (compactCodec) Encode(state string) (string, error) { return state, nil }
+
+// Decode returns compact values as is and converts JSON values
+func (compactCodec) Decode(data string) (string, error) { return JSONCodec.Decode(data) }
+
+// jsonState is the JSON form of a compact state: its header and "|" separated fields
+type jsonState struct {
+	Header string   `json:"header"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(state string) (string, error) {
+	var s jsonState
+	header, rest, ok := strings.Cut(state, "|")
+	s.Header = header
+	if ok {
+		s.Fields = strings.Split(rest, "|")
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode state as JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+// Decode sniffs the value: JSON objects are converted, anything else is
+// already in the compact format
+func (jsonCodec) Decode(data string) (string, error) {
+	if !strings.HasPrefix(data, "{") {
+		return data, nil
+	}
+	var s jsonState
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		return "", fmt.Errorf("failed to decode JSON state: %w", err)
+	}
+	if s.Fields == nil {
+		return s.Header, nil
+	}
+	return s.Header + "|" + strings.Join(s.Fields, "|"), nil
+}
+
+// codecBackend stores values encoded with a Codec
+type codecBackend struct {
+	inner Backend
+	codec Codec
+}
+
+// WithCodec wraps a backend so that values are stored encoded with codec and
+// decoded when read.
+//
+// Values written by another codec stay usable: they are decoded on read, and
+// CheckAndSet matches them by their decoded value, so state written before
+// switching codecs keeps working until it is rewritten. The wrapper does not
+// implement WindowIncrementer, since server-side updates write the compact
+// format. If codec is nil, inner is returned as is.
+func WithCodec(inner Backend, codec Codec) Backend {
+	if codec == nil {
+		return inner
+	}
+	return &codecBackend{inner: inner, codec: codec}
+}
+
+func (c *codecBackend) Get(ctx context.Context, key string) (string, error) {
+	data, err := c.inner.Get(ctx, key)
+	if err != nil || data == "" {
+		return data, err
+	}
+	return c.codec.Decode(data)
+}
+
+func (c *codecBackend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return err
+	}
+	return c.inner.Set(ctx, key, data, expiration)
+}
+
+func (c *codecBackend) CheckAndSet(ctx context.Context, key string, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	newData, err := c.codec.Encode(newValue)
+	if err != nil {
+		return false, err
+	}
+	if oldValue == "" {
+		return c.inner.CheckAndSet(ctx, key, "", newData, expiration)
+	}
+
+	oldData, err := c.codec.Encode(oldValue)
+	if err != nil {
+		return false, err
+	}
+	ok, err := c.inner.CheckAndSet(ctx, key, oldData, newData, expiration)
+	if ok || err != nil {
+		return ok, err
+	}
+
+	// The stored value may be the same state encoded by another codec
+	raw, err := c.inner.Get(ctx, key)
+	if err != nil || raw == "" || raw == oldData {
+		return false, err
+	}
+	if state, err := c.codec.Decode(raw); err != nil || state != oldValue {
+		return false, nil
+	}
+	return c.inner.CheckAndSet(ctx, key, raw, newData, expiration)
+}
+
+func (c *codecBackend) Delete(ctx context.Context, key string) error {
+	return c.inner.Delete(ctx, key)
+}
+
+func (c *codecBackend) Close() error {
+	return c.inner.Close()
+}
+
+// Keys passes through when the inner backend is a Lister
+func (c *codecBackend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	lister, ok := c.inner.(Lister)
+	if !ok {
+		return nil, ErrKeysNotSupported
+	}
+	return lister.Keys(ctx, pattern)
+}
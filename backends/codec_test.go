@@ -0,0 +1,96 @@
+package backends
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	states := map[string]string{
+		"token bucket":      "12|8.5|1761884055342794596",
+		"fixed window":      "23|2|minute|3|1761884055342794596|hour|7|1761884040000000000",
+		"composite":         "51|23|1|default|2|1761884055342794596$12|8.5|1761884055342794596",
+		"empty field":       "61|0|",
+		"header only":       "61",
+		"json-like content": `12|"quoted"|{x}`,
+	}
+
+	for _, codec := range []Codec{CompactCodec, JSONCodec} {
+		for name, state := range states {
+			encoded, err := codec.Encode(state)
+			require.NoError(t, err)
+			decoded, err := codec.Decode(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, state, decoded, "%T %s", codec, name)
+
+			// Every codec reads the values of the others
+			for _, other := range []Codec{CompactCodec, JSONCodec} {
+				decoded, err := other.Decode(encoded)
+				require.NoError(t, err)
+				assert.Equal(t, state, decoded, "%T reading %T %s", other, codec, name)
+			}
+		}
+	}
+
+	encoded, err := JSONCodec.Encode("23|1|default|2|1761884055342794596")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"header":"23","fields":["1","default","2","1761884055342794596"]}`, encoded)
+
+	_, err = JSONCodec.Decode("{not json")
+	assert.Error(t, err)
+}
+
+func TestWithCodec_StoresEncoded(t *testing.T) {
+	inner := newMockBackend()
+	backend := WithCodec(inner, JSONCodec)
+	ctx := t.Context()
+
+	ok, err := backend.CheckAndSet(ctx, "key", "", "12|5|100", 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"header":"12","fields":["5","100"]}`, inner.data["key"])
+
+	value, err := backend.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "12|5|100", value)
+
+	ok, err = backend.CheckAndSet(ctx, "key", "12|5|100", "12|4|200", 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = backend.CheckAndSet(ctx, "key", "12|5|100", "12|3|300", 0)
+	require.NoError(t, err)
+	assert.False(t, ok, "stale old value must not match")
+
+	require.NoError(t, backend.Set(ctx, "other", "23|1", 0))
+	assert.JSONEq(t, `{"header":"23","fields":["1"]}`, inner.data["other"])
+
+	assert.Same(t, inner, WithCodec(inner, nil))
+}
+
+func TestWithCodec_SwitchingCodecs(t *testing.T) {
+	inner := newMockBackend()
+	ctx := t.Context()
+
+	// State written in the compact format is updated through the JSON codec
+	inner.data["key"] = "12|5|100"
+	jsonBackend := WithCodec(inner, JSONCodec)
+	value, err := jsonBackend.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "12|5|100", value)
+	ok, err := jsonBackend.CheckAndSet(ctx, "key", value, "12|4|200", 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.JSONEq(t, `{"header":"12","fields":["4","200"]}`, inner.data["key"])
+
+	// And back to the compact format
+	compactBackend := WithCodec(inner, CompactCodec)
+	value, err = compactBackend.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, "12|4|200", value)
+	ok, err = compactBackend.CheckAndSet(ctx, "key", value, "12|3|300", 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "12|3|300", inner.data["key"])
+}
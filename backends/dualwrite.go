@@ -0,0 +1,81 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// dualWriteBackend reads from a primary backend and copies writes to a mirror
+type dualWriteBackend struct {
+	primary Backend
+	mirror  Backend
+}
+
+// NewDualWrite returns a backend for migrating state between backends, e.g.
+// from memory to Redis or from one Redis to another.
+//
+// Reads and CheckAndSet comparisons use primary only, which stays the source of
+// truth. Every successful write on primary is then copied to mirror with Set,
+// so mirror converges to the same values while it warms up. Mirror errors are
+// logged, see Warn, and never fail an operation. Copies are best-effort:
+// concurrent writers may copy their values out of order, until the next write
+// of the key.
+//
+// Unlike the memory failover composite, which switches reads to whichever
+// backend is healthy, NewDualWrite never reads from mirror. Close closes both.
+// Keys lists primary; server-side fixed window updates are not supported.
+func NewDualWrite(primary, mirror Backend) Backend {
+	return &dualWriteBackend{primary: primary, mirror: mirror}
+}
+
+func (d *dualWriteBackend) Get(ctx context.Context, key string) (string, error) {
+	return d.primary.Get(ctx, key)
+}
+
+func (d *dualWriteBackend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	if err := d.primary.Set(ctx, key, value, expiration); err != nil {
+		return err
+	}
+	d.copyValue(ctx, key, value, expiration)
+	return nil
+}
+
+func (d *dualWriteBackend) CheckAndSet(ctx context.Context, key string, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	ok, err := d.primary.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+	if !ok || err != nil {
+		return ok, err
+	}
+	d.copyValue(ctx, key, newValue, expiration)
+	return true, nil
+}
+
+func (d *dualWriteBackend) Delete(ctx context.Context, key string) error {
+	if err := d.primary.Delete(ctx, key); err != nil {
+		return err
+	}
+	if err := d.mirror.Delete(ctx, key); err != nil {
+		Warn(ctx, "ratelimit: dual write mirror delete failed", "key", key, "error", err)
+	}
+	return nil
+}
+
+func (d *dualWriteBackend) Close() error {
+	return errors.Join(d.primary.Close(), d.mirror.Close())
+}
+
+// Keys lists the keys of primary when it is a Lister
+func (d *dualWriteBackend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	lister, ok := d.primary.(Lister)
+	if !ok {
+		return nil, ErrKeysNotSupported
+	}
+	return lister.Keys(ctx, pattern)
+}
+
+// copyValue writes a value committed on primary to mirror
+func (d *dualWriteBackend) copyValue(ctx context.Context, key, value string, expiration time.Duration) {
+	if err := d.mirror.Set(ctx, key, value, expiration); err != nil {
+		Warn(ctx, "ratelimit: dual write mirror set failed", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,108 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackendDown = errors.New("backend down")
+
+// downBackend fails every operation and counts the attempts
+type downBackend struct {
+	calls int
+}
+
+func (d *downBackend) Get(context.Context, string) (string, error) {
+	d.calls++
+	return "", errBackendDown
+}
+
+func (d *downBackend) Set(context.Context, string, string, time.Duration) error {
+	d.calls++
+	return errBackendDown
+}
+
+func (d *downBackend) CheckAndSet(context.Context, string, string, string, time.Duration) (bool, error) {
+	d.calls++
+	return false, errBackendDown
+}
+
+func (d *downBackend) Delete(context.Context, string) error {
+	d.calls++
+	return errBackendDown
+}
+
+func (d *downBackend) Close() error {
+	return errBackendDown
+}
+
+func TestDualWrite_ReadsPrimaryWritesBoth(t *testing.T) {
+	primary, mirror := newMockBackend(), newMockBackend()
+	backend := NewDualWrite(primary, mirror)
+	ctx := t.Context()
+
+	require.NoError(t, backend.Set(ctx, "a", "1", time.Minute))
+	ok, err := backend.CheckAndSet(ctx, "b", "", "2", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, primary.data)
+	assert.Equal(t, primary.data, mirror.data)
+
+	// Reads and comparisons only consult primary
+	mirror.data["a"] = "stale"
+	mirror.data["only-mirror"] = "x"
+	value, err := backend.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", value)
+	value, err = backend.Get(ctx, "only-mirror")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	// A committed CheckAndSet overwrites the mirror, a lost one leaves it alone
+	ok, err = backend.CheckAndSet(ctx, "a", "1", "3", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "3", mirror.data["a"])
+	ok, err = backend.CheckAndSet(ctx, "a", "1", "4", time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+	assert.Equal(t, "3", mirror.data["a"])
+
+	require.NoError(t, backend.Delete(ctx, "a"))
+	assert.NotContains(t, primary.data, "a")
+	assert.NotContains(t, mirror.data, "a")
+}
+
+func TestDualWrite_MirrorFailures(t *testing.T) {
+	primary, mirror := newMockBackend(), &downBackend{}
+	backend := NewDualWrite(primary, mirror)
+	ctx := t.Context()
+
+	require.NoError(t, backend.Set(ctx, "a", "1", time.Minute))
+	ok, err := backend.CheckAndSet(ctx, "a", "1", "2", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, backend.Delete(ctx, "a"))
+	assert.Equal(t, 3, mirror.calls)
+	assert.Empty(t, primary.data)
+
+	assert.ErrorIs(t, backend.Close(), errBackendDown)
+}
+
+func TestDualWrite_PrimaryFailures(t *testing.T) {
+	primary, mirror := &downBackend{}, newMockBackend()
+	backend := NewDualWrite(primary, mirror)
+	ctx := t.Context()
+
+	assert.ErrorIs(t, backend.Set(ctx, "a", "1", time.Minute), errBackendDown)
+	_, err := backend.CheckAndSet(ctx, "a", "", "1", time.Minute)
+	assert.ErrorIs(t, err, errBackendDown)
+	_, err = backend.Get(ctx, "a")
+	assert.ErrorIs(t, err, errBackendDown)
+	assert.Empty(t, mirror.data, "nothing is copied unless primary commits")
+}
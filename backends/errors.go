@@ -8,4 +8,10 @@ var (
 
 	// ErrInvalidConfig is returned when the provided configuration is invalid.
 	ErrInvalidConfig = errors.New("invalid backend configuration")
+
+	// ErrKeysNotSupported is returned when key listing is requested from a backend that is not a Lister.
+	ErrKeysNotSupported = errors.New("backend does not support key listing")
+
+	// ErrWindowsNotSupported is returned when a WindowIncrementer cannot update fixed windows server-side.
+	ErrWindowsNotSupported = errors.New("backend does not support fixed window increments")
 )
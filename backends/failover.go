@@ -0,0 +1,40 @@
+package backends
+
+// BreakerState is the state of the circuit breaker of a Failover backend
+type BreakerState int32
+
+const (
+	BreakerClosed   BreakerState = iota // The primary backend serves requests
+	BreakerHalfOpen                     // The primary backend is tried again after the recovery timeout
+	BreakerOpen                         // The secondary backend serves requests
+)
+
+// String returns the lower case name of the state, e.g. "half_open"
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half_open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Failover is implemented by backends that fail over from a primary to a
+// secondary backend behind a circuit breaker, such as the memory failover
+// backend of ratelimit.WithMemoryFailover.
+type Failover interface {
+	// BreakerState returns the current circuit breaker state
+	BreakerState() BreakerState
+
+	// BreakerFailureCount returns the number of primary failures counted
+	// since the breaker last closed
+	BreakerFailureCount() int
+
+	// Degraded reports whether the breaker is not closed, with requests
+	// served by the secondary backend
+	Degraded() bool
+}
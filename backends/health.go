@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 )
 
@@ -70,6 +71,44 @@ func IsHealthError(err error) bool {
 	return errors.As(err, &he)
 }
 
+// failurePatterns are lowercase messages of common connectivity errors, for
+// backends that do not classify their errors with HealthError
+var failurePatterns = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"no such host",
+	"i/o timeout",
+	"use of closed network connection",
+}
+
+// IsBackendFailure reports whether err means the backend failed, as opposed
+// to an operational error such as a missing key or an invalid value.
+//
+// It returns true for health errors (see IsHealthError), deadline exceeded,
+// net.Error values and messages of common connectivity errors such as
+// "connection refused". Canceled contexts are the caller's doing and do not
+// count. It is the default failure classification of memory failover.
+func IsBackendFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if IsHealthError(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	errStr := strings.ToLower(err.Error())
+	for _, pattern := range failurePatterns {
+		if strings.Contains(errStr, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
 // MaybeConnError checks if the error is a connectivity issue using the provided patterns.
 //
 // The op parameter should describe the operation being performed (e.g., "redis:Get", "postgres:Ping").
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -122,6 +123,29 @@ func TestIsHealthError(t *testing.T) {
 	}
 }
 
+func TestIsBackendFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil error", err: nil, want: false},
+		{name: "health error", err: NewHealthError("redis:Get", errors.New("boom")), want: true},
+		{name: "deadline exceeded", err: fmt.Errorf("get: %w", context.DeadlineExceeded), want: true},
+		{name: "net error", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, want: true},
+		{name: "connection message", err: errors.New("dial tcp: Connection Refused"), want: true},
+		{name: "missing key", err: errors.New("key not found"), want: false},
+		{name: "invalid value", err: errors.New("invalid value"), want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsBackendFailure(tt.err))
+		})
+	}
+}
+
 func TestHealthError_ErrorChaining(t *testing.T) {
 	cause := errors.New("connection refused")
 	err := NewHealthError("redis:Ping", cause)
@@ -0,0 +1,49 @@
+package backends
+
+import (
+	"context"
+	"strings"
+)
+
+// Lister is implemented by backends that can enumerate stored keys.
+//
+// Enumeration is best-effort and intended for admin tooling, not the request
+// path: it may scan the whole keyspace, can miss keys written or expired
+// concurrently, and may return keys that expire right after. Expired keys
+// are skipped where the backend can tell.
+type Lister interface {
+	// Keys returns the keys matching pattern, in no particular order.
+	//
+	// The pattern is a glob where "*" matches any sequence of characters and
+	// "?" matches a single character; all other characters match literally.
+	// An empty pattern matches every key.
+	Keys(ctx context.Context, pattern string) ([]string, error)
+}
+
+// MatchPattern reports whether key matches the Lister glob pattern.
+//
+// Backends without native pattern support can use it to filter keys.
+func MatchPattern(pattern, key string) bool {
+	if pattern == "" {
+		return true
+	}
+	// Iterative glob matching with backtracking to the last "*"
+	p, k := 0, 0
+	star, mark := -1, 0
+	for k < len(key) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == key[k]):
+			p++
+			k++
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, k
+			p++
+		case star >= 0:
+			mark++
+			p, k = star+1, mark
+		default:
+			return false
+		}
+	}
+	return strings.Trim(pattern[p:], "*") == ""
+}
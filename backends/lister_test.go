@@ -0,0 +1,33 @@
+package backends
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"", "anything", true},
+		{"*", "", true},
+		{"*", "api:user1", true},
+		{"api:*", "api:user1", true},
+		{"api:*", "web:user1", false},
+		{"api:user?", "api:user1", true},
+		{"api:user?", "api:user12", false},
+		{"*:c", "api:user1:c", true},
+		{"*:c", "api:user1:cx", false},
+		{"api:*:c", "api:user1:c", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"exact", "exact", true},
+		{"exact", "exac", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchPattern(tt.pattern, tt.key), "MatchPattern(%q, %q)", tt.pattern, tt.key)
+	}
+}
@@ -0,0 +1,12 @@
+package backends
+
+import "time"
+
+// LockCleaner is implemented by backends keeping a lock per key in process,
+// such as the memory backend, whose lock map otherwise grows with every key
+// ever accessed.
+type LockCleaner interface {
+	// CleanupLocks removes the locks of keys without a stored value that were
+	// last used at least maxAge ago, and returns how many it removed.
+	CleanupLocks(maxAge time.Duration) int
+}
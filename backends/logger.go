@@ -0,0 +1,33 @@
+package backends
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger receives the warnings of backends and strategies, e.g. a repaired
+// state or a failed mirror write. The Logger of the limiter satisfies it.
+type Logger interface {
+	Warn(ctx context.Context, msg string, args ...any)
+}
+
+// loggerKey is the context key type for the logger stored by ContextWithLogger
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx whose warnings go to logger, see Warn.
+//
+// The limiter applies it with its own logger to every strategy call, so
+// warnings follow WithLogger instead of log/slog.
+func ContextWithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Warn logs a warning to the Logger of ctx, or to log/slog without one.
+// Arguments are alternating keys and values.
+func Warn(ctx context.Context, msg string, args ...any) {
+	if logger, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		logger.Warn(ctx, msg, args...)
+		return
+	}
+	slog.WarnContext(ctx, msg, args...)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
 )
 
 const (
@@ -11,15 +13,22 @@ const (
 	DefaultCleanupInterval = 10 * time.Minute
 )
 
-// mutexPool reduces allocations for mutex creation
-var mutexPool = sync.Pool{
+// lockPool reduces allocations for lock creation
+var lockPool = sync.Pool{
 	New: func() any {
-		return &sync.Mutex{}
+		return &keyLock{}
 	},
 }
 
+// keyLock serializes the operations on a key
+type keyLock struct {
+	mu       sync.Mutex
+	lastUsed time.Time // guarded by mu
+	removed  bool      // guarded by mu, set once CleanupLocks dropped the lock
+}
+
 type Backend struct {
-	locks         sync.Map     // map[string]*sync.Mutex
+	locks         sync.Map     // map[string]*keyLock
 	values        sync.Map     // map[string]memoryValue
 	cleanupTicker *time.Ticker // Ticker for periodic cleanup
 	cleanupStop   chan bool    // Channel to stop cleanup goroutine
@@ -50,20 +59,36 @@ func NewWithCleanup(interval time.Duration) *Backend {
 	return m
 }
 
-// getLock returns a mutex for the given key using pool to reduce allocations
-func (m *Backend) getLock(key string) *sync.Mutex {
+// getLock returns the lock for the given key using pool to reduce allocations
+func (m *Backend) getLock(key string) *keyLock {
 	if existing, ok := m.locks.Load(key); ok {
-		return existing.(*sync.Mutex)
+		return existing.(*keyLock)
 	}
 
-	// Use pooled mutex for new keys
-	mutex := mutexPool.Get().(*sync.Mutex)
-	actual, loaded := m.locks.LoadOrStore(key, mutex)
+	// Use pooled lock for new keys
+	lock := lockPool.Get().(*keyLock)
+	actual, loaded := m.locks.LoadOrStore(key, lock)
 	if loaded {
-		// Key already exists, return the pooled mutex to the pool
-		mutexPool.Put(mutex)
+		// Key already exists, return the pooled lock to the pool
+		lockPool.Put(lock)
+	}
+	return actual.(*keyLock)
+}
+
+// lock locks the lock of the given key and returns it, the caller unlocks it.
+//
+// A lock removed by CleanupLocks while the caller waited for it no longer
+// guards the key, so the caller tries again with a new lock.
+func (m *Backend) lock(key string) *keyLock {
+	for {
+		lock := m.getLock(key)
+		lock.mu.Lock()
+		if !lock.removed {
+			lock.lastUsed = time.Now()
+			return lock
+		}
+		lock.mu.Unlock()
 	}
-	return actual.(*sync.Mutex)
 }
 
 func (m *Backend) Get(ctx context.Context, key string) (string, error) {
@@ -71,9 +96,8 @@ func (m *Backend) Get(ctx context.Context, key string) (string, error) {
 		return "", err
 	}
 
-	lock := m.getLock(key)
-	lock.Lock()
-	defer lock.Unlock()
+	lock := m.lock(key)
+	defer lock.mu.Unlock()
 
 	valAny, exists := m.values.Load(key)
 	if !exists {
@@ -94,9 +118,8 @@ func (m *Backend) Set(ctx context.Context, key string, value string, expiration
 		return err
 	}
 
-	lock := m.getLock(key)
-	lock.Lock()
-	defer lock.Unlock()
+	lock := m.lock(key)
+	defer lock.mu.Unlock()
 
 	expirationTime := time.Now().Add(expiration)
 	m.values.Store(key, memoryValue{
@@ -112,14 +135,36 @@ func (m *Backend) Delete(ctx context.Context, key string) error {
 		return err
 	}
 
-	lock := m.getLock(key)
-	lock.Lock()
-	defer lock.Unlock()
+	lock := m.lock(key)
+	defer lock.mu.Unlock()
 
 	m.values.Delete(key)
 	return nil
 }
 
+// Keys returns the unexpired keys matching pattern.
+//
+// This scans every stored key; see backends.Lister for the pattern syntax.
+func (m *Backend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var keys []string
+	m.values.Range(func(k, v any) bool {
+		key := k.(string)
+		if now.After(v.(memoryValue).expiration) {
+			return true
+		}
+		if backends.MatchPattern(pattern, key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys, nil
+}
+
 // startCleanupRoutine starts the cleanup goroutine with the given interval
 func (m *Backend) startCleanupRoutine(interval time.Duration) {
 	m.cleanupTicker = time.NewTicker(interval)
@@ -154,10 +199,9 @@ func (m *Backend) cleanup() {
 
 	// Second pass: delete expired keys with their individual locks
 	for _, key := range keysToDelete {
-		lock := m.getLock(key)
-		lock.Lock()
+		lock := m.lock(key)
 		m.values.Delete(key)
-		lock.Unlock()
+		lock.mu.Unlock()
 	}
 }
 
@@ -167,6 +211,40 @@ func (m *Backend) Cleanup() {
 	m.cleanup()
 }
 
+// CleanupLocks removes the per-key locks of keys without an unexpired value
+// that were last used at least maxAge ago, and returns how many it removed.
+//
+// Every key accessed keeps a lock until then, even when only read, so with
+// high-cardinality keys such as client IPs, call it periodically, e.g. with
+// ratelimit.WithLockCleanupInterval, to bound memory use. Locks in use are
+// skipped.
+func (m *Backend) CleanupLocks(maxAge time.Duration) int {
+	now := time.Now()
+	removed := 0
+	m.locks.Range(func(key, lockAny any) bool {
+		lock := lockAny.(*keyLock)
+		if !lock.mu.TryLock() {
+			return true
+		}
+		defer lock.mu.Unlock()
+
+		if now.Sub(lock.lastUsed) < maxAge {
+			return true
+		}
+		if valAny, exists := m.values.Load(key); exists {
+			if !now.After(valAny.(memoryValue).expiration) {
+				return true
+			}
+			m.values.Delete(key)
+		}
+		lock.removed = true
+		m.locks.CompareAndDelete(key, lock)
+		removed++
+		return true
+	})
+	return removed
+}
+
 func (m *Backend) Close() error {
 	// Stop the cleanup ticker if it's running
 	if m.cleanupTicker != nil {
@@ -219,9 +297,8 @@ func (m *Backend) CheckAndSet(ctx context.Context, key, oldValue, newValue strin
 		return false, err
 	}
 
-	lock := m.getLock(key)
-	lock.Lock()
-	defer lock.Unlock()
+	lock := m.lock(key)
+	defer lock.mu.Unlock()
 
 	// Check if key exists and is not expired
 	valAny, exists := m.values.Load(key)
@@ -3,9 +3,13 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/synctest"
 	"time"
 
+	"github.com/ajiwo/ratelimit/backends"
 	"github.com/stretchr/testify/require"
 )
 
@@ -315,3 +319,119 @@ func TestMemoryStorage_cleanup(t *testing.T) {
 		require.False(t, success)
 	})
 }
+
+func TestMemoryStorage_Keys(t *testing.T) {
+	ctx := t.Context()
+	storage := New()
+	t.Cleanup(func() { _ = storage.Close() })
+
+	require.NoError(t, storage.Set(ctx, "api:user1", "v", time.Minute))
+	require.NoError(t, storage.Set(ctx, "api:user2", "v", time.Minute))
+	require.NoError(t, storage.Set(ctx, "web:user1", "v", time.Minute))
+	require.NoError(t, storage.Set(ctx, "api:expired", "v", -time.Second))
+
+	keys, err := storage.Keys(ctx, "api:*")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"api:user1", "api:user2"}, keys, "expired keys should be skipped")
+
+	keys, err = storage.Keys(ctx, "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"api:user1", "api:user2", "web:user1"}, keys)
+
+	keys, err = storage.Keys(ctx, "none:*")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	var _ backends.Lister = storage
+}
+
+// lockCount returns the number of per-key locks held by the storage
+func lockCount(storage *Backend) int {
+	n := 0
+	storage.locks.Range(func(any, any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func TestMemoryStorage_CleanupLocks(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		storage := NewWithCleanup(0)
+		t.Cleanup(func() { storage.Close() })
+
+		// Reads of distinct keys leave a lock each
+		for i := range 100 {
+			_, err := storage.Get(ctx, fmt.Sprintf("ip-%d", i))
+			require.NoError(t, err)
+		}
+		require.NoError(t, storage.Set(ctx, "stored", "value", time.Hour))
+		require.NoError(t, storage.Set(ctx, "expiring", "value", time.Second))
+		require.Equal(t, 102, lockCount(storage))
+
+		// Locks used within maxAge are kept
+		time.Sleep(30 * time.Second)
+		require.Zero(t, storage.CleanupLocks(time.Minute))
+		require.Equal(t, 102, lockCount(storage))
+
+		// Idle locks of keys without a live value are reclaimed
+		time.Sleep(30 * time.Second)
+		require.Equal(t, 101, storage.CleanupLocks(time.Minute))
+		require.Equal(t, 1, lockCount(storage))
+
+		val, err := storage.Get(ctx, "stored")
+		require.NoError(t, err)
+		require.Equal(t, "value", val)
+
+		// A caller that loaded a lock before its removal sees it removed and
+		// gets a new one
+		stale := storage.getLock("late")
+		require.Equal(t, 1, storage.CleanupLocks(0))
+		require.True(t, stale.removed)
+		require.NotSame(t, stale, storage.getLock("late"))
+	})
+}
+
+func TestMemoryStorage_CleanupLocksConcurrent(t *testing.T) {
+	ctx := t.Context()
+	storage := NewWithCleanup(0)
+	t.Cleanup(func() { storage.Close() })
+
+	// Locks of keys without a value are reclaimed while writers race for
+	// them, still a single set-if-absent may win per key
+	stop := make(chan struct{})
+	var cleaner sync.WaitGroup
+	cleaner.Go(func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				storage.CleanupLocks(0)
+			}
+		}
+	})
+
+	const goroutines, keys = 8, 500
+	var wins [keys]atomic.Int32
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for i := range keys {
+				ok, err := storage.CheckAndSet(ctx, fmt.Sprintf("key-%d", i), "", "value", time.Hour)
+				require.NoError(t, err)
+				if ok {
+					wins[i].Add(1)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(stop)
+	cleaner.Wait()
+
+	for i := range wins {
+		require.Equal(t, int32(1), wins[i].Load(), "key-%d", i)
+	}
+}
@@ -0,0 +1,116 @@
+package backends
+
+import (
+	"context"
+	"time"
+)
+
+// Operation names reported to a MetricsRecorder
+const (
+	OpGet         = "get"
+	OpSet         = "set"
+	OpCheckAndSet = "check_and_set"
+	OpDelete      = "delete"
+	OpClose       = "close"
+	OpKeys        = "keys"
+
+	OpIncrementWindows = "increment_windows"
+)
+
+// MetricsRecorder receives measurements from a backend wrapped with WithObservability.
+//
+// Implementations must be safe for concurrent use. They are called synchronously
+// on the hot path, so they should hand off to a metrics library without blocking.
+type MetricsRecorder interface {
+	// ObserveLatency records the duration of an operation, whether or not it failed
+	ObserveLatency(op string, duration time.Duration)
+
+	// IncError counts a failed operation. A CheckAndSet compare mismatch is not an error.
+	IncError(op string, err error)
+}
+
+// observedBackend decorates a Backend with latency and error measurements
+type observedBackend struct {
+	inner    Backend
+	recorder MetricsRecorder
+}
+
+// WithObservability wraps a backend so that every operation is timed and every
+// error is counted by operation name (see OpGet, OpSet, etc.).
+//
+// The wrapper works with any Backend, including user-registered ones, and
+// returns errors unchanged so health errors are still detected by failover.
+// The optional Lister, WindowIncrementer, LockCleaner and Failover interfaces
+// of inner are kept. If recorder is nil, inner is returned as is.
+func WithObservability(inner Backend, recorder MetricsRecorder) Backend {
+	if recorder == nil {
+		return inner
+	}
+	return withOptional(&observedBackend{inner: inner, recorder: recorder}, inner)
+}
+
+func (o *observedBackend) Get(ctx context.Context, key string) (string, error) {
+	start := time.Now()
+	value, err := o.inner.Get(ctx, key)
+	o.observe(OpGet, start, err)
+	return value, err
+}
+
+func (o *observedBackend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	start := time.Now()
+	err := o.inner.Set(ctx, key, value, expiration)
+	o.observe(OpSet, start, err)
+	return err
+}
+
+func (o *observedBackend) CheckAndSet(ctx context.Context, key string, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	start := time.Now()
+	ok, err := o.inner.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+	o.observe(OpCheckAndSet, start, err)
+	return ok, err
+}
+
+func (o *observedBackend) Delete(ctx context.Context, key string) error {
+	start := time.Now()
+	err := o.inner.Delete(ctx, key)
+	o.observe(OpDelete, start, err)
+	return err
+}
+
+func (o *observedBackend) Close() error {
+	start := time.Now()
+	err := o.inner.Close()
+	o.observe(OpClose, start, err)
+	return err
+}
+
+// Keys is timed like any other operation when the inner backend is a Lister
+func (o *observedBackend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	lister, ok := o.inner.(Lister)
+	if !ok {
+		return nil, ErrKeysNotSupported
+	}
+	start := time.Now()
+	keys, err := lister.Keys(ctx, pattern)
+	o.observe(OpKeys, start, err)
+	return keys, err
+}
+
+// IncrementWindows is timed like any other operation when the inner backend is a WindowIncrementer
+func (o *observedBackend) IncrementWindows(ctx context.Context, key string, quotas []WindowQuota, cost int, now time.Time) (string, bool, error) {
+	incrementer, ok := o.inner.(WindowIncrementer)
+	if !ok {
+		return "", false, ErrWindowsNotSupported
+	}
+	start := time.Now()
+	state, allowed, err := incrementer.IncrementWindows(ctx, key, quotas, cost, now)
+	o.observe(OpIncrementWindows, start, err)
+	return state, allowed, err
+}
+
+func (o *observedBackend) observe(op string, start time.Time, err error) {
+	o.recorder.ObserveLatency(op, time.Since(start))
+	if err != nil {
+		o.recorder.IncError(op, err)
+	}
+}
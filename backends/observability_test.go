@@ -0,0 +1,203 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRecorder struct {
+	mu        sync.Mutex
+	latencies []string
+	errors    map[string]int
+}
+
+func (f *fakeRecorder) ObserveLatency(op string, duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if duration >= 0 {
+		f.latencies = append(f.latencies, op)
+	}
+}
+
+func (f *fakeRecorder) IncError(op string, _ error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errors == nil {
+		f.errors = make(map[string]int)
+	}
+	f.errors[op]++
+}
+
+type failingBackend struct {
+	mockBackend
+	err error
+}
+
+func (f *failingBackend) Get(context.Context, string) (string, error) {
+	return "", f.err
+}
+
+func (f *failingBackend) CheckAndSet(context.Context, string, string, string, time.Duration) (bool, error) {
+	return false, f.err
+}
+
+func TestWithObservability_RecordsOperations(t *testing.T) {
+	recorder := &fakeRecorder{}
+	backend := WithObservability(newMockBackend(), recorder)
+	ctx := t.Context()
+
+	value, err := backend.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Empty(t, value)
+
+	ok, err := backend.CheckAndSet(ctx, "key", "", "1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// A compare mismatch is measured but not counted as an error
+	ok, err = backend.CheckAndSet(ctx, "key", "", "2", time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, backend.Set(ctx, "key", "3", time.Minute))
+	require.NoError(t, backend.Delete(ctx, "key"))
+	require.NoError(t, backend.Close())
+
+	assert.Equal(t, []string{OpGet, OpCheckAndSet, OpCheckAndSet, OpSet, OpDelete, OpClose}, recorder.latencies)
+	assert.Empty(t, recorder.errors)
+}
+
+func TestWithObservability_CountsErrors(t *testing.T) {
+	recorder := &fakeRecorder{}
+	cause := NewHealthError("fake:Get", errors.New("connection refused"))
+	backend := WithObservability(&failingBackend{mockBackend: *newMockBackend(), err: cause}, recorder)
+	ctx := t.Context()
+
+	_, err := backend.Get(ctx, "key")
+	require.ErrorIs(t, err, cause, "errors must be returned unchanged")
+	assert.True(t, IsHealthError(err))
+
+	_, err = backend.CheckAndSet(ctx, "key", "", "1", time.Minute)
+	require.Error(t, err)
+	_, err = backend.CheckAndSet(ctx, "key", "", "1", time.Minute)
+	require.Error(t, err)
+
+	require.NoError(t, backend.Set(ctx, "key", "1", time.Minute))
+
+	assert.Equal(t, map[string]int{OpGet: 1, OpCheckAndSet: 2}, recorder.errors)
+	assert.Equal(t, []string{OpGet, OpCheckAndSet, OpCheckAndSet, OpSet}, recorder.latencies)
+}
+
+func TestWithObservability_NilRecorder(t *testing.T) {
+	inner := newMockBackend()
+	assert.Same(t, inner, WithObservability(inner, nil))
+}
+
+type windowBackend struct {
+	*mockBackend
+	err error
+}
+
+func (w *windowBackend) IncrementWindows(ctx context.Context, key string, _ []WindowQuota, _ int, _ time.Time) (string, bool, error) {
+	if w.err != nil {
+		return "", false, w.err
+	}
+	return "state", true, w.Set(ctx, key, "state", time.Minute)
+}
+
+func TestWithObservability_IncrementWindows(t *testing.T) {
+	recorder := &fakeRecorder{}
+	errFailed := errors.New("increment failed")
+	ctx := t.Context()
+
+	incrementer, ok := WithObservability(&windowBackend{mockBackend: newMockBackend()}, recorder).(WindowIncrementer)
+	require.True(t, ok)
+	state, allowed, err := incrementer.IncrementWindows(ctx, "key", nil, 1, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "state", state)
+	assert.True(t, allowed)
+
+	failing := WithObservability(&windowBackend{mockBackend: newMockBackend(), err: errFailed}, recorder).(WindowIncrementer)
+	_, _, err = failing.IncrementWindows(ctx, "key", nil, 1, time.Now())
+	assert.ErrorIs(t, err, errFailed)
+
+	assert.Equal(t, []string{OpIncrementWindows, OpIncrementWindows}, recorder.latencies)
+	assert.Equal(t, 1, recorder.errors[OpIncrementWindows])
+
+	// Inner backends without support report it so callers can fall back
+	plain := WithObservability(newMockBackend(), recorder).(WindowIncrementer)
+	_, _, err = plain.IncrementWindows(ctx, "key", nil, 1, time.Now())
+	assert.ErrorIs(t, err, ErrWindowsNotSupported)
+}
+
+// cleanerBackend is a mock backend implementing LockCleaner
+type cleanerBackend struct {
+	*mockBackend
+}
+
+func (cleanerBackend) CleanupLocks(time.Duration) int { return 3 }
+
+// breakerBackend is a mock backend implementing Failover with an open breaker
+type breakerBackend struct {
+	*mockBackend
+}
+
+func (breakerBackend) BreakerState() BreakerState { return BreakerOpen }
+func (breakerBackend) BreakerFailureCount() int   { return 5 }
+func (breakerBackend) Degraded() bool             { return true }
+
+// cleanerBreakerBackend implements both LockCleaner and Failover
+type cleanerBreakerBackend struct {
+	breakerBackend
+}
+
+func (cleanerBreakerBackend) CleanupLocks(time.Duration) int { return 3 }
+
+// assertOptional checks that wrapped keeps exactly the optional interfaces of inner
+func assertOptional(t *testing.T, wrap func(Backend) Backend) {
+	t.Helper()
+	tests := []struct {
+		name              string
+		inner             Backend
+		cleaner, failover bool
+	}{
+		{"plain", newMockBackend(), false, false},
+		{"lock cleaner", cleanerBackend{newMockBackend()}, true, false},
+		{"failover", breakerBackend{newMockBackend()}, false, true},
+		{"both", cleanerBreakerBackend{breakerBackend{newMockBackend()}}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := wrap(tt.inner)
+			_, isLister := wrapped.(Lister)
+			_, isIncrementer := wrapped.(WindowIncrementer)
+			assert.True(t, isLister)
+			assert.True(t, isIncrementer)
+
+			cleaner, ok := wrapped.(LockCleaner)
+			require.Equal(t, tt.cleaner, ok)
+			if ok {
+				assert.Equal(t, 3, cleaner.CleanupLocks(time.Minute))
+			}
+			failover, ok := wrapped.(Failover)
+			require.Equal(t, tt.failover, ok)
+			if ok {
+				assert.Equal(t, BreakerOpen, failover.BreakerState())
+				assert.Equal(t, 5, failover.BreakerFailureCount())
+				assert.True(t, failover.Degraded())
+			}
+		})
+	}
+}
+
+func TestWithObservability_OptionalInterfaces(t *testing.T) {
+	assertOptional(t, func(inner Backend) Backend {
+		return WithObservability(inner, &fakeRecorder{})
+	})
+}
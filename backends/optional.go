@@ -0,0 +1,48 @@
+package backends
+
+// forwarder is a wrapper backend forwarding Lister and WindowIncrementer to
+// the backend it wraps, returning ErrKeysNotSupported and
+// ErrWindowsNotSupported when that backend lacks them
+type forwarder interface {
+	Backend
+	Lister
+	WindowIncrementer
+}
+
+// withOptional returns wrapper, extended with the LockCleaner and Failover
+// methods of inner when inner has them, so that wrapping a backend neither
+// hides them nor claims them for backends without them
+func withOptional(wrapper forwarder, inner Backend) Backend {
+	cleaner, isCleaner := inner.(LockCleaner)
+	failover, isFailover := inner.(Failover)
+	switch {
+	case isCleaner && isFailover:
+		return &cleanerFailoverForwarder{forwarder: wrapper, LockCleaner: cleaner, Failover: failover}
+	case isCleaner:
+		return &cleanerForwarder{forwarder: wrapper, LockCleaner: cleaner}
+	case isFailover:
+		return &failoverForwarder{forwarder: wrapper, Failover: failover}
+	default:
+		return wrapper
+	}
+}
+
+// cleanerForwarder is a wrapper of a LockCleaner backend, see withOptional
+type cleanerForwarder struct {
+	forwarder
+	LockCleaner
+}
+
+// failoverForwarder is a wrapper of a Failover backend, see withOptional
+type failoverForwarder struct {
+	forwarder
+	Failover
+}
+
+// cleanerFailoverForwarder is a wrapper of a LockCleaner and Failover backend,
+// see withOptional
+type cleanerFailoverForwarder struct {
+	forwarder
+	LockCleaner
+	Failover
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -36,6 +38,7 @@ type Config struct {
 type Backend struct {
 	pool             *pgxpool.Pool
 	connErrorStrings []string
+	ownsPool         bool // false for a caller-owned pool, which Close leaves open
 }
 
 // New initializes a new PostgresStorage with the given configuration.
@@ -80,12 +83,37 @@ func New(config Config) (*Backend, error) {
 	return &Backend{
 		pool:             pool,
 		connErrorStrings: patterns,
+		ownsPool:         true,
 	}, nil
 }
 
+// NewWithPool initializes a new Backend on a caller-provided connection pool.
+//
+// Apps can share one pool between the rate limiter and their own queries.
+// The pool is pinged and the ratelimit table created if missing. The caller
+// keeps ownership: Close does not close the pool.
+func NewWithPool(pool *pgxpool.Pool) (*Backend, error) {
+	if pool == nil {
+		return nil, fmt.Errorf("postgres pool cannot be nil")
+	}
+
+	if err := pool.Ping(context.Background()); err != nil {
+		return nil, backends.MaybeConnError("postgres:Ping",
+			fmt.Errorf("postgres ping failed: %w", err), connErrorStrings)
+	}
+
+	if err := createTable(context.Background(), pool); err != nil {
+		return nil, fmt.Errorf("failed to create ratelimit table: %w", err)
+	}
+
+	return NewWithClient(pool), nil
+}
+
 // NewWithClient initializes a new PostgresBackend with a pre-configured connection pool.
 //
-// The pool is assumed to be already connected and ready for use.
+// The pool is assumed to be already connected and ready for use, with the
+// ratelimit table in place; see NewWithPool to create it. The caller keeps
+// ownership: Close does not close the pool.
 func NewWithClient(pool *pgxpool.Pool) *Backend {
 	return &Backend{
 		pool:             pool,
@@ -104,6 +132,12 @@ func createTable(ctx context.Context, pool *pgxpool.Pool) error {
 	if err != nil {
 		return fmt.Errorf("failed to execute table query 'CREATE TABLE': %w", err)
 	}
+	if _, err := pool.Exec(ctx, dropLegacyIncrementWindowsFunction); err != nil {
+		return fmt.Errorf("failed to drop legacy function 'ratelimit_increment_windows': %w", err)
+	}
+	if _, err := pool.Exec(ctx, incrementWindowsFunction); err != nil {
+		return fmt.Errorf("failed to create function 'ratelimit_increment_windows': %w", err)
+	}
 	return nil
 }
 
@@ -165,8 +199,11 @@ func (p *Backend) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// Close closes the connection pool created by New.
+//
+// A pool passed to NewWithPool or NewWithClient is left open for its owner.
 func (p *Backend) Close() error {
-	if p.pool != nil {
+	if p.pool != nil && p.ownsPool {
 		p.pool.Close()
 	}
 	return nil
@@ -241,6 +278,88 @@ func (p *Backend) CheckAndSet(ctx context.Context, key, oldValue, newValue strin
 	return result.RowsAffected() == 1, nil
 }
 
+// IncrementWindows applies a fixed window request to every quota of key in one
+// round trip, see backends.WindowIncrementer.
+//
+// The update runs in the ratelimit_increment_windows function created by New,
+// which locks the row instead of retrying a compare-and-swap, so concurrent
+// requests on a hot key queue up rather than conflict. Backends created with
+// NewWithClient on a database without the function return an error wrapping
+// backends.ErrWindowsNotSupported.
+func (p *Backend) IncrementWindows(ctx context.Context, key string, quotas []backends.WindowQuota, cost int, now time.Time) (string, bool, error) {
+	names := make([]string, len(quotas))
+	limits := make([]int64, len(quotas))
+	windows := make([]int64, len(quotas))
+	skews := make([]int64, len(quotas))
+	for i, quota := range quotas {
+		names[i] = quota.Name
+		limits[i] = int64(quota.Limit)
+		windows[i] = quota.Window.Nanoseconds()
+		skews[i] = quota.MaxClockSkew.Nanoseconds()
+	}
+
+	var state string
+	var allowed bool
+	err := p.pool.QueryRow(ctx, `
+		SELECT state, allowed FROM ratelimit_increment_windows($1, $2, $3, $4, $5, $6, $7)
+	`, key, names, limits, windows, int64(cost), now.UnixNano(), skews).Scan(&state, &allowed)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == undefinedFunction {
+			return "", false, fmt.Errorf("%w: %w", backends.ErrWindowsNotSupported, err)
+		}
+		return "", false, p.maybeConnError("postgres:IncrementWindows",
+			fmt.Errorf("increment windows failed for key '%s': %w", key, err))
+	}
+	return state, allowed, nil
+}
+
+// Keys returns the unexpired keys matching pattern.
+//
+// The glob pattern is translated to a LIKE query, which cannot use the
+// primary key index for a leading "*" and may scan the whole table.
+// See backends.Lister for the pattern syntax.
+func (p *Backend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	rows, err := p.pool.Query(ctx, `
+		SELECT key FROM ratelimit_kv
+		WHERE key LIKE $1 ESCAPE '\'
+		AND (expires_at IS NULL OR expires_at > NOW())
+	`, likePattern(pattern))
+	if err != nil {
+		return nil, p.maybeConnError("postgres:Keys",
+			fmt.Errorf("failed to list keys matching '%s': %w", pattern, err))
+	}
+
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, p.maybeConnError("postgres:Keys",
+			fmt.Errorf("failed to list keys matching '%s': %w", pattern, err))
+	}
+	return keys, nil
+}
+
+// likePattern converts a Lister glob pattern into a LIKE pattern escaped with '\'
+func likePattern(pattern string) string {
+	if pattern == "" {
+		return "%"
+	}
+	var sb strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteByte('%')
+		case '?':
+			sb.WriteByte('_')
+		case '%', '_', '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
 // PurgeExpired deletes up to batchSize expired rows and returns the number deleted.
 func (p *Backend) PurgeExpired(ctx context.Context, batchSize int) (int64, error) {
 	if batchSize <= 0 {
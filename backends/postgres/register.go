@@ -2,10 +2,24 @@ package postgres
 
 import (
 	"github.com/ajiwo/ratelimit/backends"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func init() {
 	backends.Register("postgres", func(config any) (backends.Backend, error) {
+		// A pre-built pool is reused and stays owned by the caller
+		if pool, ok := config.(*pgxpool.Pool); ok {
+			return NewWithPool(pool)
+		}
+
+		// A plain string is treated as a connection string
+		if connString, ok := config.(string); ok {
+			if connString == "" {
+				return nil, backends.ErrInvalidConfig
+			}
+			return New(Config{ConnString: connString})
+		}
+
 		pgConfig, ok := config.(Config)
 		if !ok {
 			return nil, backends.ErrInvalidConfig
@@ -0,0 +1,140 @@
+package postgres
+
+// undefinedFunction is the SQLSTATE raised when calling a missing function
+const undefinedFunction = "42883"
+
+// incrementWindowsFunction creates the server-side fixed window update used by IncrementWindows.
+//
+// It reads and writes the combined fixed window state
+// ("23|N|name|count|startUnixNano|...", see strategies/DATA_FORMAT.md) and
+// mirrors the fixed window strategy: elapsed, missing or corrupted windows
+// restart at p_now, windows starting more than p_skews after p_now are clamped
+// to start at p_now with their count, the request is allowed only if every
+// quota has room for p_cost, and the expiration follows the longest remaining
+// window like the strategy's TTL. Repaired windows are stored even when the
+// request is denied.
+// Existing rows are locked with FOR UPDATE; a concurrent first insert is
+// detected with ON CONFLICT DO NOTHING and retried against the locked row.
+const incrementWindowsFunction = `
+CREATE OR REPLACE FUNCTION ratelimit_increment_windows(
+	p_key TEXT,
+	p_names TEXT[],
+	p_limits BIGINT[],
+	p_windows BIGINT[],
+	p_cost BIGINT,
+	p_now BIGINT,
+	p_skews BIGINT[]
+) RETURNS TABLE (state TEXT, allowed BOOLEAN) AS $$
+DECLARE
+	n INT := cardinality(p_names);
+	cur TEXT;
+	cur_expires TIMESTAMPTZ;
+	found_row BOOLEAN;
+	parts TEXT[];
+	counts BIGINT[];
+	starts BIGINT[];
+	stored_count BIGINT;
+	stored_start BIGINT;
+	repaired BOOLEAN;
+	ok BOOLEAN;
+	max_reset BIGINT;
+	ttl BIGINT;
+	expires TIMESTAMPTZ;
+	i INT;
+	j INT;
+BEGIN
+	LOOP
+		SELECT value, expires_at INTO cur, cur_expires
+		FROM ratelimit_kv WHERE key = p_key FOR UPDATE;
+		found_row := FOUND;
+		IF NOT found_row OR (cur_expires IS NOT NULL AND cur_expires <= NOW()) THEN
+			cur := '';
+		END IF;
+
+		-- Normalize windows: keep unexpired stored quotas, restart the others,
+		-- restart corrupted quotas and clamp quotas starting in the future
+		counts := array_fill(0::BIGINT, ARRAY[n]);
+		starts := array_fill(p_now, ARRAY[n]);
+		repaired := FALSE;
+		IF cur <> '' THEN
+			parts := string_to_array(cur, '|');
+			IF parts[1] <> '23' OR cardinality(parts) <> 2 + 3 * parts[2]::INT THEN
+				RAISE EXCEPTION 'failed to parse fixed window state for key %', p_key;
+			END IF;
+			FOR i IN 1..parts[2]::INT LOOP
+				j := array_position(p_names, parts[3 * i]);
+				CONTINUE WHEN j IS NULL;
+				stored_count := parts[3 * i + 1]::BIGINT;
+				stored_start := parts[3 * i + 2]::BIGINT;
+				IF stored_count - p_limits[j] > p_limits[j] OR stored_start > p_now + p_windows[j] THEN
+					repaired := TRUE;
+				ELSIF p_now - stored_start < p_windows[j] THEN
+					counts[j] := stored_count;
+					starts[j] := stored_start;
+					IF stored_start > p_now + p_skews[j] THEN
+						starts[j] := p_now;
+						repaired := TRUE;
+					END IF;
+				END IF;
+			END LOOP;
+		END IF;
+
+		ok := TRUE;
+		FOR j IN 1..n LOOP
+			IF counts[j] + p_cost > p_limits[j] THEN
+				ok := FALSE;
+			END IF;
+		END LOOP;
+
+		IF ok THEN
+			FOR j IN 1..n LOOP
+				counts[j] := counts[j] + p_cost;
+			END LOOP;
+		END IF;
+
+		state := '23|' || n;
+		FOR j IN 1..n LOOP
+			state := state || '|' || p_names[j] || '|' || counts[j] || '|' || starts[j];
+		END LOOP;
+		allowed := ok;
+
+		IF NOT ok AND NOT repaired THEN
+			RETURN NEXT;
+			RETURN;
+		END IF;
+
+		max_reset := 0;
+		FOR j IN 1..n LOOP
+			max_reset := GREATEST(max_reset, starts[j] + p_windows[j]);
+		END LOOP;
+		ttl := max_reset - p_now;
+		IF ttl < 1000000000 THEN
+			ttl := 1000000000;
+		ELSE
+			ttl := ttl * 5;
+		END IF;
+		expires := NOW() + (ttl / 1000) * INTERVAL '1 microsecond';
+
+		IF found_row THEN
+			UPDATE ratelimit_kv SET value = state, expires_at = expires WHERE key = p_key;
+			RETURN NEXT;
+			RETURN;
+		END IF;
+
+		INSERT INTO ratelimit_kv (key, value, expires_at) VALUES (p_key, state, expires)
+		ON CONFLICT (key) DO NOTHING;
+		IF FOUND THEN
+			RETURN NEXT;
+			RETURN;
+		END IF;
+		-- Another writer inserted the key first, retry against the locked row
+	END LOOP;
+END;
+$$ LANGUAGE plpgsql
+`
+
+// dropLegacyIncrementWindowsFunction drops the ratelimit_increment_windows
+// overload without clock skew tolerance, replaced by incrementWindowsFunction
+const dropLegacyIncrementWindowsFunction = `
+DROP FUNCTION IF EXISTS ratelimit_increment_windows(TEXT, TEXT[], BIGINT[], BIGINT[], BIGINT, BIGINT)
+`
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	_ "embed"
@@ -41,13 +42,15 @@ var cnsScript string
 type Backend struct {
 	client           redis.UniversalClient
 	connErrorStrings []string
+	ownsClient       bool // false for a caller-owned client, which Close leaves open
 }
 
 func (r *Backend) GetClient() redis.UniversalClient {
 	return r.client
 }
 
-// loadCheckAndSetScript loads and caches the CheckAndSet Lua script in Redis.
+// loadCheckAndSetScript loads and caches the CheckAndSet Lua script in Redis,
+// so CheckAndSet only sends its SHA1 digest with EVALSHA.
 //
 // The script (cns.lua) implements atomic compare-and-swap semantics:
 // - KEYS[1]: Redis storage key
@@ -58,7 +61,7 @@ func (r *Backend) loadCheckAndSetScript(ctx context.Context) error {
 	sha, err := r.client.ScriptLoad(ctx, cnsScript).Result()
 	if err != nil {
 		return r.maybeConnError("redis:ScriptLoad",
-			fmt.Errorf("failed to evaluate lua script: %w", err))
+			fmt.Errorf("failed to load lua script: %w", err))
 	}
 	if sha != checkAndSetSHA {
 		return fmt.Errorf("invalid script SHA hash for lua script")
@@ -113,15 +116,25 @@ func New(config Config) (*Backend, error) {
 			fmt.Errorf("redis ping failed: %w", err))
 	}
 
-	return &Backend{
+	backend := &Backend{
 		client:           client,
 		connErrorStrings: patterns,
-	}, nil
+		ownsClient:       true,
+	}
+	if err := backend.loadCheckAndSetScript(context.Background()); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return backend, nil
 }
 
 // NewWithClient initializes a new Backend with a pre-configured Redis universal client.
 //
-// The client is assumed to be already connected and ready for use.
+// The client is assumed to be already connected and ready for use, e.g. a
+// TLS-enabled client shared app-wide, so no second connection pool is
+// created. The caller keeps ownership: Close does not close the client.
+// Unlike New, the CheckAndSet script is not pre-loaded; the first
+// CheckAndSet loads it if Redis does not have it cached yet.
 func NewWithClient(client redis.UniversalClient) *Backend {
 	return &Backend{
 		client:           client,
@@ -154,7 +167,70 @@ func (r *Backend) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// Keys returns the keys matching pattern using SCAN, never the blocking KEYS command.
+//
+// On a cluster client every master is scanned. Redis expires keys itself, so
+// no expired key is returned. See backends.Lister for the pattern syntax.
+func (r *Backend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	pattern = redisPattern(pattern)
+
+	if cluster, ok := r.client.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		var keys []string
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			nodeKeys, err := scanKeys(ctx, node, pattern)
+			mu.Lock()
+			keys = append(keys, nodeKeys...)
+			mu.Unlock()
+			return err
+		})
+		if err != nil {
+			return nil, r.maybeConnError("redis:Keys",
+				fmt.Errorf("failed to list keys matching '%s': %w", pattern, err))
+		}
+		return keys, nil
+	}
+
+	keys, err := scanKeys(ctx, r.client, pattern)
+	if err != nil {
+		return nil, r.maybeConnError("redis:Keys",
+			fmt.Errorf("failed to list keys matching '%s': %w", pattern, err))
+	}
+	return keys, nil
+}
+
+// redisPattern escapes the Redis glob characters that are literal in a Lister pattern
+func redisPattern(pattern string) string {
+	if pattern == "" {
+		return "*"
+	}
+	var sb strings.Builder
+	for _, r := range pattern {
+		if r == '[' || r == ']' || r == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// scanKeys iterates SCAN on a single node
+func scanKeys(ctx context.Context, client redis.Cmdable, pattern string) ([]string, error) {
+	var keys []string
+	iter := client.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// Close closes the Redis client created by New.
+//
+// A client passed to NewWithClient is left open for its owner to close.
 func (r *Backend) Close() error {
+	if !r.ownsClient {
+		return nil
+	}
 	if err := r.client.Close(); err != nil {
 		return fmt.Errorf("failed to close redis connection: %w", err)
 	}
@@ -186,6 +262,10 @@ func (r *Backend) Close() error {
 //     or the key already exists when using "set if not exists"). This is not an error. Callers may safely
 //     reload state and retry with backoff according to their contention policy.
 //   - A non-nil error indicates a storage/backend failure and should not be retried blindly.
+//
+// The script is run by its SHA1 digest with EVALSHA. If Redis lost its script
+// cache, e.g. after a restart or SCRIPT FLUSH, the NOSCRIPT error is handled
+// transparently by running the script body with EVAL, which caches it again.
 
 func (r *Backend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
 	oldStr := oldValue
@@ -197,26 +277,16 @@ func (r *Backend) CheckAndSet(ctx context.Context, key, oldValue, newValue strin
 		expMs = fmt.Sprintf("%d", expiration.Milliseconds())
 	}
 
-	result, err := r.client.
-		EvalSha(ctx, checkAndSetSHA, []string{key}, oldStr, newStr, expMs).
-		Result()
+	keys := []string{key}
+	result, err := r.client.EvalSha(ctx, checkAndSetSHA, keys, oldStr, newStr, expMs).Result()
+	if err != nil && redis.HasErrorPrefix(err, "NOSCRIPT") {
+		// The script cache was flushed, e.g. by a restart: EVAL sends the
+		// script body once, which also caches it for the next EVALSHA
+		result, err = r.client.Eval(ctx, cnsScript, keys, oldStr, newStr, expMs).Result()
+	}
 	if err != nil {
-		// If script was not cached or flushed from Redis, load it and retry
-		if strings.Contains(err.Error(), "NOSCRIPT") {
-			if loadErr := r.loadCheckAndSetScript(ctx); loadErr != nil {
-				return false, fmt.Errorf("failed to evaluate lua script: %w", loadErr)
-			}
-			result, err = r.client.
-				EvalSha(ctx, checkAndSetSHA, []string{key}, oldStr, newStr, expMs).
-				Result()
-			if err != nil {
-				return false, r.maybeConnError("redis:CheckAndSet",
-					fmt.Errorf("failed to evaluate lua script: %w", err))
-			}
-		} else {
-			return false, r.maybeConnError("redis:CheckAndSet",
-				fmt.Errorf("failed to evaluate lua script: %w", err))
-		}
+		return false, r.maybeConnError("redis:CheckAndSet",
+			fmt.Errorf("failed to evaluate lua script: %w", err))
 	}
 
 	return result.(int64) == 1, nil
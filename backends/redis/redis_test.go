@@ -1,12 +1,17 @@
 package redis
 
 import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 )
 
@@ -301,3 +306,143 @@ func TestRedisStorage_CheckAndSet(t *testing.T) {
 		require.Equal(t, "noexpvalue", val)
 	})
 }
+
+func TestRedisStorage_Keys(t *testing.T) {
+	ctx := t.Context()
+	storage, teardown := setupRedisTest(t)
+	t.Cleanup(teardown)
+
+	if storage == nil {
+		t.Skip("Redis not available, skipping tests")
+	}
+
+	require.NoError(t, storage.Set(ctx, "api:user1", "v", time.Minute))
+	require.NoError(t, storage.Set(ctx, "api:user2", "v", 0))
+	require.NoError(t, storage.Set(ctx, "web:user1", "v", time.Minute))
+
+	keys, err := storage.Keys(ctx, "api:*")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"api:user1", "api:user2"}, keys)
+
+	keys, err = storage.Keys(ctx, "api:user?")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+}
+
+func TestRedisPattern(t *testing.T) {
+	tests := map[string]string{
+		"":      "*",
+		"api:*": "api:*",
+		"user?": "user?",
+		"a[b]":  `a\[b\]`,
+		`a\b`:   `a\\b`,
+	}
+	for pattern, want := range tests {
+		require.Equal(t, want, redisPattern(pattern), "redisPattern(%q)", pattern)
+	}
+}
+
+func TestRedisStorage_CloseOwnership(t *testing.T) {
+	// No server is needed: the client only dials when used
+	newClient := func() *redis.Client {
+		return redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	}
+
+	// A caller-owned client stays open
+	shared := newClient()
+	t.Cleanup(func() { _ = shared.Close() })
+	borrowed := NewWithClient(shared)
+	require.NoError(t, borrowed.Close())
+	require.NoError(t, borrowed.Close(), "closing twice is harmless")
+	err := shared.Ping(t.Context()).Err()
+	require.Error(t, err, "nothing listens on the address")
+	require.NotErrorIs(t, err, redis.ErrClosed)
+
+	// The same holds for a client passed to backends.Create
+	created, err := backends.Create("redis", shared)
+	require.NoError(t, err)
+	require.NoError(t, created.Close())
+	require.NotErrorIs(t, shared.Ping(t.Context()).Err(), redis.ErrClosed)
+
+	// A backend-owned client is closed
+	owned := &Backend{client: newClient(), connErrorStrings: connErrorStrings, ownsClient: true}
+	require.NoError(t, owned.Close())
+	require.ErrorIs(t, owned.GetClient().Ping(t.Context()).Err(), redis.ErrClosed)
+}
+
+// noScriptClient simulates Redis without the CheckAndSet script cached, e.g.
+// after a restart, recording the scripting commands sent
+type noScriptClient struct {
+	redis.UniversalClient
+	cached   bool
+	commands []string
+}
+
+func (c *noScriptClient) EvalSha(ctx context.Context, sha1 string, keys []string, args ...any) *redis.Cmd {
+	c.commands = append(c.commands, "evalsha")
+	if !c.cached {
+		return redis.NewCmdResult(nil, replyError("NOSCRIPT No matching script. Please use EVAL."))
+	}
+	return redis.NewCmdResult(int64(1), nil)
+}
+
+func (c *noScriptClient) Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd {
+	c.commands = append(c.commands, "eval")
+	c.cached = sha1Hex(script) == checkAndSetSHA
+	return redis.NewCmdResult(int64(1), nil)
+}
+
+// replyError is an error reply of the Redis server
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+func (replyError) RedisError()     {}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRedisStorage_CheckAndSetNoScript(t *testing.T) {
+	require.Equal(t, checkAndSetSHA, sha1Hex(cnsScript), "checkAndSetSHA must match cns.lua")
+
+	client := &noScriptClient{}
+	storage := NewWithClient(client)
+
+	// NOSCRIPT falls back to EVAL, which caches the script again
+	success, err := storage.CheckAndSet(t.Context(), "key", "", "value", time.Minute)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.Equal(t, []string{"evalsha", "eval"}, client.commands)
+
+	// Later calls only send the digest
+	success, err = storage.CheckAndSet(t.Context(), "key", "value", "next", time.Minute)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.Equal(t, []string{"evalsha", "eval", "evalsha"}, client.commands)
+}
+
+func TestRedisStorage_CheckAndSetAfterScriptFlush(t *testing.T) {
+	ctx := t.Context()
+	storage, teardown := setupRedisTest(t)
+	defer teardown()
+
+	if storage == nil {
+		t.Skip("Redis not available, skipping tests")
+	}
+
+	// New pre-loads the script
+	exists, err := storage.GetClient().ScriptExists(ctx, checkAndSetSHA).Result()
+	require.NoError(t, err)
+	require.Equal(t, []bool{true}, exists)
+
+	// A flushed script cache is reloaded transparently
+	require.NoError(t, storage.GetClient().ScriptFlush(ctx).Err())
+	success, err := storage.CheckAndSet(ctx, "flushed", "", "value", time.Minute)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	exists, err = storage.GetClient().ScriptExists(ctx, checkAndSetSHA).Result()
+	require.NoError(t, err)
+	require.Equal(t, []bool{true}, exists)
+}
@@ -2,10 +2,24 @@ package redis
 
 import (
 	"github.com/ajiwo/ratelimit/backends"
+	"github.com/redis/go-redis/v9"
 )
 
 func init() {
 	backends.Register("redis", func(config any) (backends.Backend, error) {
+		// A pre-built client is reused and stays owned by the caller
+		if client, ok := config.(redis.UniversalClient); ok {
+			return NewWithClient(client), nil
+		}
+
+		// A plain string is treated as a Redis URL
+		if url, ok := config.(string); ok {
+			if url == "" {
+				return nil, backends.ErrInvalidConfig
+			}
+			return New(Config{RedisURL: url})
+		}
+
 		redisConfig, ok := config.(Config)
 		if !ok {
 			return nil, backends.ErrInvalidConfig
@@ -0,0 +1,108 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// shardedBackend routes every key to one of several backends
+type shardedBackend struct {
+	shards []Backend
+	hashFn func(key string) int
+}
+
+// NewSharded returns a backend spreading keys over shards, e.g. Redis
+// instances that don't form a cluster, for throughput beyond a single server.
+//
+// Every operation on a key goes to the shard at index hashFn(key) modulo the
+// number of shards, so a key always lives on the same shard and CheckAndSet
+// stays atomic. A nil hashFn uses FNV-1a, spreading keys evenly; a custom
+// hashFn can weight shards of different capacity or pin hot keys. Changing
+// the shards or hashFn moves keys, whose state then starts over.
+//
+// Close closes every shard. Keys lists the keys of all shards, and
+// IncrementWindows is forwarded to the key's shard when it is a
+// WindowIncrementer.
+func NewSharded(shards []Backend, hashFn func(key string) int) (Backend, error) {
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("%w: sharded backend requires at least one shard", ErrInvalidConfig)
+	}
+	for i, shard := range shards {
+		if shard == nil {
+			return nil, fmt.Errorf("%w: shard %d is nil", ErrInvalidConfig, i)
+		}
+	}
+	if hashFn == nil {
+		hashFn = fnvHash
+	}
+	return &shardedBackend{shards: shards, hashFn: hashFn}, nil
+}
+
+// fnvHash is the default shard hash of NewSharded
+func fnvHash(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32())
+}
+
+// shard returns the backend of key
+func (s *shardedBackend) shard(key string) Backend {
+	i := s.hashFn(key) % len(s.shards)
+	if i < 0 {
+		i += len(s.shards)
+	}
+	return s.shards[i]
+}
+
+func (s *shardedBackend) Get(ctx context.Context, key string) (string, error) {
+	return s.shard(key).Get(ctx, key)
+}
+
+func (s *shardedBackend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	return s.shard(key).Set(ctx, key, value, expiration)
+}
+
+func (s *shardedBackend) CheckAndSet(ctx context.Context, key string, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	return s.shard(key).CheckAndSet(ctx, key, oldValue, newValue, expiration)
+}
+
+func (s *shardedBackend) Delete(ctx context.Context, key string) error {
+	return s.shard(key).Delete(ctx, key)
+}
+
+func (s *shardedBackend) Close() error {
+	errs := make([]error, len(s.shards))
+	for i, shard := range s.shards {
+		errs[i] = shard.Close()
+	}
+	return errors.Join(errs...)
+}
+
+// Keys lists the keys of every shard, all of which must be Listers
+func (s *shardedBackend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	for _, shard := range s.shards {
+		lister, ok := shard.(Lister)
+		if !ok {
+			return nil, ErrKeysNotSupported
+		}
+		shardKeys, err := lister.Keys(ctx, pattern)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, shardKeys...)
+	}
+	return keys, nil
+}
+
+// IncrementWindows goes to the shard of key when it is a WindowIncrementer
+func (s *shardedBackend) IncrementWindows(ctx context.Context, key string, quotas []WindowQuota, cost int, now time.Time) (string, bool, error) {
+	incrementer, ok := s.shard(key).(WindowIncrementer)
+	if !ok {
+		return "", false, ErrWindowsNotSupported
+	}
+	return incrementer.IncrementWindows(ctx, key, quotas, cost, now)
+}
@@ -0,0 +1,84 @@
+package backends
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharded_Validation(t *testing.T) {
+	_, err := NewSharded(nil, nil)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewSharded([]Backend{newMockBackend(), nil}, nil)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestSharded_RoutesKeysDeterministically(t *testing.T) {
+	shards := []*mockBackend{newMockBackend(), newMockBackend(), newMockBackend()}
+	backend, err := NewSharded([]Backend{shards[0], shards[1], shards[2]}, nil)
+	require.NoError(t, err)
+	ctx := t.Context()
+
+	for i := range 100 {
+		key := fmt.Sprintf("user-%d", i)
+		require.NoError(t, backend.Set(ctx, key, "v", time.Minute))
+	}
+
+	// Every key is on exactly one shard, the same on every call, and keys spread
+	total := 0
+	for _, shard := range shards {
+		assert.NotEmpty(t, shard.data)
+		total += len(shard.data)
+	}
+	assert.Equal(t, 100, total)
+	sharded := backend.(*shardedBackend)
+	for i := range 100 {
+		key := fmt.Sprintf("user-%d", i)
+		assert.Contains(t, sharded.shard(key).(*mockBackend).data, key)
+		assert.Same(t, sharded.shard(key), sharded.shard(key))
+	}
+}
+
+func TestSharded_Operations(t *testing.T) {
+	shards := []*mockBackend{newMockBackend(), newMockBackend()}
+	// A custom hash routes keys by their first byte, including negative results
+	backend, err := NewSharded([]Backend{shards[0], shards[1]}, func(key string) int {
+		if key[0] == 'a' {
+			return -2
+		}
+		return -1
+	})
+	require.NoError(t, err)
+	ctx := t.Context()
+
+	ok, err := backend.CheckAndSet(ctx, "a:1", "", "1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = backend.CheckAndSet(ctx, "a:1", "0", "2", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = backend.CheckAndSet(ctx, "b:1", "", "1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"a:1": "1"}, shards[0].data)
+	assert.Equal(t, map[string]string{"b:1": "1"}, shards[1].data)
+
+	value, err := backend.Get(ctx, "b:1")
+	require.NoError(t, err)
+	assert.Equal(t, "1", value)
+	require.NoError(t, backend.Delete(ctx, "b:1"))
+	assert.Empty(t, shards[1].data)
+
+	// The mock shards cannot list keys nor increment windows
+	_, err = backend.(Lister).Keys(ctx, "*")
+	require.ErrorIs(t, err, ErrKeysNotSupported)
+	_, _, err = backend.(WindowIncrementer).IncrementWindows(ctx, "a:1", nil, 1, time.Now())
+	require.ErrorIs(t, err, ErrWindowsNotSupported)
+
+	require.NoError(t, backend.Close())
+	assert.Nil(t, shards[0].data)
+	assert.Nil(t, shards[1].data)
+}
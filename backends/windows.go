@@ -0,0 +1,44 @@
+package backends
+
+import (
+	"context"
+	"time"
+)
+
+// WindowQuota describes one fixed window counter updated by WindowIncrementer
+type WindowQuota struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+
+	// MaxClockSkew is how far in the future a stored window may start before
+	// it is clamped to start at now, e.g. after the clock jumped backwards
+	MaxClockSkew time.Duration
+}
+
+// WindowIncrementer is implemented by backends that can update every fixed
+// window quota of a key server-side in a single round trip, instead of the
+// Get and CheckAndSet cycle used otherwise.
+//
+// The fixed window strategy uses it automatically when available. The stored
+// value is the combined fixed window state described in strategies/DATA_FORMAT.md.
+type WindowIncrementer interface {
+	// IncrementWindows atomically applies one fixed window request of the given
+	// cost to key, evaluated at now:
+	//   - quotas whose window has elapsed (or that are missing) restart at now with count 0
+	//   - corrupted quotas, with a count over twice the limit or a window
+	//     starting more than a window after now, restart at now with count 0
+	//   - quotas starting more than MaxClockSkew after now start at now instead,
+	//     keeping their count
+	//   - the request is allowed only if count+cost <= limit for every quota
+	//   - if allowed, every count is increased by cost and the state is stored,
+	//     expiring 5x the longest remaining window later (at least 1s)
+	//   - if denied, nothing is written unless a quota was restarted or
+	//     clamped as corrupted or skewed, then the repaired state is stored
+	//
+	// It returns the resulting state in the order of quotas, stored or not, and
+	// whether the request was allowed. Implementations return an error wrapping
+	// ErrWindowsNotSupported when the operation is unavailable, e.g. when the
+	// server-side function is missing, so the caller can fall back to CheckAndSet.
+	IncrementWindows(ctx context.Context, key string, quotas []WindowQuota, cost int, now time.Time) (state string, allowed bool, err error)
+}
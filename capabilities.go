@@ -0,0 +1,17 @@
+package ratelimit
+
+import "github.com/ajiwo/ratelimit/strategies"
+
+// SupportsSecondary reports whether secondary strategies can be combined with
+// the primary strategy of the limiter, as validated by New for
+// WithSecondaryStrategy.
+//
+// Primaries that can be a secondary themselves, e.g. token bucket, cannot
+// have secondaries, and neither can WithAnyStrategy tiers. Whether a given
+// strategy can be a secondary is reported by strategies.Capabilities.
+func (r *RateLimiter) SupportsSecondary() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.config.AnyConfigs) == 0 &&
+		!strategies.Capabilities(r.config.PrimaryConfig).Has(strategies.CapSecondary)
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DistinctKeysWindow is the period over which WithMaxDistinctKeys counts
+// distinct keys, the count starts over every window
+const DistinctKeysWindow = time.Minute
+
+// CardinalityResultKey is the result name reported for requests of new keys
+// allowed without enforcement beyond the WithMaxDistinctKeys bound, see
+// WithCardinalityFailOpen
+const CardinalityResultKey = "cardinality_exceeded"
+
+// CardinalityFunc is called with the bound and the first dynamic key beyond
+// it when a window of WithMaxDistinctKeys sees more distinct keys than the bound
+type CardinalityFunc func(ctx context.Context, maxKeys int, key string)
+
+// DistinctKeysOption configures WithMaxDistinctKeys
+type DistinctKeysOption func(*distinctKeysConfig)
+
+// distinctKeysConfig holds the configuration of WithMaxDistinctKeys
+type distinctKeysConfig struct {
+	maxKeys  int
+	onExceed CardinalityFunc
+	failOpen bool
+}
+
+// WithCardinalityAlert registers a function called when the distinct keys
+// bound is exceeded, e.g. to page whoever owns the key function.
+//
+// It is called at most once per DistinctKeysWindow, synchronously on the
+// goroutine of the request crossing the bound, so it must not block.
+func WithCardinalityAlert(fn CardinalityFunc) DistinctKeysOption {
+	return func(dc *distinctKeysConfig) {
+		dc.onExceed = fn
+	}
+}
+
+// WithCardinalityFailOpen allows the requests of new keys beyond the distinct
+// keys bound without running any strategy, so they never create state on the
+// backend; results hold a single CardinalityResultKey entry. Keys seen before
+// the bound was reached are still enforced.
+func WithCardinalityFailOpen() DistinctKeysOption {
+	return func(dc *distinctKeysConfig) {
+		dc.failOpen = true
+	}
+}
+
+// WithMaxDistinctKeys guards the backend against a misconfigured key
+// function, e.g. one including a timestamp, by counting the distinct keys of
+// Allow, AllowN and Check requests per DistinctKeysWindow.
+//
+// When a window sees more than maxKeys distinct keys, a warning is logged and
+// the WithCardinalityAlert function is called, once per window. Requests of
+// new keys are still enforced unless WithCardinalityFailOpen is given.
+// Allow-listed and sampled-out requests are not counted. Up to maxKeys keys
+// are tracked in memory per limiter and its views.
+func WithMaxDistinctKeys(maxKeys int, opts ...DistinctKeysOption) Option {
+	return func(config *Config) error {
+		if maxKeys <= 0 {
+			return fmt.Errorf("max distinct keys must be positive, got %d", maxKeys)
+		}
+		dc := &distinctKeysConfig{maxKeys: maxKeys}
+		for _, opt := range opts {
+			opt(dc)
+		}
+		config.distinctKeys = dc
+		return nil
+	}
+}
+
+// keyGuard counts the distinct keys of the current window, see WithMaxDistinctKeys
+type keyGuard struct {
+	config distinctKeysConfig
+
+	mu       sync.Mutex
+	start    time.Time
+	seen     map[string]struct{}
+	exceeded bool // the current window has exceeded the bound
+}
+
+// newKeyGuard returns the key guard of the config, nil without WithMaxDistinctKeys
+func newKeyGuard(config Config) *keyGuard {
+	if config.distinctKeys == nil {
+		return nil
+	}
+	return &keyGuard{config: *config.distinctKeys}
+}
+
+// admit records the dynamic key of a request under the base key prefix of
+// the limiter or view, and reports whether the request is enforced, false for
+// new keys beyond the bound with WithCardinalityFailOpen
+func (g *keyGuard) admit(ctx context.Context, logger Logger, prefix, dynamicKey string) bool {
+	if g == nil {
+		return true
+	}
+	key := prefix + dynamicKey
+
+	now := time.Now()
+	g.mu.Lock()
+	if now.Sub(g.start) >= DistinctKeysWindow {
+		g.start, g.exceeded = now, false
+		clear(g.seen)
+	}
+	if g.seen == nil {
+		g.seen = make(map[string]struct{})
+	}
+	if _, ok := g.seen[key]; ok || len(g.seen) < g.config.maxKeys {
+		g.seen[key] = struct{}{}
+		g.mu.Unlock()
+		return true
+	}
+	alert := !g.exceeded
+	g.exceeded = true
+	g.mu.Unlock()
+
+	if alert {
+		logger.Warn(ctx, "ratelimit: distinct keys bound exceeded, check the key function",
+			"max_keys", g.config.maxKeys, "window", DistinctKeysWindow, "key", dynamicKey)
+		if g.config.onExceed != nil {
+			g.config.onExceed(ctx, g.config.maxKeys, dynamicKey)
+		}
+	}
+	return !g.config.failOpen
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/concurrency"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+)
+
+// RateAndConcurrencyLeaseTTL is the lifetime of the leases of
+// WithRateAndConcurrency that are never released
+const RateAndConcurrencyLeaseTTL = time.Minute
+
+// WithRateAndConcurrency configures at most rate requests per window and at
+// most maxConcurrent of them in flight, e.g. 100 requests per minute and 10
+// at once.
+//
+// It combines a fixed window primary with a concurrency secondary, reported
+// under the "primary_default" and "secondary_default" keys. Use Acquire to
+// get the release function freeing the in-flight slot once the request is
+// done; leases never released expire after RateAndConcurrencyLeaseTTL. For
+// another lease TTL, configure a concurrency.Config secondary instead.
+func WithRateAndConcurrency(rate int, window time.Duration, maxConcurrent int) Option {
+	return func(config *Config) error {
+		rateConfig := fixedwindow.NewConfig().AddQuota("default", rate, window).Build()
+		if err := rateConfig.Validate(); err != nil {
+			return err
+		}
+		concurrencyConfig := &concurrency.Config{Max: maxConcurrent, LeaseTTL: RateAndConcurrencyLeaseTTL}
+		if err := concurrencyConfig.Validate(); err != nil {
+			return err
+		}
+		if err := WithPrimaryStrategy(rateConfig)(config); err != nil {
+			return err
+		}
+		return WithSecondaryStrategy(concurrencyConfig)(config)
+	}
+}
+
+// leaseReleaser is implemented by strategies returning concurrency leases
+// without refunding other quota
+type leaseReleaser interface {
+	Release(ctx context.Context, config strategies.Config, n int) error
+}
+
+// Acquire is like Allow and also returns a function releasing the
+// concurrency lease held by the allowed request, e.g. with
+// WithRateAndConcurrency. Quota consumed on rate tiers is kept.
+//
+// Call release once the request is done, typically deferred; it runs even
+// after ctx is canceled, and only its first call has an effect. Release is a
+// no-op for denied, allow-listed, sampled-out and failed-open requests, which
+// hold no lease, and for limiters without a concurrency strategy.
+func (r *RateLimiter) Acquire(ctx context.Context, options AccessOptions) (bool, func() error, error) {
+	noRelease := func() error { return nil }
+
+	allowed, results, err := r.allow(ctx, options, 0)
+	if err != nil {
+		return false, noRelease, err
+	}
+	if options.Result != nil {
+		*options.Result = results
+	}
+	if !allowed || results == nil || bypassed(results) {
+		return allowed, noRelease, nil
+	}
+
+	dynamicKey, err := r.dynamicKey(ctx, options)
+	if err != nil {
+		return false, noRelease, err
+	}
+	ctx = context.WithoutCancel(ctx)
+	var once sync.Once
+	var releaseErr error
+	release := func() error {
+		once.Do(func() { releaseErr = r.release(ctx, dynamicKey) })
+		return releaseErr
+	}
+	return true, release, nil
+}
+
+// release returns the concurrency lease of an allowed request for the key
+func (r *RateLimiter) release(ctx context.Context, dynamicKey string) error {
+	releaser, ok := r.strategy.(leaseReleaser)
+	if !ok {
+		return nil
+	}
+
+	strategyConfig := r.buildStrategyConfig(dynamicKey)
+	if err := releaser.Release(backends.FreshRead(r.logContext(ctx)), strategyConfig, 1); err != nil {
+		return fmt.Errorf("failed to release: %w", err)
+	}
+	return nil
+}
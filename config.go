@@ -2,9 +2,12 @@ package ratelimit
 
 import (
 	"fmt"
+	"math/rand/v2"
+	"time"
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
 	"github.com/ajiwo/ratelimit/utils"
 )
 
@@ -17,11 +20,45 @@ func validateKey(key, keyType string) error {
 
 // Config defines the configuration for single or dual strategy rate limiting
 type Config struct {
-	BaseKey         string            `json:"base_key"`
-	Storage         backends.Backend  `json:"-"`
-	PrimaryConfig   strategies.Config `json:"primary_config"`
-	SecondaryConfig strategies.Config `json:"secondary_config,omitempty"`
-	maxRetries      int
+	BaseKey               string              `json:"base_key"`
+	Storage               backends.Backend    `json:"-"`
+	PrimaryConfig         strategies.Config   `json:"primary_config"`
+	SecondaryConfig       strategies.Config   `json:"secondary_config,omitempty"`
+	ExtraSecondaryConfigs []strategies.Config `json:"extra_secondary_configs,omitempty"`
+	AnyConfigs            []strategies.Config `json:"any_configs,omitempty"` // Tiers after PrimaryConfig of WithAnyStrategy
+	maxRetries            int
+	retryBackoff          strategies.Backoff
+	rng                   *rand.Rand
+	hooks                 []Hook
+	costFunc              CostFunc
+	keyFunc               KeyFunc
+	tenant                string
+	requireTenant         bool
+	failureMode           FailureMode
+	peekFallback          time.Duration
+	penalty               *PenaltyConfig
+	allowList             ListFunc
+	denyList              ListFunc
+	sampleRate            float64
+	sampleRateSet         bool
+	keySampling           bool
+	distinctKeys          *distinctKeysConfig
+	stateCodec            backends.Codec
+	resetOnSuccess        bool
+	allowDuplicateRatios  bool
+	priorityThresholds    map[Priority]float64
+	onExceeded            ExceededFunc
+	auditFunc             AuditFunc
+	auditOverflow         AuditOverflow
+	maxWait               time.Duration
+	lockCleanupInterval   time.Duration
+	logger                Logger
+	breakerLogger         *breakerLogger     // set by WithMemoryFailover
+	validateOnly          bool               // set by Validate, options must not allocate resources
+	primaryStorage        backends.Backend   // nil unless WithStrategyBackend is given to WithPrimaryStrategy
+	secondaryStorages     []backends.Backend // per secondary in configuration order, nil entries use Storage
+	advisorySecondaries   []bool             // per secondary in configuration order, see WithAdvisory
+	ownedStorages         []backends.Backend // strategy backends created by NewFromSpec, closed with the limiter
 }
 
 // Validate validates the entire configuration
@@ -41,15 +78,35 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("primary strategy config validation failed: %w", err)
 	}
 
-	// Validate secondary strategy config if present
-	if c.SecondaryConfig != nil {
-		if err := c.SecondaryConfig.Validate(); err != nil {
-			return fmt.Errorf("secondary strategy config validation failed: %w", err)
+	if c.resetOnSuccess && c.PrimaryConfig.ID() != strategies.StrategyFixedWindow {
+		return fmt.Errorf("reset on success requires a fixed window primary strategy, got %s", c.PrimaryConfig.ID())
+	}
+
+	if len(c.AnyConfigs) > 0 && c.SecondaryConfig != nil {
+		return fmt.Errorf("any strategy cannot be combined with a secondary strategy")
+	}
+	for _, ac := range c.AnyConfigs {
+		if ac == nil {
+			return fmt.Errorf("any strategy config cannot be nil")
 		}
+		if err := ac.Validate(); err != nil {
+			return fmt.Errorf("any strategy config validation failed: %w", err)
+		}
+	}
+
+	if c.SecondaryConfig == nil && len(c.ExtraSecondaryConfigs) > 0 {
+		return fmt.Errorf("extra secondary strategies require a secondary strategy config")
+	}
 
-		// Secondary strategy must have CapSecondary capability (for smoothing)
-		if !c.SecondaryConfig.Capabilities().Has(strategies.CapSecondary) {
-			return fmt.Errorf("secondary strategy must support secondary capability, got %s", c.SecondaryConfig.ID().String())
+	// Validate secondary strategy configs if present
+	if c.SecondaryConfig != nil {
+		if err := validateSecondaryConfig(c.SecondaryConfig); err != nil {
+			return err
+		}
+		for _, sc := range c.ExtraSecondaryConfigs {
+			if err := validateSecondaryConfig(sc); err != nil {
+				return err
+			}
 		}
 
 		// Primary strategy cannot have CapSecondary if secondary is also specified
@@ -58,5 +115,60 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// The composite state of a shared backend holds a single primary value
+	if fixed, ok := c.PrimaryConfig.(*fixedwindow.Config); ok && fixed.IndependentQuotas && c.SecondaryConfig != nil && !c.hasStrategyStorage() {
+		return fmt.Errorf("independent quotas cannot be combined with a secondary strategy on the same backend")
+	}
+
+	return nil
+}
+
+// validateSecondaryConfig validates a single secondary strategy config
+func validateSecondaryConfig(sc strategies.Config) error {
+	if sc == nil {
+		return fmt.Errorf("secondary strategy config cannot be nil")
+	}
+	if err := sc.Validate(); err != nil {
+		return fmt.Errorf("secondary strategy config validation failed: %w", err)
+	}
+
+	// Secondary strategy must have CapSecondary capability (for smoothing)
+	if !sc.Capabilities().Has(strategies.CapSecondary) {
+		return fmt.Errorf("secondary strategy must support secondary capability, got %s", sc.ID().String())
+	}
 	return nil
 }
+
+// hasStrategyStorage reports whether a strategy has its own backend, see WithStrategyBackend
+func (c *Config) hasStrategyStorage() bool {
+	if c.primaryStorage != nil {
+		return true
+	}
+	for _, storage := range c.secondaryStorages {
+		if storage != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// strategyStorages returns the backend of the primary strategy followed by
+// those of the secondaries, each passed through wrap, with storage for
+// strategies without their own backend
+func (c *Config) strategyStorages(storage backends.Backend, wrap func(backends.Backend) backends.Backend) []backends.Backend {
+	storages := []backends.Backend{storage}
+	if c.primaryStorage != nil {
+		storages[0] = wrap(c.primaryStorage)
+	}
+	if c.SecondaryConfig == nil {
+		return storages
+	}
+	for i := range 1 + len(c.ExtraSecondaryConfigs) {
+		if i < len(c.secondaryStorages) && c.secondaryStorages[i] != nil {
+			storages = append(storages, wrap(c.secondaryStorages[i]))
+		} else {
+			storages = append(storages, storage)
+		}
+	}
+	return storages
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// costKey is the context key type for the request cost stored by ContextWithCost
+type costKey struct{}
+
+// ContextWithCost returns a copy of ctx carrying the cost of the request, so
+// that a layer far from the Allow call, e.g. a handler knowing the payload
+// size, can set it without plumbing options through.
+//
+// Precedence: the n of AllowN, then ContextWithCost, then WithCostFunc, then
+// 1. The cost must be positive and finite; Allow fails otherwise.
+func ContextWithCost(ctx context.Context, cost float64) context.Context {
+	return context.WithValue(ctx, costKey{}, cost)
+}
+
+// CostFromContext returns the cost stored by ContextWithCost, if any
+func CostFromContext(ctx context.Context) (float64, bool) {
+	cost, ok := ctx.Value(costKey{}).(float64)
+	return cost, ok
+}
+
+// CostFunc computes how many units of quota a request consumes, e.g. from a
+// payload size bucket carried in AccessOptions.Metadata.
+type CostFunc func(options AccessOptions) float64
+
+// WithCostFunc sets a function that computes the cost of each Allow call.
+//
+// The cost is consumed from token and leaky buckets as fractional units,
+// advances GCRA by cost emission intervals, and increments fixed window
+// counters by the cost rounded up. A request is allowed only if the whole
+// cost fits. The function must return a positive, finite value; Allow fails
+// otherwise. Peek and Refund are not affected. AllowN and ContextWithCost
+// take precedence over the function.
+func WithCostFunc(fn CostFunc) Option {
+	return func(config *Config) error {
+		if fn == nil {
+			return fmt.Errorf("cost function cannot be nil")
+		}
+		config.costFunc = fn
+		return nil
+	}
+}
+
+// requestCost returns the cost of a request from ContextWithCost or the cost
+// function, 1 when neither is set
+func (r *RateLimiter) requestCost(ctx context.Context, options AccessOptions) (float64, error) {
+	cost, ok := CostFromContext(ctx)
+	switch {
+	case ok:
+	case r.costFunc != nil:
+		cost = r.costFunc(options)
+	default:
+		return 1, nil
+	}
+	if cost <= 0 || math.IsNaN(cost) || math.IsInf(cost, 0) {
+		return 0, fmt.Errorf("request cost must be positive and finite, got %v", cost)
+	}
+	return cost, nil
+}
@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Decision is the outcome of Check, bundling everything Allow reports
+// through AccessOptions.Result into a single value.
+type Decision struct {
+	Allowed        bool                  // Overall decision, same as Allow
+	Results        strategies.Results    // Per-tier, per-quota results, e.g. "primary_default"
+	LimitingTier   string                // Result key that denied the request, "" when allowed
+	Reason         strategies.DenyReason // Why the request was denied, ReasonNone when allowed
+	RetryAfter     time.Duration         // Time until the limiting tier resets, 0 when allowed
+	Degraded       bool                  // Decided by memory failover or allowed by FailOpen during an outage
+	BackendLatency time.Duration         // Time spent in backend operations for the decision, CAS retries included
+
+	// PrimaryConsumed and SecondaryConsumed report the quota the decision
+	// consumed. Dual strategies are all-or-nothing: a denial by any tier
+	// consumes nothing, so both are false for denied, allow-listed,
+	// sampled-out and failed-open requests. An advisory secondary that
+	// denied is not consumed while the primary is. SecondaryConsumed
+	// requires every secondary to be consumed; with WithAnyStrategy,
+	// PrimaryConsumed reports whether the first tier allowed the request.
+	PrimaryConsumed   bool
+	SecondaryConsumed bool
+}
+
+// Check consumes quota like Allow and returns the full decision.
+//
+// AccessOptions.Result is ignored; use Decision.Results instead. When several
+// tiers deny, LimitingTier is the one that resets last, so retrying after
+// RetryAfter is not immediately denied by another tier.
+func (r *RateLimiter) Check(ctx context.Context, options AccessOptions) (*Decision, error) {
+	ctx, latency := withLatency(ctx)
+	allowed, results, err := r.allow(ctx, options, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &Decision{
+		Allowed:        allowed,
+		Results:        results,
+		Degraded:       r.degraded() || results == nil, // nil results: allowed by FailOpen
+		BackendLatency: latency(),
+	}
+	if allowed {
+		decision.PrimaryConsumed, decision.SecondaryConsumed = r.consumedTiers(results)
+	} else {
+		decision.LimitingTier, decision.RetryAfter = limitingTier(results, time.Now())
+		decision.Reason = denyReason(decision)
+	}
+	return decision, nil
+}
+
+// denyReason returns the reason of a denied decision: the reason of its
+// limiting tier, or Degraded when memory failover decided without the
+// backend. Deny-listed requests never reach the backend, so they keep
+// DenyList.
+func denyReason(decision *Decision) strategies.DenyReason {
+	reason := decision.Results[decision.LimitingTier].Reason
+	if decision.Degraded && reason != strategies.DenyList {
+		return strategies.Degraded
+	}
+	return reason
+}
+
+// consumedTiers reports whether an allowed request consumed the quota of the
+// primary and of every secondary strategy, from its results
+func (r *RateLimiter) consumedTiers(results strategies.Results) (primary, secondary bool) {
+	if results == nil || bypassed(results) {
+		return false, false
+	}
+
+	r.mu.RLock()
+	anyTiers := len(r.config.AnyConfigs) > 0
+	secondary = r.config.SecondaryConfig != nil
+	r.mu.RUnlock()
+
+	primary = !anyTiers
+	for name, res := range results {
+		switch {
+		case strings.HasPrefix(name, "secondary_"):
+			// Advisory tiers that denied allow the request without consuming
+			secondary = secondary && res.Allowed
+		case anyTiers && strings.HasPrefix(name, "tier1_"):
+			primary = true
+		}
+	}
+	return primary, secondary
+}
+
+// CanAllowN reports whether n units would be allowed right now, without consuming quota.
+//
+// It pre-flights a bulk operation: n fits when every result is allowed with at
+// least n remaining, e.g. n tokens in a token bucket or n requests left in
+// every fixed window quota. The Decision holds the Peek results; when n does
+// not fit, LimitingTier is the short tier that resets last and RetryAfter the
+// time until it does, an upper bound for continuous strategies. Like Peek, the
+// answer may be stale by the time Allow runs. AccessOptions.Result is ignored.
+func (r *RateLimiter) CanAllowN(ctx context.Context, options AccessOptions, n int) (bool, *Decision, error) {
+	if n <= 0 {
+		return false, nil, fmt.Errorf("request count must be positive, got %d", n)
+	}
+
+	var results strategies.Results
+	options.Result = &results
+	ctx, latency := withLatency(ctx)
+	if _, err := r.Peek(ctx, options); err != nil {
+		return false, nil, err
+	}
+
+	short := func(name string, res strategies.Result) bool {
+		// Allow-listed and sampled-out requests report no remaining quota but always fit
+		if res.Advisory {
+			return false
+		}
+		return !res.Allowed || (name != AllowListResultKey && name != SampledOutResultKey && res.Remaining < n)
+	}
+	decision := &Decision{Results: results, Degraded: r.degraded(), BackendLatency: latency()}
+	for _, res := range results {
+		decision.Degraded = decision.Degraded || res.Degraded
+	}
+	decision.LimitingTier, decision.RetryAfter = slowestTier(results, time.Now(), short)
+	decision.Allowed = decision.LimitingTier == ""
+	return decision.Allowed, decision, nil
+}
+
+// limitingTier returns the denying result that resets last and the time until it does
+func limitingTier(results strategies.Results, now time.Time) (string, time.Duration) {
+	return slowestTier(results, now, func(_ string, res strategies.Result) bool {
+		return res.Denies()
+	})
+}
+
+// slowestTier returns the result matching limits that resets last and the time until it does
+func slowestTier(results strategies.Results, now time.Time, limits func(string, strategies.Result) bool) (string, time.Duration) {
+	names := make([]string, 0, len(results))
+	for name, res := range results {
+		if limits(name, res) {
+			names = append(names, name)
+		}
+	}
+	// Sort for a deterministic choice between equal reset times
+	sort.Strings(names)
+
+	var tier string
+	var retryAfter time.Duration
+	for _, name := range names {
+		wait := max(results[name].Reset.Sub(now), 0)
+		if tier == "" || wait > retryAfter {
+			tier, retryAfter = name, wait
+		}
+	}
+	return tier, retryAfter
+}
+
+// Failover returns the memory failover backend, to observe its circuit
+// breaker state, e.g. for alerting while requests are served from memory.
+//
+// Returns false unless the limiter was created with WithMemoryFailover or a
+// backend implementing backends.Failover, also behind WithObservability or
+// WithLocalCache.
+func (r *RateLimiter) Failover() (backends.Failover, bool) {
+	failover, ok := r.storage().(backends.Failover)
+	return failover, ok
+}
+
+// degraded reports whether memory failover is currently serving requests
+func (r *RateLimiter) degraded() bool {
+	failover, ok := r.storage().(backends.Failover)
+	return ok && failover.Degraded()
+}
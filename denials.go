@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+const (
+	// denialEventBuffer is the capacity of the DenialEvents channel
+	denialEventBuffer = 100
+	// denialTrackerMaxKeys bounds the number of currently denied keys remembered
+	denialTrackerMaxKeys = 10000
+)
+
+// DenialEvent reports that a key went from allowed to denied
+type DenialEvent struct {
+	Key  string    // Dynamic key, "default" when none was given, "#tenant:key" with a tenant
+	Tier string    // Result name that denied, e.g. "hour" or "secondary_default"
+	Time time.Time // When the denial happened
+}
+
+// DenialEvents returns a channel receiving an event whenever a key transitions
+// from allowed to denied, e.g. to alert on who is being throttled right now.
+//
+// Only the first denial of a key is reported; further denials are not, until
+// an allowed request for the key ends the streak. Tracking starts with the
+// first call, and every call returns the same channel. Delivery is
+// best-effort: the channel is buffered and events are dropped while it is
+// full, so a slow consumer never blocks Allow. The channel is closed by Close.
+func (r *RateLimiter) DenialEvents() <-chan DenialEvent {
+	r.denialsOnce.Do(func() {
+		r.denials.Store(newDenialTracker(denialEventBuffer))
+	})
+	return r.denials.Load().events
+}
+
+// observeDenial records the outcome of an Allow for the denial tracker, if enabled
+func (r *RateLimiter) observeDenial(key string, allowed bool, results strategies.Results) {
+	if tracker := r.denials.Load(); tracker != nil {
+		tracker.observe(key, allowed, results, time.Now())
+	}
+}
+
+// forgetDenial drops the denial state of key, so its next denial is reported again
+func (r *RateLimiter) forgetDenial(key string) {
+	if tracker := r.denials.Load(); tracker != nil {
+		tracker.forget(key)
+	}
+}
+
+// forgetDenials drops the denial state of every key
+func (r *RateLimiter) forgetDenials() {
+	if tracker := r.denials.Load(); tracker != nil {
+		tracker.clear()
+	}
+}
+
+// closeDenials closes the DenialEvents channel, if enabled
+func (r *RateLimiter) closeDenials() {
+	if tracker := r.denials.Load(); tracker != nil {
+		tracker.close()
+	}
+}
+
+// denialTracker remembers currently denied keys to report only transitions
+type denialTracker struct {
+	events chan DenialEvent
+
+	mu     sync.Mutex
+	denied map[string]struct{}
+	closed bool
+}
+
+func newDenialTracker(buffer int) *denialTracker {
+	return &denialTracker{
+		events: make(chan DenialEvent, buffer),
+		denied: make(map[string]struct{}),
+	}
+}
+
+func (d *denialTracker) observe(key string, allowed bool, results strategies.Results, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return
+	}
+	if allowed {
+		delete(d.denied, key)
+		return
+	}
+	if _, ok := d.denied[key]; ok {
+		return
+	}
+
+	if len(d.denied) >= denialTrackerMaxKeys {
+		// Forget an arbitrary key; it may report its ongoing denial again
+		for k := range d.denied {
+			delete(d.denied, k)
+			break
+		}
+	}
+	d.denied[key] = struct{}{}
+
+	tier, _ := limitingTier(results, now)
+	select {
+	case d.events <- DenialEvent{Key: key, Tier: tier, Time: now}:
+	default:
+		// Buffer full, drop the event
+	}
+}
+
+func (d *denialTracker) forget(key string) {
+	d.mu.Lock()
+	delete(d.denied, key)
+	d.mu.Unlock()
+}
+
+func (d *denialTracker) clear() {
+	d.mu.Lock()
+	clear(d.denied)
+	d.mu.Unlock()
+}
+
+func (d *denialTracker) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.closed {
+		d.closed = true
+		close(d.events)
+	}
+}
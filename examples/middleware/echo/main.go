@@ -8,6 +8,7 @@ import (
 
 	"github.com/ajiwo/ratelimit"
 	"github.com/ajiwo/ratelimit/backends/memory"
+	limitmw "github.com/ajiwo/ratelimit/middleware"
 	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
 	"github.com/labstack/echo/v4"
@@ -68,7 +69,8 @@ func main() {
 func RateLimitMiddleware(limiter *ratelimit.RateLimiter) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			clientID := c.RealIP()
+			// RemoteAddr only: RealIP trusts X-Forwarded-For sent by any client
+			clientID := limitmw.ClientIP(c.Request())
 
 			// Check if request is allowed by rate limiter
 			allowed, err := limiter.Allow(
@@ -95,7 +97,7 @@ func RateLimitMiddleware(limiter *ratelimit.RateLimiter) echo.MiddlewareFunc {
 				)
 				if err == nil && statsOK && stats.HasQuota("default") {
 					result := stats.Default()
-					c.Response().Header().Set("X-RateLimit-Limit", "10")
+					c.Response().Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", result.Limit))
 					c.Response().Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", result.Remaining))
 					c.Response().Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", result.Reset.Unix()))
 					c.Response().Header().Set("Retry-After", fmt.Sprintf("%.0f", time.Until(result.Reset).Seconds()))
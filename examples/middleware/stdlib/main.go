@@ -4,11 +4,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/ajiwo/ratelimit"
 	"github.com/ajiwo/ratelimit/backends/memory"
+	limitmw "github.com/ajiwo/ratelimit/middleware"
 	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
 )
@@ -78,7 +78,7 @@ func middleware(limiter *ratelimit.RateLimiter, next http.Handler) http.Handler
 			)
 			if err == nil && stats.HasQuota("default") {
 				result := stats.Default()
-				w.Header().Set("X-RateLimit-Limit", "10")
+				w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", result.Limit))
 				w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", result.Remaining))
 				w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", result.Reset.Unix()))
 			}
@@ -93,6 +93,6 @@ func middleware(limiter *ratelimit.RateLimiter, next http.Handler) http.Handler
 }
 
 func getClientID(r *http.Request) string {
-	// - IP address
-	return strings.Split(r.RemoteAddr, ":")[0]
+	// - IP address, without the port, for IPv4 and IPv6 clients alike
+	return ratelimit.KeyOf("ip", limitmw.ClientIP(r))
 }
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+const (
+	// exceededWorkers bounds the WithOnExceeded callbacks running at once
+	exceededWorkers = 8
+	// exceededQueueSize is the number of pending callbacks queued before dropping
+	exceededQueueSize = 1000
+)
+
+// ExceededFunc is called with the dynamic key and the denying tier, e.g.
+// "hour" or "secondary_default", when a request is denied, see WithOnExceeded
+type ExceededFunc func(ctx context.Context, key, tier string)
+
+// WithOnExceeded registers a function called whenever Allow, AllowN or Check
+// denies a request, for side effects such as notifying a WAF or writing an
+// audit record.
+//
+// Unlike hooks, fn only sees denials, once per denied request, with the tier
+// that denied it: the denying result resetting last, "penalty" during a
+// lockout or "priority" when shed. Requests denied by WithDenyList are not
+// reported. fn runs asynchronously on a small pool of goroutines, with the
+// request context detached from its cancellation, so a slow fn never delays
+// the request; while the pool is busy, denials are queued up to a bound and
+// dropped beyond it. Close waits for queued calls to finish.
+func WithOnExceeded(fn ExceededFunc) Option {
+	return func(config *Config) error {
+		if fn == nil {
+			return fmt.Errorf("exceeded function cannot be nil")
+		}
+		config.onExceeded = fn
+		return nil
+	}
+}
+
+// exceededCall is a pending WithOnExceeded call
+type exceededCall struct {
+	ctx  context.Context
+	key  string
+	tier string
+}
+
+// exceededNotifier runs WithOnExceeded calls on a bounded pool of workers
+type exceededNotifier struct {
+	fn    ExceededFunc
+	calls chan exceededCall
+	wg    sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// newExceededNotifier starts the workers calling fn, nil if fn is nil
+func newExceededNotifier(fn ExceededFunc, workers, queueSize int) *exceededNotifier {
+	if fn == nil {
+		return nil
+	}
+	n := &exceededNotifier{
+		fn:    fn,
+		calls: make(chan exceededCall, queueSize),
+	}
+	for range workers {
+		n.wg.Go(func() {
+			for call := range n.calls {
+				n.fn(call.ctx, call.key, call.tier)
+			}
+		})
+	}
+	return n
+}
+
+// notify queues a call for the denial of key, dropping it if the queue is full
+func (n *exceededNotifier) notify(ctx context.Context, key string, results strategies.Results) {
+	if n == nil {
+		return
+	}
+	tier, _ := limitingTier(results, time.Now())
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.closed {
+		return
+	}
+	select {
+	case n.calls <- exceededCall{ctx: context.WithoutCancel(ctx), key: key, tier: tier}:
+	default:
+		// Queue full, drop the call
+	}
+}
+
+// close stops accepting calls and waits for the queued ones to finish
+func (n *exceededNotifier) close() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.calls)
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+}
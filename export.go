@@ -0,0 +1,202 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// KeyExportVersion is the version of the documents of ExportKey
+const KeyExportVersion = 1
+
+// KeyImportTTL is the expiration of the state written by ImportKey, long
+// enough for monthly windows. The next request of the key rewrites the state
+// with the expiration of its strategy.
+const KeyImportTTL = 32 * 24 * time.Hour
+
+// ErrIncompatibleExport is returned by ImportKey for documents of another
+// version or of strategies not matching the limiter
+var ErrIncompatibleExport = errors.New("incompatible key export")
+
+// keyExport is the document of ExportKey, e.g.
+// {"version":1,"strategies":["token_bucket"],"states":[{"key":"","state":"12|7|1761884055342794596"}]}
+type keyExport struct {
+	Version    int              `json:"version"`
+	Strategies []string         `json:"strategies"` // Strategy of every tier, see strategies.ID
+	States     []keyExportState `json:"states"`
+}
+
+// keyExportState is the stored state of a storage key of the exported key
+type keyExportState struct {
+	Key   string `json:"key"`   // Storage key relative to the dynamic key, e.g. ":c" for composite state
+	State string `json:"state"` // Compact state, starting with its format header
+}
+
+// ExportKey returns the strategy state of a key as a versioned JSON document
+// for ImportKey, e.g. to move keys from a memory backend to Redis during a
+// migration, or to snapshot a key for debugging.
+//
+// The state is exported as stored, in the compact format whatever the
+// WithStateCodec codec. Penalty lockouts are not exported. Returns an error
+// wrapping strategies.ErrInspectNotSupported if a strategy cannot decode its
+// state, and an error for strategies with their own backend
+// (WithStrategyBackend).
+func (r *RateLimiter) ExportKey(ctx context.Context, options AccessOptions) ([]byte, error) {
+	dynamicKey, err := r.dynamicKey(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &stateReader{inner: backends.WithCodec(r.storage(), r.stateCodec)}
+	tiers, err := r.inspectWith(backends.FreshRead(ctx), reader, dynamicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export key %q: %w", options.Key, err)
+	}
+
+	export := keyExport{Version: KeyExportVersion, States: []keyExportState{}}
+	for _, tier := range tiers {
+		export.Strategies = append(export.Strategies, tier.Strategy.String())
+	}
+	prefix := r.basePrefix + dynamicKey
+	for _, key := range reader.keys {
+		if state := reader.values[key]; state != "" {
+			export.States = append(export.States, keyExportState{Key: strings.TrimPrefix(key, prefix), State: state})
+		}
+	}
+	return json.Marshal(export)
+}
+
+// ImportKey stores the state exported by ExportKey for a key, replacing its
+// current state. The key may differ from the exported one, and the limiter
+// may use another backend or base key, but its strategies must be the same.
+//
+// The state expires after KeyImportTTL unless a request rewrites it first.
+// Returns an error wrapping ErrIncompatibleExport if the document has another
+// version, other strategies or state they cannot decode; nothing is written
+// then.
+func (r *RateLimiter) ImportKey(ctx context.Context, data []byte, options AccessOptions) error {
+	dynamicKey, err := r.dynamicKey(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	var export keyExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return fmt.Errorf("%w: %w", ErrIncompatibleExport, err)
+	}
+	if export.Version != KeyExportVersion {
+		return fmt.Errorf("%w: version %d, expected %d", ErrIncompatibleExport, export.Version, KeyExportVersion)
+	}
+
+	// Decode the imported state as this limiter would, without touching the backend
+	prefix := r.basePrefix + dynamicKey
+	reader := &stateReader{values: make(map[string]string, len(export.States))}
+	for _, state := range export.States {
+		reader.values[prefix+state.Key] = state.State
+	}
+	tiers, err := r.inspectWith(ctx, reader, dynamicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrIncompatibleExport, err)
+	}
+	ids := make([]string, len(tiers))
+	for i, tier := range tiers {
+		ids[i] = tier.Strategy.String()
+	}
+	if !slices.Equal(ids, export.Strategies) {
+		return fmt.Errorf("%w: strategies %v, expected %v", ErrIncompatibleExport, export.Strategies, ids)
+	}
+	for _, state := range export.States {
+		if !slices.Contains(reader.keys, prefix+state.Key) {
+			return fmt.Errorf("%w: unknown storage key %q", ErrIncompatibleExport, state.Key)
+		}
+	}
+
+	// Storage keys without exported state, e.g. of a fresh key, are cleared
+	storage := backends.WithCodec(r.storage(), r.stateCodec)
+	for _, key := range reader.keys {
+		if state := reader.values[key]; state != "" {
+			err = storage.Set(ctx, key, state, KeyImportTTL)
+		} else {
+			err = storage.Delete(ctx, key)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import key %q: %w", options.Key, err)
+		}
+	}
+
+	r.snapshots.forget(dynamicKey)
+	r.forgetDenial(dynamicKey)
+	return nil
+}
+
+// inspectWith inspects the state of a key read from reader, recording the
+// storage keys of the strategies
+func (r *RateLimiter) inspectWith(ctx context.Context, reader *stateReader, dynamicKey string) ([]strategies.State, error) {
+	r.mu.RLock()
+	config := r.config
+	r.mu.RUnlock()
+
+	if config.hasStrategyStorage() {
+		return nil, fmt.Errorf("exporting keys is not supported with per-strategy backends")
+	}
+
+	strategy, err := newStrategy(reader, nil, config)
+	if err != nil {
+		return nil, err
+	}
+	inspector, ok := strategy.(strategies.Inspector)
+	if !ok {
+		return nil, strategies.ErrInspectNotSupported
+	}
+	return inspector.Inspect(ctx, r.buildStrategyConfig(dynamicKey))
+}
+
+// stateReader is a read-only backend recording the keys read and their
+// values, read from inner or, without inner, from values
+type stateReader struct {
+	inner  backends.Backend
+	values map[string]string
+	keys   []string
+}
+
+var errStateReaderReadOnly = errors.New("state reader is read-only")
+
+func (s *stateReader) Get(ctx context.Context, key string) (string, error) {
+	if s.inner != nil {
+		value, err := s.inner.Get(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		if s.values == nil {
+			s.values = make(map[string]string)
+		}
+		s.values[key] = value
+	}
+	if !slices.Contains(s.keys, key) {
+		s.keys = append(s.keys, key)
+	}
+	return s.values[key], nil
+}
+
+func (s *stateReader) Set(context.Context, string, string, time.Duration) error {
+	return errStateReaderReadOnly
+}
+
+func (s *stateReader) CheckAndSet(context.Context, string, string, string, time.Duration) (bool, error) {
+	return false, errStateReaderReadOnly
+}
+
+func (s *stateReader) Delete(context.Context, string) error {
+	return errStateReaderReadOnly
+}
+
+func (s *stateReader) Close() error {
+	return nil
+}
@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"container/list"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// FailureMode decides how Allow behaves when the backend is unavailable
+type FailureMode int
+
+const (
+	// FailClosed denies the request and returns the backend error (default)
+	FailClosed FailureMode = iota
+	// FailOpen allows the request without error while the backend is unavailable
+	FailOpen
+)
+
+// peekFallbackMaxEntries bounds the number of keys held by the Peek fallback
+const peekFallbackMaxEntries = 10000
+
+// WithFailureMode sets how Allow and Check behave when the backend reports a
+// health error (see backends.IsHealthError).
+//
+// With FailOpen the request is allowed and no error is returned; hooks still
+// receive the error in Event.Err, and Check reports Degraded. Other errors,
+// such as invalid keys or corrupted state, are always returned.
+func WithFailureMode(mode FailureMode) Option {
+	return func(config *Config) error {
+		if mode != FailClosed && mode != FailOpen {
+			return fmt.Errorf("invalid failure mode %d", mode)
+		}
+		config.failureMode = mode
+		return nil
+	}
+}
+
+// WithPeekFallback keeps the last known results of every key for up to maxAge
+// and serves them from Peek when the backend reports a health error.
+//
+// Fallback results are flagged with strategies.Result.Degraded, so status
+// endpoints and headers keep working during a backend blip. Only Peek uses
+// the fallback; Allow follows the failure mode set with WithFailureMode.
+// At most 10000 keys are kept.
+func WithPeekFallback(maxAge time.Duration) Option {
+	return func(config *Config) error {
+		if maxAge <= 0 {
+			return fmt.Errorf("peek fallback max age must be positive, got %v", maxAge)
+		}
+		config.peekFallback = maxAge
+		return nil
+	}
+}
+
+// failOpen reports whether err should be swallowed and the request allowed
+func (r *RateLimiter) failOpen(err error) bool {
+	return r.failureMode == FailOpen && backends.IsHealthError(err)
+}
+
+type snapshot struct {
+	key     string
+	allowed bool
+	results strategies.Results
+	at      time.Time
+}
+
+// snapshotCache holds the last known results per dynamic key for the Peek fallback.
+// A nil cache is valid and disables the fallback.
+type snapshotCache struct {
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *snapshot
+	order   *list.List               // oldest snapshot at the front
+}
+
+func newSnapshotCache(maxAge time.Duration) *snapshotCache {
+	if maxAge <= 0 {
+		return nil
+	}
+	return &snapshotCache{
+		maxAge:  maxAge,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// store records a copy of the results as the last known state of key
+func (c *snapshotCache) store(key string, allowed bool, results strategies.Results) {
+	if c == nil {
+		return
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, exists := c.entries[key]; exists {
+		c.remove(elem)
+	} else if len(c.entries) >= peekFallbackMaxEntries {
+		// The oldest snapshot is the first to be outdated
+		c.remove(c.order.Front())
+	}
+	snap := &snapshot{key: key, allowed: allowed, results: maps.Clone(results), at: now}
+	c.entries[key] = c.order.PushBack(snap)
+}
+
+// load returns the last known state of key flagged as degraded, if not older than maxAge
+func (c *snapshotCache) load(key string) (bool, strategies.Results, bool) {
+	if c == nil {
+		return false, nil, false
+	}
+
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	var snap *snapshot
+	if ok {
+		snap = elem.Value.(*snapshot)
+	}
+	c.mu.Unlock()
+	if !ok || time.Since(snap.at) > c.maxAge {
+		return false, nil, false
+	}
+
+	results := make(strategies.Results, len(snap.results))
+	for name, res := range snap.results {
+		res.Degraded = true
+		results[name] = res
+	}
+	return snap.allowed, results, true
+}
+
+// forget drops the snapshot of key
+func (c *snapshotCache) forget(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.mu.Unlock()
+}
+
+// clear drops every snapshot
+func (c *snapshotCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	clear(c.entries)
+	c.order.Init()
+	c.mu.Unlock()
+}
+
+// remove drops a snapshot. Must be called with c.mu held.
+func (c *snapshotCache) remove(elem *list.Element) {
+	delete(c.entries, elem.Value.(*snapshot).key)
+	c.order.Remove(elem)
+}
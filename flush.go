@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ajiwo/ratelimit/backends"
+)
+
+// FlushAll deletes every storage key of this limiter, e.g. between tests or
+// before a controlled rollout.
+//
+// Unlike flushing the backend, only keys under the limiter's base key are
+// deleted, on the limiter-wide backend and on every WithStrategyBackend
+// backend, so limiters with other base keys sharing a backend keep their
+// state. Note that a base key which is a prefix of another up to a ':', e.g.
+// "api" and "api:v2", shares that namespace. The Peek fallback snapshots and
+// denial tracking of this limiter are dropped as well.
+//
+// Keys are enumerated with backends.Lister, so FlushAll is best-effort like
+// ListKeys: keys written concurrently may survive. Returns an error wrapping
+// backends.ErrKeysNotSupported if a backend cannot enumerate keys.
+func (r *RateLimiter) FlushAll(ctx context.Context) error {
+	var errs []error
+	for _, storage := range r.flushStorages() {
+		lister, ok := storage.(backends.Lister)
+		if !ok {
+			errs = append(errs, fmt.Errorf("cannot flush keys: %w", backends.ErrKeysNotSupported))
+			continue
+		}
+		keys, err := lister.Keys(ctx, r.basePrefix+"*")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list keys: %w", err))
+			continue
+		}
+		for _, key := range keys {
+			if err := storage.Delete(ctx, key); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete key %q: %w", key, err))
+			}
+		}
+	}
+
+	r.snapshots.clear()
+	r.forgetDenials()
+	return errors.Join(errs...)
+}
+
+// flushStorages returns the distinct backends holding state of this limiter
+func (r *RateLimiter) flushStorages() []backends.Backend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	storages := []backends.Backend{r.config.Storage}
+	for _, storage := range append([]backends.Backend{r.config.primaryStorage}, r.config.secondaryStorages...) {
+		if storage != nil && !slices.Contains(storages, storage) {
+			storages = append(storages, storage)
+		}
+	}
+	return storages
+}
@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/approx"
+	"github.com/ajiwo/ratelimit/strategies/concurrency"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/slidingwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/ajiwo/ratelimit/strategies/unique"
+)
+
+// HandlerTimeout bounds the backend health probe of Handler
+const HandlerTimeout = 5 * time.Second
+
+// Status is the JSON document served by Handler
+type Status struct {
+	Healthy bool           `json:"healthy"`           // The backend answered the probe and failover is not serving requests
+	Error   string         `json:"error,omitempty"`   // Why the limiter is unhealthy
+	Breaker *BreakerStatus `json:"breaker,omitempty"` // Set with WithMemoryFailover
+	Stats   Stats          `json:"stats"`
+	Limits  LimitsStatus   `json:"limits"`
+}
+
+// BreakerStatus is the circuit breaker state of WithMemoryFailover
+type BreakerStatus struct {
+	State    string `json:"state"` // "closed", "half_open" or "open"
+	Failures int    `json:"failures"`
+}
+
+// LimitsStatus describes the configured strategies.
+//
+// Strategies are described as in a Spec, without per-tier backends.
+type LimitsStatus struct {
+	BaseKey         string         `json:"base_key"`
+	Primary         StrategySpec   `json:"primary"`
+	Secondary       []StrategySpec `json:"secondary,omitempty"`
+	Any             []StrategySpec `json:"any,omitempty"`               // Tiers after the primary of WithAnyStrategy
+	SteadyStateRate *float64       `json:"steady_state_rate,omitempty"` // Omitted when no strategy limits the request rate
+}
+
+// Handler returns an HTTP handler serving the Status of the limiter as JSON,
+// to mount on an internal route such as /internal/ratelimit:
+//
+//	{"healthy":true,"stats":{"allowed":12,"denied":3,"errors":0,"cas_retries":0},
+//	 "limits":{"base_key":"api","primary":{"strategy":"fixed_window","quotas":[...]},"steady_state_rate":1.66}}
+//
+// Every request probes the backend with a read; the response status is 503
+// when the limiter is unhealthy and 200 otherwise. Only GET and HEAD are
+// served.
+func Handler(limiter *RateLimiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		status := limiter.status(req.Context())
+		code := http.StatusOK
+		if !status.Healthy {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		if req.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode(status)
+		}
+	})
+}
+
+// status returns the live Status of the limiter
+func (r *RateLimiter) status(ctx context.Context) Status {
+	status := Status{
+		Healthy: true,
+		Stats:   r.Stats(),
+		Limits:  r.limits(),
+	}
+
+	if failover, ok := r.Failover(); ok {
+		status.Breaker = &BreakerStatus{
+			State:    failover.BreakerState().String(),
+			Failures: failover.BreakerFailureCount(),
+		}
+	}
+
+	if err := r.ping(ctx); err != nil {
+		status.Healthy, status.Error = false, err.Error()
+	} else if r.degraded() {
+		status.Healthy, status.Error = false, "memory failover is serving requests"
+	}
+	return status
+}
+
+// ping reads a key of the limiter from the backend, bypassing any local cache
+func (r *RateLimiter) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(backends.FreshRead(ctx), HandlerTimeout)
+	defer cancel()
+	_, err := r.storage().Get(ctx, r.basePrefix+"health")
+	return err
+}
+
+// limits describes the configured strategies
+func (r *RateLimiter) limits() LimitsStatus {
+	r.mu.RLock()
+	limits := LimitsStatus{
+		BaseKey: r.config.BaseKey,
+		Primary: describeStrategy(r.config.PrimaryConfig),
+	}
+	if r.config.SecondaryConfig != nil {
+		limits.Secondary = append(limits.Secondary, describeStrategy(r.config.SecondaryConfig))
+	}
+	for _, config := range r.config.ExtraSecondaryConfigs {
+		limits.Secondary = append(limits.Secondary, describeStrategy(config))
+	}
+	for i, advisory := range r.config.advisorySecondaries {
+		limits.Secondary[i].Advisory = advisory
+	}
+	for _, config := range r.config.AnyConfigs {
+		limits.Any = append(limits.Any, describeStrategy(config))
+	}
+	r.mu.RUnlock()
+
+	if rate := r.SteadyStateRate(); !math.IsInf(rate, 1) {
+		limits.SteadyStateRate = &rate
+	}
+	return limits
+}
+
+// describeStrategy returns the spec of a strategy config, the inverse of
+// StrategySpec.config for the strategies a Spec can declare. Per-tier options
+// are left to the caller, and strategy backends are not described.
+func describeStrategy(config strategies.Config) StrategySpec {
+	spec := StrategySpec{Strategy: config.ID().String()}
+	switch c := config.(type) {
+	case *tokenbucket.Config:
+		spec.Burst, spec.Rate, spec.IdleTTL = c.Burst, c.Rate, Duration(c.IdleTTL)
+		if c.InitialTokens != nil {
+			tokens := *c.InitialTokens
+			spec.InitialTokens = &tokens
+		}
+	case *leakybucket.Config:
+		spec.Burst, spec.Rate, spec.IdleTTL = c.Burst, c.Rate, Duration(c.IdleTTL)
+	case *gcra.Config:
+		spec.Burst, spec.Rate, spec.IdleTTL = c.Burst, c.Rate, Duration(c.IdleTTL)
+	case *fixedwindow.Config:
+		for _, quota := range c.Quotas {
+			spec.Quotas = append(spec.Quotas, QuotaSpec{
+				Name:      quota.Name,
+				Limit:     quota.Limit,
+				Window:    Duration(quota.Window),
+				Aligned:   quota.Aligned,
+				SoftLimit: quota.SoftLimit,
+			})
+		}
+		if c.Location != nil {
+			spec.Location = c.Location.String()
+		}
+		spec.MaxClockSkew, spec.IdleTTL = Duration(c.MaxClockSkew), Duration(c.IdleTTL)
+		spec.IndependentQuotas, spec.AllowDuplicateRatios = c.IndependentQuotas, c.AllowDuplicateRatios
+	case *slidingwindow.Config:
+		spec.Limit, spec.Window = c.Limit, Duration(c.Window)
+	case *approx.Config:
+		spec.Limit, spec.Window, spec.Precision = c.Limit, Duration(c.Window), c.Precision
+	case *unique.Config:
+		spec.Limit, spec.Window = c.Limit, Duration(c.Window)
+	case *concurrency.Config:
+		spec.Max, spec.LeaseTTL = c.Max, Duration(c.LeaseTTL)
+	}
+	return spec
+}
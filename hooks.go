@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Operation identifies the limiter call that produced an Event
+type Operation string
+
+const (
+	OperationAllow Operation = "allow"
+	OperationPeek  Operation = "peek"
+)
+
+// Event describes a single limiting decision delivered to hooks
+type Event struct {
+	Operation Operation          // Limiter call that produced the event
+	Key       string             // Dynamic key, "default" when none was given, "#tenant:key" with a tenant
+	Allowed   bool               // Overall decision
+	Results   strategies.Results // Per-quota results, nil on error
+	Metadata  map[string]any     // AccessOptions.Metadata, passed through unchanged
+	Err       error              // Error returned to the caller, if any
+}
+
+// Hook is called synchronously after every Allow and Peek decision.
+//
+// Hooks run on the caller's goroutine and must be safe for concurrent use.
+// They must not modify the event's Results or Metadata. A panicking hook is
+// recovered and logged at Error, see WithLogger.
+type Hook func(ctx context.Context, event Event)
+
+// WithHook registers a hook that observes every limiting decision.
+//
+// May be given more than once; hooks are called in registration order.
+func WithHook(hook Hook) Option {
+	return func(config *Config) error {
+		if hook == nil {
+			return fmt.Errorf("hook cannot be nil")
+		}
+		config.hooks = append(config.hooks, hook)
+		return nil
+	}
+}
+
+// emit delivers the event to all registered hooks
+func (r *RateLimiter) emit(ctx context.Context, event Event) {
+	for _, hook := range r.hooks {
+		r.callHook(ctx, hook, event)
+	}
+}
+
+// callHook calls hook, recovering and logging a panic so that a faulty hook
+// fails neither the request nor the hooks after it
+func (r *RateLimiter) callHook(ctx context.Context, hook Hook, event Event) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.logger.Error(ctx, "ratelimit: hook panicked", "key", event.Key, "operation", event.Operation, "panic", v)
+		}
+	}()
+	hook(ctx, event)
+}
+
+// withMetadata echoes request metadata into every result
+func withMetadata(results strategies.Results, metadata map[string]any) {
+	if metadata == nil {
+		return
+	}
+	for name, res := range results {
+		res.Metadata = metadata
+		results[name] = res
+	}
+}
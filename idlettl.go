@@ -0,0 +1,20 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// withIdleTTL applies AccessOptions.IdleTTL to configs implementing
+// strategies.IdleTTLConfig, leaving other configs and non-positive idle TTLs
+// with the default expiration
+func withIdleTTL(config strategies.Config, idleTTL time.Duration) strategies.Config {
+	if idleTTL <= 0 {
+		return config
+	}
+	if ic, ok := config.(strategies.IdleTTLConfig); ok {
+		return ic.WithIdleTTL(idleTTL)
+	}
+	return config
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// KeyState is the decoded backend state of a key, see Inspect
+type KeyState struct {
+	Key   string             // Dynamic key, qualified with the tenant
+	Tiers []strategies.State // Stored state of every strategy tier, with a nil Value for tiers without state
+
+	// Penalty state with WithPenalty: denials since the key was last quiet,
+	// and the end of the last lockout, in the past once it is over
+	PenaltyStrikes int
+	PenaltyUntil   time.Time
+}
+
+// Inspect returns the decoded backend state of a key for troubleshooting,
+// e.g. why a user is blocked: fixed window counts and starts, bucket levels
+// and refill times, across all tiers, along with any penalty lockout.
+//
+// Unlike Peek, the state is reported as stored, without expiring windows or
+// refilling buckets up to now. Inspect reads fresh state, modifies nothing
+// and calls no hooks; allow and deny lists are not applied. Returns an error
+// wrapping strategies.ErrInspectNotSupported if a strategy cannot decode its
+// state.
+func (r *RateLimiter) Inspect(ctx context.Context, options AccessOptions) (*KeyState, error) {
+	dynamicKey, err := r.dynamicKey(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	ctx = backends.FreshRead(r.logContext(ctx))
+
+	inspector, ok := r.strategy.(strategies.Inspector)
+	if !ok {
+		return nil, fmt.Errorf("cannot inspect key: %w", strategies.ErrInspectNotSupported)
+	}
+	tiers, err := inspector.Inspect(ctx, r.buildStrategyConfig(dynamicKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect strategy: %w", err)
+	}
+
+	state := &KeyState{Key: dynamicKey, Tiers: tiers}
+	if r.penalty != nil {
+		penalty, _, _, err := r.activePenalty(ctx, dynamicKey, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		state.PenaltyStrikes, state.PenaltyUntil = penalty.strikes, penalty.until
+	}
+	return state, nil
+}
@@ -3,21 +3,27 @@ package composite
 import (
 	"sync/atomic"
 	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
 )
 
 // breakerState represents the circuit breaker state
-type breakerState int32
+type breakerState = backends.BreakerState
 
 const (
-	stateClosed breakerState = iota
-	stateHalfOpen
-	stateOpen
+	stateClosed   = backends.BreakerClosed
+	stateHalfOpen = backends.BreakerHalfOpen
+	stateOpen     = backends.BreakerOpen
 )
 
 // BreakerConfig holds configuration for circuit breaker
 type BreakerConfig struct {
 	FailureThreshold int32         // Number of failures before tripping
 	RecoveryTimeout  time.Duration // Time to wait before trying primary again
+
+	// OnStateChange, if set, is called synchronously once per state transition,
+	// on the goroutine that caused it. It must not block.
+	OnStateChange func(from, to backends.BreakerState)
 }
 
 // circuitBreaker implements circuit breaker pattern with 3 states using atomic operations
@@ -26,6 +32,7 @@ type circuitBreaker struct {
 	state        int32         // atomic, stores State value
 	failureCount int32         // atomic failure counter
 	openedAt     int64         // atomic, stores nanoseconds since Unix epoch
+	probedAt     int64         // atomic, start of the half-open probe in nanoseconds since Unix epoch
 }
 
 // newCircuitBreaker creates a new circuit breaker
@@ -53,7 +60,13 @@ func (cb *circuitBreaker) ShouldTrip(err error) bool {
 	return false
 }
 
-// IsOpen returns true if circuit is open (should use secondary backend)
+// IsOpen returns true if circuit is open (should use secondary backend).
+//
+// After the recovery timeout, the breaker goes HALF-OPEN and IsOpen returns
+// false to a single caller, whose operation probes the primary: success
+// closes the breaker, failure opens it again. Everyone else keeps using the
+// secondary meanwhile. A probe that never reports back is replaced after
+// another recovery timeout.
 func (cb *circuitBreaker) IsOpen() bool {
 	currentState := breakerState(atomic.LoadInt32(&cb.state))
 
@@ -62,29 +75,55 @@ func (cb *circuitBreaker) IsOpen() bool {
 		// Check if recovery timeout has passed
 		openedAtNano := atomic.LoadInt64(&cb.openedAt)
 		if time.Since(time.Unix(0, openedAtNano)) >= cb.config.RecoveryTimeout {
-			// Try to transition to HALF-OPEN state
+			// Try to transition to HALF-OPEN state, the winner probes
+			atomic.StoreInt64(&cb.probedAt, time.Now().UnixNano())
 			if atomic.CompareAndSwapInt32(&cb.state, int32(stateOpen), int32(stateHalfOpen)) {
+				cb.transitioned(stateOpen, stateHalfOpen)
 				return false
 			}
 		}
 		return true
 	case stateHalfOpen:
-		return false
+		return !cb.claimStaleProbe()
 	default: // StateClosed
 		return false
 	}
 }
 
+// claimStaleProbe starts a new probe if the current one started more than a
+// recovery timeout ago
+func (cb *circuitBreaker) claimStaleProbe() bool {
+	probedAtNano := atomic.LoadInt64(&cb.probedAt)
+	if time.Since(time.Unix(0, probedAtNano)) < cb.config.RecoveryTimeout {
+		return false
+	}
+	return atomic.CompareAndSwapInt64(&cb.probedAt, probedAtNano, time.Now().UnixNano())
+}
+
 // Open trips the circuit breaker to OPEN state
 func (cb *circuitBreaker) Open() {
-	atomic.StoreInt32(&cb.state, int32(stateOpen))
 	atomic.StoreInt64(&cb.openedAt, time.Now().UnixNano())
+	from := breakerState(atomic.SwapInt32(&cb.state, int32(stateOpen)))
+	cb.transitioned(from, stateOpen)
 }
 
 // Close resets the circuit breaker to CLOSED state
 func (cb *circuitBreaker) Close() {
-	atomic.StoreInt32(&cb.state, int32(stateClosed))
 	atomic.StoreInt32(&cb.failureCount, 0)
+	from := breakerState(atomic.SwapInt32(&cb.state, int32(stateClosed)))
+	cb.transitioned(from, stateClosed)
+}
+
+// transitioned reports a state change to the OnStateChange hook
+func (cb *circuitBreaker) transitioned(from, to breakerState) {
+	if from != to && cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(from, to)
+	}
+}
+
+// openedAtNano returns when the breaker last opened, in nanoseconds since Unix epoch
+func (cb *circuitBreaker) openedAtNano() int64 {
+	return atomic.LoadInt64(&cb.openedAt)
 }
 
 // GetState returns current circuit breaker state
@@ -1,9 +1,11 @@
 package composite
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/synctest"
 	"time"
@@ -109,7 +111,7 @@ func TestCircuitBreaker_HalfOpenState(t *testing.T) {
 		ctx := t.Context()
 
 		// Trip the circuit breaker
-		primary.setFail(true, errors.New("primary failed"))
+		primary.setFail(true, errPrimaryDown)
 		err = composite.Set(ctx, "key1", "value1", time.Minute)
 		assert.NoError(t, err) // Should succeed via secondary and trip circuit
 		assert.Equal(t, stateOpen, composite.GetCircuitBreakerState())
@@ -150,7 +152,7 @@ func TestCircuitBreaker_ConcurrentStateTransitions(t *testing.T) {
 	defer composite.Close()
 
 	ctx := t.Context()
-	primary.setFail(true, errors.New("primary failed"))
+	primary.setFail(true, errPrimaryDown)
 
 	const numGoroutines = 10
 	var wg sync.WaitGroup
@@ -180,3 +182,157 @@ func TestCircuitBreaker_ConcurrentStateTransitions(t *testing.T) {
 		assert.NotEmpty(t, keys)
 	}
 }
+
+func TestCircuitBreaker_StateHook(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var transitions []string
+		cb := newCircuitBreaker(BreakerConfig{
+			FailureThreshold: 2,
+			RecoveryTimeout:  time.Second,
+			OnStateChange: func(from, to breakerState) {
+				transitions = append(transitions, from.String()+">"+to.String())
+			},
+		})
+		fail := errors.New("fail")
+
+		// Failures below the threshold and repeated trips are not transitions
+		cb.ShouldTrip(fail)
+		assert.Empty(t, transitions)
+		cb.ShouldTrip(fail)
+		cb.ShouldTrip(fail)
+		assert.True(t, cb.IsOpen())
+		assert.Equal(t, []string{"closed>open"}, transitions)
+
+		time.Sleep(time.Second)
+		assert.False(t, cb.IsOpen())
+		assert.True(t, cb.IsOpen(), "only one caller probes")
+		cb.ShouldTrip(fail)
+		assert.Equal(t, []string{"closed>open", "open>half_open", "half_open>open"}, transitions)
+
+		time.Sleep(time.Second)
+		assert.False(t, cb.IsOpen())
+		cb.Close()
+		cb.Close()
+		assert.Equal(t, []string{"closed>open", "open>half_open", "half_open>open", "open>half_open", "half_open>closed"}, transitions)
+	})
+}
+
+// probedBackend counts the Set calls reaching a mock backend, optionally
+// holding them until gate is closed
+type probedBackend struct {
+	*mockBackend
+	sets atomic.Int32
+	gate chan struct{}
+}
+
+func (p *probedBackend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	p.sets.Add(1)
+	if p.gate != nil {
+		<-p.gate
+	}
+	return p.mockBackend.Set(ctx, key, value, expiration)
+}
+
+// newProbedComposite returns a composite tripped open by a failing primary,
+// without health checks so that only probes can close it
+func newProbedComposite(t *testing.T) (*Backend, *probedBackend, *mockBackend) {
+	t.Helper()
+	primary := &probedBackend{mockBackend: newMockBackend()}
+	secondary := newMockBackend()
+	composite, err := New(Config{
+		Primary:        primary,
+		Secondary:      secondary,
+		CircuitBreaker: BreakerConfig{FailureThreshold: 1, RecoveryTimeout: time.Second},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = composite.Close() })
+
+	primary.setFail(true, errPrimaryDown)
+	require.NoError(t, composite.Set(t.Context(), "trip", "1", time.Minute))
+	require.Equal(t, stateOpen, composite.GetCircuitBreakerState())
+	primary.sets.Store(0)
+	return composite, primary, secondary
+}
+
+func TestCompositeBackend_HalfOpenProbe(t *testing.T) {
+	t.Run("failed probe stays open", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			composite, primary, secondary := newProbedComposite(t)
+			ctx := t.Context()
+
+			time.Sleep(time.Second)
+			require.NoError(t, composite.Set(ctx, "probe", "1", time.Minute))
+			assert.Equal(t, int32(1), primary.sets.Load())
+			assert.Equal(t, stateOpen, composite.GetCircuitBreakerState())
+			assert.Equal(t, "1", secondary.data["probe"], "the failed probe falls back to the secondary")
+
+			// The next probe waits for another recovery timeout
+			require.NoError(t, composite.Set(ctx, "after", "1", time.Minute))
+			assert.Equal(t, int32(1), primary.sets.Load())
+		})
+	})
+
+	t.Run("successful probe closes", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			composite, primary, _ := newProbedComposite(t)
+			ctx := t.Context()
+
+			primary.setFail(false, nil)
+			require.NoError(t, composite.Set(ctx, "early", "1", time.Minute))
+			assert.Zero(t, primary.sets.Load(), "no probe before the recovery timeout")
+
+			time.Sleep(time.Second)
+			require.NoError(t, composite.Set(ctx, "probe", "1", time.Minute))
+			assert.Equal(t, stateClosed, composite.GetCircuitBreakerState())
+			require.NoError(t, composite.Set(ctx, "after", "1", time.Minute))
+			assert.Equal(t, int32(2), primary.sets.Load())
+		})
+	})
+
+	t.Run("concurrent operations during the probe", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			composite, primary, secondary := newProbedComposite(t)
+			ctx := t.Context()
+			primary.setFail(false, nil)
+			primary.gate = make(chan struct{})
+
+			time.Sleep(time.Second)
+			var wg sync.WaitGroup
+			for i := range 10 {
+				wg.Go(func() {
+					assert.NoError(t, composite.Set(ctx, fmt.Sprintf("key-%d", i), "1", time.Minute))
+				})
+			}
+			synctest.Wait()
+
+			// One operation probes the primary, the others are served by the secondary
+			assert.Equal(t, int32(1), primary.sets.Load())
+			assert.Equal(t, stateHalfOpen, composite.GetCircuitBreakerState())
+			secondary.mu.RLock()
+			assert.Len(t, secondary.data, 1+9)
+			secondary.mu.RUnlock()
+
+			close(primary.gate)
+			wg.Wait()
+			assert.Equal(t, stateClosed, composite.GetCircuitBreakerState())
+		})
+	})
+
+	t.Run("stale probe is replaced", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			composite, primary, _ := newProbedComposite(t)
+			ctx := t.Context()
+
+			time.Sleep(time.Second)
+			require.False(t, composite.circuitBreaker.IsOpen(), "first caller probes")
+			assert.True(t, composite.circuitBreaker.IsOpen(), "probe in flight")
+
+			// The probe never reported back
+			time.Sleep(time.Second)
+			primary.setFail(false, nil)
+			require.NoError(t, composite.Set(ctx, "probe", "1", time.Minute))
+			assert.Equal(t, int32(1), primary.sets.Load())
+			assert.Equal(t, stateClosed, composite.GetCircuitBreakerState())
+		})
+	})
+}
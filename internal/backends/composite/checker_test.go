@@ -1,7 +1,6 @@
 package composite
 
 import (
-	"errors"
 	"testing"
 	"time"
 
@@ -34,7 +33,7 @@ func TestHealthChecker_FailuresAndRecovery(t *testing.T) {
 	ctx := t.Context()
 
 	// Trip circuit breaker initially
-	primary.setFail(true, errors.New("primary failed"))
+	primary.setFail(true, errPrimaryDown)
 	err = composite.Set(ctx, "test", "value", time.Minute)
 	assert.NoError(t, err) // Should succeed via secondary
 	assert.Equal(t, stateOpen, composite.GetCircuitBreakerState())
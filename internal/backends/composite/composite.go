@@ -3,6 +3,8 @@ package composite
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ajiwo/ratelimit/backends"
@@ -18,6 +20,16 @@ type Config struct {
 	Secondary      backends.Backend // Secondary/fallback backend
 	CircuitBreaker BreakerConfig    // Circuit breaker configuration
 	HealthChecker  CheckerConfig    // Health check configuration (alias for backward compatibility)
+
+	// IsFailure reports whether a primary error counts toward tripping the
+	// breaker. Other errors are returned as is. Nil uses backends.IsBackendFailure.
+	IsFailure func(error) bool
+
+	// ReconcileOnRecovery copies the keys written to the secondary while the
+	// breaker was not closed to the primary when it closes, so state from the
+	// outage is not lost. At most 10000 keys are tracked, and they are copied
+	// in the background within reconcileTimeout, see closeBreaker.
+	ReconcileOnRecovery bool
 }
 
 // Backend provides automatic failover capability for rate limiting storage
@@ -27,6 +39,13 @@ type Backend struct {
 	secondary      backends.Backend
 	circuitBreaker *circuitBreaker
 	healthChecker  *healthchecker.Checker
+	isFailure      func(error) bool
+	pending        *pendingWrites // nil unless ReconcileOnRecovery
+
+	reconciling     atomic.Bool     // a reconciliation goroutine is running, see closeBreaker
+	reconciler      sync.WaitGroup  // the reconciliation goroutine
+	stopReconcile   context.Context // canceled by Close
+	cancelReconcile context.CancelFunc
 }
 
 // New creates a new composite backend
@@ -59,6 +78,14 @@ func New(config Config) (*Backend, error) {
 		primary:        config.Primary,
 		secondary:      config.Secondary,
 		circuitBreaker: newCircuitBreaker(config.CircuitBreaker),
+		isFailure:      config.IsFailure,
+	}
+	if config.ReconcileOnRecovery {
+		composite.pending = newPendingWrites()
+	}
+	composite.stopReconcile, composite.cancelReconcile = context.WithCancel(context.Background())
+	if composite.isFailure == nil {
+		composite.isFailure = backends.IsBackendFailure
 	}
 
 	// Initialize health checker
@@ -83,14 +110,14 @@ func (c *Backend) Get(ctx context.Context, key string) (string, error) {
 
 	// Try primary first
 	result, err := c.primary.Get(ctx, key)
-	if c.circuitBreaker.ShouldTrip(err) {
+	if c.shouldTrip(err) {
 		// Circuit breaker was tripped, use secondary
 		return c.secondary.Get(ctx, key)
 	}
 
 	// Circuit breaker in HALF-OPEN - test succeeded
 	if c.circuitBreaker.GetState() == stateHalfOpen {
-		c.circuitBreaker.Close()
+		c.closeBreaker()
 	}
 
 	return result, err
@@ -100,19 +127,22 @@ func (c *Backend) Get(ctx context.Context, key string) (string, error) {
 func (c *Backend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
 	if c.circuitBreaker.IsOpen() {
 		// Circuit is open - use secondary
-		return c.secondary.Set(ctx, key, value, expiration)
+		return c.setSecondary(ctx, key, value, expiration)
 	}
 
 	// Try primary first
 	err := c.primary.Set(ctx, key, value, expiration)
-	if c.circuitBreaker.ShouldTrip(err) {
+	if c.shouldTrip(err) {
 		// Circuit breaker was tripped, use secondary
-		return c.secondary.Set(ctx, key, value, expiration)
+		return c.setSecondary(ctx, key, value, expiration)
+	}
+	if err == nil {
+		c.pending.forget(key)
 	}
 
 	// Circuit breaker in HALF-OPEN - test succeeded
 	if c.circuitBreaker.GetState() == stateHalfOpen {
-		c.circuitBreaker.Close()
+		c.closeBreaker()
 	}
 
 	return err
@@ -122,19 +152,22 @@ func (c *Backend) Set(ctx context.Context, key string, value string, expiration
 func (c *Backend) CheckAndSet(ctx context.Context, key string, expected string, newValue string, expiration time.Duration) (bool, error) {
 	if c.circuitBreaker.IsOpen() {
 		// Circuit is open - use secondary
-		return c.secondary.CheckAndSet(ctx, key, expected, newValue, expiration)
+		return c.checkAndSetSecondary(ctx, key, expected, newValue, expiration)
 	}
 
 	// Try primary first
 	result, err := c.primary.CheckAndSet(ctx, key, expected, newValue, expiration)
-	if c.circuitBreaker.ShouldTrip(err) {
+	if c.shouldTrip(err) {
 		// Circuit breaker was tripped, use secondary
-		return c.secondary.CheckAndSet(ctx, key, expected, newValue, expiration)
+		return c.checkAndSetSecondary(ctx, key, expected, newValue, expiration)
+	}
+	if result && err == nil {
+		c.pending.forget(key)
 	}
 
 	// Circuit breaker in HALF-OPEN - primary test succeeded
 	if c.circuitBreaker.GetState() == stateHalfOpen {
-		c.circuitBreaker.Close()
+		c.closeBreaker()
 	}
 
 	return result, err
@@ -144,30 +177,62 @@ func (c *Backend) CheckAndSet(ctx context.Context, key string, expected string,
 func (c *Backend) Delete(ctx context.Context, key string) error {
 	if c.circuitBreaker.IsOpen() {
 		// Circuit is open - use secondary
-		return c.secondary.Delete(ctx, key)
+		return c.deleteSecondary(ctx, key)
 	}
 
 	// Try primary first
 	err := c.primary.Delete(ctx, key)
-	if c.circuitBreaker.ShouldTrip(err) {
+	if c.shouldTrip(err) {
 		// Circuit breaker was tripped, use secondary
-		return c.secondary.Delete(ctx, key)
+		return c.deleteSecondary(ctx, key)
+	}
+	if err == nil {
+		c.pending.forget(key)
 	}
 
 	// Circuit breaker in HALF-OPEN - test succeeded
 	if c.circuitBreaker.GetState() == stateHalfOpen {
-		c.circuitBreaker.Close()
+		c.closeBreaker()
 	}
 
 	return err
 }
 
+// setSecondary stores value in the secondary backend, tracking the key for reconciliation
+func (c *Backend) setSecondary(ctx context.Context, key string, value string, expiration time.Duration) error {
+	err := c.secondary.Set(ctx, key, value, expiration)
+	if err == nil {
+		c.pending.track(key, expiration)
+	}
+	return err
+}
+
+// checkAndSetSecondary compares and sets in the secondary backend, tracking the key for reconciliation
+func (c *Backend) checkAndSetSecondary(ctx context.Context, key string, expected string, newValue string, expiration time.Duration) (bool, error) {
+	ok, err := c.secondary.CheckAndSet(ctx, key, expected, newValue, expiration)
+	if ok && err == nil {
+		c.pending.track(key, expiration)
+	}
+	return ok, err
+}
+
+// deleteSecondary removes key from the secondary backend, tracking the key for reconciliation
+func (c *Backend) deleteSecondary(ctx context.Context, key string) error {
+	err := c.secondary.Delete(ctx, key)
+	if err == nil {
+		c.pending.track(key, 0)
+	}
+	return err
+}
+
 // Close closes both backends and stops health monitoring
 func (c *Backend) Close() error {
-	// Stop health monitoring
+	// Stop health monitoring and any reconciliation
 	if c.healthChecker != nil {
 		c.healthChecker.Stop()
 	}
+	c.cancelReconcile()
+	c.reconciler.Wait()
 
 	// Close both backends
 	var primaryErr, secondaryErr error
@@ -185,15 +250,71 @@ func (c *Backend) Close() error {
 	return secondaryErr
 }
 
+// Keys lists keys from the backend currently serving requests.
+//
+// Returns backends.ErrKeysNotSupported if that backend is not a backends.Lister.
+func (c *Backend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	// Listing never probes a half-open breaker, it has no outcome to report
+	active := c.primary
+	if c.circuitBreaker.GetState() != stateClosed {
+		active = c.secondary
+	}
+
+	lister, ok := active.(backends.Lister)
+	if !ok {
+		return nil, backends.ErrKeysNotSupported
+	}
+	return lister.Keys(ctx, pattern)
+}
+
+// CleanupLocks removes the idle per-key locks of both backends, see
+// backends.LockCleaner
+func (c *Backend) CleanupLocks(maxAge time.Duration) int {
+	removed := 0
+	for _, backend := range []backends.Backend{c.primary, c.secondary} {
+		if cleaner, ok := backend.(backends.LockCleaner); ok {
+			removed += cleaner.CleanupLocks(maxAge)
+		}
+	}
+	return removed
+}
+
+// shouldTrip counts err toward tripping the breaker if it is a failure, and
+// reports whether the breaker tripped
+func (c *Backend) shouldTrip(err error) bool {
+	if err == nil || !c.isFailure(err) {
+		return false
+	}
+	return c.circuitBreaker.ShouldTrip(err)
+}
+
 // onPrimaryHealthy is called when health checker detects primary is healthy
 func (c *Backend) onPrimaryHealthy() {
 	// Reset circuit breaker if it's open
 	if c.circuitBreaker.GetState() == stateOpen {
-		c.circuitBreaker.Close()
+		c.closeBreaker()
 	}
 }
 
-// GetCircuitBreakerState returns current circuit breaker state (for monitoring)
+// Degraded reports whether the circuit breaker is open or half-open, with
+// operations served by the secondary backend except for a single probe
+func (c *Backend) Degraded() bool {
+	return c.circuitBreaker.GetState() != stateClosed
+}
+
+// BreakerState returns the current circuit breaker state, implementing backends.Failover
+func (c *Backend) BreakerState() backends.BreakerState {
+	return c.circuitBreaker.GetState()
+}
+
+// BreakerFailureCount returns the primary failures counted since the breaker
+// last closed, implementing backends.Failover
+func (c *Backend) BreakerFailureCount() int {
+	return int(c.circuitBreaker.GetFailureCount())
+}
+
+// GetCircuitBreakerState returns current circuit breaker state (for monitoring),
+// HALF-OPEN while a probe tests the primary
 func (c *Backend) GetCircuitBreakerState() breakerState {
 	return c.circuitBreaker.GetState()
 }
@@ -8,10 +8,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ajiwo/ratelimit/backends"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// errPrimaryDown is a primary outage, as reported by the backend
+var errPrimaryDown = backends.NewHealthError("mock:Get", errors.New("primary failed"))
+
 // mockBackend implements backends.Backend for testing
 type mockBackend struct {
 	data    map[string]string
@@ -19,6 +23,8 @@ type mockBackend struct {
 	closed  bool
 	fail    bool
 	failErr error
+
+	missingEmpty bool // missing keys read as "" like real backends, instead of an error
 }
 
 func newMockBackend() *mockBackend {
@@ -39,7 +45,7 @@ func (m *mockBackend) Get(ctx context.Context, key string) (string, error) {
 	}
 
 	val, exists := m.data[key]
-	if !exists {
+	if !exists && !m.missingEmpty {
 		return "", errors.New("key not found")
 	}
 	return val, nil
@@ -220,7 +226,7 @@ func TestCompositeBackend_Failover(t *testing.T) {
 	ctx := t.Context()
 
 	// Make primary fail
-	primary.setFail(true, errors.New("primary failed"))
+	primary.setFail(true, errPrimaryDown)
 
 	// First failure should not trip circuit breaker yet
 	err = composite.Set(ctx, "test", "value", time.Minute)
@@ -271,7 +277,7 @@ func TestCompositeBackend_Recovery(t *testing.T) {
 	ctx := t.Context()
 
 	// Make primary fail to trip circuit breaker
-	primary.setFail(true, errors.New("primary failed"))
+	primary.setFail(true, errPrimaryDown)
 	err = composite.Set(ctx, "test", "value", time.Minute)
 	assert.NoError(t, err) // Should succeed via secondary after failure threshold is reached
 	assert.Equal(t, stateOpen, composite.GetCircuitBreakerState())
@@ -476,7 +482,7 @@ func TestCompositeBackend_ContextCancellation(t *testing.T) {
 		defer composite.Close()
 
 		// Make primary fail to trigger failover to secondary
-		primary.setFail(true, errors.New("primary failed"))
+		primary.setFail(true, errPrimaryDown)
 
 		// This should succeed via secondary and trip the circuit
 		err = composite.Set(t.Context(), "test-key", "test-value", time.Minute)
@@ -522,7 +528,7 @@ func TestCompositeBackend_StateFragmentation(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Trip circuit breaker
-	primary.setFail(true, errors.New("primary failed"))
+	primary.setFail(true, errPrimaryDown)
 	err = composite.Set(ctx, "key2", "during-failover", time.Minute)
 	assert.NoError(t, err) // Goes to secondary
 	assert.Equal(t, stateOpen, composite.GetCircuitBreakerState())
@@ -564,6 +570,12 @@ func TestCompositeBackend_ErrorClassification(t *testing.T) {
 			expectCircuitTrip: true,
 			description:       "Timeout errors should trip circuit breaker",
 		},
+		{
+			name:              "operational error does not trip",
+			primaryError:      errors.New("invalid value"),
+			expectCircuitTrip: false,
+			description:       "Errors of a responding backend are returned without tripping",
+		},
 		{
 			name:              "nil error should not trip",
 			primaryError:      nil,
@@ -610,6 +622,57 @@ func TestCompositeBackend_ErrorClassification(t *testing.T) {
 	}
 }
 
+func TestCompositeBackend_IsFailure(t *testing.T) {
+	errInvalid := errors.New("invalid value")
+	newComposite := func(t *testing.T, isFailure func(error) bool) (*Backend, *mockBackend) {
+		primary := newMockBackend()
+		composite, err := New(Config{
+			Primary:        primary,
+			Secondary:      newMockBackend(),
+			CircuitBreaker: BreakerConfig{FailureThreshold: 2, RecoveryTimeout: time.Minute},
+			IsFailure:      isFailure,
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = composite.Close() })
+		return composite, primary
+	}
+
+	t.Run("default", func(t *testing.T) {
+		composite, primary := newComposite(t, nil)
+		ctx := t.Context()
+
+		// Non-failures are returned without counting toward the threshold
+		primary.setFail(true, errInvalid)
+		for range 3 {
+			err := composite.Set(ctx, "key", "1", time.Minute)
+			assert.ErrorIs(t, err, errInvalid)
+		}
+		assert.Equal(t, int32(0), composite.GetCircuitBreakerFailureCount())
+		assert.Equal(t, stateClosed, composite.GetCircuitBreakerState())
+
+		// A connection error trips the breaker
+		primary.setFail(true, errPrimaryDown)
+		assert.ErrorIs(t, composite.Set(ctx, "key", "1", time.Minute), errPrimaryDown)
+		assert.Equal(t, int32(1), composite.GetCircuitBreakerFailureCount())
+		assert.NoError(t, composite.Set(ctx, "key", "1", time.Minute))
+		assert.Equal(t, stateOpen, composite.GetCircuitBreakerState())
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		composite, primary := newComposite(t, func(err error) bool { return errors.Is(err, errInvalid) })
+		ctx := t.Context()
+
+		primary.setFail(true, errPrimaryDown)
+		assert.ErrorIs(t, composite.Set(ctx, "key", "1", time.Minute), errPrimaryDown)
+		assert.Equal(t, int32(0), composite.GetCircuitBreakerFailureCount())
+
+		primary.setFail(true, errInvalid)
+		assert.Error(t, composite.Set(ctx, "key", "1", time.Minute))
+		assert.NoError(t, composite.Set(ctx, "key", "1", time.Minute))
+		assert.Equal(t, stateOpen, composite.GetCircuitBreakerState())
+	})
+}
+
 func TestCompositeBackend_FailureCounter(t *testing.T) {
 	primary := newMockBackend()
 	secondary := newMockBackend()
@@ -639,7 +702,7 @@ func TestCompositeBackend_FailureCounter(t *testing.T) {
 	t.Logf("Failure threshold: %d", config.CircuitBreaker.FailureThreshold)
 
 	// Make primary fail
-	primary.setFail(true, errors.New("primary failed"))
+	primary.setFail(true, errPrimaryDown)
 	t.Log("> Primary backend set to fail mode")
 
 	// Track the failure counter step by step
@@ -700,7 +763,7 @@ func TestCompositeBackend_FailureCounter(t *testing.T) {
 		assert.Error(t, err, "Primary should NOT have key %s", key)
 		t.Logf("> Primary does NOT have %s (as expected)", key)
 	}
-	primary.setFail(true, errors.New("primary failed")) // Re-enable failure
+	primary.setFail(true, errPrimaryDown) // Re-enable failure
 
 	t.Log("\n=== Recovery Test ===")
 
@@ -0,0 +1,172 @@
+package composite
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// reconcileMaxKeys bounds the number of keys tracked for reconciliation
+const reconcileMaxKeys = 10000
+
+// reconcileTimeout bounds a reconciliation, keys not copied by then are dropped
+const reconcileTimeout = 30 * time.Second
+
+// pendingWrites tracks keys written to the secondary backend, with their
+// expiry, until they are copied to the primary. A nil *pendingWrites tracks
+// nothing.
+type pendingWrites struct {
+	mu       sync.Mutex
+	maxKeys  int
+	keys     map[string]time.Time // zero expiry means the key does not expire
+	draining map[string]struct{}  // drained keys not written to the primary since, nil outside a reconciliation
+}
+
+func newPendingWrites() *pendingWrites {
+	return &pendingWrites{maxKeys: reconcileMaxKeys, keys: make(map[string]time.Time)}
+}
+
+// track records a write of key to the secondary. Keys beyond maxKeys are not tracked.
+func (p *pendingWrites) track(key string, expiration time.Duration) {
+	if p == nil {
+		return
+	}
+	var expiresAt time.Time
+	if expiration > 0 {
+		expiresAt = time.Now().Add(expiration)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.keys[key]; ok || len(p.keys) < p.maxKeys {
+		p.keys[key] = expiresAt
+	}
+}
+
+// forget drops key after a write to the primary, which is then newer than the secondary
+func (p *pendingWrites) forget(key string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.keys, key)
+	delete(p.draining, key)
+}
+
+// drain returns and clears the tracked keys
+func (p *pendingWrites) drain() map[string]time.Time {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys := p.keys
+	p.keys = make(map[string]time.Time)
+	return keys
+}
+
+// drainReconciling drains the tracked keys and watches them for primary
+// writes until doneReconciling, see stillReconciling
+func (p *pendingWrites) drainReconciling() map[string]time.Time {
+	keys := p.drain()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = make(map[string]struct{}, len(keys))
+	for key := range keys {
+		p.draining[key] = struct{}{}
+	}
+	return keys
+}
+
+// stillReconciling reports whether the drained key was not written to the
+// primary since the drain
+func (p *pendingWrites) stillReconciling(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.draining[key]
+	return ok
+}
+
+// doneReconciling stops watching the drained keys
+func (p *pendingWrites) doneReconciling() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = nil
+}
+
+// reconcile copies the keys written to the secondary during the outage to the
+// primary: current values with their remaining expiration, and deletes for
+// keys gone from the secondary. Errors are skipped, copies are best-effort.
+//
+// Every copy is a CheckAndSet against the primary value read before it, and
+// keys written to the primary since the drain are skipped, so a request
+// reaching the primary meanwhile is never overwritten by older outage state.
+func (c *Backend) reconcile(ctx context.Context) {
+	keys := c.pending.drainReconciling()
+	defer c.pending.doneReconciling()
+	for key, expiresAt := range keys {
+		if ctx.Err() != nil {
+			return
+		}
+
+		current, err := c.primary.Get(ctx, key)
+		if err != nil || !c.pending.stillReconciling(key) {
+			continue
+		}
+
+		var expiration time.Duration
+		var value string
+		if !expiresAt.IsZero() {
+			expiration = time.Until(expiresAt)
+		}
+		if expiresAt.IsZero() || expiration > 0 {
+			if value, err = c.secondary.Get(ctx, key); err != nil {
+				continue
+			}
+		}
+
+		switch {
+		case value == current:
+		case value == "":
+			// Backends have no compare-and-delete, the check above keeps the window short
+			_ = c.primary.Delete(ctx, key)
+		default:
+			_, _ = c.primary.CheckAndSet(ctx, key, current, value, expiration)
+		}
+	}
+}
+
+// closeBreaker closes the breaker, first copying the outage writes to the primary
+// when ReconcileOnRecovery is set.
+//
+// Reconciliation runs off the request path in a single background goroutine,
+// bounded by reconcileTimeout: keys are copied while operations still go to
+// the secondary, then once more after closing for writes that landed on the
+// secondary in the meantime. The breaker is left as is if a probe opened it
+// again during the first pass.
+func (c *Backend) closeBreaker() {
+	if c.pending == nil {
+		c.circuitBreaker.Close()
+		return
+	}
+	if !c.reconciling.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.reconciler.Add(1)
+	go func() {
+		defer c.reconciler.Done()
+		defer c.reconciling.Store(false)
+
+		ctx, cancel := context.WithTimeout(c.stopReconcile, reconcileTimeout)
+		defer cancel()
+		openedAt := c.circuitBreaker.openedAtNano()
+		c.reconcile(ctx)
+		if c.circuitBreaker.openedAtNano() != openedAt {
+			return
+		}
+		c.circuitBreaker.Close()
+		c.reconcile(ctx)
+	}()
+}
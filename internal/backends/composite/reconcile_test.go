@@ -0,0 +1,147 @@
+package composite
+
+import (
+	"context"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeBackend_ReconcileOnRecovery(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		primary := &probedBackend{mockBackend: newMockBackend()}
+		primary.missingEmpty = true
+		secondary := memory.New()
+		composite, err := New(Config{
+			Primary:             primary,
+			Secondary:           secondary,
+			CircuitBreaker:      BreakerConfig{FailureThreshold: 1, RecoveryTimeout: time.Second},
+			ReconcileOnRecovery: true,
+		})
+		require.NoError(t, err)
+		defer composite.Close()
+		ctx := t.Context()
+
+		primary.data["stale"] = "before"
+		primary.data["deleted"] = "before"
+		primary.data["untouched"] = "before"
+
+		// Writes during the outage go to the secondary
+		primary.setFail(true, errPrimaryDown)
+		require.NoError(t, composite.Set(ctx, "stale", "outage", time.Minute))
+		ok, err := composite.CheckAndSet(ctx, "new", "", "outage", time.Minute)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NoError(t, composite.Set(ctx, "expired", "outage", time.Millisecond))
+		require.NoError(t, composite.Set(ctx, "probe", "outage", time.Minute))
+		require.NoError(t, composite.Delete(ctx, "deleted"))
+		require.Equal(t, stateOpen, composite.GetCircuitBreakerState())
+
+		// The probe write is newer than the outage write of the same key
+		primary.setFail(false, nil)
+		time.Sleep(time.Second)
+		require.NoError(t, composite.Set(ctx, "probe", "recovered", time.Minute))
+
+		// The breaker closes once the background reconciliation is done
+		synctest.Wait()
+		require.Equal(t, stateClosed, composite.GetCircuitBreakerState())
+
+		assert.Equal(t, map[string]string{
+			"stale":     "outage",
+			"new":       "outage",
+			"probe":     "recovered",
+			"untouched": "before",
+		}, primary.data)
+		value, err := composite.Get(ctx, "new")
+		require.NoError(t, err)
+		assert.Equal(t, "outage", value)
+		assert.Empty(t, composite.pending.drain())
+	})
+}
+
+// racingBackend writes fresh to race right after reading it, like a request
+// reaching the primary while the key is reconciled
+type racingBackend struct {
+	*mockBackend
+	race string
+}
+
+func (r *racingBackend) Get(ctx context.Context, key string) (string, error) {
+	value, err := r.mockBackend.Get(ctx, key)
+	if key == r.race {
+		_ = r.mockBackend.Set(ctx, key, "fresh", time.Minute)
+	}
+	return value, err
+}
+
+func TestCompositeBackend_ReconcileSkipsNewerPrimaryWrites(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		primary := &racingBackend{mockBackend: newMockBackend(), race: "raced"}
+		primary.missingEmpty = true
+		composite, err := New(Config{
+			Primary:             primary,
+			Secondary:           memory.New(),
+			CircuitBreaker:      BreakerConfig{FailureThreshold: 1, RecoveryTimeout: time.Second},
+			ReconcileOnRecovery: true,
+		})
+		require.NoError(t, err)
+		defer composite.Close()
+		ctx := t.Context()
+
+		primary.setFail(true, errPrimaryDown)
+		require.NoError(t, composite.Set(ctx, "raced", "outage", time.Minute))
+		require.NoError(t, composite.Set(ctx, "copied", "outage", time.Minute))
+		primary.setFail(false, nil)
+
+		time.Sleep(time.Second)
+		composite.onPrimaryHealthy()
+		synctest.Wait()
+		require.Equal(t, stateClosed, composite.GetCircuitBreakerState())
+		assert.Equal(t, "fresh", primary.data["raced"], "the CheckAndSet loses to the newer write")
+		assert.Equal(t, "outage", primary.data["copied"])
+	})
+}
+
+func TestPendingWrites_PrimaryWriteDuringReconciliation(t *testing.T) {
+	pending := newPendingWrites()
+	pending.track("a", 0)
+	pending.track("b", 0)
+
+	keys := pending.drainReconciling()
+	assert.Len(t, keys, 2)
+	pending.forget("a")
+	assert.False(t, pending.stillReconciling("a"), "written to the primary since the drain")
+	assert.True(t, pending.stillReconciling("b"))
+
+	pending.doneReconciling()
+	assert.False(t, pending.stillReconciling("b"))
+}
+
+func TestCompositeBackend_ReconcileDisabled(t *testing.T) {
+	composite, primary, _ := newProbedComposite(t)
+	ctx := t.Context()
+
+	require.NoError(t, composite.Set(ctx, "outage", "1", time.Minute))
+	composite.onPrimaryHealthy()
+	require.Equal(t, stateClosed, composite.GetCircuitBreakerState())
+	assert.NotContains(t, primary.data, "outage")
+}
+
+func TestPendingWrites_MaxKeys(t *testing.T) {
+	pending := newPendingWrites()
+	pending.maxKeys = 2
+	pending.track("a", 0)
+	pending.track("b", time.Minute)
+	pending.track("c", 0)
+	pending.track("a", time.Minute)
+	pending.forget("b")
+
+	keys := pending.drain()
+	assert.Len(t, keys, 1)
+	assert.Contains(t, keys, "a")
+	assert.False(t, keys["a"].IsZero(), "a rewrite updates the expiry")
+}
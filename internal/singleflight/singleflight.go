@@ -0,0 +1,54 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+)
+
+// errPanicked is returned to waiting callers when fn panics
+var errPanicked = errors.New("coalesced call panicked")
+
+// call is an in-flight or completed Do call
+type call struct {
+	wg    sync.WaitGroup
+	value string
+	err   error
+}
+
+// Group coalesces concurrent calls with the same key into a single execution.
+//
+// The zero value is ready to use.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do runs fn and returns its result, unless a call for key is already in
+// flight, in which case it waits for that call and returns its result
+// instead. shared reports whether the result came from another caller's fn.
+//
+// Only calls overlapping in time are coalesced; results are not cached.
+func (g *Group) Do(key string, fn func() (string, error)) (value string, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.value, true, c.err
+	}
+	c := &call{err: errPanicked}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.value, c.err = fn()
+	return c.value, false, c.err
+}
@@ -0,0 +1,321 @@
+package composite
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/utils/builderpool"
+)
+
+// AnyConfig represents an any-allows configuration: a request passes if at
+// least one tier allows it
+type AnyConfig struct {
+	BaseKey      string              // Base key for tier storage key generation
+	Tiers        []strategies.Config // Tiers tried in order, e.g. a quota and then an overage bucket
+	RetryBackoff strategies.Backoff  // Delay policy between CAS retries of every tier, zero value uses default
+	key          string              // Cached "{BaseKey}:{key}" prefix of the tier keys
+}
+
+// Validate performs configuration validation for the any-allows strategy.
+//
+// Returns an error if BaseKey is empty, fewer than two tiers are configured,
+// or a tier is nil, invalid or lacks the primary capability.
+func (c *AnyConfig) Validate() error {
+	if c.BaseKey == "" {
+		return fmt.Errorf("any config base key cannot be empty")
+	}
+	if len(c.Tiers) < 2 {
+		return fmt.Errorf("any config requires at least 2 tiers, got %d", len(c.Tiers))
+	}
+	for i, tc := range c.Tiers {
+		if err := validateAnyTier(tc); err != nil {
+			return fmt.Errorf("tier %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// validateAnyTier validates a single tier config and its capabilities
+func validateAnyTier(tc strategies.Config) error {
+	if tc == nil {
+		return fmt.Errorf("strategy config cannot be nil")
+	}
+	if err := tc.Validate(); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+	if !tc.Capabilities().Has(strategies.CapPrimary) {
+		return fmt.Errorf("strategy must support primary capability")
+	}
+	return nil
+}
+
+// ID returns StrategyComposite, the any-allows strategy combines other strategies
+func (c *AnyConfig) ID() strategies.ID {
+	return strategies.StrategyComposite
+}
+
+// Capabilities returns CapPrimary, the any-allows strategy enforces hard limits
+func (c *AnyConfig) Capabilities() strategies.CapabilityFlags {
+	return strategies.CapPrimary
+}
+
+// WithKey applies a new fully-qualified-key to the any config.
+//
+// Every tier stores its state under its own key "{BaseKey}:{key}:a{n}", n
+// counting tiers from 1, since a request consumes a single tier.
+func (c *AnyConfig) WithKey(key string) strategies.Config {
+	cfg := *c
+	sb := builderpool.Get()
+	defer builderpool.Put(sb)
+	sb.WriteString(c.BaseKey)
+	sb.WriteString(":")
+	sb.WriteString(key)
+	cfg.key = sb.String()
+
+	return &cfg
+}
+
+// tierConfigs returns the tier configs with their storage keys applied
+func (c *AnyConfig) tierConfigs() []strategies.Config {
+	configs := make([]strategies.Config, len(c.Tiers))
+	for i, tc := range c.Tiers {
+		configs[i] = tc.WithKey(tierKey(c.key, AnyKeyMarker, i+1))
+	}
+	return configs
+}
+
+// GetMaxRetries returns the smallest retry count of the tiers
+func (c *AnyConfig) GetMaxRetries() int {
+	retries := c.Tiers[0].GetMaxRetries()
+	for _, tc := range c.Tiers[1:] {
+		retries = min(retries, tc.GetMaxRetries())
+	}
+	return retries
+}
+
+// WithMaxRetries applies the retry limit to every tier
+func (c *AnyConfig) WithMaxRetries(retries int) strategies.Config {
+	cfg := *c
+	cfg.Tiers = make([]strategies.Config, len(c.Tiers))
+	for i, tc := range c.Tiers {
+		cfg.Tiers[i] = tc.WithMaxRetries(retries)
+	}
+	return &cfg
+}
+
+// WithRetryBackoff applies the retry backoff to every tier implementing
+// strategies.RetryBackoffConfig
+func (c *AnyConfig) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	cfg.Tiers = make([]strategies.Config, len(c.Tiers))
+	for i, tc := range c.Tiers {
+		if bc, ok := tc.(strategies.RetryBackoffConfig); ok {
+			tc = bc.WithRetryBackoff(backoff)
+		}
+		cfg.Tiers[i] = tc
+	}
+	return &cfg
+}
+
+// WithActor applies the actor of the request to every tier implementing
+// strategies.ActorConfig
+func (c *AnyConfig) WithActor(actor string) strategies.Config {
+	cfg := *c
+	cfg.Tiers = make([]strategies.Config, len(c.Tiers))
+	for i, tc := range c.Tiers {
+		if ac, ok := tc.(strategies.ActorConfig); ok {
+			tc = ac.WithActor(actor)
+		}
+		cfg.Tiers[i] = tc
+	}
+	return &cfg
+}
+
+// WithRand applies the random source to the retry jitter and to every tier
+// implementing strategies.RandConfig
+func (c *AnyConfig) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff.Rand = rng
+	cfg.Tiers = make([]strategies.Config, len(c.Tiers))
+	for i, tc := range c.Tiers {
+		if rc, ok := tc.(strategies.RandConfig); ok {
+			tc = rc.WithRand(rng)
+		}
+		cfg.Tiers[i] = tc
+	}
+	return &cfg
+}
+
+// WithIdleTTL applies the idle TTL of the request to every tier implementing
+// strategies.IdleTTLConfig
+func (c *AnyConfig) WithIdleTTL(idleTTL time.Duration) strategies.Config {
+	cfg := *c
+	cfg.Tiers = make([]strategies.Config, len(c.Tiers))
+	for i, tc := range c.Tiers {
+		if ic, ok := tc.(strategies.IdleTTLConfig); ok {
+			tc = ic.WithIdleTTL(idleTTL)
+		}
+		cfg.Tiers[i] = tc
+	}
+	return &cfg
+}
+
+// AnyStrategy implements any-allows behavior over several tiers
+type AnyStrategy struct {
+	tiers []strategies.Strategy
+}
+
+// NewAny creates a new any-allows strategy for the tier configs.
+//
+// Tiers are tried in order and the first one that allows the request is the
+// only one consumed, atomically like a single strategy. A request denied by
+// every tier consumes nothing. Results are prefixed with the tier, e.g.
+// "tier2_default": those of the allowing tier, or of all tiers when denied.
+func NewAny(b backends.Backend, tiers ...strategies.Config) (*AnyStrategy, error) {
+	if len(tiers) < 2 {
+		return nil, fmt.Errorf("any strategy requires at least 2 tiers, got %d", len(tiers))
+	}
+
+	s := &AnyStrategy{tiers: make([]strategies.Strategy, len(tiers))}
+	for i, tc := range tiers {
+		if err := validateAnyTier(tc); err != nil {
+			return nil, fmt.Errorf("tier %d: %w", i+1, err)
+		}
+		strategy, err := strategies.Create(tc.ID(), b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tier %d strategy: %w", i+1, err)
+		}
+		s.tiers[i] = strategy
+	}
+	return s, nil
+}
+
+// prepareAny validates the any config and returns its keyed tier configs
+func (s *AnyStrategy) prepareAny(sci strategies.Config) ([]strategies.Config, error) {
+	cfg, ok := sci.(*AnyConfig)
+	if !ok {
+		return nil, fmt.Errorf("any strategy requires AnyConfig")
+	}
+	if cfg.key == "" {
+		return nil, fmt.Errorf("any key not set, call WithKey first")
+	}
+	if len(cfg.Tiers) != len(s.tiers) {
+		return nil, fmt.Errorf("any config has %d tiers, strategy has %d", len(cfg.Tiers), len(s.tiers))
+	}
+	return cfg.tierConfigs(), nil
+}
+
+// tierPrefix returns the results prefix of tier i, counting from 1
+func tierPrefix(i int) string {
+	return "tier" + strconv.Itoa(i+1) + "_"
+}
+
+// Allow consumes a unit of the first tier that allows the request
+func (s *AnyStrategy) Allow(ctx context.Context, sci strategies.Config) (strategies.Results, error) {
+	return s.AllowCost(ctx, sci, 1)
+}
+
+// AllowCost consumes cost units of the first tier that allows the request.
+//
+// Every tier strategy must implement strategies.CostAllower unless cost is 1.
+func (s *AnyStrategy) AllowCost(ctx context.Context, sci strategies.Config, cost float64) (strategies.Results, error) {
+	configs, err := s.prepareAny(sci)
+	if err != nil {
+		return nil, err
+	}
+
+	denied := make(strategies.Results)
+	for i, tc := range configs {
+		res, err := allowCost(ctx, s.tiers[i], tc, cost)
+		if err != nil {
+			return nil, fmt.Errorf("tier %d strategy allow failed: %w", i+1, err)
+		}
+		if !anyDenied(res) {
+			allowed := make(strategies.Results, len(res))
+			addPrefixed(allowed, res, tierPrefix(i))
+			return allowed, nil
+		}
+		addPrefixed(denied, res, tierPrefix(i))
+	}
+	return denied, nil
+}
+
+// Peek reports the first tier that would allow a request, or all tiers when none would
+func (s *AnyStrategy) Peek(ctx context.Context, sci strategies.Config) (strategies.Results, error) {
+	configs, err := s.prepareAny(sci)
+	if err != nil {
+		return nil, err
+	}
+
+	denied := make(strategies.Results)
+	for i, tc := range configs {
+		res, err := s.tiers[i].Peek(ctx, tc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tier %d results: %w", i+1, err)
+		}
+		if !anyDenied(res) {
+			allowed := make(strategies.Results, len(res))
+			addPrefixed(allowed, res, tierPrefix(i))
+			return allowed, nil
+		}
+		addPrefixed(denied, res, tierPrefix(i))
+	}
+	return denied, nil
+}
+
+// Reset removes the state of every tier
+func (s *AnyStrategy) Reset(ctx context.Context, sci strategies.Config) error {
+	configs, err := s.prepareAny(sci)
+	if err != nil {
+		return err
+	}
+
+	for i, tc := range configs {
+		if err := s.tiers[i].Reset(ctx, tc); err != nil {
+			return fmt.Errorf("failed to reset tier %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Inspect returns the stored state of every tier
+func (s *AnyStrategy) Inspect(ctx context.Context, sci strategies.Config) ([]strategies.State, error) {
+	configs, err := s.prepareAny(sci)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []strategies.State
+	for i, tc := range configs {
+		tierStates, err := inspectTier(ctx, s.tiers[i], tc, tierPrefix(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect tier %d: %w", i+1, err)
+		}
+		states = append(states, tierStates...)
+	}
+	return states, nil
+}
+
+// SteadyStateRate returns the long-run requests per second allowed, the
+// highest rate of the tiers since any of them can allow a request.
+//
+// Returns +Inf when a tier doesn't implement strategies.SteadyStateRateConfig
+// and so doesn't limit the rate.
+func (c *AnyConfig) SteadyStateRate() float64 {
+	rate := 0.0
+	for _, tc := range c.Tiers {
+		rc, ok := tc.(strategies.SteadyStateRateConfig)
+		if !ok {
+			return math.Inf(1)
+		}
+		rate = max(rate, rc.SteadyStateRate())
+	}
+	return rate
+}
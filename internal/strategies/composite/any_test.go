@@ -0,0 +1,80 @@
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// anyMockStrategy allows or denies every request and records the keys it consumed
+type anyMockStrategy struct {
+	compMockStrategy
+	allowed  bool
+	consumed []string
+}
+
+func (m *anyMockStrategy) Allow(_ context.Context, cfg strategies.Config) (strategies.Results, error) {
+	if m.allowed {
+		m.consumed = append(m.consumed, cfg.(compMockConfig).key)
+	}
+	return strategies.Results{"q": {Allowed: m.allowed}}, nil
+}
+
+func (m *anyMockStrategy) Peek(context.Context, strategies.Config) (strategies.Results, error) {
+	return strategies.Results{"q": {Allowed: m.allowed}}, nil
+}
+
+func TestAnyConfigValidate(t *testing.T) {
+	pri := compMockConfig{caps: strategies.CapPrimary}
+	require.NoError(t, (&AnyConfig{BaseKey: "k", Tiers: []strategies.Config{pri, pri}}).Validate())
+	require.Error(t, (&AnyConfig{Tiers: []strategies.Config{pri, pri}}).Validate())
+	require.Error(t, (&AnyConfig{BaseKey: "k", Tiers: []strategies.Config{pri}}).Validate())
+	require.Error(t, (&AnyConfig{BaseKey: "k", Tiers: []strategies.Config{pri, nil}}).Validate())
+	sec := compMockConfig{caps: strategies.CapSecondary}
+	require.ErrorContains(t, (&AnyConfig{BaseKey: "k", Tiers: []strategies.Config{pri, sec}}).Validate(), "tier 2")
+}
+
+func TestAnyStrategyFlows(t *testing.T) {
+	quota := &anyMockStrategy{}
+	overage := &anyMockStrategy{allowed: true}
+	strategies.Register(strategies.ID(10), func(backends.Backend) strategies.Strategy { return quota })
+	strategies.Register(strategies.ID(11), func(backends.Backend) strategies.Strategy { return overage })
+	quotaConfig := compMockConfig{id: strategies.ID(10), caps: strategies.CapPrimary}
+	overageConfig := compMockConfig{id: strategies.ID(11), caps: strategies.CapPrimary}
+
+	anyStrategy, err := NewAny(&mockBackend{data: make(map[string]mockData)}, quotaConfig, overageConfig)
+	require.NoError(t, err)
+	cfg := (&AnyConfig{BaseKey: "k", Tiers: []strategies.Config{quotaConfig, overageConfig}}).WithKey("user")
+	ctx := t.Context()
+
+	// Only the first allowing tier is consumed and reported
+	res, err := anyStrategy.Allow(ctx, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, strategies.Results{"tier2_q": {Allowed: true}}, res)
+	assert.Empty(t, quota.consumed)
+	assert.Equal(t, []string{"k:user:a2"}, overage.consumed)
+
+	quota.allowed = true
+	res, err = anyStrategy.Peek(ctx, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, strategies.Results{"tier1_q": {Allowed: true}}, res)
+	res, err = anyStrategy.Allow(ctx, cfg)
+	require.NoError(t, err)
+	assert.Contains(t, res, "tier1_q")
+	assert.Equal(t, []string{"k:user:a1"}, quota.consumed)
+	assert.Len(t, overage.consumed, 1)
+
+	// Denied by every tier, all results are reported
+	quota.allowed, overage.allowed = false, false
+	res, err = anyStrategy.Allow(ctx, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, strategies.Results{"tier1_q": {}, "tier2_q": {}}, res)
+
+	require.NoError(t, anyStrategy.Reset(ctx, cfg))
+	_, err = NewAny(nil, quotaConfig)
+	require.Error(t, err)
+}
@@ -3,12 +3,10 @@ package composite
 import (
 	"context"
 	"fmt"
-	"maps"
 	"time"
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
-	"github.com/ajiwo/ratelimit/utils"
 )
 
 // Strategy implements atomic dual-strategy behavior
@@ -16,8 +14,11 @@ type Strategy struct {
 	storage backends.Backend
 }
 
-// New creates a new composite strategy
-func New(b backends.Backend, pConfig strategies.Config, sConfig strategies.Config) (*Strategy, error) {
+// New creates a new composite strategy.
+//
+// Additional secondary configs may be supplied; every secondary must allow for
+// a request to pass.
+func New(b backends.Backend, pConfig strategies.Config, sConfig strategies.Config, extra ...strategies.Config) (*Strategy, error) {
 	// Validate inputs
 	if pConfig == nil {
 		return nil, fmt.Errorf("primary strategy config cannot be nil")
@@ -30,16 +31,14 @@ func New(b backends.Backend, pConfig strategies.Config, sConfig strategies.Confi
 	if err := pConfig.Validate(); err != nil {
 		return nil, fmt.Errorf("primary config validation failed: %w", err)
 	}
-	if err := sConfig.Validate(); err != nil {
-		return nil, fmt.Errorf("secondary config validation failed: %w", err)
-	}
-
-	// Check capabilities
 	if !pConfig.Capabilities().Has(strategies.CapPrimary) {
 		return nil, fmt.Errorf("primary strategy must support primary capability")
 	}
-	if !sConfig.Capabilities().Has(strategies.CapSecondary) {
-		return nil, fmt.Errorf("secondary strategy must support secondary capability")
+
+	for _, cfg := range append([]strategies.Config{sConfig}, extra...) {
+		if err := validateSecondary(cfg); err != nil {
+			return nil, err
+		}
 	}
 
 	return &Strategy{
@@ -47,15 +46,27 @@ func New(b backends.Backend, pConfig strategies.Config, sConfig strategies.Confi
 	}, nil
 }
 
-// Allow implements atomic dual-strategy logic using composite state
+// Allow implements atomic dual-strategy logic using composite state.
+//
+// Every attempt reads the state of all tiers with a single Get, decides, and
+// commits all tiers with a single CheckAndSet, so unlike a Peek followed by
+// Allow on separate strategies, concurrent requests can't consume a tier for
+// a request another tier denies, nor go over any tier's limit.
 func (cs *Strategy) Allow(ctx context.Context, sci strategies.Config) (strategies.Results, error) {
+	return cs.AllowCost(ctx, sci, 1)
+}
+
+// AllowCost consumes cost units on every tier atomically.
+//
+// Every tier strategy must implement strategies.CostAllower unless cost is 1.
+func (cs *Strategy) AllowCost(ctx context.Context, sci strategies.Config, cost float64) (strategies.Results, error) {
 	cfg, key, maxRetries, err := prepareCompositeForAllow(sci)
 	if err != nil {
 		return nil, err
 	}
 
 	for attempt := range maxRetries {
-		results, done, feedback, err := cs.tryAllowOnce(ctx, cfg, key)
+		results, done, feedback, err := cs.tryAllowOnce(ctx, cfg, key, cost)
 		if err != nil {
 			return nil, err
 		}
@@ -63,13 +74,12 @@ func (cs *Strategy) Allow(ctx context.Context, sci strategies.Config) (strategie
 			return results, nil
 		}
 		// CAS failed, apply backoff and retry due to contention
-		delay := strategies.NextDelay(attempt, feedback)
-		if err := utils.SleepOrWait(ctx, delay, 500*time.Millisecond); err != nil {
+		if err := cfg.RetryBackoff.Wait(ctx, attempt, feedback); err != nil {
 			return nil, fmt.Errorf("composite allow canceled: %w", err)
 		}
 	}
 
-	return nil, fmt.Errorf("max retries (%d) exceeded for composite operation", maxRetries)
+	return nil, fmt.Errorf("composite operation failed after %d attempts: %w", maxRetries, strategies.ErrMaxRetriesExceeded)
 }
 
 // prepareCompositeForAllow validates and extracts composite config essentials
@@ -89,13 +99,48 @@ func prepareCompositeForAllow(sci strategies.Config) (*Config, string, int, erro
 	return cfg, key, maxRetries, nil
 }
 
+// tier is an ephemeral strategy bound to a single-key adapter seeded from the composite state
+type tier struct {
+	role     string // "primary" or "secondary", used in error messages
+	advisory bool   // denials are reported without denying the request
+	prefix   string // results prefix, e.g. "primary_" or "secondary_"
+	config   strategies.Config
+	adapter  *singleKeyAdapter
+	strategy strategies.Strategy
+}
+
+// newTiers decodes the composite state and creates ephemeral strategies for every tier.
+//
+// The primary tier is always first, followed by secondaries in configuration order.
+func newTiers(cfg *Config, compositeState string) ([]tier, error) {
+	secondaries := cfg.SecondaryConfigs()
+	prefixes := secondaryPrefixes(secondaries)
+	states := decodeState(compositeState, 1+len(secondaries))
+
+	tiers := make([]tier, 0, 1+len(secondaries))
+	tiers = append(tiers, tier{role: "primary", prefix: "primary_", config: cfg.Primary})
+	for i, sc := range secondaries {
+		tiers = append(tiers, tier{role: "secondary", prefix: prefixes[i], config: sc, advisory: cfg.advisory(i)})
+	}
+
+	for i := range tiers {
+		tiers[i].adapter = newSingleKeyAdapter(states[i])
+		s, err := strategies.Create(tiers[i].config.ID(), tiers[i].adapter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s strategy: %w", tiers[i].role, err)
+		}
+		tiers[i].strategy = s
+	}
+	return tiers, nil
+}
+
 // tryAllowOnce executes a single attempt of the composite allow logic.
 // Returns:
 // - results: final results if decision is determined (denied or committed), or nil if need retry
 // - done: true if decision is final (return immediately), false if should retry due to CAS contention
 // - duration: time taken for the operation (for backoff calculation), non-zero if retry required
 // - err: any error occurred during attempt
-func (cs *Strategy) tryAllowOnce(ctx context.Context, cfg *Config, key string) (strategies.Results, bool, time.Duration, error) {
+func (cs *Strategy) tryAllowOnce(ctx context.Context, cfg *Config, key string, cost float64) (strategies.Results, bool, time.Duration, error) {
 	// Start recording time
 	beforeCAS := time.Now()
 
@@ -105,100 +150,99 @@ func (cs *Strategy) tryAllowOnce(ctx context.Context, cfg *Config, key string) (
 		return nil, true, 0, fmt.Errorf("failed to get composite state: %w", err)
 	}
 
-	// Decode composite state
-	oldPrimary, oldSecondary := decodeState(oldComposite)
-
-	// Create single-key adapters seeded with current states
-	primaryAdapter := newSingleKeyAdapter(oldPrimary)
-	secondaryAdapter := newSingleKeyAdapter(oldSecondary)
-
-	// Create ephemeral strategies bound to adapters
-	primaryStrategy, err := strategies.Create(cfg.Primary.ID(), primaryAdapter)
+	tiers, err := newTiers(cfg, oldComposite)
 	if err != nil {
-		return nil, true, 0, fmt.Errorf("failed to create primary strategy: %w", err)
+		return nil, true, 0, err
 	}
 
-	secondaryStrategy, err := strategies.Create(cfg.Secondary.ID(), secondaryAdapter)
-	if err != nil {
-		return nil, true, 0, fmt.Errorf("failed to create secondary strategy: %w", err)
+	// Peek tiers in order, the first denying tier is the final decision without commit
+	peekResults := make(strategies.Results)
+	for _, t := range tiers {
+		res, err := t.peek(ctx)
+		if err != nil {
+			return nil, true, 0, fmt.Errorf("%s strategy peek failed: %w", t.role, err)
+		}
+		addPrefixed(peekResults, res, t.prefix)
+		if anyDenied(res) {
+			return peekResults, true, 0, nil
+		}
 	}
 
-	// Peek primary
-	primaryPeekResults, err := primaryStrategy.Peek(ctx, cfg.Primary)
-	if err != nil {
-		return nil, true, 0, fmt.Errorf("primary strategy peek failed: %w", err)
+	// All tiers allow -> consume quota on every tier
+	allowResults := make(strategies.Results)
+	states := make([]string, len(tiers))
+	var ttl time.Duration
+	for i, t := range tiers {
+		res, err := t.allow(ctx, cost)
+		if err != nil {
+			return nil, true, 0, fmt.Errorf("%s strategy allow failed: %w", t.role, err)
+		}
+		addPrefixed(allowResults, res, t.prefix)
+		states[i] = t.adapter.value
+		ttl = max(ttl, t.adapter.expiration)
 	}
-	if anyDenied(primaryPeekResults) {
-		// Denied by primary, final decision without commit
-		return prefixResults(primaryPeekResults, "primary_"), true, 0, nil
+	if anyDenied(allowResults) {
+		// A tier denied despite its peek allowing, final decision without commit
+		return allowResults, true, 0, nil
 	}
 
-	// Peek secondary
-	secondaryPeekResults, err := secondaryStrategy.Peek(ctx, cfg.Secondary)
+	// Atomic commit with CAS
+	ok, err := cs.storage.CheckAndSet(ctx, key, oldComposite, encodeState(states...), ttl)
 	if err != nil {
-		return nil, true, 0, fmt.Errorf("secondary strategy peek failed: %w", err)
+		return nil, true, 0, fmt.Errorf("CAS operation failed: %w", err)
 	}
-	if anyDenied(secondaryPeekResults) {
-		// Denied by secondary, final decision without commit
-		return mergePrefixedResults(
-			prefixResults(primaryPeekResults, "primary_"),
-			prefixResults(secondaryPeekResults, "secondary_"),
-		), true, 0, nil
+	if ok {
+		return allowResults, true, 0, nil
 	}
 
-	// Both allow -> consume quota
-	primaryAllowResults, err := primaryStrategy.Allow(ctx, cfg.Primary)
-	if err != nil {
-		return nil, true, 0, fmt.Errorf("primary strategy allow failed: %w", err)
-	}
+	// CAS failed -> retry
+	return nil, false, time.Since(beforeCAS), nil
+}
 
-	secondaryAllowResults, err := secondaryStrategy.Allow(ctx, cfg.Secondary)
-	if err != nil {
-		return nil, true, 0, fmt.Errorf("secondary strategy allow failed: %w", err)
+// peek inspects the tier without consuming quota
+func (t tier) peek(ctx context.Context) (strategies.Results, error) {
+	res, err := t.strategy.Peek(ctx, t.config)
+	if err == nil && t.advisory {
+		markAdvisory(res)
 	}
+	return res, err
+}
 
-	// Encode new composite state
-	newComposite := encodeState(primaryAdapter.value, secondaryAdapter.value)
-	ttl := max(primaryAdapter.expiration, secondaryAdapter.expiration)
+// allow consumes cost units on the tier, using plain Allow for unit cost.
+//
+// An advisory tier denying the request consumes nothing, like any denying
+// strategy, while the other tiers are still consumed.
+func (t tier) allow(ctx context.Context, cost float64) (strategies.Results, error) {
+	res, err := allowCost(ctx, t.strategy, t.config, cost)
+	if err == nil && t.advisory {
+		markAdvisory(res)
+	}
+	return res, err
+}
 
-	// Atomic commit with CAS
-	ok, err := cs.storage.CheckAndSet(ctx, key, oldComposite, newComposite, ttl)
-	if err != nil {
-		return nil, true, 0, fmt.Errorf("CAS operation failed: %w", err)
+// allowCost consumes cost units of strategy, using plain Allow for unit cost
+func allowCost(ctx context.Context, strategy strategies.Strategy, config strategies.Config, cost float64) (strategies.Results, error) {
+	if cost == 1 {
+		return strategy.Allow(ctx, config)
 	}
-	if ok {
-		// Success! Merge and return results
-		return mergePrefixedResults(
-			prefixResults(primaryAllowResults, "primary_"),
-			prefixResults(secondaryAllowResults, "secondary_"),
-		), true, 0, nil
+	allower, ok := strategy.(strategies.CostAllower)
+	if !ok {
+		return nil, strategies.ErrCostNotSupported
 	}
-
-	// CAS failed -> retry
-	return nil, false, time.Since(beforeCAS), nil
+	return allower.AllowCost(ctx, config, cost)
 }
 
-// prefixResults returns a new results map with all keys prefixed
-func prefixResults(in strategies.Results, prefix string) strategies.Results {
-	out := make(strategies.Results, len(in))
+// addPrefixed copies results into out with all keys prefixed
+func addPrefixed(out, in strategies.Results, prefix string) {
 	for k, v := range in {
 		out[prefix+k] = v
 	}
-	return out
-}
-
-// mergePrefixedResults merges two results maps into a new map
-func mergePrefixedResults(a, b strategies.Results) strategies.Results {
-	out := make(strategies.Results, len(a)+len(b))
-	maps.Copy(out, a)
-	maps.Copy(out, b)
-	return out
 }
 
-// anyDenied checks if any result in the map indicates denial
+// anyDenied checks if any result in the map denies the request, advisory results never do
 func anyDenied(results strategies.Results) bool {
 	for _, result := range results {
-		if !result.Allowed {
+		if result.Denies() {
 			return true
 		}
 	}
@@ -223,45 +267,110 @@ func (cs *Strategy) Peek(ctx context.Context, sci strategies.Config) (strategies
 		return nil, fmt.Errorf("failed to get composite state: %w", err)
 	}
 
-	// Decode composite state
-	oldPrimary, oldSecondary := decodeState(oldComposite)
+	tiers, err := newTiers(cfg, oldComposite)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create single-key adapters seeded with current states
-	primaryAdapter := newSingleKeyAdapter(oldPrimary)
-	secondaryAdapter := newSingleKeyAdapter(oldSecondary)
+	results := make(strategies.Results)
+	for _, t := range tiers {
+		res, err := t.peek(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s results: %w", t.role, err)
+		}
+		addPrefixed(results, res, t.prefix)
+	}
+
+	return results, nil
+}
+
+// Refund returns n units of quota to every tier atomically using composite state
+func (cs *Strategy) Refund(ctx context.Context, sci strategies.Config, n int) error {
+	return cs.refund(ctx, sci, n, false)
+}
 
-	// Create ephemeral strategies bound to adapters
-	primaryStrategy, err := strategies.Create(cfg.Primary.ID(), primaryAdapter)
+// Release returns n leases to the concurrency tiers atomically using
+// composite state, leaving the quota consumed on the other tiers
+func (cs *Strategy) Release(ctx context.Context, sci strategies.Config, n int) error {
+	return cs.refund(ctx, sci, n, true)
+}
+
+// refund returns n units to every tier, or only to concurrency tiers when release is set
+func (cs *Strategy) refund(ctx context.Context, sci strategies.Config, n int, release bool) error {
+	cfg, key, maxRetries, err := prepareCompositeForAllow(sci)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create primary strategy: %w", err)
+		return err
 	}
 
-	secondaryStrategy, err := strategies.Create(cfg.Secondary.ID(), secondaryAdapter)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create secondary strategy: %w", err)
+	for attempt := range maxRetries {
+		done, feedback, err := cs.tryRefundOnce(ctx, cfg, key, n, release)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		// CAS failed, apply backoff and retry due to contention
+		if err := cfg.RetryBackoff.Wait(ctx, attempt, feedback); err != nil {
+			return fmt.Errorf("composite refund canceled: %w", err)
+		}
 	}
 
-	results := make(strategies.Results)
+	return fmt.Errorf("composite operation failed after %d attempts: %w", maxRetries, strategies.ErrMaxRetriesExceeded)
+}
+
+// tryRefundOnce executes a single attempt of the composite refund logic.
+// Returns done=false with the attempt duration when the CAS lost a race and should be retried.
+func (cs *Strategy) tryRefundOnce(ctx context.Context, cfg *Config, key string, n int, release bool) (bool, time.Duration, error) {
+	beforeCAS := time.Now()
 
-	// Get primary results
-	primaryResults, err := primaryStrategy.Peek(ctx, cfg.Primary)
+	oldComposite, err := cs.storage.Get(ctx, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get primary results: %w", err)
+		return true, 0, fmt.Errorf("failed to get composite state: %w", err)
 	}
-	for key, result := range primaryResults {
-		results["primary_"+key] = result
+	if oldComposite == "" {
+		// Fresh state, nothing to refund
+		return true, 0, nil
 	}
 
-	// Get secondary results
-	secondaryResults, err := secondaryStrategy.Peek(ctx, cfg.Secondary)
+	tiers, err := newTiers(cfg, oldComposite)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get secondary results: %w", err)
+		return true, 0, err
+	}
+
+	states := make([]string, len(tiers))
+	var ttl time.Duration
+	for i, t := range tiers {
+		refunder, ok := t.strategy.(strategies.Refunder)
+		if !ok {
+			return true, 0, fmt.Errorf("%s strategy: %w", t.role, strategies.ErrRefundNotSupported)
+		}
+		state := t.adapter.value
+		if err := refunder.Refund(ctx, t.config, n); err != nil {
+			return true, 0, fmt.Errorf("%s strategy refund failed: %w", t.role, err)
+		}
+		states[i] = t.adapter.value
+		if release && t.config.ID() != strategies.StrategyConcurrency {
+			// The tier keeps its quota, the refund only yields the expiration its state needs
+			states[i] = state
+		}
+		ttl = max(ttl, t.adapter.expiration)
 	}
-	for key, result := range secondaryResults {
-		results["secondary_"+key] = result
+
+	newComposite := encodeState(states...)
+	if newComposite == oldComposite {
+		return true, 0, nil
 	}
 
-	return results, nil
+	ok, err := cs.storage.CheckAndSet(ctx, key, oldComposite, newComposite, ttl)
+	if err != nil {
+		return true, 0, fmt.Errorf("CAS operation failed: %w", err)
+	}
+	if ok {
+		return true, 0, nil
+	}
+
+	return false, time.Since(beforeCAS), nil
 }
 
 // Reset atomically clears composite state using CAS
@@ -278,3 +387,52 @@ func (cs *Strategy) Reset(ctx context.Context, sci strategies.Config) error {
 
 	return cs.storage.Delete(ctx, key)
 }
+
+// Inspect returns the stored state of every tier, decoded from the composite state
+func (cs *Strategy) Inspect(ctx context.Context, sci strategies.Config) ([]strategies.State, error) {
+	cfg, ok := sci.(*Config)
+	if !ok {
+		return nil, fmt.Errorf("composite strategy requires CompositeConfig")
+	}
+
+	key := cfg.CompositeKey()
+	if key == "" {
+		return nil, fmt.Errorf("composite key not set, call WithKey first")
+	}
+
+	compositeState, err := cs.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get composite state: %w", err)
+	}
+
+	tiers, err := newTiers(cfg, compositeState)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []strategies.State
+	for _, t := range tiers {
+		tierStates, err := inspectTier(ctx, t.strategy, t.config, t.prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect %s strategy: %w", t.role, err)
+		}
+		states = append(states, tierStates...)
+	}
+	return states, nil
+}
+
+// inspectTier returns the states of strategy with their tiers prefixed
+func inspectTier(ctx context.Context, strategy strategies.Strategy, config strategies.Config, prefix string) ([]strategies.State, error) {
+	inspector, ok := strategy.(strategies.Inspector)
+	if !ok {
+		return nil, strategies.ErrInspectNotSupported
+	}
+	states, err := inspector.Inspect(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	for i := range states {
+		states[i].Tier = prefix + states[i].Tier
+	}
+	return states, nil
+}
@@ -108,6 +108,22 @@ func TestCompositeConfigHelpers_WithKey(t *testing.T) {
 	if applied.CompositeKey() != "k:fully:qualified:c" {
 		t.Fatalf("composite key not applied correctly, got: %s", applied.CompositeKey())
 	}
+
+	// Pinned key layout, see CompositeKeyVersion
+	applied = (&Config{BaseKey: "k", Primary: pri, Secondary: sec, ExtraSecondaries: []strategies.Config{sec}}).
+		WithKey("fully:qualified").(*Config)
+	var splitKeys []string
+	for _, tc := range applied.splitConfigs() {
+		splitKeys = append(splitKeys, tc.(compMockConfig).key)
+	}
+	require.Equal(t, []string{"k:fully:qualified:c1", "k:fully:qualified:c2", "k:fully:qualified:c3"}, splitKeys)
+
+	anyConfig := (&AnyConfig{BaseKey: "k", Tiers: []strategies.Config{pri, pri}}).WithKey("fully:qualified").(*AnyConfig)
+	var anyKeys []string
+	for _, tc := range anyConfig.tierConfigs() {
+		anyKeys = append(anyKeys, tc.(compMockConfig).key)
+	}
+	require.Equal(t, []string{"k:fully:qualified:a1", "k:fully:qualified:a2"}, anyKeys)
 }
 
 func TestCompositeStrategyFlows(t *testing.T) {
@@ -427,3 +443,53 @@ func (f *failingCASBackend) Delete(ctx context.Context, key string) error {
 func (f *failingCASBackend) Close() error {
 	return nil
 }
+
+func TestCompositeMultipleSecondaries(t *testing.T) {
+	storage := &mockBackend{
+		data: make(map[string]mockData),
+	}
+
+	pri := &compMockStrategy{getRes: strategies.Results{"p": {Allowed: true}}, allowRes: strategies.Results{"p": {Allowed: true}}}
+	sec1 := &compMockStrategy{getRes: strategies.Results{"s": {Allowed: true}}, allowRes: strategies.Results{"s": {Allowed: true}}}
+	sec2 := &compMockStrategy{getRes: strategies.Results{"s": {Allowed: false}}}
+
+	strategies.Register(strategies.ID(40), func(_ backends.Backend) strategies.Strategy { return pri })
+	strategies.Register(strategies.ID(41), func(_ backends.Backend) strategies.Strategy { return sec1 })
+	strategies.Register(strategies.ID(42), func(_ backends.Backend) strategies.Strategy { return sec2 })
+
+	priConfig := compMockConfig{id: strategies.ID(40), caps: strategies.CapPrimary}
+	sec1Config := compMockConfig{id: strategies.ID(41), caps: strategies.CapSecondary}
+	sec2Config := compMockConfig{id: strategies.ID(42), caps: strategies.CapSecondary}
+
+	_, err := New(storage, priConfig, sec1Config, compMockConfig{id: strategies.ID(43), caps: strategies.CapPrimary})
+	require.Error(t, err, "expected error when extra secondary lacks CapSecondary")
+
+	comp, err := New(storage, priConfig, sec1Config, sec2Config)
+	require.NoError(t, err, "Failed to create composite strategy")
+
+	cfg := &Config{BaseKey: "k", Primary: priConfig, Secondary: sec1Config, ExtraSecondaries: []strategies.Config{sec2Config}}
+	cfg = cfg.WithKey("multi").(*Config)
+	require.NoError(t, cfg.Validate())
+
+	// Extra secondary denies -> nothing committed
+	res, err := comp.Allow(t.Context(), cfg)
+	require.NoError(t, err, "Allow error: %v", err)
+	require.True(t, res["secondary_unknown1_s"].Allowed, "first secondary should allow")
+	require.False(t, res["secondary_unknown2_s"].Allowed, "extra secondary should deny")
+
+	compositeValue, err := storage.Get(t.Context(), cfg.CompositeKey())
+	require.NoError(t, err, "Failed to get composite state")
+	require.Empty(t, compositeValue, "No state should be stored when any secondary denies")
+}
+
+func TestCompositeStateMultipleTiers(t *testing.T) {
+	encoded := encodeState("p", "s1", "s2")
+	require.Equal(t, "51|p$s1$s2", encoded)
+	require.Equal(t, []string{"p", "s1", "s2"}, decodeState(encoded, 3))
+
+	// State written with fewer tiers decodes with fresh trailing states
+	require.Equal(t, []string{"p", "s1", ""}, decodeState("51|p$s1", 3))
+
+	// Invalid state decodes as all fresh
+	require.Equal(t, []string{"", ""}, decodeState("invalid", 2))
+}
@@ -2,6 +2,10 @@ package composite
 
 import (
 	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
 
 	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/ajiwo/ratelimit/utils/builderpool"
@@ -9,10 +13,14 @@ import (
 
 // Config represents a dual-strategy configuration
 type Config struct {
-	BaseKey      string            // Base key for composite storage key generation
-	Primary      strategies.Config // Primary strategy (hard limiter)
-	Secondary    strategies.Config // Secondary strategy (smoother)
-	compositeKey string            // Cached composite storage key
+	BaseKey          string              // Base key for composite storage key generation
+	Primary          strategies.Config   // Primary strategy (hard limiter)
+	Secondary        strategies.Config   // Secondary strategy (smoother)
+	ExtraSecondaries []strategies.Config // Additional secondary strategies, all must allow
+	RetryBackoff     strategies.Backoff  // Delay policy between composite CAS retries, zero value uses default
+	Advisory         []bool              // Per secondary in SecondaryConfigs order, whether its denials are only reported
+	compositeKey     string              // Cached composite storage key
+	key              string              // Cached "{BaseKey}:{key}" prefix of the split tier keys
 }
 
 // Validate performs configuration validation for the composite strategy.
@@ -20,11 +28,11 @@ type Config struct {
 // Returns an error if any of the following conditions are met:
 //   - BaseKey is empty
 //   - Primary strategy is nil
-//   - Secondary strategy is nil
+//   - Secondary strategy (or any extra secondary) is nil
 //   - Primary strategy validation fails
-//   - Secondary strategy validation fails
+//   - Secondary strategy (or any extra secondary) validation fails
 //   - Primary strategy doesn't support CapPrimary capability
-//   - Secondary strategy doesn't support CapSecondary capability
+//   - Secondary strategy (or any extra secondary) doesn't support CapSecondary capability
 //
 // Validation ensures both strategies are properly configured and compatible
 // for dual-strategy operation with atomic coordination.
@@ -43,21 +51,87 @@ func (c *Config) Validate() error {
 	if err := c.Primary.Validate(); err != nil {
 		return fmt.Errorf("primary config validation failed: %w", err)
 	}
-	if err := c.Secondary.Validate(); err != nil {
-		return fmt.Errorf("secondary config validation failed: %w", err)
-	}
 
 	// Check capabilities
 	if !c.Primary.Capabilities().Has(strategies.CapPrimary) {
 		return fmt.Errorf("primary strategy must support primary capability")
 	}
-	if !c.Secondary.Capabilities().Has(strategies.CapSecondary) {
-		return fmt.Errorf("secondary strategy must support secondary capability")
+
+	for _, sc := range c.SecondaryConfigs() {
+		if sc == nil {
+			return fmt.Errorf("composite config secondary strategy cannot be nil")
+		}
+		if err := validateSecondary(sc); err != nil {
+			return err
+		}
 	}
+	if n := len(c.SecondaryConfigs()); len(c.Advisory) > n {
+		return fmt.Errorf("composite config has %d advisory flags for %d secondary strategies", len(c.Advisory), n)
+	}
+
+	return nil
+}
 
+// validateSecondary validates a single secondary config and its capabilities
+func validateSecondary(sc strategies.Config) error {
+	if err := sc.Validate(); err != nil {
+		return fmt.Errorf("secondary config validation failed: %w", err)
+	}
+	if !sc.Capabilities().Has(strategies.CapSecondary) {
+		return fmt.Errorf("secondary strategy must support secondary capability")
+	}
 	return nil
 }
 
+// SecondaryConfigs returns the secondary config followed by any extra secondaries.
+func (c *Config) SecondaryConfigs() []strategies.Config {
+	out := make([]strategies.Config, 0, 1+len(c.ExtraSecondaries))
+	out = append(out, c.Secondary)
+	return append(out, c.ExtraSecondaries...)
+}
+
+// advisory reports whether the secondary at index i of SecondaryConfigs is advisory
+func (c *Config) advisory(i int) bool {
+	return i < len(c.Advisory) && c.Advisory[i]
+}
+
+// markAdvisory flags every result of an advisory tier, so its denials are
+// reported without denying the request
+func markAdvisory(results strategies.Results) {
+	for name, result := range results {
+		result.Advisory = true
+		results[name] = result
+	}
+}
+
+// secondaryPrefixes returns the results prefix for each secondary config.
+//
+// A single secondary keeps the "secondary_" prefix. With multiple secondaries,
+// each prefix includes the strategy ID (e.g. "secondary_tokenbucket_"), with a
+// positional suffix when the same strategy appears more than once.
+func secondaryPrefixes(configs []strategies.Config) []string {
+	if len(configs) == 1 {
+		return []string{"secondary_"}
+	}
+
+	names := make([]string, len(configs))
+	seen := make(map[string]int, len(configs))
+	for i, sc := range configs {
+		names[i] = strings.ReplaceAll(sc.ID().String(), "_", "")
+		seen[names[i]]++
+	}
+
+	prefixes := make([]string, len(configs))
+	for i, name := range names {
+		if seen[name] > 1 {
+			prefixes[i] = fmt.Sprintf("secondary_%s%d_", name, i+1)
+		} else {
+			prefixes[i] = "secondary_" + name + "_"
+		}
+	}
+	return prefixes
+}
+
 // ID returns the unique identifier for the composite strategy.
 //
 // This method implements the Config interface and returns StrategyComposite,
@@ -85,7 +159,8 @@ func (c *Config) Capabilities() strategies.CapabilityFlags {
 //
 //	"{BaseKey}:{key}:c"
 //
-// The ":c" suffix distinguishes composite state from individual strategy states.
+// The ":c" suffix distinguishes composite state from individual strategy
+// states, see CompositeKeyVersion for the full layout.
 // Primary and secondary configs are not given keys directly since they use
 // the singleKeyAdapter internally for coordinated storage.
 func (c *Config) WithKey(key string) strategies.Config {
@@ -95,7 +170,8 @@ func (c *Config) WithKey(key string) strategies.Config {
 	sb.WriteString(c.BaseKey)
 	sb.WriteString(":")
 	sb.WriteString(key)
-	sb.WriteString(":c")
+	cfg.key = sb.String()
+	sb.WriteString(CompositeKeySuffix)
 	cfg.compositeKey = sb.String()
 
 	return &cfg
@@ -113,9 +189,13 @@ func (c *Config) CompositeKey() string {
 // GetMaxRetries returns the maximum retry attempts for CAS operations.
 //
 // Returns the minimum of the primary and secondary strategy retry counts to ensure
-// both strategies can complete their operations within the retry budget.
+// all strategies can complete their operations within the retry budget.
 func (c *Config) GetMaxRetries() int {
-	return min(c.Primary.GetMaxRetries(), c.Secondary.GetMaxRetries())
+	retries := c.Primary.GetMaxRetries()
+	for _, sc := range c.SecondaryConfigs() {
+		retries = min(retries, sc.GetMaxRetries())
+	}
+	return retries
 }
 
 // WithMaxRetries applies the retry limit to both primary and secondary configs.
@@ -128,5 +208,112 @@ func (c *Config) WithMaxRetries(retries int) strategies.Config {
 	cfg := *c
 	cfg.Primary = c.Primary.WithMaxRetries(retries)
 	cfg.Secondary = c.Secondary.WithMaxRetries(retries)
+	cfg.ExtraSecondaries = make([]strategies.Config, len(c.ExtraSecondaries))
+	for i, sc := range c.ExtraSecondaries {
+		cfg.ExtraSecondaries[i] = sc.WithMaxRetries(retries)
+	}
 	return &cfg
 }
+
+// WithRetryBackoff applies the retry backoff to the composite CAS loop.
+//
+// The backoff is also forwarded to primary and secondary configs that
+// implement strategies.RetryBackoffConfig, mirroring WithMaxRetries.
+func (c *Config) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	if bc, ok := c.Primary.(strategies.RetryBackoffConfig); ok {
+		cfg.Primary = bc.WithRetryBackoff(backoff)
+	}
+	if bc, ok := c.Secondary.(strategies.RetryBackoffConfig); ok {
+		cfg.Secondary = bc.WithRetryBackoff(backoff)
+	}
+	cfg.ExtraSecondaries = make([]strategies.Config, len(c.ExtraSecondaries))
+	for i, sc := range c.ExtraSecondaries {
+		if bc, ok := sc.(strategies.RetryBackoffConfig); ok {
+			sc = bc.WithRetryBackoff(backoff)
+		}
+		cfg.ExtraSecondaries[i] = sc
+	}
+	return &cfg
+}
+
+// WithActor applies the actor of the request to the primary and secondary
+// configs that implement strategies.ActorConfig, e.g. a unique secondary.
+func (c *Config) WithActor(actor string) strategies.Config {
+	cfg := *c
+	if ac, ok := c.Primary.(strategies.ActorConfig); ok {
+		cfg.Primary = ac.WithActor(actor)
+	}
+	if ac, ok := c.Secondary.(strategies.ActorConfig); ok {
+		cfg.Secondary = ac.WithActor(actor)
+	}
+	cfg.ExtraSecondaries = make([]strategies.Config, len(c.ExtraSecondaries))
+	for i, sc := range c.ExtraSecondaries {
+		if ac, ok := sc.(strategies.ActorConfig); ok {
+			sc = ac.WithActor(actor)
+		}
+		cfg.ExtraSecondaries[i] = sc
+	}
+	return &cfg
+}
+
+// WithRand applies the random source to the composite retry jitter and to
+// the primary and secondary configs that implement strategies.RandConfig.
+func (c *Config) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff.Rand = rng
+	if rc, ok := c.Primary.(strategies.RandConfig); ok {
+		cfg.Primary = rc.WithRand(rng)
+	}
+	if rc, ok := c.Secondary.(strategies.RandConfig); ok {
+		cfg.Secondary = rc.WithRand(rng)
+	}
+	cfg.ExtraSecondaries = make([]strategies.Config, len(c.ExtraSecondaries))
+	for i, sc := range c.ExtraSecondaries {
+		if rc, ok := sc.(strategies.RandConfig); ok {
+			sc = rc.WithRand(rng)
+		}
+		cfg.ExtraSecondaries[i] = sc
+	}
+	return &cfg
+}
+
+// WithIdleTTL applies the idle TTL of the request to the primary and secondary
+// configs that implement strategies.IdleTTLConfig.
+func (c *Config) WithIdleTTL(idleTTL time.Duration) strategies.Config {
+	cfg := *c
+	if ic, ok := c.Primary.(strategies.IdleTTLConfig); ok {
+		cfg.Primary = ic.WithIdleTTL(idleTTL)
+	}
+	if ic, ok := c.Secondary.(strategies.IdleTTLConfig); ok {
+		cfg.Secondary = ic.WithIdleTTL(idleTTL)
+	}
+	cfg.ExtraSecondaries = make([]strategies.Config, len(c.ExtraSecondaries))
+	for i, sc := range c.ExtraSecondaries {
+		if ic, ok := sc.(strategies.IdleTTLConfig); ok {
+			sc = ic.WithIdleTTL(idleTTL)
+		}
+		cfg.ExtraSecondaries[i] = sc
+	}
+	return &cfg
+}
+
+// SteadyStateRate returns the long-run requests per second allowed, the
+// lowest rate of the enforcing tiers implementing
+// strategies.SteadyStateRateConfig, since every tier must allow a request.
+//
+// Advisory tiers never deny and are ignored. Returns +Inf when no tier
+// limits the rate, e.g. with only concurrency limits.
+func (c *Config) SteadyStateRate() float64 {
+	rate := math.Inf(1)
+	if rc, ok := c.Primary.(strategies.SteadyStateRateConfig); ok {
+		rate = rc.SteadyStateRate()
+	}
+	for i, sc := range c.SecondaryConfigs() {
+		if rc, ok := sc.(strategies.SteadyStateRateConfig); ok && !c.advisory(i) {
+			rate = min(rate, rc.SteadyStateRate())
+		}
+	}
+	return rate
+}
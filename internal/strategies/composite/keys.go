@@ -0,0 +1,30 @@
+package composite
+
+import "strconv"
+
+// CompositeKeyVersion is the version of the storage key layout of composite
+// strategies. Keys are derived from "{BaseKey}:{key}", the base key and the
+// dynamic key, with a suffix per layout:
+//
+//	combined dual state: "{BaseKey}:{key}:c"
+//	split tier n:        "{BaseKey}:{key}:c{n}"  (n counts tiers from 1, primary first)
+//	any tier n:          "{BaseKey}:{key}:a{n}"  (n counts tiers from 1)
+//
+// Version 1 is the only layout so far. A change to any of these keys must
+// increment the version and keep reading state under the previous keys, or
+// upgrading would orphan existing state and reset every limit.
+const CompositeKeyVersion = 1
+
+const (
+	// CompositeKeySuffix is the suffix of the combined dual state key
+	CompositeKeySuffix = ":c"
+	// SplitKeyMarker precedes the tier number of split tier keys
+	SplitKeyMarker = ":c"
+	// AnyKeyMarker precedes the tier number of any tier keys
+	AnyKeyMarker = ":a"
+)
+
+// tierKey returns the storage key of tier n, counted from 1, under prefix
+func tierKey(prefix, marker string, n int) string {
+	return prefix + marker + strconv.Itoa(n)
+}
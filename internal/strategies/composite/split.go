@@ -0,0 +1,229 @@
+package composite
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// splitTier is a tier of a split strategy, bound to its own backend
+type splitTier struct {
+	role     string // "primary" or "secondary", used in error messages
+	prefix   string // results prefix, e.g. "primary_" or "secondary_"
+	strategy strategies.Strategy
+}
+
+// SplitStrategy implements dual-strategy behavior over tiers stored on
+// different backends, e.g. a durable hard limit with an in-memory smoother
+type SplitStrategy struct {
+	tiers []splitTier
+}
+
+// NewSplit creates a dual strategy storing every tier on its own backend.
+//
+// tierBackends holds the backend of the primary followed by those of the
+// secondaries in configuration order. Every tier stores its state under its
+// own key "{BaseKey}:{key}:c{n}", n counting tiers from 1, the primary first.
+//
+// Tiers are not updated atomically: they are consumed in order once all of
+// them allow, and a tier denying a request that raced past its peek refunds
+// the earlier tiers where they support it.
+func NewSplit(tierBackends []backends.Backend, pConfig strategies.Config, sConfig strategies.Config, extra ...strategies.Config) (*SplitStrategy, error) {
+	// Tier configs are validated like those of an atomic composite
+	if _, err := New(nil, pConfig, sConfig, extra...); err != nil {
+		return nil, err
+	}
+
+	configs := append([]strategies.Config{pConfig, sConfig}, extra...)
+	if len(tierBackends) != len(configs) {
+		return nil, fmt.Errorf("split strategy has %d tiers, got %d backends", len(configs), len(tierBackends))
+	}
+
+	prefixes := append([]string{"primary_"}, secondaryPrefixes(configs[1:])...)
+	s := &SplitStrategy{tiers: make([]splitTier, len(configs))}
+	for i, tc := range configs {
+		role := "secondary"
+		if i == 0 {
+			role = "primary"
+		}
+		if tierBackends[i] == nil {
+			return nil, fmt.Errorf("%s strategy backend cannot be nil", role)
+		}
+		strategy, err := strategies.Create(tc.ID(), tierBackends[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s strategy: %w", role, err)
+		}
+		s.tiers[i] = splitTier{role: role, prefix: prefixes[i], strategy: strategy}
+	}
+	return s, nil
+}
+
+// splitConfigs returns the tier configs with their split storage keys applied
+func (c *Config) splitConfigs() []strategies.Config {
+	secondaries := c.SecondaryConfigs()
+	configs := make([]strategies.Config, 0, 1+len(secondaries))
+	configs = append(configs, c.Primary.WithKey(tierKey(c.key, SplitKeyMarker, 1)))
+	for i, sc := range secondaries {
+		configs = append(configs, sc.WithKey(tierKey(c.key, SplitKeyMarker, i+2)))
+	}
+	return configs
+}
+
+// prepareSplit validates the composite config and returns its keyed tier configs
+func (s *SplitStrategy) prepareSplit(sci strategies.Config) ([]strategies.Config, error) {
+	cfg, ok := sci.(*Config)
+	if !ok {
+		return nil, fmt.Errorf("composite strategy requires CompositeConfig")
+	}
+	if cfg.key == "" {
+		return nil, fmt.Errorf("composite key not set, call WithKey first")
+	}
+	configs := cfg.splitConfigs()
+	if len(configs) != len(s.tiers) {
+		return nil, fmt.Errorf("composite config has %d tiers, strategy has %d", len(configs), len(s.tiers))
+	}
+	return configs, nil
+}
+
+// Allow consumes a unit on every tier once all of them allow
+func (s *SplitStrategy) Allow(ctx context.Context, sci strategies.Config) (strategies.Results, error) {
+	return s.AllowCost(ctx, sci, 1)
+}
+
+// AllowCost consumes cost units on every tier once all of them allow.
+//
+// Every tier strategy must implement strategies.CostAllower unless cost is 1.
+func (s *SplitStrategy) AllowCost(ctx context.Context, sci strategies.Config, cost float64) (strategies.Results, error) {
+	configs, err := s.prepareSplit(sci)
+	if err != nil {
+		return nil, err
+	}
+
+	// Peek tiers in order, the first denying tier is the final decision without consuming
+	peekResults := make(strategies.Results)
+	for i, t := range s.tiers {
+		res, err := t.strategy.Peek(ctx, configs[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s strategy peek failed: %w", t.role, err)
+		}
+		s.markAdvisory(sci, i, res)
+		addPrefixed(peekResults, res, t.prefix)
+		if anyDenied(res) {
+			return peekResults, nil
+		}
+	}
+
+	allowResults := make(strategies.Results)
+	for i, t := range s.tiers {
+		res, err := allowCost(ctx, t.strategy, configs[i], cost)
+		if err != nil {
+			s.rollback(ctx, configs, i, cost)
+			return nil, fmt.Errorf("%s strategy allow failed: %w", t.role, err)
+		}
+		s.markAdvisory(sci, i, res)
+		addPrefixed(allowResults, res, t.prefix)
+		if anyDenied(res) {
+			// A tier denied despite its peek allowing, give back what the earlier tiers consumed
+			s.rollback(ctx, configs, i, cost)
+			return allowResults, nil
+		}
+	}
+	return allowResults, nil
+}
+
+// markAdvisory flags the results of tier i when the composite config makes it advisory
+func (s *SplitStrategy) markAdvisory(sci strategies.Config, i int, results strategies.Results) {
+	if i > 0 && sci.(*Config).advisory(i-1) {
+		markAdvisory(results)
+	}
+}
+
+// rollback refunds cost units, rounded down, on the tiers before tier n.
+// Refunds are best-effort, tiers that cannot refund keep the consumed quota.
+func (s *SplitStrategy) rollback(ctx context.Context, configs []strategies.Config, n int, cost float64) {
+	units := int(math.Floor(cost))
+	if units <= 0 {
+		return
+	}
+	for i, t := range s.tiers[:n] {
+		if refunder, ok := t.strategy.(strategies.Refunder); ok {
+			_ = refunder.Refund(ctx, configs[i], units)
+		}
+	}
+}
+
+// Peek inspects every tier without consuming quota
+func (s *SplitStrategy) Peek(ctx context.Context, sci strategies.Config) (strategies.Results, error) {
+	configs, err := s.prepareSplit(sci)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(strategies.Results)
+	for i, t := range s.tiers {
+		res, err := t.strategy.Peek(ctx, configs[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s results: %w", t.role, err)
+		}
+		s.markAdvisory(sci, i, res)
+		addPrefixed(results, res, t.prefix)
+	}
+	return results, nil
+}
+
+// Refund returns n units of quota to every tier
+func (s *SplitStrategy) Refund(ctx context.Context, sci strategies.Config, n int) error {
+	configs, err := s.prepareSplit(sci)
+	if err != nil {
+		return err
+	}
+
+	// Check every tier first so an unsupported one refunds nothing
+	for _, t := range s.tiers {
+		if _, ok := t.strategy.(strategies.Refunder); !ok {
+			return fmt.Errorf("%s strategy: %w", t.role, strategies.ErrRefundNotSupported)
+		}
+	}
+	for i, t := range s.tiers {
+		if err := t.strategy.(strategies.Refunder).Refund(ctx, configs[i], n); err != nil {
+			return fmt.Errorf("%s strategy refund failed: %w", t.role, err)
+		}
+	}
+	return nil
+}
+
+// Reset removes the state of every tier
+func (s *SplitStrategy) Reset(ctx context.Context, sci strategies.Config) error {
+	configs, err := s.prepareSplit(sci)
+	if err != nil {
+		return err
+	}
+
+	for i, t := range s.tiers {
+		if err := t.strategy.Reset(ctx, configs[i]); err != nil {
+			return fmt.Errorf("failed to reset %s strategy: %w", t.role, err)
+		}
+	}
+	return nil
+}
+
+// Inspect returns the stored state of every tier
+func (s *SplitStrategy) Inspect(ctx context.Context, sci strategies.Config) ([]strategies.State, error) {
+	configs, err := s.prepareSplit(sci)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []strategies.State
+	for i, t := range s.tiers {
+		tierStates, err := inspectTier(ctx, t.strategy, configs[i], t.prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect %s strategy: %w", t.role, err)
+		}
+		states = append(states, tierStates...)
+	}
+	return states, nil
+}
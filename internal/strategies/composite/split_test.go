@@ -0,0 +1,62 @@
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// splitMockStrategy records the backend it was created on and the keys it refunded
+type splitMockStrategy struct {
+	compMockStrategy
+	storage  backends.Backend
+	refunded []string
+}
+
+func (m *splitMockStrategy) Refund(_ context.Context, cfg strategies.Config, _ int) error {
+	m.refunded = append(m.refunded, cfg.(compMockConfig).key)
+	return nil
+}
+
+func TestSplitStrategyFlows(t *testing.T) {
+	allowed := strategies.Results{"q": {Allowed: true}}
+	primary := &splitMockStrategy{compMockStrategy: compMockStrategy{allowRes: allowed, getRes: allowed}}
+	secondary := &splitMockStrategy{compMockStrategy: compMockStrategy{allowRes: allowed, getRes: allowed}}
+	strategies.Register(strategies.ID(50), func(b backends.Backend) strategies.Strategy { primary.storage = b; return primary })
+	strategies.Register(strategies.ID(51), func(b backends.Backend) strategies.Strategy { secondary.storage = b; return secondary })
+	pConfig := compMockConfig{id: strategies.ID(50), caps: strategies.CapPrimary}
+	sConfig := compMockConfig{id: strategies.ID(51), caps: strategies.CapSecondary}
+
+	pBackend := &mockBackend{data: make(map[string]mockData)}
+	sBackend := &mockBackend{data: make(map[string]mockData)}
+	_, err := NewSplit([]backends.Backend{pBackend}, pConfig, sConfig)
+	require.ErrorContains(t, err, "got 1 backends")
+	split, err := NewSplit([]backends.Backend{pBackend, sBackend}, pConfig, sConfig)
+	require.NoError(t, err)
+	assert.Same(t, pBackend, primary.storage)
+	assert.Same(t, sBackend, secondary.storage)
+
+	cfg := (&Config{BaseKey: "k", Primary: pConfig, Secondary: sConfig}).WithKey("user")
+	ctx := t.Context()
+
+	res, err := split.Allow(ctx, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, strategies.Results{"primary_q": {Allowed: true}, "secondary_q": {Allowed: true}}, res)
+	assert.Empty(t, primary.refunded)
+
+	// A secondary denying after its peek allowed gives the primary unit back
+	secondary.allowRes = strategies.Results{"q": {Allowed: false}}
+	res, err = split.Allow(ctx, cfg)
+	require.NoError(t, err)
+	assert.False(t, res["secondary_q"].Allowed)
+	assert.Equal(t, []string{"k:user:c1"}, primary.refunded)
+
+	// Refunds reach every tier under its own key
+	require.NoError(t, split.Refund(ctx, cfg, 1))
+	assert.Equal(t, []string{"k:user:c1", "k:user:c1"}, primary.refunded)
+	assert.Equal(t, []string{"k:user:c2"}, secondary.refunded)
+}
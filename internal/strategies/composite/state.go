@@ -6,31 +6,39 @@ import (
 	"github.com/ajiwo/ratelimit/utils/builderpool"
 )
 
-// encodeState creates a composite state encoding from primary and secondary states
-// Format: 51|primaryState$secondaryState
-func encodeState(primaryState, secondaryState string) string {
+// encodeState creates a composite state encoding from the primary state followed
+// by one or more secondary states.
+// Format: 51|primaryState$secondaryState[$secondaryState...]
+func encodeState(states ...string) string {
 	sb := builderpool.Get()
 	defer builderpool.Put(sb)
 
 	sb.WriteString("51|")
-	sb.WriteString(primaryState)
-	sb.WriteString("$")
-	sb.WriteString(secondaryState)
+	for i, s := range states {
+		if i > 0 {
+			sb.WriteString("$")
+		}
+		sb.WriteString(s)
+	}
 	return sb.String()
 }
 
-// decodeState extracts primary and secondary states from composite encoding
-// Returns empty strings for both if decoding fails
-func decodeState(compositeState string) (primaryState, secondaryState string) {
+// decodeState extracts n tier states (primary first) from composite encoding.
+//
+// Returns n empty strings if decoding fails. Missing trailing states (e.g. a
+// secondary added after the state was written) decode as empty, fresh states.
+func decodeState(compositeState string, n int) []string {
+	states := make([]string, n)
 	if len(compositeState) < 3 || compositeState[:3] != "51|" {
-		return "", ""
+		return states
 	}
 
 	content := compositeState[3:] // Skip "51|"
-	parts := strings.SplitN(content, "$", 2)
-	if len(parts) != 2 {
-		return "", ""
+	parts := strings.Split(content, "$")
+	if len(parts) < 2 {
+		return states
 	}
 
-	return parts[0], parts[1]
+	copy(states, parts)
+	return states
 }
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+
+	"github.com/ajiwo/ratelimit/utils"
+)
+
+// contextKey is the context key type for the dynamic key stored by ContextWithKey
+type contextKey struct{}
+
+// ContextWithKey returns a copy of ctx carrying the dynamic key.
+//
+// Allow, Check, Peek, Reset and Refund use it when AccessOptions.Key is
+// empty and no KeyFunc derived a key, so layered middleware can compute the
+// key (e.g. the client IP) once upstream. An explicit AccessOptions.Key always takes precedence. The key is
+// validated like AccessOptions.Key unless SkipValidation is set.
+func ContextWithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// KeyFromContext returns the dynamic key stored by ContextWithKey, if any
+func KeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(contextKey{}).(string)
+	return key, ok && key != ""
+}
+
+// KeyFunc derives the dynamic key of a request, e.g. from AccessOptions.Metadata
+type KeyFunc func(options AccessOptions) string
+
+// WithKeyFunc centralizes dynamic key derivation in one function.
+//
+// It is consulted when AccessOptions.Key is empty; an empty result falls back
+// to the key from ContextWithKey, then to "default". Derived keys are
+// validated like AccessOptions.Key unless SkipValidation is set.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(config *Config) error {
+		if fn == nil {
+			return fmt.Errorf("key function cannot be nil")
+		}
+		config.keyFunc = fn
+		return nil
+	}
+}
+
+// dynamicKey validates (if enabled) and returns the dynamic key of a request.
+//
+// Precedence: AccessOptions.Key, KeyFunc, ContextWithKey, then "default".
+// The key is then qualified with the request tenant, if any.
+func (r *RateLimiter) dynamicKey(ctx context.Context, options AccessOptions) (string, error) {
+	key, err := r.requestKey(ctx, options)
+	if err != nil {
+		return "", err
+	}
+	return r.withTenant(ctx, options, key)
+}
+
+// requestKey resolves and validates the dynamic key of a request, without tenant
+func (r *RateLimiter) requestKey(ctx context.Context, options AccessOptions) (string, error) {
+	if options.Key == "" && r.keyFunc != nil {
+		options.Key = r.keyFunc(options)
+	}
+	if options.Key == "" {
+		options.Key, _ = KeyFromContext(ctx)
+	}
+	if options.Key != "" {
+		if !options.SkipValidation {
+			if err := validateKey(options.Key, "dynamic key"); err != nil {
+				return "", err
+			}
+		}
+		return options.Key, nil
+	}
+	return "default", nil
+}
+
+// KeyOf builds a canonical dynamic key from a typed value, e.g.
+// KeyOf("ip", addrPort) for the client of a request, so handlers do not
+// assemble keys from strings by hand.
+//
+// Values are formatted canonically:
+//   - netip.Addr and net.IP: IPv4-mapped IPv6 addresses become IPv4 and zones
+//     are dropped, so "::ffff:10.0.0.1" and "10.0.0.1" share a key
+//   - netip.AddrPort: the address alone, without the port, e.g. from
+//     netip.ParseAddrPort(r.RemoteAddr) for both "10.0.0.1:80" and "[::1]:80"
+//   - integers: decimal
+//   - [16]byte: a UUID in its canonical lowercase form
+//   - fmt.Stringer, e.g. uuid.UUID of common UUID packages: its String
+//   - anything else: fmt.Sprint
+//
+// The prefix and the value are joined with utils.JoinKey, which sanitizes
+// them, e.g. the colons of IPv6 addresses become underscores, and shortens
+// overlong keys. An empty prefix keys by the value alone.
+func KeyOf[T any](prefix string, v T) string {
+	value := keyValue(v)
+	if prefix == "" {
+		return utils.JoinKey(value)
+	}
+	return utils.JoinKey(prefix, value)
+}
+
+// keyValue formats v canonically for KeyOf
+func keyValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case netip.Addr:
+		return v.Unmap().WithZone("").String()
+	case netip.AddrPort:
+		return keyValue(v.Addr())
+	case net.IP:
+		addr, ok := netip.AddrFromSlice(v)
+		if !ok {
+			return v.String()
+		}
+		return keyValue(addr)
+	case int:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case [16]byte:
+		return formatUUID(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatUUID formats b as a UUID, e.g. "123e4567-e89b-12d3-a456-426614174000"
+func formatUUID(b [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"fmt"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+const (
+	// AllowListResultKey is the result name reported for allow-listed requests
+	AllowListResultKey = "allow_list"
+	// DenyListResultKey is the result name reported for deny-listed requests
+	DenyListResultKey = "deny_list"
+)
+
+// ListFunc reports whether a request is on a list, e.g. by client IP in
+// AccessOptions.Key or service account in AccessOptions.Metadata
+type ListFunc func(options AccessOptions) bool
+
+// WithAllowList bypasses rate limiting for requests matching fn.
+//
+// Allow, Check and Peek allow matching requests without running any strategy,
+// so they never consume quota nor touch the backend. Results hold a single
+// AllowListResultKey entry. The deny list, if any, is consulted first.
+func WithAllowList(fn ListFunc) Option {
+	return func(config *Config) error {
+		if fn == nil {
+			return fmt.Errorf("allow list function cannot be nil")
+		}
+		config.allowList = fn
+		return nil
+	}
+}
+
+// WithDenyList blocks requests matching fn.
+//
+// Allow, Check and Peek deny matching requests without running any strategy,
+// even with quota remaining, and without touching the backend. Results hold a
+// single DenyListResultKey entry with a zero Reset. The deny list is
+// consulted before the allow list, so a request on both is denied.
+func WithDenyList(fn ListFunc) Option {
+	return func(config *Config) error {
+		if fn == nil {
+			return fmt.Errorf("deny list function cannot be nil")
+		}
+		config.denyList = fn
+		return nil
+	}
+}
+
+// listDecision applies the deny and allow lists, in that order.
+//
+// Returns ok false when the request is on neither list and strategies decide.
+func (r *RateLimiter) listDecision(options AccessOptions) (allowed bool, results strategies.Results, ok bool) {
+	if r.denyList != nil && r.denyList(options) {
+		return false, strategies.Results{DenyListResultKey: {Allowed: false, Reason: strategies.DenyList}}, true
+	}
+	if r.allowList != nil && r.allowList(options) {
+		return true, strategies.Results{AllowListResultKey: {Allowed: true}}, true
+	}
+	return false, nil, false
+}
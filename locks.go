@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+)
+
+// WithLockCleanupInterval reclaims, every interval, the per-key locks that
+// in-process backends such as memory keep for every key ever accessed, so
+// high-cardinality keys, e.g. client IPs, don't grow memory without bound.
+//
+// Locks idle for at least interval whose key has no stored value are
+// removed, from the backends of the limiter implementing
+// backends.LockCleaner, including the memory backend of WithMemoryFailover.
+// Close stops the cleanup.
+func WithLockCleanupInterval(interval time.Duration) Option {
+	return func(config *Config) error {
+		if interval <= 0 {
+			return fmt.Errorf("lock cleanup interval must be positive, got %v", interval)
+		}
+		config.lockCleanupInterval = interval
+		return nil
+	}
+}
+
+// lockCleanup periodically reclaims idle backend locks, see WithLockCleanupInterval
+type lockCleanup struct {
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// startLockCleanup starts cleaning the locks of storages every interval, nil
+// if interval is 0
+func startLockCleanup(storages []backends.Backend, interval time.Duration) *lockCleanup {
+	var cleaners []backends.LockCleaner
+	for _, storage := range storages {
+		if cleaner, ok := storage.(backends.LockCleaner); ok {
+			cleaners = append(cleaners, cleaner)
+		}
+	}
+	if interval == 0 || len(cleaners) == 0 {
+		return nil
+	}
+
+	c := &lockCleanup{stop: make(chan struct{})}
+	c.wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				for _, cleaner := range cleaners {
+					cleaner.CleanupLocks(interval)
+				}
+			case <-c.stop:
+				return
+			}
+		}
+	})
+	return c
+}
+
+// close stops the cleanup and waits for a running pass to finish
+func (c *lockCleanup) close() {
+	if c == nil {
+		return
+	}
+	c.once.Do(func() { close(c.stop) })
+	c.wg.Wait()
+}
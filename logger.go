@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Logger receives the diagnostic messages of a limiter, see WithLogger.
+//
+// Arguments are alternating keys and values, as with log/slog. Methods are
+// called on the request path and must be safe for concurrent use.
+type Logger interface {
+	Debug(ctx context.Context, msg string, args ...any)
+	Info(ctx context.Context, msg string, args ...any)
+	Warn(ctx context.Context, msg string, args ...any)
+	Error(ctx context.Context, msg string, args ...any)
+}
+
+// WithLogger sets the logger of the limiter, which logs nothing by default.
+//
+// The limiter logs lost CheckAndSet attempts at Debug, circuit breaker
+// transitions of WithMemoryFailover at Info (Warn when opening), requests
+// failed open, exhausted retries and the warnings of strategies and backends,
+// e.g. repaired state or failed dual write mirror writes, at Warn, and other
+// failed requests and recovered hook panics at Error. NewSlogLogger adapts a
+// *slog.Logger.
+func WithLogger(logger Logger) Option {
+	return func(config *Config) error {
+		if logger == nil {
+			return fmt.Errorf("logger cannot be nil")
+		}
+		config.logger = logger
+		return nil
+	}
+}
+
+// NewSlogLogger returns a Logger writing to logger, slog.Default() if nil
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return slogLogger{logger: logger}
+}
+
+// slogLogger adapts a *slog.Logger to Logger
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.logger.DebugContext(ctx, msg, args...)
+}
+
+func (l slogLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.logger.InfoContext(ctx, msg, args...)
+}
+
+func (l slogLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.logger.WarnContext(ctx, msg, args...)
+}
+
+func (l slogLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.logger.ErrorContext(ctx, msg, args...)
+}
+
+// logAllowError logs a failed Allow, AllowN or Check request, if err is set
+func (r *RateLimiter) logAllowError(ctx context.Context, key string, err error, failedOpen bool) {
+	switch {
+	case err == nil:
+	case failedOpen:
+		r.logger.Warn(ctx, "ratelimit: backend unavailable, failing open", "key", key, "error", err)
+	case errors.Is(err, strategies.ErrMaxRetriesExceeded):
+		r.logger.Warn(ctx, "ratelimit: state update retries exhausted", "key", key, "error", err)
+	default:
+		r.logger.Error(ctx, "ratelimit: allow failed", "key", key, "error", err)
+	}
+}
+
+// logContext returns ctx carrying the limiter logger, so the warnings of
+// strategies and backends follow WithLogger, see backends.ContextWithLogger
+func (r *RateLimiter) logContext(ctx context.Context) context.Context {
+	return backends.ContextWithLogger(ctx, r.logger)
+}
+
+// nopLogger is the default Logger, discarding every message
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...any) {}
+func (nopLogger) Info(context.Context, string, ...any)  {}
+func (nopLogger) Warn(context.Context, string, ...any)  {}
+func (nopLogger) Error(context.Context, string, ...any) {}
+
+// breakerLogger logs the circuit breaker transitions of WithMemoryFailover.
+//
+// The failover backend is created while options are applied, possibly before
+// WithLogger, so the logger is set once the limiter is built and read
+// atomically by the health checker and request goroutines.
+type breakerLogger struct {
+	logger atomic.Pointer[Logger]
+	hook   func(from, to backends.BreakerState) // WithBreakerStateHook, may be nil
+}
+
+// onStateChange logs the transition and calls the registered hook
+func (b *breakerLogger) onStateChange(from, to backends.BreakerState) {
+	if logger := b.logger.Load(); logger != nil {
+		log := (*logger).Info
+		if to == backends.BreakerOpen {
+			log = (*logger).Warn
+		}
+		log(context.Background(), "ratelimit: circuit breaker state changed", "from", from.String(), "to", to.String())
+	}
+	if b.hook != nil {
+		b.hook(from, to)
+	}
+}
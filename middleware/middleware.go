@@ -0,0 +1,225 @@
+// Package middleware provides net/http rate limiting keyed by request attributes.
+//
+// The key of a request is built from KeyParts, e.g. the client IP and the
+// route, so each combination gets its own bucket:
+//
+//	handler := middleware.Handler(limiter, middleware.Config{
+//	    KeyParts: []func(*http.Request) string{middleware.ByIP, middleware.ByRoute},
+//	}, mux)
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit"
+	"github.com/ajiwo/ratelimit/utils"
+)
+
+// RetryAfterFormat is the format of the Retry-After header of denied requests
+type RetryAfterFormat int
+
+const (
+	// RetryAfterSeconds sends the whole seconds until the limiting tier
+	// resets, rounded up, e.g. "60"
+	RetryAfterSeconds RetryAfterFormat = iota
+	// RetryAfterHTTPDate sends the reset time of the limiting tier as an
+	// HTTP-date, rounded up to the second, e.g. "Sat, 01 Jan 2000 00:01:00 GMT"
+	RetryAfterHTTPDate
+)
+
+// Config configures the rate limiting middleware
+type Config struct {
+	// KeyParts extract the request attributes forming the key, the client IP
+	// of ByClientIP(TrustedProxyHops) when empty. Values are sanitized and
+	// joined with utils.JoinKey.
+	KeyParts []func(*http.Request) string
+
+	// TrustedProxyHops is the number of reverse proxies in front of the
+	// server whose forwarded headers are trusted for the default key, see
+	// ByClientIP. Zero keys by RemoteAddr and ignores forwarded headers.
+	TrustedProxyHops int
+
+	// Skip reports requests passed to next without limiting, e.g. GET
+	// /health probes. Skipped requests never reach the limiter, so they
+	// consume no quota and make no backend call. Nil limits every request.
+	Skip func(*http.Request) bool
+
+	// RetryAfterFormat is the format of the Retry-After header, seconds by
+	// default, for clients expecting an HTTP-date instead.
+	RetryAfterFormat RetryAfterFormat
+
+	// OnDenied writes the response for denied requests, after Retry-After is
+	// set. Defaults to a plain 429 Too Many Requests.
+	OnDenied http.Handler
+
+	// OnError writes the response when the limiter fails. Defaults to a
+	// plain 500 Internal Server Error.
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Handler wraps next with rate limiting by the key built from config.KeyParts.
+//
+// Allowed and skipped requests are passed to next. Denied requests get a
+// Retry-After header with the seconds until the limiting tier resets, or its
+// reset time, see Config.RetryAfterFormat.
+func Handler(limiter *ratelimit.RateLimiter, config Config, next http.Handler) http.Handler {
+	parts := config.KeyParts
+	if len(parts) == 0 {
+		parts = []func(*http.Request) string{ByClientIP(config.TrustedProxyHops)}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if config.Skip != nil && config.Skip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		decision, err := limiter.Check(r.Context(), ratelimit.AccessOptions{Key: Key(r, parts...)})
+		if err != nil {
+			if config.OnError != nil {
+				config.OnError(w, r, err)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if !decision.Allowed {
+			w.Header().Set("Retry-After", retryAfter(decision, config.RetryAfterFormat))
+			if config.OnDenied != nil {
+				config.OnDenied.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// retryAfter returns the Retry-After header value of a denied decision
+func retryAfter(decision *ratelimit.Decision, format RetryAfterFormat) string {
+	if format == RetryAfterHTTPDate {
+		result, ok := decision.Results[decision.LimitingTier]
+		at := result.Reset
+		if !ok || at.IsZero() {
+			at = time.Now().Add(decision.RetryAfter)
+		}
+		// HTTP-dates have whole seconds, rounding down would invite early retries
+		if rounded := at.Truncate(time.Second); rounded.Before(at) {
+			at = rounded.Add(time.Second)
+		}
+		return at.UTC().Format(http.TimeFormat)
+	}
+	return strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds())))
+}
+
+// Key builds the rate limit key of a request from the given parts
+func Key(r *http.Request, parts ...func(*http.Request) string) string {
+	values := make([]string, len(parts))
+	for i, part := range parts {
+		values[i] = part(r)
+	}
+	return utils.JoinKey(values...)
+}
+
+// ByIP keys by the client IP from RemoteAddr, see ClientIP.
+//
+// Behind reverse proxies, use ByClientIP instead.
+func ByIP(r *http.Request) string {
+	return ClientIP(r)
+}
+
+// ByClientIP keys by the client IP behind trustedHops reverse proxies.
+//
+// Each trusted proxy appends the address it received the request from to
+// X-Forwarded-For, so the client is the entry trustedHops from the end;
+// entries before it may be forged by the client and are ignored. Without
+// X-Forwarded-For, X-Real-IP set by the proxy is used. When neither header
+// holds a valid IP, or trustedHops is 0, the key is ClientIP(r). Only
+// trust as many hops as there are proxies that always set these headers,
+// otherwise clients can choose their own key.
+func ByClientIP(trustedHops int) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if trustedHops > 0 {
+			if ip, ok := forwardedIP(r.Header, trustedHops); ok {
+				return ip
+			}
+		}
+		return ClientIP(r)
+	}
+}
+
+// ClientIP returns the IP of the peer that sent r, from RemoteAddr without
+// the port, e.g. "10.0.0.1" for "10.0.0.1:1234" and "::1" for "[::1]:1234".
+//
+// IPv4-mapped IPv6 addresses are unmapped and zones dropped, so a client
+// keeps one key whatever form it connects with. Forwarded headers are
+// ignored, see ByClientIP. A RemoteAddr that is not an address is returned
+// as is.
+func ClientIP(r *http.Request) string {
+	if addrPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		return canonicalIP(addrPort.Addr())
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return canonicalIP(addr)
+	}
+	return host
+}
+
+// forwardedIP returns the client IP from the forwarded headers set by
+// trustedHops proxies
+func forwardedIP(header http.Header, trustedHops int) (string, bool) {
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for hop := range strings.SplitSeq(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	candidate := header.Get("X-Real-IP")
+	if len(hops) > 0 {
+		// Fewer entries than trusted hops all come from trusted proxies
+		candidate = hops[max(len(hops)-trustedHops, 0)]
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(candidate))
+	if err != nil {
+		return "", false
+	}
+	return canonicalIP(addr), true
+}
+
+// canonicalIP formats addr with IPv4-mapped addresses unmapped and without zone
+func canonicalIP(addr netip.Addr) string {
+	return addr.Unmap().WithZone("").String()
+}
+
+// ByRoute keys by the request method and URL path, e.g. "GET /api/users"
+func ByRoute(r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
+
+// ByMethod keys by the request method
+func ByMethod(r *http.Request) string {
+	return r.Method
+}
+
+// ByPath keys by the URL path
+func ByPath(r *http.Request) string {
+	return r.URL.Path
+}
+
+// ByHeader keys by the value of the named header, e.g. "X-API-Key"
+func ByHeader(name string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHandler(t *testing.T, limit int, config Config) http.Handler {
+	t.Helper()
+	limiter, err := ratelimit.New(
+		ratelimit.WithBackend(memory.New()),
+		ratelimit.WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", limit, time.Minute).Build()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	return Handler(limiter, config, ok)
+}
+
+// serve sends a request through handler and returns the recorded response
+func serve(handler http.Handler, method, path, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_IPAndRoute(t *testing.T) {
+	handler := newHandler(t, 2, Config{KeyParts: []func(*http.Request) string{ByIP, ByRoute}})
+
+	// Same IP and route share one bucket, whatever the client port
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/users", "10.0.0.1:1000", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/users", "10.0.0.1:2000", nil).Code)
+	denied := serve(handler, "GET", "/users", "10.0.0.1:3000", nil)
+	assert.Equal(t, http.StatusTooManyRequests, denied.Code)
+	assert.Equal(t, "60", denied.Header().Get("Retry-After"))
+
+	// Other routes from the same IP, and the same route from other IPs, are independent
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/orders", "10.0.0.1:1000", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(handler, "POST", "/users", "10.0.0.1:1000", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/users", "[::1]:1000", nil).Code)
+}
+
+func TestHandler_ByHeader(t *testing.T) {
+	handler := newHandler(t, 1, Config{KeyParts: []func(*http.Request) string{ByHeader("X-API-Key")}})
+	keyA := http.Header{"X-Api-Key": {"secret/a=="}}
+	keyB := http.Header{"X-Api-Key": {"secret/b=="}}
+
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/", "10.0.0.1:1000", keyA).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler, "GET", "/", "10.0.0.2:1000", keyA).Code)
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/", "10.0.0.1:1000", keyB).Code)
+}
+
+func TestHandler_DefaultsToIP(t *testing.T) {
+	handler := newHandler(t, 1, Config{})
+
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/a", "10.0.0.1:1000", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler, "GET", "/b", "10.0.0.1:1000", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/a", "10.0.0.2:1000", nil).Code)
+}
+
+func TestHandler_CustomResponses(t *testing.T) {
+	var gotErr error
+	limiter, err := ratelimit.New(
+		ratelimit.WithBackend(memory.New()),
+		ratelimit.WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", 1, time.Minute).Build()),
+		ratelimit.WithCostFunc(func(options ratelimit.AccessOptions) float64 {
+			if options.Key == "10.0.0.9" {
+				return -1 // invalid cost makes Check fail
+			}
+			return 1
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	handler := Handler(limiter, Config{
+		OnDenied: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+		OnError: func(w http.ResponseWriter, r *http.Request, err error) {
+			gotErr = err
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/", "10.0.0.1:1000", nil).Code)
+	denied := serve(handler, "GET", "/", "10.0.0.1:1000", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, denied.Code)
+	assert.NotEmpty(t, denied.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusBadGateway, serve(handler, "GET", "/", "10.0.0.9:1000", nil).Code)
+	assert.Error(t, gotErr)
+}
+
+func TestHandler_Skip(t *testing.T) {
+	keyed := 0
+	handler := newHandler(t, 1, Config{
+		KeyParts: []func(*http.Request) string{func(r *http.Request) string {
+			keyed++
+			return ByIP(r)
+		}},
+		Skip: func(r *http.Request) bool {
+			return r.Method == http.MethodGet && r.URL.Path == "/health"
+		},
+	})
+
+	// Skipped requests never reach the limiter, whatever the remaining quota
+	for range 3 {
+		assert.Equal(t, http.StatusOK, serve(handler, "GET", "/health", "10.0.0.1:1000", nil).Code)
+	}
+	assert.Zero(t, keyed)
+
+	// Other requests are limited, and the skipped ones consumed nothing
+	assert.Equal(t, http.StatusOK, serve(handler, "POST", "/api", "10.0.0.1:1000", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler, "POST", "/health", "10.0.0.1:1000", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler, "POST", "/api", "10.0.0.1:1000", nil).Code)
+	assert.Equal(t, 3, keyed)
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/health", "10.0.0.1:1000", nil).Code)
+}
+
+func TestHandler_RetryAfterFormat(t *testing.T) {
+	tests := []struct {
+		format RetryAfterFormat
+		want   string
+	}{
+		{RetryAfterSeconds, "51"},
+		{RetryAfterHTTPDate, "Sat, 01 Jan 2000 00:01:00 GMT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				// The window of the first request resets at 00:01:00
+				handler := newHandler(t, 1, Config{RetryAfterFormat: tt.format})
+				assert.Equal(t, http.StatusOK, serve(handler, "GET", "/", "10.0.0.1:1000", nil).Code)
+
+				time.Sleep(9500 * time.Millisecond)
+				denied := serve(handler, "GET", "/", "10.0.0.1:1000", nil)
+				assert.Equal(t, http.StatusTooManyRequests, denied.Code)
+				assert.Equal(t, tt.want, denied.Header().Get("Retry-After"))
+			})
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/users?page=2", nil)
+	req.RemoteAddr = "[2001:db8::1]:443"
+
+	assert.Equal(t, "2001_db8__1:GET__api_users", Key(req, ByIP, ByRoute))
+	assert.Equal(t, "GET:_api_users", Key(req, ByMethod, ByPath))
+	assert.Equal(t, "-", Key(req, ByHeader("X-API-Key")))
+}
+
+func TestClientIP(t *testing.T) {
+	for remoteAddr, want := range map[string]string{
+		"10.0.0.1:1234":          "10.0.0.1",
+		"[::1]:1234":             "::1",
+		"[2001:db8::1]:443":      "2001:db8::1",
+		"[::ffff:10.0.0.1]:1234": "10.0.0.1",
+		"[fe80::1%eth0]:1234":    "fe80::1",
+		"10.0.0.1":               "10.0.0.1",
+		"2001:db8::1":            "2001:db8::1",
+		"pipe":                   "pipe",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		assert.Equal(t, want, ClientIP(req), remoteAddr)
+	}
+}
+
+func TestByClientIP(t *testing.T) {
+	clientIP := func(hops int, header http.Header) string {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234" // the last proxy
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		return ByClientIP(hops)(req)
+	}
+	forwarded := func(values ...string) http.Header {
+		return http.Header{"X-Forwarded-For": values}
+	}
+
+	// Forwarded headers are ignored without trusted proxies
+	assert.Equal(t, "192.0.2.1", clientIP(0, forwarded("10.0.0.1")))
+	assert.Equal(t, "192.0.2.1", clientIP(0, http.Header{"X-Real-Ip": {"10.0.0.1"}}))
+
+	// One proxy appends the client address
+	assert.Equal(t, "10.0.0.1", clientIP(1, forwarded("10.0.0.1")))
+	assert.Equal(t, "2001:db8::1", clientIP(1, forwarded("2001:db8::1")))
+	assert.Equal(t, "10.0.0.1", clientIP(1, http.Header{"X-Real-Ip": {"10.0.0.1"}}))
+
+	// A client forging entries cannot choose its key
+	assert.Equal(t, "10.0.0.1", clientIP(1, forwarded("6.6.6.6, 10.0.0.1")))
+	assert.Equal(t, "10.0.0.1", clientIP(1, forwarded("6.6.6.6", "10.0.0.1")), "header lines are combined")
+	assert.Equal(t, "10.0.0.1", clientIP(2, forwarded("6.6.6.6, 10.0.0.1, 172.16.0.1")))
+
+	// Fewer entries than hops all come from trusted proxies
+	assert.Equal(t, "10.0.0.1", clientIP(3, forwarded("10.0.0.1, 172.16.0.1")))
+
+	// Invalid entries fall back to the peer address
+	assert.Equal(t, "192.0.2.1", clientIP(1, forwarded("unknown")))
+	assert.Equal(t, "192.0.2.1", clientIP(1, nil))
+}
+
+func TestHandler_TrustedProxyHops(t *testing.T) {
+	handler := newHandler(t, 1, Config{TrustedProxyHops: 1})
+	client := func(ip string) http.Header {
+		return http.Header{"X-Forwarded-For": {"6.6.6.6, " + ip}}
+	}
+
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/", "192.0.2.1:1000", client("10.0.0.1")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler, "GET", "/", "192.0.2.1:1000", client("10.0.0.1")).Code)
+	assert.Equal(t, http.StatusOK, serve(handler, "GET", "/", "192.0.2.1:1000", client("10.0.0.2")).Code,
+		"clients behind the same proxy are keyed apart")
+}
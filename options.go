@@ -10,24 +10,40 @@ import (
 	"github.com/ajiwo/ratelimit/internal/backends/composite"
 	"github.com/ajiwo/ratelimit/internal/healthchecker"
 	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
 )
 
 // Option is a functional option for configuring the rate limiter
 type Option func(*Config) error
 
 // WithPrimaryStrategy configures the primary rate limiting strategy with custom configuration
-func WithPrimaryStrategy(strategyConfig strategies.Config) Option {
+func WithPrimaryStrategy(strategyConfig strategies.Config, opts ...StrategyOption) Option {
 	return func(config *Config) error {
 		if strategyConfig == nil {
 			return fmt.Errorf("primary strategy config cannot be nil")
 		}
+		so, err := applyStrategyOptions(opts)
+		if err != nil {
+			return err
+		}
+		if so.advisory {
+			return fmt.Errorf("primary strategy cannot be advisory")
+		}
 		config.PrimaryConfig = strategyConfig
+		config.primaryStorage = so.backend
 		return nil
 	}
 }
 
-// WithSecondaryStrategy configures the secondary smoother strategy
-func WithSecondaryStrategy(strategyConfig strategies.Config) Option {
+// WithSecondaryStrategy configures the secondary smoother strategy.
+//
+// It may be given more than once to stack several smoothers (e.g. a token
+// bucket for bursts and a leaky bucket for steady pacing). A request is allowed
+// only when the primary and every secondary allow it, and quota is consumed on
+// all of them atomically. With multiple secondaries, result keys are prefixed
+// with the strategy name, e.g. "secondary_tokenbucket_default".
+func WithSecondaryStrategy(strategyConfig strategies.Config, opts ...StrategyOption) Option {
 	return func(config *Config) error {
 		if strategyConfig == nil {
 			return fmt.Errorf("secondary strategy config cannot be nil")
@@ -37,12 +53,172 @@ func WithSecondaryStrategy(strategyConfig strategies.Config) Option {
 		if !strategyConfig.Capabilities().Has(strategies.CapSecondary) {
 			return fmt.Errorf("strategy '%s' doesn't have secondary capability", strategyConfig.ID().String())
 		}
+		so, err := applyStrategyOptions(opts)
+		if err != nil {
+			return err
+		}
+
+		if config.SecondaryConfig == nil {
+			config.SecondaryConfig = strategyConfig
+			config.secondaryStorages = []backends.Backend{so.backend}
+			config.advisorySecondaries = []bool{so.advisory}
+			return nil
+		}
+		config.ExtraSecondaryConfigs = append(config.ExtraSecondaryConfigs, strategyConfig)
+		config.secondaryStorages = append(config.secondaryStorages, so.backend)
+		config.advisorySecondaries = append(config.advisorySecondaries, so.advisory)
+		return nil
+	}
+}
+
+// StrategyOption configures a strategy of WithPrimaryStrategy or WithSecondaryStrategy
+type StrategyOption func(*strategyOptions)
+
+// strategyOptions holds the per-strategy settings
+type strategyOptions struct {
+	backend  backends.Backend // nil uses the limiter-wide backend
+	advisory bool             // denials are reported without denying, see WithAdvisory
+	err      error
+}
+
+// WithStrategyBackend stores the state of the strategy on backend instead of
+// the limiter-wide WithBackend, e.g. a primary hard limit on Postgres with a
+// token bucket smoother on Redis or in local memory.
+//
+// When the tiers of a dual strategy are on different backends, each keeps its
+// own key and they are no longer updated atomically: all tiers are peeked, then
+// consumed in order, and a tier denying a request that raced past its peek
+// refunds the tiers consumed before it where they support refunds. Memory
+// failover, penalty lockouts, ListKeys and Warmup keep using the limiter-wide
+// backend, which remains required. The limiter does not close backend; the
+// caller owns it.
+func WithStrategyBackend(backend backends.Backend) StrategyOption {
+	return func(so *strategyOptions) {
+		if backend == nil {
+			so.err = fmt.Errorf("strategy backend cannot be nil")
+			return
+		}
+		so.backend = backend
+	}
+}
+
+// WithAdvisory makes a secondary strategy advisory: its denials are reported
+// in results, with Result.Advisory set, but do not deny the request, e.g. to
+// observe the impact of a proposed limit while only enforcing the current ones.
+//
+// An advisory tier consumes quota only when it allows the request. Its
+// denials are not the Decision's LimitingTier, do not count as denials in
+// Stats, and do not trigger penalties or OnExceeded. The primary strategy
+// cannot be advisory.
+func WithAdvisory() StrategyOption {
+	return func(so *strategyOptions) {
+		so.advisory = true
+	}
+}
+
+// applyStrategyOptions applies opts to default strategy options
+func applyStrategyOptions(opts []StrategyOption) (strategyOptions, error) {
+	var so strategyOptions
+	for _, opt := range opts {
+		opt(&so)
+	}
+	return so, so.err
+}
 
-		config.SecondaryConfig = strategyConfig
+// WithAnyStrategy configures tiers of which any one allowing a request is
+// enough, e.g. a regular quota followed by a paid overage bucket.
+//
+// Tiers are tried in order and only the first one that allows the request
+// is consumed; a request denied by every tier consumes nothing. Each tier
+// keeps its own state, updated atomically like a single strategy. Results are
+// prefixed with the tier, e.g. "tier2_default": those of the allowing tier,
+// or of every tier when denied. The first tier is the primary strategy, which
+// UpdateStrategy changes. It cannot be combined with WithSecondaryStrategy,
+// and refunds are not supported since the consumed tier is not recorded.
+func WithAnyStrategy(strategyConfigs ...strategies.Config) Option {
+	return func(config *Config) error {
+		if len(strategyConfigs) < 2 {
+			return fmt.Errorf("any strategy requires at least 2 strategy configs, got %d", len(strategyConfigs))
+		}
+		if err := WithPrimaryStrategy(strategyConfigs[0])(config); err != nil {
+			return err
+		}
+		for _, sc := range strategyConfigs[1:] {
+			if sc == nil {
+				return fmt.Errorf("any strategy config cannot be nil")
+			}
+			if !sc.Capabilities().Has(strategies.CapPrimary) {
+				return fmt.Errorf("strategy '%s' doesn't have primary capability", sc.ID().String())
+			}
+		}
+		config.AnyConfigs = strategyConfigs[1:]
 		return nil
 	}
 }
 
+// WithGCRAStrategy configures GCRA as the primary strategy.
+//
+// Rate is the sustained number of requests per second and burst is the
+// number of requests allowed at once; rate must be positive and burst at
+// least 1. Equivalent to WithPrimaryStrategy(&gcra.Config{Rate: rate, Burst: burst}).
+func WithGCRAStrategy(rate float64, burst int) Option {
+	return func(config *Config) error {
+		gcraConfig, err := newGCRAConfig(rate, burst)
+		if err != nil {
+			return err
+		}
+		return WithPrimaryStrategy(gcraConfig)(config)
+	}
+}
+
+// WithGCRASecondaryStrategy configures GCRA as a secondary smoother strategy.
+//
+// See WithGCRAStrategy for parameters and WithSecondaryStrategy for how
+// secondaries are combined with the primary strategy.
+func WithGCRASecondaryStrategy(rate float64, burst int) Option {
+	return func(config *Config) error {
+		gcraConfig, err := newGCRAConfig(rate, burst)
+		if err != nil {
+			return err
+		}
+		return WithSecondaryStrategy(gcraConfig)(config)
+	}
+}
+
+// WithBudgetStrategy configures a budget of units per window as the primary strategy.
+//
+// It limits a summed quantity, e.g. bytes of bandwidth, rather than requests:
+// each AllowN charges n units and is denied once the budget would be exceeded,
+// however few requests that took. The budget resets at the end of every window,
+// which starts on the first request. Results are reported under the "budget"
+// key, with the remaining budget in units. Equivalent to a fixed window with
+// a single quota; budget and window must be positive.
+func WithBudgetStrategy(budget int64, window time.Duration) Option {
+	return func(config *Config) error {
+		if budget <= 0 {
+			return fmt.Errorf("budget must be positive, got %d", budget)
+		}
+		budgetConfig := &fixedwindow.Config{Quotas: []fixedwindow.Quota{{
+			Name:   "budget",
+			Limit:  int(min(budget, math.MaxInt)),
+			Window: window,
+		}}}
+		if err := budgetConfig.Validate(); err != nil {
+			return err
+		}
+		return WithPrimaryStrategy(budgetConfig)(config)
+	}
+}
+
+// newGCRAConfig creates a validated GCRA config
+func newGCRAConfig(rate float64, burst int) (*gcra.Config, error) {
+	gcraConfig := &gcra.Config{Rate: rate, Burst: burst}
+	if err := gcraConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return gcraConfig, nil
+}
+
 // WithBaseKey sets the base key for rate limiting
 func WithBaseKey(key string) Option {
 	return func(config *Config) error {
@@ -54,8 +230,13 @@ func WithBaseKey(key string) Option {
 // AccessOptions holds the configuration for a rate limiter access operation
 type AccessOptions struct {
 	Key            string              // Dynamic key
+	Tenant         string              // Tenant isolating the dynamic key, overrides ContextWithTenant and WithTenant
 	SkipValidation bool                // Skip key validation
 	Result         *strategies.Results // Optional results pointer
+	Metadata       map[string]any      // Optional request context passed to hooks and echoed in results, never persisted
+	Priority       Priority            // Load shedding class, see WithPriorityThresholds, overrides ContextWithPriority unless PriorityHigh
+	Actor          string              // Actor of the request counted by unique strategies, see WithUniqueStrategy
+	IdleTTL        time.Duration       // Expiration of the key's state after this request when idle, 0 uses the default, see strategies.IdleTTLConfig
 }
 
 // WithBackend configures the rate limiter to use a custom backend
@@ -64,7 +245,7 @@ func WithBackend(backend backends.Backend) Option {
 		if backend == nil {
 			return fmt.Errorf("backend cannot be nil")
 		}
-		if config.Storage != nil {
+		if config.Storage != nil && !config.validateOnly {
 			err := config.Storage.Close()
 			if err != nil {
 				return fmt.Errorf("failed to close existing backend: %w", err)
@@ -75,6 +256,26 @@ func WithBackend(backend backends.Backend) Option {
 	}
 }
 
+// WithStateCodec sets how strategy state is stored in the backend.
+//
+// The default stores the compact strategy format, e.g. "23|1|default|2|...".
+// backends.JSONCodec stores a JSON object instead, to inspect values with
+// backend tools such as redis-cli. Existing values in either format are read
+// and updated transparently, so the codec can be changed on a live backend;
+// to switch back, use backends.CompactCodec until old JSON values expired.
+// Non-default codecs cost an extra read when a CheckAndSet loses, and
+// disable server-side fixed window updates (backends.WindowIncrementer).
+// Penalty lockouts keep their own format.
+func WithStateCodec(codec backends.Codec) Option {
+	return func(config *Config) error {
+		if codec == nil {
+			return fmt.Errorf("state codec cannot be nil")
+		}
+		config.stateCodec = codec
+		return nil
+	}
+}
+
 // WithMaxRetries configures the maximum number of retry attempts for atomic CheckAndSet operations.
 // This is used by all strategies that perform optimistic locking (Fixed Window, Token Bucket, Leaky Bucket, GCRA).
 //
@@ -84,8 +285,14 @@ func WithBackend(backend backends.Backend) Option {
 // on each attempt.
 //
 // If retries is 0 or not set, the system uses retry calculation:
-//   - For continuous strategies (Token Bucket, Leaky Bucket, GCRA): uses burst capacity
-//   - For Fixed Window: uses limit from the most restrictive quota available
+//   - For continuous strategies (Token Bucket, Leaky Bucket, GCRA): uses burst capacity + 1
+//   - For Fixed Window: uses limit + 1 from the most restrictive quota available
+//   - For dual strategies: uses the smallest count of all tiers for the composite CAS loop
+//
+// Every lost CAS means another request consumed quota, so these defaults are enough for a
+// request to either commit or be denied once the quota is used up. An explicit count applies
+// to the composite CAS loop and to every tier alike. Operations that run out of attempts fail
+// with an error wrapping strategies.ErrMaxRetriesExceeded.
 //
 // To disable retries entirely, set retries to 1 (a single attempt without any retries).
 func WithMaxRetries(retries int) Option {
@@ -104,6 +311,33 @@ func WithMaxRetries(retries int) Option {
 	}
 }
 
+// WithRetryBackoff configures the delay between retry attempts for atomic CheckAndSet operations.
+//
+// By default, retries use a feedback-based delay derived from the duration of the last failed
+// attempt, which stays very short on fast backends and can spin CPU on hot keys. With this option,
+// the delay starts at initial and doubles on every attempt, capped at maxDelay. When jitter is true,
+// each delay is randomized within [delay/2, delay) so contending writers drift apart.
+//
+// The backoff always honors the context: if the context deadline would pass before the next
+// delay elapses, the operation fails immediately instead of sleeping. Combined with WithMaxRetries,
+// the total time spent retrying is bounded by both the retry count and the context deadline.
+func WithRetryBackoff(initial, maxDelay time.Duration, jitter bool) Option {
+	return func(config *Config) error {
+		if initial <= 0 {
+			return fmt.Errorf("retry backoff initial delay must be positive, got %v", initial)
+		}
+		if maxDelay < initial {
+			return fmt.Errorf("retry backoff max delay (%v) cannot be less than initial delay (%v)", maxDelay, initial)
+		}
+		config.retryBackoff = strategies.Backoff{
+			Initial: initial,
+			Max:     maxDelay,
+			Jitter:  jitter,
+		}
+		return nil
+	}
+}
+
 // MemoryFailoverOption configures memory failover behavior
 type MemoryFailoverOption func(*failoverConfig)
 
@@ -114,6 +348,9 @@ type failoverConfig struct {
 	healthInterval   time.Duration
 	healthTimeout    time.Duration
 	healthTestKey    string
+	onStateChange    func(from, to backends.BreakerState)
+	isFailure        func(error) bool
+	reconcile        bool
 }
 
 // WithFailureThreshold configures the number of consecutive failures before opening circuit
@@ -156,6 +393,42 @@ func WithHealthTestKey(key string) MemoryFailoverOption {
 	}
 }
 
+// WithFailureClassifier sets which primary errors count as failures toward
+// the failure threshold. Other errors, e.g. a rejected value, are returned to
+// the caller without tripping the breaker. The default is
+// backends.IsBackendFailure, which counts connectivity errors and timeouts.
+func WithFailureClassifier(isFailure func(error) bool) MemoryFailoverOption {
+	return func(fc *failoverConfig) {
+		fc.isFailure = isFailure
+	}
+}
+
+// WithReconcileOnRecovery copies the state written to the memory backend
+// during an outage to the primary backend when it recovers, before requests go
+// back to it, so that clients keep their remaining quota instead of getting
+// the pre-outage state. Up to 10000 keys are tracked; copies are best-effort.
+//
+// The copy runs in the background for at most 30s, the breaker closing once
+// it is done. Each key is copied with CheckAndSet, so a newer write reaching
+// the primary meanwhile is kept.
+func WithReconcileOnRecovery() MemoryFailoverOption {
+	return func(fc *failoverConfig) {
+		fc.reconcile = true
+	}
+}
+
+// WithBreakerStateHook registers a function called on every circuit breaker
+// transition, e.g. to alert when requests are served by the memory backend
+// (closed to open) and when the primary is back (to closed).
+//
+// It is called synchronously, once per transition, on the goroutine of the
+// request or health check that caused it, so it must not block.
+func WithBreakerStateHook(fn func(from, to backends.BreakerState)) MemoryFailoverOption {
+	return func(fc *failoverConfig) {
+		fc.onStateChange = fn
+	}
+}
+
 // WithMemoryFailover configures automatic failover to a memory backend when the primary backend fails.
 // This provides resilience by falling back to in-memory storage during backend outages.
 //
@@ -164,7 +437,8 @@ func WithHealthTestKey(key string) MemoryFailoverOption {
 //   - The circuit breaker is OPEN (default recovery timeout: 30s)
 //
 // This option prioritizes service availability over strict state consistency:
-//   - No State Synchronization: Primary and memory backends maintain independent state
+//   - No State Synchronization: Primary and memory backends maintain independent state,
+//     unless WithReconcileOnRecovery copies the outage state back to the primary
 //   - State Fragmentation During Failover: Users may get full or partial quota resets when switching backends
 //   - Self-Correction Over Time: State consistency resumes naturally through normal rate limiting operations
 //
@@ -190,7 +464,7 @@ func WithMemoryFailover(opts ...MemoryFailoverOption) Option {
 		}
 
 		// Prevent nested failovers
-		if _, ok := config.Storage.(*composite.Backend); ok {
+		if _, ok := config.Storage.(*composite.Backend); ok || (config.validateOnly && config.breakerLogger != nil) {
 			return fmt.Errorf("memory failover cannot be enabled on a composite backend to prevent nested failovers")
 		}
 
@@ -208,6 +482,12 @@ func WithMemoryFailover(opts ...MemoryFailoverOption) Option {
 			opt(fc)
 		}
 
+		config.breakerLogger = &breakerLogger{hook: fc.onStateChange}
+		if config.validateOnly {
+			// Validate creates no backend and starts no health checks
+			return nil
+		}
+
 		// Create memory backend as secondary
 		memoryBackend := memory.New()
 
@@ -218,12 +498,15 @@ func WithMemoryFailover(opts ...MemoryFailoverOption) Option {
 			CircuitBreaker: composite.BreakerConfig{
 				FailureThreshold: fc.failureThreshold,
 				RecoveryTimeout:  fc.recoveryTimeout,
+				OnStateChange:    config.breakerLogger.onStateChange,
 			},
 			HealthChecker: healthchecker.Config{
 				Interval: fc.healthInterval,
 				Timeout:  fc.healthTimeout,
 				TestKey:  fc.healthTestKey,
 			},
+			IsFailure:           fc.isFailure,
+			ReconcileOnRecovery: fc.reconcile,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create memory failover backend: %w", err)
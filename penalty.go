@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// PenaltyResultKey is the result key reporting an active penalty lockout
+const PenaltyResultKey = "penalty"
+
+// PenaltyConfig escalates lockouts for keys that repeatedly hit the limit
+type PenaltyConfig struct {
+	Base       time.Duration // Lockout after the first denial
+	Max        time.Duration // Upper bound of the lockout
+	Multiplier float64       // Lockout growth per repeated denial, e.g. 2 doubles it
+	Decay      time.Duration // Quiet period after a lockout that starts a key over at Base, 0 means Max
+}
+
+// penaltyState is the persisted penalty of a key
+type penaltyState struct {
+	strikes int       // Denials since the key was last quiet
+	until   time.Time // End of the current lockout
+}
+
+// WithPenalty locks out keys that hit the limit, for brute-force protection.
+//
+// The first denial blocks the key for Base; every denial after a lockout
+// ended multiplies the lockout by Multiplier, up to Max. A key that stays
+// quiet for Decay after its last lockout starts over at Base. Requests during
+// a lockout are denied without consuming quota, and their results contain a
+// PenaltyResultKey entry whose Reset is the end of the lockout.
+//
+// The penalty state is stored in the backend next to the strategy state, so
+// every limiter sharing the backend sees the same lockouts. Concurrent
+// denials of one key count as a single strike.
+func WithPenalty(penalty PenaltyConfig) Option {
+	return func(config *Config) error {
+		if penalty.Base <= 0 {
+			return fmt.Errorf("penalty base must be positive, got %v", penalty.Base)
+		}
+		if penalty.Max < penalty.Base {
+			return fmt.Errorf("penalty max (%v) cannot be less than base (%v)", penalty.Max, penalty.Base)
+		}
+		if math.IsNaN(penalty.Multiplier) || math.IsInf(penalty.Multiplier, 0) || penalty.Multiplier < 1 {
+			return fmt.Errorf("penalty multiplier must be at least 1, got %v", penalty.Multiplier)
+		}
+		if penalty.Decay < 0 {
+			return fmt.Errorf("penalty decay cannot be negative, got %v", penalty.Decay)
+		}
+		if penalty.Decay == 0 {
+			penalty.Decay = penalty.Max
+		}
+		config.penalty = &penalty
+		return nil
+	}
+}
+
+// penaltyKey returns the storage key of the penalty state of a dynamic key
+func (r *RateLimiter) penaltyKey(dynamicKey string) string {
+	return r.basePrefix + dynamicKey + ":p"
+}
+
+// activePenalty returns the denied penalty result if the key is locked out.
+//
+// The raw stored value is returned for a later escalatePenalty.
+func (r *RateLimiter) activePenalty(ctx context.Context, dynamicKey string, now time.Time) (penaltyState, string, strategies.Results, error) {
+	raw, err := r.storage().Get(ctx, r.penaltyKey(dynamicKey))
+	if err != nil {
+		return penaltyState{}, "", nil, fmt.Errorf("penalty check failed: %w", err)
+	}
+	state, ok := decodePenalty(raw)
+	if !ok {
+		return penaltyState{}, "", nil, fmt.Errorf("penalty check failed: invalid state for key %q", dynamicKey)
+	}
+	if now.Before(state.until) {
+		return state, raw, penaltyResults(state.until), nil
+	}
+	return state, raw, nil, nil
+}
+
+// escalatePenalty starts the next, longer lockout of a key that was just denied.
+//
+// A lost CheckAndSet means a concurrent denial already escalated, so it is
+// not retried. Returns when the new lockout ends.
+func (r *RateLimiter) escalatePenalty(ctx context.Context, dynamicKey string, state penaltyState, raw string, now time.Time) (time.Time, error) {
+	p := r.penalty
+	if state.strikes > 0 && now.Sub(state.until) >= p.Decay {
+		state.strikes = 0
+	}
+	state.strikes++
+
+	lockout := p.Max
+	if growth := math.Pow(p.Multiplier, float64(state.strikes-1)); float64(p.Base)*growth < float64(p.Max) {
+		lockout = time.Duration(float64(p.Base) * growth)
+	}
+	state.until = now.Add(lockout)
+
+	_, err := r.storage().CheckAndSet(ctx, r.penaltyKey(dynamicKey), raw, encodePenalty(state), lockout+p.Decay)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("penalty update failed: %w", err)
+	}
+	return state.until, nil
+}
+
+// resetPenalty removes the penalty state of a key
+func (r *RateLimiter) resetPenalty(ctx context.Context, dynamicKey string) error {
+	if r.penalty == nil {
+		return nil
+	}
+	if err := r.storage().Delete(ctx, r.penaltyKey(dynamicKey)); err != nil {
+		return fmt.Errorf("failed to reset penalty: %w", err)
+	}
+	return nil
+}
+
+// penaltyResults reports a lockout lasting until the given time
+func penaltyResults(until time.Time) strategies.Results {
+	return strategies.Results{
+		PenaltyResultKey: {Allowed: false, Remaining: 0, Reset: until, Reason: strategies.QuotaExhausted},
+	}
+}
+
+// encodePenalty serializes the state as "p|strikes|untilUnixNano"
+func encodePenalty(state penaltyState) string {
+	return "p|" + strconv.Itoa(state.strikes) + "|" + strconv.FormatInt(state.until.UnixNano(), 10)
+}
+
+// decodePenalty parses a stored state, an empty value is a key without penalty
+func decodePenalty(raw string) (penaltyState, bool) {
+	if raw == "" {
+		return penaltyState{}, true
+	}
+	fields := strings.Split(raw, "|")
+	if len(fields) != 3 || fields[0] != "p" {
+		return penaltyState{}, false
+	}
+	strikes, err := strconv.Atoi(fields[1])
+	if err != nil || strikes < 0 {
+		return penaltyState{}, false
+	}
+	until, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return penaltyState{}, false
+	}
+	return penaltyState{strikes: strikes, until: time.Unix(0, until)}, true
+}
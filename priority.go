@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// PriorityResultKey is the result key reporting a request shed for its priority
+const PriorityResultKey = "priority"
+
+// Priority classifies requests sharing a quota for load shedding, see
+// WithPriorityThresholds. Applications may define further classes, e.g.
+// Priority(2) for background jobs.
+type Priority int
+
+const (
+	// PriorityHigh is the default priority, allowed up to the whole quota
+	// unless given a threshold
+	PriorityHigh Priority = iota
+	// PriorityLow is shed first when given a lower threshold
+	PriorityLow
+)
+
+// priorityKey is the context key type for the priority stored by ContextWithPriority
+type priorityKey struct{}
+
+// ContextWithPriority returns a copy of ctx carrying the priority of the
+// request, e.g. set by an upstream middleware for background clients.
+//
+// Precedence: AccessOptions.Priority when not PriorityHigh, the zero value,
+// then ContextWithPriority, then PriorityHigh. An explicit PriorityHigh
+// therefore does not override a lower priority in the context.
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority stored by ContextWithPriority, if any
+func PriorityFromContext(ctx context.Context) (Priority, bool) {
+	priority, ok := ctx.Value(priorityKey{}).(Priority)
+	return priority, ok
+}
+
+// requestPriority returns the priority of a request, see ContextWithPriority
+func requestPriority(ctx context.Context, options AccessOptions) Priority {
+	if options.Priority != PriorityHigh {
+		return options.Priority
+	}
+	if priority, ok := PriorityFromContext(ctx); ok {
+		return priority
+	}
+	return PriorityHigh
+}
+
+// WithPriorityThresholds sheds requests by AccessOptions.Priority as a key
+// approaches its limit, e.g. {PriorityLow: 0.8} denies low priority requests
+// once 80% of the quota is used while high priority ones use all of it.
+//
+// A threshold is the fraction of every tier's limit a request may use,
+// itself included, in (0, 1]; priorities without one use 1. Requests with a
+// threshold below 1 peek before consuming, so concurrent requests may slightly
+// overshoot it. Shed requests consume no quota, do not count toward
+// WithPenalty, and their results contain a PriorityResultKey entry whose Reset
+// is that of the tier over the threshold. Results without a limit, such as
+// concurrency leases, are not considered.
+func WithPriorityThresholds(thresholds map[Priority]float64) Option {
+	return func(config *Config) error {
+		for priority, threshold := range thresholds {
+			if math.IsNaN(threshold) || threshold <= 0 || threshold > 1 {
+				return fmt.Errorf("priority %d threshold must be in (0, 1], got %v", priority, threshold)
+			}
+		}
+		config.priorityThresholds = thresholds
+		return nil
+	}
+}
+
+// priorityThreshold returns the fraction of the quota requests of priority may use
+func (r *RateLimiter) priorityThreshold(priority Priority) float64 {
+	if threshold, ok := r.priorityThresholds[priority]; ok {
+		return threshold
+	}
+	return 1
+}
+
+// shedPriority peeks the strategy and returns the denied results if a request
+// of priority and cost would use more than its threshold, or nil
+func (r *RateLimiter) shedPriority(ctx context.Context, strategyConfig strategies.Config, priority Priority, cost float64) (strategies.Results, error) {
+	threshold := r.priorityThreshold(priority)
+	if threshold >= 1 {
+		return nil, nil
+	}
+
+	results, err := r.strategy.Peek(ctx, strategyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("strategy check failed: %w", err)
+	}
+
+	shed := false
+	shedResult := strategies.Result{Reason: strategies.QuotaExhausted}
+	for _, res := range results {
+		if res.Advisory {
+			continue
+		}
+		if !res.Allowed {
+			// Denied anyway, leave it to Allow so penalties still apply
+			return nil, nil
+		}
+		if res.Limit <= 0 {
+			continue
+		}
+		used := float64(res.Limit - res.Remaining)
+		if used+cost > threshold*float64(res.Limit) {
+			shed = true
+			if res.Reset.After(shedResult.Reset) {
+				shedResult.Reset = res.Reset
+			}
+		}
+	}
+	if !shed {
+		return nil, nil
+	}
+	results[PriorityResultKey] = shedResult
+	return results, nil
+}
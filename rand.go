@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// lockedSource serializes a rand.Source, which is not safe for concurrent use
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+// WithRandSource draws every random decision of the limiter from src instead
+// of the global generator: retry backoff jitter and approx counter sampling.
+//
+// Seeding it, e.g. rand.NewPCG(1, 2), makes them reproducible in tests. The
+// source is shared by all requests and serialized with a mutex, so it doesn't
+// need to be safe for concurrent use; for throughput keep the global
+// generator, which is. Strategy configs opt in via strategies.RandConfig.
+func WithRandSource(src rand.Source) Option {
+	return func(config *Config) error {
+		if src == nil {
+			return fmt.Errorf("random source cannot be nil")
+		}
+		config.rng = rand.New(&lockedSource{src: src})
+		return nil
+	}
+}
+
+// withRand applies the WithRandSource generator to configs implementing
+// strategies.RandConfig, leaving the global generator otherwise
+func withRand(config strategies.Config, rng *rand.Rand) strategies.Config {
+	if rng == nil {
+		return config
+	}
+	if rc, ok := config.(strategies.RandConfig); ok {
+		return rc.WithRand(rng)
+	}
+	return config
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+)
+
+// rateUnits maps the units accepted by ParseRate to their duration
+var rateUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "wk": 7 * 24 * time.Hour, "wks": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// ParseRate parses a rate such as "100/min", "5/s", "1000/day" or "100/2h"
+// into a request limit and its window.
+//
+// The window is a unit, optionally preceded by a positive integer count:
+// s, sec, second, m, min, minute, h, hr, hour, d, day, w, wk or week, each
+// also in plural. Units are lowercase so that "M" is not mistaken for a
+// month; months are not accepted since their length varies. The limit must
+// be a positive integer. Spaces around the numbers and the slash are ignored.
+func ParseRate(s string) (limit int, window time.Duration, err error) {
+	limitPart, windowPart, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid rate %q: expected <limit>/<window>, e.g. \"100/min\"", s)
+	}
+
+	limitPart = strings.TrimSpace(limitPart)
+	limit, err = strconv.Atoi(limitPart)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid rate %q: limit %q is not an integer", s, limitPart)
+	}
+	if limit <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate %q: limit must be positive", s)
+	}
+
+	windowPart = strings.TrimSpace(windowPart)
+	unitStart := strings.IndexFunc(windowPart, func(r rune) bool { return r < '0' || r > '9' })
+	if unitStart < 0 {
+		return 0, 0, fmt.Errorf("invalid rate %q: window %q has no unit, e.g. \"s\", \"min\" or \"h\"", s, windowPart)
+	}
+	count := int64(1)
+	if unitStart > 0 {
+		count, err = strconv.ParseInt(windowPart[:unitStart], 10, 64)
+		if err != nil || count <= 0 {
+			return 0, 0, fmt.Errorf("invalid rate %q: window count %q must be a positive integer", s, windowPart[:unitStart])
+		}
+	}
+	unitName := strings.TrimSpace(windowPart[unitStart:])
+	unit, ok := rateUnits[unitName]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid rate %q: unknown window unit %q", s, unitName)
+	}
+	if count > math.MaxInt64/int64(unit) {
+		return 0, 0, fmt.Errorf("invalid rate %q: window is too long", s)
+	}
+	return limit, time.Duration(count) * unit, nil
+}
+
+// WithRate configures a fixed window primary strategy from a rate string
+// such as "100/min", see ParseRate. Results are reported under the
+// "default" key. Equivalent to a fixed window with a single quota.
+func WithRate(rate string) Option {
+	return func(config *Config) error {
+		limit, window, err := ParseRate(rate)
+		if err != nil {
+			return err
+		}
+		return WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", limit, window).Build())(config)
+	}
+}
+
+// SteadyStateRate returns the long-run number of requests per second the
+// limiter allows per key, bursts aside, e.g. 100/60 for "100/min".
+//
+// With secondaries it is the lowest rate of the enforcing strategies, and
+// with WithAnyStrategy the highest. Strategies limiting something other than
+// the request rate, e.g. concurrency or distinct actors, don't count, and
+// +Inf is returned when none limits the rate.
+func (r *RateLimiter) SteadyStateRate() float64 {
+	rc, ok := r.buildStrategyConfig("").(strategies.SteadyStateRateConfig)
+	if !ok {
+		return math.Inf(1)
+	}
+	return rc.SteadyStateRate()
+}
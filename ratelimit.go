@@ -2,8 +2,13 @@ package ratelimit
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/internal/strategies/composite"
 	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/ajiwo/ratelimit/utils/builderpool"
@@ -13,9 +18,45 @@ type Limiter = RateLimiter
 
 // RateLimiter implements single or dual strategy rate limiting
 type RateLimiter struct {
+	mu         sync.RWMutex // guards config against concurrent UpdateStrategy
 	config     Config
 	strategy   strategies.Strategy
-	basePrefix string // cached BaseKey + ":" for fast key construction
+	basePrefix string         // cached BaseKey + ":" for fast key construction
+	snapshots  *snapshotCache // last known results for the Peek fallback, nil if disabled
+
+	limiterOptions // copied as a whole by views, see WithBaseKeyScope
+
+	denialsOnce sync.Once
+	denials     atomic.Pointer[denialTracker] // nil until DenialEvents is called
+
+	parent  *RateLimiter // limiter owning the shared resources of a view, nil if not a view
+	viewsMu sync.Mutex
+	views   []*RateLimiter // views whose DenialEvents channels Close closes
+}
+
+// limiterOptions holds the options resolved from the Config and the workers
+// of a limiter, shared by its views
+type limiterOptions struct {
+	hooks              []Hook
+	costFunc           CostFunc
+	keyFunc            KeyFunc
+	tenant             string // default tenant, see WithTenant
+	requireTenant      bool
+	failureMode        FailureMode
+	penalty            *PenaltyConfig // nil unless WithPenalty is set
+	allowList          ListFunc
+	denyList           ListFunc
+	sampler            *sampler       // nil unless WithSampleRate is below 1
+	keyGuard           *keyGuard      // nil unless WithMaxDistinctKeys is set
+	stateCodec         backends.Codec // nil for the compact format
+	resetOnSuccess     bool
+	priorityThresholds map[Priority]float64
+	maxWait            time.Duration     // 0 unless WithMaxWait is set
+	exceeded           *exceededNotifier // nil unless WithOnExceeded is set
+	audit              *auditSink        // nil unless WithAuditSink is set
+	lockCleanup        *lockCleanup      // nil unless WithLockCleanupInterval is set
+	logger             Logger
+	stats              *stats
 }
 
 // New creates a new rate limiter with functional options
@@ -38,12 +79,23 @@ func New(opts ...Option) (*RateLimiter, error) {
 
 // Allow checks if a request is allowed according to the configured strategies
 func (r *RateLimiter) Allow(ctx context.Context, options AccessOptions) (bool, error) {
-	dynamicKey, err := checkDynamicKey(options)
-	if err != nil {
-		return false, err
+	return r.allowN(ctx, options, 0)
+}
+
+// AllowN is like Allow but consumes n units of quota instead of the request
+// cost, e.g. the size in bytes of a payload against a WithBudgetStrategy
+// budget. The request is allowed only if all n units fit; the cost function
+// of WithCostFunc is not called.
+func (r *RateLimiter) AllowN(ctx context.Context, options AccessOptions, n int) (bool, error) {
+	if n <= 0 {
+		return false, fmt.Errorf("request count must be positive, got %d", n)
 	}
+	return r.allowN(ctx, options, n)
+}
 
-	allowed, results, err := r.allowWithResult(ctx, dynamicKey)
+// allowN runs allow and populates AccessOptions.Result
+func (r *RateLimiter) allowN(ctx context.Context, options AccessOptions, n int) (bool, error) {
+	allowed, results, err := r.allow(ctx, options, n)
 	if err != nil {
 		return false, err
 	}
@@ -53,40 +105,144 @@ func (r *RateLimiter) Allow(ctx context.Context, options AccessOptions) (bool, e
 		*options.Result = results
 	}
 
-	return allowed, err
+	return allowed, nil
+}
+
+// allow consumes n units of quota for the request, or its cost when n is 0,
+// and notifies hooks, shared by Allow, AllowN and Check
+func (r *RateLimiter) allow(ctx context.Context, options AccessOptions, n int) (bool, strategies.Results, error) {
+	dynamicKey, err := r.dynamicKey(ctx, options)
+	if err != nil {
+		return false, nil, err
+	}
+
+	allowed, results, listed := r.bypassDecision(options, dynamicKey)
+	if !listed && !r.keyGuard.admit(ctx, r.logger, r.basePrefix, dynamicKey) {
+		allowed, results, listed = true, strategies.Results{CardinalityResultKey: {Allowed: true}}, true
+	}
+	if !listed {
+		cost := float64(n)
+		if n == 0 {
+			cost, err = r.requestCost(ctx, options)
+		}
+		if err == nil {
+			allowed, results, err = r.allowWithResult(ctx, dynamicKey, options.Actor, options.IdleTTL, cost, requestPriority(ctx, options))
+		}
+	}
+	failedOpen := err != nil && r.failOpen(err)
+	r.stats.record(allowed || failedOpen, err)
+	r.logAllowError(ctx, dynamicKey, err, failedOpen)
+	if failedOpen {
+		allowed = true
+	} else if err == nil {
+		r.snapshots.store(dynamicKey, allowed, results)
+		r.observeDenial(dynamicKey, allowed, results)
+		if !allowed && !listed {
+			r.exceeded.notify(ctx, dynamicKey, results)
+		}
+	}
+	withMetadata(results, options.Metadata)
+	if err == nil || failedOpen {
+		r.audit.record(ctx, dynamicKey, results, allowed)
+	}
+	r.emit(ctx, Event{
+		Operation: OperationAllow,
+		Key:       dynamicKey,
+		Allowed:   allowed,
+		Results:   results,
+		Metadata:  options.Metadata,
+		Err:       err,
+	})
+	if failedOpen {
+		return true, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	return allowed, results, nil
 }
 
 // Peek retrieves strategy results without consuming quota and returns an overall allowed boolean
 func (r *RateLimiter) Peek(ctx context.Context, options AccessOptions) (bool, error) {
-	dynamicKey, err := checkDynamicKey(options)
+	dynamicKey, err := r.dynamicKey(ctx, options)
 	if err != nil {
 		return false, err
 	}
 
-	strategyConfig := r.buildStrategyConfig(dynamicKey)
-
-	// Get stats from the strategy (composite or single)
-	results, err := r.strategy.Peek(ctx, strategyConfig)
+	// Sampling only applies to requests consuming quota
+	allowed, results, listed := r.listDecision(options)
+	if !listed {
+		allowed, results, err = r.peekWithResult(ctx, dynamicKey, options.Actor)
+	}
+	switch {
+	case err == nil:
+		r.snapshots.store(dynamicKey, allowed, results)
+	case backends.IsHealthError(err):
+		// Serve the last known state during a backend outage, if enabled
+		if snapAllowed, snapResults, ok := r.snapshots.load(dynamicKey); ok {
+			allowed, results, err = snapAllowed, snapResults, nil
+		}
+	}
+	withMetadata(results, options.Metadata)
+	r.emit(ctx, Event{
+		Operation: OperationPeek,
+		Key:       dynamicKey,
+		Allowed:   allowed,
+		Results:   results,
+		Metadata:  options.Metadata,
+		Err:       err,
+	})
 	if err != nil {
-		return false, fmt.Errorf("failed to get stats: %w", err)
+		return false, err
 	}
+
 	if options.Result != nil {
 		*options.Result = results
 	}
+	return allowed, nil
+}
+
+// peekWithResult retrieves strategy results without consuming quota
+func (r *RateLimiter) peekWithResult(ctx context.Context, dynamicKey, actor string) (bool, strategies.Results, error) {
+	ctx = r.logContext(ctx)
+	if r.penalty != nil {
+		_, _, lockout, err := r.activePenalty(ctx, dynamicKey, time.Now())
+		if err != nil {
+			return false, nil, err
+		}
+		if lockout != nil {
+			return false, lockout, nil
+		}
+	}
+
+	strategyConfig := withActor(r.buildStrategyConfig(dynamicKey), actor)
+
+	// Get stats from the strategy (composite or single)
+	results, err := r.strategy.Peek(ctx, strategyConfig)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
 	// Determine overall allowed similarly to Allow
 	allAllowed := true
 	for _, res := range results {
-		if !res.Allowed {
+		if res.Denies() {
 			allAllowed = false
 			break
 		}
 	}
-	return allAllowed, nil
+	return allAllowed, results, nil
 }
 
-// Reset resets the rate limit counters for all strategies (mainly for testing)
+// Reset clears all rate limit state of the key, so manual unblocking truly unblocks.
+//
+// Every storage key derived from the base and dynamic key is deleted: the
+// strategy state (in dual strategy mode all tiers, which share one composite
+// key) and the WithPenalty lockout. The Peek fallback snapshot and denial
+// tracking of the key held by this limiter are dropped as well.
 func (r *RateLimiter) Reset(ctx context.Context, options AccessOptions) error {
-	dynamicKey, err := checkDynamicKey(options)
+	dynamicKey, err := r.dynamicKey(ctx, options)
 	if err != nil {
 		return err
 	}
@@ -94,28 +250,184 @@ func (r *RateLimiter) Reset(ctx context.Context, options AccessOptions) error {
 	strategyConfig := r.buildStrategyConfig(dynamicKey)
 
 	// Reset the strategy (composite or single)
-	if err := r.strategy.Reset(ctx, strategyConfig); err != nil {
+	if err := r.strategy.Reset(r.logContext(ctx), strategyConfig); err != nil {
 		return fmt.Errorf("failed to reset strategy: %w", err)
 	}
+	if err := r.resetPenalty(ctx, dynamicKey); err != nil {
+		return err
+	}
 
+	r.snapshots.forget(dynamicKey)
+	r.forgetDenial(dynamicKey)
 	return nil
 }
 
-// Close cleans up resources used by the rate limiter
+// Refund returns one unit of previously consumed quota for the key
+//
+// See RefundN for details.
+func (r *RateLimiter) Refund(ctx context.Context, options AccessOptions) error {
+	return r.RefundN(ctx, options, 1)
+}
+
+// RefundN returns n units of previously consumed quota for the key, the inverse of Allow.
+//
+// This supports "only count successful requests" patterns, where quota is
+// consumed optimistically and given back when the downstream work fails.
+// Refunds are clamped so that no strategy ever exceeds its capacity, and
+// refunding a fresh key is a no-op. In dual strategy mode the refund is
+// applied to all strategies atomically.
+//
+// Returns strategies.ErrRefundNotSupported if a configured strategy
+// cannot refund.
+func (r *RateLimiter) RefundN(ctx context.Context, options AccessOptions, n int) error {
+	if n <= 0 {
+		return fmt.Errorf("refund count must be positive, got %d", n)
+	}
+
+	dynamicKey, err := r.dynamicKey(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	refunder, ok := r.strategy.(strategies.Refunder)
+	if !ok {
+		return strategies.ErrRefundNotSupported
+	}
+
+	strategyConfig := r.buildStrategyConfig(dynamicKey)
+	if err := refunder.Refund(backends.FreshRead(r.logContext(ctx)), strategyConfig, n); err != nil {
+		return fmt.Errorf("failed to refund: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStrategy swaps the primary strategy config at runtime, e.g. to raise a
+// limit from 100 to 200 requests per minute.
+//
+// Stored state is kept, so existing keys retain their consumed counts and
+// are evaluated against the new limits from the next call on. The new config
+// must use the same strategy as the current one, since state formats differ
+// between strategies. Concurrent Allow, Peek and Refund calls see either the
+// old or the new config, never a mix.
+func (r *RateLimiter) UpdateStrategy(strategyConfig strategies.Config) error {
+	if strategyConfig == nil {
+		return fmt.Errorf("primary strategy config cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current := r.config.PrimaryConfig.ID(); strategyConfig.ID() != current {
+		return fmt.Errorf("cannot change primary strategy from %s to %s at runtime", current, strategyConfig.ID())
+	}
+
+	config := r.config
+	config.PrimaryConfig = strategyConfig
+	config.applyDuplicateRatios(r.logger)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	r.config = config
+	return nil
+}
+
+// Close cleans up resources used by the rate limiter, including the
+// resources shared with its views, see WithBaseKeyScope.
+//
+// Closing a view only closes its DenialEvents channel.
 func (r *RateLimiter) Close() error {
-	// Close the storage backend
+	r.closeDenials()
+	if r.parent != nil {
+		return nil
+	}
+	r.viewsMu.Lock()
+	for _, view := range r.views {
+		view.closeDenials()
+	}
+	r.viewsMu.Unlock()
+	r.exceeded.close()
+	r.audit.close()
+	r.lockCleanup.close()
+
+	// Close the storage backends
+	var errs []error
 	if r.config.Storage != nil {
-		return r.config.Storage.Close()
+		errs = append(errs, r.config.Storage.Close())
 	}
-	return nil
+	for _, storage := range r.config.ownedStorages {
+		errs = append(errs, storage.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ListKeys returns the storage keys of this limiter matching pattern, for admin tooling.
+//
+// The pattern is matched against the dynamic part of the key, after the base
+// key, using the glob syntax of backends.Lister; "" lists every key. Returned
+// keys are full storage keys, including the base key and any strategy suffix.
+// Enumeration is best-effort and may scan the whole backend, so avoid it on
+// the request path.
+//
+// Returns an error wrapping backends.ErrKeysNotSupported if the backend
+// cannot enumerate keys.
+func (r *RateLimiter) ListKeys(ctx context.Context, pattern string) ([]string, error) {
+	lister, ok := r.storage().(backends.Lister)
+	if !ok {
+		return nil, fmt.Errorf("cannot list keys: %w", backends.ErrKeysNotSupported)
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	keys, err := lister.Keys(ctx, r.basePrefix+pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	return keys, nil
+}
+
+// storage returns the configured backend
+func (r *RateLimiter) storage() backends.Backend {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config.Storage
 }
 
 // allowWithResult1 checks if a request is allowed and returns detailed results
-func (r *RateLimiter) allowWithResult(ctx context.Context, dynamicKey string) (bool, strategies.Results, error) {
-	strategyConfig := r.buildStrategyConfig(dynamicKey)
+func (r *RateLimiter) allowWithResult(ctx context.Context, dynamicKey, actor string, idleTTL time.Duration, cost float64, priority Priority) (bool, strategies.Results, error) {
+	ctx = withCoalescedReads(backends.FreshRead(r.logContext(ctx)))
+
+	// Locked out keys are denied without consuming quota
+	var penalty penaltyState
+	var penaltyRaw string
+	now := time.Now()
+	if r.penalty != nil {
+		var lockout strategies.Results
+		var err error
+		penalty, penaltyRaw, lockout, err = r.activePenalty(ctx, dynamicKey, now)
+		if err != nil {
+			return false, nil, err
+		}
+		if lockout != nil {
+			return false, lockout, nil
+		}
+	}
+
+	strategyConfig := withIdleTTL(withActor(r.buildStrategyConfig(dynamicKey), actor), idleTTL)
+
+	// Lower priorities are shed before the limit without consuming quota
+	shed, err := r.shedPriority(ctx, strategyConfig, priority, cost)
+	if err != nil {
+		return false, nil, err
+	}
+	if shed != nil {
+		return false, shed, nil
+	}
 
-	// Use the strategy (composite or single)
-	results, err := r.strategy.Allow(ctx, strategyConfig)
+	// Use the strategy (composite or single), always on fresh state
+	results, err := r.allowStrategy(ctx, strategyConfig, cost)
 	if err != nil {
 		return false, nil, fmt.Errorf("strategy check failed: %w", err)
 	}
@@ -123,30 +435,63 @@ func (r *RateLimiter) allowWithResult(ctx context.Context, dynamicKey string) (b
 	// Determine if the request was allowed by checking if all results allow it
 	allAllowed := true
 	for _, result := range results {
-		if !result.Allowed {
+		if result.Denies() {
 			allAllowed = false
 			break
 		}
 	}
 
+	if !allAllowed && r.penalty != nil {
+		until, err := r.escalatePenalty(ctx, dynamicKey, penalty, penaltyRaw, now)
+		if err != nil {
+			return false, nil, err
+		}
+		results[PenaltyResultKey] = penaltyResults(until)[PenaltyResultKey]
+	}
+
 	return allAllowed, results, nil
 }
 
+// allowStrategy consumes cost units, using plain Allow for unit cost
+func (r *RateLimiter) allowStrategy(ctx context.Context, strategyConfig strategies.Config, cost float64) (strategies.Results, error) {
+	if cost == 1 {
+		return r.strategy.Allow(ctx, strategyConfig)
+	}
+	allower, ok := r.strategy.(strategies.CostAllower)
+	if !ok {
+		return nil, strategies.ErrCostNotSupported
+	}
+	return allower.AllowCost(ctx, strategyConfig, cost)
+}
+
 // buildStrategyConfig builds the appropriate strategy config (composite or single)
 func (r *RateLimiter) buildStrategyConfig(dynamicKey string) strategies.Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// build any strategy config
+	if len(r.config.AnyConfigs) > 0 {
+		cc := (&composite.AnyConfig{
+			BaseKey: r.config.BaseKey,
+			Tiers:   append([]strategies.Config{r.config.PrimaryConfig}, r.config.AnyConfigs...),
+		}).
+			WithKey(dynamicKey)
+
+		return r.applyRetryPolicy(cc)
+	}
+
 	// build dual strategy config
 	if r.config.SecondaryConfig != nil {
 		cc := (&composite.Config{
-			BaseKey:   r.config.BaseKey,
-			Primary:   r.config.PrimaryConfig,
-			Secondary: r.config.SecondaryConfig,
+			BaseKey:          r.config.BaseKey,
+			Primary:          r.config.PrimaryConfig,
+			Secondary:        r.config.SecondaryConfig,
+			ExtraSecondaries: r.config.ExtraSecondaryConfigs,
+			Advisory:         r.config.advisorySecondaries,
 		}).
 			WithKey(dynamicKey)
 
-		if r.config.maxRetries > 0 {
-			return cc.WithMaxRetries(r.config.maxRetries)
-		}
-		return cc
+		return r.applyRetryPolicy(cc)
 	}
 
 	// build single strategy config
@@ -159,27 +504,26 @@ func (r *RateLimiter) buildStrategyConfig(dynamicKey string) strategies.Config {
 	cc := r.config.PrimaryConfig.
 		WithKey(sb.String())
 
-	if r.config.maxRetries > 0 {
-		return cc.WithMaxRetries(r.config.maxRetries)
-	}
-	return cc
+	return r.applyRetryPolicy(cc)
 }
 
-// checkDynamicKey validates (if enabled) and returns the dynamic key
-func checkDynamicKey(options AccessOptions) (string, error) {
-	if options.Key != "" {
-		if !options.SkipValidation {
-			if err := validateKey(options.Key, "dynamic key"); err != nil {
-				return "", err
-			}
+// applyRetryPolicy applies the limiter-wide retry count, backoff and random source to a strategy config
+func (r *RateLimiter) applyRetryPolicy(cc strategies.Config) strategies.Config {
+	if r.config.maxRetries > 0 {
+		cc = cc.WithMaxRetries(r.config.maxRetries)
+	}
+	if !r.config.retryBackoff.IsZero() {
+		if bc, ok := cc.(strategies.RetryBackoffConfig); ok {
+			cc = bc.WithRetryBackoff(r.config.retryBackoff)
 		}
-		return options.Key, nil
 	}
-	return "default", nil
+	return withRand(cc, r.config.rng)
 }
 
 // newRateLimiter creates a new rate limiter
 func newRateLimiter(config Config) (*RateLimiter, error) {
+	config.applyDuplicateRatios(config.logger)
+
 	// Validate configuration
 	if err := config.Validate(); err != nil {
 		return nil, err
@@ -188,27 +532,99 @@ func newRateLimiter(config Config) (*RateLimiter, error) {
 	limiter := &RateLimiter{
 		config:     config,
 		basePrefix: config.BaseKey + ":",
+		snapshots:  newSnapshotCache(config.peekFallback),
+		limiterOptions: limiterOptions{
+			hooks:              config.hooks,
+			costFunc:           config.costFunc,
+			keyFunc:            config.keyFunc,
+			tenant:             config.tenant,
+			requireTenant:      config.requireTenant,
+			failureMode:        config.failureMode,
+			penalty:            config.penalty,
+			allowList:          config.allowList,
+			denyList:           config.denyList,
+			sampler:            newSampler(config),
+			keyGuard:           newKeyGuard(config),
+			stateCodec:         config.stateCodec,
+			resetOnSuccess:     config.resetOnSuccess,
+			priorityThresholds: config.priorityThresholds,
+			maxWait:            config.maxWait,
+			logger:             config.logger,
+			stats:              new(stats),
+		},
+	}
+	if limiter.logger == nil {
+		limiter.logger = nopLogger{}
+	}
+	if config.breakerLogger != nil {
+		config.breakerLogger.logger.Store(&limiter.logger)
+	}
+
+	// Strategies see the backend through a wrapper counting lost CAS attempts
+	wrap := func(b backends.Backend) backends.Backend {
+		return &statsBackend{
+			Backend:    backends.WithCodec(b, config.stateCodec),
+			casRetries: &limiter.stats.casRetries,
+			logger:     limiter.logger,
+		}
+	}
+	storage := wrap(config.Storage)
+	var storages []backends.Backend
+	if config.hasStrategyStorage() {
+		storages = config.strategyStorages(storage, wrap)
+	}
+	strategy, err := newStrategy(storage, storages, config)
+	if err != nil {
+		return nil, err
+	}
+	limiter.strategy = strategy
+	limiter.exceeded = newExceededNotifier(config.onExceeded, exceededWorkers, exceededQueueSize)
+	limiter.audit = newAuditSink(config.auditFunc, config.auditOverflow, auditQueueSize)
+	limiter.lockCleanup = startLockCleanup(limiter.flushStorages(), config.lockCleanupInterval)
+
+	return limiter, nil
+}
+
+// newStrategy creates the strategy of config on storage, composite for dual and any strategies.
+//
+// storages, when not nil, holds the backend of every strategy, see
+// Config.strategyStorages; dual strategies then keep each tier on its own.
+func newStrategy(storage backends.Backend, storages []backends.Backend, config Config) (strategies.Strategy, error) {
+	if len(config.AnyConfigs) > 0 {
+		tiers := append([]strategies.Config{config.PrimaryConfig}, config.AnyConfigs...)
+		anyStrategy, err := composite.NewAny(storage, tiers...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create any strategy: %w", err)
+		}
+		return anyStrategy, nil
+	}
+
+	if config.SecondaryConfig != nil && storages != nil {
+		split, err := composite.NewSplit(storages, config.PrimaryConfig, config.SecondaryConfig, config.ExtraSecondaryConfigs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create composite strategy: %w", err)
+		}
+		return split, nil
 	}
 
 	// Check if we have a dual-strategy configuration
 	if config.SecondaryConfig != nil {
 		// Use comp strategy for dual-strategy behavior
-		comp, err := composite.New(config.Storage, config.PrimaryConfig, config.SecondaryConfig)
+		comp, err := composite.New(storage, config.PrimaryConfig, config.SecondaryConfig, config.ExtraSecondaryConfigs...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create composite strategy: %w", err)
 		}
-		limiter.strategy = comp
-
-		return limiter, nil
+		return comp, nil
 	}
 
 	// Single strategy case
+	if storages != nil {
+		storage = storages[0]
+	}
 	primaryStrategyID := config.PrimaryConfig.ID()
-	primaryStrategy, err := strategies.Create(primaryStrategyID, config.Storage)
+	primaryStrategy, err := strategies.Create(primaryStrategyID, storage)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create primary strategy: %w", err)
 	}
-	limiter.strategy = primaryStrategy
-
-	return limiter, nil
+	return primaryStrategy, nil
 }
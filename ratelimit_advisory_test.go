@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proposedLimit is a secondary allowing a single request per hour
+func proposedLimit() *tokenbucket.Config {
+	return &tokenbucket.Config{Burst: 1, Rate: 1.0 / 3600}
+}
+
+func TestWithAdvisory(t *testing.T) {
+	for name, backendOption := range map[string][]StrategyOption{
+		"composite": nil,
+		"split":     {WithStrategyBackend(memory.New())},
+	} {
+		t.Run(name, func(t *testing.T) {
+			limiter, err := New(
+				WithBackend(memory.New()),
+				WithPrimaryStrategy(perMinute(3)),
+				WithSecondaryStrategy(proposedLimit(), append(backendOption, WithAdvisory())...),
+			)
+			require.NoError(t, err)
+			defer limiter.Close()
+
+			var results strategies.Results
+			options := AccessOptions{Key: "user", Result: &results}
+			allowed, err := limiter.Allow(t.Context(), options)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.True(t, results["secondary_default"].Allowed)
+			assert.True(t, results["secondary_default"].Advisory)
+
+			decision, err := limiter.Check(t.Context(), options)
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed, "the advisory denial does not deny")
+			assert.False(t, decision.Results["secondary_default"].Allowed)
+			assert.True(t, decision.Results["secondary_default"].Advisory)
+			assert.Empty(t, decision.LimitingTier)
+			assert.Equal(t, 1, decision.Results["primary_default"].Remaining, "the enforced tier is consumed")
+
+			allowed, err = limiter.Peek(t.Context(), options)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			// The enforced primary still denies
+			assert.Equal(t, 1, allowN(t, limiter, 3))
+			decision, err = limiter.Check(t.Context(), options)
+			require.NoError(t, err)
+			assert.False(t, decision.Allowed)
+			assert.Equal(t, "primary_default", decision.LimitingTier)
+		})
+	}
+}
+
+func TestWithAdvisory_Enforced(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(3)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}, WithAdvisory()),
+		WithSecondaryStrategy(proposedLimit()),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	assert.Equal(t, 1, allowN(t, limiter, 3), "a non-advisory secondary still denies")
+
+	var results strategies.Results
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.False(t, results["secondary_tokenbucket2_default"].Allowed)
+	assert.False(t, results["secondary_tokenbucket2_default"].Advisory)
+}
+
+func TestWithAdvisory_Primary(t *testing.T) {
+	_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(3), WithAdvisory()))
+	require.ErrorContains(t, err, "cannot be advisory")
+}
+
+func TestWithAdvisory_Window(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		// A denied advisory tier consumes nothing, it allows again once refilled
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(100)),
+			WithSecondaryStrategy(&tokenbucket.Config{Burst: 1, Rate: 1}, WithAdvisory()),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		assert.Equal(t, 2, allowN(t, limiter, 2))
+		time.Sleep(time.Second)
+
+		var results strategies.Results
+		_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		assert.True(t, results["secondary_default"].Allowed)
+	})
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnyStrategy_Overage(t *testing.T) {
+	overage := fixedwindow.NewConfig().AddQuota("overage", 3, time.Hour).Build()
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithAnyStrategy(perMinute(2), overage),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	ctx := t.Context()
+
+	allow := func() (bool, strategies.Results) {
+		t.Helper()
+		var results strategies.Results
+		allowed, err := limiter.Allow(ctx, AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		return allowed, results
+	}
+
+	// The regular quota is used first, without touching the overage
+	for range 2 {
+		allowed, results := allow()
+		require.True(t, allowed)
+		assert.Contains(t, results, "tier1_default")
+		assert.NotContains(t, results, "tier2_overage")
+	}
+
+	// Once it is exhausted, only the overage bucket is consumed
+	for i := range 3 {
+		allowed, results := allow()
+		require.True(t, allowed)
+		assert.Equal(t, 2-i, results["tier2_overage"].Remaining)
+		assert.NotContains(t, results, "tier1_default")
+	}
+
+	allowed, results := allow()
+	assert.False(t, allowed)
+	assert.False(t, results["tier1_default"].Allowed)
+	assert.False(t, results["tier2_overage"].Allowed)
+
+	// Other keys start with the regular quota
+	var other strategies.Results
+	allowed, err = limiter.Allow(ctx, AccessOptions{Key: "other", Result: &other})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, other["tier1_default"].Remaining)
+}
+
+func TestAnyStrategy_Invalid(t *testing.T) {
+	_, err := New(WithBackend(memory.New()), WithAnyStrategy(perMinute(2)))
+	assert.Error(t, err)
+
+	_, err = New(
+		WithBackend(memory.New()),
+		WithAnyStrategy(perMinute(2), perMinute(3)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+	)
+	assert.ErrorContains(t, err, "secondary")
+}
@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditEntry is a decision received by auditRecorder
+type auditEntry struct {
+	key     string
+	results strategies.Results
+	allowed bool
+}
+
+// auditRecorder collects WithAuditSink decisions
+type auditRecorder struct {
+	mu      sync.Mutex
+	entries []auditEntry
+}
+
+func (a *auditRecorder) record(_ context.Context, key string, results strategies.Results, allowed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, auditEntry{key: key, results: results, allowed: allowed})
+}
+
+func TestWithAuditSink_Validation(t *testing.T) {
+	require.Error(t, WithAuditSink(nil, AuditDrop)(&Config{}))
+	require.Error(t, WithAuditSink(func(context.Context, string, strategies.Results, bool) {}, AuditOverflow(7))(&Config{}))
+}
+
+func TestWithAuditSink_AllowedAndDenied(t *testing.T) {
+	var recorder auditRecorder
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(10)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 1, Rate: 0.01}),
+		WithAuditSink(recorder.record, AuditDrop),
+	)
+	require.NoError(t, err)
+
+	var results strategies.Results
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	_, err = limiter.Check(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+
+	// Modifying returned results does not affect the audited copy
+	delete(results, "primary_default")
+
+	// Close delivers the queued decisions, in order
+	require.NoError(t, limiter.Close())
+	require.Len(t, recorder.entries, 2)
+
+	first := recorder.entries[0]
+	assert.Equal(t, "user", first.key)
+	assert.True(t, first.allowed)
+	assert.Equal(t, 9, first.results["primary_default"].Remaining)
+	assert.Equal(t, 0, first.results["secondary_default"].Remaining)
+
+	second := recorder.entries[1]
+	assert.False(t, second.allowed)
+	assert.True(t, second.results["primary_default"].Allowed)
+	assert.False(t, second.results["secondary_default"].Allowed)
+	assert.Equal(t, strategies.BurstExceeded, second.results["secondary_default"].Reason)
+}
+
+func TestWithAuditSink_FailedRequests(t *testing.T) {
+	var recorder auditRecorder
+	limiter, err := New(
+		WithBackend(downBackend{}),
+		WithPrimaryStrategy(perMinute(10)),
+		WithAuditSink(recorder.record, AuditDrop),
+	)
+	require.NoError(t, err)
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.Error(t, err)
+	require.NoError(t, limiter.Close())
+	assert.Empty(t, recorder.entries)
+
+	recorder = auditRecorder{}
+	limiter, err = New(
+		WithBackend(downBackend{}),
+		WithPrimaryStrategy(perMinute(10)),
+		WithFailureMode(FailOpen),
+		WithAuditSink(recorder.record, AuditDrop),
+	)
+	require.NoError(t, err)
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	require.NoError(t, limiter.Close())
+	require.Len(t, recorder.entries, 1)
+	assert.True(t, recorder.entries[0].allowed)
+	assert.Nil(t, recorder.entries[0].results)
+}
+
+func TestWithAuditSink_Overflow(t *testing.T) {
+	t.Run("drop", func(t *testing.T) {
+		release := make(chan struct{})
+		var recorder auditRecorder
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(1)),
+			WithAuditSink(func(ctx context.Context, key string, results strategies.Results, allowed bool) {
+				<-release
+				recorder.record(ctx, key, results, allowed)
+			}, AuditDrop),
+		)
+		require.NoError(t, err)
+
+		// Decisions beyond the busy sink and the queue are dropped, not waited for
+		requests := auditQueueSize + 10
+		allowN(t, limiter, requests)
+
+		close(release)
+		require.NoError(t, limiter.Close())
+		assert.GreaterOrEqual(t, len(recorder.entries), auditQueueSize)
+		assert.Less(t, len(recorder.entries), requests)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		var recorder auditRecorder
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(1)),
+			WithAuditSink(func(ctx context.Context, key string, results strategies.Results, allowed bool) {
+				time.Sleep(time.Microsecond)
+				recorder.record(ctx, key, results, allowed)
+			}, AuditBlock),
+		)
+		require.NoError(t, err)
+
+		// Requests wait for the sink, so no decision is lost
+		requests := auditQueueSize + 10
+		allowN(t, limiter, requests)
+		require.NoError(t, limiter.Close())
+		assert.Len(t, recorder.entries, requests)
+	})
+
+	t.Run("block until done", func(t *testing.T) {
+		busy := make(chan struct{}, 1)
+		release := make(chan struct{})
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(1)),
+			WithAuditSink(func(context.Context, string, strategies.Results, bool) {
+				select {
+				case busy <- struct{}{}:
+				default:
+				}
+				<-release
+			}, AuditBlock),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		defer close(release)
+
+		// The sink is busy with the first decision, then the queue fills up
+		allowN(t, limiter, 1)
+		<-busy
+		allowN(t, limiter, auditQueueSize)
+
+		// The queue is full: the request waits until its context is done
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+		_, err = limiter.Allow(ctx, AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	})
+}
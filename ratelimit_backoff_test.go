@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// casCountingBackend wraps a backend and counts CheckAndSet attempts and successes.
+// Reads are delayed to emulate a network round trip, widening the Get/CAS race window.
+type casCountingBackend struct {
+	backends.Backend
+	readLatency time.Duration
+	attempts    atomic.Int64
+	successes   atomic.Int64
+}
+
+func (c *casCountingBackend) Get(ctx context.Context, key string) (string, error) {
+	v, err := c.Backend.Get(ctx, key)
+	time.Sleep(c.readLatency)
+	return v, err
+}
+
+func (c *casCountingBackend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	c.attempts.Add(1)
+	ok, err := c.Backend.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+	if ok {
+		c.successes.Add(1)
+	}
+	return ok, err
+}
+
+// contentionMaxRetries is high enough that no call gives up under the test contention
+const contentionMaxRetries = 5000
+
+// attemptsPerSuccess hammers a single key and reports CAS attempts per successful CAS
+func attemptsPerSuccess(t testing.TB, opts ...Option) float64 {
+	backend := &casCountingBackend{Backend: memory.New(), readLatency: 50 * time.Microsecond}
+	limiter, err := New(append([]Option{
+		WithBackend(backend),
+		WithBaseKey("contention"),
+		WithMaxRetries(contentionMaxRetries),
+		WithPrimaryStrategy(&tokenbucket.Config{Burst: 10000, Rate: 1}),
+	}, opts...)...)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	const goroutines = 32
+	const perGoroutine = 20
+
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for range perGoroutine {
+				_, err := limiter.Allow(context.Background(), AccessOptions{Key: "hot"})
+				assert.NoError(t, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	successes := backend.successes.Load()
+	require.Equal(t, int64(goroutines*perGoroutine), successes)
+	return float64(backend.attempts.Load()) / float64(successes)
+}
+
+func TestWithRetryBackoff_Validation(t *testing.T) {
+	cfg := &Config{}
+	require.Error(t, WithRetryBackoff(0, time.Second, false)(cfg), "initial must be positive")
+	require.Error(t, WithRetryBackoff(time.Second, time.Millisecond, false)(cfg), "max must not be less than initial")
+
+	require.NoError(t, WithRetryBackoff(time.Millisecond, 50*time.Millisecond, true)(cfg))
+	assert.Equal(t, time.Millisecond, cfg.retryBackoff.Initial)
+	assert.Equal(t, 50*time.Millisecond, cfg.retryBackoff.Max)
+	assert.True(t, cfg.retryBackoff.Jitter)
+}
+
+func TestWithRetryBackoff_PropagatesToStrategyConfig(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+		WithRetryBackoff(time.Millisecond, 20*time.Millisecond, false),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	cfg, ok := limiter.buildStrategyConfig("user").(*tokenbucket.Config)
+	require.True(t, ok)
+	assert.Equal(t, time.Millisecond, cfg.RetryBackoff.Initial)
+	assert.Equal(t, 20*time.Millisecond, cfg.RetryBackoff.Max)
+}
+
+func TestWithRetryBackoff_ReducesContention(t *testing.T) {
+	tight := attemptsPerSuccess(t)
+	backoff := attemptsPerSuccess(t, WithRetryBackoff(200*time.Microsecond, 20*time.Millisecond, true))
+
+	t.Logf("CAS attempts per success: default=%.2f backoff=%.2f", tight, backoff)
+	assert.Less(t, backoff, tight, "backoff should reduce CAS attempts per success")
+}
+
+func BenchmarkAllow_Contention(b *testing.B) {
+	b.Run("default", func(b *testing.B) {
+		for b.Loop() {
+			b.ReportMetric(attemptsPerSuccess(b), "cas/success")
+		}
+	})
+	b.Run("backoff", func(b *testing.B) {
+		for b.Loop() {
+			b.ReportMetric(attemptsPerSuccess(b, WithRetryBackoff(200*time.Microsecond, 20*time.Millisecond, true)), "cas/success")
+		}
+	})
+}
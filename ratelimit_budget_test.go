@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetStrategy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithBudgetStrategy(10_000, time.Minute),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		ctx := t.Context()
+
+		// A few large payloads exhaust the byte budget
+		var results strategies.Results
+		for _, size := range []int{4_000, 1_500, 3_000} {
+			allowed, err := limiter.AllowN(ctx, AccessOptions{Key: "user", Result: &results}, size)
+			require.NoError(t, err)
+			require.True(t, allowed)
+		}
+		assert.Equal(t, 1_500, results["budget"].Remaining)
+		assert.Equal(t, 10_000, results["budget"].Limit)
+
+		allowed, err := limiter.AllowN(ctx, AccessOptions{Key: "user", Result: &results}, 2_000)
+		require.NoError(t, err)
+		assert.False(t, allowed, "the payload exceeds the remaining budget")
+		assert.Equal(t, 1_500, results["budget"].Remaining)
+
+		allowed, err = limiter.AllowN(ctx, AccessOptions{Key: "user"}, 1_500)
+		require.NoError(t, err)
+		assert.True(t, allowed, "the rest of the budget still fits")
+		allowed, err = limiter.Allow(ctx, AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		// The budget resets with the window
+		time.Sleep(time.Minute)
+		allowed, err = limiter.AllowN(ctx, AccessOptions{Key: "user", Result: &results}, 10_000)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Zero(t, results["budget"].Remaining)
+	})
+}
+
+func TestBudgetStrategy_Invalid(t *testing.T) {
+	_, err := New(WithBudgetStrategy(0, time.Minute))
+	assert.Error(t, err)
+	_, err = New(WithBudgetStrategy(100, 0))
+	assert.Error(t, err)
+
+	limiter := newKeyLimiter(t)
+	_, err = limiter.AllowN(t.Context(), AccessOptions{Key: "user"}, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalCache_AllowBypassesCache(t *testing.T) {
+	shared := memory.New()
+	cached, err := New(
+		WithBackend(backends.WithLocalCache(shared, time.Minute)),
+		WithPrimaryStrategy(perMinute(1)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cached.Close() })
+
+	// Another instance writing to the same store, without a cache
+	other, err := New(
+		WithBackend(shared),
+		WithPrimaryStrategy(perMinute(1)),
+	)
+	require.NoError(t, err)
+
+	allowed, err := cached.Peek(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, err = other.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Peek may serve the cached, stale state
+	allowed, err = cached.Peek(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, allowed, "peek within the cache ttl sees stale state")
+
+	// Allow always reads fresh state
+	allowed, err = cached.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.False(t, allowed, "allow must not be decided on cached state")
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// canAllowN asserts whether n units fit for the "user" key and returns the decision
+func canAllowN(t *testing.T, limiter *RateLimiter, n int, want bool) *Decision {
+	t.Helper()
+	ok, decision, err := limiter.CanAllowN(t.Context(), AccessOptions{Key: "user"}, n)
+	require.NoError(t, err)
+	assert.Equal(t, want, ok, "n=%d", n)
+	assert.Equal(t, ok, decision.Allowed)
+	return decision
+}
+
+func TestCanAllowN_FixedWindow(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(5)))
+		require.NoError(t, err)
+		defer limiter.Close()
+		require.Equal(t, 2, allowN(t, limiter, 2))
+
+		// Exactly the remaining quota fits, one more does not
+		decision := canAllowN(t, limiter, 3, true)
+		assert.Empty(t, decision.LimitingTier)
+		assert.Zero(t, decision.RetryAfter)
+
+		decision = canAllowN(t, limiter, 4, false)
+		assert.Equal(t, "default", decision.LimitingTier)
+		assert.Equal(t, time.Minute, decision.RetryAfter)
+		assert.Equal(t, 3, decision.Results.Default().Remaining)
+
+		// Nothing was consumed
+		assert.Equal(t, 3, allowN(t, limiter, 5))
+	})
+}
+
+func TestCanAllowN_NearlyExhaustedQuota(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(fixedwindow.NewConfig().
+				AddQuota("minute", 10, time.Minute).
+				AddQuota("hour", 12, time.Hour).
+				Build()),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		require.Equal(t, 8, allowN(t, limiter, 8))
+
+		// The minute quota is short of 3
+		decision := canAllowN(t, limiter, 3, false)
+		assert.Equal(t, "minute", decision.LimitingTier)
+		canAllowN(t, limiter, 2, true)
+
+		// With a fresh minute, the nearly exhausted hour quota limits
+		time.Sleep(time.Minute)
+		canAllowN(t, limiter, 4, true)
+		decision = canAllowN(t, limiter, 5, false)
+		assert.Equal(t, "hour", decision.LimitingTier)
+		assert.Equal(t, 59*time.Minute, decision.RetryAfter)
+	})
+}
+
+func TestCanAllowN_TokenBucket(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		require.Equal(t, 2, allowN(t, limiter, 2))
+
+		canAllowN(t, limiter, 3, true)
+		canAllowN(t, limiter, 4, false)
+
+		// One more token after refilling for a second
+		time.Sleep(time.Second)
+		canAllowN(t, limiter, 4, true)
+	})
+}
+
+func TestCanAllowN_Lists(t *testing.T) {
+	limiter := newKeyLimiter(t,
+		WithAllowList(onKeys("user")),
+		WithDenyList(onKeys("banned")),
+	)
+
+	canAllowN(t, limiter, 100, true)
+
+	ok, decision, err := limiter.CanAllowN(t.Context(), AccessOptions{Key: "banned"}, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, DenyListResultKey, decision.LimitingTier)
+}
+
+func TestCanAllowN_Arguments(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	for _, n := range []int{0, -1} {
+		_, _, err := limiter.CanAllowN(t.Context(), AccessOptions{Key: "user"}, n)
+		assert.Error(t, err)
+	}
+
+	// The caller's result pointer is left alone
+	var results strategies.Results
+	_, _, err := limiter.CanAllowN(t.Context(), AccessOptions{Key: "user", Result: &results}, 1)
+	require.NoError(t, err)
+	assert.Nil(t, results)
+}
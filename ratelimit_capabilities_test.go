@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    strategies.Config
+		primary   bool
+		secondary bool
+	}{
+		{"fixed window", fixedwindow.NewConfig().AddQuota("default", 10, time.Minute).Build(), true, false},
+		{"token bucket", &tokenbucket.Config{Burst: 10, Rate: 1}, true, true},
+		{"leaky bucket", &leakybucket.Config{Burst: 10, Rate: 1}, true, true},
+		{"gcra", &gcra.Config{Burst: 10, Rate: 1}, true, true},
+		{"nil", nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps := strategies.Capabilities(tt.config)
+			assert.Equal(t, tt.primary, caps.Has(strategies.CapPrimary))
+			assert.Equal(t, tt.secondary, caps.Has(strategies.CapSecondary))
+
+			// The capabilities predict whether New accepts the strategy as a secondary
+			if tt.config == nil {
+				return
+			}
+			limiter, err := New(
+				WithBackend(memory.New()),
+				WithPrimaryStrategy(perMinute(100)),
+				WithSecondaryStrategy(tt.config),
+			)
+			if tt.secondary {
+				require.NoError(t, err)
+				limiter.Close()
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestSupportsSecondary(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"fixed window", []Option{WithPrimaryStrategy(perMinute(10))}, true},
+		{"dual", []Option{WithPrimaryStrategy(perMinute(10)), WithSecondaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 1})}, true},
+		{"token bucket", []Option{WithPrimaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 1})}, false},
+		{"any", []Option{WithAnyStrategy(perMinute(10), perMinute(5))}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := New(append(tt.opts, WithBackend(memory.New()))...)
+			require.NoError(t, err)
+			defer limiter.Close()
+			assert.Equal(t, tt.want, limiter.SupportsSecondary())
+		})
+	}
+}
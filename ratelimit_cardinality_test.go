@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cardinalityAlerts counts the calls of a CardinalityFunc and records their keys
+type cardinalityAlerts struct {
+	keys []string
+}
+
+func (c *cardinalityAlerts) alert(_ context.Context, maxKeys int, key string) {
+	c.keys = append(c.keys, fmt.Sprintf("%d:%s", maxKeys, key))
+}
+
+func TestMaxDistinctKeys_AlertsOncePerWindow(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		alerts := &cardinalityAlerts{}
+		logger := &captureLogger{}
+		limiter := newKeyLimiter(t, WithLogger(logger), WithMaxDistinctKeys(10, WithCardinalityAlert(alerts.alert)))
+
+		// A key function including a timestamp creates a new key per request
+		for i := range 50 {
+			var results strategies.Results
+			allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: fmt.Sprintf("user-%d", i), Result: &results})
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.Contains(t, results, "default", "Keys beyond the bound are still enforced")
+		}
+		assert.Equal(t, []string{"10:user-10"}, alerts.keys)
+		assert.Len(t, logger.at(slog.LevelWarn), 1)
+
+		// Known keys never alert, the next window starts over
+		for range 3 {
+			_, err := limiter.Allow(t.Context(), AccessOptions{Key: "user-0"})
+			require.NoError(t, err)
+		}
+		assert.Len(t, alerts.keys, 1)
+
+		time.Sleep(DistinctKeysWindow)
+		for i := range 11 {
+			_, err := limiter.Allow(t.Context(), AccessOptions{Key: fmt.Sprintf("next-%d", i)})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"10:user-10", "10:next-10"}, alerts.keys)
+	})
+}
+
+func TestMaxDistinctKeys_FailOpen(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter := newKeyLimiter(t, WithMaxDistinctKeys(2, WithCardinalityFailOpen()))
+
+		for _, key := range []string{"a", "b"} {
+			_, err := limiter.Allow(t.Context(), AccessOptions{Key: key})
+			require.NoError(t, err)
+		}
+
+		// New keys beyond the bound are allowed without creating state
+		for range 5 {
+			var results strategies.Results
+			allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "c", Result: &results})
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.Equal(t, strategies.Results{CardinalityResultKey: {Allowed: true}}, results)
+		}
+		keys, err := limiter.ListKeys(t.Context(), "c*")
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		// Keys seen before the bound are still enforced
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "a"})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		allowed, err = limiter.Allow(t.Context(), AccessOptions{Key: "a"})
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
+
+func TestMaxDistinctKeys_Validation(t *testing.T) {
+	require.Error(t, WithMaxDistinctKeys(0)(&Config{}))
+	require.Error(t, WithMaxDistinctKeys(-1)(&Config{}))
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+	"testing/synctest"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storedValues returns the raw values of all keys in backend
+func storedValues(t *testing.T, backend *memory.Backend) []string {
+	t.Helper()
+	keys, err := backend.Keys(t.Context(), "")
+	require.NoError(t, err)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, err := backend.Get(t.Context(), key)
+		require.NoError(t, err)
+		values = append(values, value)
+	}
+	return values
+}
+
+func TestWithStateCodec_JSON(t *testing.T) {
+	tests := map[string]Option{
+		"fixed window": WithPrimaryStrategy(perMinute(3)),
+		"token bucket": WithPrimaryStrategy(&tokenbucket.Config{Burst: 3, Rate: 0.01}),
+	}
+
+	for name, strategy := range tests {
+		t.Run(name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				backend := memory.New()
+				limiter, err := New(WithBackend(backend), strategy, WithStateCodec(backends.JSONCodec))
+				require.NoError(t, err)
+				defer limiter.Close()
+
+				assert.Equal(t, 3, allowN(t, limiter, 5))
+				values := storedValues(t, backend)
+				require.Len(t, values, 1)
+				assert.True(t, strings.HasPrefix(values[0], `{"header":`), values[0])
+				assert.Equal(t, 0, remaining(t, limiter, "user").Default().Remaining)
+			})
+		})
+	}
+}
+
+func TestWithStateCodec_SwitchKeepsState(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		backend := memory.New()
+		defer backend.Close()
+
+		compact, err := New(WithBackend(backend), WithPrimaryStrategy(perMinute(5)))
+		require.NoError(t, err)
+		require.Equal(t, 2, allowN(t, compact, 2))
+		assert.True(t, strings.HasPrefix(storedValues(t, backend)[0], "23|1|default|2|"))
+
+		// The JSON codec picks up the counts stored in the compact format
+		json, err := New(WithBackend(backend), WithPrimaryStrategy(perMinute(5)), WithStateCodec(backends.JSONCodec))
+		require.NoError(t, err)
+		assert.Equal(t, 3, allowN(t, json, 5))
+		assert.True(t, strings.HasPrefix(storedValues(t, backend)[0], "{"))
+
+		// Switching back needs the compact codec to read the JSON values
+		compact, err = New(WithBackend(backend), WithPrimaryStrategy(perMinute(5)), WithStateCodec(backends.CompactCodec))
+		require.NoError(t, err)
+		assert.Equal(t, 0, allowN(t, compact, 1))
+		require.NoError(t, compact.Refund(t.Context(), AccessOptions{Key: "user"}))
+		assert.True(t, strings.HasPrefix(storedValues(t, backend)[0], "23|1|default|4|"))
+	})
+}
+
+func TestWithStateCodec_Nil(t *testing.T) {
+	_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)), WithStateCodec(nil))
+	assert.Error(t, err)
+}
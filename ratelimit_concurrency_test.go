@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRateAndConcurrency(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithRateAndConcurrency(3, time.Minute, 2))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	ctx := t.Context()
+	options := AccessOptions{Key: "user"}
+
+	// Concurrency cap: two requests in flight
+	allowed, release1, err := limiter.Acquire(ctx, options)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	allowed, release2, err := limiter.Acquire(ctx, options)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	var results strategies.Results
+	allowed, release, err := limiter.Acquire(ctx, AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed, "a third request in flight is denied")
+	assert.False(t, results["secondary_default"].Allowed)
+	assert.True(t, results["primary_default"].Allowed)
+	require.NoError(t, release(), "releasing a denied request is a no-op")
+
+	// Releasing frees a slot, releasing twice frees only one
+	require.NoError(t, release1())
+	require.NoError(t, release1())
+	allowed, release3, err := limiter.Acquire(ctx, options)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Rate cap: the three allowed requests used the window, releases do not refund it
+	require.NoError(t, release2())
+	require.NoError(t, release3())
+	allowed, _, err = limiter.Acquire(ctx, AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.False(t, results["primary_default"].Allowed)
+	assert.NotContains(t, results, "secondary_default", "denied by the rate cap alone")
+}
+
+func TestWithRateAndConcurrency_Invalid(t *testing.T) {
+	for name, option := range map[string]Option{
+		"rate":       WithRateAndConcurrency(0, time.Minute, 2),
+		"window":     WithRateAndConcurrency(3, 0, 2),
+		"concurrent": WithRateAndConcurrency(3, time.Minute, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New(WithBackend(memory.New()), option)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAcquire_WithoutConcurrency(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	allowed, release, err := limiter.Acquire(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	require.NoError(t, release())
+
+	allowed, _, err = limiter.Acquire(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.False(t, allowed, "release does not refund rate quota")
+}
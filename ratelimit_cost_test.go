@@ -0,0 +1,179 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"testing"
+	"testing/synctest"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sizeCost charges by the "size" metadata bucket
+func sizeCost(options AccessOptions) float64 {
+	switch options.Metadata["size"] {
+	case "large":
+		return 5
+	case "medium":
+		return 2.5
+	default:
+		return 1
+	}
+}
+
+func allowSized(t *testing.T, limiter *RateLimiter, size string) (bool, strategies.Result) {
+	t.Helper()
+	var results strategies.Results
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{
+		Key:      "user",
+		Metadata: map[string]any{"size": size},
+		Result:   &results,
+	})
+	require.NoError(t, err)
+	return allowed, results.Default()
+}
+
+func TestWithCostFunc_Validation(t *testing.T) {
+	require.Error(t, WithCostFunc(nil)(&Config{}))
+
+	cfg := &Config{}
+	require.NoError(t, WithCostFunc(sizeCost)(cfg))
+	assert.NotNil(t, cfg.costFunc)
+}
+
+func TestCostFunc_VariableConsumption(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy strategies.Config
+		// remaining after small (1), large (5) and medium (2.5) requests,
+		// leaky bucket truncates its fractional fill and fixed window rounds the cost up
+		want []int
+	}{
+		{"token bucket", &tokenbucket.Config{Burst: 10, Rate: 0.001}, []int{9, 4, 1}},
+		{"leaky bucket", &leakybucket.Config{Burst: 10, Rate: 0.001}, []int{9, 4, 2}},
+		{"fixed window", perMinute(10), []int{9, 4, 1}},
+		{"gcra", &gcra.Config{Burst: 10, Rate: 0.001}, []int{9, 4, 1}},
+	}
+
+	for _, tt := range tests {
+		// Frozen time keeps buckets from refilling between requests
+		t.Run(tt.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				limiter, err := New(
+					WithBackend(memory.New()),
+					WithPrimaryStrategy(tt.strategy),
+					WithCostFunc(sizeCost),
+				)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = limiter.Close() })
+
+				for i, size := range []string{"small", "large", "medium"} {
+					allowed, res := allowSized(t, limiter, size)
+					require.True(t, allowed, "%s request should be allowed", size)
+					assert.Equal(t, tt.want[i], res.Remaining, "remaining after %s request", size)
+				}
+
+				// A large request no longer fits, but a small one still does
+				allowed, res := allowSized(t, limiter, "large")
+				assert.False(t, allowed, "large request should exceed the remaining quota")
+				assert.Equal(t, tt.want[2], res.Remaining, "denied request must not consume quota")
+
+				allowed, _ = allowSized(t, limiter, "small")
+				assert.True(t, allowed, "small request should still fit")
+			})
+		})
+	}
+}
+
+func TestCostFunc_DualStrategy(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(100)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 6, Rate: 0.001}),
+		WithCostFunc(sizeCost),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	var results strategies.Results
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{
+		Key:      "user",
+		Metadata: map[string]any{"size": "large"},
+		Result:   &results,
+	})
+	require.NoError(t, err)
+	require.True(t, allowed)
+	assert.Equal(t, 95, results.PrimaryDefault().Remaining)
+	assert.Equal(t, 1, results.SecondaryDefault().Remaining)
+
+	allowed, err = limiter.Allow(t.Context(), AccessOptions{
+		Key:      "user",
+		Metadata: map[string]any{"size": "large"},
+		Result:   &results,
+	})
+	require.NoError(t, err)
+	assert.False(t, allowed, "secondary should deny the second large request")
+
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 95, results.PrimaryDefault().Remaining, "denied request must not consume primary quota")
+}
+
+func TestCostFunc_InvalidCost(t *testing.T) {
+	for _, cost := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(10)),
+			WithCostFunc(func(AccessOptions) float64 { return cost }),
+		)
+		require.NoError(t, err)
+
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.Error(t, err, "cost %v should be rejected", cost)
+		assert.False(t, allowed)
+		_ = limiter.Close()
+	}
+}
+
+func TestContextWithCost(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(&tokenbucket.Config{Burst: 10, Rate: 0.001}),
+		WithCostFunc(sizeCost),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	allow := func(ctx context.Context, n int) int {
+		t.Helper()
+		var results strategies.Results
+		options := AccessOptions{Key: "user", Metadata: map[string]any{"size": "large"}, Result: &results}
+		var err error
+		if n > 0 {
+			_, err = limiter.AllowN(ctx, options, n)
+		} else {
+			_, err = limiter.Allow(ctx, options)
+		}
+		require.NoError(t, err)
+		return results.Default().Remaining
+	}
+
+	// The context cost takes precedence over the cost function, AllowN over both
+	ctx := ContextWithCost(t.Context(), 0.5)
+	assert.Equal(t, 9, allow(ctx, 0))
+	assert.Equal(t, 7, allow(ctx, 2))
+	assert.Equal(t, 2, allow(t.Context(), 0))
+
+	_, err = limiter.Allow(ContextWithCost(t.Context(), -1), AccessOptions{Key: "user"})
+	require.ErrorContains(t, err, "request cost must be positive")
+
+	cost, ok := CostFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, cost)
+}
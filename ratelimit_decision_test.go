@@ -0,0 +1,266 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// downBackend fails every operation with a health error
+type downBackend struct{}
+
+var errDown = backends.NewHealthError("down:Get", errors.New("connection refused"))
+
+func (downBackend) Get(context.Context, string) (string, error) { return "", errDown }
+func (downBackend) Set(context.Context, string, string, time.Duration) error {
+	return errDown
+}
+func (downBackend) CheckAndSet(context.Context, string, string, string, time.Duration) (bool, error) {
+	return false, errDown
+}
+func (downBackend) Delete(context.Context, string) error { return errDown }
+func (downBackend) Close() error                         { return nil }
+
+func TestCheck_MatchesAllow(t *testing.T) {
+	newLimiter := func() *RateLimiter {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(fixedwindow.NewConfig().
+				AddQuota("minute", 3, time.Minute).
+				AddQuota("hour", 10, time.Hour).
+				Build()),
+			WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 0.001}),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = limiter.Close() })
+		return limiter
+	}
+	allowLimiter, checkLimiter := newLimiter(), newLimiter()
+
+	for i := range 5 {
+		var results strategies.Results
+		allowed, err := allowLimiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+
+		decision, err := checkLimiter.Check(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+
+		assert.Equal(t, allowed, decision.Allowed, "request %d", i)
+		require.Len(t, decision.Results, len(results))
+		for name, res := range results {
+			assert.Equal(t, res.Allowed, decision.Results[name].Allowed, "request %d %s", i, name)
+			assert.Equal(t, res.Remaining, decision.Results[name].Remaining, "request %d %s", i, name)
+			assert.WithinDuration(t, res.Reset, decision.Results[name].Reset, time.Second)
+		}
+		assert.False(t, decision.Degraded)
+
+		if decision.Allowed {
+			assert.Empty(t, decision.LimitingTier)
+			assert.Zero(t, decision.RetryAfter)
+			continue
+		}
+		assert.Equal(t, "primary_minute", decision.LimitingTier, "request %d", i)
+		assert.InDelta(t, time.Minute, decision.RetryAfter, float64(time.Second))
+	}
+}
+
+func TestCheck_ConsumedTiers(t *testing.T) {
+	tests := map[string]struct {
+		options   []StrategyOption // of the secondary
+		limit     int              // primary limit per minute
+		burst     int              // secondary burst
+		allowed   bool
+		primary   bool
+		secondary bool
+		remaining map[string]int // after the second request
+	}{
+		"both allow": {
+			limit: 5, burst: 5, allowed: true, primary: true, secondary: true,
+			remaining: map[string]int{"primary_default": 3, "secondary_default": 3},
+		},
+		"secondary denies": {
+			limit: 5, burst: 1,
+			remaining: map[string]int{"primary_default": 4, "secondary_default": 0},
+		},
+		"primary denies": {
+			limit: 1, burst: 5,
+			remaining: map[string]int{"primary_default": 0, "secondary_default": 4},
+		},
+		"advisory secondary denies": {
+			options: []StrategyOption{WithAdvisory()},
+			limit:   5, burst: 1, allowed: true, primary: true,
+			remaining: map[string]int{"primary_default": 3, "secondary_default": 0},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			limiter := newKeyLimiter(t,
+				WithPrimaryStrategy(perMinute(tt.limit)),
+				WithSecondaryStrategy(&tokenbucket.Config{Burst: tt.burst, Rate: 0.001}, tt.options...),
+			)
+
+			first, err := limiter.Check(t.Context(), AccessOptions{Key: "user"})
+			require.NoError(t, err)
+			require.True(t, first.Allowed)
+			assert.True(t, first.PrimaryConsumed)
+			assert.True(t, first.SecondaryConsumed)
+
+			second, err := limiter.Check(t.Context(), AccessOptions{Key: "user"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, second.Allowed)
+			assert.Equal(t, tt.primary, second.PrimaryConsumed, "primary consumed")
+			assert.Equal(t, tt.secondary, second.SecondaryConsumed, "secondary consumed")
+
+			var results strategies.Results
+			_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+			require.NoError(t, err)
+			for tier, remaining := range tt.remaining {
+				assert.Equal(t, remaining, results[tier].Remaining, tier)
+			}
+		})
+	}
+}
+
+func TestCheck_ConsumedTiersWithoutSecondary(t *testing.T) {
+	limiter := newKeyLimiter(t, WithPrimaryStrategy(perMinute(1)), WithAllowList(onKeys("internal")))
+
+	decision, err := limiter.Check(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, decision.PrimaryConsumed)
+	assert.False(t, decision.SecondaryConsumed)
+
+	decision, err = limiter.Check(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.False(t, decision.PrimaryConsumed)
+
+	// Allow-listed requests run no strategy
+	decision, err = limiter.Check(t.Context(), AccessOptions{Key: "internal"})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.False(t, decision.PrimaryConsumed)
+
+	// With any-allows tiers, only the first counts as the primary
+	anyLimiter := newKeyLimiter(t, WithAnyStrategy(perMinute(1), perMinute(5)))
+	for _, primary := range []bool{true, false} {
+		decision, err := anyLimiter.Check(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, primary, decision.PrimaryConsumed)
+		assert.False(t, decision.SecondaryConsumed)
+	}
+}
+
+func TestCheck_LimitingTierResetsLast(t *testing.T) {
+	now := time.Now()
+	results := strategies.Results{
+		"primary_default":   {Allowed: true, Reset: now.Add(time.Hour)},
+		"secondary_a":       {Allowed: false, Reset: now.Add(2 * time.Second)},
+		"secondary_b":       {Allowed: false, Reset: now.Add(10 * time.Second)},
+		"secondary_expired": {Allowed: false, Reset: now.Add(-time.Second)},
+	}
+
+	tier, retryAfter := limitingTier(results, now)
+	assert.Equal(t, "secondary_b", tier)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	tier, retryAfter = limitingTier(strategies.Results{"default": {Allowed: false, Reset: now.Add(-time.Second)}}, now)
+	assert.Equal(t, "default", tier)
+	assert.Zero(t, retryAfter, "past resets clamp to zero")
+}
+
+func TestCheck_Degraded(t *testing.T) {
+	limiter, err := New(
+		WithBackend(downBackend{}),
+		WithMemoryFailover(WithFailureThreshold(1)),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	decision, err := limiter.Check(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.True(t, decision.Degraded, "decision served by the memory fallback should be flagged")
+}
+
+func TestCheck_Reason(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		key  string
+		want strategies.DenyReason
+	}{
+		{"fixed window exhausted", nil, "user", strategies.QuotaExhausted},
+		{"token bucket burst exceeded", []Option{
+			WithPrimaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 0.01}),
+		}, "user", strategies.BurstExceeded},
+		{"secondary burst exceeded", []Option{
+			WithPrimaryStrategy(perMinute(10)),
+			WithSecondaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 0.01}),
+		}, "user", strategies.BurstExceeded},
+		{"concurrency cap", []Option{
+			WithRateAndConcurrency(10, time.Minute, 2),
+		}, "user", strategies.ConcurrencyCap},
+		{"deny list", []Option{WithDenyList(onKeys("banned"))}, "banned", strategies.DenyList},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newKeyLimiter(t, tt.opts...)
+
+			var last *Decision
+			for range 3 {
+				decision, err := limiter.Check(t.Context(), AccessOptions{Key: tt.key})
+				require.NoError(t, err)
+				if decision.Allowed {
+					assert.Equal(t, strategies.ReasonNone, decision.Reason)
+				}
+				last = decision
+			}
+			require.False(t, last.Allowed)
+			assert.Equal(t, tt.want, last.Reason)
+			assert.Equal(t, tt.want, last.Results[last.LimitingTier].Reason)
+		})
+	}
+}
+
+func TestCheck_ReasonDegraded(t *testing.T) {
+	limiter, err := New(
+		WithBackend(downBackend{}),
+		WithMemoryFailover(WithFailureThreshold(1)),
+		WithPrimaryStrategy(perMinute(1)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	decision, err := limiter.Check(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	require.True(t, decision.Allowed)
+	decision, err = limiter.Check(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, strategies.Degraded, decision.Reason)
+	assert.Equal(t, "degraded", decision.Reason.String())
+}
+
+func TestCheck_Errors(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	decision, err := limiter.Check(t.Context(), AccessOptions{Key: "bad key!"})
+	require.Error(t, err)
+	assert.Nil(t, decision)
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drainDenials returns the events currently buffered in ch
+func drainDenials(ch <-chan DenialEvent) []DenialEvent {
+	var events []DenialEvent
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestDenialEvents_OnePerTransition(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(3)))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	events := limiter.DenialEvents()
+
+	before := time.Now()
+	for range 10 {
+		_, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+	}
+
+	got := drainDenials(events)
+	require.Len(t, got, 1, "only the transition into denial is reported")
+	assert.Equal(t, "user", got[0].Key)
+	assert.Equal(t, "default", got[0].Tier)
+	assert.False(t, got[0].Time.Before(before))
+
+	// Other keys transition independently
+	for range 5 {
+		_, err := limiter.Allow(t.Context(), AccessOptions{Key: "other"})
+		require.NoError(t, err)
+	}
+	got = drainDenials(events)
+	require.Len(t, got, 1)
+	assert.Equal(t, "other", got[0].Key)
+
+	// An allowed request ends the streak, the next denial is reported again
+	require.NoError(t, limiter.Reset(t.Context(), AccessOptions{Key: "user"}))
+	for range 5 {
+		_, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+	}
+	got = drainDenials(events)
+	require.Len(t, got, 1)
+	assert.Equal(t, "user", got[0].Key)
+}
+
+func TestDenialEvents_Tier(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("hour", 100, time.Hour).Build()),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 0.001}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	events := limiter.DenialEvents()
+
+	for range 4 {
+		_, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+	}
+
+	got := drainDenials(events)
+	require.Len(t, got, 1)
+	assert.Equal(t, "secondary_default", got[0].Tier)
+}
+
+func TestDenialEvents_DropOnFull(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	events := limiter.DenialEvents()
+	assert.Equal(t, events, limiter.DenialEvents(), "every call returns the same channel")
+
+	// Nobody reads: denials beyond the buffer are dropped without blocking
+	for i := range denialEventBuffer + 10 {
+		key := fmt.Sprintf("user%d", i)
+		for range 2 {
+			_, err := limiter.Allow(t.Context(), AccessOptions{Key: key})
+			require.NoError(t, err)
+		}
+	}
+	assert.Len(t, drainDenials(events), denialEventBuffer)
+}
+
+func TestDenialEvents_ClosedByClose(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)))
+	require.NoError(t, err)
+	events := limiter.DenialEvents()
+
+	require.NoError(t, limiter.Close())
+	_, ok := <-events
+	assert.False(t, ok, "channel should be closed")
+}
+
+func TestDenialEvents_NotTrackedUntilRequested(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	for range 3 {
+		_, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+	}
+	assert.Nil(t, limiter.denials.Load())
+}
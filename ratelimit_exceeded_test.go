@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exceededRecorder collects WithOnExceeded calls
+type exceededRecorder struct {
+	mu    sync.Mutex
+	calls [][2]string
+}
+
+func (e *exceededRecorder) record(_ context.Context, key, tier string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, [2]string{key, tier})
+}
+
+func TestWithOnExceeded_Validation(t *testing.T) {
+	require.Error(t, WithOnExceeded(nil)(&Config{}))
+}
+
+func TestWithOnExceeded_FiresOncePerDenial(t *testing.T) {
+	var recorder exceededRecorder
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(fixedwindow.NewConfig().
+			AddQuota("minute", 10, time.Minute).
+			AddQuota("hour", 3, time.Hour).
+			Build()),
+		WithOnExceeded(recorder.record),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, allowN(t, limiter, 5))
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "other"})
+	require.NoError(t, err)
+
+	// Close waits for the queued calls
+	require.NoError(t, limiter.Close())
+	assert.Equal(t, [][2]string{{"user", "hour"}, {"user", "hour"}}, recorder.calls)
+}
+
+func TestWithOnExceeded_DenyingTier(t *testing.T) {
+	var recorder exceededRecorder
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(10)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 1, Rate: 0.01}),
+		WithDenyList(func(options AccessOptions) bool { return options.Key == "banned" }),
+		WithOnExceeded(recorder.record),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, allowN(t, limiter, 2))
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "banned"})
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	require.NoError(t, limiter.Close())
+	assert.Equal(t, [][2]string{{"user", "secondary_default"}}, recorder.calls)
+}
+
+func TestWithOnExceeded_DoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var recorder exceededRecorder
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(1)),
+		WithOnExceeded(func(ctx context.Context, key, tier string) {
+			<-release
+			recorder.record(ctx, key, tier)
+		}),
+	)
+	require.NoError(t, err)
+
+	// Denials beyond the busy workers and the queue are dropped, not waited for
+	denials := exceededWorkers + exceededQueueSize + 10
+	assert.Equal(t, 1, allowN(t, limiter, denials+1))
+
+	close(release)
+	require.NoError(t, limiter.Close())
+	assert.GreaterOrEqual(t, len(recorder.calls), exceededQueueSize)
+	assert.Less(t, len(recorder.calls), denials)
+}
+
+func TestWithOnExceeded_DetachedContext(t *testing.T) {
+	ctxErr := make(chan error, 1)
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(1)),
+		WithOnExceeded(func(ctx context.Context, _, _ string) {
+			time.Sleep(10 * time.Millisecond)
+			ctxErr <- ctx.Err()
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	for range 2 {
+		_, err = limiter.Allow(ctx, AccessOptions{Key: "user"})
+		require.NoError(t, err)
+	}
+	cancel()
+
+	require.NoError(t, limiter.Close())
+	assert.NoError(t, <-ctxErr)
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportKey_RoundTrip(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		source, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(5)))
+		require.NoError(t, err)
+		defer source.Close()
+		assert.Equal(t, 3, allowN(t, source, 3))
+
+		data, err := source.ExportKey(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+
+		// The target stores JSON as a different backend would, under another key
+		target, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(5)),
+			WithStateCodec(backends.JSONCodec),
+		)
+		require.NoError(t, err)
+		defer target.Close()
+		require.NoError(t, target.ImportKey(t.Context(), data, AccessOptions{Key: "moved"}))
+
+		var result strategies.Results
+		_, err = target.Peek(t.Context(), AccessOptions{Key: "moved", Result: &result})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Default().Remaining)
+
+		// The window keeps its start
+		time.Sleep(time.Minute)
+		_, err = target.Peek(t.Context(), AccessOptions{Key: "moved", Result: &result})
+		require.NoError(t, err)
+		assert.Equal(t, 5, result.Default().Remaining)
+	})
+}
+
+func TestExportKey_DualStrategy(t *testing.T) {
+	options := []Option{
+		WithPrimaryStrategy(perMinute(10)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 4, Rate: 0.01}),
+	}
+	source, err := New(append(options, WithBackend(memory.New()))...)
+	require.NoError(t, err)
+	defer source.Close()
+	assert.Equal(t, 4, allowN(t, source, 6))
+
+	data, err := source.ExportKey(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	var export keyExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, KeyExportVersion, export.Version)
+	assert.Equal(t, []string{"fixed_window", "token_bucket"}, export.Strategies)
+	require.Len(t, export.States, 1)
+
+	target, err := New(append(options, WithBackend(memory.New()))...)
+	require.NoError(t, err)
+	defer target.Close()
+	require.NoError(t, target.ImportKey(t.Context(), data, AccessOptions{Key: "user"}))
+	assert.Equal(t, 0, allowN(t, target, 1))
+}
+
+func TestExportKey_FreshKeyClearsState(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(2)))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	data, err := limiter.ExportKey(t.Context(), AccessOptions{Key: "fresh"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+
+	require.NoError(t, limiter.ImportKey(t.Context(), data, AccessOptions{Key: "user"}))
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+}
+
+func TestImportKey_Incompatible(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(2)))
+	require.NoError(t, err)
+	defer limiter.Close()
+	allowN(t, limiter, 1)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `12|1|2`},
+		{"version", `{"version":2,"strategies":["fixed_window"],"states":[]}`},
+		{"strategies", `{"version":1,"strategies":["token_bucket"],"states":[{"key":"","state":"12|1|946684800000000000"}]}`},
+		{"state", `{"version":1,"strategies":["fixed_window"],"states":[{"key":"","state":"12|1|946684800000000000"}]}`},
+		{"storage key", `{"version":1,"strategies":["fixed_window"],"states":[{"key":":c","state":"23|1|default|1|946684800000000000"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limiter.ImportKey(t.Context(), []byte(tt.data), AccessOptions{Key: "user"})
+			assert.ErrorIs(t, err, ErrIncompatibleExport)
+		})
+	}
+
+	// Nothing was written
+	assert.Equal(t, 1, allowN(t, limiter, 2))
+}
@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outageBackend is a memory backend failing with errDown while down is set
+type outageBackend struct {
+	*memory.Backend
+	down atomic.Bool
+}
+
+func (o *outageBackend) Get(ctx context.Context, key string) (string, error) {
+	if o.down.Load() {
+		return "", errDown
+	}
+	return o.Backend.Get(ctx, key)
+}
+
+func (o *outageBackend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	if o.down.Load() {
+		return false, errDown
+	}
+	return o.Backend.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+}
+
+func TestFailover_StateHook(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var mu sync.Mutex
+		var transitions [][2]backends.BreakerState
+		primary := &outageBackend{Backend: memory.New()}
+		limiter, err := New(
+			WithBackend(primary),
+			WithMemoryFailover(
+				WithFailureThreshold(1),
+				WithHealthCheckInterval(time.Second),
+				WithBreakerStateHook(func(from, to backends.BreakerState) {
+					mu.Lock()
+					defer mu.Unlock()
+					transitions = append(transitions, [2]backends.BreakerState{from, to})
+				}),
+			),
+			WithPrimaryStrategy(perMinute(5)),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		failover, ok := limiter.Failover()
+		require.True(t, ok)
+		assert.Equal(t, backends.BreakerClosed, failover.BreakerState())
+
+		// The outage trips the breaker once, however many requests fail over
+		primary.down.Store(true)
+		assert.Equal(t, 3, allowN(t, limiter, 3))
+		assert.Equal(t, backends.BreakerOpen, failover.BreakerState())
+		assert.Equal(t, 1, failover.BreakerFailureCount())
+
+		// The health check closes it once the primary is back
+		primary.down.Store(false)
+		time.Sleep(time.Second)
+		synctest.Wait()
+		assert.Equal(t, backends.BreakerClosed, failover.BreakerState())
+		assert.Zero(t, failover.BreakerFailureCount())
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, [][2]backends.BreakerState{
+			{backends.BreakerClosed, backends.BreakerOpen},
+			{backends.BreakerOpen, backends.BreakerClosed},
+		}, transitions)
+	})
+}
+
+func TestFailover_NotConfigured(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	failover, ok := limiter.Failover()
+	assert.False(t, ok)
+	assert.Nil(t, failover)
+}
+
+func TestFailover_FailureClassifier(t *testing.T) {
+	limiter, err := New(
+		WithBackend(downBackend{}),
+		WithMemoryFailover(
+			WithFailureThreshold(1),
+			WithFailureClassifier(func(error) bool { return false }),
+		),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	// Errors that are not failures reach the caller instead of failing over
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.ErrorIs(t, err, errDown)
+	failover, ok := limiter.Failover()
+	require.True(t, ok)
+	assert.Equal(t, backends.BreakerClosed, failover.BreakerState())
+}
+
+func TestFailover_ReconcileOnRecovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []MemoryFailoverOption
+		remaining int
+	}{
+		{"disabled", nil, 5},
+		{"enabled", []MemoryFailoverOption{WithReconcileOnRecovery()}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				primary := &outageBackend{Backend: memory.New()}
+				opts := append([]MemoryFailoverOption{
+					WithFailureThreshold(1),
+					WithHealthCheckInterval(time.Second),
+				}, tt.opts...)
+				limiter, err := New(
+					WithBackend(primary),
+					WithMemoryFailover(opts...),
+					WithPrimaryStrategy(perMinute(5)),
+				)
+				require.NoError(t, err)
+				defer limiter.Close()
+
+				primary.down.Store(true)
+				assert.Equal(t, 3, allowN(t, limiter, 3))
+
+				primary.down.Store(false)
+				time.Sleep(time.Second)
+				synctest.Wait()
+				failover, _ := limiter.Failover()
+				require.Equal(t, backends.BreakerClosed, failover.BreakerState())
+
+				// Requests served during the outage count against the primary
+				assert.Equal(t, tt.remaining, allowN(t, limiter, 5))
+			})
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyBackend fails every operation with a health error while down is set
+type flakyBackend struct {
+	backends.Backend
+	down atomic.Bool
+}
+
+func (f *flakyBackend) Get(ctx context.Context, key string) (string, error) {
+	if f.down.Load() {
+		return "", errDown
+	}
+	return f.Backend.Get(ctx, key)
+}
+
+func (f *flakyBackend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	if f.down.Load() {
+		return false, errDown
+	}
+	return f.Backend.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+}
+
+func newFlakyLimiter(t *testing.T, opts ...Option) (*RateLimiter, *flakyBackend) {
+	t.Helper()
+	backend := &flakyBackend{Backend: memory.New()}
+	limiter, err := New(append([]Option{WithBackend(backend), WithPrimaryStrategy(perMinute(5))}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	return limiter, backend
+}
+
+func TestPeekFallback_ServesStaleResults(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, backend := newFlakyLimiter(t, WithPeekFallback(time.Minute))
+		allowN(t, limiter, 2)
+
+		backend.down.Store(true)
+		time.Sleep(30 * time.Second)
+
+		var results strategies.Results
+		allowed, err := limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		require.Contains(t, results, "default")
+		assert.Equal(t, 3, results["default"].Remaining)
+		assert.True(t, results["default"].Degraded)
+
+		// Snapshots older than the max age are not served
+		time.Sleep(time.Minute)
+		_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user"})
+		assert.True(t, backends.IsHealthError(err), "expected health error, got %v", err)
+
+		// Unknown keys have no snapshot
+		_, err = limiter.Peek(t.Context(), AccessOptions{Key: "other"})
+		assert.True(t, backends.IsHealthError(err), "expected health error, got %v", err)
+	})
+}
+
+func TestPeekFallback_FreshResultsNotDegraded(t *testing.T) {
+	limiter, _ := newFlakyLimiter(t, WithPeekFallback(time.Minute))
+	allowN(t, limiter, 1)
+
+	var results strategies.Results
+	_, err := limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, results["default"].Degraded)
+}
+
+func TestPeekFallback_Disabled(t *testing.T) {
+	limiter, backend := newFlakyLimiter(t)
+	allowN(t, limiter, 1)
+
+	backend.down.Store(true)
+	_, err := limiter.Peek(t.Context(), AccessOptions{Key: "user"})
+	assert.True(t, backends.IsHealthError(err), "expected health error, got %v", err)
+}
+
+func TestSnapshotCache_EvictsOldest(t *testing.T) {
+	cache := newSnapshotCache(time.Minute)
+	for i := range peekFallbackMaxEntries {
+		cache.store(fmt.Sprintf("key%d", i), true, nil)
+	}
+
+	// Refreshing key0 makes key1 the oldest snapshot
+	cache.store("key0", false, nil)
+	cache.store("new", true, nil)
+	assert.Len(t, cache.entries, peekFallbackMaxEntries)
+	assert.Equal(t, peekFallbackMaxEntries, cache.order.Len())
+
+	_, _, ok := cache.load("key1")
+	assert.False(t, ok)
+	allowed, _, ok := cache.load("key0")
+	require.True(t, ok)
+	assert.False(t, allowed)
+	_, _, ok = cache.load("new")
+	assert.True(t, ok)
+
+	cache.forget("new")
+	cache.clear()
+	assert.Empty(t, cache.entries)
+	assert.Zero(t, cache.order.Len())
+}
+
+func TestWithPeekFallback_Invalid(t *testing.T) {
+	_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(5)), WithPeekFallback(0))
+	assert.Error(t, err)
+}
+
+func TestFailureMode_Allow(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    FailureMode
+		allowed bool
+		wantErr bool
+	}{
+		{name: "fail closed", mode: FailClosed, allowed: false, wantErr: true},
+		{name: "fail open", mode: FailOpen, allowed: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []Event
+			limiter, backend := newFlakyLimiter(t,
+				WithFailureMode(tt.mode),
+				WithPeekFallback(time.Minute),
+				WithHook(func(_ context.Context, e Event) { events = append(events, e) }),
+			)
+			allowN(t, limiter, 1)
+			backend.down.Store(true)
+
+			allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+			assert.Equal(t, tt.allowed, allowed)
+			assert.Equal(t, tt.wantErr, err != nil, "err = %v", err)
+
+			// Hooks see the backend error in either mode
+			require.NotEmpty(t, events)
+			last := events[len(events)-1]
+			assert.ErrorIs(t, last.Err, errDown)
+			assert.Equal(t, tt.allowed, last.Allowed)
+
+			decision, err := limiter.Check(t.Context(), AccessOptions{Key: "user"})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed)
+			assert.True(t, decision.Degraded)
+		})
+	}
+}
+
+func TestWithFailureMode_Invalid(t *testing.T) {
+	_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(5)), WithFailureMode(FailureMode(7)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlushAll_RespectsBaseKey(t *testing.T) {
+	shared := memory.New()
+	defer shared.Close()
+	newLimiter := func(baseKey string, opts ...Option) *RateLimiter {
+		limiter, err := New(append([]Option{WithBackend(shared), WithBaseKey(baseKey), WithPrimaryStrategy(perMinute(1))}, opts...)...)
+		require.NoError(t, err)
+		return limiter
+	}
+	api := newLimiter("api", WithPenalty(PenaltyConfig{Base: time.Minute, Max: time.Hour, Multiplier: 2}))
+	login := newLimiter("login")
+	ctx := t.Context()
+
+	for _, limiter := range []*RateLimiter{api, login} {
+		for _, key := range []string{"alice", "bob", "bob"} {
+			_, err := limiter.Allow(ctx, AccessOptions{Key: key})
+			require.NoError(t, err)
+		}
+	}
+	keys, err := shared.Keys(ctx, "*")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"api:alice", "api:bob", "api:bob:p", "login:alice", "login:bob"}, keys)
+
+	require.NoError(t, api.FlushAll(ctx))
+	keys, err = shared.Keys(ctx, "*")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"login:alice", "login:bob"}, keys)
+
+	// Flushed keys start over, the other limiter's keys are still limited
+	allowed, err := api.Allow(ctx, AccessOptions{Key: "bob"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, err = login.Allow(ctx, AccessOptions{Key: "bob"})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestFlushAll_StrategyBackends(t *testing.T) {
+	shared, primary, secondary := memory.New(), memory.New(), memory.New()
+	defer primary.Close()
+	defer secondary.Close()
+	limiter, err := New(
+		WithBackend(shared),
+		WithPrimaryStrategy(perMinute(5), WithStrategyBackend(primary)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 0.001}, WithStrategyBackend(secondary)),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	ctx := t.Context()
+
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+	require.NoError(t, limiter.FlushAll(ctx))
+	for _, backend := range []*memory.Backend{shared, primary, secondary} {
+		keys, err := backend.Keys(ctx, "*")
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	}
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+}
+
+func TestFlushAll_NotSupported(t *testing.T) {
+	// Embedding the interface hides the Keys method of the memory backend
+	storage := struct{ backends.Backend }{memory.New()}
+	limiter, err := New(WithBackend(storage), WithPrimaryStrategy(perMinute(1)))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	require.ErrorIs(t, limiter.FlushAll(t.Context()), backends.ErrKeysNotSupported)
+}
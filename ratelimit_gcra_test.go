@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithGCRAStrategy_Validation(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		ok    bool
+	}{
+		{"valid", 10, 5, true},
+		{"burst of one", 0.5, 1, true},
+		{"zero rate", 0, 5, false},
+		{"negative rate", -1, 5, false},
+		{"zero burst", 10, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{}
+			err := WithGCRAStrategy(tt.rate, tt.burst)(cfg)
+			secErr := WithGCRASecondaryStrategy(tt.rate, tt.burst)(cfg)
+			if !tt.ok {
+				require.Error(t, err)
+				require.Error(t, secErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, secErr)
+			assert.Equal(t, &gcra.Config{Rate: tt.rate, Burst: tt.burst}, cfg.PrimaryConfig)
+			assert.Equal(t, &gcra.Config{Rate: tt.rate, Burst: tt.burst}, cfg.SecondaryConfig)
+		})
+	}
+
+	caps := (&gcra.Config{}).Capabilities()
+	assert.True(t, caps.Has(strategies.CapPrimary))
+	assert.True(t, caps.Has(strategies.CapSecondary))
+}
+
+func TestGCRA_SingleStrategyLimiter(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithGCRAStrategy(0.001, 3),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	var results strategies.Results
+	for i := range 3 {
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		require.True(t, allowed, "request %d should be allowed", i)
+	}
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed, "burst should be exhausted")
+	assert.Equal(t, 0, results.Default().Remaining)
+
+	// Other keys are unaffected
+	allowed, err = limiter.Allow(t.Context(), AccessOptions{Key: "other"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestGCRA_DualStrategyLimiter(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+		WithGCRASecondaryStrategy(0.001, 2),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	var results strategies.Results
+	for range 2 {
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	// GCRA smoother denies before the fixed window is exhausted
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.False(t, results.SecondaryDefault().Allowed)
+	assert.True(t, results.PrimaryDefault().Allowed)
+
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 3, results.PrimaryDefault().Remaining, "denied request must not consume primary quota")
+}
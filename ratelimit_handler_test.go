@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getStatus serves a GET request with the limiter handler
+func getStatus(t *testing.T, limiter *RateLimiter) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler(limiter).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/ratelimit", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+func TestHandler_ReflectsTraffic(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithBaseKey("api"),
+		WithPrimaryStrategy(perMinute(2)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	code, body := getStatus(t, limiter)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, body["healthy"])
+	assert.NotContains(t, body, "breaker")
+	assert.Equal(t, map[string]any{
+		"base_key": "api",
+		"primary": map[string]any{
+			"strategy": "fixed_window",
+			"quotas":   []any{map[string]any{"name": "default", "limit": 2.0, "window": "1m0s"}},
+		},
+		"secondary":         []any{map[string]any{"strategy": "token_bucket", "burst": 5.0, "rate": 1.0}},
+		"steady_state_rate": 2.0 / 60,
+	}, body["limits"])
+	assert.Equal(t, map[string]any{"allowed": 0.0, "denied": 0.0, "errors": 0.0, "cas_retries": 0.0}, body["stats"])
+
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+	_, body = getStatus(t, limiter)
+	assert.Equal(t, map[string]any{"allowed": 2.0, "denied": 1.0, "errors": 0.0, "cas_retries": 0.0}, body["stats"])
+}
+
+func TestHandler_BackendDown(t *testing.T) {
+	limiter, err := New(WithBackend(downBackend{}), WithPrimaryStrategy(perMinute(2)))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	code, body := getStatus(t, limiter)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, false, body["healthy"])
+	assert.Equal(t, errDown.Error(), body["error"])
+}
+
+func TestHandler_BreakerState(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		primary := &outageBackend{Backend: memory.New()}
+		limiter, err := New(
+			WithBackend(primary),
+			WithMemoryFailover(WithFailureThreshold(1), WithHealthCheckInterval(time.Second)),
+			WithPrimaryStrategy(perMinute(5)),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		code, body := getStatus(t, limiter)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]any{"state": "closed", "failures": 0.0}, body["breaker"])
+
+		// Requests served from memory make the limiter unhealthy
+		primary.down.Store(true)
+		assert.Equal(t, 1, allowN(t, limiter, 1))
+		code, body = getStatus(t, limiter)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, false, body["healthy"])
+		assert.Equal(t, map[string]any{"state": "open", "failures": 1.0}, body["breaker"])
+
+		primary.down.Store(false)
+		time.Sleep(time.Second)
+		synctest.Wait()
+		code, body = getStatus(t, limiter)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "closed", body["breaker"].(map[string]any)["state"])
+	})
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	rec := httptest.NewRecorder()
+	Handler(limiter).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/internal/ratelimit", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHook_Validation(t *testing.T) {
+	require.Error(t, WithHook(nil)(&Config{}), "expected error for nil hook")
+
+	cfg := &Config{}
+	require.NoError(t, WithHook(func(context.Context, Event) {})(cfg))
+	require.NoError(t, WithHook(func(context.Context, Event) {})(cfg))
+	assert.Len(t, cfg.hooks, 2)
+}
+
+func TestMetadata_ReachesHooksAndResults(t *testing.T) {
+	backend := memory.New()
+	var events []Event
+	limiter, err := New(
+		WithBaseKey("meta"),
+		WithBackend(backend),
+		WithPrimaryStrategy(perMinute(1)),
+		WithSecondaryStrategy(&leakybucket.Config{Burst: 5, Rate: 0.001}),
+		WithHook(func(_ context.Context, e Event) { events = append(events, e) }),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	metadata := map[string]any{"route": "/checkout", "method": "POST"}
+	var results strategies.Results
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Metadata: metadata, Result: &results})
+	require.NoError(t, err)
+	require.True(t, allowed)
+	for name, res := range results {
+		assert.Equal(t, metadata, res.Metadata, "result %s should echo metadata", name)
+	}
+
+	allowed, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Metadata: metadata, Result: &results})
+	require.NoError(t, err)
+	require.False(t, allowed)
+	assert.Equal(t, metadata, results.PrimaryDefault().Metadata)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, OperationAllow, events[0].Operation)
+	assert.True(t, events[0].Allowed)
+	assert.Equal(t, OperationPeek, events[1].Operation)
+	assert.False(t, events[1].Allowed)
+	for _, e := range events {
+		assert.Equal(t, "user", e.Key)
+		assert.Equal(t, metadata, e.Metadata)
+		assert.NoError(t, e.Err)
+	}
+	assert.Equal(t, map[string]any{"route": "/checkout", "method": "POST"}, metadata, "metadata must not be modified")
+
+	// Metadata is never persisted to the backend
+	state, err := backend.Get(t.Context(), "meta:user:c")
+	require.NoError(t, err)
+	require.NotEmpty(t, state)
+	assert.NotContains(t, state, "checkout")
+
+	// Without metadata, results carry none
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "other", Result: &results})
+	require.NoError(t, err)
+	assert.Nil(t, results.PrimaryDefault().Metadata)
+}
+
+func TestMetadata_NoLeakBetweenConcurrentCalls(t *testing.T) {
+	var mu sync.Mutex
+	mismatches := 0
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(&tokenbucket.Config{Burst: 1000, Rate: 1}),
+		WithHook(func(_ context.Context, e Event) {
+			if e.Metadata["key"] != e.Key {
+				mu.Lock()
+				mismatches++
+				mu.Unlock()
+			}
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Go(func() {
+			key := fmt.Sprintf("user%d", g)
+			for range 50 {
+				var results strategies.Results
+				_, err := limiter.Allow(t.Context(), AccessOptions{
+					Key:      key,
+					Metadata: map[string]any{"key": key},
+					Result:   &results,
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, key, results.Default().Metadata["key"])
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Zero(t, mismatches, "hook events must carry their own call's metadata")
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessOptions_IdleTTL(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		backend := memory.New()
+		// Refills in 10 seconds, kept 50 seconds by default
+		limiter, err := New(
+			WithBackend(backend),
+			WithBaseKey("api"),
+			WithPrimaryStrategy(&tokenbucket.Config{Burst: 10, Rate: 1}),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		allow := func(key string, idleTTL time.Duration) {
+			t.Helper()
+			_, err := limiter.Allow(t.Context(), AccessOptions{Key: key, IdleTTL: idleTTL})
+			require.NoError(t, err)
+		}
+		stored := func(key string) bool {
+			t.Helper()
+			keys, err := backend.Keys(t.Context(), "api:"+key)
+			require.NoError(t, err)
+			return len(keys) == 1
+		}
+
+		allow("idle", 15*time.Second)
+		allow("active", 15*time.Second)
+		allow("default", 0)
+		allow("short", time.Second)
+
+		time.Sleep(5 * time.Second)
+		assert.True(t, stored("short"), "state is kept until the bucket refills")
+
+		for range 4 {
+			allow("active", 15*time.Second)
+			time.Sleep(5 * time.Second)
+		}
+		assert.False(t, stored("idle"), "idle key expires after its idle TTL")
+		assert.True(t, stored("active"), "active key persists")
+		assert.True(t, stored("default"), "default expiration is unchanged")
+		assert.False(t, stored("short"))
+	})
+}
+
+func TestAccessOptions_IdleTTL_NeverBeforeFresh(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		backend := memory.New()
+		limiter, err := New(
+			WithBackend(backend),
+			WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", 1, 10*time.Second).Build()),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		options := AccessOptions{Key: "user", IdleTTL: time.Second}
+		allowed, err := limiter.Allow(t.Context(), options)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		// The window still counts the request after the idle TTL
+		time.Sleep(5 * time.Second)
+		allowed, err = limiter.Allow(t.Context(), options)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		time.Sleep(6 * time.Second)
+		keys, err := backend.Keys(t.Context(), "*")
+		require.NoError(t, err)
+		assert.Empty(t, keys, "state expires with its window")
+	})
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// independentQuotas returns 5 requests per minute and 3 per hour, stored under their own keys
+func independentQuotas() *fixedwindow.Config {
+	return fixedwindow.NewConfig().
+		AddQuota("minute", 5, time.Minute).
+		AddQuota("hour", 3, time.Hour).
+		SetIndependentQuotas(true).
+		Build()
+}
+
+func TestIndependentQuotas_DenialAggregates(t *testing.T) {
+	limiter := newKeyLimiter(t, WithPrimaryStrategy(independentQuotas()))
+
+	assert.Equal(t, 3, allowN(t, limiter, 5))
+
+	var results strategies.Results
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.True(t, results["minute"].Allowed)
+	assert.False(t, results["hour"].Allowed)
+
+	// Denied requests consume no quota
+	assert.Equal(t, 2, remaining(t, limiter, "user")["minute"].Remaining)
+}
+
+func TestIndependentQuotas_ScanAndExport(t *testing.T) {
+	limiter := newKeyLimiter(t, WithPrimaryStrategy(independentQuotas()))
+	require.NoError(t, limiter.Warmup(t.Context(), []string{"a"}))
+	consume(t, limiter, "b", 2)
+
+	assert.Equal(t, map[string]int{"a": 3, "b": 1}, scanRemaining(t, limiter, "hour"))
+
+	data, err := limiter.ExportKey(t.Context(), AccessOptions{Key: "a"})
+	require.NoError(t, err)
+	var export keyExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	keys := make([]string, len(export.States))
+	for i, state := range export.States {
+		keys[i] = state.Key
+	}
+	assert.ElementsMatch(t, []string{":q:minute", ":q:hour"}, keys, "Warmup should create the key of every quota")
+
+	require.NoError(t, limiter.ImportKey(t.Context(), data, AccessOptions{Key: "c"}))
+	assert.Equal(t, 3, remaining(t, limiter, "c")["hour"].Remaining)
+}
+
+func TestIndependentQuotas_SharedSecondary(t *testing.T) {
+	_, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(independentQuotas()),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+	)
+	require.ErrorContains(t, err, "independent quotas")
+
+	// Tiers on their own backends keep their own keys
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(independentQuotas(), WithStrategyBackend(memory.New())),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	assert.Equal(t, 3, allowN(t, limiter, 5))
+}
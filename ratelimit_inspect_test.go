@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect_SingleStrategy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(fixedwindow.NewConfig().
+				AddQuota("minute", 2, time.Minute).
+				AddQuota("hour", 10, time.Hour).
+				Build()),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		ctx := t.Context()
+
+		// A fresh key has a tier without state
+		state, err := limiter.Inspect(ctx, AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.Equal(t, &KeyState{
+			Key:   "user",
+			Tiers: []strategies.State{{Strategy: strategies.StrategyFixedWindow}},
+		}, state)
+
+		start := time.Now()
+		assert.Equal(t, 2, allowN(t, limiter, 3))
+
+		// Denied requests are not counted, and the windows keep their start
+		time.Sleep(30 * time.Second)
+		state, err = limiter.Inspect(ctx, AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		require.Len(t, state.Tiers, 1)
+		assert.Empty(t, state.Tiers[0].Tier)
+		assert.ElementsMatch(t, []fixedwindow.FixedWindow{
+			{Name: "minute", Count: 2, Start: start},
+			{Name: "hour", Count: 2, Start: start},
+		}, state.Tiers[0].Value)
+	})
+}
+
+func TestInspect_DualStrategy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(5)),
+			WithSecondaryStrategy(&tokenbucket.Config{Burst: 4, Rate: 1}),
+			WithPenalty(PenaltyConfig{Base: time.Minute, Max: time.Hour, Multiplier: 2}),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		assert.Equal(t, 4, allowN(t, limiter, 5))
+		state, err := limiter.Inspect(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+
+		require.Len(t, state.Tiers, 2)
+		assert.Equal(t, "primary_", state.Tiers[0].Tier)
+		assert.Equal(t, []fixedwindow.FixedWindow{{Name: "default", Count: 4, Start: time.Now()}}, state.Tiers[0].Value)
+		assert.Equal(t, "secondary_", state.Tiers[1].Tier)
+		assert.Equal(t, strategies.StrategyTokenBucket, state.Tiers[1].Strategy)
+		assert.Equal(t, tokenbucket.TokenBucket{Tokens: 0, LastRefill: time.Now()}, state.Tiers[1].Value)
+
+		// The denial started a lockout
+		assert.Equal(t, 1, state.PenaltyStrikes)
+		assert.Equal(t, time.Now().Add(time.Minute), state.PenaltyUntil)
+	})
+}
+
+func TestInspect_AnyStrategy(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithAnyStrategy(perMinute(1), &gcra.Config{Rate: 1, Burst: 5}),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		assert.Equal(t, 3, allowN(t, limiter, 3))
+		state, err := limiter.Inspect(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+
+		require.Len(t, state.Tiers, 2)
+		assert.Equal(t, "tier1_", state.Tiers[0].Tier)
+		assert.Equal(t, []fixedwindow.FixedWindow{{Name: "default", Count: 1, Start: time.Now()}}, state.Tiers[0].Value)
+		assert.Equal(t, "tier2_", state.Tiers[1].Tier)
+		assert.Equal(t, gcra.GCRA{TAT: time.Now().Add(2 * time.Second)}, state.Tiers[1].Value)
+	})
+}
+
+func TestInspect_NotSupported(t *testing.T) {
+	registerMockStrategy(t, strategies.StrategyTokenBucket, &mockStrategyOne{})
+	limiter, err := New(
+		WithBackend(&mockBackendOne{}),
+		WithPrimaryStrategy(mockStrategyConfig{id: strategies.StrategyTokenBucket, caps: strategies.CapPrimary}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	_, err = limiter.Inspect(t.Context(), AccessOptions{Key: "user"})
+	require.ErrorIs(t, err, strategies.ErrInspectNotSupported)
+}
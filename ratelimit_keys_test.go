@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKeyLimiter(t *testing.T, opts ...Option) *RateLimiter {
+	t.Helper()
+	limiter, err := New(append([]Option{WithBackend(memory.New()), WithPrimaryStrategy(perMinute(2))}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	return limiter
+}
+
+func TestContextWithKey_UsedWhenNoExplicitKey(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	ctx := ContextWithKey(t.Context(), "10.0.0.1")
+
+	for range 2 {
+		allowed, err := limiter.Allow(ctx, AccessOptions{})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, err := limiter.Allow(ctx, AccessOptions{})
+	require.NoError(t, err)
+	assert.False(t, allowed, "context key should be exhausted")
+
+	// The same quota is visible through an explicit key
+	allowed, err = limiter.Peek(t.Context(), AccessOptions{Key: "10.0.0.1"})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// Other keys, including the default key, are untouched
+	allowed, err = limiter.Allow(t.Context(), AccessOptions{})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, err = limiter.Allow(ContextWithKey(t.Context(), "10.0.0.2"), AccessOptions{})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	require.NoError(t, limiter.Reset(ctx, AccessOptions{}))
+	allowed, err = limiter.Allow(ctx, AccessOptions{})
+	require.NoError(t, err)
+	assert.True(t, allowed, "reset should clear the context key")
+}
+
+func TestContextWithKey_ExplicitKeyOverrides(t *testing.T) {
+	var keys []string
+	limiter := newKeyLimiter(t, WithHook(func(_ context.Context, e Event) { keys = append(keys, e.Key) }))
+	ctx := ContextWithKey(t.Context(), "from-context")
+
+	for range 2 {
+		_, err := limiter.Allow(ctx, AccessOptions{Key: "explicit"})
+		require.NoError(t, err)
+	}
+	allowed, err := limiter.Allow(ctx, AccessOptions{})
+	require.NoError(t, err)
+	assert.True(t, allowed, "context key quota should be unused")
+
+	assert.Equal(t, []string{"explicit", "explicit", "from-context"}, keys)
+}
+
+func TestContextWithKey_NoKeyAnywhere(t *testing.T) {
+	var keys []string
+	limiter := newKeyLimiter(t, WithHook(func(_ context.Context, e Event) { keys = append(keys, e.Key) }))
+
+	// Without any key, requests share the "default" dynamic key
+	_, err := limiter.Allow(t.Context(), AccessOptions{})
+	require.NoError(t, err)
+	_, err = limiter.Allow(ContextWithKey(t.Context(), ""), AccessOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "default"}, keys)
+
+	_, ok := KeyFromContext(t.Context())
+	assert.False(t, ok)
+}
+
+func TestContextWithKey_Validation(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	ctx := ContextWithKey(t.Context(), "bad key!")
+
+	_, err := limiter.Allow(ctx, AccessOptions{})
+	assert.Error(t, err, "context keys are validated like explicit keys")
+
+	_, err = limiter.Allow(ctx, AccessOptions{SkipValidation: true})
+	assert.NoError(t, err)
+}
+
+func TestWithKeyFunc(t *testing.T) {
+	var keys []string
+	limiter := newKeyLimiter(t,
+		WithKeyFunc(func(options AccessOptions) string {
+			ip, _ := options.Metadata["ip"].(string)
+			return ip
+		}),
+		WithHook(func(_ context.Context, e Event) { keys = append(keys, e.Key) }),
+	)
+	ctx := ContextWithKey(t.Context(), "from-context")
+
+	for _, options := range []AccessOptions{
+		{Metadata: map[string]any{"ip": "10.0.0.1"}},
+		{Key: "explicit", Metadata: map[string]any{"ip": "10.0.0.1"}},
+		{}, // key function returns "", falls back to the context key
+	} {
+		_, err := limiter.Allow(ctx, options)
+		require.NoError(t, err)
+	}
+	_, err := limiter.Allow(t.Context(), AccessOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"10.0.0.1", "explicit", "from-context", "default"}, keys)
+
+	_, err = limiter.Allow(t.Context(), AccessOptions{Metadata: map[string]any{"ip": "bad key!"}})
+	assert.Error(t, err, "derived keys are validated")
+}
+
+func TestWithKeyFunc_Nil(t *testing.T) {
+	_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(2)), WithKeyFunc(nil))
+	assert.Error(t, err)
+}
+
+func TestKeyOf_Addr(t *testing.T) {
+	for remoteAddr, want := range map[string]string{
+		"10.0.0.1:8080":           "ip:10.0.0.1",
+		"[2001:db8::1]:443":       "ip:2001_db8__1",
+		"[::ffff:10.0.0.1]:80":    "ip:10.0.0.1",
+		"[fe80::1%eth0]:80":       "ip:fe80__1",
+		"[2001:DB8:0:0::1]:65535": "ip:2001_db8__1",
+	} {
+		addrPort, err := netip.ParseAddrPort(remoteAddr)
+		require.NoError(t, err)
+		key := KeyOf("ip", addrPort)
+		assert.Equal(t, want, key, remoteAddr)
+		require.NoError(t, validateKey(key, "dynamic key"))
+
+		if strings.HasPrefix(remoteAddr, "[") {
+			// The naive split keeps only "[2001" of an IPv6 address
+			assert.NotEqual(t, "ip:"+strings.Split(remoteAddr, ":")[0], key, remoteAddr)
+		}
+	}
+
+	assert.Equal(t, "ip:10.0.0.1", KeyOf("ip", netip.MustParseAddr("10.0.0.1")))
+	assert.Equal(t, "ip:10.0.0.1", KeyOf("ip", net.ParseIP("10.0.0.1")), "net.IP holds IPv4 as IPv4-mapped")
+	assert.Equal(t, "2001_db8__1", KeyOf("", net.ParseIP("2001:db8::1")))
+}
+
+func TestKeyOf_Numbers(t *testing.T) {
+	assert.Equal(t, "user:42", KeyOf("user", 42))
+	assert.Equal(t, "user:-7", KeyOf("user", int64(-7)))
+	assert.Equal(t, "user:9223372036854775807", KeyOf("user", int64(math.MaxInt64)))
+	assert.Equal(t, "user:18446744073709551615", KeyOf("user", uint64(math.MaxUint64)))
+	assert.Equal(t, "shard:3", KeyOf("shard", uint32(3)))
+}
+
+func TestKeyOf_Other(t *testing.T) {
+	id := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	assert.Equal(t, "req:123e4567-e89b-12d3-a456-426614174000", KeyOf("req", id))
+
+	assert.Equal(t, "tenant:a_b_c", KeyOf("tenant", "a:b c"), "strings are sanitized")
+	assert.Equal(t, "plan:1", KeyOf("plan", stringerKey{}))
+
+	long := KeyOf("user", strings.Repeat("x", 100))
+	assert.Len(t, long, 64)
+	require.NoError(t, validateKey(long, "dynamic key"))
+}
+
+// stringerKey is a key type implementing fmt.Stringer
+type stringerKey struct{}
+
+func (stringerKey) String() string { return "1" }
+
+func TestKeyOf_Allow(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	v4, err := netip.ParseAddrPort("10.0.0.1:1000")
+	require.NoError(t, err)
+	mapped, err := netip.ParseAddrPort("[::ffff:10.0.0.1]:2000")
+	require.NoError(t, err)
+
+	for _, addrPort := range []netip.AddrPort{v4, mapped} {
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: KeyOf("ip", addrPort)})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: KeyOf("ip", v4)})
+	require.NoError(t, err)
+	assert.False(t, allowed, "one client on any port and address form shares a key")
+}
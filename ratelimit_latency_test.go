@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowBackend is a memory backend whose reads and compare-and-sets take delay
+type slowBackend struct {
+	*memory.Backend
+	delay time.Duration
+}
+
+func (s *slowBackend) Get(ctx context.Context, key string) (string, error) {
+	time.Sleep(s.delay)
+	return s.Backend.Get(ctx, key)
+}
+
+func (s *slowBackend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	time.Sleep(s.delay)
+	return s.Backend.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+}
+
+func TestDecision_BackendLatency(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(&slowBackend{Backend: memory.New(), delay: 30 * time.Millisecond}),
+			WithPrimaryStrategy(perMinute(5)),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		ctx := t.Context()
+
+		// A Get and a CheckAndSet per decision
+		decision, err := limiter.Check(ctx, AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, decision.BackendLatency, 60*time.Millisecond)
+
+		// Each call reports only its own latency
+		decision, err = limiter.Check(ctx, AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.Less(t, decision.BackendLatency, 120*time.Millisecond)
+
+		_, decision, err = limiter.CanAllowN(ctx, AccessOptions{Key: "user"}, 1)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, decision.BackendLatency, 30*time.Millisecond)
+	})
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/internal/strategies/composite"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStorageKeyLayout pins the storage keys of every limiter layout. A
+// failure here means existing state would be orphaned on upgrade, see
+// composite.CompositeKeyVersion.
+func TestStorageKeyLayout(t *testing.T) {
+	require.Equal(t, 1, composite.CompositeKeyVersion)
+
+	smoother := &tokenbucket.Config{Burst: 5, Rate: 1}
+	tests := []struct {
+		name string
+		opts func(primary, secondary *memory.Backend) []Option
+		keys []string
+	}{
+		{
+			name: "single",
+			opts: func(_, _ *memory.Backend) []Option {
+				return []Option{WithPrimaryStrategy(perMinute(1))}
+			},
+			keys: []string{"api:user"},
+		},
+		{
+			name: "composite",
+			opts: func(_, _ *memory.Backend) []Option {
+				return []Option{WithPrimaryStrategy(perMinute(1)), WithSecondaryStrategy(smoother)}
+			},
+			keys: []string{"api:user:c"},
+		},
+		{
+			name: "any",
+			opts: func(_, _ *memory.Backend) []Option {
+				return []Option{WithAnyStrategy(perMinute(1), perMinute(1))}
+			},
+			keys: []string{"api:user:a1", "api:user:a2"},
+		},
+		{
+			name: "split",
+			opts: func(primary, secondary *memory.Backend) []Option {
+				return []Option{
+					WithPrimaryStrategy(perMinute(1), WithStrategyBackend(primary)),
+					WithSecondaryStrategy(smoother, WithStrategyBackend(secondary)),
+				}
+			},
+			keys: []string{"api:user:c1", "api:user:c2"},
+		},
+		{
+			name: "penalty",
+			opts: func(_, _ *memory.Backend) []Option {
+				return []Option{
+					WithPrimaryStrategy(perMinute(1)),
+					WithPenalty(PenaltyConfig{Base: time.Minute, Max: time.Hour, Multiplier: 2}),
+				}
+			},
+			keys: []string{"api:user", "api:user:p"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared, primary, secondary := memory.New(), memory.New(), memory.New()
+			defer primary.Close()
+			defer secondary.Close()
+			limiter, err := New(append([]Option{WithBackend(shared), WithBaseKey("api")}, tt.opts(primary, secondary)...)...)
+			require.NoError(t, err)
+			defer limiter.Close()
+
+			// Exhaust every tier so that the any tiers and the penalty are written
+			for range 3 {
+				_, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+				require.NoError(t, err)
+			}
+
+			var keys []string
+			for _, backend := range []*memory.Backend{shared, primary, secondary} {
+				found, err := backend.Keys(t.Context(), "*")
+				require.NoError(t, err)
+				keys = append(keys, found...)
+			}
+			slices.Sort(keys)
+			assert.Equal(t, tt.keys, keys)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/approx"
+	"github.com/ajiwo/ratelimit/strategies/concurrency"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/slidingwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResults_Limit(t *testing.T) {
+	tests := map[string]struct {
+		options []Option
+		want    map[string]int
+	}{
+		"fixed window": {
+			options: []Option{WithPrimaryStrategy(fixedwindow.NewConfig().
+				AddQuota("minute", 10, time.Minute).
+				AddQuota("hour", 100, time.Hour).
+				Build())},
+			want: map[string]int{"minute": 10, "hour": 100},
+		},
+		"token bucket": {
+			options: []Option{WithPrimaryStrategy(&tokenbucket.Config{Burst: 7, Rate: 1})},
+			want:    map[string]int{"default": 7},
+		},
+		"leaky bucket": {
+			options: []Option{WithPrimaryStrategy(&leakybucket.Config{Burst: 6, Rate: 1})},
+			want:    map[string]int{"default": 6},
+		},
+		"gcra": {
+			options: []Option{WithGCRAStrategy(1, 5)},
+			want:    map[string]int{"default": 5},
+		},
+		"concurrency": {
+			options: []Option{WithPrimaryStrategy(&concurrency.Config{Max: 4, LeaseTTL: time.Minute})},
+			want:    map[string]int{"default": 4},
+		},
+		"approx": {
+			options: []Option{WithPrimaryStrategy(&approx.Config{Limit: 1000, Window: time.Minute})},
+			want:    map[string]int{"default": 1000},
+		},
+		"sliding window": {
+			options: []Option{WithPrimaryStrategy(&slidingwindow.Config{Limit: 8, Window: time.Minute})},
+			want:    map[string]int{"default": 8},
+		},
+		"dual": {
+			options: []Option{
+				WithPrimaryStrategy(perMinute(20)),
+				WithSecondaryStrategy(&tokenbucket.Config{Burst: 3, Rate: 1}),
+			},
+			want: map[string]int{"primary_default": 20, "secondary_default": 3},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			limiter, err := New(append([]Option{WithBackend(memory.New())}, tt.options...)...)
+			require.NoError(t, err)
+			defer limiter.Close()
+
+			var allowResults strategies.Results
+			_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &allowResults})
+			require.NoError(t, err)
+			peekResults := remaining(t, limiter, "user")
+
+			for _, results := range []strategies.Results{allowResults, peekResults} {
+				require.Len(t, results, len(tt.want))
+				for tier, limit := range tt.want {
+					assert.Equal(t, limit, results[tier].Limit, tier)
+				}
+			}
+		})
+	}
+}
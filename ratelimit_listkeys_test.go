@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListKeys(t *testing.T) {
+	backend := memory.New()
+	limiter, err := New(
+		WithBaseKey("api"),
+		WithBackend(backend),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	for _, key := range []string{"user1", "user2", "admin"} {
+		_, err := limiter.Allow(t.Context(), AccessOptions{Key: key})
+		require.NoError(t, err)
+	}
+	// Keys of other limiters sharing the backend are not listed
+	require.NoError(t, backend.Set(t.Context(), "web:user1", "x", time.Minute))
+
+	keys, err := limiter.ListKeys(t.Context(), "")
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+	for _, key := range keys {
+		assert.Contains(t, key, "api:")
+	}
+
+	keys, err = limiter.ListKeys(t.Context(), "user*")
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestListKeys_Unsupported(t *testing.T) {
+	limiter, err := New(
+		WithBackend(downBackend{}),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	_, err = limiter.ListKeys(t.Context(), "*")
+	require.ErrorIs(t, err, backends.ErrKeysNotSupported)
+}
+
+func TestListKeys_ThroughWrappers(t *testing.T) {
+	inner := memory.New()
+	limiter, err := New(
+		WithBackend(backends.WithLocalCache(inner, time.Second)),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+
+	keys, err := limiter.ListKeys(t.Context(), "user*")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
+
+func TestListKeys_MemoryFailover(t *testing.T) {
+	limiter, err := New(
+		WithBackend(downBackend{}),
+		WithMemoryFailover(WithFailureThreshold(1)),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	// Primary is not a Lister
+	_, err = limiter.ListKeys(t.Context(), "*")
+	require.ErrorIs(t, err, backends.ErrKeysNotSupported)
+
+	// After tripping, keys come from the memory fallback
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+
+	keys, err := limiter.ListKeys(t.Context(), "*")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
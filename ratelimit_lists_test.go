@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// onKeys returns a ListFunc matching the given dynamic keys
+func onKeys(keys ...string) ListFunc {
+	return func(options AccessOptions) bool {
+		for _, key := range keys {
+			if options.Key == key {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestWithAllowList_NeverConsumesQuota(t *testing.T) {
+	limiter := newKeyLimiter(t, WithAllowList(onKeys("internal")))
+	ctx := t.Context()
+
+	for range 5 {
+		var results strategies.Results
+		allowed, err := limiter.Allow(ctx, AccessOptions{Key: "internal", Result: &results})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, strategies.Results{AllowListResultKey: {Allowed: true}}, results)
+	}
+
+	// Nothing was stored for the allow-listed key
+	keys, err := limiter.ListKeys(ctx, "*")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// Other keys are limited as usual
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+}
+
+func TestWithDenyList_BlocksWithQuotaRemaining(t *testing.T) {
+	limiter, err := New(
+		WithBackend(downBackend{}),
+		WithPrimaryStrategy(perMinute(100)),
+		WithDenyList(onKeys("banned")),
+	)
+	require.NoError(t, err)
+	ctx := t.Context()
+
+	// The backend is down, so any strategy run would fail
+	decision, err := limiter.Check(ctx, AccessOptions{Key: "banned"})
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, DenyListResultKey, decision.LimitingTier)
+	assert.Zero(t, decision.RetryAfter)
+
+	allowed, err := limiter.Peek(ctx, AccessOptions{Key: "banned"})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, err = limiter.Allow(ctx, AccessOptions{Key: "user"})
+	assert.ErrorIs(t, err, errDown)
+}
+
+func TestWithDenyList_TakesPrecedenceOverAllowList(t *testing.T) {
+	limiter := newKeyLimiter(t,
+		WithAllowList(onKeys("internal", "both")),
+		WithDenyList(onKeys("both")),
+	)
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "both"})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = limiter.Allow(t.Context(), AccessOptions{Key: "internal"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestWithLists_RejectNil(t *testing.T) {
+	_, err := New(WithAllowList(nil))
+	assert.Error(t, err)
+	_, err = New(WithDenyList(nil))
+	assert.Error(t, err)
+}
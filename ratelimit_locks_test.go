@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cleanupCountingBackend is a memory backend counting lock cleanups
+type cleanupCountingBackend struct {
+	*memory.Backend
+	cleanups atomic.Int32
+	removed  atomic.Int32
+}
+
+func (c *cleanupCountingBackend) CleanupLocks(maxAge time.Duration) int {
+	c.cleanups.Add(1)
+	n := c.Backend.CleanupLocks(maxAge)
+	c.removed.Add(int32(n))
+	return n
+}
+
+func TestWithLockCleanupInterval(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		backend := &cleanupCountingBackend{Backend: memory.NewWithCleanup(0)}
+		limiter, err := New(
+			WithBackend(backend),
+			WithPrimaryStrategy(perMinute(1)),
+			WithLockCleanupInterval(time.Minute),
+		)
+		require.NoError(t, err)
+
+		// Peeked keys hold a lock but no value
+		for _, key := range []string{"a", "b", "c"} {
+			_, err := limiter.Peek(t.Context(), AccessOptions{Key: key})
+			require.NoError(t, err)
+		}
+		time.Sleep(time.Minute)
+		synctest.Wait()
+		assert.Equal(t, int32(1), backend.cleanups.Load())
+		assert.Equal(t, int32(3), backend.removed.Load())
+
+		time.Sleep(time.Minute)
+		synctest.Wait()
+		assert.Equal(t, int32(2), backend.cleanups.Load())
+
+		// Close stops the cleanup
+		require.NoError(t, limiter.Close())
+		time.Sleep(time.Hour)
+		synctest.Wait()
+		assert.Equal(t, int32(2), backend.cleanups.Load())
+	})
+}
+
+func TestWithLockCleanupInterval_Invalid(t *testing.T) {
+	_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)), WithLockCleanupInterval(0))
+	require.ErrorContains(t, err, "lock cleanup interval must be positive")
+}
@@ -0,0 +1,189 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logEntry is a message captured by captureLogger
+type logEntry struct {
+	level slog.Level
+	msg   string
+}
+
+// captureLogger records every message with its level
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (c *captureLogger) log(level slog.Level, msg string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, logEntry{level: level, msg: msg})
+}
+
+func (c *captureLogger) Debug(_ context.Context, msg string, _ ...any) { c.log(slog.LevelDebug, msg) }
+func (c *captureLogger) Info(_ context.Context, msg string, _ ...any)  { c.log(slog.LevelInfo, msg) }
+func (c *captureLogger) Warn(_ context.Context, msg string, _ ...any)  { c.log(slog.LevelWarn, msg) }
+func (c *captureLogger) Error(_ context.Context, msg string, _ ...any) { c.log(slog.LevelError, msg) }
+
+// at returns the messages logged at level
+func (c *captureLogger) at(level slog.Level) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var msgs []string
+	for _, entry := range c.entries {
+		if entry.level == level {
+			msgs = append(msgs, entry.msg)
+		}
+	}
+	return msgs
+}
+
+// conflictBackend loses every CheckAndSet, as if another writer always won
+type conflictBackend struct {
+	*memory.Backend
+}
+
+func (conflictBackend) CheckAndSet(context.Context, string, string, string, time.Duration) (bool, error) {
+	return false, nil
+}
+
+func TestWithLogger_Validation(t *testing.T) {
+	require.Error(t, WithLogger(nil)(&Config{}))
+}
+
+func TestWithLogger_CASExhaustion(t *testing.T) {
+	logger := &captureLogger{}
+	limiter, err := New(
+		WithBackend(conflictBackend{Backend: memory.New()}),
+		WithPrimaryStrategy(perMinute(5)),
+		WithMaxRetries(3),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.Error(t, err)
+	assert.Equal(t, []string{"ratelimit: state update retries exhausted"}, logger.at(slog.LevelWarn))
+	assert.Len(t, logger.at(slog.LevelDebug), 3)
+	assert.Empty(t, logger.at(slog.LevelError))
+}
+
+func TestWithLogger_BackendError(t *testing.T) {
+	logger := &captureLogger{}
+	limiter, err := New(WithBackend(downBackend{}), WithPrimaryStrategy(perMinute(5)), WithLogger(logger))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.ErrorIs(t, err, errDown)
+	assert.Equal(t, []string{"ratelimit: allow failed"}, logger.at(slog.LevelError))
+
+	// Failing open is logged as a warning instead
+	limiter, err = New(WithBackend(downBackend{}), WithPrimaryStrategy(perMinute(5)), WithFailureMode(FailOpen), WithLogger(logger))
+	require.NoError(t, err)
+	defer limiter.Close()
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, []string{"ratelimit: backend unavailable, failing open"}, logger.at(slog.LevelWarn))
+}
+
+func TestWithLogger_HookPanic(t *testing.T) {
+	logger := &captureLogger{}
+	var called bool
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+		WithHook(func(context.Context, Event) { panic("boom") }),
+		WithHook(func(context.Context, Event) { called = true }),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.True(t, called, "hooks after a panicking one still run")
+	assert.Equal(t, []string{"ratelimit: hook panicked"}, logger.at(slog.LevelError))
+}
+
+func TestWithLogger_BreakerTransitions(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		logger := &captureLogger{}
+		primary := &outageBackend{Backend: memory.New()}
+		// The failover is configured before the logger, which still sees its transitions
+		limiter, err := New(
+			WithBackend(primary),
+			WithMemoryFailover(WithFailureThreshold(1), WithHealthCheckInterval(time.Second)),
+			WithPrimaryStrategy(perMinute(5)),
+			WithLogger(logger),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		primary.down.Store(true)
+		allowN(t, limiter, 1)
+		primary.down.Store(false)
+		time.Sleep(time.Second)
+		synctest.Wait()
+
+		assert.Equal(t, []string{"ratelimit: circuit breaker state changed"}, logger.at(slog.LevelWarn))
+		assert.Equal(t, []string{"ratelimit: circuit breaker state changed"}, logger.at(slog.LevelInfo))
+	})
+}
+
+func TestWithLogger_StrategyAndBackendWarnings(t *testing.T) {
+	logger := &captureLogger{}
+	mem := memory.New()
+	limiter, err := New(
+		WithBackend(backends.NewDualWrite(mem, downBackend{})),
+		WithPrimaryStrategy(perMinute(5)),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	allowN(t, limiter, 1)
+	assert.Equal(t, []string{"ratelimit: dual write mirror set failed"}, logger.at(slog.LevelWarn))
+
+	// A count far beyond the limit is reset by the strategy
+	keys, err := limiter.ListKeys(t.Context(), "*")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	corrupted := fmt.Sprintf("23|1|default|999|%d", time.Now().UnixNano())
+	require.NoError(t, mem.Set(t.Context(), keys[0], corrupted, time.Minute))
+
+	allowN(t, limiter, 1)
+	assert.Contains(t, logger.at(slog.LevelWarn), "ratelimit: resetting corrupted fixed window state")
+}
+
+func TestNewSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	logger.Debug(t.Context(), "d", "key", "a")
+	logger.Info(t.Context(), "i")
+	logger.Warn(t.Context(), "w")
+	logger.Error(t.Context(), "e")
+
+	out := buf.String()
+	for _, want := range []string{"level=DEBUG msg=d key=a", "level=INFO msg=i", "level=WARN msg=w", "level=ERROR msg=e"} {
+		assert.Contains(t, out, want)
+	}
+	assert.NotNil(t, NewSlogLogger(nil))
+}
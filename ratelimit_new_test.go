@@ -6,16 +6,27 @@ import (
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// builtinFactories restores real strategies after tests that replace them with mocks
+var builtinFactories = map[strategies.ID]strategies.StrategyFactory{
+	strategies.StrategyTokenBucket: func(b backends.Backend) strategies.Strategy { return tokenbucket.New(b) },
+	strategies.StrategyGCRA:        func(b backends.Backend) strategies.Strategy { return gcra.New(b) },
+}
+
 // factory that returns provided strategy instance
 func registerMockStrategy(t *testing.T, id strategies.ID, s strategies.Strategy) {
 	t.Helper()
 	strategies.Register(id, func(_ backends.Backend) strategies.Strategy { // backend is not used by mocks
 		return s
 	})
+	if factory, ok := builtinFactories[id]; ok {
+		t.Cleanup(func() { strategies.Register(id, factory) })
+	}
 }
 
 func TestNew_And_Composites(t *testing.T) {
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hitLimit sends requests until the key is denied and returns the lockout duration
+func hitLimit(t *testing.T, limiter *RateLimiter) time.Duration {
+	t.Helper()
+	for range 10 {
+		var results strategies.Results
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		if !allowed {
+			require.Contains(t, results, PenaltyResultKey)
+			return time.Until(results[PenaltyResultKey].Reset)
+		}
+	}
+	t.Fatal("never denied")
+	return 0
+}
+
+func TestWithPenalty_Escalates(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", 1, time.Second).Build()),
+			WithPenalty(PenaltyConfig{Base: 10 * time.Second, Max: 40 * time.Second, Multiplier: 2, Decay: time.Minute}),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		var lockouts []time.Duration
+		for range 4 {
+			lockout := hitLimit(t, limiter)
+			lockouts = append(lockouts, lockout)
+
+			// Hammering during the lockout is denied without extending it
+			time.Sleep(lockout / 2)
+			var results strategies.Results
+			allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+			require.NoError(t, err)
+			assert.False(t, allowed)
+			assert.Equal(t, lockout/2, time.Until(results[PenaltyResultKey].Reset))
+
+			time.Sleep(lockout / 2)
+		}
+		assert.Equal(t, []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 40 * time.Second}, lockouts)
+
+		// Quiet for the decay period, the key starts over
+		time.Sleep(time.Minute)
+		assert.Equal(t, 10*time.Second, hitLimit(t, limiter))
+	})
+}
+
+func TestWithPenalty_PeekAndReset(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(1)),
+			WithPenalty(PenaltyConfig{Base: 10 * time.Minute, Max: time.Hour, Multiplier: 2}),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		hitLimit(t, limiter)
+
+		// The fixed window resets before the lockout ends
+		time.Sleep(2 * time.Minute)
+		decision, err := limiter.Check(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, PenaltyResultKey, decision.LimitingTier)
+		assert.Equal(t, 8*time.Minute, decision.RetryAfter)
+
+		allowed, err := limiter.Peek(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		require.NoError(t, limiter.Reset(t.Context(), AccessOptions{Key: "user"}))
+		allowed, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+}
+
+func TestWithPenalty_Validation(t *testing.T) {
+	for name, penalty := range map[string]PenaltyConfig{
+		"zero base":          {Max: time.Minute, Multiplier: 2},
+		"max below base":     {Base: time.Minute, Max: time.Second, Multiplier: 2},
+		"multiplier below 1": {Base: time.Second, Max: time.Minute, Multiplier: 0.5},
+		"negative decay":     {Base: time.Second, Max: time.Minute, Multiplier: 2, Decay: -time.Second},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, WithPenalty(penalty)(&Config{}))
+		})
+	}
+
+	cfg := &Config{}
+	require.NoError(t, WithPenalty(PenaltyConfig{Base: time.Second, Max: time.Minute, Multiplier: 1})(cfg))
+	assert.Equal(t, time.Minute, cfg.penalty.Decay, "decay defaults to max")
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriority_LowShedFirst(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(10)),
+		WithPriorityThresholds(map[Priority]float64{PriorityLow: 0.8}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	ctx := t.Context()
+
+	allow := func(priority Priority) (bool, strategies.Results) {
+		t.Helper()
+		var results strategies.Results
+		allowed, err := limiter.Allow(ctx, AccessOptions{Key: "shared", Priority: priority, Result: &results})
+		require.NoError(t, err)
+		return allowed, results
+	}
+
+	// Under load, low priority requests use up to 80% of the shared quota
+	lowAllowed, highAllowed := 0, 0
+	for range 12 {
+		if ok, _ := allow(PriorityLow); ok {
+			lowAllowed++
+		}
+		if ok, _ := allow(PriorityHigh); ok {
+			highAllowed++
+		}
+	}
+	assert.Equal(t, 4, lowAllowed)
+	assert.Equal(t, 6, highAllowed)
+
+	// Once the quota is exhausted, the strategy denies every priority
+	allowed, results := allow(PriorityLow)
+	assert.False(t, allowed)
+	assert.NotContains(t, results, PriorityResultKey)
+}
+
+func TestPriority_ShedResults(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 10, Rate: 0.001}),
+		WithPriorityThresholds(map[Priority]float64{PriorityLow: 0.5, Priority(2): 0.2}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	ctx := t.Context()
+
+	// Priority 2 may use 1 of 5, low priority 2 of 5 of which 1 is already used
+	for _, priority := range []Priority{Priority(2), PriorityLow} {
+		n := 0
+		for range 3 {
+			if ok, _ := limiter.Allow(ctx, AccessOptions{Key: "k", Priority: priority}); ok {
+				n++
+			}
+		}
+		assert.Equal(t, 1, n, "priority %d", priority)
+	}
+
+	var results strategies.Results
+	allowed, err := limiter.Allow(ctx, AccessOptions{Key: "k", Priority: PriorityLow, Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.Contains(t, results, PriorityResultKey)
+	assert.False(t, results[PriorityResultKey].Allowed)
+	assert.Equal(t, results["primary_default"].Reset, results[PriorityResultKey].Reset)
+	assert.Equal(t, 3, results["primary_default"].Remaining, "shed requests consume nothing")
+
+	decision, err := limiter.Check(ctx, AccessOptions{Key: "k", Priority: PriorityLow})
+	require.NoError(t, err)
+	assert.Equal(t, PriorityResultKey, decision.LimitingTier)
+	assert.Positive(t, decision.RetryAfter)
+	assert.LessOrEqual(t, decision.RetryAfter, time.Minute)
+
+	// High priority still gets the rest
+	n := 0
+	for range 4 {
+		if ok, _ := limiter.Allow(ctx, AccessOptions{Key: "k"}); ok {
+			n++
+		}
+	}
+	assert.Equal(t, 3, n)
+}
+
+func TestPriority_Validation(t *testing.T) {
+	for _, threshold := range []float64{0, -0.5, 1.5} {
+		assert.Error(t, WithPriorityThresholds(map[Priority]float64{PriorityLow: threshold})(&Config{}))
+	}
+	assert.NoError(t, WithPriorityThresholds(map[Priority]float64{PriorityLow: 1})(&Config{}))
+}
+
+func TestContextWithPriority(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(10)),
+		WithPriorityThresholds(map[Priority]float64{PriorityLow: 0.5, Priority(2): 0.2}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	allowed := func(ctx context.Context, priority Priority) int {
+		t.Helper()
+		n := 0
+		for range 10 {
+			ok, err := limiter.Allow(ctx, AccessOptions{Key: "shared", Priority: priority})
+			require.NoError(t, err)
+			if ok {
+				n++
+			}
+		}
+		return n
+	}
+
+	// The context priority applies when the options leave the default
+	ctx := ContextWithPriority(t.Context(), Priority(2))
+	assert.Equal(t, 2, allowed(ctx, PriorityHigh))
+	// An explicit priority overrides the context
+	assert.Equal(t, 3, allowed(ctx, PriorityLow))
+
+	priority, ok := PriorityFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, Priority(2), priority)
+	_, ok = PriorityFromContext(t.Context())
+	assert.False(t, ok)
+}
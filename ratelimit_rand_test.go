@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/internal/strategies/composite"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/approx"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRandSource_DeterministicJitter(t *testing.T) {
+	jitter := func() []time.Duration {
+		limiter := newKeyLimiter(t,
+			WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+			WithRetryBackoff(8*time.Millisecond, 8*time.Millisecond, true),
+			WithRandSource(rand.NewPCG(1, 2)),
+		)
+		cc, ok := limiter.buildStrategyConfig("user").(*composite.Config)
+		require.True(t, ok)
+
+		// The composite and its tiers share the seeded generator
+		secondary := cc.Secondary.(*tokenbucket.Config).RetryBackoff
+		delays := make([]time.Duration, 10)
+		for i := range delays {
+			backoff := cc.RetryBackoff
+			if i%2 == 1 {
+				backoff = secondary
+			}
+			delays[i] = backoff.Delay(3, 0)
+		}
+		return delays
+	}
+
+	first := jitter()
+	assert.Equal(t, first, jitter(), "the same seed gives the same jitter")
+	assert.NotEqual(t, first[0], first[1])
+}
+
+func TestWithRandSource_DeterministicSampling(t *testing.T) {
+	allowedUntilDenied := func(seed uint64) int {
+		limiter := newKeyLimiter(t,
+			WithPrimaryStrategy(&approx.Config{Limit: 500, Window: time.Hour, Precision: 8}),
+			WithRandSource(rand.NewPCG(seed, seed)),
+		)
+		return allowN(t, limiter, 5000)
+	}
+
+	counts := map[int]bool{}
+	for seed := range uint64(5) {
+		count := allowedUntilDenied(seed)
+		assert.Equal(t, count, allowedUntilDenied(seed), "seed %d", seed)
+		counts[count] = true
+	}
+	assert.Greater(t, len(counts), 1, "different seeds sample differently")
+}
+
+func TestWithRandSource_Errors(t *testing.T) {
+	_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)), WithRandSource(nil))
+	require.ErrorContains(t, err, "random source cannot be nil")
+
+	// Per-config backoffs keep their delays, only the jitter source changes
+	limiter := newKeyLimiter(t,
+		WithPrimaryStrategy(&tokenbucket.Config{Burst: 1, Rate: 1, RetryBackoff: strategies.Backoff{Initial: time.Second}}),
+		WithRandSource(rand.NewPCG(1, 2)),
+	)
+	backoff := limiter.buildStrategyConfig("user").(*tokenbucket.Config).RetryBackoff
+	assert.Equal(t, time.Second, backoff.Initial)
+	assert.NotNil(t, backoff.Rand)
+}
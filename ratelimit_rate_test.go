@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/approx"
+	"github.com/ajiwo/ratelimit/strategies/concurrency"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/slidingwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		rate   string
+		limit  int
+		window time.Duration
+		err    string
+	}{
+		{rate: "100/min", limit: 100, window: time.Minute},
+		{rate: "5/s", limit: 5, window: time.Second},
+		{rate: "1000/day", limit: 1000, window: 24 * time.Hour},
+		{rate: "100/2h", limit: 100, window: 2 * time.Hour},
+		{rate: "10/90s", limit: 10, window: 90 * time.Second},
+		{rate: "3/seconds", limit: 3, window: time.Second},
+		{rate: "50/minutes", limit: 50, window: time.Minute},
+		{rate: "7/hr", limit: 7, window: time.Hour},
+		{rate: "20/2 weeks", limit: 20, window: 14 * 24 * time.Hour},
+		{rate: " 100 / min ", limit: 100, window: time.Minute},
+
+		{rate: "0/s", err: "limit must be positive"},
+		{rate: "-5/s", err: "limit must be positive"},
+		{rate: "100", err: "expected <limit>/<window>"},
+		{rate: "", err: "expected <limit>/<window>"},
+		{rate: "1.5/s", err: `limit "1.5" is not an integer`},
+		{rate: "many/s", err: `limit "many" is not an integer`},
+		{rate: "100/", err: "has no unit"},
+		{rate: "100/60", err: "has no unit"},
+		{rate: "100/0h", err: `window count "0" must be a positive integer`},
+		{rate: "100/-2h", err: `unknown window unit "-2h"`},
+		{rate: "100/M", err: `unknown window unit "M"`},
+		{rate: "100/month", err: `unknown window unit "month"`},
+		{rate: "100/ms", err: `unknown window unit "ms"`},
+		{rate: "100/1h30m", err: `unknown window unit "h30m"`},
+		{rate: "100/min/s", err: `unknown window unit "min/s"`},
+		{rate: "1/99999999999999999999d", err: "must be a positive integer"},
+		{rate: "1/999999999w", err: "window is too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			limit, window, err := ParseRate(tt.rate)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.limit, limit)
+			assert.Equal(t, tt.window, window)
+		})
+	}
+}
+
+func TestWithRate(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithRate("2/min"))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	var results strategies.Results
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, results["default"].Limit)
+	assert.Equal(t, 1, allowN(t, limiter, 2))
+
+	_, err = New(WithBackend(memory.New()), WithRate("2/fortnight"))
+	require.ErrorContains(t, err, "unknown window unit")
+}
+
+func TestSteadyStateRate(t *testing.T) {
+	tests := map[string]struct {
+		options []Option
+		want    float64
+	}{
+		"fixed window": {
+			options: []Option{WithPrimaryStrategy(fixedwindow.NewConfig().
+				AddQuota("minute", 120, time.Minute).
+				AddQuota("hour", 3600, time.Hour).
+				Build())},
+			want: 1,
+		},
+		"token bucket": {
+			options: []Option{WithPrimaryStrategy(&tokenbucket.Config{Burst: 10, Rate: 5})},
+			want:    5,
+		},
+		"leaky bucket": {
+			options: []Option{WithPrimaryStrategy(&leakybucket.Config{Burst: 10, Rate: 0.5})},
+			want:    0.5,
+		},
+		"gcra": {
+			options: []Option{WithGCRAStrategy(3, 10)},
+			want:    3,
+		},
+		"approx": {
+			options: []Option{WithPrimaryStrategy(&approx.Config{Limit: 600, Window: time.Minute})},
+			want:    10,
+		},
+		"sliding window": {
+			options: []Option{WithPrimaryStrategy(&slidingwindow.Config{Limit: 30, Window: time.Minute})},
+			want:    0.5,
+		},
+		"concurrency": {
+			options: []Option{WithPrimaryStrategy(&concurrency.Config{Max: 4, LeaseTTL: time.Minute})},
+			want:    math.Inf(1),
+		},
+		"composite picks the tightest": {
+			options: []Option{
+				WithRate("600/min"),
+				WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 2}),
+				WithSecondaryStrategy(&gcra.Config{Burst: 5, Rate: 4}),
+			},
+			want: 2,
+		},
+		"composite ignores advisory tiers": {
+			options: []Option{
+				WithRate("600/min"),
+				WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 2}, WithAdvisory()),
+			},
+			want: 10,
+		},
+		"composite ignores concurrency": {
+			options: []Option{WithRateAndConcurrency(60, time.Minute, 2)},
+			want:    1,
+		},
+		"any picks the loosest": {
+			options: []Option{WithAnyStrategy(perMinute(60), &tokenbucket.Config{Burst: 5, Rate: 3})},
+			want:    3,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			limiter, err := New(append([]Option{WithBackend(memory.New())}, tt.options...)...)
+			require.NoError(t, err)
+			defer limiter.Close()
+
+			assert.Equal(t, tt.want, limiter.SteadyStateRate())
+		})
+	}
+}
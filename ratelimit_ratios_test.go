@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateRatios has two quotas of one request per minute
+func duplicateRatios() *fixedwindow.Config {
+	return fixedwindow.NewConfig().
+		AddQuota("minute", 1, time.Minute).
+		AddQuota("hour", 60, time.Hour).
+		Build()
+}
+
+func TestWithAllowDuplicateRatios(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		backend := memory.New()
+		defer backend.Close()
+		_, err := New(WithBackend(backend), WithPrimaryStrategy(duplicateRatios()))
+		require.ErrorContains(t, err, "duplicate rate ratios", "strict by default")
+
+		logger := &captureLogger{}
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(duplicateRatios()),
+			WithAllowDuplicateRatios(true),
+			WithLogger(logger),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		assert.Len(t, logger.at(slog.LevelWarn), 1)
+
+		var results strategies.Results
+		options := AccessOptions{Key: "user", Result: &results}
+		allowed, err := limiter.Allow(t.Context(), options)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 0, results["minute"].Remaining)
+		assert.Equal(t, 59, results["hour"].Remaining)
+		assert.Equal(t, time.Hour-time.Minute, results["hour"].Reset.Sub(results["minute"].Reset))
+
+		allowed, err = limiter.Allow(t.Context(), options)
+		require.NoError(t, err)
+		assert.False(t, allowed, "the minute quota is enforced")
+
+		// The minute window restarts while the hour keeps counting
+		time.Sleep(time.Minute)
+		allowed, err = limiter.Allow(t.Context(), options)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 58, results["hour"].Remaining)
+	})
+}
+
+func TestWithAllowDuplicateRatios_UpdateStrategy(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)))
+	require.NoError(t, err)
+	defer limiter.Close()
+	require.Error(t, limiter.UpdateStrategy(duplicateRatios()))
+
+	limiter, err = New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)), WithAllowDuplicateRatios(true))
+	require.NoError(t, err)
+	defer limiter.Close()
+	require.NoError(t, limiter.UpdateStrategy(duplicateRatios()))
+
+	require.NoError(t, Validate(WithBackend(memory.New()), WithPrimaryStrategy(duplicateRatios()), WithAllowDuplicateRatios(true)))
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefund_SingleStrategy(t *testing.T) {
+	limiter, err := New(
+		WithBaseKey("refund"),
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 0.001}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	opts := AccessOptions{Key: "user"}
+	for range 3 {
+		allowed, err := limiter.Allow(t.Context(), opts)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	require.NoError(t, limiter.RefundN(t.Context(), opts, 2))
+
+	var results strategies.Results
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 4, results.Default().Remaining)
+
+	// Never refunds above capacity
+	require.NoError(t, limiter.RefundN(t.Context(), opts, 10))
+	require.NoError(t, limiter.Refund(t.Context(), opts))
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 5, results.Default().Remaining)
+}
+
+func TestRefund_DualStrategy(t *testing.T) {
+	limiter, err := New(
+		WithBaseKey("refund"),
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(fixedwindow.NewConfig().
+			AddQuota("default", 10, time.Minute).
+			Build()),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 0.001}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	opts := AccessOptions{Key: "user"}
+
+	// Refund on a fresh key is a no-op
+	require.NoError(t, limiter.Refund(t.Context(), opts))
+
+	for range 3 {
+		allowed, err := limiter.Allow(t.Context(), opts)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	require.NoError(t, limiter.RefundN(t.Context(), opts, 2))
+
+	var results strategies.Results
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 9, results.PrimaryDefault().Remaining)
+	assert.Equal(t, 4, results.SecondaryDefault().Remaining)
+
+	require.NoError(t, limiter.RefundN(t.Context(), opts, 10))
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 10, results.PrimaryDefault().Remaining)
+	assert.Equal(t, 5, results.SecondaryDefault().Remaining)
+}
+
+func TestRefund_Errors(t *testing.T) {
+	mb := &mockBackendOne{}
+	primCfg := mockStrategyConfig{id: strategies.StrategyTokenBucket, caps: strategies.CapPrimary}
+
+	rl, err := newRateLimiter(Config{BaseKey: "base", Storage: mb, PrimaryConfig: primCfg})
+	require.NoError(t, err)
+
+	require.Error(t, rl.RefundN(t.Context(), AccessOptions{}, 0), "expected error for non-positive count")
+	require.Error(t, rl.Refund(t.Context(), AccessOptions{Key: "bad key!"}), "expected error for invalid key")
+
+	// Strategies without refund support are reported
+	rl.strategy = &mockStrategyOne{}
+	require.ErrorIs(t, rl.Refund(t.Context(), AccessOptions{}), strategies.ErrRefundNotSupported)
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReset_ClearsPenalty(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", 1, time.Second).Build()),
+			WithPenalty(PenaltyConfig{Base: 10 * time.Second, Max: time.Hour, Multiplier: 2}),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		assert.Equal(t, 10*time.Second, hitLimit(t, limiter))
+		time.Sleep(10 * time.Second)
+		assert.Equal(t, 20*time.Second, hitLimit(t, limiter))
+
+		require.NoError(t, limiter.Reset(t.Context(), AccessOptions{Key: "user"}))
+
+		// Full access right away, and the next lockout is back at Base
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 10*time.Second, hitLimit(t, limiter))
+	})
+}
+
+func TestReset_ClearsAllTiers(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithBaseKey("api"),
+		WithPrimaryStrategy(perMinute(2)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 0.01}),
+		WithPenalty(PenaltyConfig{Base: time.Hour, Max: time.Hour, Multiplier: 1}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+	keys, err := limiter.ListKeys(t.Context(), "")
+	require.NoError(t, err)
+	assert.Len(t, keys, 2, "composite state and penalty")
+
+	require.NoError(t, limiter.Reset(t.Context(), AccessOptions{Key: "user"}))
+	keys, err = limiter.ListKeys(t.Context(), "")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+}
+
+func TestReset_ReportsDenialAgain(t *testing.T) {
+	// Requests costing more than the limit are denied even on fresh state
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(1)),
+		WithCostFunc(func(AccessOptions) float64 { return 2 }),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	events := limiter.DenialEvents()
+
+	allowN(t, limiter, 1)
+	require.NoError(t, limiter.Reset(t.Context(), AccessOptions{Key: "user"}))
+	allowN(t, limiter, 1)
+
+	assert.Len(t, drainDenials(events), 2, "the denial after Reset is a new transition")
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/internal/strategies/composite"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDualLimiter(t *testing.T, opts ...Option) *RateLimiter {
+	t.Helper()
+	backend := &casCountingBackend{Backend: memory.New(), readLatency: 50 * time.Microsecond}
+	limiter, err := New(append([]Option{
+		WithBackend(backend),
+		WithBaseKey("retries"),
+		WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", 1000, time.Minute).Build()),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 100, Rate: 1}),
+	}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { limiter.Close() })
+	return limiter
+}
+
+// hammer sends one request per goroutine for the same key and counts the
+// failures caused by running out of CAS attempts
+func hammer(t *testing.T, limiter *RateLimiter) int64 {
+	const goroutines = 50
+
+	var exhausted atomic.Int64
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			_, err := limiter.Allow(context.Background(), AccessOptions{Key: "hot"})
+			if errors.Is(err, strategies.ErrMaxRetriesExceeded) {
+				exhausted.Add(1)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+	return exhausted.Load()
+}
+
+func TestWithMaxRetries_PropagatesToComposite(t *testing.T) {
+	limiter := newDualLimiter(t, WithMaxRetries(7))
+
+	cfg, ok := limiter.buildStrategyConfig("user").(*composite.Config)
+	require.True(t, ok)
+	assert.Equal(t, 7, cfg.GetMaxRetries())
+	assert.Equal(t, 7, cfg.Primary.GetMaxRetries())
+	assert.Equal(t, 7, cfg.Secondary.GetMaxRetries())
+}
+
+func TestWithMaxRetries_CompositeDefault(t *testing.T) {
+	limiter := newDualLimiter(t)
+
+	cfg, ok := limiter.buildStrategyConfig("user").(*composite.Config)
+	require.True(t, ok)
+	assert.Equal(t, 101, cfg.GetMaxRetries(), "smallest tier default, token bucket burst + 1")
+}
+
+func TestWithMaxRetries_CompositeContention(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		assert.Zero(t, hammer(t, newDualLimiter(t)))
+	})
+
+	t.Run("single attempt", func(t *testing.T) {
+		// Lost races are not deterministic, a few rounds make one practically certain
+		var exhausted int64
+		for range 10 {
+			exhausted += hammer(t, newDualLimiter(t, WithMaxRetries(1)))
+			if exhausted > 0 {
+				break
+			}
+		}
+		assert.Positive(t, exhausted)
+	})
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSampleRate_HalfOfRequestsConsume(t *testing.T) {
+	limiter := newKeyLimiter(t,
+		WithPrimaryStrategy(perMinute(10000)),
+		WithSampleRate(0.5),
+		WithRandSource(rand.NewPCG(1, 2)),
+	)
+
+	sampledOut := 0
+	for range 1000 {
+		var results strategies.Results
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		require.True(t, allowed)
+		if _, ok := results[SampledOutResultKey]; ok {
+			require.Len(t, results, 1)
+			sampledOut++
+		}
+	}
+	assert.InDelta(t, 500, sampledOut, 75)
+
+	// Only the sampled requests consumed quota
+	var results strategies.Results
+	_, err := limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 10000-(1000-sampledOut), results["default"].Remaining)
+}
+
+func TestWithSampleRate_PeekNotSampled(t *testing.T) {
+	source := rand.NewPCG(1, 2)
+	limiter := newKeyLimiter(t,
+		WithPrimaryStrategy(perMinute(1)),
+		WithSampleRate(0),
+		WithRandSource(source),
+		WithDenyList(func(options AccessOptions) bool { return options.Key == "blocked" }),
+	)
+	allowN(t, limiter, 1)
+	state := *source
+
+	// Peek reports the stored state without drawing from the source
+	for range 3 {
+		var results strategies.Results
+		allowed, err := limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.NotContains(t, results, SampledOutResultKey)
+		assert.Equal(t, 1, results["default"].Remaining)
+	}
+	assert.Equal(t, state, *source)
+
+	// The lists still apply
+	allowed, err := limiter.Peek(t.Context(), AccessOptions{Key: "blocked"})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestWithSampleRate_ByKey(t *testing.T) {
+	newLimiter := func(rate float64) *RateLimiter {
+		return newKeyLimiter(t, WithPrimaryStrategy(perMinute(1)), WithSampleRate(rate), WithKeySampling())
+	}
+	enforcedKeys := func(limiter *RateLimiter) map[string]bool {
+		enforced := map[string]bool{}
+		for i := range 1000 {
+			key := fmt.Sprintf("user%d", i)
+			// Every call of a key gets the same treatment
+			var outcomes []bool
+			for range 3 {
+				allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: key})
+				require.NoError(t, err)
+				outcomes = append(outcomes, allowed)
+			}
+			switch {
+			case outcomes[0] && outcomes[1] && outcomes[2]:
+				// Sampled out, never limited
+			case outcomes[0] && !outcomes[1] && !outcomes[2]:
+				enforced[key] = true
+			default:
+				t.Fatalf("key %s was sampled inconsistently: %v", key, outcomes)
+			}
+		}
+		return enforced
+	}
+
+	half := enforcedKeys(newLimiter(0.5))
+	assert.InDelta(t, 500, len(half), 75)
+	assert.Equal(t, half, enforcedKeys(newLimiter(0.5)), "the same keys on every limiter")
+
+	// Ramping up only adds keys
+	for key := range enforcedKeys(newLimiter(0.2)) {
+		assert.True(t, half[key], key)
+	}
+}
+
+func TestWithSampleRate_Bounds(t *testing.T) {
+	// 0 enforces nothing, without touching the backend
+	limiter := newKeyLimiter(t, WithSampleRate(0), WithBackend(downBackend{}))
+	assert.Equal(t, 5, allowN(t, limiter, 5))
+
+	// 1 enforces every request
+	limiter = newKeyLimiter(t, WithSampleRate(1))
+	assert.Nil(t, limiter.sampler)
+	assert.Equal(t, 2, allowN(t, limiter, 5))
+
+	for _, rate := range []float64{-0.1, 1.5} {
+		_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(1)), WithSampleRate(rate))
+		require.ErrorContains(t, err, "sample rate must be between 0 and 1")
+	}
+}
@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanRemaining collects the remaining count of result name for every scanned key
+func scanRemaining(t *testing.T, limiter *RateLimiter, name string) map[string]int {
+	t.Helper()
+	remaining := make(map[string]int)
+	for key, results := range limiter.Scan(t.Context()) {
+		require.Contains(t, results, name)
+		remaining[key] = results[name].Remaining
+	}
+	return remaining
+}
+
+// consume allows n requests for key
+func consume(t *testing.T, limiter *RateLimiter, key string, n int) {
+	t.Helper()
+	for range n {
+		_, err := limiter.Allow(t.Context(), AccessOptions{Key: key})
+		require.NoError(t, err)
+	}
+}
+
+func TestScan_SingleStrategy(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+		WithPenalty(PenaltyConfig{Base: time.Minute, Max: time.Hour, Multiplier: 2}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	consume(t, limiter, "a", 1)
+	consume(t, limiter, "b", 2)
+	remaining := scanRemaining(t, limiter, "default")
+	assert.Equal(t, map[string]int{"a": 4, "b": 3}, remaining)
+
+	// Scanning is read-only
+	assert.Equal(t, remaining, scanRemaining(t, limiter, "default"))
+
+	// The penalty lockout is not a key of its own, locked out keys report it like Peek
+	consume(t, limiter, "c", 6)
+	keys := make(map[string]strategies.Results)
+	maps.Insert(keys, limiter.Scan(t.Context()))
+	assert.Len(t, keys, 3)
+	assert.Contains(t, keys["c"], PenaltyResultKey)
+}
+
+func TestScan_DualStrategy(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 3, Rate: 0.001}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	consume(t, limiter, "a", 1)
+	consume(t, limiter, "team:b", 2)
+	assert.Equal(t, map[string]int{"a": 4, "team:b": 3}, scanRemaining(t, limiter, "primary_default"))
+}
+
+func TestScan_AnyStrategy(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithAnyStrategy(perMinute(1), perMinute(5)),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	// Both tiers have state, the key is yielded once
+	consume(t, limiter, "a", 2)
+	keys := make(map[string]strategies.Results)
+	maps.Insert(keys, limiter.Scan(t.Context()))
+	require.Len(t, keys, 1)
+	assert.Equal(t, 4, keys["a"]["tier2_default"].Remaining)
+}
+
+func TestScan_StopsEarly(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	for _, key := range []string{"a", "b", "c"} {
+		consume(t, limiter, key, 1)
+	}
+
+	// Breaking out of the loop stops peeking
+	n := 0
+	for range limiter.Scan(t.Context()) {
+		n++
+		break
+	}
+	assert.Equal(t, 1, n)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	for key := range limiter.Scan(ctx) {
+		t.Errorf("unexpected key %q after cancellation", key)
+	}
+
+	// Backends that cannot list keys yield nothing
+	limiter, err := New(WithBackend(downBackend{}), WithPrimaryStrategy(perMinute(2)))
+	require.NoError(t, err)
+	for key := range limiter.Scan(t.Context()) {
+		t.Errorf("unexpected key %q", key)
+	}
+}
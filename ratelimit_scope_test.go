@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBaseKeyScope_Isolation(t *testing.T) {
+	limiter := newKeyLimiter(t, WithBaseKey("api"))
+	billing, err := limiter.WithBaseKeyScope("billing")
+	require.NoError(t, err)
+	search, err := limiter.WithBaseKeyScope("search")
+	require.NoError(t, err)
+
+	// Every namespace has its own quota for the same key
+	assert.Equal(t, 2, allowN(t, billing, 3))
+	assert.Equal(t, 2, allowN(t, search, 3))
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+
+	keys, err := billing.ListKeys(t.Context(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api:billing:user"}, keys)
+
+	// Resetting a view leaves the others denied
+	require.NoError(t, billing.Reset(t.Context(), AccessOptions{Key: "user"}))
+	assert.Equal(t, 2, allowN(t, billing, 2))
+	assert.Zero(t, allowN(t, search, 1))
+
+	// Views count in the shared stats
+	assert.Equal(t, Stats{Allowed: 8, Denied: 4}, limiter.Stats())
+	assert.Equal(t, limiter.Stats(), search.Stats())
+}
+
+func TestWithBaseKeyScope_Nested(t *testing.T) {
+	limiter := newKeyLimiter(t, WithBaseKey("api"),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 10, Rate: 1}))
+	billing, err := limiter.WithBaseKeyScope("billing")
+	require.NoError(t, err)
+	invoices, err := billing.WithBaseKeyScope("invoices")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, allowN(t, invoices, 3))
+	assert.Equal(t, 2, allowN(t, billing, 3))
+	keys, err := invoices.ListKeys(t.Context(), "")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.True(t, strings.HasPrefix(keys[0], "api:billing:invoices:user"), keys[0])
+}
+
+func TestWithBaseKeyScope_Close(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	view, err := limiter.WithBaseKeyScope("module")
+	require.NoError(t, err)
+	events := view.DenialEvents()
+
+	// Closing a view leaves the shared backend open
+	require.NoError(t, view.Close())
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.Equal(t, 2, allowN(t, view, 3))
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+
+	// Closing the limiter closes the channels of its views
+	other, err := limiter.WithBaseKeyScope("other")
+	require.NoError(t, err)
+	events = other.DenialEvents()
+	require.NoError(t, limiter.Close())
+	_, ok = <-events
+	assert.False(t, ok)
+}
+
+func TestWithBaseKeyScope_InvalidScope(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	for _, scope := range []string{"", "has space", strings.Repeat("a", 64)} {
+		_, err := limiter.WithBaseKeyScope(scope)
+		assert.Error(t, err, scope)
+	}
+}
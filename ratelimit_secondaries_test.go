@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMultiSecondaryLimiter(t *testing.T, tbBurst, lbBurst int) *RateLimiter {
+	t.Helper()
+
+	limiter, err := New(
+		WithBaseKey("multi"),
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(fixedwindow.NewConfig().
+			AddQuota("default", 100, time.Minute).
+			Build()),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: tbBurst, Rate: 0.001}),
+		WithSecondaryStrategy(&leakybucket.Config{Burst: lbBurst, Rate: 0.001}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	return limiter
+}
+
+func TestWithSecondaryStrategy_Accumulates(t *testing.T) {
+	cfg := &Config{}
+	tb := &tokenbucket.Config{Burst: 1, Rate: 1}
+	lb := &leakybucket.Config{Burst: 1, Rate: 1}
+
+	require.NoError(t, WithSecondaryStrategy(tb)(cfg))
+	require.NoError(t, WithSecondaryStrategy(lb)(cfg))
+
+	assert.Equal(t, tb, cfg.SecondaryConfig)
+	assert.Equal(t, []strategies.Config{lb}, cfg.ExtraSecondaryConfigs)
+}
+
+func TestMultipleSecondaries_TokenBucketDenies(t *testing.T) {
+	limiter := newMultiSecondaryLimiter(t, 2, 5)
+
+	var results strategies.Results
+	for range 2 {
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.False(t, results["secondary_tokenbucket_default"].Allowed, "token bucket should deny")
+
+	// Denied request must not consume quota on any tier
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 98, results["primary_default"].Remaining)
+	assert.Contains(t, results, "secondary_leakybucket_default")
+}
+
+func TestMultipleSecondaries_LeakyBucketDenies(t *testing.T) {
+	limiter := newMultiSecondaryLimiter(t, 5, 2)
+
+	var results strategies.Results
+	for range 2 {
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.True(t, results["secondary_tokenbucket_default"].Allowed, "token bucket should allow")
+	assert.False(t, results["secondary_leakybucket_default"].Allowed, "leaky bucket should deny")
+
+	// Denied request must not consume quota on any tier
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 98, results["primary_default"].Remaining)
+	assert.Equal(t, 3, results["secondary_tokenbucket_default"].Remaining)
+}
+
+func TestMultipleSecondaries_Reset(t *testing.T) {
+	limiter := newMultiSecondaryLimiter(t, 1, 1)
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	require.NoError(t, limiter.Reset(t.Context(), AccessOptions{Key: "user"}))
+
+	allowed, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedBackend_EndToEnd(t *testing.T) {
+	shards := []*memory.Backend{memory.New(), memory.New(), memory.New()}
+	sharded, err := backends.NewSharded([]backends.Backend{shards[0], shards[1], shards[2]}, nil)
+	require.NoError(t, err)
+	limiter, err := New(WithBackend(sharded), WithPrimaryStrategy(perMinute(2)))
+	require.NoError(t, err)
+	defer limiter.Close()
+	ctx := t.Context()
+
+	for i := range 30 {
+		key := fmt.Sprintf("user-%d", i)
+		for j, want := range []bool{true, true, false} {
+			allowed, err := limiter.Allow(ctx, AccessOptions{Key: key})
+			require.NoError(t, err)
+			assert.Equal(t, want, allowed, "%s request %d", key, j)
+		}
+	}
+
+	// Every key is stored once, spread over the shards
+	keys, err := limiter.ListKeys(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, keys, 30)
+	for _, shard := range shards {
+		shardKeys, err := shard.Keys(ctx, "*")
+		require.NoError(t, err)
+		assert.NotEmpty(t, shardKeys)
+	}
+
+	require.NoError(t, limiter.FlushAll(ctx))
+	keys, err = limiter.ListKeys(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
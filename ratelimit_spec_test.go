@@ -0,0 +1,326 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	_ "github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSpec() Spec {
+	return Spec{
+		BaseKey: "spec",
+		Backend: BackendSpec{Type: "memory"},
+		Primary: StrategySpec{
+			Strategy: "fixed_window",
+			Quotas: []QuotaSpec{
+				{Name: "minute", Limit: 8, Window: Duration(time.Minute)},
+				{Name: "hour", Limit: 100, Window: Duration(time.Hour)},
+			},
+		},
+		Secondary: []StrategySpec{
+			{Strategy: "token_bucket", Burst: 5, Rate: 0.001},
+		},
+		MaxRetries: 7,
+		RetryBackoff: &BackoffSpec{
+			Initial: Duration(time.Millisecond),
+			Max:     Duration(10 * time.Millisecond),
+		},
+	}
+}
+
+func TestSpec_JSONRoundTrip(t *testing.T) {
+	spec := testSpec()
+
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"window":"1m0s"`, "durations should be serialized as strings")
+
+	var decoded Spec
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, spec, decoded)
+
+	original, err := NewFromSpec(spec)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = original.Close() })
+
+	rebuilt, err := NewFromSpec(decoded)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = rebuilt.Close() })
+
+	assert.Equal(t, original.config.maxRetries, rebuilt.config.maxRetries)
+	assert.Equal(t, original.config.retryBackoff, rebuilt.config.retryBackoff)
+
+	// Both limiters must make identical decisions
+	for i := range 7 {
+		var want, got strategies.Results
+		wantAllowed, err := original.Allow(t.Context(), AccessOptions{Key: "user", Result: &want})
+		require.NoError(t, err)
+		gotAllowed, err := rebuilt.Allow(t.Context(), AccessOptions{Key: "user", Result: &got})
+		require.NoError(t, err)
+
+		assert.Equal(t, wantAllowed, gotAllowed, "request %d", i)
+		for name, res := range want {
+			assert.Equal(t, res.Allowed, got[name].Allowed, "request %d quota %s", i, name)
+			assert.Equal(t, res.Remaining, got[name].Remaining, "request %d quota %s", i, name)
+		}
+	}
+}
+
+func TestSpec_Unmarshal(t *testing.T) {
+	data := `{
+		"base_key": "api",
+		"backend": {"type": "memory"},
+		"primary": {"strategy": "gcra", "burst": 2, "rate": 1}
+	}`
+
+	var spec Spec
+	require.NoError(t, json.Unmarshal([]byte(data), &spec))
+	require.NoError(t, spec.Validate())
+
+	limiter, err := NewFromSpec(spec)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	for range 2 {
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.False(t, allowed, "gcra burst should be exhausted")
+
+	err = json.Unmarshal([]byte(`{"primary": {"quotas": [{"window": "soon"}]}}`), &spec)
+	require.ErrorContains(t, err, `invalid duration "soon"`)
+}
+
+func TestSpec_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Spec)
+		errMsg string
+	}{
+		{"missing backend", func(s *Spec) { s.Backend.Type = "" }, "backend type is required"},
+		{"missing primary", func(s *Spec) { s.Primary = StrategySpec{} }, "primary strategy is required"},
+		{"unknown primary", func(s *Spec) { s.Primary.Strategy = "sliding_log" }, `unknown strategy "sliding_log"`},
+		{"unknown secondary", func(s *Spec) { s.Secondary[0].Strategy = "bogus" }, `secondary strategy 0: unknown strategy "bogus"`},
+		{"composite", func(s *Spec) { s.Primary.Strategy = "composite" }, "cannot be declared directly"},
+		{"missing quotas", func(s *Spec) { s.Primary.Quotas = nil }, "fixed_window requires at least one quota"},
+		{"invalid burst", func(s *Spec) { s.Secondary[0].Burst = 0 }, "secondary strategy 0"},
+		{"invalid backoff", func(s *Spec) { s.RetryBackoff.Max = 0 }, "retry backoff"},
+		{"advisory primary", func(s *Spec) { s.Primary.Advisory = true }, "primary strategy cannot be advisory"},
+		{"missing tier backend", func(s *Spec) { s.Secondary[0].Backend = &BackendSpec{} }, "secondary strategy 0: backend type is required"},
+		{"missing window", func(s *Spec) { s.Secondary[0] = StrategySpec{Strategy: "sliding_window", Limit: 5} }, "secondary strategy 0"},
+		{"missing lease ttl", func(s *Spec) { s.Primary = StrategySpec{Strategy: "concurrency", Max: 5} }, "primary strategy"},
+		{"invalid location", func(s *Spec) { s.Primary.Location = "Nowhere/Special" }, `primary strategy: invalid location "Nowhere/Special"`},
+		{"invalid soft limit", func(s *Spec) { s.Primary.Quotas[0].SoftLimit = 8 }, "primary strategy"},
+		{"negative idle ttl", func(s *Spec) { s.Secondary[0].IdleTTL = Duration(-time.Second) }, "secondary strategy 0: idle ttl must not be negative"},
+		{"unsupported idle ttl", func(s *Spec) {
+			s.Secondary[0] = StrategySpec{Strategy: "sliding_window", Limit: 5, Window: Duration(time.Minute), IdleTTL: Duration(time.Hour)}
+		}, `secondary strategy 0: strategy "sliding_window" does not support an idle ttl`},
+		{"any with secondary", func(s *Spec) { s.Any = []StrategySpec{{Strategy: "gcra", Burst: 1, Rate: 1}} }, "any strategy cannot be combined with a secondary strategy"},
+		{"advisory any tier", func(s *Spec) {
+			s.Secondary, s.Any = nil, []StrategySpec{{Strategy: "gcra", Burst: 1, Rate: 1, Advisory: true}}
+		}, "any tier 0 cannot be advisory"},
+		{"any tier backend", func(s *Spec) {
+			s.Secondary, s.Any = nil, []StrategySpec{{Strategy: "gcra", Burst: 1, Rate: 1, Backend: &BackendSpec{Type: "memory"}}}
+		}, "any tier 0: backend is not supported"},
+		{"primary backend with any", func(s *Spec) {
+			s.Secondary, s.Any = nil, []StrategySpec{{Strategy: "gcra", Burst: 1, Rate: 1}}
+			s.Primary.Backend = &BackendSpec{Type: "memory"}
+		}, "primary strategy: backend is not supported with any tiers"},
+		{"invalid any tier", func(s *Spec) { s.Secondary, s.Any = nil, []StrategySpec{{Strategy: "gcra"}} }, "any tier 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := testSpec()
+			tt.mutate(&spec)
+
+			require.ErrorContains(t, spec.Validate(), tt.errMsg)
+
+			_, err := NewFromSpec(spec)
+			require.Error(t, err)
+		})
+	}
+
+	spec := testSpec()
+	spec.Backend.Type = "nosuchbackend"
+	require.NoError(t, spec.Validate(), "backend existence is checked on build")
+	_, err := NewFromSpec(spec)
+	require.ErrorContains(t, err, `backend "nosuchbackend"`)
+}
+
+func TestSpec_Strategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary StrategySpec
+		options AccessOptions
+	}{
+		{"sliding_window", StrategySpec{Strategy: "sliding_window", Limit: 2, Window: Duration(time.Minute)}, AccessOptions{Key: "user"}},
+		{"approx", StrategySpec{Strategy: "approx", Limit: 2, Window: Duration(time.Minute), Precision: 1}, AccessOptions{Key: "user"}},
+		{"unique", StrategySpec{Strategy: "unique", Limit: 2, Window: Duration(time.Minute)}, AccessOptions{Key: "tenant", Actor: "alice"}},
+		{"concurrency", StrategySpec{Strategy: "concurrency", Max: 2, LeaseTTL: Duration(time.Minute)}, AccessOptions{Key: "user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := Spec{BaseKey: "spec", Backend: BackendSpec{Type: "memory"}, Primary: tt.primary}
+			data, err := json.Marshal(spec)
+			require.NoError(t, err)
+			var decoded Spec
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			require.Equal(t, spec, decoded)
+
+			limiter, err := NewFromSpec(decoded)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = limiter.Close() })
+
+			allowed, err := limiter.Allow(t.Context(), tt.options)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+			assert.Equal(t, tt.primary, limiter.limits().Primary, "limits should describe the spec back")
+		})
+	}
+}
+
+func TestSpec_TierOptions(t *testing.T) {
+	spec := Spec{
+		Backend: BackendSpec{Type: "memory"},
+		Primary: StrategySpec{
+			Strategy: "sliding_window", Limit: 10, Window: Duration(time.Minute),
+			Backend: &BackendSpec{Type: "memory"},
+		},
+		Secondary: []StrategySpec{
+			{Strategy: "token_bucket", Burst: 1, Rate: 0.001, Advisory: true},
+		},
+	}
+	require.NoError(t, spec.Validate())
+
+	limiter, err := NewFromSpec(spec)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	require.NotNil(t, limiter.config.primaryStorage)
+	require.Equal(t, []backends.Backend{limiter.config.primaryStorage}, limiter.config.ownedStorages)
+
+	// The advisory bucket is empty after the first request, yet the third
+	// request is still allowed, flagged secondary_default.Advisory
+	for range 2 {
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	var results strategies.Results
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.True(t, results["secondary_default"].Advisory)
+
+	limits := limiter.limits()
+	require.Len(t, limits.Secondary, 1)
+	assert.True(t, limits.Secondary[0].Advisory)
+	assert.Nil(t, limits.Primary.Backend, "strategy backends are not described")
+}
+
+func TestSpec_StrategyOptions(t *testing.T) {
+	empty := 0.0
+	tests := []struct {
+		name    string
+		primary StrategySpec
+	}{
+		{"fixed_window", StrategySpec{
+			Strategy: "fixed_window",
+			Quotas: []QuotaSpec{
+				{Name: "hour", Limit: 3, Window: Duration(time.Hour), Aligned: true, SoftLimit: 1},
+				{Name: "day", Limit: 72, Window: Duration(24 * time.Hour)},
+			},
+			Location:             "Asia/Tokyo",
+			MaxClockSkew:         Duration(5 * time.Second),
+			IdleTTL:              Duration(48 * time.Hour),
+			IndependentQuotas:    true,
+			AllowDuplicateRatios: true,
+		}},
+		{"token_bucket", StrategySpec{Strategy: "token_bucket", Burst: 3, Rate: 0.001, InitialTokens: &empty, IdleTTL: Duration(time.Hour)}},
+		{"leaky_bucket", StrategySpec{Strategy: "leaky_bucket", Burst: 3, Rate: 0.001, IdleTTL: Duration(time.Hour)}},
+		{"gcra", StrategySpec{Strategy: "gcra", Burst: 3, Rate: 0.001, IdleTTL: Duration(time.Hour)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := Spec{BaseKey: "spec", Backend: BackendSpec{Type: "memory"}, Primary: tt.primary}
+			data, err := json.Marshal(spec)
+			require.NoError(t, err)
+			var decoded Spec
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			require.Equal(t, spec, decoded)
+			require.NoError(t, decoded.Validate())
+
+			limiter, err := NewFromSpec(decoded)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = limiter.Close() })
+			assert.Equal(t, tt.primary, limiter.limits().Primary, "limits should describe the spec back")
+		})
+	}
+}
+
+func TestSpec_SoftLimitAndInitialTokens(t *testing.T) {
+	spec := Spec{
+		Backend: BackendSpec{Type: "memory"},
+		Primary: StrategySpec{
+			Strategy: "fixed_window",
+			Quotas:   []QuotaSpec{{Name: "minute", Limit: 3, Window: Duration(time.Minute), SoftLimit: 1}},
+		},
+	}
+	limiter, err := NewFromSpec(spec)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	var soft []bool
+	for range 3 {
+		var results strategies.Results
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		require.True(t, allowed)
+		soft = append(soft, results["minute"].SoftLimited)
+	}
+	assert.Equal(t, []bool{false, true, true}, soft)
+
+	// A bucket starting empty denies the first request
+	empty := 0.0
+	spec.Primary = StrategySpec{Strategy: "token_bucket", Burst: 3, Rate: 0.001, InitialTokens: &empty}
+	limiter, err = NewFromSpec(spec)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestSpec_AnyTiers(t *testing.T) {
+	data := `{
+		"backend": {"type": "memory"},
+		"primary": {"strategy": "fixed_window", "quotas": [{"name": "minute", "limit": 1, "window": "1m"}]},
+		"any": [{"strategy": "token_bucket", "burst": 1, "rate": 0.001}]
+	}`
+	var spec Spec
+	require.NoError(t, json.Unmarshal([]byte(data), &spec))
+	require.NoError(t, spec.Validate())
+
+	limiter, err := NewFromSpec(spec)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	// The overage bucket allows one request beyond the quota
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+
+	limits := limiter.limits()
+	assert.Equal(t, spec.Primary, limits.Primary)
+	assert.Equal(t, spec.Any, limits.Any)
+	assert.Empty(t, limits.Secondary)
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowGetBackend counts reads, each taking a millisecond like a network round trip
+type slowGetBackend struct {
+	backends.Backend
+	gets atomic.Int64
+}
+
+func (s *slowGetBackend) Get(ctx context.Context, key string) (string, error) {
+	s.gets.Add(1)
+	select {
+	case <-time.After(time.Millisecond):
+		return s.Backend.Get(ctx, key)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestAllow_ColdKeyStampedeCoalescesReads(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		backend := &slowGetBackend{Backend: memory.New()}
+		limiter, err := New(
+			WithBackend(backend),
+			WithPrimaryStrategy(&tokenbucket.Config{Burst: 60, Rate: 0.001}),
+			WithMaxRetries(200),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		const goroutines = 100
+		var allowed atomic.Int64
+		var wg sync.WaitGroup
+		for range goroutines {
+			wg.Go(func() {
+				ok, err := limiter.Allow(t.Context(), AccessOptions{Key: "cold"})
+				assert.NoError(t, err)
+				if ok {
+					allowed.Add(1)
+				}
+			})
+		}
+		wg.Wait()
+
+		// Every caller still consumes with its own CheckAndSet
+		assert.Equal(t, int64(60), allowed.Load())
+
+		// The initial reads were a single Get; only the retries of lost
+		// CheckAndSets read on their own
+		assert.Equal(t, int64(1+limiter.Stats().CASRetries), backend.gets.Load())
+	})
+}
+
+func TestAllow_CoalescedReadErrorNotShared(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		backend := &slowGetBackend{Backend: memory.New()}
+		limiter, err := New(WithBackend(backend), WithPrimaryStrategy(&tokenbucket.Config{Burst: 10, Rate: 1}))
+		require.NoError(t, err)
+		defer limiter.Close()
+
+		// The first caller gives up during the shared read, the second must not fail with it
+		ctx, cancel := context.WithCancel(t.Context())
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			_, _ = limiter.Allow(ctx, AccessOptions{Key: "cold"})
+		})
+		synctest.Wait()
+		wg.Go(func() {
+			allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "cold"})
+			assert.NoError(t, err)
+			assert.True(t, allowed)
+		})
+		synctest.Wait()
+		cancel()
+		wg.Wait()
+	})
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conflictingBackend makes the next n CheckAndSet calls lose as if another writer won
+type conflictingBackend struct {
+	backends.Backend
+	conflicts atomic.Int64
+}
+
+func (c *conflictingBackend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	if c.conflicts.Add(-1) >= 0 {
+		return false, nil
+	}
+	return c.Backend.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+}
+
+func TestStats_Counters(t *testing.T) {
+	backend := &conflictingBackend{Backend: memory.New()}
+	backend.conflicts.Store(2)
+	limiter, err := New(WithBackend(backend), WithPrimaryStrategy(perMinute(3)))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	assert.Equal(t, Stats{}, limiter.Stats())
+
+	assert.Equal(t, 3, allowN(t, limiter, 5))
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+
+	assert.Equal(t, Stats{Allowed: 3, Denied: 2, CASRetries: 2}, limiter.Stats(), "Peek is not counted")
+}
+
+func TestStats_Errors(t *testing.T) {
+	limiter, err := New(WithBackend(downBackend{}), WithPrimaryStrategy(perMinute(3)))
+	require.NoError(t, err)
+
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.Error(t, err)
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "bad key"})
+	require.Error(t, err)
+
+	assert.Equal(t, Stats{Errors: 1}, limiter.Stats(), "invalid keys never reach the strategy")
+}
+
+func TestStats_FailOpen(t *testing.T) {
+	limiter, err := New(WithBackend(downBackend{}), WithPrimaryStrategy(perMinute(3)), WithFailureMode(FailOpen))
+	require.NoError(t, err)
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	assert.Equal(t, Stats{Allowed: 1, Errors: 1}, limiter.Stats())
+}
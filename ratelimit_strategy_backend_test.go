@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategyBackend_DualRouting(t *testing.T) {
+	shared, primary, secondary := memory.New(), memory.New(), memory.New()
+	defer primary.Close()
+	defer secondary.Close()
+	limiter, err := New(
+		WithBackend(shared),
+		WithPrimaryStrategy(perMinute(5), WithStrategyBackend(primary)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 0.001}, WithStrategyBackend(secondary)),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	ctx := t.Context()
+
+	// Each tier is stored on its own backend, none on the limiter-wide one
+	var results strategies.Results
+	allowed, err := limiter.Allow(ctx, AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 4, results["primary_default"].Remaining)
+	assert.Equal(t, 1, results["secondary_default"].Remaining)
+
+	keys, err := primary.Keys(ctx, "*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default:user:c1"}, keys)
+	keys, err = secondary.Keys(ctx, "*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default:user:c2"}, keys)
+	keys, err = shared.Keys(ctx, "*")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// The exhausted smoother denies without consuming the primary
+	assert.Equal(t, 1, allowN(t, limiter, 3))
+	_, err = limiter.Peek(ctx, AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 3, results["primary_default"].Remaining)
+	assert.Equal(t, 0, results["secondary_default"].Remaining)
+
+	// Refund and Reset reach every backend
+	require.NoError(t, limiter.Refund(ctx, AccessOptions{Key: "user"}))
+	_, err = limiter.Peek(ctx, AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.Equal(t, 4, results["primary_default"].Remaining)
+	assert.Equal(t, 1, results["secondary_default"].Remaining)
+
+	require.NoError(t, limiter.Reset(ctx, AccessOptions{Key: "user"}))
+	for _, b := range []*memory.Backend{primary, secondary} {
+		keys, err := b.Keys(ctx, "*")
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	}
+}
+
+func TestStrategyBackend_SinglePrimary(t *testing.T) {
+	shared, primary := memory.New(), memory.New()
+	defer primary.Close()
+	limiter, err := New(
+		WithBackend(shared),
+		WithPrimaryStrategy(perMinute(2), WithStrategyBackend(primary)),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	assert.Equal(t, 2, allowN(t, limiter, 3))
+	keys, err := primary.Keys(t.Context(), "*")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	keys, err = shared.Keys(t.Context(), "*")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestStrategyBackend_Validation(t *testing.T) {
+	_, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(2), WithStrategyBackend(nil)),
+	)
+	require.ErrorContains(t, err, "strategy backend cannot be nil")
+
+	primary := memory.New()
+	defer primary.Close()
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(2), WithStrategyBackend(primary)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 2, Rate: 1}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	require.ErrorContains(t, limiter.Warmup(t.Context(), []string{"user"}), "per-strategy backends")
+}
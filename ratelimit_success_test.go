@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResetOnSuccess(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+		WithResetOnSuccess(),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	// 4 failed logins, then a successful one starts the key over
+	assert.Equal(t, 4, allowN(t, limiter, 4))
+	require.NoError(t, limiter.MarkSuccess(t.Context(), AccessOptions{Key: "user"}))
+	assert.Equal(t, 5, allowN(t, limiter, 6))
+
+	// A success of another key does not reset this one
+	require.NoError(t, limiter.MarkSuccess(t.Context(), AccessOptions{Key: "other"}))
+	assert.Zero(t, allowN(t, limiter, 1))
+}
+
+func TestResetOnSuccess_Disabled(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	assert.Equal(t, 2, allowN(t, limiter, 2))
+	err := limiter.MarkSuccess(t.Context(), AccessOptions{Key: "user"})
+	require.ErrorIs(t, err, ErrResetOnSuccessDisabled)
+	assert.Zero(t, allowN(t, limiter, 1))
+}
+
+func TestResetOnSuccess_RequiresFixedWindow(t *testing.T) {
+	_, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+		WithResetOnSuccess(),
+	)
+	assert.ErrorContains(t, err, "fixed window")
+}
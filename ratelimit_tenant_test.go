@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTenantLimiter(t *testing.T, opts ...Option) *RateLimiter {
+	t.Helper()
+	limiter, err := New(append([]Option{WithBackend(memory.New()), WithBaseKey("api"), WithPrimaryStrategy(perMinute(2))}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	return limiter
+}
+
+func TestTenant_IsolatedBuckets(t *testing.T) {
+	limiter := newTenantLimiter(t)
+	tenantA := AccessOptions{Tenant: "tenantA", Key: "user1"}
+	tenantB := AccessOptions{Tenant: "tenantB", Key: "user1"}
+
+	for range 2 {
+		allowed, err := limiter.Allow(t.Context(), tenantA)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, err := limiter.Allow(t.Context(), tenantA)
+	require.NoError(t, err)
+	assert.False(t, allowed, "tenantA:user1 should be exhausted")
+
+	// Same user key in another tenant, or without tenant, is a fresh bucket
+	for _, options := range []AccessOptions{tenantB, {Key: "user1"}, {Key: "tenantA:user1"}, {Key: "@tenantA:user1"}} {
+		allowed, err = limiter.Peek(t.Context(), options)
+		require.NoError(t, err)
+		assert.True(t, allowed, "%+v should not share tenantA:user1", options)
+	}
+
+	keys, err := limiter.ListKeys(t.Context(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api:#tenantA:user1"}, keys)
+
+	// Reset and Refund are tenant-scoped too
+	require.NoError(t, limiter.Reset(t.Context(), tenantB))
+	allowed, err = limiter.Peek(t.Context(), tenantA)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NoError(t, limiter.Reset(t.Context(), tenantA))
+	allowed, err = limiter.Peek(t.Context(), tenantA)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestTenant_Precedence(t *testing.T) {
+	var keys []string
+	limiter := newTenantLimiter(t,
+		WithTenant("fallback"),
+		WithHook(func(_ context.Context, e Event) { keys = append(keys, e.Key) }),
+	)
+	ctx := ContextWithTenant(t.Context(), "fromctx")
+
+	for _, call := range []struct {
+		ctx     context.Context
+		options AccessOptions
+	}{
+		{ctx, AccessOptions{Tenant: "explicit", Key: "user1"}},
+		{ctx, AccessOptions{Key: "user1"}},
+		{t.Context(), AccessOptions{Key: "user1"}},
+	} {
+		_, err := limiter.Allow(call.ctx, call.options)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"#explicit:user1", "#fromctx:user1", "#fallback:user1"}, keys)
+}
+
+func TestTenant_Required(t *testing.T) {
+	limiter := newTenantLimiter(t, WithRequireTenant())
+
+	_, err := limiter.Allow(t.Context(), AccessOptions{Key: "user1"})
+	assert.ErrorIs(t, err, ErrTenantRequired)
+	_, err = limiter.Peek(t.Context(), AccessOptions{Key: "user1"})
+	assert.ErrorIs(t, err, ErrTenantRequired)
+
+	allowed, err := limiter.Allow(t.Context(), AccessOptions{Tenant: "tenantA", Key: "user1"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = limiter.Allow(ContextWithTenant(t.Context(), "tenantA"), AccessOptions{Key: "user1"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestTenant_Validation(t *testing.T) {
+	for _, tenant := range []string{"a:b", "bad tenant", "tenant#x"} {
+		_, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(2)), WithTenant(tenant))
+		assert.Error(t, err, "WithTenant(%q)", tenant)
+	}
+
+	limiter := newTenantLimiter(t)
+	// Tenants are validated even when the key validation is skipped
+	_, err := limiter.Allow(t.Context(), AccessOptions{Tenant: "a:b", Key: "user1", SkipValidation: true})
+	assert.Error(t, err)
+	_, err = limiter.Allow(t.Context(), AccessOptions{Tenant: "tenantA", Key: "user 1", SkipValidation: true})
+	assert.NoError(t, err)
+}
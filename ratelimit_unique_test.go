@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/unique"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithUniqueStrategy(t *testing.T) {
+	limiter, err := New(WithBackend(memory.New()), WithUniqueStrategy(2, time.Hour))
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	allow := func(actor string) bool {
+		t.Helper()
+		allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "tenant", Actor: actor})
+		require.NoError(t, err)
+		return allowed
+	}
+	assert.True(t, allow("alice"))
+	assert.True(t, allow("alice"), "a known actor does not count again")
+	assert.True(t, allow("bob"))
+	assert.False(t, allow("carol"))
+	assert.True(t, allow("bob"))
+
+	allowed, err := limiter.Peek(t.Context(), AccessOptions{Key: "tenant", Actor: "carol"})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, err = limiter.Allow(t.Context(), AccessOptions{Key: "tenant"})
+	require.ErrorIs(t, err, unique.ErrActorRequired)
+
+	_, err = New(WithBackend(memory.New()), WithUniqueStrategy(0, time.Hour))
+	require.Error(t, err)
+}
+
+func TestWithUniqueStrategy_Secondary(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(3)),
+		WithSecondaryStrategy(&unique.Config{Limit: 2, Window: time.Hour}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+
+	var allowed []bool
+	for i := range 4 {
+		ok, err := limiter.Allow(t.Context(), AccessOptions{Key: "tenant", Actor: fmt.Sprintf("user-%d", i%3)})
+		require.NoError(t, err)
+		allowed = append(allowed, ok)
+	}
+	// user-2 is a third actor, denied without consuming the request rate
+	assert.Equal(t, []bool{true, true, false, true}, allowed)
+}
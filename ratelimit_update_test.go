@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func perMinute(limit int) *fixedwindow.Config {
+	return fixedwindow.NewConfig().
+		AddQuota("default", limit, time.Minute).
+		Build()
+}
+
+func allowN(t *testing.T, limiter *RateLimiter, n int) int {
+	t.Helper()
+	allowed := 0
+	for range n {
+		ok, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		if ok {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestUpdateStrategy_RaiseLimit(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(3)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	require.Equal(t, 3, allowN(t, limiter, 5), "only the first 3 requests should be allowed")
+
+	require.NoError(t, limiter.UpdateStrategy(perMinute(5)))
+
+	// Consumed counts are kept, only the 2 extra requests are allowed
+	assert.Equal(t, 2, allowN(t, limiter, 5), "raised limit should immediately allow previously denied requests")
+}
+
+func TestUpdateStrategy_LowerLimit(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	require.Equal(t, 3, allowN(t, limiter, 3))
+
+	require.NoError(t, limiter.UpdateStrategy(perMinute(2)))
+
+	assert.Equal(t, 0, allowN(t, limiter, 3), "lowered limit below consumed count should deny")
+}
+
+func TestUpdateStrategy_Errors(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(5)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	require.Error(t, limiter.UpdateStrategy(nil))
+	require.ErrorContains(t, limiter.UpdateStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}), "cannot change primary strategy")
+	require.Error(t, limiter.UpdateStrategy(perMinute(0)), "invalid config should be rejected")
+
+	// Limiter keeps working with the previous config after failed updates
+	assert.Equal(t, 5, allowN(t, limiter, 6))
+}
+
+func TestUpdateStrategy_Concurrent(t *testing.T) {
+	limiter, err := New(
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(perMinute(1000)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 50 {
+				_, err := limiter.Allow(t.Context(), AccessOptions{Key: "user"})
+				assert.NoError(t, err)
+			}
+		})
+	}
+	for i := range 20 {
+		require.NoError(t, limiter.UpdateStrategy(perMinute(1000+i)))
+	}
+	wg.Wait()
+}
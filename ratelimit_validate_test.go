@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"testing/synctest"
+
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeCountingBackend counts Close calls and never contacts a server
+type closeCountingBackend struct {
+	downBackend
+	closed int
+}
+
+func (c *closeCountingBackend) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestValidate_SameFailuresAsNew(t *testing.T) {
+	// A valid config of a strategy that was never registered
+	unregistered := mockStrategyConfig{id: strategies.ID(200), caps: strategies.CapPrimary}
+	tests := []struct {
+		name string
+		opts []Option
+		err  string
+	}{
+		{"no backend", []Option{WithPrimaryStrategy(perMinute(1))}, "storage backend cannot be nil"},
+		{"nil primary", []Option{WithBackend(downBackend{})}, "primary strategy config cannot be nil"},
+		{"invalid primary", []Option{WithBackend(downBackend{}), WithPrimaryStrategy(&tokenbucket.Config{Burst: 0, Rate: 1})}, "primary strategy config validation failed"},
+		{
+			"secondary without capability",
+			[]Option{WithBackend(downBackend{}), WithPrimaryStrategy(perMinute(1)), WithSecondaryStrategy(perMinute(1))},
+			"doesn't have secondary capability",
+		},
+		{"unregistered strategy", []Option{WithBackend(downBackend{}), WithPrimaryStrategy(unregistered)}, "strategy not found"},
+		{"failing option", []Option{WithBackend(downBackend{}), WithRate("1/fortnight")}, "unknown window unit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.opts...)
+			require.ErrorContains(t, err, tt.err)
+
+			_, newErr := New(tt.opts...)
+			require.ErrorContains(t, newErr, tt.err)
+		})
+	}
+
+	require.NoError(t, Validate(
+		WithBackend(downBackend{}),
+		WithPrimaryStrategy(perMinute(10)),
+		WithSecondaryStrategy(&tokenbucket.Config{Burst: 5, Rate: 1}),
+	))
+}
+
+func TestValidate_NoResources(t *testing.T) {
+	// The bubble fails the test if a goroutine, e.g. a health check, is left running
+	synctest.Test(t, func(t *testing.T) {
+		first, second := &closeCountingBackend{}, &closeCountingBackend{}
+		err := Validate(
+			WithBackend(first),
+			WithBackend(second),
+			WithMemoryFailover(),
+			WithPrimaryStrategy(perMinute(10)),
+			WithOnExceeded(func(ctx context.Context, key, tier string) {}),
+		)
+		require.NoError(t, err)
+		assert.Zero(t, first.closed, "a replaced backend is not closed")
+		assert.Zero(t, second.closed)
+
+		err = Validate(WithBackend(second), WithMemoryFailover(), WithMemoryFailover(), WithPrimaryStrategy(perMinute(10)))
+		require.ErrorContains(t, err, "nested failovers")
+	})
+}
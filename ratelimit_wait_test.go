@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWaitLimiter(t *testing.T, rate float64, opts ...Option) *RateLimiter {
+	t.Helper()
+	opts = append([]Option{
+		WithBackend(memory.New()),
+		WithPrimaryStrategy(&tokenbucket.Config{Burst: 2, Rate: rate}),
+	}, opts...)
+	limiter, err := New(opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = limiter.Close() })
+	return limiter
+}
+
+func TestWithMaxWait_Validation(t *testing.T) {
+	require.Error(t, WithMaxWait(0)(&Config{}))
+	require.Error(t, WithMaxWait(-time.Second)(&Config{}))
+	require.NoError(t, WithMaxWait(time.Second)(&Config{}))
+}
+
+func TestWait_SleepsUntilAllowed(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter := newWaitLimiter(t, 1, WithMaxWait(5*time.Second))
+		assert.Equal(t, 2, allowN(t, limiter, 2))
+
+		start := time.Now()
+		decision, err := limiter.Wait(t.Context(), AccessOptions{Key: "user"})
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, time.Second, time.Since(start))
+	})
+}
+
+func TestWait_TooLongReturnsImmediately(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		// A token every 100 seconds, far beyond the cap
+		limiter := newWaitLimiter(t, 0.01, WithMaxWait(time.Second))
+		assert.Equal(t, 2, allowN(t, limiter, 2))
+
+		start := time.Now()
+		decision, err := limiter.Wait(t.Context(), AccessOptions{Key: "user"})
+		require.ErrorIs(t, err, ErrWaitTooLong)
+		assert.Zero(t, time.Since(start), "Wait should not sleep")
+		require.NotNil(t, decision)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, 100*time.Second, decision.RetryAfter)
+	})
+}
+
+func TestWait_CapCountsTimeAlreadyWaited(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter := newWaitLimiter(t, 1,
+			WithMaxWait(1400*time.Millisecond),
+			WithCostFunc(func(options AccessOptions) float64 {
+				if cost, ok := options.Metadata["cost"].(float64); ok {
+					return cost
+				}
+				return 1
+			}),
+		)
+		assert.Equal(t, 2, allowN(t, limiter, 2))
+
+		// Another caller takes half of the token refilling during the first sleep
+		go func() {
+			time.Sleep(600 * time.Millisecond)
+			allowed, err := limiter.Allow(t.Context(), AccessOptions{Key: "user", Metadata: map[string]any{"cost": 0.5}})
+			assert.NoError(t, err)
+			assert.True(t, allowed)
+		}()
+
+		// The second retry, 0.5s after the first second, would end past the cap
+		start := time.Now()
+		_, err := limiter.Wait(t.Context(), AccessOptions{Key: "user"})
+		require.ErrorIs(t, err, ErrWaitTooLong)
+		assert.Equal(t, time.Second, time.Since(start))
+	})
+}
+
+func TestWait_ContextDone(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter := newWaitLimiter(t, 0.01)
+		assert.Equal(t, 2, allowN(t, limiter, 2))
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		start := time.Now()
+		_, err := limiter.Wait(ctx, AccessOptions{Key: "user"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 10*time.Second, time.Since(start))
+	})
+}
+
+func TestWait_DenyList(t *testing.T) {
+	limiter := newWaitLimiter(t, 1, WithDenyList(func(AccessOptions) bool { return true }))
+
+	_, err := limiter.Wait(t.Context(), AccessOptions{Key: "user"})
+	require.ErrorIs(t, err, ErrWaitTooLong)
+}
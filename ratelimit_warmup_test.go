@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/approx"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// remaining peeks the results of key
+func remaining(t *testing.T, limiter *RateLimiter, key string) strategies.Results {
+	t.Helper()
+	var results strategies.Results
+	_, err := limiter.Peek(t.Context(), AccessOptions{Key: key, Result: &results})
+	require.NoError(t, err)
+	return results
+}
+
+func TestWarmup_CreatesFullQuotaState(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(WithBackend(memory.New()), WithPrimaryStrategy(perMinute(5)))
+		require.NoError(t, err)
+		defer limiter.Close()
+		ctx := t.Context()
+
+		require.NoError(t, limiter.Warmup(ctx, []string{"tenant-a", "tenant-b"}))
+		keys, err := limiter.ListKeys(ctx, "")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"default:tenant-a", "default:tenant-b"}, keys)
+
+		// The first request uses the warmed up window
+		time.Sleep(10 * time.Second)
+		var results strategies.Results
+		allowed, err := limiter.Allow(ctx, AccessOptions{Key: "tenant-a", Result: &results})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 4, results.Default().Remaining)
+		assert.Equal(t, 50*time.Second, time.Until(results.Default().Reset))
+
+		// Warming up again does not overwrite consumed quota
+		require.NoError(t, limiter.Warmup(ctx, []string{"tenant-a"}))
+		assert.Equal(t, 4, remaining(t, limiter, "tenant-a").Default().Remaining)
+		assert.Equal(t, 5, remaining(t, limiter, "tenant-b").Default().Remaining)
+	})
+}
+
+func TestWarmup_Strategies(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(perMinute(5)),
+			WithSecondaryStrategy(&tokenbucket.Config{Burst: 3, Rate: 1}),
+			WithSecondaryStrategy(&gcra.Config{Burst: 4, Rate: 1}),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		ctx := t.Context()
+
+		require.NoError(t, limiter.Warmup(ctx, []string{"user"}))
+		keys, err := limiter.ListKeys(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, keys, 1)
+
+		var results strategies.Results
+		allowed, err := limiter.Allow(ctx, AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 4, results.PrimaryDefault().Remaining)
+		assert.Equal(t, 2, results["secondary_tokenbucket_default"].Remaining)
+		assert.Equal(t, 3, results["secondary_gcra_default"].Remaining)
+	})
+}
+
+func TestWarmup_InitialTokens(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		limiter, err := New(
+			WithBackend(memory.New()),
+			WithPrimaryStrategy(tokenbucket.NewConfig().SetBurst(3).SetRate(1).SetInitialTokens(0).Build()),
+		)
+		require.NoError(t, err)
+		defer limiter.Close()
+		ctx := t.Context()
+
+		// The warmed up bucket starts empty like a new one, and earns tokens from the warmup
+		require.NoError(t, limiter.Warmup(ctx, []string{"user"}))
+		assert.Equal(t, 0, remaining(t, limiter, "user").Default().Remaining)
+		time.Sleep(2 * time.Second)
+		assert.Equal(t, 2, allowN(t, limiter, 3))
+	})
+}
+
+func TestWarmup_Errors(t *testing.T) {
+	limiter := newKeyLimiter(t)
+	assert.Error(t, limiter.Warmup(t.Context(), []string{"ok", "not valid!"}))
+
+	noRefund, err := New(WithBackend(memory.New()), WithPrimaryStrategy(&approx.Config{Limit: 10, Window: time.Minute}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = noRefund.Close() })
+	assert.ErrorIs(t, noRefund.Warmup(t.Context(), []string{"user"}), strategies.ErrRefundNotSupported)
+
+	keys, err := noRefund.ListKeys(t.Context(), "")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,146 @@
+// Package ratelimittest provides test doubles for code built on ratelimit: an
+// in-memory backend and a scripted strategy that count calls and fail on demand.
+package ratelimittest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+)
+
+// Op names a backend or strategy operation, for call counts and injected failures
+type Op string
+
+const (
+	OpGet         Op = "get"
+	OpSet         Op = "set"
+	OpCheckAndSet Op = "check_and_set"
+	OpDelete      Op = "delete"
+	OpAllow       Op = "allow"
+	OpPeek        Op = "peek"
+	OpReset       Op = "reset"
+	OpRefund      Op = "refund"
+)
+
+// calls counts operations, safe for concurrent use
+type calls struct {
+	mu     sync.Mutex
+	counts map[Op]int
+}
+
+func (c *calls) add(op Op) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[Op]int)
+	}
+	c.counts[op]++
+}
+
+// Calls returns the number of calls of op, failed ones included
+func (c *calls) Calls(op Op) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[op]
+}
+
+// ResetCalls sets all call counts back to zero
+func (c *calls) ResetCalls() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts = nil
+}
+
+// Backend is an in-memory backends.Backend with the semantics of the memory
+// backend, counting calls per operation and failing them on demand.
+//
+// A failed operation returns the injected error without touching the stored
+// values. The zero value is not usable; use NewBackend.
+type Backend struct {
+	calls
+	store *memory.Backend
+
+	mu       sync.Mutex
+	failures map[Op]error   // fail every call until cleared
+	once     map[Op][]error // fail the next calls, in order
+}
+
+// NewBackend returns an empty backend that does not fail
+func NewBackend() *Backend {
+	return &Backend{
+		store:    memory.New(),
+		failures: make(map[Op]error),
+		once:     make(map[Op][]error),
+	}
+}
+
+// Fail makes every call of op return err, until Fail(op, nil)
+func (b *Backend) Fail(op Op, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		delete(b.failures, op)
+		return
+	}
+	b.failures[op] = err
+}
+
+// FailNext makes the next calls of op return errs, one per call, before
+// any error set by Fail
+func (b *Backend) FailNext(op Op, errs ...error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.once[op] = append(b.once[op], errs...)
+}
+
+// call counts a call of op and returns its injected error, if any
+func (b *Backend) call(op Op) error {
+	b.add(op)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if errs := b.once[op]; len(errs) > 0 {
+		b.once[op] = errs[1:]
+		return errs[0]
+	}
+	return b.failures[op]
+}
+
+func (b *Backend) Get(ctx context.Context, key string) (string, error) {
+	if err := b.call(OpGet); err != nil {
+		return "", err
+	}
+	return b.store.Get(ctx, key)
+}
+
+func (b *Backend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	if err := b.call(OpSet); err != nil {
+		return err
+	}
+	return b.store.Set(ctx, key, value, expiration)
+}
+
+func (b *Backend) CheckAndSet(ctx context.Context, key string, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	if err := b.call(OpCheckAndSet); err != nil {
+		return false, err
+	}
+	return b.store.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+}
+
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	if err := b.call(OpDelete); err != nil {
+		return err
+	}
+	return b.store.Delete(ctx, key)
+}
+
+// Keys lists the stored keys matching pattern, without counting a call
+func (b *Backend) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return b.store.Keys(ctx, pattern)
+}
+
+func (b *Backend) Close() error {
+	return b.store.Close()
+}
@@ -0,0 +1,69 @@
+package ratelimittest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit"
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend(t *testing.T) {
+	backend := NewBackend()
+	defer backend.Close()
+	ctx := t.Context()
+	errDown := errors.New("down")
+
+	require.NoError(t, backend.Set(ctx, "a", "1", time.Minute))
+	ok, err := backend.CheckAndSet(ctx, "a", "1", "2", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// One-shot failures come first and leave the stored value alone
+	backend.FailNext(OpGet, errDown)
+	_, err = backend.Get(ctx, "a")
+	require.ErrorIs(t, err, errDown)
+	value, err := backend.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "2", value)
+
+	backend.Fail(OpDelete, errDown)
+	require.ErrorIs(t, backend.Delete(ctx, "a"), errDown)
+	require.ErrorIs(t, backend.Delete(ctx, "a"), errDown)
+	backend.Fail(OpDelete, nil)
+	require.NoError(t, backend.Delete(ctx, "a"))
+
+	assert.Equal(t, 1, backend.Calls(OpSet))
+	assert.Equal(t, 1, backend.Calls(OpCheckAndSet))
+	assert.Equal(t, 2, backend.Calls(OpGet))
+	assert.Equal(t, 3, backend.Calls(OpDelete))
+	backend.ResetCalls()
+	assert.Zero(t, backend.Calls(OpGet))
+}
+
+func TestBackend_Limiter(t *testing.T) {
+	backend := NewBackend()
+	limiter, err := ratelimit.New(
+		ratelimit.WithBackend(backend),
+		ratelimit.WithPrimaryStrategy(&fixedwindow.Config{
+			Quotas: []fixedwindow.Quota{{Name: "default", Limit: 1, Window: time.Minute}},
+		}),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	ctx := t.Context()
+
+	allowed, err := limiter.Allow(ctx, ratelimit.AccessOptions{Key: "user"})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Positive(t, backend.Calls(OpCheckAndSet))
+
+	// An outage reaches the limiter as a backend error
+	backend.Fail(OpGet, backends.NewHealthError("get", errors.New("connection refused")))
+	_, err = limiter.Allow(ctx, ratelimit.AccessOptions{Key: "user"})
+	assert.True(t, backends.IsHealthError(err))
+}
@@ -0,0 +1,187 @@
+package ratelimittest
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// StrategyID identifies the Strategy test double in the strategies registry
+const StrategyID strategies.ID = math.MaxUint8
+
+// ResultKey is the key of the single result reported by Strategy
+const ResultKey = "default"
+
+func init() {
+	strategies.Register(StrategyID, func(backends.Backend) strategies.Strategy {
+		return dispatcher{}
+	})
+}
+
+// Outcome is a scripted result of Strategy.Allow
+type Outcome struct {
+	Allowed   bool
+	Remaining int   // Reported in the result
+	Err       error // Returned instead of a result when set
+}
+
+var (
+	Allowed = Outcome{Allowed: true} // Allows the request
+	Denied  = Outcome{}              // Denies the request
+)
+
+// Failure returns an Outcome failing with err, e.g. a backends.HealthError
+func Failure(err error) Outcome {
+	return Outcome{Err: err}
+}
+
+// Strategy is a strategies.Strategy whose Allow calls return scripted
+// outcomes in order, counting calls per operation. It never touches the
+// backend, so limiters using it are deterministic.
+//
+// Once the script runs out, Allow returns the default outcome, Allowed unless
+// changed with SetDefault. Peek reports the outcome the next Allow returns,
+// without consuming it. The zero value is ready to use.
+type Strategy struct {
+	calls
+
+	mu       sync.Mutex
+	script   []Outcome
+	fallback *Outcome
+}
+
+// NewStrategy returns a strategy scripted with outcomes
+func NewStrategy(outcomes ...Outcome) *Strategy {
+	s := &Strategy{}
+	s.Script(outcomes...)
+	return s
+}
+
+// Script appends outcomes to be returned by the next Allow calls
+func (s *Strategy) Script(outcomes ...Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, outcomes...)
+}
+
+// SetDefault sets the outcome of Allow calls once the script runs out
+func (s *Strategy) SetDefault(outcome Outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = &outcome
+}
+
+// Config returns a strategy config running s, to use it in a limiter, e.g.
+// ratelimit.WithPrimaryStrategy(s.Config())
+func (s *Strategy) Config() strategies.Config {
+	return &config{strategy: s}
+}
+
+// next returns the outcome of the next Allow, consuming it if consume is set
+func (s *Strategy) next(consume bool) Outcome {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.script) > 0 {
+		outcome := s.script[0]
+		if consume {
+			s.script = s.script[1:]
+		}
+		return outcome
+	}
+	if s.fallback != nil {
+		return *s.fallback
+	}
+	return Allowed
+}
+
+// results converts outcome into strategy results
+func results(outcome Outcome) (strategies.Results, error) {
+	if outcome.Err != nil {
+		return nil, outcome.Err
+	}
+	return strategies.Results{ResultKey: {
+		Allowed:   outcome.Allowed,
+		Remaining: outcome.Remaining,
+		Reset:     time.Now(),
+	}}, nil
+}
+
+// Allow returns the next scripted outcome
+func (s *Strategy) Allow(context.Context, strategies.Config) (strategies.Results, error) {
+	s.add(OpAllow)
+	return results(s.next(true))
+}
+
+// AllowCost is like Allow, whatever the cost
+func (s *Strategy) AllowCost(ctx context.Context, config strategies.Config, _ float64) (strategies.Results, error) {
+	return s.Allow(ctx, config)
+}
+
+// Peek returns the outcome the next Allow returns, without consuming it
+func (s *Strategy) Peek(context.Context, strategies.Config) (strategies.Results, error) {
+	s.add(OpPeek)
+	return results(s.next(false))
+}
+
+// Reset only counts the call
+func (s *Strategy) Reset(context.Context, strategies.Config) error {
+	s.add(OpReset)
+	return nil
+}
+
+// Refund only counts the call
+func (s *Strategy) Refund(context.Context, strategies.Config, int) error {
+	s.add(OpRefund)
+	return nil
+}
+
+// config is the strategies.Config of a Strategy
+type config struct {
+	strategy   *Strategy
+	key        string
+	maxRetries int
+}
+
+func (c *config) Validate() error                          { return nil }
+func (c *config) ID() strategies.ID                        { return StrategyID }
+func (c *config) Capabilities() strategies.CapabilityFlags { return strategies.CapPrimary }
+func (c *config) GetMaxRetries() int                       { return c.maxRetries }
+
+func (c *config) WithKey(key string) strategies.Config {
+	clone := *c
+	clone.key = key
+	return &clone
+}
+
+func (c *config) WithMaxRetries(retries int) strategies.Config {
+	clone := *c
+	clone.maxRetries = retries
+	return &clone
+}
+
+// dispatcher is the registered strategy, running the Strategy of each config
+type dispatcher struct{}
+
+func (dispatcher) Allow(ctx context.Context, sc strategies.Config) (strategies.Results, error) {
+	return sc.(*config).strategy.Allow(ctx, sc)
+}
+
+func (dispatcher) AllowCost(ctx context.Context, sc strategies.Config, cost float64) (strategies.Results, error) {
+	return sc.(*config).strategy.AllowCost(ctx, sc, cost)
+}
+
+func (dispatcher) Peek(ctx context.Context, sc strategies.Config) (strategies.Results, error) {
+	return sc.(*config).strategy.Peek(ctx, sc)
+}
+
+func (dispatcher) Reset(ctx context.Context, sc strategies.Config) error {
+	return sc.(*config).strategy.Reset(ctx, sc)
+}
+
+func (dispatcher) Refund(ctx context.Context, sc strategies.Config, n int) error {
+	return sc.(*config).strategy.Refund(ctx, sc, n)
+}
@@ -0,0 +1,78 @@
+package ratelimittest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ajiwo/ratelimit"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategy(t *testing.T) {
+	errDown := errors.New("down")
+	strategy := NewStrategy(Allowed, Denied, Failure(errDown), Outcome{Allowed: true, Remaining: 4})
+	ctx := t.Context()
+
+	// Peek previews the next outcome without consuming it
+	results, err := strategy.Peek(ctx, strategy.Config())
+	require.NoError(t, err)
+	assert.True(t, results[ResultKey].Allowed)
+
+	var allowed []bool
+	for range 2 {
+		results, err := strategy.Allow(ctx, strategy.Config())
+		require.NoError(t, err)
+		allowed = append(allowed, results[ResultKey].Allowed)
+	}
+	assert.Equal(t, []bool{true, false}, allowed)
+
+	_, err = strategy.Allow(ctx, strategy.Config())
+	require.ErrorIs(t, err, errDown)
+	results, err = strategy.Allow(ctx, strategy.Config())
+	require.NoError(t, err)
+	assert.Equal(t, 4, results[ResultKey].Remaining)
+
+	// The default outcome applies once the script runs out
+	results, err = strategy.Allow(ctx, strategy.Config())
+	require.NoError(t, err)
+	assert.True(t, results[ResultKey].Allowed)
+	strategy.SetDefault(Denied)
+	results, err = strategy.Allow(ctx, strategy.Config())
+	require.NoError(t, err)
+	assert.False(t, results[ResultKey].Allowed)
+
+	assert.Equal(t, 6, strategy.Calls(OpAllow))
+	assert.Equal(t, 1, strategy.Calls(OpPeek))
+}
+
+func TestStrategy_Limiter(t *testing.T) {
+	strategy := NewStrategy(Allowed, Denied, Allowed)
+	strategy.SetDefault(Denied)
+	backend := NewBackend()
+	limiter, err := ratelimit.New(
+		ratelimit.WithBackend(backend),
+		ratelimit.WithPrimaryStrategy(strategy.Config()),
+	)
+	require.NoError(t, err)
+	defer limiter.Close()
+	ctx := t.Context()
+
+	var allowed []bool
+	var results strategies.Results
+	for range 4 {
+		ok, err := limiter.Allow(ctx, ratelimit.AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		allowed = append(allowed, ok)
+	}
+	assert.Equal(t, []bool{true, false, true, false}, allowed)
+	assert.Contains(t, results, ResultKey)
+
+	require.NoError(t, limiter.Refund(ctx, ratelimit.AccessOptions{Key: "user"}))
+	require.NoError(t, limiter.Reset(ctx, ratelimit.AccessOptions{Key: "user"}))
+	assert.Equal(t, 4, strategy.Calls(OpAllow))
+	assert.Equal(t, 1, strategy.Calls(OpRefund))
+	assert.Equal(t, 1, strategy.Calls(OpReset))
+	assert.Zero(t, backend.Calls(OpGet)+backend.Calls(OpCheckAndSet), "the strategy never touches the backend")
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+)
+
+// WithAllowDuplicateRatios keeps fixed window quotas with identical rate
+// ratios, e.g. 1 per minute and 60 per hour for different burst shapes,
+// instead of rejecting the configuration.
+//
+// Each quota is enforced with its own window. Duplicates are logged as a
+// warning through WithLogger when the limiter is created or its strategy
+// updated. The default is strict.
+func WithAllowDuplicateRatios(allow bool) Option {
+	return func(config *Config) error {
+		config.allowDuplicateRatios = allow
+		return nil
+	}
+}
+
+// applyDuplicateRatios lets the fixed window configs of c keep quotas with
+// duplicate rate ratios when WithAllowDuplicateRatios is set, warning about
+// each config that has some
+func (c *Config) applyDuplicateRatios(logger Logger) {
+	if !c.allowDuplicateRatios {
+		return
+	}
+	if logger == nil {
+		logger = nopLogger{}
+	}
+
+	allow := func(sc strategies.Config) strategies.Config {
+		fc, ok := sc.(*fixedwindow.Config)
+		if !ok || fc.AllowDuplicateRatios {
+			return sc
+		}
+		if err := fc.CheckRateRatios(); err != nil {
+			logger.Warn(context.Background(), "ratelimit: keeping fixed window quotas with duplicate rate ratios", "error", err)
+		}
+		cfg := *fc
+		cfg.AllowDuplicateRatios = true
+		return &cfg
+	}
+
+	c.PrimaryConfig = allow(c.PrimaryConfig)
+	if c.SecondaryConfig != nil {
+		c.SecondaryConfig = allow(c.SecondaryConfig)
+	}
+	for i, sc := range c.ExtraSecondaryConfigs {
+		c.ExtraSecondaryConfigs[i] = allow(sc)
+	}
+	for i, ac := range c.AnyConfigs {
+		c.AnyConfigs[i] = allow(ac)
+	}
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// SampledOutResultKey is the result name reported for requests allowed
+// without enforcement, see WithSampleRate
+const SampledOutResultKey = "sampled_out"
+
+// sampler picks the requests the limiter enforces, see WithSampleRate
+type sampler struct {
+	rate  float64
+	byKey bool
+	rng   *rand.Rand // nil uses the global generator
+}
+
+// WithSampleRate enforces limits on only a fraction of requests, from 0 to 1,
+// to ramp up enforcement during a rollout, e.g. 0.1, then 0.5, then 1.
+//
+// Requests left out are allowed without running any strategy, so they never
+// consume quota nor touch the backend; results hold a single
+// SampledOutResultKey entry. Requests are sampled at random, from the
+// WithRandSource source if set, or per key with WithKeySampling. The deny and
+// allow lists are applied first. Peek is never sampled and reports the stored
+// state. A rate of 1, the default, enforces every request and 0 none.
+func WithSampleRate(rate float64) Option {
+	return func(config *Config) error {
+		if !(rate >= 0 && rate <= 1) {
+			return fmt.Errorf("sample rate must be between 0 and 1, got %v", rate)
+		}
+		config.sampleRate = rate
+		config.sampleRateSet = true
+		return nil
+	}
+}
+
+// WithKeySampling makes WithSampleRate pick keys instead of requests: the
+// same dynamic key is always either enforced or not, from a hash of the key.
+// Raising the rate only adds keys, so enforced keys stay enforced.
+func WithKeySampling() Option {
+	return func(config *Config) error {
+		config.keySampling = true
+		return nil
+	}
+}
+
+// newSampler returns the sampler of the config, nil when every request is enforced
+func newSampler(config Config) *sampler {
+	if !config.sampleRateSet || config.sampleRate >= 1 {
+		return nil
+	}
+	return &sampler{rate: config.sampleRate, byKey: config.keySampling, rng: config.rng}
+}
+
+// enforced reports whether the request for dynamicKey is sampled for enforcement
+func (s *sampler) enforced(dynamicKey string) bool {
+	if s == nil {
+		return true
+	}
+	if s.byKey {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(dynamicKey))
+		return float64(mix64(h.Sum64())) < s.rate*math.MaxUint64
+	}
+	if s.rng != nil {
+		return s.rng.Float64() < s.rate
+	}
+	// #nosec: G404 non security context
+	return rand.Float64() < s.rate
+}
+
+// mix64 spreads the bits of an FNV hash, whose high bits barely change across
+// keys differing in their last characters, e.g. "user1" and "user2"
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// bypassDecision applies the deny and allow lists, then sampling, for
+// requests consuming quota.
+//
+// Returns ok false when strategies decide the request.
+func (r *RateLimiter) bypassDecision(options AccessOptions, dynamicKey string) (allowed bool, results strategies.Results, ok bool) {
+	if allowed, results, ok := r.listDecision(options); ok {
+		return allowed, results, true
+	}
+	if !r.sampler.enforced(dynamicKey) {
+		return true, strategies.Results{SampledOutResultKey: {Allowed: true}}, true
+	}
+	return false, nil, false
+}
+
+// bypassed reports whether results come from the allow list, sampling or
+// the distinct keys guard rather than strategies, so no quota was consumed
+func bypassed(results strategies.Results) bool {
+	_, listed := results[AllowListResultKey]
+	_, sampledOut := results[SampledOutResultKey]
+	_, unguarded := results[CardinalityResultKey]
+	return listed || sampledOut || unguarded
+}
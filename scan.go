@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"iter"
+	"strings"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/internal/strategies/composite"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+)
+
+// Scan returns an iterator over the active keys of this limiter and their
+// current results, e.g. for an admin dashboard.
+//
+// Keys are those of ListKeys mapped back to dynamic keys, as passed to Peek
+// after tenant qualification, each yielded once. Their results are read
+// lazily, one Peek per key as the iteration advances, without calling hooks or
+// consuming quota. Keys are listed from the limiter-wide backend, so tiers
+// stored elsewhere with WithStrategyBackend are only seen through the tiers
+// on it.
+//
+// Iteration stops early when ctx is done or on the first error, including
+// backends.ErrKeysNotSupported; call ListKeys to tell these apart from an
+// empty limiter.
+func (r *RateLimiter) Scan(ctx context.Context) iter.Seq2[string, strategies.Results] {
+	return func(yield func(string, strategies.Results) bool) {
+		lister, ok := r.storage().(backends.Lister)
+		if !ok {
+			return
+		}
+		keys, err := lister.Keys(ctx, r.basePrefix+"*")
+		if err != nil {
+			return
+		}
+
+		seen := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			dynamicKey, ok := r.scanKey(key)
+			if !ok || seen[dynamicKey] {
+				continue
+			}
+			seen[dynamicKey] = true
+
+			if ctx.Err() != nil {
+				return
+			}
+			_, results, err := r.peekWithResult(ctx, dynamicKey, "")
+			if err != nil {
+				return
+			}
+			if !yield(dynamicKey, results) {
+				return
+			}
+		}
+	}
+}
+
+// scanKey returns the dynamic key of a storage key of this limiter, without
+// the suffix of composite tiers, or false for keys that hold no strategy
+// state such as penalty lockouts
+func (r *RateLimiter) scanKey(storageKey string) (string, bool) {
+	dynamicKey, ok := strings.CutPrefix(storageKey, r.basePrefix)
+	if !ok {
+		return "", false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	dynamicKey = r.config.cutQuotaSuffix(dynamicKey)
+	switch {
+	case len(r.config.AnyConfigs) > 0:
+		return cutTierSuffix(dynamicKey, composite.AnyKeyMarker)
+	case r.config.SecondaryConfig != nil && r.config.hasStrategyStorage():
+		return cutTierSuffix(dynamicKey, composite.SplitKeyMarker)
+	case r.config.SecondaryConfig != nil:
+		return strings.CutSuffix(dynamicKey, composite.CompositeKeySuffix)
+	case r.penalty != nil && strings.HasSuffix(dynamicKey, ":p"):
+		return "", false
+	}
+	return dynamicKey, true
+}
+
+// cutTierSuffix removes a tier suffix, marker followed by the tier number, from key
+func cutTierSuffix(key, marker string) (string, bool) {
+	i := strings.LastIndex(key, marker)
+	if i < 0 {
+		return "", false
+	}
+	n := key[i+len(marker):]
+	if n == "" || strings.Trim(n, "0123456789") != "" {
+		return "", false
+	}
+	return key[:i], true
+}
+
+// cutQuotaSuffix removes the suffix of an independent fixed window quota, see
+// fixedwindow.QuotaKeyMarker, from key
+func (c *Config) cutQuotaSuffix(key string) string {
+	for _, config := range append([]strategies.Config{c.PrimaryConfig}, c.AnyConfigs...) {
+		fixed, ok := config.(*fixedwindow.Config)
+		if !ok || !fixed.IndependentQuotas {
+			continue
+		}
+		for _, quota := range fixed.Quotas {
+			if base, ok := strings.CutSuffix(key, fixedwindow.QuotaKeyMarker+quota.Name); ok {
+				return base
+			}
+		}
+	}
+	return key
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+// WithBaseKeyScope returns a view of the limiter storing its keys under an
+// extra namespace segment, e.g. "api:billing:" for base key "api" and scope
+// "billing", so the modules of an app can share one configured limiter
+// without their keys colliding.
+//
+// The view shares the backend, strategy, options, Stats counters and
+// background workers of the limiter, so it costs no extra connections or
+// goroutines; scopes can be nested. Closing the limiter closes its views,
+// while closing a view only closes its DenialEvents channel. UpdateStrategy
+// only changes the limiter or view it is called on.
+//
+// Returns an error if the scoped base key is not a valid key.
+func (r *RateLimiter) WithBaseKeyScope(scope string) (*RateLimiter, error) {
+	r.mu.RLock()
+	config := r.config
+	r.mu.RUnlock()
+
+	config.BaseKey += ":" + scope
+	if err := validateKey(scope, "base key scope"); err != nil {
+		return nil, err
+	}
+	if err := validateKey(config.BaseKey, "scoped base key"); err != nil {
+		return nil, err
+	}
+
+	root := r
+	if r.parent != nil {
+		root = r.parent
+	}
+	view := &RateLimiter{
+		config:         config,
+		strategy:       r.strategy,
+		basePrefix:     config.BaseKey + ":",
+		snapshots:      newSnapshotCache(config.peekFallback),
+		limiterOptions: r.limiterOptions,
+		parent:         root,
+	}
+
+	root.viewsMu.Lock()
+	root.views = append(root.views, view)
+	root.viewsMu.Unlock()
+	return view, nil
+}
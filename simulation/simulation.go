@@ -0,0 +1,84 @@
+// Package simulation replays synthetic or recorded arrivals against a
+// strategy config offline, to compare limits before deploying them:
+//
+//	decisions, err := simulation.Replay(ctx, &tokenbucket.Config{Burst: 10, Rate: 1}, arrivals)
+//
+// Every replay runs on its own memory backend, with the strategy deciding at
+// the time of each arrival instead of the current time, so a trace of hours
+// replays in milliseconds and always yields the same decisions.
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Key is the key of the replayed requests
+const Key = "simulation"
+
+// Decision is the outcome of a replayed arrival
+type Decision struct {
+	At      time.Time          // Arrival time
+	Allowed bool               // Whether the strategy allowed the request
+	Results strategies.Results // Per-quota results, e.g. "default"
+}
+
+// Replay decides every arrival with a fresh instance of the strategy of
+// config and returns the decisions in arrival order.
+//
+// Arrivals are requests for a single key and must be in non-decreasing
+// order. The package of the strategy must be imported so that it registers
+// itself, which it is when building its config.
+func Replay(ctx context.Context, config strategies.Config, arrivals []time.Time) ([]Decision, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(arrivals); i++ {
+		if arrivals[i].Before(arrivals[i-1]) {
+			return nil, fmt.Errorf("arrival %d at %v is before the previous one at %v", i, arrivals[i], arrivals[i-1])
+		}
+	}
+
+	backend := memory.NewWithCleanup(0)
+	defer backend.Close()
+	strategy, err := strategies.Create(config.ID(), backend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s strategy: %w", config.ID(), err)
+	}
+	config = config.WithKey(Key)
+
+	decisions := make([]Decision, len(arrivals))
+	for i, at := range arrivals {
+		results, err := strategy.Allow(strategies.ContextWithNow(ctx, at), config)
+		if err != nil {
+			return nil, fmt.Errorf("arrival %d: %w", i, err)
+		}
+		decisions[i] = Decision{At: at, Allowed: allowed(results), Results: results}
+	}
+	return decisions, nil
+}
+
+// Allowed returns the number of allowed decisions
+func Allowed(decisions []Decision) int {
+	n := 0
+	for _, decision := range decisions {
+		if decision.Allowed {
+			n++
+		}
+	}
+	return n
+}
+
+// allowed reports whether no result denies the request
+func allowed(results strategies.Results) bool {
+	for _, result := range results {
+		if result.Denies() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// start is far from the current time, so that decisions depending on the
+// real clock would differ
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// at returns arrivals at the offsets from start
+func at(offsets ...time.Duration) []time.Time {
+	arrivals := make([]time.Time, len(offsets))
+	for i, offset := range offsets {
+		arrivals[i] = start.Add(offset)
+	}
+	return arrivals
+}
+
+func allowedSequence(decisions []Decision) []bool {
+	allowed := make([]bool, len(decisions))
+	for i, decision := range decisions {
+		allowed[i] = decision.Allowed
+	}
+	return allowed
+}
+
+func TestReplay_FixedWindowBurst(t *testing.T) {
+	config := fixedwindow.NewConfig().AddQuota("default", 3, time.Minute).Build()
+	arrivals := at(
+		0, time.Second, 2*time.Second, 3*time.Second, // Burst of 4: the last one is over the limit
+		30*time.Second, 59*time.Second, // Still in the first window
+		time.Minute, time.Minute, time.Minute, time.Minute, // The window started at 0 has ended
+	)
+
+	decisions, err := Replay(context.Background(), config, arrivals)
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{true, true, true, false, false, false, true, true, true, false}, allowedSequence(decisions))
+	assert.Equal(t, 6, Allowed(decisions))
+	assert.Equal(t, arrivals[5], decisions[5].At)
+	assert.WithinDuration(t, start.Add(time.Minute), decisions[5].Results["default"].Reset, 0)
+	assert.Equal(t, strategies.QuotaExhausted, decisions[5].Results["default"].Reason)
+}
+
+func TestReplay_TokenBucketBurst(t *testing.T) {
+	config := &tokenbucket.Config{Burst: 2, Rate: 1}
+	arrivals := at(
+		0, 0, 0, // Burst of 3 drains the bucket of 2
+		500*time.Millisecond,     // Half a token
+		time.Second, time.Second, // One token
+		3*time.Second, 3*time.Second, 3*time.Second, // Refilled to the burst, not 2 seconds' worth on top
+	)
+
+	decisions, err := Replay(context.Background(), config, arrivals)
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{true, true, false, false, true, false, true, true, false}, allowedSequence(decisions))
+	assert.Equal(t, 0, decisions[7].Results["default"].Remaining)
+	assert.Equal(t, start.Add(4*time.Second), decisions[8].Results["default"].Reset)
+}
+
+func TestReplay_Deterministic(t *testing.T) {
+	config := &tokenbucket.Config{Burst: 5, Rate: 0.5}
+	var arrivals []time.Time
+	for i := range 100 {
+		arrivals = append(arrivals, start.Add(time.Duration(i*i)*10*time.Millisecond))
+	}
+
+	first, err := Replay(context.Background(), config, arrivals)
+	require.NoError(t, err)
+	second, err := Replay(context.Background(), config, arrivals)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+}
+
+func TestReplay_Errors(t *testing.T) {
+	_, err := Replay(context.Background(), &tokenbucket.Config{Burst: 2, Rate: 1}, at(time.Second, 0))
+	assert.ErrorContains(t, err, "arrival 1")
+
+	_, err = Replay(context.Background(), &tokenbucket.Config{Burst: 0, Rate: 1}, at(0))
+	assert.ErrorIs(t, err, strategies.ErrInvalidBurst)
+}
@@ -0,0 +1,379 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/approx"
+	"github.com/ajiwo/ratelimit/strategies/concurrency"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/slidingwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/ajiwo/ratelimit/strategies/unique"
+)
+
+// Spec is a declarative, serializable description of a rate limiter.
+//
+// Unlike Config, which holds live backend and strategy instances, a Spec only
+// contains plain values and can be loaded from JSON or YAML files. Use
+// NewFromSpec to build a limiter from it; to hot-reload limits, build a new
+// limiter from the updated Spec and swap it in.
+//
+// Example (JSON):
+//
+//	{
+//	  "base_key": "api",
+//	  "backend": {"type": "redis", "url": "redis://localhost:6379/0"},
+//	  "primary": {"strategy": "fixed_window", "quotas": [{"name": "hourly", "limit": 1000, "window": "1h"}]},
+//	  "secondary": [{"strategy": "token_bucket", "burst": 10, "rate": 5}]
+//	}
+//
+// Any lists the tiers after the primary of WithAnyStrategy and cannot be
+// combined with Secondary. Tiers of an any strategy cannot be advisory nor
+// have their own backend.
+type Spec struct {
+	BaseKey      string         `json:"base_key" yaml:"base_key"`
+	Backend      BackendSpec    `json:"backend" yaml:"backend"`
+	Primary      StrategySpec   `json:"primary" yaml:"primary"`
+	Secondary    []StrategySpec `json:"secondary,omitempty" yaml:"secondary,omitempty"`
+	Any          []StrategySpec `json:"any,omitempty" yaml:"any,omitempty"`
+	MaxRetries   int            `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+	RetryBackoff *BackoffSpec   `json:"retry_backoff,omitempty" yaml:"retry_backoff,omitempty"`
+}
+
+// BackendSpec describes a storage backend registered with backends.Register.
+//
+// For "redis" the URL is a Redis URL, for "postgres" a connection string.
+// The "memory" backend takes no URL. The backend package must be imported
+// so that it registers itself.
+type BackendSpec struct {
+	Type string `json:"type" yaml:"type"`
+	URL  string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// StrategySpec describes a strategy by its canonical name (see strategies.ID.String).
+//
+// Burst and Rate apply to token_bucket, leaky_bucket and gcra, InitialTokens
+// to token_bucket only. Quotas, Location, IndependentQuotas, MaxClockSkew and
+// AllowDuplicateRatios apply to fixed_window only, see fixedwindow.Config;
+// Location is an IANA time zone name such as "Europe/Berlin". Limit and Window
+// apply to sliding_window, approx and unique, Precision to approx only. Max
+// and LeaseTTL apply to concurrency. IdleTTL applies to the strategies
+// implementing strategies.IdleTTLConfig.
+//
+// Advisory makes a secondary strategy advisory, see WithAdvisory. Backend
+// stores the state of the strategy on its own backend, see
+// WithStrategyBackend; unlike there, the limiter built by NewFromSpec owns
+// and closes it.
+type StrategySpec struct {
+	Strategy      string       `json:"strategy" yaml:"strategy"`
+	Burst         int          `json:"burst,omitempty" yaml:"burst,omitempty"`
+	Rate          float64      `json:"rate,omitempty" yaml:"rate,omitempty"`
+	InitialTokens *float64     `json:"initial_tokens,omitempty" yaml:"initial_tokens,omitempty"`
+	Quotas        []QuotaSpec  `json:"quotas,omitempty" yaml:"quotas,omitempty"`
+	Location      string       `json:"location,omitempty" yaml:"location,omitempty"`
+	MaxClockSkew  Duration     `json:"max_clock_skew,omitempty" yaml:"max_clock_skew,omitempty"`
+	Limit         int          `json:"limit,omitempty" yaml:"limit,omitempty"`
+	Window        Duration     `json:"window,omitempty" yaml:"window,omitempty"`
+	Precision     int          `json:"precision,omitempty" yaml:"precision,omitempty"`
+	Max           int          `json:"max,omitempty" yaml:"max,omitempty"`
+	LeaseTTL      Duration     `json:"lease_ttl,omitempty" yaml:"lease_ttl,omitempty"`
+	IdleTTL       Duration     `json:"idle_ttl,omitempty" yaml:"idle_ttl,omitempty"`
+	Advisory      bool         `json:"advisory,omitempty" yaml:"advisory,omitempty"`
+	Backend       *BackendSpec `json:"backend,omitempty" yaml:"backend,omitempty"`
+
+	IndependentQuotas    bool `json:"independent_quotas,omitempty" yaml:"independent_quotas,omitempty"`
+	AllowDuplicateRatios bool `json:"allow_duplicate_ratios,omitempty" yaml:"allow_duplicate_ratios,omitempty"`
+}
+
+// QuotaSpec describes a single fixed window quota, see fixedwindow.Quota
+type QuotaSpec struct {
+	Name      string   `json:"name" yaml:"name"`
+	Limit     int      `json:"limit" yaml:"limit"`
+	Window    Duration `json:"window" yaml:"window"`
+	Aligned   bool     `json:"aligned,omitempty" yaml:"aligned,omitempty"`
+	SoftLimit int      `json:"soft_limit,omitempty" yaml:"soft_limit,omitempty"`
+}
+
+// BackoffSpec describes the retry backoff, see WithRetryBackoff
+type BackoffSpec struct {
+	Initial Duration `json:"initial" yaml:"initial"`
+	Max     Duration `json:"max" yaml:"max"`
+	Jitter  bool     `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+}
+
+// Duration is a time.Duration that is serialized as a string such as "1m30s"
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", text, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// NewFromSpec creates a new rate limiter from a declarative Spec.
+//
+// Additional options are applied after the ones derived from the spec and can
+// be used to override them, e.g. WithMemoryFailover.
+func NewFromSpec(spec Spec, opts ...Option) (*RateLimiter, error) {
+	specOpts, created, err := spec.options()
+	if err != nil {
+		return nil, err
+	}
+
+	limiter, err := New(append(specOpts, opts...)...)
+	if err != nil {
+		closeBackends(created)
+		return nil, err
+	}
+	return limiter, nil
+}
+
+// Validate checks the spec for missing fields and unknown names without
+// creating a backend.
+func (s Spec) Validate() error {
+	if s.Backend.Type == "" {
+		return fmt.Errorf("backend type is required")
+	}
+	if _, err := s.strategyConfigs(); err != nil {
+		return err
+	}
+	if s.RetryBackoff != nil {
+		if err := WithRetryBackoff(time.Duration(s.RetryBackoff.Initial), time.Duration(s.RetryBackoff.Max), s.RetryBackoff.Jitter)(&Config{}); err != nil {
+			return fmt.Errorf("retry backoff: %w", err)
+		}
+	}
+	return nil
+}
+
+// options converts the spec into limiter options, creating the backends
+// last. It returns the created backends, the limiter-wide one first.
+func (s Spec) options() ([]Option, []backends.Backend, error) {
+	if s.Backend.Type == "" {
+		return nil, nil, fmt.Errorf("backend type is required")
+	}
+
+	configs, err := s.strategyConfigs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var opts []Option
+	if s.BaseKey != "" {
+		opts = append(opts, WithBaseKey(s.BaseKey))
+	}
+	if s.MaxRetries != 0 {
+		opts = append(opts, WithMaxRetries(s.MaxRetries))
+	}
+	if s.RetryBackoff != nil {
+		opts = append(opts, WithRetryBackoff(time.Duration(s.RetryBackoff.Initial), time.Duration(s.RetryBackoff.Max), s.RetryBackoff.Jitter))
+	}
+
+	backend, err := s.Backend.create()
+	if err != nil {
+		return nil, nil, err
+	}
+	created := []backends.Backend{backend}
+	tierOptions := func(spec StrategySpec) ([]StrategyOption, error) {
+		var tierOpts []StrategyOption
+		if spec.Advisory {
+			tierOpts = append(tierOpts, WithAdvisory())
+		}
+		if spec.Backend != nil {
+			tierBackend, err := spec.Backend.create()
+			if err != nil {
+				return nil, err
+			}
+			created = append(created, tierBackend)
+			tierOpts = append(tierOpts, WithStrategyBackend(tierBackend))
+		}
+		return tierOpts, nil
+	}
+
+	if len(s.Any) > 0 {
+		return append(opts, WithAnyStrategy(configs...), WithBackend(backend)), created, nil
+	}
+
+	primaryOpts, err := tierOptions(s.Primary)
+	if err != nil {
+		closeBackends(created)
+		return nil, nil, fmt.Errorf("primary strategy: %w", err)
+	}
+	opts = append(opts, WithPrimaryStrategy(configs[0], primaryOpts...))
+	for i, spec := range s.Secondary {
+		secondaryOpts, err := tierOptions(spec)
+		if err != nil {
+			closeBackends(created)
+			return nil, nil, fmt.Errorf("secondary strategy %d: %w", i, err)
+		}
+		opts = append(opts, WithSecondaryStrategy(configs[i+1], secondaryOpts...))
+	}
+	if len(created) > 1 {
+		opts = append(opts, withOwnedStorages(created[1:]))
+	}
+
+	return append(opts, WithBackend(backend)), created, nil
+}
+
+// create creates the backend described by the spec
+func (b BackendSpec) create() (backends.Backend, error) {
+	var backendConfig any
+	if b.URL != "" {
+		backendConfig = b.URL
+	}
+	backend, err := backends.Create(b.Type, backendConfig)
+	if err != nil {
+		return nil, fmt.Errorf("backend %q: %w", b.Type, err)
+	}
+	return backend, nil
+}
+
+// withOwnedStorages makes the limiter close the strategy backends it was
+// given, see StrategySpec.Backend
+func withOwnedStorages(storages []backends.Backend) Option {
+	return func(config *Config) error {
+		config.ownedStorages = storages
+		return nil
+	}
+}
+
+// closeBackends closes backends created for a limiter that failed to build
+func closeBackends(created []backends.Backend) {
+	for _, backend := range created {
+		_ = backend.Close()
+	}
+}
+
+// strategyConfigs builds the primary config followed by the secondary configs,
+// or by the any tier configs
+func (s Spec) strategyConfigs() ([]strategies.Config, error) {
+	if s.Primary.Strategy == "" {
+		return nil, fmt.Errorf("primary strategy is required")
+	}
+
+	primary, err := s.Primary.config()
+	if err != nil {
+		return nil, fmt.Errorf("primary strategy: %w", err)
+	}
+	if s.Primary.Advisory {
+		return nil, fmt.Errorf("primary strategy cannot be advisory")
+	}
+
+	configs := []strategies.Config{primary}
+	for i, spec := range s.Secondary {
+		sc, err := spec.config()
+		if err != nil {
+			return nil, fmt.Errorf("secondary strategy %d: %w", i, err)
+		}
+		configs = append(configs, sc)
+	}
+
+	if len(s.Any) > 0 {
+		if len(s.Secondary) > 0 {
+			return nil, fmt.Errorf("any strategy cannot be combined with a secondary strategy")
+		}
+		if s.Primary.Backend != nil {
+			return nil, fmt.Errorf("primary strategy: backend is not supported with any tiers")
+		}
+	}
+	for i, spec := range s.Any {
+		ac, err := spec.config()
+		if err != nil {
+			return nil, fmt.Errorf("any tier %d: %w", i, err)
+		}
+		if spec.Advisory {
+			return nil, fmt.Errorf("any tier %d cannot be advisory", i)
+		}
+		if spec.Backend != nil {
+			return nil, fmt.Errorf("any tier %d: backend is not supported with any tiers", i)
+		}
+		configs = append(configs, ac)
+	}
+	return configs, nil
+}
+
+// config builds and validates the strategy config described by the spec
+func (s StrategySpec) config() (strategies.Config, error) {
+	id, err := strategies.ParseID(s.Strategy)
+	if err != nil {
+		return nil, fmt.Errorf("unknown strategy %q", s.Strategy)
+	}
+
+	var cfg strategies.Config
+	switch id {
+	case strategies.StrategyTokenBucket:
+		cfg = &tokenbucket.Config{Burst: s.Burst, Rate: s.Rate, InitialTokens: s.InitialTokens}
+	case strategies.StrategyLeakyBucket:
+		cfg = &leakybucket.Config{Burst: s.Burst, Rate: s.Rate}
+	case strategies.StrategyGCRA:
+		cfg = &gcra.Config{Burst: s.Burst, Rate: s.Rate}
+	case strategies.StrategyFixedWindow:
+		if len(s.Quotas) == 0 {
+			return nil, fmt.Errorf("fixed_window requires at least one quota")
+		}
+		fixed := &fixedwindow.Config{
+			MaxClockSkew:         time.Duration(s.MaxClockSkew),
+			IndependentQuotas:    s.IndependentQuotas,
+			AllowDuplicateRatios: s.AllowDuplicateRatios,
+		}
+		for _, q := range s.Quotas {
+			fixed.Quotas = append(fixed.Quotas, fixedwindow.Quota{
+				Name:      q.Name,
+				Limit:     q.Limit,
+				Window:    time.Duration(q.Window),
+				Aligned:   q.Aligned,
+				SoftLimit: q.SoftLimit,
+			})
+		}
+		if s.Location != "" {
+			loc, err := time.LoadLocation(s.Location)
+			if err != nil {
+				return nil, fmt.Errorf("invalid location %q: %w", s.Location, err)
+			}
+			fixed.Location = loc
+		}
+		cfg = fixed
+	case strategies.StrategySlidingWindow:
+		cfg = &slidingwindow.Config{Limit: s.Limit, Window: time.Duration(s.Window)}
+	case strategies.StrategyApprox:
+		cfg = &approx.Config{Limit: s.Limit, Window: time.Duration(s.Window), Precision: s.Precision}
+	case strategies.StrategyUnique:
+		cfg = &unique.Config{Limit: s.Limit, Window: time.Duration(s.Window)}
+	case strategies.StrategyConcurrency:
+		cfg = &concurrency.Config{Max: s.Max, LeaseTTL: time.Duration(s.LeaseTTL)}
+	default:
+		return nil, fmt.Errorf("strategy %q cannot be declared directly", s.Strategy)
+	}
+
+	if s.IdleTTL < 0 {
+		return nil, fmt.Errorf("idle ttl must not be negative, got %v", time.Duration(s.IdleTTL))
+	}
+	if s.IdleTTL > 0 {
+		ic, ok := cfg.(strategies.IdleTTLConfig)
+		if !ok {
+			return nil, fmt.Errorf("strategy %q does not support an idle ttl", s.Strategy)
+		}
+		cfg = ic.WithIdleTTL(time.Duration(s.IdleTTL))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if s.Backend != nil && s.Backend.Type == "" {
+		return nil, fmt.Errorf("backend type is required")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/internal/singleflight"
+)
+
+// Stats is a snapshot of the counters of a limiter since it was created
+type Stats struct {
+	Allowed    uint64 `json:"allowed"`     // Allow and Check calls that allowed the request, including FailOpen
+	Denied     uint64 `json:"denied"`      // Allow and Check calls that denied the request
+	Errors     uint64 `json:"errors"`      // Allow and Check calls whose strategy failed, e.g. with a backend error
+	CASRetries uint64 `json:"cas_retries"` // CheckAndSet attempts that lost to a concurrent writer and were retried or given up
+}
+
+// stats holds the live counters behind Stats
+type stats struct {
+	allowed    atomic.Uint64
+	denied     atomic.Uint64
+	errors     atomic.Uint64
+	casRetries atomic.Uint64
+}
+
+// Stats returns the aggregate counters accumulated since the limiter was created.
+//
+// Use it to size WithMaxRetries and backends: a high CASRetries to Allowed
+// ratio means many requests contend for the same keys. Counters are updated
+// atomically without locks; fields of the snapshot are read one by one, so
+// they may be off by in-flight requests relative to each other.
+func (r *RateLimiter) Stats() Stats {
+	return Stats{
+		Allowed:    r.stats.allowed.Load(),
+		Denied:     r.stats.denied.Load(),
+		Errors:     r.stats.errors.Load(),
+		CASRetries: r.stats.casRetries.Load(),
+	}
+}
+
+// record counts the outcome of an Allow or Check call
+func (s *stats) record(allowed bool, err error) {
+	switch {
+	case err != nil:
+		s.errors.Add(1)
+		if allowed {
+			s.allowed.Add(1)
+		}
+	case allowed:
+		s.allowed.Add(1)
+	default:
+		s.denied.Add(1)
+	}
+}
+
+// statsBackend counts lost CheckAndSet attempts of the strategies and adds
+// the time spent in the backend to the latency of the call, see withLatency.
+// It also coalesces concurrent reads of a key, see withCoalescedReads.
+type statsBackend struct {
+	backends.Backend
+	casRetries *atomic.Uint64
+	logger     Logger
+	reads      singleflight.Group
+}
+
+func (s *statsBackend) Get(ctx context.Context, key string) (string, error) {
+	defer addLatency(ctx, time.Now())
+	if lostCAS, ok := ctx.Value(coalescedReadsKey{}).(*atomic.Bool); ok && !lostCAS.Load() {
+		value, shared, err := s.reads.Do(key, func() (string, error) {
+			return s.Backend.Get(ctx, key)
+		})
+		// A shared error may be due to the context of another caller
+		if !shared || err == nil {
+			return value, err
+		}
+	}
+	return s.Backend.Get(ctx, key)
+}
+
+func (s *statsBackend) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	defer addLatency(ctx, time.Now())
+	return s.Backend.Set(ctx, key, value, expiration)
+}
+
+func (s *statsBackend) Delete(ctx context.Context, key string) error {
+	defer addLatency(ctx, time.Now())
+	return s.Backend.Delete(ctx, key)
+}
+
+func (s *statsBackend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	defer addLatency(ctx, time.Now())
+	ok, err := s.Backend.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+	if !ok && err == nil {
+		s.casRetries.Add(1)
+		s.logger.Debug(ctx, "ratelimit: lost CheckAndSet, retrying", "key", key)
+		if lostCAS, found := ctx.Value(coalescedReadsKey{}).(*atomic.Bool); found {
+			lostCAS.Store(true)
+		}
+	}
+	return ok, err
+}
+
+// IncrementWindows passes through to the inner backend so fixed windows keep
+// their single round trip
+func (s *statsBackend) IncrementWindows(ctx context.Context, key string, quotas []backends.WindowQuota, cost int, now time.Time) (string, bool, error) {
+	incrementer, ok := s.Backend.(backends.WindowIncrementer)
+	if !ok {
+		return "", false, backends.ErrWindowsNotSupported
+	}
+	defer addLatency(ctx, time.Now())
+	return incrementer.IncrementWindows(ctx, key, quotas, cost, now)
+}
+
+// latencyKey is the context key of the backend latency accumulated by a call
+type latencyKey struct{}
+
+// withLatency returns a context accumulating the time statsBackend operations
+// take, read with the returned function
+func withLatency(ctx context.Context) (context.Context, func() time.Duration) {
+	var latency atomic.Int64
+	return context.WithValue(ctx, latencyKey{}, &latency), func() time.Duration {
+		return time.Duration(latency.Load())
+	}
+}
+
+// coalescedReadsKey is the context key of the lost CheckAndSet flag of a call
+type coalescedReadsKey struct{}
+
+// withCoalescedReads returns a context whose statsBackend reads join any
+// concurrent read of the same key, so a stampede on a cold key makes a
+// single backend Get.
+//
+// Each caller still consumes with its own CheckAndSet. Once one is lost, the
+// shared value may predate the winning write, so the reads of the retries go
+// to the backend on their own.
+func withCoalescedReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, coalescedReadsKey{}, new(atomic.Bool))
+}
+
+// addLatency adds the time since start to the latency accumulated in ctx, if any
+func addLatency(ctx context.Context, start time.Time) {
+	if latency, ok := ctx.Value(latencyKey{}).(*atomic.Int64); ok {
+		latency.Add(int64(time.Since(start)))
+	}
+}
@@ -0,0 +1,84 @@
+package approx
+
+import (
+	"context"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/approx/internal"
+)
+
+// Strategy implements approximate fixed window limiting with Morris counters
+type Strategy struct {
+	storage backends.Backend
+}
+
+// New creates a new approximate strategy
+func New(storage backends.Backend) *Strategy {
+	return &Strategy{storage: storage}
+}
+
+// Allow counts the request if the estimated count of the window is below the limit.
+//
+// Remaining is derived from the estimate and is approximate as well.
+func (s *Strategy) Allow(ctx context.Context, config strategies.Config) (strategies.Results, error) {
+	return s.check(ctx, config, internal.TryUpdate)
+}
+
+// Peek reports the estimated state without counting a request
+func (s *Strategy) Peek(ctx context.Context, config strategies.Config) (strategies.Results, error) {
+	return s.check(ctx, config, internal.ReadOnly)
+}
+
+func (s *Strategy) check(ctx context.Context, config strategies.Config, mode internal.AllowMode) (strategies.Results, error) {
+	approxConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	res, err := internal.Allow(ctx, s.storage, approxConfig, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]strategies.Result{
+		"default": {
+			Allowed:   res.Allowed,
+			Limit:     approxConfig.Limit,
+			Remaining: res.Remaining,
+			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.QuotaExhausted),
+		},
+	}, nil
+}
+
+// Reset clears the counter of the key
+func (s *Strategy) Reset(ctx context.Context, config strategies.Config) error {
+	approxConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	return s.storage.Delete(ctx, approxConfig.Key)
+}
+
+// Counter is the stored Morris counter of a window, see Strategy.Inspect
+type Counter = internal.Counter
+
+// Inspect returns the stored approximate counter of the key without modifying it, see strategies.Inspector
+func (s *Strategy) Inspect(ctx context.Context, config strategies.Config) ([]strategies.State, error) {
+	approxConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	counter, found, err := internal.Inspect(ctx, s.storage, approxConfig.Key)
+	if err != nil {
+		return nil, err
+	}
+	state := strategies.State{Strategy: strategies.StrategyApprox}
+	if found {
+		state.Value = counter
+	}
+	return []strategies.State{state}, nil
+}
@@ -0,0 +1,93 @@
+package approx
+
+import (
+	"context"
+	"math"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCountingBackend counts successful CheckAndSet calls
+type writeCountingBackend struct {
+	backends.Backend
+	writes int
+}
+
+func (b *writeCountingBackend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	ok, err := b.Backend.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+	if ok {
+		b.writes++
+	}
+	return ok, err
+}
+
+func TestApprox_WithinErrorBound(t *testing.T) {
+	storage := &writeCountingBackend{Backend: memory.New()}
+	t.Cleanup(func() { storage.Close() })
+	strategy := New(storage)
+	config := &Config{Key: "global", Limit: 5000, Window: time.Hour, Precision: 1024}
+
+	allowed := 0
+	for {
+		results, err := strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		if !results.Default().Allowed {
+			break
+		}
+		allowed++
+	}
+
+	// Within 5 relative standard errors of the limit
+	stdErr := 1 / math.Sqrt(2*float64(config.Precision))
+	assert.InDelta(t, config.Limit, allowed, 5*stdErr*float64(config.Limit))
+	assert.Less(t, storage.writes, allowed/2, "most requests are not written")
+}
+
+func TestApprox_PeekAndReset(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := memory.New()
+		defer storage.Close()
+		strategy := New(storage)
+		config := &Config{Key: "global", Limit: 3, Window: time.Minute, Precision: 1 << 30}
+
+		// With a huge precision, nearly every request increments the counter
+		for range 3 {
+			results, err := strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.True(t, results.Default().Allowed)
+		}
+		for range 2 {
+			results, err := strategy.Peek(t.Context(), config)
+			require.NoError(t, err)
+			assert.Equal(t, strategies.Result{Allowed: false, Limit: 3, Remaining: 0, Reset: time.Now().Add(time.Minute), Reason: strategies.QuotaExhausted}, results.Default())
+		}
+
+		// The next window starts over
+		time.Sleep(time.Minute)
+		results, err := strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		assert.True(t, results.Default().Allowed)
+
+		require.NoError(t, strategy.Reset(t.Context(), config))
+		results, err = strategy.Peek(t.Context(), config)
+		require.NoError(t, err)
+		assert.Equal(t, 3, results.Default().Remaining)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{Limit: 10, Window: time.Second}).Validate())
+	assert.ErrorIs(t, (&Config{Limit: 0, Window: time.Second}).Validate(), strategies.ErrInvalidLimit)
+	assert.ErrorIs(t, (&Config{Limit: 10}).Validate(), strategies.ErrInvalidWindow)
+	assert.Error(t, (&Config{Limit: 10, Window: time.Second, Precision: -1}).Validate())
+
+	assert.Equal(t, DefaultPrecision, (&Config{}).GetPrecision())
+	assert.Equal(t, 11, (&Config{Limit: 10}).GetMaxRetries())
+}
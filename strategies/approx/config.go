@@ -0,0 +1,184 @@
+package approx
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// DefaultPrecision is the precision used when Config.Precision is 0
+const DefaultPrecision = 64
+
+// Config implements the Config interface for approximate fixed window limiting.
+//
+// Each window is counted by a Morris counter, a single small exponent that is
+// only incremented with a probability decreasing as the count grows. Most
+// allowed requests therefore only read the state, trading exactness for far
+// fewer writes on hot, global keys.
+//
+// Accuracy: the estimated count n' of n requests is unbiased with a relative
+// standard error of about 1/sqrt(2*Precision), i.e. ~8.8% with the default
+// precision of 64 and ~2.2% with 1024. Roughly 95% of windows deny between
+// Limit*(1-2e) and Limit*(1+2e) requests, where e is the relative standard
+// error. Use an exact strategy when the limit must never be exceeded.
+type Config struct {
+	Key          string             // Storage key for the counter state
+	Limit        int                // Approximate number of requests per window
+	Window       time.Duration      // Window length
+	Precision    int                // Counter precision, higher is more accurate but writes more; 0 uses DefaultPrecision
+	MaxRetries   int                // Maximum retry attempts for atomic operations, 0 means use default
+	RetryBackoff strategies.Backoff // Delay policy between retry attempts, zero value uses default
+	Rand         *rand.Rand         // Source of the increment sampling, safe for concurrent use; nil uses the global generator
+}
+
+// Validate performs configuration validation for the approximate limiter.
+//
+// Returns an error if any of the following conditions are met:
+//   - Limit <= 0
+//   - Window <= 0
+//   - Precision < 0
+//
+// Note: The Key field is not validated here as it may be set later
+// using WithKey() for dynamic key assignment.
+func (c *Config) Validate() error {
+	if c.Limit <= 0 {
+		return fmt.Errorf("%w: approx limit must be positive, got %d", strategies.ErrInvalidLimit, c.Limit)
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("%w: approx window must be positive, got %v", strategies.ErrInvalidWindow, c.Window)
+	}
+	if c.Precision < 0 {
+		return fmt.Errorf("approx precision cannot be negative, got %d", c.Precision)
+	}
+	return nil
+}
+
+// ID returns the unique identifier for the approximate strategy.
+//
+// This method implements the Config interface and returns StrategyApprox,
+// which is used for logging, debugging, and strategy selection.
+func (c *Config) ID() strategies.ID {
+	return strategies.StrategyApprox
+}
+
+// Capabilities returns the supported capabilities of the approximate strategy.
+//
+// This strategy only supports the primary role. It is not a smoother and
+// does not support multi-quota configurations.
+func (c *Config) Capabilities() strategies.CapabilityFlags {
+	return strategies.CapPrimary
+}
+
+// WithKey returns a copy of the config with the provided key applied.
+//
+// The key is used as-is for storage without modification or prefixing.
+// This allows direct control over storage keys for backend compatibility.
+func (c *Config) WithKey(key string) strategies.Config {
+	cfg := *c
+	cfg.Key = key
+	return &cfg
+}
+
+// WithMaxRetries returns a copy of the config with the provided retry limit applied.
+//
+// This controls the maximum number of retry attempts for atomic operations
+// (CheckAndSet) when storage conflicts occur. Set to 0 to use the default
+// retry limit.
+func (c *Config) WithMaxRetries(retries int) strategies.Config {
+	cfg := *c
+	cfg.MaxRetries = retries
+	return &cfg
+}
+
+// WithRetryBackoff returns a copy of the config with the provided retry backoff applied.
+//
+// This controls the delay between retry attempts for atomic operations
+// (CheckAndSet). A zero Backoff keeps the default feedback-based delay.
+func (c *Config) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	return &cfg
+}
+
+// WithRand returns a copy of the config sampling counter increments and
+// drawing its retry jitter from rng.
+//
+// This method implements strategies.RandConfig; the limiter applies its
+// WithRandSource source with it, making the counts reproducible.
+func (c *Config) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.Rand = rng
+	cfg.RetryBackoff.Rand = rng
+	return &cfg
+}
+
+// GetKey returns the storage key for the counter state.
+//
+// This method implements the internal.Config interface used by the approximate
+// algorithm.
+func (c *Config) GetKey() string {
+	return c.Key
+}
+
+// GetLimit returns the approximate number of requests per window.
+//
+// This method implements the internal.Config interface used by the approximate
+// algorithm.
+func (c *Config) GetLimit() int {
+	return c.Limit
+}
+
+// GetWindow returns the window length.
+//
+// This method implements the internal.Config interface used by the approximate
+// algorithm.
+func (c *Config) GetWindow() time.Duration {
+	return c.Window
+}
+
+// GetPrecision returns the counter precision, DefaultPrecision when unset.
+//
+// This method implements the internal.Config interface used by the approximate
+// algorithm.
+func (c *Config) GetPrecision() int {
+	if c.Precision > 0 {
+		return c.Precision
+	}
+	return DefaultPrecision
+}
+
+// GetMaxRetries returns the configured maximum retry attempts for atomic operations.
+//
+// When MaxRetries is 0 (default), returns Limit + 1 like the fixed window
+// strategy. When MaxRetries > 0, returns the explicitly configured value.
+func (c *Config) GetMaxRetries() int {
+	if c.MaxRetries > 0 {
+		return c.MaxRetries
+	}
+	return c.Limit + 1
+}
+
+// GetRand returns the source of the increment sampling, nil for the global generator.
+//
+// This method implements the internal.Config interface used by the approximate
+// algorithm.
+func (c *Config) GetRand() *rand.Rand {
+	return c.Rand
+}
+
+// GetRetryBackoff returns the configured delay policy between retry attempts.
+//
+// This method implements the internal.Config interface used by the approximate
+// algorithm. A zero Backoff means the default feedback-based delay is used.
+func (c *Config) GetRetryBackoff() strategies.Backoff {
+	return c.RetryBackoff
+}
+
+// SteadyStateRate returns the long-run requests per second allowed, Limit/Window.
+//
+// This method implements strategies.SteadyStateRateConfig.
+func (c *Config) SteadyStateRate() float64 {
+	return float64(c.Limit) / c.Window.Seconds()
+}
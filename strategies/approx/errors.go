@@ -0,0 +1,6 @@
+package approx
+
+import "errors"
+
+// ErrInvalidConfig is returned when the provided config is not of type approx.Config.
+var ErrInvalidConfig = errors.New("approx strategy requires approx.Config")
@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// AllowMode represents the operation mode for `Allow`
+type AllowMode int
+
+const (
+	// ReadOnly only inspects current state without modifications
+	ReadOnly AllowMode = iota
+	// TryUpdate attempts to count the request with retry logic
+	TryUpdate
+)
+
+// Result contains the result of Allow operation
+type Result struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Time
+}
+
+type parameter struct {
+	backoff    strategies.Backoff
+	key        string
+	limit      int
+	maxRetries int
+	now        time.Time
+	precision  int
+	random     func() float64
+	storage    backends.Backend
+	window     time.Duration
+}
+
+// Allow provides a unified implementation for both Allow and Peek operations
+// mode determines whether to perform read-only inspection or actual counting
+func Allow(
+	ctx context.Context,
+	storage backends.Backend,
+	config Config,
+	mode AllowMode,
+
+) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	if mode == ReadOnly {
+		counter, _, err := p.getState(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		estimate := p.estimate(counter)
+		return p.result(counter, estimate < p.limit, estimate), nil
+	}
+
+	return p.count(ctx)
+}
+
+// newParameter builds the operation parameters from config
+func newParameter(ctx context.Context, storage backends.Backend, config Config) *parameter {
+	return &parameter{
+		backoff:    config.GetRetryBackoff(),
+		key:        config.GetKey(),
+		limit:      config.GetLimit(),
+		maxRetries: config.GetMaxRetries(),
+		now:        strategies.Now(ctx),
+		precision:  config.GetPrecision(),
+		random:     randomFloat(config.GetRand()),
+		storage:    storage,
+		window:     config.GetWindow(),
+	}
+}
+
+// randomFloat returns the uniform [0, 1) generator of rng, or the global one when rng is nil
+func randomFloat(rng *rand.Rand) func() float64 {
+	if rng == nil {
+		// #nosec: G404 non security context
+		return rand.Float64
+	}
+	return rng.Float64
+}
+
+// count counts the request if the estimate leaves room for it.
+//
+// Most allowed requests leave the exponent unchanged and are not written,
+// which is what saves writes on busy keys.
+func (p *parameter) count(ctx context.Context) (Result, error) {
+	for attempt := range p.maxRetries {
+		if err := ctx.Err(); err != nil {
+			return Result{}, NewContextCanceledError(err)
+		}
+
+		counter, oldValue, err := p.getState(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+
+		estimate := p.estimate(counter)
+		if estimate >= p.limit {
+			return p.result(counter, false, estimate), nil
+		}
+
+		next, changed := counter.Count(p.precision, p.random())
+		if !changed {
+			return p.result(counter, true, estimate+1), nil
+		}
+
+		beforeCAS := time.Now()
+		success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, encodeState(next), p.expiration(next))
+		if err != nil {
+			return Result{}, NewStateSaveError(err)
+		}
+		if success {
+			return p.result(next, true, estimate+1), nil
+		}
+
+		if err := p.backoff.Wait(ctx, attempt, time.Since(beforeCAS)); err != nil {
+			return Result{}, NewContextCanceledError(err)
+		}
+	}
+
+	return Result{}, ErrConcurrentAccess
+}
+
+// getState returns the counter of the current window and the raw stored value.
+//
+// An expired window is replaced by an empty counter starting at p.now, and so
+// is a window starting more than a window after p.now.
+func (p *parameter) getState(ctx context.Context) (Counter, string, error) {
+	data, err := p.storage.Get(ctx, p.key)
+	if err != nil {
+		return Counter{}, "", NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return Counter{Start: p.now}, "", nil
+	}
+
+	counter, ok := decodeState(data)
+	if !ok {
+		return Counter{}, "", ErrStateParsing
+	}
+	if counter.Start.After(p.now.Add(p.window)) {
+		// Written by a clock that jumped ahead, the window would not end for too long
+		backends.Warn(ctx, "ratelimit: resetting approx state from the future", "key", p.key, "start", counter.Start)
+		counter = Counter{Start: p.now}
+	}
+	if p.now.Sub(counter.Start) >= p.window {
+		counter = Counter{Start: p.now}
+	}
+	return counter, data, nil
+}
+
+// estimate returns the estimated number of requests in the window, rounded.
+//
+// Exponents beyond what an int can count saturate, rather than overflow.
+func (p *parameter) estimate(counter Counter) int {
+	estimate := math.Round(counter.Estimate(p.precision))
+	if estimate >= math.MaxInt {
+		return math.MaxInt
+	}
+	return int(estimate)
+}
+
+// result reports the counter given the estimated number of requests in the window
+func (p *parameter) result(counter Counter, allowed bool, count int) Result {
+	return Result{
+		Allowed:   allowed,
+		Remaining: max(p.limit-count, 0),
+		Reset:     counter.Start.Add(p.window),
+	}
+}
+
+// expiration keeps the state until its window ends
+func (p *parameter) expiration(counter Counter) time.Duration {
+	return max(counter.Start.Add(p.window).Sub(p.now), time.Second)
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"math/rand/v2"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	limit  int
+	window time.Duration
+}
+
+func (c testConfig) GetKey() string                      { return "k" }
+func (c testConfig) GetLimit() int                       { return c.limit }
+func (c testConfig) GetWindow() time.Duration            { return c.window }
+func (c testConfig) GetPrecision() int                   { return 1 << 30 }
+func (c testConfig) GetMaxRetries() int                  { return 1 }
+func (c testConfig) GetRetryBackoff() strategies.Backoff { return strategies.Backoff{} }
+func (c testConfig) GetRand() *rand.Rand                 { return nil }
+
+func TestAllow_ResetsStateFromTheFuture(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		storage := memory.New()
+		defer storage.Close()
+		config := testConfig{limit: 3, window: time.Minute}
+
+		// A full window written by a clock an hour ahead
+		full := Counter{Start: time.Now().Add(time.Hour)}
+		for full.Estimate(config.GetPrecision()) < 3 {
+			full, _ = full.Count(config.GetPrecision(), 0)
+		}
+		require.NoError(t, storage.Set(ctx, "k", encodeState(full), time.Hour))
+
+		result, err := Allow(ctx, storage, config, ReadOnly)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, time.Now().Add(time.Minute), result.Reset)
+
+		result, err = Allow(ctx, storage, config, TryUpdate)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Remaining)
+	})
+}
@@ -0,0 +1,18 @@
+package internal
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+type Config interface {
+	GetKey() string
+	GetLimit() int
+	GetWindow() time.Duration
+	GetPrecision() int
+	GetMaxRetries() int
+	GetRetryBackoff() strategies.Backoff
+	GetRand() *rand.Rand
+}
@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+var (
+	ErrStateParsing     = errors.New("failed to parse approx state: invalid encoding")
+	ErrConcurrentAccess = fmt.Errorf("failed to update approx state after max attempts due to concurrent access: %w", strategies.ErrMaxRetriesExceeded)
+)
+
+func NewStateRetrievalError(err error) error {
+	return fmt.Errorf("failed to get approx state: %w", err)
+}
+
+func NewStateSaveError(err error) error {
+	return fmt.Errorf("failed to save approx state: %w", err)
+}
+
+func NewContextCanceledError(err error) error {
+	return fmt.Errorf("context canceled or timed out: %w", err)
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/utils/builderpool"
+)
+
+// Counter is a Morris counter for one fixed window.
+//
+// Instead of the count n it stores an exponent c that grows by one with
+// probability (1+1/a)^-c per event, where a is the precision. The estimate
+// a*((1+1/a)^c - 1) is unbiased with variance n*(n-1)/(2a).
+type Counter struct {
+	Exponent int
+	Start    time.Time
+}
+
+// Estimate returns the approximate number of counted events
+func (c Counter) Estimate(precision int) float64 {
+	a := float64(precision)
+	return a * (math.Pow(1+1/a, float64(c.Exponent)) - 1)
+}
+
+// IncrementProbability returns the probability that the next event increments the exponent
+func (c Counter) IncrementProbability(precision int) float64 {
+	return math.Pow(1+1/float64(precision), -float64(c.Exponent))
+}
+
+// Count counts one event given a uniform random value in [0, 1).
+//
+// Returns false when the exponent, and so the stored state, is unchanged.
+func (c Counter) Count(precision int, random float64) (Counter, bool) {
+	if random >= c.IncrementProbability(precision) {
+		return c, false
+	}
+	c.Exponent++
+	return c, true
+}
+
+// encodeState serializes the counter into a compact ASCII format:
+// 71|exponent|start_unix_nano
+func encodeState(c Counter) string {
+	sb := builderpool.Get()
+	defer builderpool.Put(sb)
+
+	sb.WriteString("71|")
+	sb.WriteString(strconv.Itoa(c.Exponent))
+	sb.WriteByte('|')
+	sb.WriteString(strconv.FormatInt(c.Start.UnixNano(), 10))
+	return sb.String()
+}
+
+func decodeState(s string) (Counter, bool) {
+	if len(s) < 3 || s[:3] != "71|" {
+		return Counter{}, false
+	}
+
+	exponentStr, startStr, ok := strings.Cut(s[3:], "|")
+	if !ok {
+		return Counter{}, false
+	}
+	exponent, err := strconv.Atoi(exponentStr)
+	if err != nil || exponent < 0 {
+		return Counter{}, false
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return Counter{}, false
+	}
+	return Counter{Exponent: exponent, Start: time.Unix(0, start)}, true
+}
+
+// Inspect returns the stored state of key without modifying it, or false for a fresh key
+func Inspect(ctx context.Context, storage backends.Backend, key string) (Counter, bool, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return Counter{}, false, NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return Counter{}, false, nil
+	}
+	counter, ok := decodeState(data)
+	if !ok {
+		return Counter{}, false, ErrStateParsing
+	}
+	return counter, true, nil
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter_ErrorBound(t *testing.T) {
+	const n = 20000
+	const trials = 200
+
+	for _, precision := range []int{64, 1024} {
+		// Documented relative standard error: 1/sqrt(2*precision)
+		stdErr := 1 / math.Sqrt(2*float64(precision))
+
+		var sum float64
+		within2 := 0
+		for trial := range trials {
+			rng := rand.New(rand.NewPCG(uint64(precision), uint64(trial)))
+			var c Counter
+			for range n {
+				c, _ = c.Count(precision, rng.Float64())
+			}
+			estimate := c.Estimate(precision)
+			relErr := math.Abs(estimate-n) / n
+
+			sum += estimate
+			assert.Less(t, relErr, 5*stdErr, "precision %d trial %d estimated %.0f", precision, trial, estimate)
+			if relErr <= 2*stdErr {
+				within2++
+			}
+		}
+
+		// Unbiased: the mean converges to the true count
+		mean := sum / trials
+		assert.InDelta(t, n, mean, 3*stdErr*n/math.Sqrt(trials), "precision %d", precision)
+		assert.GreaterOrEqual(t, within2, trials*90/100, "precision %d", precision)
+	}
+}
+
+func TestCounter_FewWrites(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var c Counter
+	writes := 0
+	for range 100000 {
+		var changed bool
+		c, changed = c.Count(64, rng.Float64())
+		if changed {
+			writes++
+		}
+	}
+	assert.Equal(t, c.Exponent, writes)
+	assert.Less(t, writes, 1000, "a write per exponent step, not per event")
+}
+
+func TestState_EncodeDecode(t *testing.T) {
+	c := Counter{Exponent: 42, Start: time.Unix(0, 1761884055342794596)}
+	encoded := encodeState(c)
+	assert.Equal(t, "71|42|1761884055342794596", encoded)
+
+	decoded, ok := decodeState(encoded)
+	require.True(t, ok)
+	assert.Equal(t, c.Exponent, decoded.Exponent)
+	assert.True(t, c.Start.Equal(decoded.Start))
+
+	for _, invalid := range []string{"", "71|", "71|1", "71|-1|5", "71|x|5", "71|1|x", "23|1|5"} {
+		_, ok := decodeState(invalid)
+		assert.False(t, ok, "%q", invalid)
+	}
+}
+
+func FuzzDecodeState(f *testing.F) {
+	f.Add("71|5|1761884055342794596")
+	f.Add("71|0|0")
+	f.Add("71|-1|0")
+	f.Add("71|100000|0")
+	f.Add("71|99999999999999999999|0")
+	f.Add("71|1|")
+	f.Add("71|\xff|0")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		counter, ok := decodeState(s)
+		if !ok {
+			return
+		}
+		p := &parameter{limit: 10, precision: 64}
+		if estimate := p.estimate(counter); estimate < 0 {
+			t.Fatalf("decoded negative estimate %d from %q", estimate, s)
+		}
+		decoded, ok := decodeState(encodeState(counter))
+		if !ok || decoded.Exponent != counter.Exponent || !decoded.Start.Equal(counter.Start) {
+			t.Fatalf("re-encoding %q does not round trip", s)
+		}
+	})
+}
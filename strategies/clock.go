@@ -0,0 +1,27 @@
+package strategies
+
+import (
+	"context"
+	"time"
+)
+
+// nowKey is the context key of the time set by ContextWithNow
+type nowKey struct{}
+
+// ContextWithNow returns a context making strategies decide as if the current
+// time were now, e.g. to replay recorded arrivals offline with the simulation
+// package.
+//
+// Only the decision uses it: backends still expire stored state by their own
+// clock, and retry backoff still sleeps in real time.
+func ContextWithNow(ctx context.Context, now time.Time) context.Context {
+	return context.WithValue(ctx, nowKey{}, now)
+}
+
+// Now returns the time set by ContextWithNow, or the current time
+func Now(ctx context.Context) time.Time {
+	if now, ok := ctx.Value(nowKey{}).(time.Time); ok {
+		return now
+	}
+	return time.Now()
+}
@@ -1,8 +1,12 @@
 package strategies
 
 import (
+	"context"
+	"math"
 	"math/rand/v2"
 	"time"
+
+	"github.com/ajiwo/ratelimit/utils"
 )
 
 const (
@@ -18,6 +22,9 @@ const (
 	TTLFactor  = 5
 )
 
+// maxExpirationSeconds is the longest expiration representable as a time.Duration in whole seconds
+const maxExpirationSeconds = math.MaxInt64 / int64(time.Second)
+
 // CalcExpiration calculates an appropriate expiration time for storage operations
 //
 // based on capacity and rate, with a minimum of 1 second
@@ -27,15 +34,55 @@ func CalcExpiration(capacity int, rate float64) time.Duration {
 	if expirationSeconds < 1 {
 		expirationSeconds = 1
 	}
+	// Tiny rates would overflow time.Duration into a negative TTL
+	if expirationSeconds >= float64(maxExpirationSeconds) {
+		return time.Duration(maxExpirationSeconds) * time.Second
+	}
 	return time.Duration(expirationSeconds) * time.Second
 }
 
+// CalcIdleExpiration is CalcExpiration for state kept idleTTL after its last
+// write, see IdleTTLConfig: the time until capacity refills at rate, or
+// idleTTL if longer, with a minimum of 1 second. A zero idleTTL returns
+// CalcExpiration(capacity, rate).
+func CalcIdleExpiration(capacity int, rate float64, idleTTL time.Duration) time.Duration {
+	if idleTTL <= 0 {
+		return CalcExpiration(capacity, rate)
+	}
+	refillSeconds := float64(capacity) / rate
+	if refillSeconds >= float64(maxExpirationSeconds) {
+		return time.Duration(maxExpirationSeconds) * time.Second
+	}
+	return IdleExpiration(time.Duration(refillSeconds*float64(time.Second)), idleTTL)
+}
+
+// IdleExpiration returns the expiration of state that is equivalent to a
+// fresh key after fresh: fresh, or idleTTL if longer, with a minimum of 1
+// second. A zero idleTTL returns fresh times TTLFactor, the default margin.
+func IdleExpiration(fresh, idleTTL time.Duration) time.Duration {
+	expiration := max(fresh, idleTTL)
+	if idleTTL <= 0 {
+		expiration = fresh * TTLFactor
+	}
+	return max(expiration, time.Second)
+}
+
+// ValidRate reports whether rate is a usable per-second rate: positive and finite
+func ValidRate(rate float64) bool {
+	return rate > 0 && !math.IsInf(rate, 1)
+}
+
 // NextDelay calculates the next delay.
 //
 // It produces a sawtooth-like pattern of exponential backoff for constant feedback.
 // In practice, feedback is random, measured from the time before and after of the
 // last failed CheckAndSet operation.
 func NextDelay(attempt int, feedback time.Duration) time.Duration {
+	return nextDelay(attempt, feedback, nil)
+}
+
+// nextDelay is NextDelay with the jitter drawn from rng, nil uses the global generator
+func nextDelay(attempt int, feedback time.Duration, rng *rand.Rand) time.Duration {
 	// Clamp feedback duration to prevent very short delays that could overwhelm the system
 	// The 30ns lower bound reduces randomness for sub-30ns feedback values but prevents
 	// system overload from rapid retries
@@ -49,9 +96,82 @@ func NextDelay(attempt int, feedback time.Duration) time.Duration {
 	delay := (feedback * mult) << shift
 
 	half := delay >> 1
-	// #nosec: G404 non security context
-	jitter := time.Duration(rand.Int64N(int64(half)))
+	jitter := time.Duration(int64N(rng, int64(half)))
 	// fmt.Printf("attempt=%d feedback=%v delay=%v half=%v jitter=%v\n", attempt, feedback, delay, half, jitter)
 
 	return half + jitter
 }
+
+// int64N returns a random int64 in [0, n) from rng, or from the global
+// generator when rng is nil
+func int64N(rng *rand.Rand, n int64) int64 {
+	if rng == nil {
+		// #nosec: G404 non security context
+		return rand.Int64N(n)
+	}
+	return rng.Int64N(n)
+}
+
+// Backoff configures the delay between CheckAndSet retry attempts.
+//
+// The zero value keeps the default feedback-based delay computed by NextDelay.
+// When Initial is set, the delay doubles on every attempt starting from Initial
+// and is capped at Max. With Jitter enabled, each delay is randomized within
+// [delay/2, delay) to spread out contending writers.
+type Backoff struct {
+	Initial time.Duration // Delay before the first retry, 0 uses the default feedback-based delay
+	Max     time.Duration // Upper bound for a single delay, 0 means no upper bound
+	Jitter  bool          // Randomize each delay to reduce lock-step retries
+	Rand    *rand.Rand    // Source of the jitter, safe for concurrent use; nil uses the global generator
+}
+
+// IsZero reports whether the backoff is unset, keeping the default delay.
+//
+// Rand alone doesn't change the delay, only where its jitter comes from.
+func (b Backoff) IsZero() bool {
+	return b.Initial <= 0
+}
+
+// Delay returns the delay before the retry following the given attempt (0-based).
+//
+// For a zero Backoff it delegates to NextDelay using the feedback duration.
+func (b Backoff) Delay(attempt int, feedback time.Duration) time.Duration {
+	if b.IsZero() {
+		return nextDelay(attempt, feedback, b.Rand)
+	}
+
+	// Cap the shift to avoid overflowing time.Duration on large attempt counts
+	delay := b.Initial << min(attempt, 32)
+	if delay <= 0 || (b.Max > 0 && delay > b.Max) {
+		delay = b.Max
+	}
+	if delay <= 0 {
+		delay = b.Initial
+	}
+
+	if b.Jitter {
+		half := delay >> 1
+		if half > 0 {
+			return half + time.Duration(int64N(b.Rand, int64(half)))
+		}
+	}
+	return delay
+}
+
+// Wait blocks for the delay before the retry following the given attempt.
+//
+// A zero Backoff keeps the historical behavior of short sleeps bypassing the context.
+// A configured Backoff always honors the context, and fails fast with
+// context.DeadlineExceeded when the deadline would pass before the delay elapses,
+// so the total time spent retrying never exceeds the caller's deadline.
+func (b Backoff) Wait(ctx context.Context, attempt int, feedback time.Duration) error {
+	delay := b.Delay(attempt, feedback)
+	if b.IsZero() {
+		return utils.SleepOrWait(ctx, delay, 500*time.Millisecond)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return context.DeadlineExceeded
+	}
+	return utils.SleepOrWait(ctx, delay, 0)
+}
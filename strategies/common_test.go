@@ -1,6 +1,9 @@
 package strategies
 
 import (
+	"context"
+	"math"
+	"math/rand/v2"
 	"testing"
 	"time"
 
@@ -19,6 +22,36 @@ func TestCalcExpiration(t *testing.T) {
 	// Non-integer seconds should truncate after multiplication logic: (3/2)*5 = 7.5 -> 7s
 	d = CalcExpiration(3, 2)
 	assert.Equal(t, 7*time.Second, d)
+
+	// Tiny rates are capped instead of overflowing into a negative TTL
+	d = CalcExpiration(10, math.SmallestNonzeroFloat64)
+	assert.Equal(t, time.Duration(maxExpirationSeconds)*time.Second, d)
+	assert.Positive(t, d)
+}
+
+func TestCalcIdleExpiration(t *testing.T) {
+	// No idle TTL keeps the default: (10/5)*5 = 10 seconds
+	assert.Equal(t, 10*time.Second, CalcIdleExpiration(10, 5, 0))
+
+	// Idle TTL longer than the refill time of 2 seconds
+	assert.Equal(t, 3*time.Second, CalcIdleExpiration(10, 5, 3*time.Second))
+
+	// Never before the bucket refills
+	assert.Equal(t, 2*time.Second, CalcIdleExpiration(10, 5, time.Millisecond))
+
+	// Very small -> min 1 second
+	assert.Equal(t, time.Second, CalcIdleExpiration(1, 1000, time.Millisecond))
+
+	// Tiny rates are capped
+	assert.Equal(t, time.Duration(maxExpirationSeconds)*time.Second,
+		CalcIdleExpiration(10, math.SmallestNonzeroFloat64, time.Second))
+}
+
+func TestIdleExpiration(t *testing.T) {
+	assert.Equal(t, 10*time.Second, IdleExpiration(2*time.Second, 0))
+	assert.Equal(t, 3*time.Second, IdleExpiration(2*time.Second, 3*time.Second))
+	assert.Equal(t, 2*time.Second, IdleExpiration(2*time.Second, time.Millisecond))
+	assert.Equal(t, time.Second, IdleExpiration(time.Millisecond, time.Millisecond))
 }
 
 func TestNextDelay(t *testing.T) {
@@ -150,3 +183,81 @@ func TestNextDelay_SawtoothPattern(t *testing.T) {
 	actualRatio := float64(avgDelays[8]) / float64(avgDelays[0])
 	assert.InDelta(t, expectedRatio, actualRatio, 2.0, "attempt 8 should be approximately 9x attempt 0")
 }
+
+func TestBackoff_Delay(t *testing.T) {
+	t.Run("zero value uses feedback delay", func(t *testing.T) {
+		var b Backoff
+		assert.True(t, b.IsZero())
+		d := b.Delay(0, 100*time.Millisecond)
+		assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+		assert.Less(t, d, 100*time.Millisecond)
+	})
+
+	t.Run("exponential growth capped at max", func(t *testing.T) {
+		b := Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond}
+		assert.False(t, b.IsZero())
+		assert.Equal(t, 1*time.Millisecond, b.Delay(0, 0))
+		assert.Equal(t, 2*time.Millisecond, b.Delay(1, 0))
+		assert.Equal(t, 4*time.Millisecond, b.Delay(2, 0))
+		assert.Equal(t, 8*time.Millisecond, b.Delay(3, 0))
+		assert.Equal(t, 10*time.Millisecond, b.Delay(4, 0))
+		assert.Equal(t, 10*time.Millisecond, b.Delay(1000, 0))
+	})
+
+	t.Run("no max never overflows", func(t *testing.T) {
+		b := Backoff{Initial: time.Second}
+		assert.Positive(t, b.Delay(100, 0))
+	})
+
+	t.Run("jitter stays within half-open range", func(t *testing.T) {
+		b := Backoff{Initial: 8 * time.Millisecond, Max: 8 * time.Millisecond, Jitter: true}
+		for range 100 {
+			d := b.Delay(3, 0)
+			assert.GreaterOrEqual(t, d, 4*time.Millisecond)
+			assert.Less(t, d, 8*time.Millisecond)
+		}
+	})
+
+	t.Run("seeded rand repeats the jitter", func(t *testing.T) {
+		delays := func(b Backoff) []time.Duration {
+			b.Rand = rand.New(rand.NewPCG(1, 2))
+			out := make([]time.Duration, 10)
+			for i := range out {
+				out[i] = b.Delay(3, 100*time.Millisecond)
+			}
+			return out
+		}
+		for _, b := range []Backoff{{}, {Initial: 8 * time.Millisecond, Max: 8 * time.Millisecond, Jitter: true}} {
+			first := delays(b)
+			assert.Equal(t, first, delays(b))
+			assert.NotEqual(t, first[0], first[1], "still random within a run")
+		}
+	})
+}
+
+func TestBackoff_Wait(t *testing.T) {
+	t.Run("sleeps for the configured delay", func(t *testing.T) {
+		b := Backoff{Initial: 5 * time.Millisecond, Max: 5 * time.Millisecond}
+		start := time.Now()
+		assert.NoError(t, b.Wait(t.Context(), 0, 0))
+		assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+	})
+
+	t.Run("fails fast when deadline is shorter than delay", func(t *testing.T) {
+		b := Backoff{Initial: time.Second, Max: time.Second}
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := b.Wait(ctx, 0, 0)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("honors cancellation", func(t *testing.T) {
+		b := Backoff{Initial: time.Second, Max: time.Second}
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		assert.ErrorIs(t, b.Wait(ctx, 0, 0), context.Canceled)
+	})
+}
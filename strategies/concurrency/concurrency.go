@@ -0,0 +1,99 @@
+package concurrency
+
+import (
+	"context"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/concurrency/internal"
+)
+
+// Strategy implements concurrency limiting with expiring leases
+type Strategy struct {
+	storage backends.Backend
+}
+
+// New creates a new concurrency strategy
+func New(storage backends.Backend) *Strategy {
+	return &Strategy{storage: storage}
+}
+
+// Allow acquires a lease if fewer than Max are active.
+//
+// Release the lease with Refund once the request is done.
+func (s *Strategy) Allow(ctx context.Context, config strategies.Config) (strategies.Results, error) {
+	return s.check(ctx, config, internal.TryUpdate)
+}
+
+// Peek reports the active leases without acquiring one
+func (s *Strategy) Peek(ctx context.Context, config strategies.Config) (strategies.Results, error) {
+	return s.check(ctx, config, internal.ReadOnly)
+}
+
+func (s *Strategy) check(ctx context.Context, config strategies.Config, mode internal.AllowMode) (strategies.Results, error) {
+	concurrencyConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	res, err := internal.Allow(ctx, s.storage, concurrencyConfig, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]strategies.Result{
+		"default": {
+			Allowed:   res.Allowed,
+			Limit:     concurrencyConfig.Max,
+			Remaining: res.Remaining,
+			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.ConcurrencyCap),
+		},
+	}, nil
+}
+
+// Reset releases all leases of the key
+func (s *Strategy) Reset(ctx context.Context, config strategies.Config) error {
+	concurrencyConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	return s.storage.Delete(ctx, concurrencyConfig.Key)
+}
+
+// Refund releases n leases acquired by earlier Allow calls
+func (s *Strategy) Refund(ctx context.Context, config strategies.Config, n int) error {
+	concurrencyConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	return internal.Release(ctx, s.storage, concurrencyConfig, n)
+}
+
+// Release releases n leases acquired by earlier Allow calls, the same as Refund
+func (s *Strategy) Release(ctx context.Context, config strategies.Config, n int) error {
+	return s.Refund(ctx, config, n)
+}
+
+// Leases is the stored set of active leases, see Strategy.Inspect
+type Leases = internal.Leases
+
+// Inspect returns the stored leases of the key without modifying it, see strategies.Inspector
+func (s *Strategy) Inspect(ctx context.Context, config strategies.Config) ([]strategies.State, error) {
+	concurrencyConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	leases, found, err := internal.Inspect(ctx, s.storage, concurrencyConfig.Key)
+	if err != nil {
+		return nil, err
+	}
+	state := strategies.State{Strategy: strategies.StrategyConcurrency}
+	if found {
+		state.Value = leases
+	}
+	return []strategies.State{state}, nil
+}
@@ -0,0 +1,123 @@
+package concurrency
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrency_AcquireAndRelease(t *testing.T) {
+	storage := memory.New()
+	t.Cleanup(func() { storage.Close() })
+	strategy := New(storage)
+	config := &Config{Key: "leases", Max: 2, LeaseTTL: time.Minute}
+
+	for i := range 2 {
+		results, err := strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		usage := config.Usage(results.Default())
+		assert.True(t, usage.Allowed)
+		assert.Equal(t, i+1, usage.Active)
+		assert.Equal(t, 2, usage.Max)
+	}
+
+	results, err := strategy.Allow(t.Context(), config)
+	require.NoError(t, err)
+	assert.False(t, results.Default().Allowed, "all leases are taken")
+
+	// Peek reports the active count without acquiring
+	for range 3 {
+		results, err = strategy.Peek(t.Context(), config)
+		require.NoError(t, err)
+		assert.Equal(t, 2, config.Usage(results.Default()).Active)
+	}
+
+	require.NoError(t, strategy.Refund(t.Context(), config, 1))
+	results, err = strategy.Peek(t.Context(), config)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Allowed: true, Active: 1, Max: 2, Reset: results.Default().Reset}, config.Usage(results.Default()))
+
+	results, err = strategy.Allow(t.Context(), config)
+	require.NoError(t, err)
+	assert.True(t, results.Default().Allowed)
+
+	// Releasing more leases than active clamps at zero
+	require.NoError(t, strategy.Refund(t.Context(), config, 5))
+	results, err = strategy.Peek(t.Context(), config)
+	require.NoError(t, err)
+	assert.Equal(t, 0, config.Usage(results.Default()).Active)
+}
+
+func TestConcurrency_LeakedLeaseReclaimed(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := memory.New()
+		defer storage.Close()
+		strategy := New(storage)
+		config := &Config{Key: "leases", Max: 1, LeaseTTL: 30 * time.Second}
+
+		// The holder of this lease dies without releasing it
+		results, err := strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		require.True(t, results.Default().Allowed)
+		leaseExpiry := time.Now().Add(30 * time.Second)
+
+		time.Sleep(10 * time.Second)
+		results, err = strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		assert.False(t, results.Default().Allowed)
+		assert.Equal(t, leaseExpiry, results.Default().Reset, "denied until the leaked lease expires")
+
+		time.Sleep(20 * time.Second)
+		results, err = strategy.Peek(t.Context(), config)
+		require.NoError(t, err)
+		assert.Equal(t, 0, config.Usage(results.Default()).Active, "the leaked lease no longer counts")
+
+		results, err = strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		assert.True(t, results.Default().Allowed)
+	})
+}
+
+func TestConcurrency_ReleaseKeepsOldestLease(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := memory.New()
+		defer storage.Close()
+		strategy := New(storage)
+		config := &Config{Key: "leases", Max: 2, LeaseTTL: 30 * time.Second}
+
+		// A leaked lease, then one that is released properly
+		_, err := strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		time.Sleep(10 * time.Second)
+		_, err = strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		require.NoError(t, strategy.Refund(t.Context(), config, 1))
+
+		// The remaining lease is the first one, reclaimed 30s after it was acquired
+		time.Sleep(20 * time.Second)
+		results, err := strategy.Peek(t.Context(), config)
+		require.NoError(t, err)
+		assert.Equal(t, 0, config.Usage(results.Default()).Active)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{Max: 1, LeaseTTL: time.Second}).Validate())
+	assert.ErrorIs(t, (&Config{Max: 0, LeaseTTL: time.Second}).Validate(), strategies.ErrInvalidLimit)
+	assert.ErrorIs(t, (&Config{Max: 1}).Validate(), strategies.ErrInvalidWindow)
+}
+
+func TestConfig_Properties(t *testing.T) {
+	config := &Config{Max: 5, LeaseTTL: time.Second}
+	assert.Equal(t, strategies.StrategyConcurrency, config.ID())
+	assert.True(t, config.Capabilities().Has(strategies.CapPrimary))
+	assert.True(t, config.Capabilities().Has(strategies.CapSecondary))
+	assert.Equal(t, 6, config.GetMaxRetries())
+	assert.Equal(t, "k", config.WithKey("k").(*Config).Key)
+	assert.Empty(t, config.Key, "WithKey returns a copy")
+}
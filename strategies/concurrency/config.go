@@ -0,0 +1,162 @@
+package concurrency
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Config implements the Config interface for concurrency limiting.
+//
+// Concurrency limiting caps the number of requests in flight instead of the
+// request rate. Allow acquires a lease and Refund releases it once the work
+// is done. Every lease expires after LeaseTTL, so leases leaked by a crashed
+// process are reclaimed instead of blocking the key forever.
+type Config struct {
+	Key          string             // Storage key for the lease state
+	Max          int                // Maximum number of active leases
+	LeaseTTL     time.Duration      // Lifetime of a lease that is never released
+	MaxRetries   int                // Maximum retry attempts for atomic operations, 0 means use default
+	RetryBackoff strategies.Backoff // Delay policy between retry attempts, zero value uses default
+}
+
+// Validate performs configuration validation for the concurrency limiter.
+//
+// Returns an error if any of the following conditions are met:
+//   - Max <= 0
+//   - LeaseTTL <= 0
+//
+// Note: The Key field is not validated here as it may be set later
+// using WithKey() for dynamic key assignment.
+func (c *Config) Validate() error {
+	if c.Max <= 0 {
+		return fmt.Errorf("%w: concurrency max must be positive, got %d", strategies.ErrInvalidLimit, c.Max)
+	}
+	if c.LeaseTTL <= 0 {
+		return fmt.Errorf("%w: concurrency lease TTL must be positive, got %v", strategies.ErrInvalidWindow, c.LeaseTTL)
+	}
+	return nil
+}
+
+// ID returns the unique identifier for the concurrency strategy.
+//
+// This method implements the Config interface and returns StrategyConcurrency,
+// which is used for logging, debugging, and strategy selection.
+func (c *Config) ID() strategies.ID {
+	return strategies.StrategyConcurrency
+}
+
+// Capabilities returns the supported capabilities of the concurrency strategy.
+//
+// This strategy supports primary and secondary roles, e.g. a fixed window
+// primary with a concurrency secondary, but does not support multi-quota
+// configurations.
+func (c *Config) Capabilities() strategies.CapabilityFlags {
+	return strategies.CapPrimary | strategies.CapSecondary
+}
+
+// WithKey returns a copy of the config with the provided key applied.
+//
+// The key is used as-is for storage without modification or prefixing.
+// This allows direct control over storage keys for backend compatibility.
+func (c *Config) WithKey(key string) strategies.Config {
+	cfg := *c
+	cfg.Key = key
+	return &cfg
+}
+
+// WithMaxRetries returns a copy of the config with the provided retry limit applied.
+//
+// This controls the maximum number of retry attempts for atomic operations
+// (CheckAndSet) when storage conflicts occur. Set to 0 to use the default
+// retry limit.
+func (c *Config) WithMaxRetries(retries int) strategies.Config {
+	cfg := *c
+	cfg.MaxRetries = retries
+	return &cfg
+}
+
+// WithRetryBackoff returns a copy of the config with the provided retry backoff applied.
+//
+// This controls the delay between retry attempts for atomic operations
+// (CheckAndSet). A zero Backoff keeps the default feedback-based delay.
+func (c *Config) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	return &cfg
+}
+
+// WithRand returns a copy of the config drawing its retry jitter from rng.
+//
+// This method implements strategies.RandConfig and sets RetryBackoff.Rand,
+// keeping the rest of the backoff.
+func (c *Config) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff.Rand = rng
+	return &cfg
+}
+
+// GetKey returns the storage key for the lease state.
+//
+// This method implements the internal.Config interface used by the concurrency
+// algorithm.
+func (c *Config) GetKey() string {
+	return c.Key
+}
+
+// GetMax returns the maximum number of active leases.
+//
+// This method implements the internal.Config interface used by the concurrency
+// algorithm.
+func (c *Config) GetMax() int {
+	return c.Max
+}
+
+// GetLeaseTTL returns the lifetime of a lease that is never released.
+//
+// This method implements the internal.Config interface used by the concurrency
+// algorithm.
+func (c *Config) GetLeaseTTL() time.Duration {
+	return c.LeaseTTL
+}
+
+// GetMaxRetries returns the configured maximum retry attempts for atomic operations.
+//
+// When MaxRetries is 0 (default), returns Max + 1: every lost CheckAndSet
+// means another lease was acquired, so after Max losses the limiter is full.
+// When MaxRetries > 0, returns the explicitly configured value.
+func (c *Config) GetMaxRetries() int {
+	if c.MaxRetries > 0 {
+		return c.MaxRetries
+	}
+	return c.Max + 1
+}
+
+// GetRetryBackoff returns the configured delay policy between retry attempts.
+//
+// This method implements the internal.Config interface used by the concurrency
+// algorithm. A zero Backoff means the default feedback-based delay is used.
+func (c *Config) GetRetryBackoff() strategies.Backoff {
+	return c.RetryBackoff
+}
+
+// Usage returns the typed view of a concurrency result, e.g. for dashboards
+// showing utilization.
+func (c *Config) Usage(result strategies.Result) Usage {
+	return Usage{
+		Allowed: result.Allowed,
+		Active:  max(c.Max-result.Remaining, 0),
+		Max:     c.Max,
+		Reset:   result.Reset,
+	}
+}
+
+// Usage is the result of a concurrency check in terms of leases
+type Usage struct {
+	Allowed bool      // Whether a lease was (Allow) or could be (Peek) acquired
+	Active  int       // Leases currently held, including the one just acquired
+	Max     int       // Maximum number of active leases
+	Reset   time.Time // When the next lease expires if all are taken, otherwise now
+}
@@ -0,0 +1,6 @@
+package concurrency
+
+import "errors"
+
+// ErrInvalidConfig is returned when the provided config is not of type concurrency.Config.
+var ErrInvalidConfig = errors.New("concurrency strategy requires concurrency.Config")
@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// AllowMode represents the operation mode for `Allow`
+type AllowMode int
+
+const (
+	// ReadOnly only inspects current state without modifications
+	ReadOnly AllowMode = iota
+	// TryUpdate attempts to acquire a lease with retry logic
+	TryUpdate
+)
+
+// Result contains the result of Allow operation
+type Result struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Time
+}
+
+type parameter struct {
+	backoff    strategies.Backoff
+	key        string
+	leaseTTL   time.Duration
+	max        int
+	maxRetries int
+	now        time.Time
+	storage    backends.Backend
+}
+
+// Allow provides a unified implementation for both Allow and Peek operations
+// mode determines whether to perform read-only inspection or actual lease acquisition
+func Allow(
+	ctx context.Context,
+	storage backends.Backend,
+	config Config,
+	mode AllowMode,
+
+) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	if mode == ReadOnly {
+		leases, _, err := p.getState(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		return p.result(leases, len(leases.Expiries) < p.max), nil
+	}
+
+	return p.acquire(ctx)
+}
+
+// Release ends n leases, clamped to the number of active leases.
+//
+// A missing state has no leases, so it is a no-op.
+func Release(ctx context.Context, storage backends.Backend, config Config, n int) error {
+	if err := ctx.Err(); err != nil {
+		return NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	return p.update(ctx, func(leases Leases) (Leases, bool) {
+		if len(leases.Expiries) == 0 {
+			return leases, false
+		}
+		return leases.release(n), true
+	})
+}
+
+// newParameter builds the operation parameters from config
+func newParameter(ctx context.Context, storage backends.Backend, config Config) *parameter {
+	return &parameter{
+		backoff:    config.GetRetryBackoff(),
+		key:        config.GetKey(),
+		leaseTTL:   config.GetLeaseTTL(),
+		max:        config.GetMax(),
+		maxRetries: config.GetMaxRetries(),
+		now:        strategies.Now(ctx),
+		storage:    storage,
+	}
+}
+
+// acquire adds a lease if fewer than max are active
+func (p *parameter) acquire(ctx context.Context) (Result, error) {
+	var result Result
+	err := p.update(ctx, func(leases Leases) (Leases, bool) {
+		if len(leases.Expiries) >= p.max {
+			result = p.result(leases, false)
+			return leases, false
+		}
+		leases = leases.acquire(p.now.Add(p.leaseTTL))
+		result = p.result(leases, true)
+		return leases, true
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// update applies fn to the active leases and stores the result with
+// CheckAndSet, retrying on conflicts. fn returns false to leave the state as is.
+func (p *parameter) update(ctx context.Context, fn func(Leases) (Leases, bool)) error {
+	for attempt := range p.maxRetries {
+		if err := ctx.Err(); err != nil {
+			return NewContextCanceledError(err)
+		}
+
+		leases, oldValue, err := p.getState(ctx)
+		if err != nil {
+			return err
+		}
+
+		leases, changed := fn(leases)
+		if !changed {
+			return nil
+		}
+
+		beforeCAS := time.Now()
+		success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, encodeState(leases), p.expiration(leases))
+		if err != nil {
+			return NewStateSaveError(err)
+		}
+		if success {
+			return nil
+		}
+
+		if err := p.backoff.Wait(ctx, attempt, time.Since(beforeCAS)); err != nil {
+			return NewContextCanceledError(err)
+		}
+	}
+
+	return ErrConcurrentAccess
+}
+
+// getState returns the active leases at p.now and the raw stored value
+func (p *parameter) getState(ctx context.Context) (Leases, string, error) {
+	data, err := p.storage.Get(ctx, p.key)
+	if err != nil {
+		return Leases{}, "", NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return Leases{}, "", nil
+	}
+
+	leases, ok := decodeState(data)
+	if !ok {
+		return Leases{}, "", ErrStateParsing
+	}
+	return leases.active(p.now), data, nil
+}
+
+// result reports the leases, Reset is when the next lease expires if all are taken
+func (p *parameter) result(leases Leases, allowed bool) Result {
+	active := len(leases.Expiries)
+	reset := p.now
+	if active >= p.max && active > 0 {
+		reset = leases.Expiries[0]
+	}
+	return Result{
+		Allowed:   allowed,
+		Remaining: max(p.max-active, 0),
+		Reset:     reset,
+	}
+}
+
+// expiration keeps the state until its last lease expires
+func (p *parameter) expiration(leases Leases) time.Duration {
+	if len(leases.Expiries) == 0 {
+		return time.Second
+	}
+	return max(leases.Expiries[len(leases.Expiries)-1].Sub(p.now), time.Second)
+}
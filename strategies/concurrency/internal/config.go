@@ -0,0 +1,15 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+type Config interface {
+	GetKey() string
+	GetMax() int
+	GetLeaseTTL() time.Duration
+	GetMaxRetries() int
+	GetRetryBackoff() strategies.Backoff
+}
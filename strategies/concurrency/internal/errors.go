@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+var (
+	ErrStateParsing     = errors.New("failed to parse concurrency state: invalid encoding")
+	ErrConcurrentAccess = fmt.Errorf("failed to update concurrency state after max attempts due to concurrent access: %w", strategies.ErrMaxRetriesExceeded)
+)
+
+func NewStateRetrievalError(err error) error {
+	return fmt.Errorf("failed to get concurrency state: %w", err)
+}
+
+func NewStateSaveError(err error) error {
+	return fmt.Errorf("failed to save concurrency state: %w", err)
+}
+
+func NewContextCanceledError(err error) error {
+	return fmt.Errorf("context canceled or timed out: %w", err)
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/utils/builderpool"
+)
+
+// Leases holds the expiry of every active lease, sorted ascending
+type Leases struct {
+	Expiries []time.Time `json:"expiries"`
+}
+
+// active drops the leases expired at now and returns the rest
+func (l Leases) active(now time.Time) Leases {
+	i := 0
+	for i < len(l.Expiries) && !l.Expiries[i].After(now) {
+		i++
+	}
+	return Leases{Expiries: l.Expiries[i:]}
+}
+
+// acquire adds a lease expiring at expiry, keeping the order
+func (l Leases) acquire(expiry time.Time) Leases {
+	expiries := slices.Clone(l.Expiries)
+	i, _ := slices.BinarySearchFunc(expiries, expiry, time.Time.Compare)
+	return Leases{Expiries: slices.Insert(expiries, i, expiry)}
+}
+
+// release drops up to n leases, the ones expiring last first.
+//
+// Leases are anonymous, so a release cannot tell which one it ends. Dropping
+// the latest keeps the oldest, which is also the first to expire if its
+// holder leaked it.
+func (l Leases) release(n int) Leases {
+	return Leases{Expiries: slices.Clone(l.Expiries[:max(len(l.Expiries)-n, 0)])}
+}
+
+// encodeState serializes leases into a compact ASCII format:
+// 61|N|expiry1_unix_nano|...|expiryN_unix_nano
+func encodeState(l Leases) string {
+	sb := builderpool.Get()
+	defer builderpool.Put(sb)
+
+	sb.WriteString("61|")
+	sb.WriteString(strconv.Itoa(len(l.Expiries)))
+	for _, expiry := range l.Expiries {
+		sb.WriteByte('|')
+		sb.WriteString(strconv.FormatInt(expiry.UnixNano(), 10))
+	}
+	return sb.String()
+}
+
+func decodeState(s string) (Leases, bool) {
+	if len(s) < 4 || s[:3] != "61|" {
+		return Leases{}, false
+	}
+
+	fields := strings.Split(s[3:], "|")
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 0 || n != len(fields)-1 {
+		return Leases{}, false
+	}
+
+	expiries := make([]time.Time, n)
+	for i, field := range fields[1:] {
+		ns, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return Leases{}, false
+		}
+		expiries[i] = time.Unix(0, ns)
+	}
+	if !slices.IsSortedFunc(expiries, time.Time.Compare) {
+		return Leases{}, false
+	}
+	return Leases{Expiries: expiries}, true
+}
+
+// Inspect returns the stored state of key without modifying it, or false for a fresh key
+func Inspect(ctx context.Context, storage backends.Backend, key string) (Leases, bool, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return Leases{}, false, NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return Leases{}, false, nil
+	}
+	leases, ok := decodeState(data)
+	if !ok {
+		return Leases{}, false, ErrStateParsing
+	}
+	return leases, true, nil
+}
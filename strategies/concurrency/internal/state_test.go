@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeState(t *testing.T) {
+	leases := Leases{Expiries: []time.Time{time.Unix(0, 100), time.Unix(0, 200)}}
+	encoded := encodeState(leases)
+	assert.Equal(t, "61|2|100|200", encoded)
+
+	decoded, ok := decodeState(encoded)
+	assert.True(t, ok)
+	assert.Equal(t, leases, decoded)
+
+	decoded, ok = decodeState(encodeState(Leases{}))
+	assert.True(t, ok)
+	assert.Empty(t, decoded.Expiries)
+}
+
+func TestDecodeStateInvalid(t *testing.T) {
+	for _, s := range []string{"", "12|1|1", "61|", "61|2|100", "61|1|abc", "61|2|200|100", "61|-1"} {
+		_, ok := decodeState(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func FuzzDecodeState(f *testing.F) {
+	f.Add("61|0")
+	f.Add("61|2|1761884055342794596|1761884056342794596")
+	f.Add("61|2|2|1")
+	f.Add("61|3|1")
+	f.Add("61|-1")
+	f.Add("61|99999999999999999999")
+	f.Add("61|1|\xff")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		leases, ok := decodeState(s)
+		if !ok {
+			return
+		}
+		decoded, ok := decodeState(encodeState(leases))
+		if !ok || len(decoded.Expiries) != len(leases.Expiries) {
+			t.Fatalf("re-encoding %q does not round trip", s)
+		}
+	})
+}
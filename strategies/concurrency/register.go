@@ -0,0 +1,12 @@
+package concurrency
+
+import (
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+func init() {
+	strategies.Register(strategies.StrategyConcurrency, func(storage backends.Backend) strategies.Strategy {
+		return New(storage)
+	})
+}
@@ -1,7 +1,9 @@
 package strategies
 
 import (
+	"math/rand/v2"
 	"strings"
+	"time"
 )
 
 // ID uniquely identifies a strategy implementation
@@ -14,6 +16,10 @@ const (
 	StrategyLeakyBucket
 	StrategyGCRA
 	StrategyComposite
+	StrategyConcurrency
+	StrategyApprox
+	StrategyUnique
+	StrategySlidingWindow
 )
 
 // String returns the canonical string representation of the strategy ID
@@ -29,11 +35,31 @@ func (id ID) String() string {
 		return "gcra"
 	case StrategyComposite:
 		return "composite"
+	case StrategyConcurrency:
+		return "concurrency"
+	case StrategyApprox:
+		return "approx"
+	case StrategyUnique:
+		return "unique"
+	case StrategySlidingWindow:
+		return "sliding_window"
 	default:
 		return "unknown"
 	}
 }
 
+// ParseID returns the strategy ID for its canonical string representation.
+//
+// Returns ErrStrategyNotFound if the name does not match any known strategy.
+func ParseID(name string) (ID, error) {
+	for id := StrategyTokenBucket; id <= StrategySlidingWindow; id++ {
+		if id.String() == name {
+			return id, nil
+		}
+	}
+	return StrategyUnknown, ErrStrategyNotFound
+}
+
 // Config defines the interface for all strategy configurations
 type Config interface {
 	// Validate performs configuration validation and returns an error if invalid.
@@ -69,6 +95,61 @@ type Config interface {
 	WithMaxRetries(retries int) Config
 }
 
+// RetryBackoffConfig is implemented by strategy configs that accept a custom retry backoff.
+//
+// It is optional so that custom strategy configs keep satisfying Config unchanged.
+type RetryBackoffConfig interface {
+	// WithRetryBackoff returns a copy of the config with the provided retry backoff applied.
+	WithRetryBackoff(backoff Backoff) Config
+}
+
+// ActorConfig is implemented by strategy configs counting distinct actors,
+// e.g. users, instead of requests.
+//
+// The limiter applies the actor of every request, so configs wrapping others
+// forward it to the tiers implementing ActorConfig.
+type ActorConfig interface {
+	// WithActor returns a copy of the config with the actor of the request applied.
+	WithActor(actor string) Config
+}
+
+// IdleTTLConfig is implemented by strategy configs whose stored state can
+// expire sooner than the default after the last request, to reclaim storage
+// of one-off keys such as anonymous client IPs.
+//
+// The state expires idleTTL after the last write, but never before it is
+// equivalent to a fresh key, e.g. a full bucket or an elapsed window, so
+// limits are not affected. The limiter applies AccessOptions.IdleTTL, and
+// configs wrapping others forward it to the tiers implementing IdleTTLConfig.
+type IdleTTLConfig interface {
+	// WithIdleTTL returns a copy of the config with the idle TTL applied, 0
+	// keeps the default expiration.
+	WithIdleTTL(idleTTL time.Duration) Config
+}
+
+// RandConfig is implemented by strategy configs making random decisions, e.g.
+// retry jitter or sampling, so that a seeded source can make them
+// reproducible.
+//
+// The limiter applies its WithRandSource source, and configs wrapping others
+// forward it to the tiers implementing RandConfig.
+type RandConfig interface {
+	// WithRand returns a copy of the config drawing random numbers from rng,
+	// which is safe for concurrent use; nil uses the global generator.
+	WithRand(rng *rand.Rand) Config
+}
+
+// SteadyStateRateConfig is implemented by strategy configs limiting the
+// request rate, for capacity planning.
+//
+// Configs limiting something else, e.g. concurrent or distinct actor
+// requests, don't implement it.
+type SteadyStateRateConfig interface {
+	// SteadyStateRate returns the long-run number of requests per second the
+	// config allows, bursts aside.
+	SteadyStateRate() float64
+}
+
 // CapabilityFlags defines the capabilities and roles a strategy can fulfill
 type CapabilityFlags uint8
 
@@ -92,6 +173,19 @@ const (
 	CapQuotas
 )
 
+// Capabilities returns the capabilities of a strategy config, none for nil.
+//
+// Config UIs and tooling can use it before building a limiter, e.g. to only
+// offer strategies that can be a secondary:
+//
+//	strategies.Capabilities(config).Has(strategies.CapSecondary)
+func Capabilities(config Config) CapabilityFlags {
+	if config == nil {
+		return 0
+	}
+	return config.Capabilities()
+}
+
 // Has checks if the flags contain a specific capability.
 //
 // This method enables capability checking for strategy validation and role assignment.
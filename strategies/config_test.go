@@ -16,6 +16,10 @@ func TestStrategyID_String(t *testing.T) {
 		{StrategyLeakyBucket, "leaky_bucket"},
 		{StrategyGCRA, "gcra"},
 		{StrategyComposite, "composite"},
+		{StrategyConcurrency, "concurrency"},
+		{StrategyApprox, "approx"},
+		{StrategyUnique, "unique"},
+		{StrategySlidingWindow, "sliding_window"},
 		{ID(255), "unknown"},
 	}
 	for _, tc := range cases {
@@ -25,6 +29,19 @@ func TestStrategyID_String(t *testing.T) {
 	}
 }
 
+func TestParseID(t *testing.T) {
+	for _, id := range []ID{StrategyTokenBucket, StrategyFixedWindow, StrategyLeakyBucket, StrategyGCRA, StrategyComposite, StrategyConcurrency, StrategyApprox, StrategyUnique, StrategySlidingWindow} {
+		got, err := ParseID(id.String())
+		require.NoError(t, err)
+		require.Equal(t, id, got)
+	}
+
+	_, err := ParseID("sliding_log")
+	require.ErrorIs(t, err, ErrStrategyNotFound)
+	_, err = ParseID("unknown")
+	require.ErrorIs(t, err, ErrStrategyNotFound)
+}
+
 func TestCapabilityFlags_HasAndString(t *testing.T) {
 	var f CapabilityFlags
 	require.False(t, f.Has(CapPrimary))
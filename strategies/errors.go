@@ -3,3 +3,21 @@ package strategies
 import "errors"
 
 var ErrStrategyNotFound = errors.New("strategy not found")
+
+var ErrRefundNotSupported = errors.New("strategy does not support refund")
+
+var ErrCostNotSupported = errors.New("strategy does not support request cost")
+
+var ErrInspectNotSupported = errors.New("strategy does not support inspect")
+
+// ErrMaxRetriesExceeded is wrapped by errors of operations that lost every
+// CheckAndSet attempt to concurrent writers of the same key
+var ErrMaxRetriesExceeded = errors.New("max retries exceeded")
+
+// Config validation errors, wrapped by strategy Validate methods
+var (
+	ErrInvalidRate   = errors.New("invalid rate")
+	ErrInvalidBurst  = errors.New("invalid burst")
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidWindow = errors.New("invalid window")
+)
@@ -3,6 +3,8 @@ package fixedwindow
 import (
 	"fmt"
 	"math"
+	"math/rand/v2"
+	"slices"
 	"time"
 
 	"github.com/ajiwo/ratelimit/strategies"
@@ -23,15 +25,39 @@ import (
 //	a distinct rate limit based on requests per window duration.
 type Quota = internal.Quota
 
+// Month is the window of an aligned quota that resets on the first day of every month.
+//
+// Unaligned quotas and rate ratio validation use its nominal length of 30 days.
+const Month = internal.Month
+
+// DefaultMaxClockSkew is how far in the future a stored window may start
+// before it is clamped to the current time, see Config.MaxClockSkew
+const DefaultMaxClockSkew = time.Second
+
 // Config implements the Config interface for fixed window rate limiting with multi-quota support.
 //
 // Config supports up to 8 named quotas per key. Each quota is tracked independently
 // with its own counter and window state. Quotas must have unique rate ratios
-// (requests per second) to prevent duplicate rate limits.
+// (requests per second) to prevent duplicate rate limits, unless
+// AllowDuplicateRatios is set.
 type Config struct {
-	Key        string  // Storage key for the rate limit state
-	Quotas     []Quota // Named quotas with their limits and windows (sorted for determinism)
-	MaxRetries int     // Maximum retry attempts for atomic operations, 0 means use default
+	Key          string             // Storage key for the rate limit state
+	Quotas       []Quota            // Named quotas with their limits and windows (sorted for determinism)
+	MaxRetries   int                // Maximum retry attempts for atomic operations, 0 means use default
+	RetryBackoff strategies.Backoff // Delay policy between retry attempts, zero value uses default
+	IdleTTL      time.Duration      // Expiration after the last request, zero uses default, see strategies.IdleTTLConfig
+	Location     *time.Location     // Time zone of aligned quota boundaries, nil means UTC
+	MaxClockSkew time.Duration      // Tolerated future window starts, zero uses DefaultMaxClockSkew
+
+	// IndependentQuotas stores every quota under its own key, see
+	// QuotaKeyMarker, instead of all quotas in one value, so that large
+	// quota sets are updated without rewriting each other. Quotas are then
+	// no longer updated atomically together, see configBuilder.SetIndependentQuotas.
+	IndependentQuotas bool
+
+	// AllowDuplicateRatios keeps quotas with the same rate ratio, e.g. 1 per
+	// minute and 60 per hour for different burst shapes, see CheckRateRatios
+	AllowDuplicateRatios bool
 }
 
 // GetKey returns the storage key for rate limit state.
@@ -61,8 +87,10 @@ func (c *Config) GetQuotas() []internal.Quota {
 //   - More than 8 quotas are configured
 //   - Any quota has a limit <= 0
 //   - Any quota has a window duration <= 0
+//   - Any aligned quota has a window that neither divides a day nor is Month
 //   - Any quota name is invalid (utils.ValidateQuotaName)
-//   - Multiple quotas have the same rate ratio
+//   - Multiple quotas have the same rate ratio, unless AllowDuplicateRatios is set
+//   - MaxClockSkew is negative
 //
 // Rate ratio validation ensures each quota enforces a distinct rate limit
 // by checking that requests per second values are unique (with 1e-9 tolerance
@@ -83,27 +111,40 @@ func (c *Config) Validate() error {
 			return err
 		}
 		if quota.Limit <= 0 {
-			return fmt.Errorf("fixed window quota '%s' limit must be positive, got %d", quota.Name, quota.Limit)
+			return fmt.Errorf("%w: fixed window quota '%s' limit must be positive, got %d", strategies.ErrInvalidLimit, quota.Name, quota.Limit)
 		}
 		if quota.Window <= 0 {
-			return fmt.Errorf("fixed window quota '%s' window must be positive, got %v", quota.Name, quota.Window)
+			return fmt.Errorf("%w: fixed window quota '%s' window must be positive, got %v", strategies.ErrInvalidWindow, quota.Name, quota.Window)
+		}
+		if quota.SoftLimit < 0 || (quota.SoftLimit > 0 && quota.SoftLimit >= quota.Limit) {
+			return fmt.Errorf("%w: fixed window quota '%s' soft limit must be between 0 and the limit %d, got %d", strategies.ErrInvalidLimit, quota.Name, quota.Limit, quota.SoftLimit)
 		}
+		if quota.Aligned && !internal.ValidAlignment(quota.Window) {
+			return fmt.Errorf("%w: fixed window quota '%s' is aligned, its window must divide a day or be Month, got %v", strategies.ErrInvalidWindow, quota.Name, quota.Window)
+		}
+	}
+
+	if c.MaxClockSkew < 0 {
+		return fmt.Errorf("fixed window max clock skew must not be negative, got %v", c.MaxClockSkew)
 	}
 
 	// Validate for duplicate rate ratios (requests per second)
-	if err := c.validateUniqueRateRatios(); err != nil {
-		return err
+	if !c.AllowDuplicateRatios {
+		if err := c.CheckRateRatios(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// validateUniqueRateRatios ensures each quota has a unique rate ratio.
+// CheckRateRatios ensures each quota has a unique rate ratio.
 //
 // This method calculates the rate ratio (requests per second) for each quota
 // and ensures no two quotas have identical rates. This prevents redundant
 // quotas that would enforce the same rate limit with different names.
-func (c *Config) validateUniqueRateRatios() error {
+// It reports duplicates even when AllowDuplicateRatios is set.
+func (c *Config) CheckRateRatios() error {
 	// Map to track rate ratios: normalized requests per second
 	rateRatios := make(map[float64]string)
 
@@ -168,6 +209,68 @@ func (c *Config) WithMaxRetries(retries int) strategies.Config {
 	return &cfg
 }
 
+// WithRetryBackoff returns a copy of the config with the provided retry backoff applied.
+//
+// This controls the delay between retry attempts for atomic operations
+// (CheckAndSet). A zero Backoff keeps the default feedback-based delay.
+func (c *Config) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	return &cfg
+}
+
+// WithRand returns a copy of the config drawing its retry jitter from rng.
+//
+// This method implements strategies.RandConfig and sets RetryBackoff.Rand,
+// keeping the rest of the backoff.
+func (c *Config) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff.Rand = rng
+	return &cfg
+}
+
+// WithIdleTTL returns a copy of the config with the provided idle TTL applied.
+//
+// The stored state expires idleTTL after the last request instead of the
+// default, but never before it is equivalent to a fresh key. A zero idleTTL
+// keeps the default expiration.
+func (c *Config) WithIdleTTL(idleTTL time.Duration) strategies.Config {
+	cfg := *c
+	cfg.IdleTTL = idleTTL
+	return &cfg
+}
+
+// WithTimezone returns a copy of the config whose aligned quotas follow loc.
+//
+// Daily and monthly aligned windows then reset at local midnight, e.g. for a
+// "1000 requests per day" SLA defined in the customer's time zone. DST
+// transitions make such days 23 or 25 hours long. Unaligned quotas are not
+// affected.
+func (c *Config) WithTimezone(loc *time.Location) *Config {
+	cfg := *c
+	cfg.Location = loc
+	return &cfg
+}
+
+// GetLocation returns the time zone of aligned quota boundaries.
+//
+// This method implements the internal.Config interface used by the fixed window
+// algorithm. A nil location means UTC.
+func (c *Config) GetLocation() *time.Location {
+	return c.Location
+}
+
+// GetMaxClockSkew returns how far in the future a stored window may start.
+//
+// This method implements the internal.Config interface used by the fixed window
+// algorithm. It returns DefaultMaxClockSkew when MaxClockSkew is zero.
+func (c *Config) GetMaxClockSkew() time.Duration {
+	if c.MaxClockSkew == 0 {
+		return DefaultMaxClockSkew
+	}
+	return c.MaxClockSkew
+}
+
 // GetMaxRetries returns the configured maximum retry attempts for atomic operations.
 //
 // When MaxRetries is 0 (default), returns the limit of the most restrictive quota
@@ -193,11 +296,43 @@ func (c *Config) GetMaxRetries() int {
 	return mostRestrictive.Limit + 1
 }
 
+// GetRetryBackoff returns the configured delay policy between retry attempts.
+//
+// This method implements the internal.Config interface used by the fixed window
+// algorithm. A zero Backoff means the default feedback-based delay is used.
+func (c *Config) GetRetryBackoff() strategies.Backoff {
+	return c.RetryBackoff
+}
+
+// SteadyStateRate returns the long-run requests per second allowed, the
+// lowest Limit/Window ratio of the quotas.
+//
+// This method implements strategies.SteadyStateRateConfig. Month quotas
+// use their nominal length of 30 days.
+func (c *Config) SteadyStateRate() float64 {
+	rate := math.Inf(1)
+	for _, quota := range c.Quotas {
+		rate = min(rate, float64(quota.Limit)/quota.Window.Seconds())
+	}
+	return rate
+}
+
+// GetIdleTTL returns the configured expiration after the last request.
+//
+// This method implements the internal.Config interface used by the fixedwindow
+// algorithm. A zero idle TTL means the default expiration is used.
+func (c *Config) GetIdleTTL() time.Duration {
+	return c.IdleTTL
+}
+
 // configBuilder provides a fluent interface for building multi-quota configurations
 type configBuilder struct {
-	key        string
-	quotas     []Quota
-	maxRetries int
+	key          string
+	quotas       []Quota
+	maxRetries   int
+	location     *time.Location
+	maxClockSkew time.Duration
+	independent  bool
 }
 
 // NewConfig creates a multi-quota FixedWindowConfig with a builder pattern
@@ -219,6 +354,33 @@ func (b *configBuilder) SetMaxRetries(retries int) *configBuilder {
 	return b
 }
 
+// SetTimezone sets the time zone of aligned quota boundaries, UTC by default
+func (b *configBuilder) SetTimezone(loc *time.Location) *configBuilder {
+	b.location = loc
+	return b
+}
+
+// SetMaxClockSkew sets how far in the future a stored window may start, e.g.
+// written by a server whose clock is ahead, DefaultMaxClockSkew by default
+func (b *configBuilder) SetMaxClockSkew(skew time.Duration) *configBuilder {
+	b.maxClockSkew = skew
+	return b
+}
+
+// SetIndependentQuotas stores every quota under its own key instead of all
+// quotas in one value, e.g. for tenants with many quotas, whose requests
+// otherwise rewrite and contend on a single growing value.
+//
+// The cost is cross-quota atomicity: quotas are consumed one after the other,
+// and when one denies, those consumed before it are refunded, so concurrent
+// requests may briefly see counts of a request that ends up denied. Changing
+// it starts every key over. Independent quotas cannot be combined with
+// secondary strategies on the same backend.
+func (b *configBuilder) SetIndependentQuotas(independent bool) *configBuilder {
+	b.independent = independent
+	return b
+}
+
 // AddQuota adds a new quota to the configuration
 func (b *configBuilder) AddQuota(name string, limit int, window time.Duration) *configBuilder {
 	b.quotas = append(b.quotas, Quota{
@@ -229,15 +391,97 @@ func (b *configBuilder) AddQuota(name string, limit int, window time.Duration) *
 	return b
 }
 
-// Build creates the FixedWindowConfig from the builder
+// AddAlignedQuota adds a quota whose windows start on calendar boundaries.
+//
+// Boundaries are in UTC unless SetTimezone selects another time zone.
+// The window must divide a day, e.g. time.Hour or 24 * time.Hour, or be Month.
+// A request at 10:59 and one at 11:01 fall in different hourly windows no
+// matter when the first request arrived.
+func (b *configBuilder) AddAlignedQuota(name string, limit int, window time.Duration) *configBuilder {
+	b.quotas = append(b.quotas, Quota{
+		Name:    name,
+		Limit:   limit,
+		Window:  window,
+		Aligned: true,
+	})
+	return b
+}
+
+// SetSoftLimit sets the soft limit of the quota added with the given name.
+//
+// Requests of a window beyond the soft limit are still allowed up to the
+// limit, with strategies.Result.SoftLimited set, e.g. to send a "slow down"
+// header before clients get denied. It must be below the limit.
+func (b *configBuilder) SetSoftLimit(name string, softLimit int) *configBuilder {
+	for i := range b.quotas {
+		if b.quotas[i].Name == name {
+			b.quotas[i].SoftLimit = softLimit
+		}
+	}
+	return b
+}
+
+// Build creates the FixedWindowConfig from the builder.
+//
+// The config gets its own copy of the quotas, so the builder may be reused.
 func (b *configBuilder) Build() *Config {
 	return &Config{
-		Key:        b.key,
-		MaxRetries: b.maxRetries,
-		Quotas:     b.quotas,
+		Key:          b.key,
+		MaxRetries:   b.maxRetries,
+		Quotas:       slices.Clone(b.quotas),
+		Location:     b.location,
+		MaxClockSkew: b.maxClockSkew,
+
+		IndependentQuotas: b.independent,
+	}
+}
+
+// Clone returns an independent copy of the builder.
+//
+// Quotas added to the clone do not affect the original and vice versa.
+func (b *configBuilder) Clone() *configBuilder {
+	return &configBuilder{
+		key:          b.key,
+		quotas:       slices.Clone(b.quotas),
+		maxRetries:   b.maxRetries,
+		location:     b.location,
+		maxClockSkew: b.maxClockSkew,
+		independent:  b.independent,
 	}
 }
 
+// Template validates the quotas added so far and returns a reusable template.
+//
+// Use it to define one quota set for many limiters that only differ by key,
+// the quotas and their rate ratios are validated once here.
+func (b *configBuilder) Template() (*Template, error) {
+	cfg := b.Build()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Template{config: *cfg}, nil
+}
+
+// Template is a validated quota set that produces configs for different keys
+type Template struct {
+	config Config
+}
+
+// ForKey returns a new config with the template quotas and the given key.
+//
+// Every config gets its own copy of the quotas.
+func (t *Template) ForKey(key string) *Config {
+	cfg := t.config
+	cfg.Key = key
+	cfg.Quotas = slices.Clone(t.config.Quotas)
+	return &cfg
+}
+
+// Quotas returns a copy of the template quotas
+func (t *Template) Quotas() []Quota {
+	return slices.Clone(t.config.Quotas)
+}
+
 // QuotaConfig represents a quota configuration for the custom multi-quota builder
 type QuotaConfig struct {
 	Limit  int
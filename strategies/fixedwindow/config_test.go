@@ -226,3 +226,131 @@ func TestConfig_Builder(t *testing.T) {
 	require.Empty(t, noKeyConfig.Key, "Key should be empty if not set")
 	require.Len(t, noKeyConfig.Quotas, 1, "Should have one quota")
 }
+
+func TestConfig_BuilderClone(t *testing.T) {
+	base := NewConfig().SetKey("base").AddQuota("minute", 10, time.Minute)
+	clone := base.Clone().SetKey("clone").AddQuota("hour", 100, time.Hour)
+	base.AddQuota("second", 1, time.Second)
+
+	baseConfig, cloneConfig := base.Build(), clone.Build()
+	assert.Equal(t, "base", baseConfig.Key)
+	assert.Equal(t, "clone", cloneConfig.Key)
+	assert.Equal(t, []Quota{
+		{Name: "minute", Limit: 10, Window: time.Minute},
+		{Name: "second", Limit: 1, Window: time.Second},
+	}, baseConfig.Quotas)
+	assert.Equal(t, []Quota{
+		{Name: "minute", Limit: 10, Window: time.Minute},
+		{Name: "hour", Limit: 100, Window: time.Hour},
+	}, cloneConfig.Quotas)
+
+	// Built configs do not alias the builder or each other
+	first, second := base.Build(), base.Build()
+	first.Quotas[0].Limit = 99
+	assert.Equal(t, 10, second.Quotas[0].Limit)
+	assert.Equal(t, 10, base.Build().Quotas[0].Limit)
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := NewConfig().
+		SetMaxRetries(5).
+		AddQuota("minute", 10, time.Minute).
+		AddQuota("hour", 100, time.Hour).
+		Template()
+	require.NoError(t, err)
+
+	alice, bob := tmpl.ForKey("alice"), tmpl.ForKey("bob")
+	assert.Equal(t, "alice", alice.Key)
+	assert.Equal(t, "bob", bob.Key)
+	assert.Equal(t, 5, bob.MaxRetries)
+	require.NoError(t, alice.Validate())
+	require.NoError(t, bob.Validate())
+
+	// Mutating one config affects neither the other nor the template
+	alice.Quotas[0].Limit = 1
+	alice.Quotas = append(alice.Quotas, Quota{Name: "day", Limit: 1000, Window: 24 * time.Hour})
+	assert.Equal(t, 10, bob.Quotas[0].Limit)
+	assert.Len(t, bob.Quotas, 2)
+	assert.Equal(t, 10, tmpl.ForKey("carol").Quotas[0].Limit)
+	assert.Len(t, tmpl.Quotas(), 2)
+
+	quotas := tmpl.Quotas()
+	quotas[0].Limit = 1
+	assert.Equal(t, 10, tmpl.Quotas()[0].Limit)
+}
+
+func TestTemplate_Invalid(t *testing.T) {
+	_, err := NewConfig().
+		AddQuota("minute", 10, time.Minute).
+		AddQuota("alias", 600, time.Hour).
+		Template()
+	assert.Error(t, err, "duplicate rate ratios should fail once at template creation")
+
+	_, err = NewConfig().Template()
+	assert.Error(t, err)
+}
+
+func TestConfig_AllowDuplicateRatios(t *testing.T) {
+	cfg := NewConfig().
+		AddQuota("minute", 1, time.Minute).
+		AddQuota("hour", 60, time.Hour).
+		Build()
+	require.Error(t, cfg.Validate())
+
+	cfg.AllowDuplicateRatios = true
+	require.NoError(t, cfg.Validate())
+	assert.Error(t, cfg.CheckRateRatios(), "duplicates are still reported")
+
+	withKey := cfg.WithKey("user").(*Config)
+	assert.True(t, withKey.AllowDuplicateRatios)
+}
+
+// TestConfig_ValidateBoundaries checks that unusable values fail with sentinel errors
+func TestConfig_ValidateBoundaries(t *testing.T) {
+	testCases := []struct {
+		name    string
+		limit   int
+		window  time.Duration
+		aligned bool
+		wantErr error
+	}{
+		{name: "smallest limit and window", limit: 1, window: time.Nanosecond},
+		{name: "zero limit", limit: 0, window: time.Minute, wantErr: strategies.ErrInvalidLimit},
+		{name: "negative limit", limit: -1, window: time.Minute, wantErr: strategies.ErrInvalidLimit},
+		{name: "zero window", limit: 1, window: 0, wantErr: strategies.ErrInvalidWindow},
+		{name: "negative window", limit: 1, window: -time.Second, wantErr: strategies.ErrInvalidWindow},
+		{name: "aligned hour", limit: 1, window: time.Hour, aligned: true},
+		{name: "aligned day", limit: 1, window: 24 * time.Hour, aligned: true},
+		{name: "aligned month", limit: 1, window: Month, aligned: true},
+		{name: "aligned window not dividing a day", limit: 1, window: 7 * time.Hour, aligned: true, wantErr: strategies.ErrInvalidWindow},
+		{name: "aligned week", limit: 1, window: 7 * 24 * time.Hour, aligned: true, wantErr: strategies.ErrInvalidWindow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{
+				Key:    "boundary",
+				Quotas: []Quota{{Name: "default", Limit: tc.limit, Window: tc.window, Aligned: tc.aligned}},
+			}
+			err := config.Validate()
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestConfig_MaxClockSkew(t *testing.T) {
+	config := NewConfig().AddQuota("default", 5, time.Minute).Build()
+	require.NoError(t, config.Validate())
+	assert.Equal(t, DefaultMaxClockSkew, config.GetMaxClockSkew())
+
+	config = NewConfig().AddQuota("default", 5, time.Minute).SetMaxClockSkew(5 * time.Second).Build()
+	require.NoError(t, config.Validate())
+	assert.Equal(t, 5*time.Second, config.GetMaxClockSkew())
+
+	config = NewConfig().AddQuota("default", 5, time.Minute).SetMaxClockSkew(-time.Second).Build()
+	assert.ErrorContains(t, config.Validate(), "clock skew")
+}
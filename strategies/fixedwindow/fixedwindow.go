@@ -2,6 +2,7 @@ package fixedwindow
 
 import (
 	"context"
+	"math"
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
@@ -30,12 +31,18 @@ func (f *Strategy) Allow(ctx context.Context, config strategies.Config) (strateg
 		return nil, ErrInvalidConfig
 	}
 
-	res, err := internal.Allow(ctx, f.storage, fixedConfig, internal.TryUpdate)
+	var res map[string]internal.Result
+	var err error
+	if fixedConfig.IndependentQuotas {
+		res, err = f.allowIndependent(ctx, fixedConfig, 1)
+	} else {
+		res, err = internal.Allow(ctx, f.storage, fixedConfig, internal.TryUpdate)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return convertResults(res), nil
+	return convertResults(fixedConfig, res), nil
 }
 
 // Peek inspects current state without consuming quota
@@ -45,12 +52,18 @@ func (f *Strategy) Peek(ctx context.Context, config strategies.Config) (strategi
 		return nil, ErrInvalidConfig
 	}
 
-	res, err := internal.Allow(ctx, f.storage, fixedConfig, internal.ReadOnly)
+	var res map[string]internal.Result
+	var err error
+	if fixedConfig.IndependentQuotas {
+		res, err = f.peekIndependent(ctx, fixedConfig.quotaConfigs())
+	} else {
+		res, err = internal.Allow(ctx, f.storage, fixedConfig, internal.ReadOnly)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return convertResults(res), nil
+	return convertResults(fixedConfig, res), nil
 }
 
 // Reset resets the rate limit counter for the given key
@@ -60,18 +73,93 @@ func (f *Strategy) Reset(ctx context.Context, config strategies.Config) error {
 		return ErrInvalidConfig
 	}
 
+	if fixedConfig.IndependentQuotas {
+		return f.resetIndependent(ctx, fixedConfig)
+	}
 	return internal.Reset(ctx, fixedConfig, f.storage)
 }
 
-// convertResults converts internal.Result map to strategies.Result map
-func convertResults(internalResults map[string]internal.Result) strategies.Results {
+// Refund decrements the request counters of the current windows by n
+func (f *Strategy) Refund(ctx context.Context, config strategies.Config, n int) error {
+	fixedConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	if fixedConfig.IndependentQuotas {
+		return f.refundIndependent(ctx, fixedConfig.quotaConfigs(), n)
+	}
+	return internal.Refund(ctx, f.storage, fixedConfig, n)
+}
+
+// AllowCost is like Allow but increments every quota by cost, rounded up to
+// a whole number of requests. The request is denied unless all quotas have room.
+func (f *Strategy) AllowCost(ctx context.Context, config strategies.Config, cost float64) (strategies.Results, error) {
+	fixedConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	var res map[string]internal.Result
+	var err error
+	if fixedConfig.IndependentQuotas {
+		res, err = f.allowIndependent(ctx, fixedConfig, int(math.Ceil(cost)))
+	} else {
+		res, err = internal.AllowCost(ctx, f.storage, fixedConfig, int(math.Ceil(cost)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResults(fixedConfig, res), nil
+}
+
+// convertResults converts internal.Result map to strategies.Result map, with
+// the limit of each quota and whether its soft limit is exceeded
+func convertResults(config *Config, internalResults map[string]internal.Result) strategies.Results {
+	quotas := make(map[string]Quota, len(config.Quotas))
+	for _, quota := range config.Quotas {
+		quotas[quota.Name] = quota
+	}
+
 	results := make(strategies.Results, len(internalResults))
 	for name, res := range internalResults {
+		quota := quotas[name]
 		results[name] = strategies.Result{
-			Allowed:   res.Allowed,
-			Remaining: res.Remaining,
-			Reset:     res.Reset,
+			Allowed:     res.Allowed,
+			Limit:       quota.Limit,
+			Remaining:   res.Remaining,
+			Reset:       res.Reset,
+			WindowStart: res.WindowStart,
+			WindowEnd:   res.Reset,
+			Reason:      strategies.DeniedBy(res.Allowed, strategies.QuotaExhausted),
+			SoftLimited: res.Allowed && quota.SoftLimit > 0 && quota.Limit-res.Remaining > quota.SoftLimit,
 		}
 	}
 	return results
 }
+
+// Inspect returns the stored fixed windows of the key without modifying it, see strategies.Inspector
+func (f *Strategy) Inspect(ctx context.Context, config strategies.Config) ([]strategies.State, error) {
+	fixedConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	var windows []FixedWindow
+	var found bool
+	var err error
+	if fixedConfig.IndependentQuotas {
+		windows, found, err = f.inspectIndependent(ctx, fixedConfig)
+	} else {
+		windows, found, err = internal.Inspect(ctx, f.storage, fixedConfig.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := strategies.State{Strategy: strategies.StrategyFixedWindow}
+	if found {
+		state.Value = windows
+	}
+	return []strategies.State{state}, nil
+}
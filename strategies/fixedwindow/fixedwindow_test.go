@@ -7,6 +7,7 @@ import (
 	"testing/synctest"
 	"time"
 
+	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -390,6 +391,170 @@ func TestFixedWindow_PreciseTiming(t *testing.T) {
 	})
 }
 
+func TestFixedWindow_AlignedWindow(t *testing.T) {
+	// The synctest clock starts at midnight UTC, 2000-01-01
+	clockAt := func(hour, minute int) {
+		start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		time.Sleep(time.Until(start.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)))
+	}
+
+	t.Run("boundary splits requests", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			storage := newMockBackend()
+			t.Cleanup(func() { storage.Close() })
+			strategy := New(storage)
+			config := NewConfig().SetKey("aligned").AddAlignedQuota("hourly", 1, time.Hour).Build()
+
+			clockAt(10, 59)
+			result, err := strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.True(t, result["hourly"].Allowed)
+			assert.Equal(t, time.Date(2000, 1, 1, 11, 0, 0, 0, time.UTC), result["hourly"].Reset.UTC())
+
+			clockAt(11, 1)
+			result, err = strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.True(t, result["hourly"].Allowed, "11:01 is in a new hourly window")
+		})
+	})
+
+	t.Run("first request does not shift window", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			storage := newMockBackend()
+			t.Cleanup(func() { storage.Close() })
+			strategy := New(storage)
+			config := NewConfig().SetKey("aligned").AddAlignedQuota("hourly", 1, time.Hour).Build()
+
+			clockAt(10, 30)
+			result, err := strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.True(t, result["hourly"].Allowed)
+
+			clockAt(10, 59)
+			result, err = strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.False(t, result["hourly"].Allowed)
+
+			clockAt(11, 1)
+			result, err = strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.True(t, result["hourly"].Allowed, "an unaligned window would last until 11:30")
+		})
+	})
+
+	t.Run("monthly resets on the first", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			storage := newMockBackend()
+			t.Cleanup(func() { storage.Close() })
+			strategy := New(storage)
+			config := NewConfig().SetKey("aligned").AddAlignedQuota("monthly", 1, Month).Build()
+
+			// February 2000 has 29 days
+			time.Sleep(time.Until(time.Date(2000, 2, 15, 12, 0, 0, 0, time.UTC)))
+			result, err := strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.True(t, result["monthly"].Allowed)
+			assert.Equal(t, time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC), result["monthly"].Reset.UTC())
+
+			time.Sleep(time.Until(time.Date(2000, 2, 29, 23, 59, 0, 0, time.UTC)))
+			result, err = strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.False(t, result["monthly"].Allowed)
+
+			time.Sleep(time.Until(time.Date(2000, 3, 1, 0, 0, 0, 0, time.UTC)))
+			result, err = strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.True(t, result["monthly"].Allowed)
+			assert.Equal(t, time.Date(2000, 4, 1, 0, 0, 0, 0, time.UTC), result["monthly"].Reset.UTC())
+		})
+	})
+}
+
+func TestFixedWindow_AlignedTimezone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// allowAt returns the result of the only quota of config at the given time
+	allowAt := func(t *testing.T, strategy *Strategy, config *Config, at time.Time) strategies.Result {
+		time.Sleep(time.Until(at))
+		results, err := strategy.Allow(t.Context(), config)
+		require.NoError(t, err)
+		return results[config.Quotas[0].Name]
+	}
+
+	// DST started on 2000-04-02 at 02:00 EST and ended on 2000-10-29 at 02:00 EDT
+	t.Run("daily window across spring forward", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			storage := newMockBackend()
+			t.Cleanup(func() { storage.Close() })
+			strategy := New(storage)
+			config := NewConfig().SetKey("tz").SetTimezone(ny).AddAlignedQuota("daily", 1, 24*time.Hour).Build()
+
+			result := allowAt(t, strategy, config, time.Date(2000, 4, 2, 0, 30, 0, 0, ny))
+			assert.True(t, result.Allowed)
+			assert.Equal(t, time.Date(2000, 4, 3, 0, 0, 0, 0, ny), result.Reset)
+			assert.Equal(t, 23*time.Hour, result.Reset.Sub(time.Date(2000, 4, 2, 0, 0, 0, 0, ny)))
+
+			result = allowAt(t, strategy, config, time.Date(2000, 4, 2, 23, 59, 0, 0, ny))
+			assert.False(t, result.Allowed)
+
+			result = allowAt(t, strategy, config, time.Date(2000, 4, 3, 0, 0, 0, 0, ny))
+			assert.True(t, result.Allowed, "a new day starts at local midnight")
+		})
+	})
+
+	t.Run("hourly window across spring forward", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			storage := newMockBackend()
+			t.Cleanup(func() { storage.Close() })
+			strategy := New(storage)
+			config := NewConfig().SetKey("tz").SetTimezone(ny).AddAlignedQuota("hourly", 1, time.Hour).Build()
+
+			result := allowAt(t, strategy, config, time.Date(2000, 4, 2, 1, 59, 0, 0, ny))
+			assert.True(t, result.Allowed)
+			assert.Equal(t, time.Date(2000, 4, 2, 3, 0, 0, 0, ny), result.Reset, "02:00 EST is 03:00 EDT")
+
+			result = allowAt(t, strategy, config, time.Date(2000, 4, 2, 3, 1, 0, 0, ny))
+			assert.True(t, result.Allowed)
+		})
+	})
+
+	t.Run("repeated hour at fall back", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			storage := newMockBackend()
+			t.Cleanup(func() { storage.Close() })
+			strategy := New(storage)
+			config := NewConfig().SetKey("tz").SetTimezone(ny).AddAlignedQuota("hourly", 1, time.Hour).Build()
+
+			firstOneThirty := time.Date(2000, 10, 29, 1, 30, 0, 0, ny)
+			result := allowAt(t, strategy, config, firstOneThirty)
+			assert.True(t, result.Allowed)
+
+			// 01:00 to 02:00 happens twice, the second time is still the same clock hour
+			result = allowAt(t, strategy, config, firstOneThirty.Add(time.Hour))
+			assert.False(t, result.Allowed)
+			assert.Equal(t, firstOneThirty.Add(90*time.Minute), result.Reset)
+		})
+	})
+
+	t.Run("monthly window resets at local midnight", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			storage := newMockBackend()
+			t.Cleanup(func() { storage.Close() })
+			strategy := New(storage)
+			config := NewConfig().SetKey("tz").AddAlignedQuota("monthly", 1, Month).Build().WithTimezone(ny)
+
+			result := allowAt(t, strategy, config, time.Date(2000, 10, 15, 0, 0, 0, 0, ny))
+			assert.True(t, result.Allowed)
+			assert.Equal(t, time.Date(2000, 11, 1, 0, 0, 0, 0, ny), result.Reset)
+
+			// Midnight UTC on the first is still October in New York
+			result = allowAt(t, strategy, config, time.Date(2000, 11, 1, 0, 0, 0, 0, time.UTC))
+			assert.False(t, result.Allowed)
+		})
+	})
+}
+
 func TestFixedWindow_ValidationDuplicateRates(t *testing.T) {
 	// Test 1: Valid unique ratios
 	t.Run("valid unique ratios", func(t *testing.T) {
@@ -523,3 +688,255 @@ func TestFixedWindow_InvalidConfig(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "fixed window strategy requires fixedwindow.Config")
 }
+
+func TestFixedWindow_Refund(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := newMockBackend()
+		t.Cleanup(func() { storage.Close() })
+		strategy := New(storage)
+
+		config := NewConfig().
+			SetKey("refund-key").
+			AddQuota("minute", 5, time.Minute).
+			AddQuota("hour", 50, time.Hour).
+			Build()
+
+		ctx := t.Context()
+
+		// Refund on fresh state is a no-op
+		require.NoError(t, strategy.Refund(ctx, config, 1))
+		assert.Empty(t, storage.store, "Refund should not create state")
+
+		for range 3 {
+			result, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			require.True(t, result["minute"].Allowed)
+		}
+
+		require.NoError(t, strategy.Refund(ctx, config, 2))
+		result, err := strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 4, result["minute"].Remaining, "Remaining should increase by refunded requests")
+		assert.Equal(t, 49, result["hour"].Remaining, "Refund should apply to all quotas")
+
+		// Refund beyond capacity is clamped
+		require.NoError(t, strategy.Refund(ctx, config, 10))
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 5, result["minute"].Remaining, "Remaining should not exceed limit")
+		assert.Equal(t, 50, result["hour"].Remaining, "Remaining should not exceed limit")
+	})
+}
+
+func TestFixedWindow_SoftLimit(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		strategy := New(newMockBackend())
+		config := NewConfig().
+			SetKey("test-key").
+			AddQuota("minute", 5, time.Minute).
+			AddQuota("hour", 100, time.Hour).
+			SetSoftLimit("minute", 3).
+			Build()
+		require.NoError(t, config.Validate())
+
+		ctx := t.Context()
+
+		// Up to the soft limit requests are allowed as usual
+		for i := range 3 {
+			result, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			assert.True(t, result["minute"].Allowed, "request %d", i)
+			assert.False(t, result["minute"].SoftLimited, "request %d", i)
+		}
+
+		// Between the soft and hard limits they are allowed but flagged
+		for i := range 2 {
+			result, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			assert.True(t, result["minute"].Allowed, "request %d", i)
+			assert.True(t, result["minute"].SoftLimited, "request %d", i)
+			assert.False(t, result["hour"].SoftLimited, "quota without soft limit")
+
+			peek, err := strategy.Peek(ctx, config)
+			require.NoError(t, err)
+			assert.Equal(t, i == 0, peek["minute"].SoftLimited, "peek denies once the quota is used up")
+		}
+
+		// Beyond the hard limit they are denied
+		result, err := strategy.Allow(ctx, config)
+		require.NoError(t, err)
+		assert.False(t, result["minute"].Allowed)
+		assert.False(t, result["minute"].SoftLimited)
+
+		// A new window starts below the soft limit again
+		time.Sleep(time.Minute)
+		result, err = strategy.Allow(ctx, config)
+		require.NoError(t, err)
+		assert.True(t, result["minute"].Allowed)
+		assert.False(t, result["minute"].SoftLimited)
+	})
+}
+
+func TestFixedWindow_SoftLimitValidation(t *testing.T) {
+	for _, soft := range []int{-1, 5, 6} {
+		config := NewConfig().AddQuota("default", 5, time.Minute).SetSoftLimit("default", soft).Build()
+		err := config.Validate()
+		require.ErrorIs(t, err, strategies.ErrInvalidLimit, "soft limit %d", soft)
+		assert.Contains(t, err.Error(), "soft limit")
+	}
+}
+
+func TestFixedWindow_IndependentQuotas(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := newMockBackend()
+		t.Cleanup(func() { storage.Close() })
+		strategy := New(storage)
+
+		config := NewConfig().
+			SetKey("independent-key").
+			AddQuota("minute", 5, time.Minute).
+			AddQuota("hour", 3, time.Hour).
+			SetIndependentQuotas(true).
+			Build()
+
+		ctx := t.Context()
+
+		for range 3 {
+			result, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			require.True(t, result.AllAllowed())
+		}
+		assert.Len(t, storage.store, 2, "Every quota should have its own key")
+		assert.Contains(t, storage.store, "independent-key:q:minute")
+		assert.Contains(t, storage.store, "independent-key:q:hour")
+		assert.NotContains(t, storage.store, "independent-key")
+
+		// The hour quota denies, the minute quota consumed before it is refunded
+		result, err := strategy.Allow(ctx, config)
+		require.NoError(t, err)
+		assert.False(t, result.AllAllowed(), "Denial should aggregate across keys")
+		assert.True(t, result["minute"].Allowed)
+		assert.Equal(t, 2, result["minute"].Remaining)
+		assert.False(t, result["hour"].Allowed)
+		assert.Equal(t, 0, result["hour"].Remaining)
+
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result["minute"].Remaining, "Denied request should not consume the minute quota")
+		assert.Equal(t, 0, result["hour"].Remaining)
+
+		states, err := strategy.Inspect(ctx, config)
+		require.NoError(t, err)
+		require.Len(t, states, 1)
+		assert.Len(t, states[0].Value, 2, "Inspect should merge the windows of every quota")
+
+		require.NoError(t, strategy.Refund(ctx, config, 1))
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result["minute"].Remaining)
+		assert.Equal(t, 1, result["hour"].Remaining)
+
+		require.NoError(t, strategy.Reset(ctx, config))
+		assert.Empty(t, storage.store, "Reset should delete every quota key")
+	})
+}
+
+// writeRecorder records the keys written through CheckAndSet
+type writeRecorder struct {
+	*mockBackend
+	writes map[string]int
+}
+
+func (w *writeRecorder) CheckAndSet(ctx context.Context, key string, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	ok, err := w.mockBackend.CheckAndSet(ctx, key, oldValue, newValue, expiration)
+	w.mu.Lock()
+	w.writes[key]++
+	w.mu.Unlock()
+	return ok, err
+}
+
+func TestFixedWindow_IndependentQuotasConcurrent(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := &writeRecorder{mockBackend: newMockBackend(), writes: make(map[string]int)}
+		strategy := New(storage)
+
+		minute := NewConfig().SetKey("parallel-key").AddQuota("minute", 100, time.Minute).SetIndependentQuotas(true).Build()
+		hour := NewConfig().SetKey("parallel-key").AddQuota("hour", 1000, time.Hour).SetIndependentQuotas(true).Build()
+
+		ctx := t.Context()
+		waitGroup := &sync.WaitGroup{}
+		for range 20 {
+			waitGroup.Go(func() {
+				_, err := strategy.Allow(ctx, minute)
+				assert.NoError(t, err)
+			})
+			waitGroup.Go(func() {
+				_, err := strategy.Allow(ctx, hour)
+				assert.NoError(t, err)
+			})
+		}
+		waitGroup.Wait()
+
+		// Updates of one quota never write the key of the other
+		assert.Len(t, storage.writes, 2)
+		assert.GreaterOrEqual(t, storage.writes["parallel-key:q:minute"], 20)
+		assert.GreaterOrEqual(t, storage.writes["parallel-key:q:hour"], 20)
+
+		combined := NewConfig().
+			SetKey("parallel-key").
+			AddQuota("minute", 100, time.Minute).
+			AddQuota("hour", 1000, time.Hour).
+			SetIndependentQuotas(true).
+			Build()
+		result, err := strategy.Peek(ctx, combined)
+		require.NoError(t, err)
+		assert.Equal(t, 80, result["minute"].Remaining)
+		assert.Equal(t, 980, result["hour"].Remaining)
+	})
+}
+
+func TestFixedWindow_WindowBoundaries(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := newMockBackend()
+		t.Cleanup(func() { storage.Close() })
+		strategy := New(storage)
+
+		config := NewConfig().
+			SetKey("bounds-key").
+			AddQuota("minute", 5, time.Minute).
+			AddAlignedQuota("hour", 50, time.Hour).
+			Build()
+
+		ctx := t.Context()
+		time.Sleep(90 * time.Minute)
+
+		peeked, err := strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		allowed, err := strategy.Allow(ctx, config)
+		require.NoError(t, err)
+
+		for _, result := range []strategies.Results{peeked, allowed} {
+			minute := result["minute"]
+			assert.True(t, minute.WindowStart.Equal(time.Now()), "Unaligned windows start at the first request")
+			assert.Equal(t, time.Minute, minute.WindowEnd.Sub(minute.WindowStart))
+			assert.True(t, minute.Reset.Equal(minute.WindowEnd))
+
+			hour := result["hour"]
+			assert.True(t, hour.WindowStart.Equal(time.Now().Truncate(time.Hour)), "Aligned windows start on the hour")
+			assert.Equal(t, time.Hour, hour.WindowEnd.Sub(hour.WindowStart))
+			assert.True(t, hour.Reset.Equal(hour.WindowEnd))
+		}
+
+		// Denied results report the window they are denied in
+		time.Sleep(10 * time.Second)
+		for range 5 {
+			_, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+		}
+		denied, err := strategy.Allow(ctx, config)
+		require.NoError(t, err)
+		assert.False(t, denied["minute"].Allowed)
+		assert.True(t, denied["minute"].WindowStart.Equal(allowed["minute"].WindowStart))
+		assert.True(t, denied["minute"].WindowEnd.Equal(allowed["minute"].WindowEnd))
+	})
+}
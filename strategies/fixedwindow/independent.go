@@ -0,0 +1,110 @@
+package fixedwindow
+
+import (
+	"context"
+	"maps"
+
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow/internal"
+)
+
+// QuotaKeyMarker separates the key from the quota name in the storage keys
+// of independent quotas, e.g. "api:user:q:hourly", see Config.IndependentQuotas
+const QuotaKeyMarker = ":q:"
+
+// quotaConfigs returns a config of every quota stored under its own key
+func (c *Config) quotaConfigs() []*Config {
+	configs := make([]*Config, len(c.Quotas))
+	for i, quota := range c.Quotas {
+		cfg := *c
+		cfg.Key = c.Key + QuotaKeyMarker + quota.Name
+		cfg.Quotas = []Quota{quota}
+		cfg.IndependentQuotas = false
+		configs[i] = &cfg
+	}
+	return configs
+}
+
+// allowIndependent consumes cost from the quotas one after the other. When a
+// quota denies, the quotas consumed before it are refunded and the others are
+// only read, so no quota keeps the cost of a denied request.
+func (f *Strategy) allowIndependent(ctx context.Context, config *Config, cost int) (map[string]internal.Result, error) {
+	configs := config.quotaConfigs()
+	results := make(map[string]internal.Result, len(configs))
+	for i, quotaConfig := range configs {
+		res, err := internal.AllowCost(ctx, f.storage, quotaConfig, cost)
+		if err != nil {
+			_ = f.refundIndependent(ctx, configs[:i], cost)
+			return nil, err
+		}
+		maps.Copy(results, res)
+		if res[quotaConfig.Quotas[0].Name].Allowed {
+			continue
+		}
+
+		if err := f.refundIndependent(ctx, configs[:i], cost); err != nil {
+			return nil, err
+		}
+		for _, consumed := range configs[:i] {
+			name := consumed.Quotas[0].Name
+			res := results[name]
+			res.Remaining = min(res.Remaining+cost, consumed.Quotas[0].Limit)
+			results[name] = res
+		}
+		rest, err := f.peekIndependent(ctx, configs[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(results, rest)
+		return results, nil
+	}
+	return results, nil
+}
+
+// peekIndependent reads the quotas of configs without consuming them
+func (f *Strategy) peekIndependent(ctx context.Context, configs []*Config) (map[string]internal.Result, error) {
+	results := make(map[string]internal.Result, len(configs))
+	for _, quotaConfig := range configs {
+		res, err := internal.Allow(ctx, f.storage, quotaConfig, internal.ReadOnly)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(results, res)
+	}
+	return results, nil
+}
+
+// refundIndependent refunds n from the quotas of configs
+func (f *Strategy) refundIndependent(ctx context.Context, configs []*Config, n int) error {
+	for _, quotaConfig := range configs {
+		if err := internal.Refund(ctx, f.storage, quotaConfig, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetIndependent deletes the keys of every quota
+func (f *Strategy) resetIndependent(ctx context.Context, config *Config) error {
+	for _, quotaConfig := range config.quotaConfigs() {
+		if err := internal.Reset(ctx, quotaConfig, f.storage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inspectIndependent returns the stored windows of every quota, and false
+// when no quota has stored state
+func (f *Strategy) inspectIndependent(ctx context.Context, config *Config) ([]FixedWindow, bool, error) {
+	var windows []FixedWindow
+	for _, quotaConfig := range config.quotaConfigs() {
+		stored, found, err := internal.Inspect(ctx, f.storage, quotaConfig.Key)
+		if err != nil {
+			return nil, false, err
+		}
+		if found {
+			windows = append(windows, stored...)
+		}
+	}
+	return windows, windows != nil, nil
+}
@@ -0,0 +1,97 @@
+package internal
+
+import "time"
+
+// Month is the window of a quota that resets on the first day of every month.
+//
+// Its nominal length of 30 days is used for rate ratio validation and for
+// unaligned quotas, aligned quotas use the actual length of each month.
+const Month = 30 * 24 * time.Hour
+
+// day is the longest aligned window other than Month
+const day = 24 * time.Hour
+
+// windowStart returns the start of the window containing now.
+//
+// Unaligned windows start at now. Aligned windows start at the calendar
+// boundary in loc before now: the first of the month for Month, otherwise
+// midnight plus a whole number of windows on the wall clock.
+func (q Quota) windowStart(now time.Time, loc *time.Location) time.Time {
+	if !q.Aligned {
+		return now
+	}
+
+	wall := wallClock(now, loc)
+	var start time.Time
+	if q.Window == Month {
+		start = atWall(time.Date(wall.Year(), wall.Month(), 1, 0, 0, 0, 0, time.UTC), loc)
+	} else {
+		midnight := time.Date(wall.Year(), wall.Month(), wall.Day(), 0, 0, 0, 0, time.UTC)
+		start = atWall(midnight.Add(wall.Sub(midnight).Truncate(q.Window)), loc)
+	}
+
+	// A wall clock hour repeated by a DST transition may end before now,
+	// continue from there so the window always contains now
+	for end := q.windowEnd(start, loc); !now.Before(end); end = q.windowEnd(start, loc) {
+		start = end
+	}
+	return start
+}
+
+// windowEnd returns when the window starting at start resets.
+//
+// Aligned windows end at the next calendar boundary in loc, so a day window
+// spanning a DST transition lasts 23 or 25 hours.
+func (q Quota) windowEnd(start time.Time, loc *time.Location) time.Time {
+	if !q.Aligned {
+		return start.Add(q.Window)
+	}
+
+	wall := wallClock(start, loc)
+	if q.Window == Month {
+		return atWall(time.Date(wall.Year(), wall.Month()+1, 1, 0, 0, 0, 0, time.UTC), loc)
+	}
+
+	midnight := time.Date(wall.Year(), wall.Month(), wall.Day(), 0, 0, 0, 0, time.UTC)
+	return atWall(midnight.Add(wall.Sub(midnight).Truncate(q.Window)+q.Window), loc)
+}
+
+// expired reports whether the window starting at start has ended at now
+func (q Quota) expired(start, now time.Time, loc *time.Location) bool {
+	return !now.Before(q.windowEnd(start, loc))
+}
+
+// corrupted reports whether window cannot result from counting requests: a
+// count over the limit by more than a window's worth, e.g. from external
+// writes, or a window ending more than two windows after now, e.g. written by
+// a clock that jumped ahead
+func (q Quota) corrupted(window FixedWindow, now time.Time, loc *time.Location) bool {
+	return window.Count-q.Limit > q.Limit ||
+		q.windowEnd(window.Start, loc).After(now.Add(q.Window).Add(q.Window))
+}
+
+// wallClock returns the wall clock reading of t in loc as a UTC time
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// atWall returns the instant the wall clock in loc shows wall, given as a UTC time.
+//
+// Wall clock times skipped by a DST transition are moved forward by the
+// length of the gap, repeated ones resolve to their first occurrence.
+func atWall(wall time.Time, loc *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	// time.Date moves skipped times back by the length of the gap
+	if gap := wall.Sub(wallClock(t, loc)); gap > 0 {
+		t = t.Add(gap)
+	}
+	return t
+}
+
+// ValidAlignment reports whether window can be aligned to calendar boundaries.
+//
+// Aligned windows must divide a day evenly or be Month.
+func ValidAlignment(window time.Duration) bool {
+	return window == Month || (window > 0 && window <= day && day%window == 0)
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuota_AlignedWindowContainsNow(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// DST started on 2024-03-10 at 02:00 EST and ended on 2024-11-03 at 02:00 EDT
+	springForward := time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)
+	fallBack := time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC)
+
+	for _, window := range []time.Duration{15 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour, 24 * time.Hour, Month} {
+		quota := Quota{Name: "q", Limit: 1, Window: window, Aligned: true}
+		for _, transition := range []time.Time{springForward, fallBack} {
+			for now := transition.Add(-3 * time.Hour); now.Before(transition.Add(3 * time.Hour)); now = now.Add(7 * time.Minute) {
+				start := quota.windowStart(now, ny)
+				end := quota.windowEnd(start, ny)
+				assert.False(t, now.Before(start), "window %v at %v starts at %v", window, now, start)
+				assert.True(t, now.Before(end), "window %v at %v ends at %v", window, now, end)
+				assert.False(t, quota.expired(start, now, ny))
+			}
+		}
+	}
+}
+
+func TestQuota_AlignedWindowSkippedHour(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	quota := Quota{Name: "q", Limit: 1, Window: 2 * time.Hour, Aligned: true}
+
+	// 02:00 to 03:00 does not exist on 2024-03-10, the 02:00 boundary is 03:00 EDT
+	now := time.Date(2024, 3, 10, 3, 30, 0, 0, ny)
+	start := quota.windowStart(now, ny)
+	assert.Equal(t, time.Date(2024, 3, 10, 3, 0, 0, 0, ny), start)
+	assert.Equal(t, time.Date(2024, 3, 10, 4, 0, 0, 0, ny), quota.windowEnd(start, ny))
+}
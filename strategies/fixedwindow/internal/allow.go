@@ -2,11 +2,12 @@ package internal
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"time"
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
-	"github.com/ajiwo/ratelimit/utils"
 )
 
 // AllowMode represents the operation mode for `Allow`
@@ -21,19 +22,25 @@ const (
 
 // Result contains the result of Allow operation
 type Result struct {
-	Allowed   bool
-	Remaining int
-	Reset     time.Time
+	Allowed     bool
+	Remaining   int
+	Reset       time.Time
+	WindowStart time.Time
 	// For internal use: indicates if state was updated (only meaningful in TryUpdate mode)
 	stateUpdated bool
 }
 
 type parameter struct {
-	key        string
-	maxRetries int
-	now        time.Time
-	quotas     []Quota
-	storage    backends.Backend
+	backoff      strategies.Backoff
+	cost         int
+	idleTTL      time.Duration
+	key          string
+	loc          *time.Location
+	maxClockSkew time.Duration
+	maxRetries   int
+	now          time.Time
+	quotas       []Quota
+	storage      backends.Backend
 }
 
 // Allow provides a unified implementation for both Allow and Peek operations
@@ -49,15 +56,7 @@ func Allow(
 		return nil, NewContextCanceledError(err)
 	}
 
-	maxRetries := config.GetMaxRetries()
-
-	p := &parameter{
-		storage:    storage,
-		key:        config.GetKey(),
-		now:        time.Now(),
-		quotas:     config.GetQuotas(),
-		maxRetries: maxRetries,
-	}
+	p := newParameter(ctx, storage, config)
 
 	// Read-only mode: just get current state and calculate results
 	if mode == ReadOnly {
@@ -68,63 +67,110 @@ func Allow(
 	return p.allowTryAndUpdate(ctx)
 }
 
+// AllowCost increments every quota by cost instead of one.
+//
+// The request is allowed only if all quotas have room for the full cost.
+func AllowCost(ctx context.Context, storage backends.Backend, config Config, cost int) (map[string]Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+	p.cost = cost
+
+	return p.allowTryAndUpdate(ctx)
+}
+
+// newParameter builds the operation parameters from config
+func newParameter(ctx context.Context, storage backends.Backend, config Config) *parameter {
+	maxRetries := config.GetMaxRetries()
+	loc := config.GetLocation()
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	return &parameter{
+		backoff:      config.GetRetryBackoff(),
+		idleTTL:      config.GetIdleTTL(),
+		cost:         1,
+		storage:      storage,
+		key:          config.GetKey(),
+		loc:          loc,
+		now:          strategies.Now(ctx),
+		quotas:       config.GetQuotas(),
+		maxRetries:   maxRetries,
+		maxClockSkew: config.GetMaxClockSkew(),
+	}
+}
+
 // allowReadOnly implements read-only mode using combined state
 func (p *parameter) allowReadOnly(ctx context.Context) (map[string]Result, error) {
-	results := make(map[string]Result, len(p.quotas))
-
 	// Get current combined state
 	quotaStates, _, err := p.getAndParseState(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a map for quick lookup of quota states by name
-	quotaStateMap := make(map[string]FixedWindow, len(quotaStates))
-	for _, state := range quotaStates {
-		quotaStateMap[state.Name] = state
+	// Normalize per-quota windows in-memory and calculate results
+	return p.calculateResults(p.normalizeWindows(ctx, quotaStates)), nil
+}
+
+// allowTryAndUpdate implements try-and-update mode, server-side when the
+// backend is a backends.WindowIncrementer and with CheckAndSet retries otherwise.
+// Backends increment windows with their own expiration, so an idle TTL takes
+// the CheckAndSet path.
+func (p *parameter) allowTryAndUpdate(ctx context.Context) (map[string]Result, error) {
+	if incrementer, ok := p.storage.(backends.WindowIncrementer); ok && !p.hasAlignedQuota() && p.idleTTL <= 0 {
+		results, err := p.allowIncrement(ctx, incrementer)
+		if !errors.Is(err, backends.ErrWindowsNotSupported) {
+			return results, err
+		}
 	}
+	return p.allowCheckAndSet(ctx)
+}
 
-	// Normalize per-quota windows in-memory and calculate results
+// hasAlignedQuota reports whether any quota is aligned to calendar boundaries,
+// backends.WindowIncrementer only knows windows that start at the first request
+func (p *parameter) hasAlignedQuota() bool {
 	for _, quota := range p.quotas {
-		name := quota.Name
-		var window FixedWindow
-		if existingState, exists := quotaStateMap[name]; exists {
-			window = existingState
-			// Check if current window has expired
-			if p.now.Sub(window.Start) >= quota.Window {
-				// Window has expired, use fresh state
-				window = FixedWindow{
-					Name:  name,
-					Count: 0,
-					Start: p.now,
-				}
-			}
-		} else {
-			// This quota doesn't exist in state yet, initialize it
-			window = FixedWindow{
-				Name:  name,
-				Count: 0,
-				Start: p.now,
-			}
+		if quota.Aligned {
+			return true
 		}
+	}
+	return false
+}
 
-		// Calculate remaining requests and reset time
-		remaining := max(quota.Limit-window.Count, 0)
-		resetTime := window.Start.Add(quota.Window)
+// allowIncrement updates all quotas in a single backend round trip
+func (p *parameter) allowIncrement(ctx context.Context, incrementer backends.WindowIncrementer) (map[string]Result, error) {
+	quotas := make([]backends.WindowQuota, len(p.quotas))
+	for i, quota := range p.quotas {
+		quotas[i] = backends.WindowQuota{Name: quota.Name, Limit: quota.Limit, Window: quota.Window, MaxClockSkew: p.maxClockSkew}
+	}
 
-		results[name] = Result{
-			Allowed:      remaining > 0,
-			Remaining:    remaining,
-			Reset:        resetTime,
-			stateUpdated: false,
+	data, allowed, err := incrementer.IncrementWindows(ctx, p.key, quotas, p.cost, p.now)
+	if err != nil {
+		if errors.Is(err, backends.ErrWindowsNotSupported) {
+			return nil, err
 		}
+		return nil, NewStateRetrievalError(err)
+	}
+
+	quotaStates, ok := decodeState(data)
+	if !ok {
+		return nil, NewStateParsingError()
 	}
 
-	return results, nil
+	// The backend already normalized and repaired the windows at p.now, this
+	// only aligns them with p.quotas
+	normalizedStates := p.normalizeWindows(ctx, quotaStates)
+	if !allowed {
+		return p.calculateResults(normalizedStates), nil
+	}
+	return p.calculateFinalResults(normalizedStates), nil
 }
 
-// allowTryAndUpdate implements try-and-update mode with retries using combined state
-func (p *parameter) allowTryAndUpdate(ctx context.Context) (map[string]Result, error) {
+// allowCheckAndSet implements try-and-update mode with retries using combined state
+func (p *parameter) allowCheckAndSet(ctx context.Context) (map[string]Result, error) {
 	// Try atomic CheckAndSet operations with combined state
 	for attempt := range p.maxRetries {
 		// Check if context is canceled or timed out
@@ -141,12 +187,24 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (map[string]Result, e
 		beforeCAS := time.Now()
 
 		// Normalize per-quota windows in-memory
-		normalizedStates := p.normalizeWindows(quotaStates)
+		normalizedStates := p.normalizeWindows(ctx, quotaStates)
 
 		// Evaluate allow for all quotas
 		allAllowed := p.areAllQuotasAllowed(normalizedStates)
 
 		if !allAllowed {
+			// Store windows clamped from the future, so that they end a window
+			// after the first clamp rather than after every request
+			if oldValue != "" && slices.ContainsFunc(quotaStates, p.skewed) {
+				newTTL := computeMaxResetTTL(normalizedStates, p.quotas, p.now, p.loc, p.idleTTL)
+				success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, encodeState(normalizedStates), newTTL)
+				if err != nil {
+					return nil, err
+				}
+				if !success {
+					continue
+				}
+			}
 			return p.calculateResults(normalizedStates), nil
 		}
 
@@ -155,7 +213,7 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (map[string]Result, e
 
 		// Use CheckAndSet for atomic update
 		newValue := encodeState(incrementedStates)
-		newTTL := computeMaxResetTTL(incrementedStates, p.quotas, p.now)
+		newTTL := computeMaxResetTTL(incrementedStates, p.quotas, p.now, p.loc, p.idleTTL)
 		success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, newValue, newTTL)
 		if err != nil {
 			return nil, err
@@ -168,11 +226,9 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (map[string]Result, e
 		}
 
 		feedback := time.Since(beforeCAS)
-		delay := strategies.NextDelay(attempt, feedback)
-
 		// If CheckAndSet failed, retry if we haven't exhausted attempts
 		if attempt < p.maxRetries-1 {
-			if err := utils.SleepOrWait(ctx, delay, 500*time.Millisecond); err != nil {
+			if err := p.backoff.Wait(ctx, attempt, feedback); err != nil {
 				return nil, NewContextCanceledError(err)
 			}
 			continue
@@ -200,7 +256,7 @@ func (p *parameter) getAndParseState(ctx context.Context) ([]FixedWindow, string
 			quotaStates = append(quotaStates, FixedWindow{
 				Name:  name,
 				Count: 0,
-				Start: p.now,
+				Start: quota.windowStart(p.now, p.loc),
 			})
 		}
 		oldValue = "" // Key doesn't exist
@@ -215,8 +271,14 @@ func (p *parameter) getAndParseState(ctx context.Context) ([]FixedWindow, string
 	return quotaStates, oldValue, nil
 }
 
-// normalizeWindows normalizes per-quota windows in-memory
-func (p *parameter) normalizeWindows(quotaStates []FixedWindow) []FixedWindow {
+// normalizeWindows normalizes per-quota windows in-memory.
+//
+// Expired windows start over at p.now, and so do corrupted ones, logging a
+// warning, see Quota.corrupted. Windows starting more than p.maxClockSkew
+// after p.now, e.g. written before the clock jumped backwards, are moved to
+// start at p.now with their count, logging a warning, so they end one window
+// from now at the latest.
+func (p *parameter) normalizeWindows(ctx context.Context, quotaStates []FixedWindow) []FixedWindow {
 	// Create a map for quick lookup of existing quota states
 	stateMap := make(map[string]FixedWindow, len(quotaStates))
 	for _, state := range quotaStates {
@@ -227,17 +289,31 @@ func (p *parameter) normalizeWindows(quotaStates []FixedWindow) []FixedWindow {
 	for _, quota := range p.quotas {
 		name := quota.Name
 		window := stateMap[name]
+		corrupted := quota.corrupted(window, p.now, p.loc)
+		if corrupted {
+			backends.Warn(ctx, "ratelimit: resetting corrupted fixed window state",
+				"key", p.key, "quota", name, "count", window.Count, "start", window.Start)
+		}
 		// Check if current window has expired
-		if p.now.Sub(window.Start) >= quota.Window {
+		if corrupted || quota.expired(window.Start, p.now, p.loc) {
 			// Start new window
 			window.Count = 0
-			window.Start = p.now
+			window.Start = quota.windowStart(p.now, p.loc)
+		} else if p.skewed(window) {
+			backends.Warn(ctx, "ratelimit: clamping fixed window starting in the future",
+				"key", p.key, "quota", name, "start", window.Start, "now", p.now)
+			window.Start = quota.windowStart(p.now, p.loc)
 		}
 		normalizedStates = append(normalizedStates, window)
 	}
 	return normalizedStates
 }
 
+// skewed reports whether window starts more than the tolerated clock skew after now
+func (p *parameter) skewed(window FixedWindow) bool {
+	return window.Start.After(p.now.Add(p.maxClockSkew))
+}
+
 // areAllQuotasAllowed checks if all quotas are allowed (have capacity)
 func (p *parameter) areAllQuotasAllowed(normalizedStates []FixedWindow) bool {
 	// Create a map for quick lookup of normalized states
@@ -249,7 +325,7 @@ func (p *parameter) areAllQuotasAllowed(normalizedStates []FixedWindow) bool {
 	for _, quota := range p.quotas {
 		name := quota.Name
 		window := stateMap[name]
-		if window.Count >= quota.Limit {
+		if window.Count+p.cost > quota.Limit {
 			return false
 		}
 	}
@@ -268,14 +344,15 @@ func (p *parameter) calculateResults(normalizedStates []FixedWindow) map[string]
 	for _, quota := range p.quotas {
 		name := quota.Name
 		window := stateMap[name]
-		allowed := window.Count < quota.Limit
+		allowed := window.Count+p.cost <= quota.Limit
 		remaining := max(quota.Limit-window.Count, 0)
-		resetTime := window.Start.Add(quota.Window)
+		resetTime := quota.windowEnd(window.Start, p.loc)
 
 		tempResults[name] = Result{
 			Allowed:      allowed,
 			Remaining:    remaining,
 			Reset:        resetTime,
+			WindowStart:  window.Start,
 			stateUpdated: false,
 		}
 	}
@@ -289,7 +366,7 @@ func (p *parameter) incrementAllQuotas(normalizedStates []FixedWindow) []FixedWi
 	for i, window := range normalizedStates {
 		incrementedStates[i] = FixedWindow{
 			Name:  window.Name,
-			Count: window.Count + 1,
+			Count: window.Count + p.cost,
 			Start: window.Start,
 		}
 	}
@@ -312,7 +389,8 @@ func (p *parameter) calculateFinalResults(normalizedStates []FixedWindow) map[st
 		finalResults[name] = Result{
 			Allowed:      true,
 			Remaining:    remaining,
-			Reset:        window.Start.Add(quota.Window),
+			Reset:        quota.windowEnd(window.Start, p.loc),
+			WindowStart:  window.Start,
 			stateUpdated: true,
 		}
 	}
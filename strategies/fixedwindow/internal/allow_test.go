@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -60,6 +61,22 @@ func (m *mockConfig) GetMaxRetries() int {
 	return args.Int(0)
 }
 
+func (m *mockConfig) GetRetryBackoff() strategies.Backoff {
+	return strategies.Backoff{}
+}
+
+func (m *mockConfig) GetIdleTTL() time.Duration {
+	return 0
+}
+
+func (m *mockConfig) GetLocation() *time.Location {
+	return nil
+}
+
+func (m *mockConfig) GetMaxClockSkew() time.Duration {
+	return time.Second
+}
+
 func TestAllow(t *testing.T) {
 	ctx := t.Context()
 	key := "test-key"
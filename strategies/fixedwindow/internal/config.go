@@ -1,6 +1,10 @@
 package internal
 
-import "time"
+import (
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
 
 // MaxQuota defines the maximum number of quota configurations allowed
 // in a fixed window rate limiter.
@@ -10,10 +14,16 @@ type Config interface {
 	GetKey() string
 	GetQuotas() []Quota
 	GetMaxRetries() int
+	GetRetryBackoff() strategies.Backoff
+	GetIdleTTL() time.Duration
+	GetLocation() *time.Location
+	GetMaxClockSkew() time.Duration
 }
 
 type Quota struct {
-	Name   string
-	Limit  int
-	Window time.Duration
+	Name      string
+	Limit     int
+	Window    time.Duration
+	Aligned   bool // Windows start on calendar boundaries instead of at the first request
+	SoftLimit int  // Requests beyond it are allowed but flagged SoftLimited, 0 disables
 }
@@ -3,11 +3,13 @@ package internal
 import (
 	"errors"
 	"fmt"
+
+	"github.com/ajiwo/ratelimit/strategies"
 )
 
 var (
 	ErrStateParsing     = errors.New("failed to parse fixed window state: invalid encoding")
-	ErrConcurrentAccess = errors.New("failed to update fixed window state after max attempts due to concurrent access")
+	ErrConcurrentAccess = fmt.Errorf("failed to update fixed window state after max attempts due to concurrent access: %w", strategies.ErrMaxRetriesExceeded)
 )
 
 // State operation error functions
@@ -20,7 +22,7 @@ func NewStateParsingError() error {
 }
 
 func NewStateUpdateError(attempts int) error {
-	return fmt.Errorf("failed to update fixed window state after %d attempts due to concurrent access", attempts)
+	return fmt.Errorf("failed to update fixed window state after %d attempts due to concurrent access: %w", attempts, strategies.ErrMaxRetriesExceeded)
 }
 
 func NewContextCanceledError(err error) error {
@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticConfig is a fixed Config for tests
+type staticConfig struct {
+	key    string
+	quotas []Quota
+}
+
+func (c staticConfig) GetKey() string                      { return c.key }
+func (c staticConfig) GetQuotas() []Quota                  { return c.quotas }
+func (c staticConfig) GetMaxRetries() int                  { return 10 }
+func (c staticConfig) GetRetryBackoff() strategies.Backoff { return strategies.Backoff{} }
+func (c staticConfig) GetLocation() *time.Location         { return nil }
+func (c staticConfig) GetIdleTTL() time.Duration           { return 0 }
+func (c staticConfig) GetMaxClockSkew() time.Duration      { return time.Second }
+
+// incrementingBackend implements backends.WindowIncrementer in Go on top of a
+// memory backend, following the contract the Postgres function implements
+type incrementingBackend struct {
+	backends.Backend
+	calls int
+	err   error
+}
+
+func (b *incrementingBackend) IncrementWindows(ctx context.Context, key string, quotas []backends.WindowQuota, cost int, now time.Time) (string, bool, error) {
+	b.calls++
+	if b.err != nil {
+		return "", false, b.err
+	}
+
+	data, err := b.Get(ctx, key)
+	if err != nil {
+		return "", false, err
+	}
+	stored, _ := decodeState(data)
+	byName := make(map[string]FixedWindow, len(stored))
+	for _, w := range stored {
+		byName[w.Name] = w
+	}
+
+	states := make([]FixedWindow, len(quotas))
+	allowed, repaired := true, false
+	for i, q := range quotas {
+		w, ok := byName[q.Name]
+		switch {
+		case ok && (w.Count-q.Limit > q.Limit || w.Start.After(now.Add(q.Window))):
+			w, repaired = FixedWindow{Name: q.Name, Start: now}, true
+		case !ok || now.Sub(w.Start) >= q.Window:
+			w = FixedWindow{Name: q.Name, Start: now}
+		case w.Start.After(now.Add(q.MaxClockSkew)):
+			w.Start, repaired = now, true
+		}
+		states[i] = w
+		allowed = allowed && w.Count+cost <= q.Limit
+	}
+	if !allowed && !repaired {
+		return encodeState(states), false, nil
+	}
+	var maxReset time.Time
+	for i, q := range quotas {
+		if allowed {
+			states[i].Count += cost
+		}
+		if reset := states[i].Start.Add(q.Window); reset.After(maxReset) {
+			maxReset = reset
+		}
+	}
+	ttl := maxReset.Sub(now)
+	if ttl < time.Second {
+		ttl = time.Second
+	} else {
+		ttl *= strategies.TTLFactor
+	}
+	state := encodeState(states)
+	return state, allowed, b.Set(ctx, key, state, ttl)
+}
+
+func TestAllow_WindowIncrementerMatchesCheckAndSet(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		config := staticConfig{key: "user", quotas: []Quota{
+			{Name: "second", Limit: 3, Window: time.Second},
+			{Name: "minute", Limit: 10, Window: time.Minute},
+			{Name: "hour", Limit: 50, Window: time.Hour},
+		}}
+		incrementer := &incrementingBackend{Backend: memory.New()}
+		plain := memory.New()
+		defer incrementer.Close()
+		defer plain.Close()
+
+		for step := range 40 {
+			cost := 1 + step%2
+			want, err := AllowCost(ctx, plain, config, cost)
+			require.NoError(t, err)
+			got, err := AllowCost(ctx, incrementer, config, cost)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "step %d", step)
+
+			if step%5 == 4 {
+				time.Sleep(700 * time.Millisecond)
+			}
+		}
+		assert.Equal(t, 40, incrementer.calls)
+
+		// Peek reads the state written by the incrementer
+		want, err := Allow(ctx, plain, config, ReadOnly)
+		require.NoError(t, err)
+		got, err := Allow(ctx, incrementer, config, ReadOnly)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+}
+
+func TestAllow_WindowIncrementerFallback(t *testing.T) {
+	ctx := t.Context()
+	config := staticConfig{key: "user", quotas: []Quota{{Name: "minute", Limit: 2, Window: time.Minute}}}
+
+	// Unsupported: falls back to CheckAndSet on the same backend
+	unsupported := &incrementingBackend{
+		Backend: memory.New(),
+		err:     errors.Join(backends.ErrWindowsNotSupported, errors.New("function missing")),
+	}
+	t.Cleanup(func() { _ = unsupported.Close() })
+	for i := range 3 {
+		res, err := Allow(ctx, unsupported, config, TryUpdate)
+		require.NoError(t, err)
+		assert.Equal(t, i < 2, res["minute"].Allowed, "request %d", i)
+	}
+
+	// Other errors are returned
+	errDown := backends.NewHealthError("test:IncrementWindows", errors.New("connection refused"))
+	failing := &incrementingBackend{Backend: memory.New(), err: errDown}
+	t.Cleanup(func() { _ = failing.Close() })
+	_, err := Allow(ctx, failing, config, TryUpdate)
+	assert.ErrorIs(t, err, errDown)
+	assert.True(t, backends.IsHealthError(err))
+}
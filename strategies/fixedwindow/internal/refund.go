@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+)
+
+// Refund decrements the request count of every quota's current window by n.
+//
+// Counts never drop below zero, and windows that have already expired are
+// not affected. A missing state has nothing to refund, so it is a no-op.
+func Refund(ctx context.Context, storage backends.Backend, config Config, n int) error {
+	if err := ctx.Err(); err != nil {
+		return NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	for attempt := range p.maxRetries {
+		if err := ctx.Err(); err != nil {
+			return NewContextCanceledError(err)
+		}
+
+		quotaStates, oldValue, err := p.getAndParseState(ctx)
+		if err != nil {
+			return err
+		}
+		if oldValue == "" {
+			return nil
+		}
+
+		beforeCAS := time.Now()
+
+		refundedStates := p.normalizeWindows(ctx, quotaStates)
+		for i := range refundedStates {
+			refundedStates[i].Count = max(refundedStates[i].Count-n, 0)
+		}
+
+		newValue := encodeState(refundedStates)
+		newTTL := computeMaxResetTTL(refundedStates, p.quotas, p.now, p.loc, p.idleTTL)
+		success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, newValue, newTTL)
+		if err != nil {
+			return err
+		}
+		if success {
+			return nil
+		}
+
+		if err := p.backoff.Wait(ctx, attempt, time.Since(beforeCAS)); err != nil {
+			return NewContextCanceledError(err)
+		}
+	}
+
+	return NewStateUpdateError(p.maxRetries)
+}
@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllow_RepairsCorruptedState(t *testing.T) {
+	quota := Quota{Name: "default", Limit: 10, Window: time.Minute}
+	config := staticConfig{key: "k", quotas: []Quota{quota}}
+
+	tests := []struct {
+		name      string
+		window    func(now time.Time) FixedWindow
+		repaired  bool
+		remaining int // Before repairing
+	}{
+		{
+			name:      "count over twice the limit",
+			window:    func(now time.Time) FixedWindow { return FixedWindow{Name: "default", Count: 25, Start: now} },
+			repaired:  true,
+			remaining: 10,
+		},
+		{
+			name: "window from a clock jump",
+			window: func(now time.Time) FixedWindow {
+				return FixedWindow{Name: "default", Count: 10, Start: now.Add(time.Hour)}
+			},
+			repaired:  true,
+			remaining: 10,
+		},
+		{
+			name:      "count over the limit within a window's worth",
+			window:    func(now time.Time) FixedWindow { return FixedWindow{Name: "default", Count: 15, Start: now} },
+			repaired:  false,
+			remaining: 0,
+		},
+	}
+	for _, tt := range tests {
+		for _, incrementing := range []bool{false, true} {
+			name := tt.name
+			if incrementing {
+				name += " server-side"
+			}
+			t.Run(name, func(t *testing.T) {
+				synctest.Test(t, func(t *testing.T) {
+					ctx := t.Context()
+					mem := memory.New()
+					defer mem.Close()
+					var storage backends.Backend = mem
+					if incrementing {
+						storage = &incrementingBackend{Backend: mem}
+					}
+					require.NoError(t, mem.Set(ctx, "k", encodeState([]FixedWindow{tt.window(time.Now())}), time.Hour))
+
+					results, err := Allow(ctx, mem, config, ReadOnly)
+					require.NoError(t, err)
+					assert.Equal(t, tt.remaining, results["default"].Remaining, "remaining is never negative")
+
+					results, err = Allow(ctx, storage, config, TryUpdate)
+					require.NoError(t, err)
+					assert.Equal(t, tt.repaired, results["default"].Allowed)
+
+					if tt.repaired {
+						assert.Equal(t, 9, results["default"].Remaining)
+						data, err := mem.Get(ctx, "k")
+						require.NoError(t, err)
+						states, ok := decodeState(data)
+						require.True(t, ok)
+						assert.Equal(t, 1, states[0].Count)
+						assert.Equal(t, time.Now(), states[0].Start)
+					}
+				})
+			})
+		}
+	}
+}
+
+func TestAllow_ClampsFutureWindowStart(t *testing.T) {
+	quota := Quota{Name: "default", Limit: 2, Window: time.Minute}
+	config := staticConfig{key: "k", quotas: []Quota{quota}}
+
+	for _, incrementing := range []bool{false, true} {
+		name := "check-and-set"
+		if incrementing {
+			name = "server-side"
+		}
+		t.Run(name, func(t *testing.T) {
+			synctest.Test(t, func(t *testing.T) {
+				ctx := t.Context()
+				mem := memory.New()
+				defer mem.Close()
+				var storage backends.Backend = mem
+				if incrementing {
+					storage = &incrementingBackend{Backend: mem}
+				}
+
+				// A full window written before the clock jumped back 45 seconds
+				// would deny requests for 1m45s
+				window := FixedWindow{Name: "default", Count: 2, Start: time.Now().Add(45 * time.Second)}
+				require.NoError(t, mem.Set(ctx, "k", encodeState([]FixedWindow{window}), time.Hour))
+
+				results, err := Allow(ctx, mem, config, ReadOnly)
+				require.NoError(t, err)
+				assert.Equal(t, time.Now().Add(time.Minute), results["default"].Reset)
+
+				// The count is kept, the window ends a minute from now
+				results, err = Allow(ctx, storage, config, TryUpdate)
+				require.NoError(t, err)
+				assert.False(t, results["default"].Allowed)
+				assert.Equal(t, time.Now().Add(time.Minute), results["default"].Reset)
+
+				// The clamped start is stored, so later denials do not move it
+				time.Sleep(30 * time.Second)
+				results, err = Allow(ctx, storage, config, TryUpdate)
+				require.NoError(t, err)
+				assert.False(t, results["default"].Allowed)
+				assert.Equal(t, time.Now().Add(30*time.Second), results["default"].Reset)
+
+				time.Sleep(30 * time.Second)
+				results, err = Allow(ctx, storage, config, TryUpdate)
+				require.NoError(t, err)
+				assert.True(t, results["default"].Allowed)
+			})
+		})
+	}
+}
+
+func TestAllow_ToleratesClockSkew(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		mem := memory.New()
+		defer mem.Close()
+		config := staticConfig{key: "k", quotas: []Quota{{Name: "default", Limit: 2, Window: time.Minute}}}
+
+		// A window started by a server whose clock is slightly ahead is kept
+		start := time.Now().Add(500 * time.Millisecond)
+		require.NoError(t, mem.Set(ctx, "k", encodeState([]FixedWindow{{Name: "default", Count: 1, Start: start}}), time.Hour))
+
+		results, err := Allow(ctx, mem, config, TryUpdate)
+		require.NoError(t, err)
+		assert.True(t, results["default"].Allowed)
+		assert.Equal(t, start.Add(time.Minute), results["default"].Reset)
+	})
+}
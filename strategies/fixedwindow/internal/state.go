@@ -1,9 +1,11 @@
 package internal
 
 import (
+	"context"
 	"strconv"
 	"time"
 
+	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/ajiwo/ratelimit/utils/builderpool"
 )
@@ -96,9 +98,9 @@ func parseStartTime(data string, isLastQuota bool) (int64, string, bool) {
 }
 
 func decodeState(s string) ([]FixedWindow, bool) {
-	// example minimal valid state:
-	// "23|1|a|1|0"
-	if len(s) < 10 || s[:3] != "23|" || s[4:5] != "|" {
+	// example minimal valid state, as encoded for an unnamed quota:
+	// "23|1||1|0"
+	if len(s) < 9 || s[:3] != "23|" || s[4:5] != "|" {
 		return nil, false
 	}
 
@@ -160,14 +162,15 @@ func findQuotaByName(name string, quotas []Quota) (Quota, bool) {
 }
 
 // computeMaxResetTTL calculates the TTL as the maximum reset time across all quotas minus now
-// with a minimum of 1 second (non-configurable)
-func computeMaxResetTTL(quotaStates []FixedWindow, quotas []Quota, now time.Time) time.Duration {
+// with a minimum of 1 second (non-configurable). A positive idleTTL replaces the
+// default margin, see strategies.IdleExpiration.
+func computeMaxResetTTL(quotaStates []FixedWindow, quotas []Quota, now time.Time, loc *time.Location, idleTTL time.Duration) time.Duration {
 	var maxReset time.Time
 
 	// Find the latest reset time across all quotas
 	for _, window := range quotaStates {
 		if quota, exists := findQuotaByName(window.Name, quotas); exists {
-			resetTime := window.Start.Add(quota.Window)
+			resetTime := quota.windowEnd(window.Start, loc)
 			if resetTime.After(maxReset) {
 				maxReset = resetTime
 			}
@@ -179,9 +182,28 @@ func computeMaxResetTTL(quotaStates []FixedWindow, quotas []Quota, now time.Time
 	}
 
 	ttl := maxReset.Sub(now)
+	if idleTTL > 0 {
+		return strategies.IdleExpiration(ttl, idleTTL)
+	}
 	if ttl < 1*time.Second {
 		return 1 * time.Second
 	}
 
 	return ttl * strategies.TTLFactor
 }
+
+// Inspect returns the stored state of key without modifying it, or false for a fresh key
+func Inspect(ctx context.Context, storage backends.Backend, key string) ([]FixedWindow, bool, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return nil, false, NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return nil, false, nil
+	}
+	windows, ok := decodeState(data)
+	if !ok {
+		return nil, false, ErrStateParsing
+	}
+	return windows, true, nil
+}
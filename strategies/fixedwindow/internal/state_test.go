@@ -130,7 +130,41 @@ func TestComputeMaxResetTTL(t *testing.T) {
 		},
 	}
 
-	ttl := computeMaxResetTTL(quotaStates, quotas, now)
+	ttl := computeMaxResetTTL(quotaStates, quotas, now, time.UTC, 0)
 	assert.True(t, ttl >= 30*time.Second)  // Default window hasn't expired
 	assert.True(t, ttl <= 300*time.Second) // Shouldn't be more than the max window
 }
+
+func FuzzDecodeState(f *testing.F) {
+	f.Add("23|1|default|2|1761884055342794596")
+	f.Add("23|2|minute|3|1761884055342794596|hour|7|1761884040000000000")
+	f.Add("23|1|a|1|0")
+	f.Add("23|9|a|1|0")
+	f.Add("23|2|a|1|0")
+	f.Add("23|1|a|-1|0")
+	f.Add("23|1|a|1|0|")
+	f.Add("23|1|\xff|1|0")
+	f.Add("23|1|a|99999999999999999999|0")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		states, ok := decodeState(s)
+		if !ok {
+			return
+		}
+		for _, state := range states {
+			if state.Count < 0 {
+				t.Fatalf("decoded negative count from %q", s)
+			}
+		}
+		decoded, ok := decodeState(encodeState(states))
+		if !ok || len(decoded) != len(states) {
+			t.Fatalf("re-encoding %q does not round trip", s)
+		}
+		for i := range states {
+			if decoded[i].Name != states[i].Name || decoded[i].Count != states[i].Count ||
+				!decoded[i].Start.Equal(states[i].Start) {
+				t.Fatalf("re-encoding %q does not round trip", s)
+			}
+		}
+	})
+}
@@ -2,35 +2,44 @@ package gcra
 
 import (
 	"fmt"
+	"math/rand/v2"
+	"time"
 
 	"github.com/ajiwo/ratelimit/strategies"
 )
 
+// MaxRate is the highest supported GCRA rate, one request per nanosecond.
+//
+// Higher rates would round the emission interval down to zero.
+const MaxRate = 1e9
+
 // Config implements the Config interface for Generic Cell Rate Algorithm (GCRA) rate limiting.
 //
 // GCRA uses a theoretical arrival time (TAT) to track when the next request would be allowed.
 // The algorithm updates the TAT based on the configured rate and burst parameters.
 type Config struct {
-	Key        string  // Storage key for GCRA state (theoretical arrival time)
-	Rate       float64 // Requests per second (sustained rate limit)
-	Burst      int     // Maximum burst size (concurrent request tolerance)
-	MaxRetries int     // Maximum retry attempts for atomic operations, 0 means use default
+	Key          string             // Storage key for GCRA state (theoretical arrival time)
+	Rate         float64            // Requests per second (sustained rate limit)
+	Burst        int                // Maximum burst size (concurrent request tolerance)
+	MaxRetries   int                // Maximum retry attempts for atomic operations, 0 means use default
+	RetryBackoff strategies.Backoff // Delay policy between retry attempts, zero value uses default
+	IdleTTL      time.Duration      // Expiration after the last request, zero uses default, see strategies.IdleTTLConfig
 }
 
 // Validate performs configuration validation for the GCRA strategy.
 //
 // Returns an error if any of the following conditions are met:
-//   - Rate <= 0
+//   - Rate <= 0, NaN, or above MaxRate
 //   - Burst <= 0
 //
 // Note: The Key field is not validated here as it may be set later
 // using WithKey() for dynamic key assignment.
 func (c *Config) Validate() error {
-	if c.Rate <= 0 {
-		return fmt.Errorf("gcra rate must be positive, got %f", c.Rate)
+	if !strategies.ValidRate(c.Rate) || c.Rate > MaxRate {
+		return fmt.Errorf("%w: gcra rate must be positive and at most %g, got %f", strategies.ErrInvalidRate, MaxRate, c.Rate)
 	}
 	if c.Burst <= 0 {
-		return fmt.Errorf("gcra burst must be positive, got %d", c.Burst)
+		return fmt.Errorf("%w: gcra burst must be positive, got %d", strategies.ErrInvalidBurst, c.Burst)
 	}
 	return nil
 }
@@ -72,6 +81,37 @@ func (c *Config) WithMaxRetries(retries int) strategies.Config {
 	return &cfg
 }
 
+// WithRetryBackoff returns a copy of the config with the provided retry backoff applied.
+//
+// This controls the delay between retry attempts for atomic operations
+// (CheckAndSet). A zero Backoff keeps the default feedback-based delay.
+func (c *Config) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	return &cfg
+}
+
+// WithRand returns a copy of the config drawing its retry jitter from rng.
+//
+// This method implements strategies.RandConfig and sets RetryBackoff.Rand,
+// keeping the rest of the backoff.
+func (c *Config) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff.Rand = rng
+	return &cfg
+}
+
+// WithIdleTTL returns a copy of the config with the provided idle TTL applied.
+//
+// The stored state expires idleTTL after the last request instead of the
+// default, but never before it is equivalent to a fresh key. A zero idleTTL
+// keeps the default expiration.
+func (c *Config) WithIdleTTL(idleTTL time.Duration) strategies.Config {
+	cfg := *c
+	cfg.IdleTTL = idleTTL
+	return &cfg
+}
+
 // GetBurst returns the maximum burst size for the GCRA strategy.
 //
 // This method implements the `internal.Config` interface used by the GCRA
@@ -110,3 +150,74 @@ func (c *Config) GetMaxRetries() int {
 	}
 	return c.Burst + 1
 }
+
+// GetRetryBackoff returns the configured delay policy between retry attempts.
+//
+// This method implements the internal.Config interface used by the GCRA
+// algorithm. A zero Backoff means the default feedback-based delay is used.
+func (c *Config) GetRetryBackoff() strategies.Backoff {
+	return c.RetryBackoff
+}
+
+// SteadyStateRate returns the long-run requests per second allowed, the sustained rate.
+//
+// This method implements strategies.SteadyStateRateConfig.
+func (c *Config) SteadyStateRate() float64 {
+	return c.Rate
+}
+
+// GetIdleTTL returns the configured expiration after the last request.
+//
+// This method implements the internal.Config interface used by the gcra
+// algorithm. A zero idle TTL means the default expiration is used.
+func (c *Config) GetIdleTTL() time.Duration {
+	return c.IdleTTL
+}
+
+// configBuilder builds a GCRA Config, see NewConfig
+type configBuilder struct {
+	config Config
+}
+
+// NewConfig creates a GCRA Config with a builder pattern, e.g.
+// NewConfig().SetBurst(10).SetRate(1).Build(), as an alternative to a
+// Config literal
+func NewConfig() *configBuilder {
+	return &configBuilder{}
+}
+
+// SetKey sets the key for the configuration
+func (b *configBuilder) SetKey(key string) *configBuilder {
+	b.config.Key = key
+	return b
+}
+
+// SetBurst sets the maximum requests allowed at once
+func (b *configBuilder) SetBurst(burst int) *configBuilder {
+	b.config.Burst = burst
+	return b
+}
+
+// SetRate sets the sustained requests per second
+func (b *configBuilder) SetRate(rate float64) *configBuilder {
+	b.config.Rate = rate
+	return b
+}
+
+// SetMaxRetries sets the maximum number of retries for the configuration
+func (b *configBuilder) SetMaxRetries(retries int) *configBuilder {
+	b.config.MaxRetries = retries
+	return b
+}
+
+// SetIdleTTL sets the expiration after the last request, see Config.IdleTTL
+func (b *configBuilder) SetIdleTTL(idleTTL time.Duration) *configBuilder {
+	b.config.IdleTTL = idleTTL
+	return b
+}
+
+// Build creates the Config from the builder, the builder may be reused
+func (b *configBuilder) Build() *Config {
+	cfg := b.config
+	return &cfg
+}
@@ -1,7 +1,9 @@
 package gcra
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/stretchr/testify/assert"
@@ -124,3 +126,63 @@ func TestConfig_Properties(t *testing.T) {
 	require.Equal(t, 10, config.WithMaxRetries(10).GetMaxRetries(),
 		"WithMaxRetries should update the max retries")
 }
+
+// TestConfig_ValidateBoundaries checks that unusable values fail with sentinel errors
+func TestConfig_ValidateBoundaries(t *testing.T) {
+	testCases := []struct {
+		name    string
+		burst   int
+		rate    float64
+		wantErr error
+	}{
+		{name: "smallest burst", burst: 1, rate: 1},
+		{name: "tiny rate", burst: 1, rate: 1e-6},
+		{name: "max rate", burst: 1, rate: MaxRate},
+		{name: "above max rate", burst: 1, rate: math.Nextafter(MaxRate, math.Inf(1)), wantErr: strategies.ErrInvalidRate},
+		{name: "zero burst", burst: 0, rate: 1, wantErr: strategies.ErrInvalidBurst},
+		{name: "negative burst", burst: -1, rate: 1, wantErr: strategies.ErrInvalidBurst},
+		{name: "zero rate", burst: 1, rate: 0, wantErr: strategies.ErrInvalidRate},
+		{name: "negative rate", burst: 1, rate: -1, wantErr: strategies.ErrInvalidRate},
+		{name: "NaN rate", burst: 1, rate: math.NaN(), wantErr: strategies.ErrInvalidRate},
+		{name: "infinite rate", burst: 1, rate: math.Inf(1), wantErr: strategies.ErrInvalidRate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{Key: "boundary", Burst: tc.burst, Rate: tc.rate}
+			err := config.Validate()
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestConfig_Builder(t *testing.T) {
+	built := NewConfig().
+		SetKey("user").
+		SetBurst(5).
+		SetRate(2.5).
+		SetMaxRetries(3).
+		SetIdleTTL(time.Hour).
+		Build()
+	literal := &Config{
+		Key:        "user",
+		Burst:      5,
+		Rate:       2.5,
+		MaxRetries: 3,
+		IdleTTL:    time.Hour,
+	}
+
+	assert.Equal(t, literal, built, "Builder should produce the same config as a literal")
+	require.NoError(t, built.Validate())
+
+	// Built configs are independent of the builder
+	builder := NewConfig().SetBurst(1).SetRate(1)
+	first := builder.Build()
+	builder.SetBurst(0)
+	assert.Equal(t, 1, first.Burst)
+	assert.Error(t, builder.Build().Validate())
+}
@@ -34,8 +34,10 @@ func (g *Strategy) Allow(ctx context.Context, config strategies.Config) (strateg
 	return map[string]strategies.Result{
 		"default": {
 			Allowed:   res.Allowed,
+			Limit:     gcraConfig.Burst,
 			Remaining: res.Remaining,
 			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
 		},
 	}, nil
 }
@@ -54,8 +56,10 @@ func (g *Strategy) Peek(ctx context.Context, config strategies.Config) (strategi
 	return map[string]strategies.Result{
 		"default": {
 			Allowed:   res.Allowed,
+			Limit:     gcraConfig.Burst,
 			Remaining: res.Remaining,
 			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
 		},
 	}, nil
 }
@@ -69,3 +73,57 @@ func (g *Strategy) Reset(ctx context.Context, config strategies.Config) error {
 
 	return g.storage.Delete(ctx, gcraConfig.Key)
 }
+
+// Refund returns n previously allowed requests to the GCRA burst allowance
+func (g *Strategy) Refund(ctx context.Context, config strategies.Config, n int) error {
+	gcraConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	return internal.Refund(ctx, g.storage, gcraConfig, n)
+}
+
+// AllowCost is like Allow but consumes cost units of quota, e.g. a request
+// weighted by payload size. The request is denied unless the full cost fits.
+func (g *Strategy) AllowCost(ctx context.Context, config strategies.Config, cost float64) (strategies.Results, error) {
+	gcraConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	res, err := internal.AllowCost(ctx, g.storage, gcraConfig, cost)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]strategies.Result{
+		"default": {
+			Allowed:   res.Allowed,
+			Limit:     gcraConfig.Burst,
+			Remaining: res.Remaining,
+			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
+		},
+	}, nil
+}
+
+// GCRA is the stored state of GCRA, see Strategy.Inspect
+type GCRA = internal.GCRA
+
+// Inspect returns the stored GCRA state of the key without modifying it, see strategies.Inspector
+func (g *Strategy) Inspect(ctx context.Context, config strategies.Config) ([]strategies.State, error) {
+	gcraConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	gcraState, found, err := internal.Inspect(ctx, g.storage, gcraConfig.Key)
+	if err != nil {
+		return nil, err
+	}
+	state := strategies.State{Strategy: strategies.StrategyGCRA}
+	if found {
+		state.Value = gcraState
+	}
+	return []strategies.State{state}, nil
+}
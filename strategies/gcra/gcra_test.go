@@ -453,3 +453,86 @@ func TestGCRA_EmissionIntervalCalculation(t *testing.T) {
 		})
 	}
 }
+
+func TestGCRA_Refund(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		storage := &mockBackend{store: make(map[string]string)}
+		strategy := New(storage)
+
+		config := &Config{
+			Key:   "refund-test-key",
+			Burst: 5,
+			Rate:  1.0,
+		}
+
+		// Refund on fresh state is a no-op
+		require.NoError(t, strategy.Refund(ctx, config, 1))
+		assert.Empty(t, storage.store, "Refund should not create state")
+
+		var remaining int
+		for range 3 {
+			result, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			require.True(t, result["default"].Allowed)
+			remaining = result["default"].Remaining
+		}
+
+		require.NoError(t, strategy.Refund(ctx, config, 2))
+		result, err := strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, remaining+2, result["default"].Remaining, "Remaining should increase by refunded requests")
+
+		// Refund beyond capacity is clamped
+		require.NoError(t, strategy.Refund(ctx, config, 10))
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 5, result["default"].Remaining, "Remaining should not exceed burst")
+	})
+}
+
+func TestGCRA_ResetTime(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		storage := &mockBackend{store: make(map[string]string)}
+		strategy := New(storage)
+
+		config := &Config{
+			Key:   "reset-time-key",
+			Rate:  2.0, // one request every 500ms
+			Burst: 10,
+		}
+
+		// A fresh limiter has its full burst now
+		result, err := strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, time.Now(), result["default"].Reset)
+
+		// 4 requests push the TAT 2s ahead: full burst again after 2s
+		for range 4 {
+			result, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, time.Now().Add(2*time.Second), result["default"].Reset)
+
+		time.Sleep(500 * time.Millisecond)
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, time.Now().Add(1500*time.Millisecond), result["default"].Reset)
+
+		// A denied request resets when one emission interval has passed
+		for range 7 {
+			_, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+		}
+		result, err = strategy.Allow(ctx, config)
+		require.NoError(t, err)
+		assert.False(t, result["default"].Allowed)
+		assert.Equal(t, time.Now().Add(500*time.Millisecond), result["default"].Reset)
+
+		time.Sleep(500 * time.Millisecond)
+		result, err = strategy.Allow(ctx, config)
+		require.NoError(t, err)
+		assert.True(t, result["default"].Allowed, "request should fit at the reported reset")
+	})
+}
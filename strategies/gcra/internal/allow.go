@@ -6,7 +6,6 @@ import (
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
-	"github.com/ajiwo/ratelimit/utils"
 )
 
 // AllowMode represents the operation mode for `Allow`
@@ -29,8 +28,11 @@ type Result struct {
 }
 
 type parameter struct {
+	backoff          strategies.Backoff
 	burst            int
+	cost             float64
 	emissionInterval time.Duration
+	idleTTL          time.Duration
 	key              string
 	limit            time.Duration
 	maxRetries       int
@@ -52,14 +54,42 @@ func Allow(
 		return Result{}, NewContextCanceledError(err)
 	}
 
+	p := newParameter(ctx, storage, config)
+
+	// Read-only mode: just get current state and calculate results
+	if mode == ReadOnly {
+		return p.allowReadOnly(ctx)
+	}
+
+	// Try-and-update mode: attempt to consume quota with retries
+	return p.consumeQuota(ctx)
+}
+
+// AllowCost advances the TAT by cost emission intervals instead of one.
+func AllowCost(ctx context.Context, storage backends.Backend, config Config, cost float64) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+	p.cost = cost
+
+	return p.consumeQuota(ctx)
+}
+
+// newParameter builds the operation parameters from config
+func newParameter(ctx context.Context, storage backends.Backend, config Config) *parameter {
 	maxRetries := config.GetMaxRetries()
 
-	now := time.Now()
+	now := strategies.Now(ctx)
 	emissionInterval := time.Duration(1e9/config.GetRate()) * time.Nanosecond
 	limit := time.Duration(float64(config.GetBurst()) * float64(emissionInterval))
 
-	p := &parameter{
+	return &parameter{
+		backoff:          config.GetRetryBackoff(),
+		idleTTL:          config.GetIdleTTL(),
 		burst:            config.GetBurst(),
+		cost:             1,
 		emissionInterval: emissionInterval,
 		key:              config.GetKey(),
 		limit:            limit,
@@ -68,14 +98,6 @@ func Allow(
 		rate:             config.GetRate(),
 		storage:          storage,
 	}
-
-	// Read-only mode: just get current state and calculate results
-	if mode == ReadOnly {
-		return p.allowReadOnly(ctx)
-	}
-
-	// Try-and-update mode: attempt to consume quota with retries
-	return p.consumeQuota(ctx)
 }
 
 // allowReadOnly implements read-only mode
@@ -91,7 +113,7 @@ func (p *parameter) allowReadOnly(ctx context.Context) (Result, error) {
 		return Result{
 			Allowed:      true,
 			Remaining:    p.burst,
-			Reset:        p.now.Add(p.limit),
+			Reset:        p.now,
 			stateUpdated: false,
 		}, nil
 	}
@@ -105,13 +127,10 @@ func (p *parameter) allowReadOnly(ctx context.Context) (Result, error) {
 	// Calculate remaining requests based on current TAT
 	remaining := p.calculateRemaining(state.TAT)
 
-	// Calculate reset time (when next request will be allowed)
-	resetTime := state.TAT.Add(p.limit)
-
 	return Result{
 		Allowed:      remaining > 0,
 		Remaining:    remaining,
-		Reset:        resetTime,
+		Reset:        p.fullAt(state.TAT),
 		stateUpdated: false,
 	}, nil
 }
@@ -154,7 +173,7 @@ func (p *parameter) consumeQuota(ctx context.Context) (Result, error) {
 		if state.TAT.After(p.now) {
 			newTAT = state.TAT
 		}
-		newTAT = newTAT.Add(p.emissionInterval)
+		newTAT = newTAT.Add(time.Duration(float64(p.emissionInterval) * p.cost))
 
 		// Check if request is allowed
 		allowed := newTAT.Sub(p.now) <= p.limit
@@ -170,7 +189,7 @@ func (p *parameter) consumeQuota(ctx context.Context) (Result, error) {
 
 			// Save updated state
 			newValue := encodeState(state)
-			expiration := strategies.CalcExpiration(p.burst, p.rate)
+			expiration := strategies.CalcIdleExpiration(p.burst, p.rate, p.idleTTL)
 
 			success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, newValue, expiration)
 			if err != nil {
@@ -182,26 +201,25 @@ func (p *parameter) consumeQuota(ctx context.Context) (Result, error) {
 				return Result{
 					Allowed:      true,
 					Remaining:    remaining,
-					Reset:        state.TAT.Add(p.limit),
+					Reset:        p.fullAt(state.TAT),
 					stateUpdated: true,
 				}, nil
 			}
 
 			feedback := time.Since(beforeCAS)
-			delay := strategies.NextDelay(attempt, feedback)
-
 			// If CheckAndSet failed, retry if we haven't exhausted attempts
 			if attempt < p.maxRetries-1 {
-				if err := utils.SleepOrWait(ctx, delay, 500*time.Millisecond); err != nil {
+				if err := p.backoff.Wait(ctx, attempt, feedback); err != nil {
 					return Result{}, NewContextCanceledError(err)
 				}
 				continue
 			}
 			break
 		} else {
-			// Request denied
-			remaining := 0
-			resetTime := newTAT
+			// Request denied, a costly request may be denied with quota left.
+			// It fits once newTAT is within the burst tolerance of the clock.
+			remaining := p.calculateRemaining(state.TAT)
+			resetTime := newTAT.Add(-p.limit)
 
 			return Result{
 				Allowed:      false,
@@ -216,6 +234,14 @@ func (p *parameter) consumeQuota(ctx context.Context) (Result, error) {
 	return Result{}, NewStateUpdateError(p.maxRetries)
 }
 
+// fullAt returns when the full burst is available again, i.e. the TAT has passed
+func (p *parameter) fullAt(tat time.Time) time.Time {
+	if tat.Before(p.now) {
+		return p.now
+	}
+	return tat
+}
+
 // calculateRemaining calculates the number of remaining requests based on current state
 func (p *parameter) calculateRemaining(tat time.Time) int {
 	if p.now.After(tat) {
@@ -1,8 +1,16 @@
 package internal
 
+import (
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
 type Config interface {
 	GetKey() string
 	GetBurst() int
 	GetRate() float64
 	GetMaxRetries() int
+	GetRetryBackoff() strategies.Backoff
+	GetIdleTTL() time.Duration
 }
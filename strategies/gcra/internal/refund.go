@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Refund moves the theoretical arrival time back by n emission intervals.
+//
+// The TAT is never moved before now, which corresponds to a full burst. A
+// missing or already caught-up state is left untouched.
+func Refund(ctx context.Context, storage backends.Backend, config Config, n int) error {
+	if err := ctx.Err(); err != nil {
+		return NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	for attempt := range p.maxRetries {
+		if err := ctx.Err(); err != nil {
+			return NewContextCanceledError(err)
+		}
+
+		data, err := p.storage.Get(ctx, p.key)
+		if err != nil {
+			return NewStateRetrievalError(err)
+		}
+		if data == "" {
+			return nil
+		}
+
+		state, ok := decodeState(data)
+		if !ok {
+			return ErrStateParsing
+		}
+		if !state.TAT.After(p.now) {
+			return nil
+		}
+
+		state.TAT = state.TAT.Add(-time.Duration(n) * p.emissionInterval)
+		if state.TAT.Before(p.now) {
+			state.TAT = p.now
+		}
+
+		beforeCAS := time.Now()
+		newValue := encodeState(state)
+		expiration := strategies.CalcIdleExpiration(p.burst, p.rate, p.idleTTL)
+
+		success, err := p.storage.CheckAndSet(ctx, p.key, data, newValue, expiration)
+		if err != nil {
+			return NewStateSaveError(err)
+		}
+		if success {
+			return nil
+		}
+
+		if err := p.backoff.Wait(ctx, attempt, time.Since(beforeCAS)); err != nil {
+			return NewContextCanceledError(err)
+		}
+	}
+
+	return NewStateUpdateError(p.maxRetries)
+}
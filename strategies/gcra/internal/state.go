@@ -1,9 +1,11 @@
 package internal
 
 import (
+	"context"
 	"strconv"
 	"time"
 
+	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/utils/builderpool"
 )
 
@@ -53,3 +55,19 @@ func parseStateFields(data string) (int64, bool) {
 
 	return tat, true
 }
+
+// Inspect returns the stored state of key without modifying it, or false for a fresh key
+func Inspect(ctx context.Context, storage backends.Backend, key string) (GCRA, bool, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return GCRA{}, false, NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return GCRA{}, false, nil
+	}
+	state, ok := decodeState(data)
+	if !ok {
+		return GCRA{}, false, ErrStateParsing
+	}
+	return state, true, nil
+}
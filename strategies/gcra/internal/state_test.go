@@ -107,3 +107,22 @@ func TestParseStateFields(t *testing.T) {
 		assert.False(t, ok)
 	})
 }
+
+func FuzzDecodeState(f *testing.F) {
+	f.Add("42|1761884055342794596")
+	f.Add("42|-1")
+	f.Add("42|99999999999999999999")
+	f.Add("42|")
+	f.Add("42|\xff")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		state, ok := decodeState(s)
+		if !ok {
+			return
+		}
+		decoded, ok := decodeState(encodeState(state))
+		if !ok || !decoded.TAT.Equal(state.TAT) {
+			t.Fatalf("re-encoding %q does not round trip", s)
+		}
+	})
+}
@@ -18,5 +18,50 @@ type Strategy interface {
 	// All strategies treat empty state as a fresh request with full quota available.
 	// After Reset, the next Allow call will behave identically to a new user or
 	// a request whose previous state has expired due to TTL.
+	//
+	// Reset must delete every storage key derived from the config key. Strategies
+	// wrapping others, like the composite of a dual strategy limiter, delegate
+	// so that no tier keeps state.
 	Reset(ctx context.Context, config Config) error
 }
+
+// Refunder is implemented by strategies that can return previously consumed quota.
+//
+// Refund gives back n units consumed by earlier Allow calls, e.g. when the
+// downstream work failed and should not count against the limit. Refunds are
+// clamped so that quota never exceeds the configured capacity; refunding a
+// fresh (or fully recovered) state is a no-op.
+type Refunder interface {
+	Refund(ctx context.Context, config Config, n int) error
+}
+
+// CostAllower is implemented by strategies that can consume a variable amount
+// of quota per request.
+//
+// AllowCost behaves like Allow but consumes cost units instead of one, so a
+// request is only allowed when the whole cost fits. Cost must be positive.
+type CostAllower interface {
+	AllowCost(ctx context.Context, config Config, cost float64) (Results, error)
+}
+
+// Inspector is implemented by strategies that can decode their stored state,
+// for troubleshooting why a key is limited.
+//
+// Inspect returns the state of every tier of the key, a single one for plain
+// strategies, without modifying it. A fresh key has tiers with a nil Value.
+type Inspector interface {
+	Inspect(ctx context.Context, config Config) ([]State, error)
+}
+
+// State is the decoded stored state of a strategy tier, see Inspector
+type State struct {
+	// Tier is the results prefix of the tier, e.g. "primary_" or "tier2_",
+	// empty for single strategies
+	Tier     string
+	Strategy ID
+	// Value is the stored state as persisted, nil for a fresh key: a
+	// []fixedwindow.FixedWindow, tokenbucket.TokenBucket,
+	// leakybucket.LeakyBucket, gcra.GCRA, approx.Counter or
+	// concurrency.Leases for the built-in strategies
+	Value any
+}
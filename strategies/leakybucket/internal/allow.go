@@ -6,7 +6,6 @@ import (
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
-	"github.com/ajiwo/ratelimit/utils"
 )
 
 // AllowMode represents the operation mode for `Allow`
@@ -29,7 +28,10 @@ type Result struct {
 }
 
 type parameter struct {
+	backoff    strategies.Backoff
 	capacity   int
+	cost       float64
+	idleTTL    time.Duration
 	key        string
 	leakRate   float64
 	maxRetries int
@@ -50,16 +52,7 @@ func Allow(
 		return Result{}, NewContextCanceledError(err)
 	}
 
-	maxRetries := config.GetMaxRetries()
-
-	p := &parameter{
-		storage:    storage,
-		key:        config.GetKey(),
-		now:        time.Now(),
-		leakRate:   config.GetRate(),
-		capacity:   config.GetBurst(),
-		maxRetries: maxRetries,
-	}
+	p := newParameter(ctx, storage, config)
 
 	// Read-only mode: just get current state and calculate results
 	if mode == ReadOnly {
@@ -70,6 +63,37 @@ func Allow(
 	return p.allowTryAndUpdate(ctx)
 }
 
+// AllowCost adds cost requests to the bucket instead of one.
+//
+// The request is allowed only if the bucket has room for all of them.
+func AllowCost(ctx context.Context, storage backends.Backend, config Config, cost float64) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+	p.cost = cost
+
+	return p.allowTryAndUpdate(ctx)
+}
+
+// newParameter builds the operation parameters from config
+func newParameter(ctx context.Context, storage backends.Backend, config Config) *parameter {
+	maxRetries := config.GetMaxRetries()
+
+	return &parameter{
+		backoff:    config.GetRetryBackoff(),
+		idleTTL:    config.GetIdleTTL(),
+		storage:    storage,
+		key:        config.GetKey(),
+		now:        strategies.Now(ctx),
+		leakRate:   config.GetRate(),
+		capacity:   config.GetBurst(),
+		cost:       1,
+		maxRetries: maxRetries,
+	}
+}
+
 // allowReadOnly implements read-only mode
 func (p *parameter) allowReadOnly(ctx context.Context) (Result, error) {
 	// Get current state
@@ -96,11 +120,7 @@ func (p *parameter) allowReadOnly(ctx context.Context) (Result, error) {
 		return Result{}, ErrStateParsing
 	}
 
-	// Leak requests based on time elapsed
-	timeElapsed := p.now.Sub(bucket.LastLeak).Seconds()
-	requestsToLeak := timeElapsed * p.leakRate
-	bucket.Requests = max(0.0, bucket.Requests-requestsToLeak)
-	bucket.LastLeak = p.now
+	bucket = p.leak(bucket)
 
 	// Calculate remaining capacity
 	remaining := max(p.capacity-int(bucket.Requests), 0)
@@ -108,7 +128,7 @@ func (p *parameter) allowReadOnly(ctx context.Context) (Result, error) {
 	return Result{
 		Allowed:      remaining > 0,
 		Remaining:    remaining,
-		Reset:        p.now, // Leaky buckets don't have a reset time
+		Reset:        p.emptyAt(bucket),
 		stateUpdated: false,
 	}, nil
 }
@@ -146,28 +166,24 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 			}
 			oldValue = data
 
-			// Leak requests based on elapsed time
-			elapsed := p.now.Sub(bucket.LastLeak)
-			requestsToLeak := float64(elapsed.Nanoseconds()) * p.leakRate / 1e9
-			bucket.Requests = max(0.0, bucket.Requests-requestsToLeak)
-			bucket.LastLeak = p.now
+			bucket = p.leak(bucket)
 		}
 
 		// Calculate if request is allowed
-		allowed := bucket.Requests+1 <= float64(p.capacity)
+		allowed := bucket.Requests+p.cost <= float64(p.capacity)
 
 		if allowed {
 			beforeCAS := time.Now()
 
 			// Add request to bucket
-			bucket.Requests += 1.0
+			bucket.Requests += p.cost
 
 			// Calculate remaining capacity after adding request
 			remaining := max(p.capacity-int(bucket.Requests), 0)
 
 			// Save updated bucket state
 			newValue := encodeState(bucket)
-			expiration := strategies.CalcExpiration(p.capacity, p.leakRate)
+			expiration := strategies.CalcIdleExpiration(p.capacity, p.leakRate, p.idleTTL)
 
 			success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, newValue, expiration)
 			if err != nil {
@@ -179,17 +195,15 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 				return Result{
 					Allowed:      true,
 					Remaining:    remaining,
-					Reset:        p.now, // When allowed, no specific reset needed
+					Reset:        p.emptyAt(bucket),
 					stateUpdated: true,
 				}, nil
 			}
 
 			feedback := time.Since(beforeCAS)
-			delay := strategies.NextDelay(attempt, feedback)
-
 			// If CheckAndSet failed, retry if we haven't exhausted attempts
 			if attempt < p.maxRetries-1 {
-				if err := utils.SleepOrWait(ctx, delay, 500*time.Millisecond); err != nil {
+				if err := p.backoff.Wait(ctx, attempt, feedback); err != nil {
 					return Result{}, NewContextCanceledError(err)
 				}
 				continue
@@ -202,7 +216,7 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 			return Result{
 				Allowed:      false,
 				Remaining:    remaining,
-				Reset:        calculateResetTime(p.now, bucket, p.capacity, p.leakRate),
+				Reset:        calculateResetTime(p.now, bucket, p.capacity, p.leakRate, p.cost),
 				stateUpdated: oldValue == "",
 			}, nil
 		}
@@ -212,21 +226,47 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 	return Result{}, ErrConcurrentAccess
 }
 
+// leak removes the requests leaked since the last leak.
+//
+// Peek, Allow and Refund share this computation. A last leak in the future,
+// written by a concurrent caller whose clock read came later, or by another
+// instance with clock skew, leaks nothing and is kept, so the same time is
+// never leaked twice and no phantom requests are added.
+func (p *parameter) leak(bucket LeakyBucket) LeakyBucket {
+	if !p.now.After(bucket.LastLeak) {
+		return bucket
+	}
+	elapsed := p.now.Sub(bucket.LastLeak)
+	requestsToLeak := float64(elapsed.Nanoseconds()) * p.leakRate / 1e9
+	bucket.Requests = max(0.0, bucket.Requests-requestsToLeak)
+	bucket.LastLeak = p.now
+	return bucket
+}
+
+// emptyAt returns when the bucket will have leaked all requests, i.e. full capacity is back
+func (p *parameter) emptyAt(bucket LeakyBucket) time.Time {
+	if bucket.Requests <= 0 {
+		return p.now
+	}
+	return p.now.Add(time.Duration(bucket.Requests / p.leakRate * float64(time.Second)))
+}
+
 // calculateResetTime calculates when the bucket will have capacity for another request
 func calculateResetTime(
 	now time.Time,
 	bucket LeakyBucket,
 	capacity int,
 	leakRate float64,
+	cost float64,
 
 ) time.Time {
-	if bucket.Requests < float64(capacity) {
+	if bucket.Requests+cost <= float64(capacity) {
 		// Already has capacity, no reset needed
 		return now
 	}
 
-	// Calculate time to leak (bucket.Requests - capacity + 1) requests
-	requestsToLeak := bucket.Requests - float64(capacity) + 1
+	// Calculate time to leak (bucket.Requests - capacity + cost) requests
+	requestsToLeak := bucket.Requests - float64(capacity) + cost
 	if requestsToLeak <= 0 {
 		return now
 	}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -65,6 +66,14 @@ func (m *mockConfig) GetMaxRetries() int {
 	return args.Int(0)
 }
 
+func (m *mockConfig) GetRetryBackoff() strategies.Backoff {
+	return strategies.Backoff{}
+}
+
+func (m *mockConfig) GetIdleTTL() time.Duration {
+	return 0
+}
+
 func TestAllow(t *testing.T) {
 	ctx := t.Context()
 	key := "test-key"
@@ -149,6 +158,20 @@ func TestAllow(t *testing.T) {
 			assert.Error(t, err)
 			assert.Equal(t, ErrStateParsing, err)
 		})
+
+		t.Run("non-finite state", func(t *testing.T) {
+			storage := new(mockBackend)
+			config := new(mockConfig)
+
+			config.On("GetKey").Return(key)
+			config.On("GetBurst").Return(capacity)
+			config.On("GetMaxRetries").Return(maxRetries)
+			config.On("GetRate").Return(leakRate)
+			storage.On("Get", ctx, key).Return("32|NaN|1761884055342794596", nil)
+
+			_, err := Allow(ctx, storage, config, ReadOnly)
+			assert.Equal(t, ErrStateParsing, err)
+		})
 	})
 
 	t.Run("TryUpdate mode", func(t *testing.T) {
@@ -290,7 +313,7 @@ func TestCalculateResetTime(t *testing.T) {
 
 	t.Run("bucket has capacity", func(t *testing.T) {
 		bucket := LeakyBucket{Requests: 5.0} // Less than capacity
-		resetTime := calculateResetTime(now, bucket, capacity, leakRate)
+		resetTime := calculateResetTime(now, bucket, capacity, leakRate, 1)
 		assert.Equal(t, now, resetTime)
 	})
 
@@ -300,7 +323,7 @@ func TestCalculateResetTime(t *testing.T) {
 		timeToLeakSeconds := requestsToLeak / leakRate
 		expectedResetTime := now.Add(time.Duration(timeToLeakSeconds * float64(time.Second)))
 
-		resetTime := calculateResetTime(now, bucket, capacity, leakRate)
+		resetTime := calculateResetTime(now, bucket, capacity, leakRate, 1)
 		assert.WithinDuration(t, expectedResetTime, resetTime, 1*time.Millisecond)
 	})
 
@@ -310,7 +333,28 @@ func TestCalculateResetTime(t *testing.T) {
 		timeToLeakSeconds := requestsToLeak / leakRate
 		expectedResetTime := now.Add(time.Duration(timeToLeakSeconds * float64(time.Second)))
 
-		resetTime := calculateResetTime(now, bucket, capacity, leakRate)
+		resetTime := calculateResetTime(now, bucket, capacity, leakRate, 1)
 		assert.WithinDuration(t, expectedResetTime, resetTime, 1*time.Millisecond)
 	})
 }
+
+func TestLeak(t *testing.T) {
+	now := time.Now()
+	p := &parameter{now: now, leakRate: 2, capacity: 10}
+
+	t.Run("leaks elapsed time", func(t *testing.T) {
+		bucket := p.leak(LeakyBucket{Requests: 5, LastLeak: now.Add(-time.Second)})
+		assert.Equal(t, LeakyBucket{Requests: 3, LastLeak: now}, bucket)
+	})
+
+	t.Run("never below empty", func(t *testing.T) {
+		bucket := p.leak(LeakyBucket{Requests: 1, LastLeak: now.Add(-time.Minute)})
+		assert.Equal(t, LeakyBucket{Requests: 0, LastLeak: now}, bucket)
+	})
+
+	t.Run("last leak in the future", func(t *testing.T) {
+		// Written by a concurrent caller whose clock read came after ours
+		future := LeakyBucket{Requests: 5, LastLeak: now.Add(time.Millisecond)}
+		assert.Equal(t, future, p.leak(future))
+	})
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Refund removes n previously added requests from the bucket.
+//
+// The request count never drops below zero. A missing state is already an
+// empty bucket, so the refund is a no-op.
+func Refund(ctx context.Context, storage backends.Backend, config Config, n int) error {
+	if err := ctx.Err(); err != nil {
+		return NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	for attempt := range p.maxRetries {
+		if err := ctx.Err(); err != nil {
+			return NewContextCanceledError(err)
+		}
+
+		data, err := p.storage.Get(ctx, p.key)
+		if err != nil {
+			return NewStateRetrievalError(err)
+		}
+		if data == "" {
+			return nil
+		}
+
+		bucket, ok := decodeState(data)
+		if !ok {
+			return ErrStateParsing
+		}
+
+		bucket = p.leak(bucket)
+		bucket.Requests = max(0.0, bucket.Requests-float64(n))
+
+		beforeCAS := time.Now()
+		newValue := encodeState(bucket)
+		expiration := strategies.CalcIdleExpiration(p.capacity, p.leakRate, p.idleTTL)
+
+		success, err := p.storage.CheckAndSet(ctx, p.key, data, newValue, expiration)
+		if err != nil {
+			return NewStateSaveError(err)
+		}
+		if success {
+			return nil
+		}
+
+		if err := p.backoff.Wait(ctx, attempt, time.Since(beforeCAS)); err != nil {
+			return NewContextCanceledError(err)
+		}
+	}
+
+	return ErrConcurrentAccess
+}
@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"context"
+	"math"
 	"strconv"
 	"time"
 
+	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/utils/builderpool"
 )
 
@@ -59,7 +62,7 @@ func decodeState(s string) (LeakyBucket, bool) {
 	data := s[3:] // Skip "32|"
 
 	req, last, ok := parseStateFields(data)
-	if !ok {
+	if !ok || math.IsNaN(req) || math.IsInf(req, 0) {
 		return LeakyBucket{}, false
 	}
 
@@ -68,3 +71,19 @@ func decodeState(s string) (LeakyBucket, bool) {
 		LastLeak: time.Unix(0, last),
 	}, true
 }
+
+// Inspect returns the stored state of key without modifying it, or false for a fresh key
+func Inspect(ctx context.Context, storage backends.Backend, key string) (LeakyBucket, bool, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return LeakyBucket{}, false, NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return LeakyBucket{}, false, nil
+	}
+	bucket, ok := decodeState(data)
+	if !ok {
+		return LeakyBucket{}, false, ErrStateParsing
+	}
+	return bucket, true, nil
+}
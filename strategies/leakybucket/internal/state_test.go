@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -133,3 +134,28 @@ func TestParseStateFields(t *testing.T) {
 		assert.Equal(t, int64(1234567890), last)
 	})
 }
+
+func FuzzDecodeState(f *testing.F) {
+	f.Add("32|123.45|1761884055342794596")
+	f.Add("32|0|0")
+	f.Add("32|NaN|0")
+	f.Add("32|-Inf|0")
+	f.Add("32|1e400|0")
+	f.Add("32|1|99999999999999999999")
+	f.Add("32||")
+	f.Add("32|\xff|0")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		bucket, ok := decodeState(s)
+		if !ok {
+			return
+		}
+		if math.IsNaN(bucket.Requests) || math.IsInf(bucket.Requests, 0) {
+			t.Fatalf("decoded non-finite requests from %q", s)
+		}
+		decoded, ok := decodeState(encodeState(bucket))
+		if !ok || decoded.Requests != bucket.Requests || !decoded.LastLeak.Equal(bucket.LastLeak) {
+			t.Fatalf("re-encoding %q does not round trip", s)
+		}
+	})
+}
@@ -34,8 +34,10 @@ func (l *Strategy) Allow(ctx context.Context, config strategies.Config) (strateg
 	return map[string]strategies.Result{
 		"default": {
 			Allowed:   res.Allowed,
+			Limit:     lbConfig.Burst,
 			Remaining: res.Remaining,
 			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
 		},
 	}, nil
 }
@@ -54,8 +56,10 @@ func (l *Strategy) Peek(ctx context.Context, config strategies.Config) (strategi
 	return map[string]strategies.Result{
 		"default": {
 			Allowed:   res.Allowed,
+			Limit:     lbConfig.Burst,
 			Remaining: res.Remaining,
 			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
 		},
 	}, nil
 }
@@ -69,3 +73,57 @@ func (l *Strategy) Reset(ctx context.Context, config strategies.Config) error {
 
 	return l.storage.Delete(ctx, lbConfig.Key)
 }
+
+// Refund removes n previously allowed requests from the leaky bucket
+func (l *Strategy) Refund(ctx context.Context, config strategies.Config, n int) error {
+	lbConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	return internal.Refund(ctx, l.storage, lbConfig, n)
+}
+
+// AllowCost is like Allow but consumes cost units of quota, e.g. a request
+// weighted by payload size. The request is denied unless the full cost fits.
+func (l *Strategy) AllowCost(ctx context.Context, config strategies.Config, cost float64) (strategies.Results, error) {
+	lbConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	res, err := internal.AllowCost(ctx, l.storage, lbConfig, cost)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]strategies.Result{
+		"default": {
+			Allowed:   res.Allowed,
+			Limit:     lbConfig.Burst,
+			Remaining: res.Remaining,
+			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
+		},
+	}, nil
+}
+
+// LeakyBucket is the stored state of a leaky bucket, see Strategy.Inspect
+type LeakyBucket = internal.LeakyBucket
+
+// Inspect returns the stored leaky bucket of the key without modifying it, see strategies.Inspector
+func (l *Strategy) Inspect(ctx context.Context, config strategies.Config) ([]strategies.State, error) {
+	lbConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	bucket, found, err := internal.Inspect(ctx, l.storage, lbConfig.Key)
+	if err != nil {
+		return nil, err
+	}
+	state := strategies.State{Strategy: strategies.StrategyLeakyBucket}
+	if found {
+		state.Value = bucket
+	}
+	return []strategies.State{state}, nil
+}
@@ -197,3 +197,79 @@ func TestLeakyBucketReset(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, result["default"].Allowed, "Request should be allowed after reset")
 }
+
+func TestLeakyBucketRefund(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		storage := &mockBackend{store: make(map[string]string)}
+		strategy := New(storage)
+
+		config := &Config{
+			Key:   "refund-test-key",
+			Burst: 5,
+			Rate:  1.0,
+		}
+
+		// Refund on fresh state is a no-op
+		require.NoError(t, strategy.Refund(ctx, config, 1))
+		assert.Empty(t, storage.store, "Refund should not create state")
+
+		for range 3 {
+			result, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			require.True(t, result["default"].Allowed)
+		}
+
+		require.NoError(t, strategy.Refund(ctx, config, 2))
+		result, err := strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 4, result["default"].Remaining, "Remaining should increase by refunded requests")
+
+		// Refund beyond capacity is clamped
+		require.NoError(t, strategy.Refund(ctx, config, 10))
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 5, result["default"].Remaining, "Remaining should not exceed burst")
+	})
+}
+
+func TestLeakyBucketResetTime(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		storage := &mockBackend{store: make(map[string]string)}
+		strategy := New(storage)
+
+		config := &Config{
+			Key:   "reset-time-user",
+			Burst: 10,
+			Rate:  2.0, // 2 requests leak per second
+		}
+
+		// An empty bucket has full capacity now
+		result, err := strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, time.Now(), result["default"].Reset)
+
+		// 4 queued requests leak out after 4 / 2 = 2s
+		for range 4 {
+			result, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, time.Now().Add(2*time.Second), result["default"].Reset)
+
+		time.Sleep(500 * time.Millisecond)
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, time.Now().Add(1500*time.Millisecond), result["default"].Reset)
+
+		// A denied request resets when one request has leaked
+		for range 7 {
+			_, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+		}
+		result, err = strategy.Allow(ctx, config)
+		require.NoError(t, err)
+		assert.False(t, result["default"].Allowed)
+		assert.Equal(t, time.Now().Add(500*time.Millisecond), result["default"].Reset)
+	})
+}
@@ -0,0 +1,59 @@
+package strategies
+
+import "fmt"
+
+// DenyReason categorizes why a result denies a request, for logs and metrics
+type DenyReason uint8
+
+const (
+	ReasonNone     DenyReason = iota // The result allows the request
+	QuotaExhausted                   // A counted quota is used up: fixed window, sliding window, approx, unique
+	BurstExceeded                    // A bucket is empty: token bucket, leaky bucket, GCRA
+	ConcurrencyCap                   // Too many requests are in flight: concurrency
+	DenyList                         // The key is deny-listed
+	Degraded                         // Denied while a fallback decided without the backend
+)
+
+// reasonNames holds the canonical names of the reasons, "" for ReasonNone
+var reasonNames = [...]string{
+	ReasonNone:     "",
+	QuotaExhausted: "quota_exhausted",
+	BurstExceeded:  "burst_exceeded",
+	ConcurrencyCap: "concurrency_cap",
+	DenyList:       "deny_list",
+	Degraded:       "degraded",
+}
+
+// String returns the snake case name of the reason, e.g. "burst_exceeded",
+// or "" for ReasonNone
+func (r DenyReason) String() string {
+	if int(r) < len(reasonNames) {
+		return reasonNames[r]
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (r DenyReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (r *DenyReason) UnmarshalText(text []byte) error {
+	for reason, name := range reasonNames {
+		if name == string(text) {
+			*r = DenyReason(reason)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown deny reason %q", text)
+}
+
+// DeniedBy returns reason for a denied result and ReasonNone for an allowed
+// one, for strategies building their results
+func DeniedBy(allowed bool, reason DenyReason) DenyReason {
+	if allowed {
+		return ReasonNone
+	}
+	return reason
+}
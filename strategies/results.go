@@ -1,14 +1,69 @@
 package strategies
 
-import "time"
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
 
 type Results map[string]Result
 
 // Result represents the result of a rate limiting check
 type Result struct {
-	Allowed   bool      // Whether the request is allowed
-	Remaining int       // Remaining requests in the current window
-	Reset     time.Time // When the current window resets
+	Allowed   bool           `json:"allowed"`            // Whether the request is allowed
+	Limit     int            `json:"limit"`              // Configured limit: quota limit, bucket burst or maximum leases; 0 for list and penalty results
+	Remaining int            `json:"remaining"`          // Remaining requests in the current window
+	Reset     time.Time      `json:"reset"`              // When the window resets, or a continuous bucket is full again (denied: when the request fits)
+	Metadata  map[string]any `json:"metadata,omitempty"` // Caller metadata echoed from the access options, never persisted
+	Degraded  bool           `json:"degraded,omitempty"` // Served from a last known snapshot while the backend is unavailable
+	Advisory  bool           `json:"advisory,omitempty"` // Reported by an advisory tier, whose denial does not deny the request
+	Reason    DenyReason     `json:"reason,omitempty"`   // Why the result denies, ReasonNone when allowed
+
+	// SoftLimited reports an allowed request beyond the soft limit of its
+	// quota, e.g. to ask clients to slow down before they get denied
+	SoftLimited bool `json:"soft_limited,omitempty"`
+
+	// WindowStart and WindowEnd are the boundaries of the current window of
+	// fixed window quotas, e.g. to show calendar-aligned windows on a
+	// dashboard; WindowEnd equals Reset. Zero for other strategies.
+	WindowStart time.Time `json:"window_start,omitzero"`
+	WindowEnd   time.Time `json:"window_end,omitzero"`
+}
+
+// Denies reports whether the result denies the request: it is not allowed
+// and not advisory.
+func (r Result) Denies() bool {
+	return !r.Allowed && !r.Advisory
+}
+
+// jsonResult is the JSON shape of a Result in Results.MarshalJSON
+type jsonResult struct {
+	Result
+	RetryAfter int `json:"retry_after"`
+}
+
+// MarshalJSON encodes the results as an object keyed by result name, e.g. for
+// a status endpoint:
+//
+//	{"default":{"allowed":false,"limit":10,"remaining":0,"reset":"2000-01-01T00:01:00Z","retry_after":60}}
+//
+// Each result has the "allowed", "limit", "remaining" and "reset" (RFC 3339)
+// fields of Result, "metadata", "degraded", "reason", "soft_limited",
+// "window_start" and "window_end" when set, and "retry_after": the whole
+// seconds until reset, rounded up, for denying results, see Result.Denies,
+// and 0 otherwise. The shape is stable and decodes back into Results,
+// dropping retry_after.
+func (r Results) MarshalJSON() ([]byte, error) {
+	now := time.Now()
+	out := make(map[string]jsonResult, len(r))
+	for name, result := range r {
+		var retryAfter int
+		if result.Denies() {
+			retryAfter = int(math.Ceil(max(result.Reset.Sub(now), 0).Seconds()))
+		}
+		out[name] = jsonResult{Result: result, RetryAfter: retryAfter}
+	}
+	return json.Marshal(out)
 }
 
 // Default returns the result for the "default" quota.
@@ -62,9 +117,11 @@ func (r Results) HasQuota(name string) bool {
 }
 
 // AllAllowed returns true if all quotas in the results are allowed.
+//
+// Advisory results are ignored, see Result.Denies.
 func (r Results) AllAllowed() bool {
 	for _, result := range r {
-		if !result.Allowed {
+		if result.Denies() {
 			return false
 		}
 	}
@@ -81,6 +138,47 @@ func (r Results) AnyAllowed() bool {
 	return false
 }
 
+// TierResult is a result along with its name, e.g. "hour" or "secondary_default"
+type TierResult struct {
+	Name string
+	Result
+}
+
+// Tightest returns the result with the least headroom, the headline number
+// to show when several quotas apply, e.g. in a single RateLimit header.
+//
+// Headroom is the Remaining to Limit ratio, so 5 of 10 per minute is tighter
+// than 400 of 1000 per day. Denying results have no headroom, advisory
+// denials keep the headroom of their Remaining. Results without a Limit, e.g.
+// allow list results, are only chosen when nothing else is reported. Ties go
+// to the first name in lexical order. Returns a zero TierResult for empty
+// results.
+func (r Results) Tightest() TierResult {
+	var tightest TierResult
+	minHeadroom := math.Inf(1)
+	for name, result := range r {
+		headroom := result.headroom()
+		if tightest.Name == "" || headroom < minHeadroom ||
+			(headroom == minHeadroom && name < tightest.Name) {
+			tightest, minHeadroom = TierResult{Name: name, Result: result}, headroom
+		}
+	}
+	return tightest
+}
+
+// headroom returns the fraction of the limit remaining, 0 when the result
+// denies and +Inf without a limit
+func (r Result) headroom() float64 {
+	switch {
+	case r.Denies():
+		return 0
+	case r.Limit <= 0:
+		return math.Inf(1)
+	default:
+		return float64(r.Remaining) / float64(r.Limit)
+	}
+}
+
 // Len returns the number of quotas in the results.
 func (r Results) Len() int {
 	return len(r)
@@ -1,7 +1,12 @@
 package strategies
 
 import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/synctest"
 	"time"
 
 	"github.com/stretchr/testify/require"
@@ -269,3 +274,164 @@ func TestResultsDualStrategyHelpers(t *testing.T) {
 		require.Equal(t, Result{}, r.Secondary("hourly"))
 	})
 }
+
+func TestResultsAdvisory(t *testing.T) {
+	advisory := Result{Allowed: false, Advisory: true}
+	require.False(t, advisory.Denies())
+	require.True(t, Result{}.Denies())
+	require.False(t, Result{Allowed: true}.Denies())
+
+	r := Results{"primary_default": {Allowed: true}, "secondary_default": advisory}
+	require.True(t, r.AllAllowed(), "advisory denials do not deny")
+
+	r["primary_default"] = Result{}
+	require.False(t, r.AllAllowed())
+}
+
+func TestResultsTightest(t *testing.T) {
+	tests := []struct {
+		name    string
+		results Results
+		want    string
+	}{
+		{
+			name: "least remaining ratio",
+			results: Results{
+				"minute": {Allowed: true, Limit: 10, Remaining: 5},
+				"hour":   {Allowed: true, Limit: 100, Remaining: 30},
+				"day":    {Allowed: true, Limit: 1000, Remaining: 400},
+			},
+			want: "hour",
+		},
+		{
+			name: "ratio rather than absolute remaining",
+			results: Results{
+				"minute": {Allowed: true, Limit: 10, Remaining: 2},
+				"hour":   {Allowed: true, Limit: 100, Remaining: 50},
+				"day":    {Allowed: true, Limit: 1000, Remaining: 900},
+			},
+			want: "minute",
+		},
+		{
+			name: "denied has no headroom",
+			results: Results{
+				"minute": {Allowed: true, Limit: 10, Remaining: 0},
+				"hour":   {Allowed: false, Limit: 100, Remaining: 3},
+				"day":    {Allowed: true, Limit: 1000, Remaining: 10},
+			},
+			want: "hour",
+		},
+		{
+			name: "advisory denial keeps its headroom",
+			results: Results{
+				"primary_default":   {Allowed: true, Limit: 10, Remaining: 2},
+				"secondary_default": {Allowed: false, Advisory: true, Limit: 100, Remaining: 50},
+			},
+			want: "primary_default",
+		},
+		{
+			name: "ties by name",
+			results: Results{
+				"minute": {Allowed: true, Limit: 10, Remaining: 5},
+				"hour":   {Allowed: true, Limit: 100, Remaining: 50},
+				"day":    {Allowed: true, Limit: 1000, Remaining: 500},
+			},
+			want: "day",
+		},
+		{
+			name: "results without limit last",
+			results: Results{
+				"allow_list": {Allowed: true},
+				"minute":     {Allowed: true, Limit: 10, Remaining: 10},
+			},
+			want: "minute",
+		},
+		{
+			name:    "only results without limit",
+			results: Results{"allow_list": {Allowed: true}},
+			want:    "allow_list",
+		},
+		{
+			name:    "empty",
+			results: Results{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tightest := tt.results.Tightest()
+			require.Equal(t, tt.want, tightest.Name)
+			require.Equal(t, tt.results[tt.want], tightest.Result)
+		})
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// jsonResults returns results covering every JSON field, relative to now
+func jsonResults(now time.Time) Results {
+	return Results{
+		"primary_default": {
+			Allowed:     true,
+			Limit:       5,
+			Remaining:   4,
+			Reset:       now.Add(time.Minute),
+			Metadata:    map[string]any{"route": "/api"},
+			SoftLimited: true,
+		},
+		"secondary_default": {
+			Allowed:   false,
+			Limit:     2,
+			Remaining: 0,
+			Reset:     now.Add(1500 * time.Millisecond),
+			Degraded:  true,
+			Reason:    BurstExceeded,
+		},
+	}
+}
+
+func TestResultsJSON_Golden(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		data, err := json.MarshalIndent(jsonResults(time.Now().UTC()), "", "  ")
+		require.NoError(t, err)
+
+		golden := filepath.Join("testdata", "results.golden.json")
+		if *updateGolden {
+			require.NoError(t, os.WriteFile(golden, append(data, '\n'), 0o644))
+		}
+		want, err := os.ReadFile(golden)
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(data)+"\n", "JSON shape changed; run with -update if intended")
+	})
+}
+
+func TestResultsJSON_RoundTrip(t *testing.T) {
+	results := jsonResults(time.Now().UTC().Truncate(time.Second))
+	data, err := json.Marshal(results)
+	require.NoError(t, err)
+
+	var decoded Results
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, results, decoded)
+
+	data, err = json.Marshal(Results{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(data))
+}
+
+func TestResultsJSON_RetryAfterAdvisory(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		reset := time.Now().Add(time.Minute)
+		data, err := json.Marshal(Results{
+			"primary_default":   {Allowed: false, Reset: reset},
+			"secondary_default": {Allowed: false, Advisory: true, Reset: reset},
+		})
+		require.NoError(t, err)
+
+		var decoded map[string]struct {
+			RetryAfter int `json:"retry_after"`
+		}
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, 60, decoded["primary_default"].RetryAfter)
+		require.Zero(t, decoded["secondary_default"].RetryAfter, "advisory denials do not deny")
+	})
+}
@@ -0,0 +1,157 @@
+package slidingwindow
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Config implements the Config interface for exact sliding window limiting.
+//
+// At most Limit requests are allowed in any Window long interval, without the
+// boundary bursts of fixed windows. The timestamps of the last Limit allowed
+// requests are kept in a fixed-size ring buffer: a request is allowed when
+// the buffer is not full or its oldest timestamp is at least Window old, and
+// then overwrites it. State is therefore bounded by Limit, unlike a log of
+// every request, but still grows with it and is rewritten on every allowed
+// request. It suits the memory backend and small limits, e.g. 10 logins per
+// hour; prefer fixed windows or GCRA for large limits on remote backends.
+type Config struct {
+	Key          string             // Storage key for the request timestamps
+	Limit        int                // Maximum number of requests in any window
+	Window       time.Duration      // Window length
+	MaxRetries   int                // Maximum retry attempts for atomic operations, 0 means use default
+	RetryBackoff strategies.Backoff // Delay policy between retry attempts, zero value uses default
+}
+
+// Validate performs configuration validation for the sliding window limiter.
+//
+// Returns an error if any of the following conditions are met:
+//   - Limit <= 0
+//   - Window <= 0
+//
+// Note: The Key field is not validated here as it may be set later
+// using WithKey() for dynamic key assignment.
+func (c *Config) Validate() error {
+	if c.Limit <= 0 {
+		return fmt.Errorf("%w: sliding window limit must be positive, got %d", strategies.ErrInvalidLimit, c.Limit)
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("%w: sliding window must be positive, got %v", strategies.ErrInvalidWindow, c.Window)
+	}
+	return nil
+}
+
+// ID returns the unique identifier for the sliding window strategy.
+//
+// This method implements the Config interface and returns StrategySlidingWindow,
+// which is used for logging, debugging, and strategy selection.
+func (c *Config) ID() strategies.ID {
+	return strategies.StrategySlidingWindow
+}
+
+// Capabilities returns the supported capabilities of the sliding window strategy.
+//
+// This strategy supports:
+//   - CapPrimary: Can be used as a primary hard limiter
+//
+// Note: Like fixed window, this strategy does NOT support CapSecondary, so
+// it can be the primary of a dual-strategy configuration, e.g. with a token
+// bucket smoothing bursts.
+func (c *Config) Capabilities() strategies.CapabilityFlags {
+	return strategies.CapPrimary
+}
+
+// WithKey returns a copy of the config with the provided key applied.
+//
+// The key is used as-is for storage without modification or prefixing.
+// This allows direct control over storage keys for backend compatibility.
+func (c *Config) WithKey(key string) strategies.Config {
+	cfg := *c
+	cfg.Key = key
+	return &cfg
+}
+
+// WithMaxRetries returns a copy of the config with the provided retry limit applied.
+//
+// This controls the maximum number of retry attempts for atomic operations
+// (CheckAndSet) when storage conflicts occur. Set to 0 to use the default
+// retry limit.
+func (c *Config) WithMaxRetries(retries int) strategies.Config {
+	cfg := *c
+	cfg.MaxRetries = retries
+	return &cfg
+}
+
+// WithRetryBackoff returns a copy of the config with the provided retry backoff applied.
+//
+// This controls the delay between retry attempts for atomic operations
+// (CheckAndSet). A zero Backoff keeps the default feedback-based delay.
+func (c *Config) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	return &cfg
+}
+
+// WithRand returns a copy of the config drawing its retry jitter from rng.
+//
+// This method implements strategies.RandConfig and sets RetryBackoff.Rand,
+// keeping the rest of the backoff.
+func (c *Config) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff.Rand = rng
+	return &cfg
+}
+
+// GetKey returns the storage key for the request timestamps.
+//
+// This method implements the internal.Config interface used by the sliding
+// window algorithm.
+func (c *Config) GetKey() string {
+	return c.Key
+}
+
+// GetLimit returns the maximum number of requests in any window.
+//
+// This method implements the internal.Config interface used by the sliding
+// window algorithm.
+func (c *Config) GetLimit() int {
+	return c.Limit
+}
+
+// GetWindow returns the window length.
+//
+// This method implements the internal.Config interface used by the sliding
+// window algorithm.
+func (c *Config) GetWindow() time.Duration {
+	return c.Window
+}
+
+// GetMaxRetries returns the configured maximum retry attempts for atomic operations.
+//
+// When MaxRetries is 0 (default), returns Limit + 1: every lost CheckAndSet
+// means another request was recorded, so after Limit losses the window is
+// full. When MaxRetries > 0, returns the explicitly configured value.
+func (c *Config) GetMaxRetries() int {
+	if c.MaxRetries > 0 {
+		return c.MaxRetries
+	}
+	return c.Limit + 1
+}
+
+// GetRetryBackoff returns the configured delay policy between retry attempts.
+//
+// This method implements the internal.Config interface used by the sliding
+// window algorithm. A zero Backoff means the default feedback-based delay is used.
+func (c *Config) GetRetryBackoff() strategies.Backoff {
+	return c.RetryBackoff
+}
+
+// SteadyStateRate returns the long-run requests per second allowed, Limit/Window.
+//
+// This method implements strategies.SteadyStateRateConfig.
+func (c *Config) SteadyStateRate() float64 {
+	return float64(c.Limit) / c.Window.Seconds()
+}
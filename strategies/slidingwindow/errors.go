@@ -0,0 +1,6 @@
+package slidingwindow
+
+import "errors"
+
+// ErrInvalidConfig is returned when the provided config is not of type slidingwindow.Config.
+var ErrInvalidConfig = errors.New("sliding window strategy requires slidingwindow.Config")
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// AllowMode represents the operation mode for `Allow`
+type AllowMode int
+
+const (
+	// ReadOnly only inspects current state without modifications
+	ReadOnly AllowMode = iota
+	// TryUpdate attempts to record the request with retry logic
+	TryUpdate
+)
+
+// Result contains the result of Allow operation
+type Result struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Time
+}
+
+type parameter struct {
+	backoff    strategies.Backoff
+	key        string
+	limit      int
+	maxRetries int
+	now        time.Time
+	storage    backends.Backend
+	window     time.Duration
+}
+
+// Allow provides a unified implementation for both Allow and Peek operations
+// mode determines whether to perform read-only inspection or actually record the request.
+//
+// A request is allowed while the log is not full or its oldest timestamp is
+// at least a window old, which is exactly fewer than limit requests in the
+// window ending now.
+func Allow(
+	ctx context.Context,
+	storage backends.Backend,
+	config Config,
+	mode AllowMode,
+) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	if mode == ReadOnly {
+		log, _, err := p.getState(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		return p.result(log, p.allows(log)), nil
+	}
+
+	return p.record(ctx)
+}
+
+// newParameter builds the operation parameters from config
+func newParameter(ctx context.Context, storage backends.Backend, config Config) *parameter {
+	return &parameter{
+		backoff:    config.GetRetryBackoff(),
+		key:        config.GetKey(),
+		limit:      config.GetLimit(),
+		maxRetries: config.GetMaxRetries(),
+		now:        strategies.Now(ctx),
+		storage:    storage,
+		window:     config.GetWindow(),
+	}
+}
+
+// allows reports whether the log has room for a request at p.now
+func (p *parameter) allows(log Log) bool {
+	return !log.full(p.limit) || p.now.Sub(log.oldest()) >= p.window
+}
+
+// record adds the request to the log if the limit allows it
+func (p *parameter) record(ctx context.Context) (Result, error) {
+	for attempt := range p.maxRetries {
+		if err := ctx.Err(); err != nil {
+			return Result{}, NewContextCanceledError(err)
+		}
+
+		log, oldValue, err := p.getState(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		if !p.allows(log) {
+			return p.result(log, false), nil
+		}
+
+		log = log.add(p.now, p.limit)
+		beforeCAS := time.Now()
+		success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, encodeState(log), p.expiration(log))
+		if err != nil {
+			return Result{}, NewStateSaveError(err)
+		}
+		if success {
+			return p.result(log, true), nil
+		}
+
+		if err := p.backoff.Wait(ctx, attempt, time.Since(beforeCAS)); err != nil {
+			return Result{}, NewContextCanceledError(err)
+		}
+	}
+
+	return Result{}, ErrConcurrentAccess
+}
+
+// getState returns the log fitted to the limit and the raw stored value
+func (p *parameter) getState(ctx context.Context) (Log, string, error) {
+	data, err := p.storage.Get(ctx, p.key)
+	if err != nil {
+		return Log{}, "", NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return Log{}, "", nil
+	}
+
+	log, ok := decodeState(data)
+	if !ok {
+		return Log{}, "", ErrStateParsing
+	}
+	return log.resize(p.limit), data, nil
+}
+
+// result reports the requests of the window ending at p.now. Reset is when
+// the oldest of them leaves the window and frees a slot, or now when none is
+// in the window.
+func (p *parameter) result(log Log, allowed bool) Result {
+	count, oldest := log.inWindow(p.now, p.window)
+	if count == 0 {
+		return Result{Allowed: allowed, Remaining: p.limit, Reset: p.now}
+	}
+	return Result{
+		Allowed:   allowed,
+		Remaining: max(p.limit-count, 0),
+		Reset:     oldest.Add(p.window),
+	}
+}
+
+// expiration keeps the state until its newest request leaves the window
+func (p *parameter) expiration(log Log) time.Duration {
+	return max(log.newest().Add(p.window).Sub(p.now), time.Second)
+}
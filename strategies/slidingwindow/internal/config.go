@@ -0,0 +1,15 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+type Config interface {
+	GetKey() string
+	GetLimit() int
+	GetWindow() time.Duration
+	GetMaxRetries() int
+	GetRetryBackoff() strategies.Backoff
+}
@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+var (
+	ErrStateParsing     = errors.New("failed to parse sliding window state: invalid encoding")
+	ErrConcurrentAccess = fmt.Errorf("failed to update sliding window state after max attempts due to concurrent access: %w", strategies.ErrMaxRetriesExceeded)
+)
+
+func NewStateRetrievalError(err error) error {
+	return fmt.Errorf("failed to get sliding window state: %w", err)
+}
+
+func NewStateSaveError(err error) error {
+	return fmt.Errorf("failed to save sliding window state: %w", err)
+}
+
+func NewContextCanceledError(err error) error {
+	return fmt.Errorf("context canceled or timed out: %w", err)
+}
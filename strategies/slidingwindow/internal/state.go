@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/utils/builderpool"
+)
+
+// Log is a ring buffer of the timestamps of the last allowed requests.
+//
+// It holds at most limit timestamps. Until it is full, requests are appended
+// and Head is 0; once full, Times[Head] is the oldest timestamp and the next
+// allowed request overwrites it, so the state never grows past the limit.
+type Log struct {
+	Head  int
+	Times []time.Time
+}
+
+// full reports whether the log holds limit timestamps
+func (l Log) full(limit int) bool {
+	return len(l.Times) >= limit
+}
+
+// oldest returns the oldest timestamp of a non-empty log
+func (l Log) oldest() time.Time {
+	return l.Times[l.Head]
+}
+
+// newest returns the newest timestamp of a non-empty log
+func (l Log) newest() time.Time {
+	return l.Times[(l.Head+len(l.Times)-1)%len(l.Times)]
+}
+
+// add records an allowed request at now, overwriting the oldest timestamp of
+// a full log
+func (l Log) add(now time.Time, limit int) Log {
+	if !l.full(limit) {
+		l.Times = append(l.Times, now)
+		return l
+	}
+	l.Times[l.Head] = now
+	l.Head = (l.Head + 1) % len(l.Times)
+	return l
+}
+
+// resize fits a log written with another limit, keeping the newest
+// timestamps in chronological order
+func (l Log) resize(limit int) Log {
+	if len(l.Times) == limit || (l.Head == 0 && len(l.Times) < limit) {
+		return l
+	}
+	ordered := make([]time.Time, 0, len(l.Times))
+	ordered = append(ordered, l.Times[l.Head:]...)
+	ordered = append(ordered, l.Times[:l.Head]...)
+	if len(ordered) > limit {
+		ordered = ordered[len(ordered)-limit:]
+	}
+	return Log{Times: ordered}
+}
+
+// inWindow returns the number of timestamps less than window before now, and
+// the oldest of them
+func (l Log) inWindow(now time.Time, window time.Duration) (int, time.Time) {
+	count := 0
+	var oldest time.Time
+	for _, t := range l.Times {
+		if now.Sub(t) >= window {
+			continue
+		}
+		if count == 0 || t.Before(oldest) {
+			oldest = t
+		}
+		count++
+	}
+	return count, oldest
+}
+
+// encodeState serializes the log into a compact ASCII format:
+// 91|head|N|unix_nano1|...|unix_nanoN
+func encodeState(l Log) string {
+	sb := builderpool.Get()
+	defer builderpool.Put(sb)
+
+	sb.WriteString("91|")
+	sb.WriteString(strconv.Itoa(l.Head))
+	sb.WriteByte('|')
+	sb.WriteString(strconv.Itoa(len(l.Times)))
+	for _, t := range l.Times {
+		sb.WriteByte('|')
+		sb.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+	}
+	return sb.String()
+}
+
+func decodeState(s string) (Log, bool) {
+	if len(s) < 3 || s[:3] != "91|" {
+		return Log{}, false
+	}
+	headStr, rest, ok := strings.Cut(s[3:], "|")
+	if !ok {
+		return Log{}, false
+	}
+	head, err := strconv.Atoi(headStr)
+	if err != nil || head < 0 {
+		return Log{}, false
+	}
+	countStr, rest, _ := strings.Cut(rest, "|")
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 || count > len(rest) || (head > 0 && head >= count) {
+		return Log{}, false
+	}
+
+	times := make([]time.Time, 0, count)
+	for field := range strings.SplitSeq(rest, "|") {
+		if count == 0 {
+			if field != "" {
+				return Log{}, false
+			}
+			break
+		}
+		nanos, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return Log{}, false
+		}
+		times = append(times, time.Unix(0, nanos))
+	}
+	if len(times) != count {
+		return Log{}, false
+	}
+	return Log{Head: head, Times: times}, true
+}
+
+// Inspect returns the stored state of key without modifying it, or false for a fresh key
+func Inspect(ctx context.Context, storage backends.Backend, key string) (Log, bool, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return Log{}, false, NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return Log{}, false, nil
+	}
+	log, ok := decodeState(data)
+	if !ok {
+		return Log{}, false, ErrStateParsing
+	}
+	return log, true, nil
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_AddEvictsOldest(t *testing.T) {
+	base := time.Unix(1000, 0)
+	at := func(seconds int) time.Time { return base.Add(time.Duration(seconds) * time.Second) }
+
+	var log Log
+	for i := range 3 {
+		log = log.add(at(i), 3)
+	}
+	assert.Equal(t, Log{Times: []time.Time{at(0), at(1), at(2)}}, log)
+	assert.True(t, log.full(3))
+	assert.Equal(t, at(0), log.oldest())
+
+	// Each new timestamp overwrites the oldest in place
+	log = log.add(at(3), 3)
+	assert.Equal(t, Log{Head: 1, Times: []time.Time{at(3), at(1), at(2)}}, log)
+	assert.Equal(t, at(1), log.oldest())
+	assert.Equal(t, at(3), log.newest())
+
+	log = log.add(at(4), 3).add(at(5), 3).add(at(6), 3)
+	assert.Equal(t, Log{Head: 1, Times: []time.Time{at(6), at(4), at(5)}}, log)
+	assert.Equal(t, at(4), log.oldest())
+	assert.Equal(t, at(6), log.newest())
+
+	count, oldest := log.inWindow(at(7), 3*time.Second)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, at(5), oldest)
+}
+
+func TestLog_Resize(t *testing.T) {
+	base := time.Unix(1000, 0)
+	at := func(seconds int) time.Time { return base.Add(time.Duration(seconds) * time.Second) }
+	log := Log{Head: 1, Times: []time.Time{at(3), at(1), at(2)}}
+
+	assert.Equal(t, log, log.resize(3))
+	assert.Equal(t, Log{Times: []time.Time{at(2), at(3)}}, log.resize(2), "keeps the newest")
+	assert.Equal(t, Log{Times: []time.Time{at(1), at(2), at(3)}}, log.resize(5), "unrolls to append")
+
+	grown := log.resize(5).add(at(4), 5)
+	assert.Equal(t, at(1), grown.oldest())
+	assert.Equal(t, at(4), grown.newest())
+}
+
+func TestState_EncodeDecode(t *testing.T) {
+	log := Log{Head: 1, Times: []time.Time{time.Unix(0, 1761884058000000000), time.Unix(0, 1761884055342794596)}}
+	encoded := encodeState(log)
+	assert.Equal(t, "91|1|2|1761884058000000000|1761884055342794596", encoded)
+
+	decoded, ok := decodeState(encoded)
+	require.True(t, ok)
+	assert.Equal(t, log.Head, decoded.Head)
+	require.Len(t, decoded.Times, 2)
+	for i := range log.Times {
+		assert.True(t, log.Times[i].Equal(decoded.Times[i]))
+	}
+
+	empty, ok := decodeState(encodeState(Log{}))
+	require.True(t, ok)
+	assert.Empty(t, empty.Times)
+
+	for _, invalid := range []string{
+		"", "91|", "91|0", "91|x|0", "91|-1|0", "91|0|-1", "91|0|1", "91|0|1|x",
+		"91|0|0|5", "91|0|1|5|6", "91|2|2|5|6", "91|1|0", "81|0|0",
+	} {
+		_, ok := decodeState(invalid)
+		assert.False(t, ok, "%q", invalid)
+	}
+}
+
+func FuzzDecodeState(f *testing.F) {
+	f.Add("91|1|2|1761884058000000000|1761884055342794596")
+	f.Add("91|0|0")
+	f.Add("91|0|1|")
+	f.Add("91|3|2|1|2")
+	f.Add("91|0|99999999999|1")
+	f.Add("91|0|1|\xff")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		log, ok := decodeState(s)
+		if !ok {
+			return
+		}
+		if len(log.Times) > 0 {
+			_, _ = log.oldest(), log.newest()
+		}
+		decoded, ok := decodeState(encodeState(log))
+		if !ok || decoded.Head != log.Head || len(decoded.Times) != len(log.Times) {
+			t.Fatalf("re-encoding %q does not round trip", s)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package slidingwindow
+
+import (
+	"context"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/slidingwindow/internal"
+)
+
+// Strategy implements exact sliding window limiting with a ring buffer of timestamps
+type Strategy struct {
+	storage backends.Backend
+}
+
+// New creates a new sliding window strategy
+func New(storage backends.Backend) *Strategy {
+	return &Strategy{storage: storage}
+}
+
+// Allow records the request if fewer than Limit requests were allowed in the
+// window ending now.
+//
+// Reset is when the oldest request of the window leaves it, freeing a slot.
+func (s *Strategy) Allow(ctx context.Context, config strategies.Config) (strategies.Results, error) {
+	return s.check(ctx, config, internal.TryUpdate)
+}
+
+// Peek reports the requests of the window ending now without recording one
+func (s *Strategy) Peek(ctx context.Context, config strategies.Config) (strategies.Results, error) {
+	return s.check(ctx, config, internal.ReadOnly)
+}
+
+func (s *Strategy) check(ctx context.Context, config strategies.Config, mode internal.AllowMode) (strategies.Results, error) {
+	windowConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	res, err := internal.Allow(ctx, s.storage, windowConfig, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]strategies.Result{
+		"default": {
+			Allowed:   res.Allowed,
+			Limit:     windowConfig.Limit,
+			Remaining: res.Remaining,
+			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.QuotaExhausted),
+		},
+	}, nil
+}
+
+// Reset forgets the requests of the key
+func (s *Strategy) Reset(ctx context.Context, config strategies.Config) error {
+	windowConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	return s.storage.Delete(ctx, windowConfig.Key)
+}
+
+// Log is the stored ring buffer of request timestamps, see Strategy.Inspect
+type Log = internal.Log
+
+// Inspect returns the stored timestamps of the key without modifying them, see strategies.Inspector
+func (s *Strategy) Inspect(ctx context.Context, config strategies.Config) ([]strategies.State, error) {
+	windowConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	log, found, err := internal.Inspect(ctx, s.storage, windowConfig.Key)
+	if err != nil {
+		return nil, err
+	}
+	state := strategies.State{Strategy: strategies.StrategySlidingWindow}
+	if found {
+		state.Value = log
+	}
+	return []strategies.State{state}, nil
+}
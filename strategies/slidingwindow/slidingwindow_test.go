@@ -0,0 +1,105 @@
+package slidingwindow
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindow_ExactAtBoundary(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := memory.New()
+		defer storage.Close()
+		strategy := New(storage)
+		config := &Config{Key: "user", Limit: 3, Window: time.Minute}
+		start := time.Now()
+
+		allow := func() strategies.Result {
+			t.Helper()
+			results, err := strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			return results.Default()
+		}
+
+		// Requests at 0s, 20s and 40s fill the window
+		for i := range 3 {
+			if i > 0 {
+				time.Sleep(20 * time.Second)
+			}
+			result := allow()
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 2-i, result.Remaining)
+			assert.Equal(t, start.Add(time.Minute), result.Reset)
+		}
+
+		// Unlike a fixed window, the limit holds across any minute
+		time.Sleep(20*time.Second - time.Nanosecond)
+		assert.False(t, allow().Allowed, "the first request is still in the window")
+
+		// Exactly a window after the first request, its slot is free
+		time.Sleep(time.Nanosecond)
+		result := allow()
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Equal(t, start.Add(80*time.Second), result.Reset, "the second request leaves next")
+		assert.False(t, allow().Allowed)
+
+		// The second request is evicted next, not the newest one
+		time.Sleep(20 * time.Second)
+		assert.True(t, allow().Allowed)
+		assert.False(t, allow().Allowed)
+	})
+}
+
+func TestSlidingWindow_PeekInspectAndReset(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := memory.New()
+		defer storage.Close()
+		strategy := New(storage)
+		config := &Config{Key: "user", Limit: 2, Window: time.Minute}
+
+		results, err := strategy.Peek(t.Context(), config)
+		require.NoError(t, err)
+		assert.Equal(t, strategies.Result{Allowed: true, Limit: 2, Remaining: 2, Reset: time.Now()}, results.Default())
+
+		for range 3 {
+			_, err := strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+		}
+		for range 2 {
+			results, err := strategy.Peek(t.Context(), config)
+			require.NoError(t, err)
+			assert.Equal(t, strategies.Result{Allowed: false, Limit: 2, Remaining: 0, Reset: time.Now().Add(time.Minute), Reason: strategies.QuotaExhausted}, results.Default())
+		}
+
+		states, err := strategy.Inspect(t.Context(), config)
+		require.NoError(t, err)
+		require.Len(t, states, 1)
+		assert.Len(t, states[0].Value.(Log).Times, 2, "denied requests are not stored")
+
+		// A lower limit keeps the newest requests
+		lower := &Config{Key: "user", Limit: 1, Window: time.Minute}
+		results, err = strategy.Allow(t.Context(), lower)
+		require.NoError(t, err)
+		assert.False(t, results.Default().Allowed)
+
+		require.NoError(t, strategy.Reset(t.Context(), config))
+		results, err = strategy.Peek(t.Context(), config)
+		require.NoError(t, err)
+		assert.Equal(t, 2, results.Default().Remaining)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{Limit: 10, Window: time.Second}).Validate())
+	assert.ErrorIs(t, (&Config{Limit: 0, Window: time.Second}).Validate(), strategies.ErrInvalidLimit)
+	assert.ErrorIs(t, (&Config{Limit: 10}).Validate(), strategies.ErrInvalidWindow)
+
+	assert.Equal(t, 11, (&Config{Limit: 10}).GetMaxRetries())
+	assert.Equal(t, strategies.CapPrimary, (&Config{}).Capabilities())
+}
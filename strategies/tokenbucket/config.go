@@ -1,7 +1,10 @@
 package tokenbucket
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/rand/v2"
+	"time"
 
 	"github.com/ajiwo/ratelimit/strategies"
 )
@@ -12,26 +15,37 @@ import (
 // allowed requests. Tokens are added to the bucket at a constant rate,
 // up to a maximum burst size. Each request consumes one token.
 type Config struct {
-	Key        string  // Storage key for the token bucket state
-	Burst      int     // Maximum tokens the bucket can hold
-	Rate       float64 // Tokens to add per second (rate limit)
-	MaxRetries int     // Maximum retry attempts for atomic operations, 0 means use default
+	Key          string             // Storage key for the token bucket state
+	Burst        int                // Maximum tokens the bucket can hold
+	Rate         float64            // Tokens to add per second (rate limit)
+	MaxRetries   int                // Maximum retry attempts for atomic operations, 0 means use default
+	RetryBackoff strategies.Backoff // Delay policy between retry attempts, zero value uses default
+	IdleTTL      time.Duration      // Expiration after the last request, zero uses default, see strategies.IdleTTLConfig
+
+	// InitialTokens is the number of tokens of a new bucket, at most Burst,
+	// so that cold keys earn their burst instead of spending it right away.
+	// Nil starts the bucket full, zero starts it with no tokens.
+	InitialTokens *float64
 }
 
 // Validate performs configuration validation for the token bucket.
 //
 // Returns an error if any of the following conditions are met:
 //   - Burst <= 0
-//   - Rate <= 0
+//   - Rate <= 0, NaN, or infinite
+//   - InitialTokens > Burst, NaN, or negative
 //
 // Note: The Key field is not validated here as it may be set later
 // using WithKey() for dynamic key assignment.
 func (c *Config) Validate() error {
 	if c.Burst <= 0 {
-		return fmt.Errorf("token bucket burst must be positive, got %d", c.Burst)
+		return fmt.Errorf("%w: token bucket burst must be positive, got %d", strategies.ErrInvalidBurst, c.Burst)
+	}
+	if !strategies.ValidRate(c.Rate) {
+		return fmt.Errorf("%w: token bucket rate must be positive and finite, got %f", strategies.ErrInvalidRate, c.Rate)
 	}
-	if c.Rate <= 0 {
-		return fmt.Errorf("token bucket rate must be positive, got %f", c.Rate)
+	if c.InitialTokens != nil && !(*c.InitialTokens >= 0 && *c.InitialTokens <= float64(c.Burst)) {
+		return fmt.Errorf("%w: token bucket initial tokens must be between 0 and burst %d, got %f", strategies.ErrInvalidBurst, c.Burst, *c.InitialTokens)
 	}
 	return nil
 }
@@ -73,6 +87,37 @@ func (c *Config) WithMaxRetries(retries int) strategies.Config {
 	return &cfg
 }
 
+// WithRetryBackoff returns a copy of the config with the provided retry backoff applied.
+//
+// This controls the delay between retry attempts for atomic operations
+// (CheckAndSet). A zero Backoff keeps the default feedback-based delay.
+func (c *Config) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	return &cfg
+}
+
+// WithRand returns a copy of the config drawing its retry jitter from rng.
+//
+// This method implements strategies.RandConfig and sets RetryBackoff.Rand,
+// keeping the rest of the backoff.
+func (c *Config) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff.Rand = rng
+	return &cfg
+}
+
+// WithIdleTTL returns a copy of the config with the provided idle TTL applied.
+//
+// The stored state expires idleTTL after the last request instead of the
+// default, but never before it is equivalent to a fresh key. A zero idleTTL
+// keeps the default expiration.
+func (c *Config) WithIdleTTL(idleTTL time.Duration) strategies.Config {
+	cfg := *c
+	cfg.IdleTTL = idleTTL
+	return &cfg
+}
+
 // GetKey returns the storage key for the token bucket state.
 //
 // This method implements the internal.Config interface used by the token bucket
@@ -92,6 +137,17 @@ func (c *Config) GetBurst() int {
 	return c.Burst
 }
 
+// GetInitialTokens returns the number of tokens of a new bucket.
+//
+// This method implements the internal.Config interface used by the token bucket
+// algorithm. It returns Burst when InitialTokens is nil.
+func (c *Config) GetInitialTokens() float64 {
+	if c.InitialTokens == nil {
+		return float64(c.Burst)
+	}
+	return *c.InitialTokens
+}
+
 // GetRate returns the rate at which tokens are added to the bucket.
 //
 // This method implements the internal.Config interface used by the token bucket
@@ -111,3 +167,110 @@ func (c *Config) GetMaxRetries() int {
 	}
 	return c.Burst + 1
 }
+
+// GetRetryBackoff returns the configured delay policy between retry attempts.
+//
+// This method implements the internal.Config interface used by the token bucket
+// algorithm. A zero Backoff means the default feedback-based delay is used.
+func (c *Config) GetRetryBackoff() strategies.Backoff {
+	return c.RetryBackoff
+}
+
+// SteadyStateRate returns the long-run requests per second allowed, the refill rate.
+//
+// This method implements strategies.SteadyStateRateConfig.
+func (c *Config) SteadyStateRate() float64 {
+	return c.Rate
+}
+
+// GetIdleTTL returns the configured expiration after the last request.
+//
+// This method implements the internal.Config interface used by the tokenbucket
+// algorithm. A zero idle TTL means the default expiration is used.
+func (c *Config) GetIdleTTL() time.Duration {
+	return c.IdleTTL
+}
+
+// configBuilder builds a token bucket Config, see NewConfig
+type configBuilder struct {
+	config Config
+}
+
+// NewConfig creates a token bucket Config with a builder pattern, e.g.
+// NewConfig().SetBurst(10).SetRate(1).Build(), as an alternative to a
+// Config literal
+func NewConfig() *configBuilder {
+	return &configBuilder{}
+}
+
+// SetKey sets the key for the configuration
+func (b *configBuilder) SetKey(key string) *configBuilder {
+	b.config.Key = key
+	return b
+}
+
+// SetBurst sets the maximum tokens the bucket can hold
+func (b *configBuilder) SetBurst(burst int) *configBuilder {
+	b.config.Burst = burst
+	return b
+}
+
+// SetRate sets the tokens added per second
+func (b *configBuilder) SetRate(rate float64) *configBuilder {
+	b.config.Rate = rate
+	return b
+}
+
+// SetInitialTokens sets the tokens of a new bucket, see Config.InitialTokens
+func (b *configBuilder) SetInitialTokens(tokens float64) *configBuilder {
+	b.config.InitialTokens = &tokens
+	return b
+}
+
+// SetMaxRetries sets the maximum number of retries for the configuration
+func (b *configBuilder) SetMaxRetries(retries int) *configBuilder {
+	b.config.MaxRetries = retries
+	return b
+}
+
+// SetIdleTTL sets the expiration after the last request, see Config.IdleTTL
+func (b *configBuilder) SetIdleTTL(idleTTL time.Duration) *configBuilder {
+	b.config.IdleTTL = idleTTL
+	return b
+}
+
+// Build creates the Config from the builder, the builder may be reused
+func (b *configBuilder) Build() *Config {
+	cfg := b.config
+	return &cfg
+}
+
+// UnmarshalJSON decodes a Config, also accepting the BurstSize and RefillRate
+// fields of earlier versions as deprecated spellings of Burst and Rate, so
+// stored configs keep working instead of silently decoding a zero burst or
+// rate. Both spellings of a field must agree when both are set.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	decoded := struct {
+		*plain
+		BurstSize  *int     `json:"BurstSize"`
+		RefillRate *float64 `json:"RefillRate"`
+	}{plain: (*plain)(c)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.BurstSize != nil {
+		if c.Burst != 0 && c.Burst != *decoded.BurstSize {
+			return fmt.Errorf("%w: token bucket burst %d conflicts with BurstSize %d", strategies.ErrInvalidBurst, c.Burst, *decoded.BurstSize)
+		}
+		c.Burst = *decoded.BurstSize
+	}
+	if decoded.RefillRate != nil {
+		if c.Rate != 0 && c.Rate != *decoded.RefillRate {
+			return fmt.Errorf("%w: token bucket rate %f conflicts with RefillRate %f", strategies.ErrInvalidRate, c.Rate, *decoded.RefillRate)
+		}
+		c.Rate = *decoded.RefillRate
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package tokenbucket
 
 import (
+	"encoding/json"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/stretchr/testify/assert"
@@ -130,3 +133,126 @@ func TestConfig_Properties(t *testing.T) {
 	require.Equal(t, 11, config.GetMaxRetries(),
 		"MaxRetries should return the calculated max retries when unset or set to 0")
 }
+
+// TestConfig_ValidateBoundaries checks that unusable values fail with sentinel errors
+func TestConfig_ValidateBoundaries(t *testing.T) {
+	testCases := []struct {
+		name    string
+		burst   int
+		rate    float64
+		wantErr error
+	}{
+		{name: "smallest burst", burst: 1, rate: 1},
+		{name: "tiny rate", burst: 1, rate: math.SmallestNonzeroFloat64},
+		{name: "huge rate", burst: 1, rate: math.MaxFloat64},
+		{name: "zero burst", burst: 0, rate: 1, wantErr: strategies.ErrInvalidBurst},
+		{name: "negative burst", burst: -1, rate: 1, wantErr: strategies.ErrInvalidBurst},
+		{name: "zero rate", burst: 1, rate: 0, wantErr: strategies.ErrInvalidRate},
+		{name: "negative zero rate", burst: 1, rate: math.Copysign(0, -1), wantErr: strategies.ErrInvalidRate},
+		{name: "negative rate", burst: 1, rate: -1, wantErr: strategies.ErrInvalidRate},
+		{name: "NaN rate", burst: 1, rate: math.NaN(), wantErr: strategies.ErrInvalidRate},
+		{name: "infinite rate", burst: 1, rate: math.Inf(1), wantErr: strategies.ErrInvalidRate},
+		{name: "negative infinite rate", burst: 1, rate: math.Inf(-1), wantErr: strategies.ErrInvalidRate},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{Key: "boundary", Burst: tc.burst, Rate: tc.rate}
+			err := config.Validate()
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestConfig_ValidateInitialTokens(t *testing.T) {
+	tokens := func(n float64) *float64 { return &n }
+	testCases := []struct {
+		name    string
+		initial *float64
+		want    float64
+		wantErr error
+	}{
+		{name: "default", initial: nil, want: 4},
+		{name: "start empty", initial: tokens(0), want: 0},
+		{name: "partial", initial: tokens(1.5), want: 1.5},
+		{name: "burst", initial: tokens(4), want: 4},
+		{name: "over burst", initial: tokens(5), wantErr: strategies.ErrInvalidBurst},
+		{name: "negative", initial: tokens(-0.5), wantErr: strategies.ErrInvalidBurst},
+		{name: "NaN", initial: tokens(math.NaN()), wantErr: strategies.ErrInvalidBurst},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{Key: "initial", Burst: 4, Rate: 1, InitialTokens: tc.initial}
+			err := config.Validate()
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, config.GetInitialTokens())
+		})
+	}
+}
+
+func TestConfig_Builder(t *testing.T) {
+	initial := 2.0
+	built := NewConfig().
+		SetKey("user").
+		SetBurst(5).
+		SetRate(2.5).
+		SetInitialTokens(2).
+		SetMaxRetries(3).
+		SetIdleTTL(time.Hour).
+		Build()
+	literal := &Config{
+		Key:           "user",
+		Burst:         5,
+		Rate:          2.5,
+		InitialTokens: &initial,
+		MaxRetries:    3,
+		IdleTTL:       time.Hour,
+	}
+
+	assert.Equal(t, literal, built, "Builder should produce the same config as a literal")
+	require.NoError(t, built.Validate())
+
+	// Built configs are independent of the builder
+	builder := NewConfig().SetBurst(1).SetRate(1)
+	first := builder.Build()
+	builder.SetBurst(0)
+	assert.Equal(t, 1, first.Burst)
+	assert.Error(t, builder.Build().Validate())
+}
+
+func TestConfig_UnmarshalLegacyFields(t *testing.T) {
+	var current, legacy Config
+	require.NoError(t, json.Unmarshal([]byte(`{"Key":"user","Burst":3,"Rate":0.5}`), &current))
+	require.NoError(t, json.Unmarshal([]byte(`{"Key":"user","BurstSize":3,"RefillRate":0.5}`), &legacy))
+	assert.Equal(t, current, legacy, "Both spellings should decode to the same config")
+	require.NoError(t, legacy.Validate())
+
+	// Both spellings behave the same
+	strategy := New(&mockBackend{store: make(map[string]string)})
+	for range 4 {
+		want, err := strategy.Allow(t.Context(), current.WithKey("current"))
+		require.NoError(t, err)
+		got, err := strategy.Allow(t.Context(), legacy.WithKey("legacy"))
+		require.NoError(t, err)
+		assert.Equal(t, want["default"].Allowed, got["default"].Allowed)
+		assert.Equal(t, want["default"].Remaining, got["default"].Remaining)
+	}
+
+	// Agreeing spellings are accepted, conflicting ones are rejected
+	var both Config
+	require.NoError(t, json.Unmarshal([]byte(`{"Burst":3,"BurstSize":3,"Rate":0.5,"RefillRate":0.5}`), &both))
+	assert.Equal(t, 3, both.Burst)
+	err := json.Unmarshal([]byte(`{"Burst":3,"BurstSize":4,"Rate":0.5}`), &both)
+	assert.ErrorIs(t, err, strategies.ErrInvalidBurst)
+	err = json.Unmarshal([]byte(`{"Burst":3,"Rate":0.5,"RefillRate":1}`), &both)
+	assert.ErrorIs(t, err, strategies.ErrInvalidRate)
+}
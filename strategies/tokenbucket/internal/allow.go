@@ -7,7 +7,6 @@ import (
 
 	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/strategies"
-	"github.com/ajiwo/ratelimit/utils"
 )
 
 type AllowMode int
@@ -25,8 +24,12 @@ type Result struct {
 }
 
 type parameter struct {
+	backoff    strategies.Backoff
 	burstSize  int
 	capacity   float64
+	cost       float64
+	idleTTL    time.Duration
+	initial    float64
 	key        string
 	now        time.Time
 	maxRetries int
@@ -45,23 +48,46 @@ func Allow(
 		return Result{}, NewContextCanceledError(err)
 	}
 
+	p := newParameter(ctx, storage, config)
+
+	if mode == ReadOnly {
+		return p.allowReadOnly(ctx)
+	}
+
+	return p.allowTryAndUpdate(ctx)
+}
+
+// AllowCost consumes cost tokens instead of one.
+//
+// The request is allowed only if the bucket holds at least cost tokens.
+func AllowCost(ctx context.Context, storage backends.Backend, config Config, cost float64) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+	p.cost = cost
+
+	return p.allowTryAndUpdate(ctx)
+}
+
+// newParameter builds the operation parameters from config
+func newParameter(ctx context.Context, storage backends.Backend, config Config) *parameter {
 	maxRetries := config.GetMaxRetries()
 
-	p := &parameter{
+	return &parameter{
+		backoff:    config.GetRetryBackoff(),
+		idleTTL:    config.GetIdleTTL(),
 		burstSize:  config.GetBurst(),
 		capacity:   float64(config.GetBurst()),
+		cost:       1,
+		initial:    config.GetInitialTokens(),
 		key:        config.GetKey(),
 		maxRetries: maxRetries,
-		now:        time.Now(),
+		now:        strategies.Now(ctx),
 		refillRate: config.GetRate(),
 		storage:    storage,
 	}
-
-	if mode == ReadOnly {
-		return p.allowReadOnly(ctx)
-	}
-
-	return p.allowTryAndUpdate(ctx)
 }
 
 func (p *parameter) allowReadOnly(ctx context.Context) (Result, error) {
@@ -71,10 +97,12 @@ func (p *parameter) allowReadOnly(ctx context.Context) (Result, error) {
 	}
 
 	if data == "" {
+		bucket := p.fresh()
+		remaining := int(bucket.Tokens)
 		return Result{
-			Allowed:      true,
-			Remaining:    p.burstSize,
-			Reset:        p.now,
+			Allowed:      remaining > 0,
+			Remaining:    remaining,
+			Reset:        p.fullAt(bucket),
 			stateUpdated: false,
 		}, nil
 	}
@@ -84,17 +112,14 @@ func (p *parameter) allowReadOnly(ctx context.Context) (Result, error) {
 		return Result{}, ErrStateParsing
 	}
 
-	timeElapsed := p.now.Sub(bucket.LastRefill).Seconds()
-	tokensToAdd := timeElapsed * p.refillRate
-	bucket.Tokens = math.Min(bucket.Tokens+tokensToAdd, p.capacity)
-	bucket.LastRefill = p.now
+	bucket = p.refill(bucket)
 
 	remaining := max(int(bucket.Tokens), 0)
 
 	return Result{
 		Allowed:      remaining > 0,
 		Remaining:    remaining,
-		Reset:        p.now,
+		Reset:        p.fullAt(bucket),
 		stateUpdated: false,
 	}, nil
 }
@@ -113,10 +138,7 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 		var bucket TokenBucket
 		var oldValue string
 		if data == "" {
-			bucket = TokenBucket{
-				Tokens:     p.capacity,
-				LastRefill: p.now,
-			}
+			bucket = p.fresh()
 			oldValue = ""
 		} else {
 			if b, ok := decodeState(data); ok {
@@ -126,21 +148,18 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 			}
 			oldValue = data
 
-			elapsed := p.now.Sub(bucket.LastRefill)
-			tokensToAdd := float64(elapsed.Nanoseconds()) * p.refillRate / 1e9
-			bucket.Tokens = math.Min(bucket.Tokens+tokensToAdd, p.capacity)
-			bucket.LastRefill = p.now
+			bucket = p.refill(bucket)
 		}
 
-		allowed := math.Floor(bucket.Tokens) >= 1.0
+		allowed := bucket.Tokens >= p.cost
 
 		if allowed {
 			beforeCAS := time.Now()
-			bucket.Tokens -= 1.0
+			bucket.Tokens -= p.cost
 			remaining := max(int(bucket.Tokens), 0)
 
 			newValue := encodeState(bucket)
-			expiration := strategies.CalcExpiration(p.burstSize, p.refillRate)
+			expiration := strategies.CalcIdleExpiration(p.burstSize, p.refillRate, p.idleTTL)
 
 			success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, newValue, expiration)
 			if err != nil {
@@ -151,16 +170,14 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 				return Result{
 					Allowed:      true,
 					Remaining:    remaining,
-					Reset:        p.now,
+					Reset:        p.fullAt(bucket),
 					stateUpdated: true,
 				}, nil
 			}
 
 			feedback := time.Since(beforeCAS)
-			delay := strategies.NextDelay(attempt, feedback)
-
 			if attempt < p.maxRetries-1 {
-				if err := utils.SleepOrWait(ctx, delay, 500*time.Millisecond); err != nil {
+				if err := p.backoff.Wait(ctx, attempt, feedback); err != nil {
 					return Result{}, NewContextCanceledError(err)
 				}
 				continue
@@ -168,12 +185,25 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 			break
 		}
 
+		// A bucket starting below capacity is stored even when denied, so
+		// that it earns tokens from now on
+		if oldValue == "" && bucket.Tokens < p.capacity {
+			expiration := strategies.CalcIdleExpiration(p.burstSize, p.refillRate, p.idleTTL)
+			success, err := p.storage.CheckAndSet(ctx, p.key, "", encodeState(bucket), expiration)
+			if err != nil {
+				return Result{}, NewStateSaveError(err)
+			}
+			if !success {
+				continue
+			}
+		}
+
 		remaining := max(int(bucket.Tokens), 0)
 
 		return Result{
 			Allowed:      false,
 			Remaining:    remaining,
-			Reset:        calculateResetTime(p.now, bucket, p.refillRate),
+			Reset:        calculateResetTime(p.now, bucket, p.refillRate, p.cost),
 			stateUpdated: oldValue == "",
 		}, nil
 	}
@@ -181,17 +211,53 @@ func (p *parameter) allowTryAndUpdate(ctx context.Context) (Result, error) {
 	return Result{}, ErrConcurrentAccess
 }
 
+// fresh returns the bucket of a new key, holding the initial tokens
+func (p *parameter) fresh() TokenBucket {
+	return TokenBucket{
+		Tokens:     p.initial,
+		LastRefill: p.now,
+	}
+}
+
+// refill adds the tokens earned since the last refill, capped at capacity.
+//
+// Peek, Allow and Refund share this computation so that every path reports
+// the same remaining tokens for the same state and clock, regardless of the
+// backend. A last refill in the future (clock skew between instances) earns
+// nothing and is kept, so the skewed time is not refilled twice.
+func (p *parameter) refill(bucket TokenBucket) TokenBucket {
+	if !p.now.After(bucket.LastRefill) {
+		return bucket
+	}
+	elapsed := p.now.Sub(bucket.LastRefill)
+	tokensToAdd := float64(elapsed.Nanoseconds()) * p.refillRate / 1e9
+	bucket.Tokens = math.Min(bucket.Tokens+tokensToAdd, p.capacity)
+	bucket.LastRefill = p.now
+	return bucket
+}
+
+// fullAt returns when the bucket will be refilled to capacity
+func (p *parameter) fullAt(bucket TokenBucket) time.Time {
+	missing := p.capacity - bucket.Tokens
+	if missing <= 0 {
+		return p.now
+	}
+	return p.now.Add(time.Duration(missing / p.refillRate * float64(time.Second)))
+}
+
+// calculateResetTime returns when the bucket will hold enough tokens for cost
 func calculateResetTime(
 	now time.Time,
 	bucket TokenBucket,
 	refillRate float64,
+	cost float64,
 
 ) time.Time {
-	if bucket.Tokens >= 1.0 {
+	if bucket.Tokens >= cost {
 		return now
 	}
 
-	tokensNeeded := 1.0 - bucket.Tokens
+	tokensNeeded := cost - bucket.Tokens
 	if tokensNeeded <= 0 {
 		return now
 	}
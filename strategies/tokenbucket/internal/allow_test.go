@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ajiwo/ratelimit/strategies"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -56,6 +57,11 @@ func (m *mockConfigOne) GetBurst() int {
 	return args.Int(0)
 }
 
+// GetInitialTokens starts buckets full, like a nil tokenbucket.Config.InitialTokens
+func (m *mockConfigOne) GetInitialTokens() float64 {
+	return float64(m.GetBurst())
+}
+
 func (m *mockConfigOne) GetRate() float64 {
 	args := m.Called()
 	return args.Get(0).(float64)
@@ -66,6 +72,14 @@ func (m *mockConfigOne) GetMaxRetries() int {
 	return args.Int(0)
 }
 
+func (m *mockConfigOne) GetRetryBackoff() strategies.Backoff {
+	return strategies.Backoff{}
+}
+
+func (m *mockConfigOne) GetIdleTTL() time.Duration {
+	return 0
+}
+
 func TestAllow(t *testing.T) {
 	ctx := t.Context()
 	key := "test-key"
@@ -149,6 +163,20 @@ func TestAllow(t *testing.T) {
 			assert.Error(t, err)
 			assert.Equal(t, ErrStateParsing, err)
 		})
+
+		t.Run("non-finite state", func(t *testing.T) {
+			storage := new(mockBackendOne)
+			config := new(mockConfigOne)
+
+			config.On("GetKey").Return(key)
+			config.On("GetBurst").Return(burstSize)
+			config.On("GetMaxRetries").Return(maxRetries)
+			config.On("GetRate").Return(refillRate)
+			storage.On("Get", ctx, key).Return("12|NaN|1761884055342794596", nil)
+
+			_, err := Allow(ctx, storage, config, ReadOnly)
+			assert.Equal(t, ErrStateParsing, err)
+		})
 	})
 
 	t.Run("TryUpdate mode", func(t *testing.T) {
@@ -298,7 +326,7 @@ func Test_calculateResetTime(t *testing.T) {
 
 	t.Run("tokens are sufficient", func(t *testing.T) {
 		bucket := TokenBucket{Tokens: 1.5}
-		resetTime := calculateResetTime(now, bucket, refillRate)
+		resetTime := calculateResetTime(now, bucket, refillRate, 1)
 		assert.Equal(t, now, resetTime)
 	})
 
@@ -308,7 +336,7 @@ func Test_calculateResetTime(t *testing.T) {
 		timeToRefill := tokensNeeded / refillRate
 		expectedResetTime := now.Add(time.Duration(timeToRefill * float64(time.Second)))
 
-		resetTime := calculateResetTime(now, bucket, refillRate)
+		resetTime := calculateResetTime(now, bucket, refillRate, 1)
 		assert.WithinDuration(t, expectedResetTime, resetTime, 1*time.Millisecond)
 	})
 
@@ -318,7 +346,35 @@ func Test_calculateResetTime(t *testing.T) {
 		timeToRefill := tokensNeeded / refillRate
 		expectedResetTime := now.Add(time.Duration(timeToRefill * float64(time.Second)))
 
-		resetTime := calculateResetTime(now, bucket, refillRate)
+		resetTime := calculateResetTime(now, bucket, refillRate, 1)
 		assert.WithinDuration(t, expectedResetTime, resetTime, 1*time.Millisecond)
 	})
 }
+
+func Test_refill(t *testing.T) {
+	now := time.Now()
+	p := &parameter{capacity: 10, refillRate: 2, now: now}
+
+	t.Run("no time elapsed", func(t *testing.T) {
+		bucket := p.refill(TokenBucket{Tokens: 9, LastRefill: now})
+		assert.Equal(t, 9.0, bucket.Tokens)
+	})
+
+	t.Run("elapsed time earns tokens", func(t *testing.T) {
+		bucket := p.refill(TokenBucket{Tokens: 5, LastRefill: now.Add(-500 * time.Millisecond)})
+		assert.InDelta(t, 6.0, bucket.Tokens, 1e-9)
+		assert.Equal(t, now, bucket.LastRefill)
+	})
+
+	t.Run("capped at capacity", func(t *testing.T) {
+		bucket := p.refill(TokenBucket{Tokens: 9, LastRefill: now.Add(-time.Hour)})
+		assert.Equal(t, 10.0, bucket.Tokens)
+	})
+
+	t.Run("last refill in the future", func(t *testing.T) {
+		future := now.Add(time.Second)
+		bucket := p.refill(TokenBucket{Tokens: 5, LastRefill: future})
+		assert.Equal(t, 5.0, bucket.Tokens, "clock skew must not drain tokens")
+		assert.Equal(t, future, bucket.LastRefill)
+	})
+}
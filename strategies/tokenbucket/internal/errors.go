@@ -3,11 +3,13 @@ package internal
 import (
 	"errors"
 	"fmt"
+
+	"github.com/ajiwo/ratelimit/strategies"
 )
 
 var (
 	ErrStateParsing     = errors.New("failed to parse token bucket state: invalid encoding")
-	ErrConcurrentAccess = errors.New("failed to update token bucket state after max attempts due to concurrent access")
+	ErrConcurrentAccess = fmt.Errorf("failed to update token bucket state after max attempts due to concurrent access: %w", strategies.ErrMaxRetriesExceeded)
 )
 
 func NewStateRetrievalError(err error) error {
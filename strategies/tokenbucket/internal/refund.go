@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Refund returns n previously consumed tokens to the bucket.
+//
+// Tokens are clamped to the bucket capacity. A missing state is already a
+// full bucket, so the refund is a no-op.
+func Refund(ctx context.Context, storage backends.Backend, config Config, n int) error {
+	if err := ctx.Err(); err != nil {
+		return NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	for attempt := range p.maxRetries {
+		if err := ctx.Err(); err != nil {
+			return NewContextCanceledError(err)
+		}
+
+		data, err := p.storage.Get(ctx, p.key)
+		if err != nil {
+			return NewStateRetrievalError(err)
+		}
+		if data == "" {
+			return nil
+		}
+
+		bucket, ok := decodeState(data)
+		if !ok {
+			return ErrStateParsing
+		}
+
+		bucket = p.refill(bucket)
+		bucket.Tokens = math.Min(bucket.Tokens+float64(n), p.capacity)
+
+		beforeCAS := time.Now()
+		newValue := encodeState(bucket)
+		expiration := strategies.CalcIdleExpiration(p.burstSize, p.refillRate, p.idleTTL)
+
+		success, err := p.storage.CheckAndSet(ctx, p.key, data, newValue, expiration)
+		if err != nil {
+			return NewStateSaveError(err)
+		}
+		if success {
+			return nil
+		}
+
+		if err := p.backoff.Wait(ctx, attempt, time.Since(beforeCAS)); err != nil {
+			return NewContextCanceledError(err)
+		}
+	}
+
+	return ErrConcurrentAccess
+}
@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"context"
+	"math"
 	"strconv"
 	"time"
 
+	"github.com/ajiwo/ratelimit/backends"
 	"github.com/ajiwo/ratelimit/utils/builderpool"
 )
 
@@ -35,7 +38,7 @@ func decodeState(s string) (TokenBucket, bool) {
 	data := s[3:] // Skip "12|"
 
 	tokens, last, ok := parseStateFields(data)
-	if !ok {
+	if !ok || math.IsNaN(tokens) || math.IsInf(tokens, 0) {
 		return TokenBucket{}, false
 	}
 
@@ -66,3 +69,19 @@ func parseStateFields(data string) (float64, int64, bool) {
 
 	return tokens, last, true
 }
+
+// Inspect returns the stored state of key without modifying it, or false for a fresh key
+func Inspect(ctx context.Context, storage backends.Backend, key string) (TokenBucket, bool, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return TokenBucket{}, false, NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return TokenBucket{}, false, nil
+	}
+	bucket, ok := decodeState(data)
+	if !ok {
+		return TokenBucket{}, false, ErrStateParsing
+	}
+	return bucket, true, nil
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -57,3 +58,28 @@ func TestDecodeStateInvalid(t *testing.T) {
 		})
 	}
 }
+
+func FuzzDecodeState(f *testing.F) {
+	f.Add("12|123.45|1761884055342794596")
+	f.Add("12|0|0")
+	f.Add("12|NaN|0")
+	f.Add("12|+Inf|0")
+	f.Add("12|1e400|0")
+	f.Add("12|1|99999999999999999999")
+	f.Add("12||")
+	f.Add("12|\xff|0")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		bucket, ok := decodeState(s)
+		if !ok {
+			return
+		}
+		if math.IsNaN(bucket.Tokens) || math.IsInf(bucket.Tokens, 0) {
+			t.Fatalf("decoded non-finite tokens from %q", s)
+		}
+		decoded, ok := decodeState(encodeState(bucket))
+		if !ok || decoded.Tokens != bucket.Tokens || !decoded.LastRefill.Equal(bucket.LastRefill) {
+			t.Fatalf("re-encoding %q does not round trip", s)
+		}
+	})
+}
@@ -32,8 +32,10 @@ func (t *Strategy) Allow(ctx context.Context, config strategies.Config) (strateg
 	return map[string]strategies.Result{
 		"default": {
 			Allowed:   res.Allowed,
+			Limit:     tokenConfig.Burst,
 			Remaining: res.Remaining,
 			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
 		},
 	}, nil
 }
@@ -52,8 +54,10 @@ func (t *Strategy) Peek(ctx context.Context, config strategies.Config) (strategi
 	return map[string]strategies.Result{
 		"default": {
 			Allowed:   res.Allowed,
+			Limit:     tokenConfig.Burst,
 			Remaining: res.Remaining,
 			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
 		},
 	}, nil
 }
@@ -66,3 +70,53 @@ func (t *Strategy) Reset(ctx context.Context, config strategies.Config) error {
 
 	return t.storage.Delete(ctx, tokenConfig.Key)
 }
+
+func (t *Strategy) Refund(ctx context.Context, config strategies.Config, n int) error {
+	tokenConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	return internal.Refund(ctx, t.storage, tokenConfig, n)
+}
+
+// AllowCost is like Allow but consumes cost units of quota, e.g. a request
+// weighted by payload size. The request is denied unless the full cost fits.
+func (t *Strategy) AllowCost(ctx context.Context, config strategies.Config, cost float64) (strategies.Results, error) {
+	tokenConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	res, err := internal.AllowCost(ctx, t.storage, tokenConfig, cost)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]strategies.Result{
+		"default": {
+			Allowed:   res.Allowed,
+			Limit:     tokenConfig.Burst,
+			Remaining: res.Remaining,
+			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.BurstExceeded),
+		},
+	}, nil
+}
+
+// Inspect returns the stored token bucket of the key without modifying it, see strategies.Inspector
+func (t *Strategy) Inspect(ctx context.Context, config strategies.Config) ([]strategies.State, error) {
+	tokenConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	bucket, found, err := internal.Inspect(ctx, t.storage, tokenConfig.Key)
+	if err != nil {
+		return nil, err
+	}
+	state := strategies.State{Strategy: strategies.StrategyTokenBucket}
+	if found {
+		state.Value = bucket
+	}
+	return []strategies.State{state}, nil
+}
@@ -320,3 +320,151 @@ func TestTokenBucket_ConcurrentAccess(t *testing.T) {
 		assert.False(t, result["default"].Allowed, "11th request should be denied")
 	})
 }
+
+func TestTokenBucket_Refund(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		storage := &mockBackend{store: make(map[string]string)}
+		t.Cleanup(func() { storage.Close() })
+		strategy := New(storage)
+
+		config := &Config{
+			Key:   "refund-test-key",
+			Burst: 5,
+			Rate:  1.0,
+		}
+
+		// Refund on fresh state is a no-op
+		require.NoError(t, strategy.Refund(ctx, config, 1))
+		assert.Empty(t, storage.store, "Refund should not create state")
+
+		for range 3 {
+			result, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			require.True(t, result["default"].Allowed)
+		}
+
+		require.NoError(t, strategy.Refund(ctx, config, 2))
+		result, err := strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 4, result["default"].Remaining, "Remaining should increase by refunded tokens")
+
+		// Refund beyond capacity is clamped
+		require.NoError(t, strategy.Refund(ctx, config, 10))
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, 5, result["default"].Remaining, "Remaining should not exceed burst")
+	})
+}
+
+func TestTokenBucket_ResetTime(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		ctx := t.Context()
+		storage := &mockBackend{store: make(map[string]string)}
+		strategy := New(storage)
+
+		config := &Config{
+			Key:   "reset-time-key",
+			Burst: 10,
+			Rate:  2.0, // 2 tokens per second
+		}
+
+		// A full bucket is full now
+		result, err := strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, time.Now(), result["default"].Reset)
+
+		// Drain 4 tokens: full again after 4 / 2 = 2s
+		for range 4 {
+			result, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, time.Now().Add(2*time.Second), result["default"].Reset)
+
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, time.Now().Add(2*time.Second), result["default"].Reset)
+
+		// Half a second later 1 token was refilled
+		time.Sleep(500 * time.Millisecond)
+		result, err = strategy.Peek(ctx, config)
+		require.NoError(t, err)
+		assert.Equal(t, time.Now().Add(1500*time.Millisecond), result["default"].Reset)
+
+		// A denied request resets when the next token is available
+		for range 7 {
+			_, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+		}
+		result, err = strategy.Allow(ctx, config)
+		require.NoError(t, err)
+		assert.False(t, result["default"].Allowed)
+		assert.Equal(t, time.Now().Add(500*time.Millisecond), result["default"].Reset)
+	})
+}
+
+func TestTokenBucket_InitialTokens(t *testing.T) {
+	tokens := func(n float64) *float64 { return &n }
+
+	t.Run("start empty", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx := t.Context()
+			strategy := New(&mockBackend{store: make(map[string]string)})
+			config := &Config{Key: "empty", Burst: 4, Rate: 2, InitialTokens: tokens(0)}
+
+			result, err := strategy.Peek(ctx, config)
+			require.NoError(t, err)
+			assert.False(t, result["default"].Allowed)
+			assert.Equal(t, time.Now().Add(2*time.Second), result["default"].Reset)
+
+			// A literal zero denies the first request, and the bucket earns tokens from then on
+			result, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			assert.False(t, result["default"].Allowed)
+			assert.Equal(t, time.Now().Add(500*time.Millisecond), result["default"].Reset)
+
+			time.Sleep(500 * time.Millisecond)
+			result, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			assert.True(t, result["default"].Allowed)
+			result, err = strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			assert.False(t, result["default"].Allowed)
+
+			// The burst is earned at the refill rate
+			time.Sleep(2 * time.Second)
+			for i := range 4 {
+				result, err = strategy.Allow(ctx, config)
+				require.NoError(t, err)
+				assert.True(t, result["default"].Allowed, "Request %d should be allowed", i)
+			}
+		})
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		strategy := New(&mockBackend{store: make(map[string]string)})
+		config := &Config{Key: "partial", Burst: 4, Rate: 0.01, InitialTokens: tokens(2)}
+
+		allowed := 0
+		for range 4 {
+			result, err := strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			if result["default"].Allowed {
+				allowed++
+			}
+		}
+		assert.Equal(t, 2, allowed)
+	})
+
+	t.Run("burst", func(t *testing.T) {
+		strategy := New(&mockBackend{store: make(map[string]string)})
+		config := &Config{Key: "burst", Burst: 4, Rate: 0.01, InitialTokens: tokens(4)}
+
+		for i := range 4 {
+			result, err := strategy.Allow(t.Context(), config)
+			require.NoError(t, err)
+			assert.True(t, result["default"].Allowed, "Request %d should be allowed", i)
+			assert.Equal(t, 3-i, result["default"].Remaining)
+		}
+	})
+}
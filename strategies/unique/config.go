@@ -0,0 +1,164 @@
+package unique
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Config implements the Config interface for distinct actor limiting.
+//
+// Instead of requests, the strategy counts the distinct actors of a key per
+// fixed window, e.g. at most 100 distinct users of a tenant per hour. Requests
+// of an actor already counted in the window are always allowed; a new actor
+// is denied once Limit distinct actors were seen. The window starts with the
+// first actor. Actors are stored as 64-bit hashes, so state grows with Limit
+// and not with actor IDs, and no actor ID is persisted.
+type Config struct {
+	Key          string             // Storage key for the actor set
+	Actor        string             // Actor of the request, set per request with WithActor
+	Limit        int                // Maximum number of distinct actors per window
+	Window       time.Duration      // Window length
+	MaxRetries   int                // Maximum retry attempts for atomic operations, 0 means use default
+	RetryBackoff strategies.Backoff // Delay policy between retry attempts, zero value uses default
+}
+
+// Validate performs configuration validation for the distinct actor limiter.
+//
+// Returns an error if any of the following conditions are met:
+//   - Limit <= 0
+//   - Window <= 0
+//
+// Note: The Key and Actor fields are not validated here as they are set
+// later per request using WithKey() and WithActor().
+func (c *Config) Validate() error {
+	if c.Limit <= 0 {
+		return fmt.Errorf("%w: unique limit must be positive, got %d", strategies.ErrInvalidLimit, c.Limit)
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("%w: unique window must be positive, got %v", strategies.ErrInvalidWindow, c.Window)
+	}
+	return nil
+}
+
+// ID returns the unique identifier for the distinct actor strategy.
+//
+// This method implements the Config interface and returns StrategyUnique,
+// which is used for logging, debugging, and strategy selection.
+func (c *Config) ID() strategies.ID {
+	return strategies.StrategyUnique
+}
+
+// Capabilities returns the supported capabilities of the distinct actor strategy.
+//
+// This strategy supports primary and secondary roles, e.g. a request rate
+// primary with a distinct actor secondary, but does not support multi-quota
+// configurations.
+func (c *Config) Capabilities() strategies.CapabilityFlags {
+	return strategies.CapPrimary | strategies.CapSecondary
+}
+
+// WithKey returns a copy of the config with the provided key applied.
+//
+// The key is used as-is for storage without modification or prefixing.
+// This allows direct control over storage keys for backend compatibility.
+func (c *Config) WithKey(key string) strategies.Config {
+	cfg := *c
+	cfg.Key = key
+	return &cfg
+}
+
+// WithActor returns a copy of the config with the actor of the request applied.
+//
+// This method implements strategies.ActorConfig; the limiter applies
+// AccessOptions.Actor with it.
+func (c *Config) WithActor(actor string) strategies.Config {
+	cfg := *c
+	cfg.Actor = actor
+	return &cfg
+}
+
+// WithMaxRetries returns a copy of the config with the provided retry limit applied.
+//
+// This controls the maximum number of retry attempts for atomic operations
+// (CheckAndSet) when storage conflicts occur. Set to 0 to use the default
+// retry limit.
+func (c *Config) WithMaxRetries(retries int) strategies.Config {
+	cfg := *c
+	cfg.MaxRetries = retries
+	return &cfg
+}
+
+// WithRetryBackoff returns a copy of the config with the provided retry backoff applied.
+//
+// This controls the delay between retry attempts for atomic operations
+// (CheckAndSet). A zero Backoff keeps the default feedback-based delay.
+func (c *Config) WithRetryBackoff(backoff strategies.Backoff) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff = backoff
+	return &cfg
+}
+
+// WithRand returns a copy of the config drawing its retry jitter from rng.
+//
+// This method implements strategies.RandConfig and sets RetryBackoff.Rand,
+// keeping the rest of the backoff.
+func (c *Config) WithRand(rng *rand.Rand) strategies.Config {
+	cfg := *c
+	cfg.RetryBackoff.Rand = rng
+	return &cfg
+}
+
+// GetKey returns the storage key for the actor set.
+//
+// This method implements the internal.Config interface used by the distinct
+// actor algorithm.
+func (c *Config) GetKey() string {
+	return c.Key
+}
+
+// GetActor returns the actor of the request.
+//
+// This method implements the internal.Config interface used by the distinct
+// actor algorithm.
+func (c *Config) GetActor() string {
+	return c.Actor
+}
+
+// GetLimit returns the maximum number of distinct actors per window.
+//
+// This method implements the internal.Config interface used by the distinct
+// actor algorithm.
+func (c *Config) GetLimit() int {
+	return c.Limit
+}
+
+// GetWindow returns the window length.
+//
+// This method implements the internal.Config interface used by the distinct
+// actor algorithm.
+func (c *Config) GetWindow() time.Duration {
+	return c.Window
+}
+
+// GetMaxRetries returns the configured maximum retry attempts for atomic operations.
+//
+// When MaxRetries is 0 (default), returns Limit + 1: every lost CheckAndSet
+// means another actor was counted, so after Limit losses the window is full.
+// When MaxRetries > 0, returns the explicitly configured value.
+func (c *Config) GetMaxRetries() int {
+	if c.MaxRetries > 0 {
+		return c.MaxRetries
+	}
+	return c.Limit + 1
+}
+
+// GetRetryBackoff returns the configured delay policy between retry attempts.
+//
+// This method implements the internal.Config interface used by the distinct
+// actor algorithm. A zero Backoff means the default feedback-based delay is used.
+func (c *Config) GetRetryBackoff() strategies.Backoff {
+	return c.RetryBackoff
+}
@@ -0,0 +1,10 @@
+package unique
+
+import "errors"
+
+var (
+	// ErrInvalidConfig is returned when the provided config is not of type unique.Config.
+	ErrInvalidConfig = errors.New("unique strategy requires unique.Config")
+	// ErrActorRequired is returned by Allow when the config has no actor, see Config.WithActor.
+	ErrActorRequired = errors.New("unique strategy requires an actor")
+)
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// AllowMode represents the operation mode for `Allow`
+type AllowMode int
+
+const (
+	// ReadOnly only inspects current state without modifications
+	ReadOnly AllowMode = iota
+	// TryUpdate attempts to count the actor with retry logic
+	TryUpdate
+)
+
+// Result contains the result of Allow operation
+type Result struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Time
+}
+
+type parameter struct {
+	actor      string
+	backoff    strategies.Backoff
+	key        string
+	limit      int
+	maxRetries int
+	now        time.Time
+	storage    backends.Backend
+	window     time.Duration
+}
+
+// Allow provides a unified implementation for both Allow and Peek operations
+// mode determines whether to perform read-only inspection or actually count the actor.
+//
+// An actor already seen in the window is always allowed without a write. A
+// new actor is allowed and counted while fewer than the limit were seen.
+// Peek without an actor reports whether a new actor would be allowed.
+func Allow(
+	ctx context.Context,
+	storage backends.Backend,
+	config Config,
+	mode AllowMode,
+) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, NewContextCanceledError(err)
+	}
+
+	p := newParameter(ctx, storage, config)
+
+	if mode == ReadOnly {
+		actors, _, err := p.getState(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		allowed := len(actors.Hashes) < p.limit || (p.actor != "" && actors.contains(hashActor(p.actor)))
+		return p.result(actors, allowed), nil
+	}
+
+	return p.count(ctx)
+}
+
+// newParameter builds the operation parameters from config
+func newParameter(ctx context.Context, storage backends.Backend, config Config) *parameter {
+	return &parameter{
+		actor:      config.GetActor(),
+		backoff:    config.GetRetryBackoff(),
+		key:        config.GetKey(),
+		limit:      config.GetLimit(),
+		maxRetries: config.GetMaxRetries(),
+		now:        strategies.Now(ctx),
+		storage:    storage,
+		window:     config.GetWindow(),
+	}
+}
+
+// count adds the actor to the window if it is new and the limit allows it
+func (p *parameter) count(ctx context.Context) (Result, error) {
+	hash := hashActor(p.actor)
+	for attempt := range p.maxRetries {
+		if err := ctx.Err(); err != nil {
+			return Result{}, NewContextCanceledError(err)
+		}
+
+		actors, oldValue, err := p.getState(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		if actors.contains(hash) {
+			return p.result(actors, true), nil
+		}
+		if len(actors.Hashes) >= p.limit {
+			return p.result(actors, false), nil
+		}
+
+		actors = actors.add(hash)
+		beforeCAS := time.Now()
+		success, err := p.storage.CheckAndSet(ctx, p.key, oldValue, encodeState(actors), p.expiration(actors))
+		if err != nil {
+			return Result{}, NewStateSaveError(err)
+		}
+		if success {
+			return p.result(actors, true), nil
+		}
+
+		if err := p.backoff.Wait(ctx, attempt, time.Since(beforeCAS)); err != nil {
+			return Result{}, NewContextCanceledError(err)
+		}
+	}
+
+	return Result{}, ErrConcurrentAccess
+}
+
+// getState returns the actors of the window current at p.now and the raw
+// stored value. An ended window is returned as a fresh one starting now.
+func (p *parameter) getState(ctx context.Context) (Actors, string, error) {
+	data, err := p.storage.Get(ctx, p.key)
+	if err != nil {
+		return Actors{}, "", NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return Actors{Start: p.now}, "", nil
+	}
+
+	actors, ok := decodeState(data)
+	if !ok {
+		return Actors{}, "", ErrStateParsing
+	}
+	// A start after now, written by a concurrent caller whose clock read came
+	// later, is the current window too
+	if !p.now.Before(actors.Start.Add(p.window)) {
+		return Actors{Start: p.now}, data, nil
+	}
+	return actors, data, nil
+}
+
+// result reports the actors of the window, Reset is when the window ends
+func (p *parameter) result(actors Actors, allowed bool) Result {
+	return Result{
+		Allowed:   allowed,
+		Remaining: max(p.limit-len(actors.Hashes), 0),
+		Reset:     actors.Start.Add(p.window),
+	}
+}
+
+// expiration keeps the state until its window ends
+func (p *parameter) expiration(actors Actors) time.Duration {
+	return max(actors.Start.Add(p.window).Sub(p.now), time.Second)
+}
@@ -0,0 +1,16 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+type Config interface {
+	GetKey() string
+	GetLimit() int
+	GetWindow() time.Duration
+	GetActor() string
+	GetMaxRetries() int
+	GetRetryBackoff() strategies.Backoff
+}
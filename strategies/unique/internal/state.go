@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/utils/builderpool"
+)
+
+// Actors holds the distinct actors seen in the current window, as sorted
+// 64-bit FNV-1a hashes so that state size is bounded and no actor ID is stored
+type Actors struct {
+	Start  time.Time `json:"start"`
+	Hashes []uint64  `json:"hashes"`
+}
+
+// hashActor returns the stored hash of an actor
+func hashActor(actor string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(actor))
+	return h.Sum64()
+}
+
+// contains reports whether the actor hash was seen in the window
+func (a Actors) contains(hash uint64) bool {
+	_, found := slices.BinarySearch(a.Hashes, hash)
+	return found
+}
+
+// add adds the actor hash, keeping the order
+func (a Actors) add(hash uint64) Actors {
+	hashes := slices.Clone(a.Hashes)
+	i, _ := slices.BinarySearch(hashes, hash)
+	return Actors{Start: a.Start, Hashes: slices.Insert(hashes, i, hash)}
+}
+
+// encodeState serializes actors into a compact ASCII format:
+// 81|startNano|N|hash1_hex|...|hashN_hex
+func encodeState(a Actors) string {
+	sb := builderpool.Get()
+	defer builderpool.Put(sb)
+
+	sb.WriteString("81|")
+	sb.WriteString(strconv.FormatInt(a.Start.UnixNano(), 10))
+	sb.WriteByte('|')
+	sb.WriteString(strconv.Itoa(len(a.Hashes)))
+	for _, hash := range a.Hashes {
+		sb.WriteByte('|')
+		sb.WriteString(strconv.FormatUint(hash, 16))
+	}
+	return sb.String()
+}
+
+func decodeState(s string) (Actors, bool) {
+	if len(s) < 4 || s[:3] != "81|" {
+		return Actors{}, false
+	}
+
+	fields := strings.Split(s[3:], "|")
+	if len(fields) < 2 {
+		return Actors{}, false
+	}
+	start, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Actors{}, false
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil || n < 0 || n != len(fields)-2 {
+		return Actors{}, false
+	}
+
+	hashes := make([]uint64, n)
+	for i, field := range fields[2:] {
+		hash, err := strconv.ParseUint(field, 16, 64)
+		if err != nil {
+			return Actors{}, false
+		}
+		// Sorted without duplicates
+		if i > 0 && hash <= hashes[i-1] {
+			return Actors{}, false
+		}
+		hashes[i] = hash
+	}
+	return Actors{Start: time.Unix(0, start), Hashes: hashes}, true
+}
+
+// Inspect returns the stored state of key without modifying it, or false for a fresh key
+func Inspect(ctx context.Context, storage backends.Backend, key string) (Actors, bool, error) {
+	data, err := storage.Get(ctx, key)
+	if err != nil {
+		return Actors{}, false, NewStateRetrievalError(err)
+	}
+	if data == "" {
+		return Actors{}, false, nil
+	}
+	actors, ok := decodeState(data)
+	if !ok {
+		return Actors{}, false, ErrStateParsing
+	}
+	return actors, true, nil
+}
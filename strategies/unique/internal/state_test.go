@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActors_Add(t *testing.T) {
+	var a Actors
+	for _, actor := range []string{"bob", "alice", "carol", "alice"} {
+		if hash := hashActor(actor); !a.contains(hash) {
+			a = a.add(hash)
+		}
+	}
+	require.Len(t, a.Hashes, 3)
+	assert.IsIncreasing(t, a.Hashes)
+	assert.True(t, a.contains(hashActor("alice")))
+	assert.False(t, a.contains(hashActor("dave")))
+}
+
+func TestState_EncodeDecode(t *testing.T) {
+	a := Actors{Start: time.Unix(0, 1761884055342794596), Hashes: []uint64{0x1f, 0xabc, 0xffffffffffffffff}}
+	encoded := encodeState(a)
+	assert.Equal(t, "81|1761884055342794596|3|1f|abc|ffffffffffffffff", encoded)
+
+	decoded, ok := decodeState(encoded)
+	require.True(t, ok)
+	assert.Equal(t, a.Hashes, decoded.Hashes)
+	assert.True(t, a.Start.Equal(decoded.Start))
+
+	empty, ok := decodeState(encodeState(Actors{Start: a.Start}))
+	require.True(t, ok)
+	assert.Empty(t, empty.Hashes)
+
+	for _, invalid := range []string{
+		"", "81|", "81|1", "81|x|0", "81|1|-1", "81|1|2|a", "81|1|1|x",
+		"81|1|2|b|a", "81|1|2|a|a", "81|1|1|10000000000000000", "71|1|0",
+	} {
+		_, ok := decodeState(invalid)
+		assert.False(t, ok, "%q", invalid)
+	}
+}
+
+func FuzzDecodeState(f *testing.F) {
+	f.Add("81|1761884055342794596|2|1f|abc")
+	f.Add("81|0|0")
+	f.Add("81|-1|0")
+	f.Add("81|1|1|")
+	f.Add("81|1|2|abc|1f")
+	f.Add("81|1|1|\xff")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		actors, ok := decodeState(s)
+		if !ok {
+			return
+		}
+		decoded, ok := decodeState(encodeState(actors))
+		if !ok || !decoded.Start.Equal(actors.Start) || len(decoded.Hashes) != len(actors.Hashes) {
+			t.Fatalf("re-encoding %q does not round trip", s)
+		}
+	})
+}
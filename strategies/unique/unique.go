@@ -0,0 +1,91 @@
+package unique
+
+import (
+	"context"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/unique/internal"
+)
+
+// Strategy implements distinct actor limiting over fixed windows
+type Strategy struct {
+	storage backends.Backend
+}
+
+// New creates a new distinct actor strategy
+func New(storage backends.Backend) *Strategy {
+	return &Strategy{storage: storage}
+}
+
+// Allow counts the actor of the config if it is new to the window.
+//
+// Actors already counted are allowed without a write; a new actor is allowed
+// while fewer than Limit were seen. Returns ErrActorRequired without an actor.
+func (s *Strategy) Allow(ctx context.Context, config strategies.Config) (strategies.Results, error) {
+	return s.check(ctx, config, internal.TryUpdate)
+}
+
+// Peek reports the distinct actors of the window without counting one.
+//
+// With an actor, Allowed reports whether that actor would be allowed;
+// without, whether a new actor would be.
+func (s *Strategy) Peek(ctx context.Context, config strategies.Config) (strategies.Results, error) {
+	return s.check(ctx, config, internal.ReadOnly)
+}
+
+func (s *Strategy) check(ctx context.Context, config strategies.Config, mode internal.AllowMode) (strategies.Results, error) {
+	uniqueConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+	if mode == internal.TryUpdate && uniqueConfig.Actor == "" {
+		return nil, ErrActorRequired
+	}
+
+	res, err := internal.Allow(ctx, s.storage, uniqueConfig, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]strategies.Result{
+		"default": {
+			Allowed:   res.Allowed,
+			Limit:     uniqueConfig.Limit,
+			Remaining: res.Remaining,
+			Reset:     res.Reset,
+			Reason:    strategies.DeniedBy(res.Allowed, strategies.QuotaExhausted),
+		},
+	}, nil
+}
+
+// Reset forgets the actors of the key
+func (s *Strategy) Reset(ctx context.Context, config strategies.Config) error {
+	uniqueConfig, ok := config.(*Config)
+	if !ok {
+		return ErrInvalidConfig
+	}
+
+	return s.storage.Delete(ctx, uniqueConfig.Key)
+}
+
+// Actors is the stored set of actor hashes of a window, see Strategy.Inspect
+type Actors = internal.Actors
+
+// Inspect returns the stored actors of the key without modifying it, see strategies.Inspector
+func (s *Strategy) Inspect(ctx context.Context, config strategies.Config) ([]strategies.State, error) {
+	uniqueConfig, ok := config.(*Config)
+	if !ok {
+		return nil, ErrInvalidConfig
+	}
+
+	actors, found, err := internal.Inspect(ctx, s.storage, uniqueConfig.Key)
+	if err != nil {
+		return nil, err
+	}
+	state := strategies.State{Strategy: strategies.StrategyUnique}
+	if found {
+		state.Value = actors
+	}
+	return []strategies.State{state}, nil
+}
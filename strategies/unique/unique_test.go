@@ -0,0 +1,88 @@
+package unique
+
+import (
+	"fmt"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends/memory"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnique_DistinctActors(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		storage := memory.New()
+		defer storage.Close()
+		strategy := New(storage)
+		config := &Config{Key: "tenant", Limit: 3, Window: time.Hour}
+
+		allow := func(actor string) strategies.Result {
+			t.Helper()
+			results, err := strategy.Allow(t.Context(), config.WithActor(actor))
+			require.NoError(t, err)
+			return results.Default()
+		}
+
+		// New actors count up to the limit
+		for i := range 3 {
+			result := allow(fmt.Sprintf("user-%d", i))
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 2-i, result.Remaining)
+		}
+		assert.False(t, allow("user-3").Allowed)
+
+		// Known actors have no effect on the count and are still allowed
+		for range 5 {
+			result := allow("user-1")
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 0, result.Remaining)
+			assert.Equal(t, time.Now().Add(time.Hour), result.Reset)
+		}
+
+		// The next window starts over
+		time.Sleep(time.Hour)
+		assert.True(t, allow("user-3").Allowed)
+		assert.Equal(t, 1, allow("user-4").Remaining)
+	})
+}
+
+func TestUnique_PeekAndReset(t *testing.T) {
+	storage := memory.New()
+	defer storage.Close()
+	strategy := New(storage)
+	config := &Config{Key: "tenant", Limit: 1, Window: time.Hour}
+
+	results, err := strategy.Allow(t.Context(), config.WithActor("alice"))
+	require.NoError(t, err)
+	require.True(t, results.Default().Allowed)
+
+	for actor, allowed := range map[string]bool{"alice": true, "bob": false, "": false} {
+		results, err := strategy.Peek(t.Context(), config.WithActor(actor))
+		require.NoError(t, err)
+		assert.Equal(t, allowed, results.Default().Allowed, "actor %q", actor)
+	}
+
+	states, err := strategy.Inspect(t.Context(), config)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Len(t, states[0].Value.(Actors).Hashes, 1)
+
+	require.NoError(t, strategy.Reset(t.Context(), config))
+	results, err = strategy.Peek(t.Context(), config.WithActor("bob"))
+	require.NoError(t, err)
+	assert.True(t, results.Default().Allowed)
+}
+
+func TestUnique_Errors(t *testing.T) {
+	strategy := New(memory.New())
+
+	_, err := strategy.Allow(t.Context(), &Config{Key: "tenant", Limit: 1, Window: time.Hour})
+	require.ErrorIs(t, err, ErrActorRequired)
+
+	for _, config := range []*Config{{Limit: 0, Window: time.Hour}, {Limit: 1}} {
+		assert.Error(t, config.Validate())
+	}
+}
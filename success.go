@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrResetOnSuccessDisabled is returned by MarkSuccess without WithResetOnSuccess
+var ErrResetOnSuccessDisabled = errors.New("reset on success is not enabled")
+
+// WithResetOnSuccess lets MarkSuccess reset the counters of a key, so that
+// only failures accumulate, e.g. for login throttling: every attempt calls
+// Allow, and a successful login calls MarkSuccess to start the key over.
+//
+// It requires a fixed window primary strategy.
+func WithResetOnSuccess() Option {
+	return func(config *Config) error {
+		config.resetOnSuccess = true
+		return nil
+	}
+}
+
+// MarkSuccess reports a successful attempt for the key, resetting its
+// strategy state so that the next attempts start from a full quota.
+//
+// Unlike Reset, a WithPenalty lockout of the key is left in place. Returns
+// ErrResetOnSuccessDisabled if the limiter was not created with
+// WithResetOnSuccess.
+func (r *RateLimiter) MarkSuccess(ctx context.Context, options AccessOptions) error {
+	if !r.resetOnSuccess {
+		return ErrResetOnSuccessDisabled
+	}
+
+	dynamicKey, err := r.dynamicKey(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	if err := r.strategy.Reset(r.logContext(ctx), r.buildStrategyConfig(dynamicKey)); err != nil {
+		return fmt.Errorf("failed to reset strategy: %w", err)
+	}
+	r.snapshots.forget(dynamicKey)
+	r.forgetDenial(dynamicKey)
+	return nil
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTenantRequired is returned when WithRequireTenant is set and a request has no tenant
+var ErrTenantRequired = errors.New("tenant is required")
+
+// tenantKey is the context key type for the tenant stored by ContextWithTenant
+type tenantKey struct{}
+
+// WithTenant sets the default tenant of the limiter.
+//
+// Tenants isolate dynamic keys: "tenantA" with key "user1" and "tenantB" with
+// key "user1" are distinct buckets. The tenant is inserted between the base
+// key and the dynamic key as a "#tenant:" segment, which validated dynamic
+// keys cannot produce, so a tenant-less key never collides with a tenant's.
+// AccessOptions.Tenant and ContextWithTenant override the default per request.
+func WithTenant(id string) Option {
+	return func(config *Config) error {
+		if err := validateTenant(id); err != nil {
+			return err
+		}
+		config.tenant = id
+		return nil
+	}
+}
+
+// WithRequireTenant makes every request without a tenant fail with ErrTenantRequired
+func WithRequireTenant() Option {
+	return func(config *Config) error {
+		config.requireTenant = true
+		return nil
+	}
+}
+
+// ContextWithTenant returns a copy of ctx carrying the tenant id, e.g. set by
+// an authentication middleware alongside the request span.
+//
+// It is used when AccessOptions.Tenant is empty, and overrides WithTenant.
+func ContextWithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// TenantFromContext returns the tenant stored by ContextWithTenant, if any
+func TenantFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
+
+// validateTenant checks a tenant id like a dynamic key, without ':' so that
+// the tenant segment cannot be confused with the dynamic key
+func validateTenant(id string) error {
+	if err := validateKey(id, "tenant"); err != nil {
+		return err
+	}
+	if strings.ContainsRune(id, ':') {
+		return fmt.Errorf("tenant cannot contain ':', got %q", id)
+	}
+	return nil
+}
+
+// withTenant qualifies the dynamic key with the request tenant, if any.
+//
+// Precedence: AccessOptions.Tenant, ContextWithTenant, then WithTenant.
+// Tenants are always validated, even with SkipValidation.
+func (r *RateLimiter) withTenant(ctx context.Context, options AccessOptions, key string) (string, error) {
+	tenant := options.Tenant
+	if tenant == "" {
+		tenant, _ = TenantFromContext(ctx)
+	}
+	if tenant == "" {
+		tenant = r.tenant
+	}
+
+	if tenant == "" {
+		if r.requireTenant {
+			return "", ErrTenantRequired
+		}
+		return key, nil
+	}
+	if err := validateTenant(tenant); err != nil {
+		return "", err
+	}
+	return "#" + tenant + ":" + key, nil
+}
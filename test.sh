@@ -11,6 +11,9 @@ go test -count=1 -timeout=30s -race -coverprofile=coverage.out .
 cd ../redis
 go test -count=1 -timeout=30s -race -coverprofile=coverage.out . 
 
+cd ../cassandra
+go test -count=1 -timeout=60s -race . 
+
 cd ../..
 
 sync
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/gcra"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+)
+
+// benchStrategies are limiter options per strategy with limits that are never
+// reached, so every request takes the write path
+var benchStrategies = map[string][]ratelimit.Option{
+	"fixedwindow": {ratelimit.WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", 1<<24, time.Hour).Build())},
+	"tokenbucket": {ratelimit.WithPrimaryStrategy(&tokenbucket.Config{Burst: 1 << 24, Rate: 1 << 20})},
+	"leakybucket": {ratelimit.WithPrimaryStrategy(&leakybucket.Config{Burst: 1 << 24, Rate: 1 << 20})},
+	"gcra":        {ratelimit.WithPrimaryStrategy(&gcra.Config{Burst: 1 << 24, Rate: 1 << 20})},
+	"dual": {
+		ratelimit.WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", 1<<24, time.Hour).Build()),
+		ratelimit.WithSecondaryStrategy(&tokenbucket.Config{Burst: 1 << 24, Rate: 1 << 20}),
+	},
+}
+
+// benchmarkAllow runs Allow in parallel across the given number of keys and
+// reports the CAS retries per operation from Stats
+func benchmarkAllow(b *testing.B, keys int) {
+	for _, backendName := range []string{"memory", "redis", "postgres"} {
+		for _, strategyName := range []string{"fixedwindow", "tokenbucket", "leakybucket", "gcra", "dual"} {
+			b.Run(backendName+"/"+strategyName, func(b *testing.B) {
+				storage := UseBackend(b, backendName)
+				limiter, err := ratelimit.New(append([]ratelimit.Option{
+					ratelimit.WithBackend(storage),
+					ratelimit.WithBaseKey(fmt.Sprintf("bench_%d", time.Now().UnixNano())),
+					ratelimit.WithMaxRetries(1000),
+				}, benchStrategies[strategyName]...)...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.Cleanup(func() { limiter.Close() })
+
+				var next atomic.Int64
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						key := fmt.Sprintf("key%d", next.Add(1)%int64(keys))
+						if _, err := limiter.Allow(b.Context(), ratelimit.AccessOptions{Key: key}); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+				b.StopTimer()
+
+				stats := limiter.Stats()
+				b.ReportMetric(float64(stats.CASRetries)/float64(b.N), "cas_retries/op")
+			})
+		}
+	}
+}
+
+// BenchmarkAllow_ManyKeys spreads requests over many keys, the common case
+// of per-user limits.
+//
+// Redis and Postgres run when reachable, e.g.:
+//
+//	go test -run '^$' -bench Allow_ ./...
+func BenchmarkAllow_ManyKeys(b *testing.B) {
+	benchmarkAllow(b, 1000)
+}
+
+// BenchmarkAllow_HotKey sends every request to a single key to measure CAS contention
+func BenchmarkAllow_HotKey(b *testing.B) {
+	benchmarkAllow(b, 1)
+}
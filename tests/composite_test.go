@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit"
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestComposite_StressAtomicTiers checks that concurrent requests on a single
+// key consume the primary and secondary tiers together on every backend: each
+// tier is consumed exactly once per allowed request, so neither tier goes over
+// its limit nor loses quota to a request the other tier denied.
+func TestComposite_StressAtomicTiers(t *testing.T) {
+	const goroutines = 200
+
+	tests := []struct {
+		name           string
+		primaryLimit   int
+		secondaryBurst int
+	}{
+		{"PrimaryTighter", 30, 60},
+		{"SecondaryTighter", 60, 30},
+	}
+
+	for _, backendName := range []string{"memory", "postgres", "redis"} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s_%sBackend", tt.name, backendName), func(t *testing.T) {
+				backend := UseBackend(t, backendName)
+				limiter, err := ratelimit.New(
+					ratelimit.WithBaseKey(fmt.Sprintf("comp-stress-%s-%d", backendName, time.Now().UnixNano())),
+					ratelimit.WithBackend(backend),
+					ratelimit.WithPrimaryStrategy(fixedwindow.NewConfig().AddQuota("default", tt.primaryLimit, time.Hour).Build()),
+					// Slow enough that nothing refills while the test runs
+					ratelimit.WithSecondaryStrategy(&tokenbucket.Config{Burst: tt.secondaryBurst, Rate: 0.0001}),
+					ratelimit.WithMaxRetries(goroutines),
+				)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = limiter.Close() })
+
+				var allowed, denied, failed atomic.Int64
+				start := make(chan struct{})
+				var wg sync.WaitGroup
+				for range goroutines {
+					wg.Go(func() {
+						<-start
+						ok, err := limiter.Allow(t.Context(), ratelimit.AccessOptions{Key: "stress"})
+						switch {
+						case err != nil:
+							failed.Add(1)
+							t.Logf("Unexpected error: %v", err)
+						case ok:
+							allowed.Add(1)
+						default:
+							denied.Add(1)
+						}
+					})
+				}
+				close(start)
+				wg.Wait()
+
+				want := min(tt.primaryLimit, tt.secondaryBurst)
+				assert.Equal(t, int64(0), failed.Load())
+				assert.Equal(t, int64(want), allowed.Load())
+				assert.Equal(t, int64(goroutines-want), denied.Load())
+
+				// Both tiers consumed exactly the allowed requests
+				var results strategies.Results
+				_, err = limiter.Peek(t.Context(), ratelimit.AccessOptions{Key: "stress", Result: &results})
+				require.NoError(t, err)
+				assert.Equal(t, tt.primaryLimit-want, results.PrimaryDefault().Remaining)
+				assert.Equal(t, tt.secondaryBurst-want, results.SecondaryDefault().Remaining)
+			})
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// casOnly hides optional backend interfaces such as backends.WindowIncrementer,
+// forcing the fixed window strategy onto the Get and CheckAndSet path
+type casOnly struct {
+	backends.Backend
+}
+
+// threeQuotas returns the common minute/hour/day fixed window config
+func threeQuotas(key string, perMinute int) *fixedwindow.Config {
+	return fixedwindow.NewConfig().
+		SetKey(key).
+		AddQuota("minute", perMinute, time.Minute).
+		AddQuota("hour", 10*perMinute, time.Hour).
+		AddQuota("day", 100*perMinute, 24*time.Hour).
+		Build()
+}
+
+// TestFixedWindow_ConcurrentExactLimit checks that concurrent requests on a
+// 3-quota key are allowed exactly up to the most restrictive quota, both with
+// server-side increments and with CheckAndSet.
+func TestFixedWindow_ConcurrentExactLimit(t *testing.T) {
+	const goroutines, requestsPerGoroutine, limit = 16, 5, 37
+
+	for _, backendName := range []string{"memory", "redis", "postgres"} {
+		for _, mode := range []string{"native", "cas"} {
+			t.Run(backendName+"_"+mode, func(t *testing.T) {
+				storage := UseBackend(t, backendName)
+				t.Cleanup(func() { storage.Close() })
+				if mode == "cas" {
+					storage = casOnly{storage}
+				}
+
+				strategy := fixedwindow.New(storage)
+				config := threeQuotas(fmt.Sprintf("fw_exact_%s_%s_%d", backendName, mode, time.Now().UnixNano()), limit)
+				config.MaxRetries = goroutines * requestsPerGoroutine
+
+				var allowed, denied atomic.Int64
+				var wg sync.WaitGroup
+				for range goroutines {
+					wg.Go(func() {
+						for range requestsPerGoroutine {
+							result, err := strategy.Allow(t.Context(), config)
+							if !assert.NoError(t, err) {
+								return
+							}
+							if result["minute"].Allowed {
+								allowed.Add(1)
+							} else {
+								denied.Add(1)
+							}
+						}
+					})
+				}
+				wg.Wait()
+
+				assert.EqualValues(t, limit, allowed.Load())
+				assert.EqualValues(t, goroutines*requestsPerGoroutine-limit, denied.Load())
+
+				result, err := strategy.Peek(t.Context(), config)
+				require.NoError(t, err)
+				assert.Equal(t, 0, result["minute"].Remaining)
+				assert.Equal(t, 10*limit-limit, result["hour"].Remaining)
+				assert.Equal(t, 100*limit-limit, result["day"].Remaining)
+			})
+		}
+	}
+}
+
+// BenchmarkFixedWindow_ThreeQuotas compares server-side increments with
+// CheckAndSet for a minute/hour/day config under 16 concurrent goroutines.
+//
+// Run with TEST_POSTGRES_DSN set, e.g.:
+//
+//	go test -run '^$' -bench FixedWindow_ThreeQuotas ./...
+func BenchmarkFixedWindow_ThreeQuotas(b *testing.B) {
+	for _, mode := range []string{"native", "cas"} {
+		b.Run("postgres_"+mode, func(b *testing.B) {
+			storage := UseBackend(b, "postgres")
+			b.Cleanup(func() { storage.Close() })
+			if mode == "cas" {
+				storage = casOnly{storage}
+			}
+
+			strategy := fixedwindow.New(storage)
+			// A limit that is never reached keeps every request on the write path
+			config := threeQuotas(fmt.Sprintf("fw_bench_%s_%d", mode, time.Now().UnixNano()), 1<<24)
+			config.MaxRetries = 1000
+
+			const goroutines = 16
+			b.ResetTimer()
+			var wg sync.WaitGroup
+			for g := range goroutines {
+				wg.Go(func() {
+					for i := g; i < b.N; i += goroutines {
+						if _, err := strategy.Allow(b.Context(), config); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // UseBackend creates a backend instance for testing, skipping the test if the backend is not available
-func UseBackend(t *testing.T, name string) backends.Backend {
+func UseBackend(t testing.TB, name string) backends.Backend {
 	t.Helper()
 	var backend backends.Backend
 	var err error
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ajiwo/ratelimit"
+	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLeakyBucket_StressExactAdmission checks that concurrent requests on a
+// single key are admitted exactly up to the burst on every backend, so no
+// update is lost between the read and the CheckAndSet of the bucket.
+func TestLeakyBucket_StressExactAdmission(t *testing.T) {
+	const goroutines = 200
+	const burst = 50
+
+	for _, backendName := range []string{"memory", "postgres", "redis"} {
+		t.Run(backendName+"Backend", func(t *testing.T) {
+			backend := UseBackend(t, backendName)
+			limiter, err := ratelimit.New(
+				ratelimit.WithBaseKey(fmt.Sprintf("lb-stress-%s-%d", backendName, time.Now().UnixNano())),
+				ratelimit.WithBackend(backend),
+				// Slow enough that nothing leaks while the test runs
+				ratelimit.WithPrimaryStrategy(&leakybucket.Config{Burst: burst, Rate: 0.0001}),
+				ratelimit.WithMaxRetries(goroutines),
+			)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = limiter.Close() })
+
+			var allowed, denied, failed atomic.Int64
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for range goroutines {
+				wg.Go(func() {
+					<-start
+					ok, err := limiter.Allow(t.Context(), ratelimit.AccessOptions{Key: "stress"})
+					switch {
+					case err != nil:
+						failed.Add(1)
+						t.Logf("Unexpected error: %v", err)
+					case ok:
+						allowed.Add(1)
+					default:
+						denied.Add(1)
+					}
+				})
+			}
+			close(start)
+			wg.Wait()
+
+			assert.Equal(t, int64(0), failed.Load())
+			assert.Equal(t, int64(burst), allowed.Load())
+			assert.Equal(t, int64(goroutines-burst), denied.Load())
+		})
+	}
+}
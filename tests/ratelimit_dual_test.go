@@ -12,6 +12,7 @@ import (
 	"github.com/ajiwo/ratelimit/strategies/fixedwindow"
 	"github.com/ajiwo/ratelimit/strategies/gcra"
 	"github.com/ajiwo/ratelimit/strategies/leakybucket"
+	"github.com/ajiwo/ratelimit/strategies/slidingwindow"
 	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -135,6 +136,18 @@ var dualStrategyConfigs = []DualStrategyConfig{
 		},
 		testType: "multiQuota",
 	},
+	{
+		name: "SlidingWindow_TokenBucket",
+		primaryStrategy: &slidingwindow.Config{
+			Limit:  5,
+			Window: 10 * time.Second,
+		},
+		secondaryStrategy: &tokenbucket.Config{
+			Burst: 3,
+			Rate:  0.5,
+		},
+		testType: "basic",
+	},
 }
 
 // testDualStrategyBackend runs a single test case for a backend and dual strategy configuration
@@ -407,6 +420,47 @@ func TestDualStrategy_Basic(t *testing.T) {
 	}
 }
 
+// TestDualStrategy_SlidingWindow tests a sliding window primary with a token bucket secondary across all backends
+func TestDualStrategy_SlidingWindow(t *testing.T) {
+	backends := []string{"memory", "postgres", "redis"}
+	config := dualStrategyConfigs[7] // SlidingWindow_TokenBucket
+
+	for _, backend := range backends {
+		if isCI() {
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Run(fmt.Sprintf("%s_%s", config.name, backend), func(t *testing.T) {
+			testDualStrategyBackend(t, backend, config)
+			testSlidingWindowDualResults(t, UseBackend(t, backend), config)
+		})
+	}
+}
+
+// testSlidingWindowDualResults checks the prefixed results of a sliding window primary with a token bucket secondary
+func testSlidingWindowDualResults(t *testing.T, backend backends.Backend, config DualStrategyConfig) {
+	limiter := createDualLimiter(t, backend, config, "sliding")
+	ctx := t.Context()
+	var results strategies.Results
+
+	// The token bucket denies first, after its burst of 3
+	for i := range 3 {
+		allowed, err := limiter.Allow(ctx, ratelimit.AccessOptions{Key: "user", Result: &results})
+		require.NoError(t, err)
+		assert.True(t, allowed, "Request %d should be allowed", i+1)
+		assert.Equal(t, 5, results["primary_default"].Limit)
+		assert.Equal(t, 4-i, results["primary_default"].Remaining)
+		assert.Equal(t, 3, results["secondary_default"].Limit)
+		assert.Equal(t, 2-i, results["secondary_default"].Remaining)
+	}
+
+	allowed, err := limiter.Allow(ctx, ratelimit.AccessOptions{Key: "user", Result: &results})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.True(t, results["primary_default"].Allowed, "The sliding window still has room")
+	assert.False(t, results["secondary_default"].Allowed)
+	assert.Equal(t, 2, results["primary_default"].Remaining, "Denied requests consume no primary quota")
+}
+
 // TestDualStrategy_MultiQuota tests dual strategy with multiple quotas across all backends
 func TestDualStrategy_MultiQuota(t *testing.T) {
 	backends := []string{"memory", "postgres", "redis"}
@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/ajiwo/ratelimit/strategies/tokenbucket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTokenBucket_BackendConsistency checks that refill is computed identically
+// regardless of the backend, so one consume right after creation leaves exactly
+// capacity-1 tokens everywhere.
+func TestTokenBucket_BackendConsistency(t *testing.T) {
+	ctx := t.Context()
+
+	for _, backendName := range []string{"memory", "redis", "postgres"} {
+		t.Run(backendName+"Backend", func(t *testing.T) {
+			storage := UseBackend(t, backendName)
+			t.Cleanup(func() { storage.Close() })
+
+			strategy := tokenbucket.New(storage)
+			config := &tokenbucket.Config{Key: "tb_consistency_" + backendName, Burst: 10, Rate: 1}
+			require.NoError(t, strategy.Reset(ctx, config))
+
+			result, err := strategy.Allow(ctx, config)
+			require.NoError(t, err)
+			assert.True(t, result.Default().Allowed)
+			assert.Equal(t, 9, result.Default().Remaining, "%s: allow should leave capacity-1", backendName)
+
+			result, err = strategy.Peek(ctx, config)
+			require.NoError(t, err)
+			assert.Equal(t, 9, result.Default().Remaining, "%s: peek should not over-count fractional refill", backendName)
+		})
+	}
+}
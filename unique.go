@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/ajiwo/ratelimit/strategies"
+	"github.com/ajiwo/ratelimit/strategies/unique"
+)
+
+// WithUniqueStrategy configures a primary strategy limiting the distinct
+// actors of a key, e.g. at most 100 distinct users per tenant and hour,
+// instead of its requests. See unique.Config.
+//
+// The actor is passed as AccessOptions.Actor; Allow fails without one.
+// Requests of an actor already counted in the window are always allowed.
+// Results are reported under the "default" key. To limit request rates too,
+// combine a rate primary with a unique.Config secondary.
+func WithUniqueStrategy(maxDistinct int, window time.Duration) Option {
+	return WithPrimaryStrategy(&unique.Config{Limit: maxDistinct, Window: window})
+}
+
+// withActor applies the actor of the request to configs implementing
+// strategies.ActorConfig, leaving other configs unchanged
+func withActor(config strategies.Config, actor string) strategies.Config {
+	if ac, ok := config.(strategies.ActorConfig); ok {
+		return ac.WithActor(actor)
+	}
+	return config
+}
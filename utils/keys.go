@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// KeySeparator separates the parts of a composite key
+	KeySeparator = ":"
+	// maxKeyLength is the maximum key length accepted by ValidateKey
+	maxKeyLength = 64
+	// keyHashLength is the number of hex digits kept from the hash of an overlong key
+	keyHashLength = 16
+)
+
+// SanitizeKeyPart makes an arbitrary string usable as one part of a key.
+//
+// Characters rejected by ValidateKey, and the KeySeparator, are replaced by
+// an underscore (_), so a part never spans two segments of a composite key.
+// An empty part becomes a hyphen (-).
+func SanitizeKeyPart(part string) string {
+	if part == "" {
+		return "-"
+	}
+	var sb strings.Builder
+	sb.Grow(len(part))
+	for i := 0; i < len(part); i++ {
+		c := part[i]
+		if c < 128 && allowedCharsArray[c] && c != KeySeparator[0] {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// JoinKey builds a valid key from arbitrary parts, e.g. a client IP and a route.
+//
+// Parts are sanitized with SanitizeKeyPart and joined with KeySeparator. Keys
+// longer than 64 bytes are shortened, keeping a prefix and replacing the rest
+// by a hash of the full key so distinct long keys stay distinct.
+func JoinKey(parts ...string) string {
+	sanitized := make([]string, len(parts))
+	for i, part := range parts {
+		sanitized[i] = SanitizeKeyPart(part)
+	}
+	key := strings.Join(sanitized, KeySeparator)
+	if len(key) <= maxKeyLength {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	prefix := maxKeyLength - keyHashLength - 1
+	return key[:prefix] + "-" + hex.EncodeToString(sum[:])[:keyHashLength]
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeKeyPart(t *testing.T) {
+	tests := []struct {
+		part string
+		want string
+	}{
+		{"10.0.0.1", "10.0.0.1"},
+		{"::1", "__1"},
+		{"/api/users", "_api_users"},
+		{"Bearer abc=", "Bearer_abc_"},
+		{"héllo", "h__llo"},
+		{"", "-"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeKeyPart(tt.part); got != tt.want {
+			t.Errorf("SanitizeKeyPart(%q) = %q, want %q", tt.part, got, tt.want)
+		}
+	}
+}
+
+func TestJoinKey(t *testing.T) {
+	key := JoinKey("2001:db8::1", "GET", "/api/users")
+	if key != "2001_db8__1:GET:_api_users" {
+		t.Errorf("unexpected key %q", key)
+	}
+	if err := ValidateKey(key, "key"); err != nil {
+		t.Errorf("joined key is invalid: %v", err)
+	}
+
+	// Separators inside parts cannot make distinct parts collide
+	if JoinKey("a:b", "c") == JoinKey("a", "b:c") {
+		t.Error("keys with separators in parts collide")
+	}
+}
+
+func TestJoinKey_Long(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	a := JoinKey("10.0.0.1", long+"a")
+	b := JoinKey("10.0.0.1", long+"b")
+
+	for _, key := range []string{a, b} {
+		if len(key) != 64 {
+			t.Errorf("expected 64 byte key, got %d bytes", len(key))
+		}
+		if err := ValidateKey(key, "key"); err != nil {
+			t.Errorf("shortened key is invalid: %v", err)
+		}
+		if !strings.HasPrefix(key, "10.0.0.1:xxx") {
+			t.Errorf("shortened key lost its prefix: %q", key)
+		}
+	}
+	if a == b {
+		t.Error("distinct long keys collide")
+	}
+}
@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"fmt"
+
+	"github.com/ajiwo/ratelimit/backends"
+)
+
+// Validate checks options the way New does, without constructing a limiter,
+// e.g. to lint a configuration in CI or an admin UI.
+//
+// Every option is applied and the resulting configuration is validated,
+// including strategy configs and the capabilities of primary and secondary
+// strategies, so Validate fails whenever New would. No resources are
+// allocated: WithMemoryFailover creates no memory backend and starts no
+// health checks, WithBackend does not close a backend it replaces, and no
+// background workers are started. Backends are not contacted; the Redis and
+// Postgres constructors already check reachability when the backend passed
+// to WithBackend is created. The caller still owns that backend.
+func Validate(opts ...Option) error {
+	config := Config{
+		BaseKey:      "default",
+		validateOnly: true,
+	}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+	config.applyDuplicateRatios(config.logger)
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	// Strategy constructors check the tier combinations; they only keep a
+	// reference to the backend
+	var storages []backends.Backend
+	if config.hasStrategyStorage() {
+		storages = config.strategyStorages(config.Storage, func(b backends.Backend) backends.Backend { return b })
+	}
+	_, err := newStrategy(config.Storage, storages, config)
+	return err
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// minWaitRetry is the delay before retrying a denial reporting no retry time
+const minWaitRetry = 10 * time.Millisecond
+
+// ErrWaitTooLong is returned by Wait when the request cannot be allowed
+// within the WithMaxWait cap
+var ErrWaitTooLong = errors.New("wait exceeds the maximum wait")
+
+// WithMaxWait caps how long Wait blocks, whatever the context deadline.
+//
+// When a denial's retry time would take Wait past maxWait, counted from the
+// start of the call, Wait returns ErrWaitTooLong at once instead of sleeping,
+// so request handlers fail fast rather than block for minutes.
+func WithMaxWait(maxWait time.Duration) Option {
+	return func(config *Config) error {
+		if maxWait <= 0 {
+			return fmt.Errorf("max wait must be positive, got %v", maxWait)
+		}
+		config.maxWait = maxWait
+		return nil
+	}
+}
+
+// Wait blocks until the request is allowed, consuming its quota like Check,
+// and returns the allowing decision.
+//
+// Each denied attempt sleeps for the decision's RetryAfter and tries again.
+// Denials go through the limiter like any other: they notify hooks and count
+// towards WithPenalty lockouts. Wait returns the context error when ctx is
+// done first, and an error wrapping ErrWaitTooLong, along with the denying
+// decision, when the next retry would exceed the WithMaxWait cap.
+// Deny-listed requests are never allowed and return ErrWaitTooLong at once.
+func (r *RateLimiter) Wait(ctx context.Context, options AccessOptions) (*Decision, error) {
+	start := time.Now()
+	for {
+		decision, err := r.Check(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		if decision.Allowed {
+			return decision, nil
+		}
+		if decision.LimitingTier == DenyListResultKey {
+			return decision, fmt.Errorf("%w: request is deny-listed", ErrWaitTooLong)
+		}
+
+		delay := max(decision.RetryAfter, minWaitRetry)
+		if r.maxWait > 0 {
+			if waited := time.Since(start); waited+delay > r.maxWait {
+				return decision, fmt.Errorf("%w: retry after %v exceeds the %v left of %v",
+					ErrWaitTooLong, delay, max(r.maxWait-waited, 0), r.maxWait)
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
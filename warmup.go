@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ajiwo/ratelimit/backends"
+	"github.com/ajiwo/ratelimit/strategies"
+)
+
+// Warmup creates the state of the given dynamic keys at full quota, e.g. for
+// known hot tenants right after a deploy, so their first requests do not pay
+// for creating it.
+//
+// Keys are validated and qualified with the default tenant like
+// AccessOptions.Key. Warmup is idempotent: keys that already have state are
+// left untouched. Fixed windows of warmed up keys start at the time of the
+// warmup rather than at the first request.
+//
+// Returns strategies.ErrRefundNotSupported if a configured strategy cannot
+// derive its full quota state, and an error for strategies with their own
+// backend (WithStrategyBackend).
+func (r *RateLimiter) Warmup(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		dynamicKey, err := r.dynamicKey(ctx, AccessOptions{Key: key})
+		if err != nil {
+			return err
+		}
+		if err := r.warmup(ctx, dynamicKey); err != nil {
+			return fmt.Errorf("failed to warm up key %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// warmup derives the full quota state of a key by consuming and refunding one
+// unit on a scratch backend, then stores it unless the key already has state
+func (r *RateLimiter) warmup(ctx context.Context, dynamicKey string) error {
+	r.mu.RLock()
+	config := r.config
+	r.mu.RUnlock()
+
+	if config.hasStrategyStorage() {
+		return fmt.Errorf("warmup is not supported with per-strategy backends")
+	}
+
+	scratch := &scratchBackend{}
+	strategy, err := newStrategy(scratch, nil, config)
+	if err != nil {
+		return err
+	}
+	refunder, ok := strategy.(strategies.Refunder)
+	if !ok {
+		return strategies.ErrRefundNotSupported
+	}
+
+	// Buckets starting below their burst deny the request, nothing to refund then
+	strategyConfig := r.buildStrategyConfig(dynamicKey)
+	results, err := strategy.Allow(ctx, strategyConfig)
+	if err != nil {
+		return err
+	}
+	if results.AllAllowed() {
+		if err := refunder.Refund(ctx, strategyConfig, 1); err != nil {
+			return err
+		}
+	}
+	if len(scratch.keys) == 0 {
+		return nil
+	}
+
+	// An empty old value only creates missing keys
+	storage := backends.WithCodec(r.storage(), r.stateCodec)
+	for _, key := range scratch.keys {
+		state := scratch.states[key]
+		if state.value == "" {
+			continue
+		}
+		if _, err := storage.CheckAndSet(ctx, key, "", state.value, state.expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scratchBackend holds the last states written by a strategy, without persisting them
+type scratchBackend struct {
+	keys   []string // In the order first written
+	states map[string]scratchState
+}
+
+// scratchState is a state written to a scratchBackend
+type scratchState struct {
+	value      string
+	expiration time.Duration
+}
+
+func (b *scratchBackend) Get(_ context.Context, key string) (string, error) {
+	return b.states[key].value, nil
+}
+
+func (b *scratchBackend) Set(_ context.Context, key, value string, expiration time.Duration) error {
+	if b.states == nil {
+		b.states = make(map[string]scratchState)
+	}
+	if _, ok := b.states[key]; !ok {
+		b.keys = append(b.keys, key)
+	}
+	b.states[key] = scratchState{value: value, expiration: expiration}
+	return nil
+}
+
+func (b *scratchBackend) CheckAndSet(ctx context.Context, key, oldValue, newValue string, expiration time.Duration) (bool, error) {
+	if current, _ := b.Get(ctx, key); current != oldValue {
+		return false, nil
+	}
+	return true, b.Set(ctx, key, newValue, expiration)
+}
+
+func (b *scratchBackend) Delete(_ context.Context, key string) error {
+	if state, ok := b.states[key]; ok {
+		state.value = ""
+		b.states[key] = state
+	}
+	return nil
+}
+
+func (b *scratchBackend) Close() error {
+	return nil
+}